@@ -20,6 +20,7 @@ import (
 // - Blank lines are discarded.
 // - Comments are discarded unless they contain a C directive, i.e #include, #if or #endif.
 // - Typedefs following this pattern "typedef void* GO_%name%_PTR" are translated into "#define %name% GO_%name%_PTR".
+//   Typedefs named GO_STACK_OF_%name%_PTR are translated into "#define GO_STACK_OF_%name%_PTR STACK_OF(%name%)*".
 // - Enums are validated against their definition in the OpenSSL headers. Example:
 //   "enum { GO_EVP_CTRL_GCM_SET_TAG = 0x11 }" => "_Static_assert(EVP_CTRL_GCM_SET_TAG == 0x11);"
 // - Function macros are validated against their definition in the OpenSSL headers. Example:
@@ -162,7 +163,13 @@ func tryConvertTypedef(w io.Writer, l string) bool {
 		return false
 	}
 	name := l[i1+len("GO_") : i2]
-	fmt.Fprintf(w, "#define GO_%s_PTR %s*\n", name, name)
+	typ := name
+	if elem := strings.TrimPrefix(name, "STACK_OF_"); elem != name {
+		// Stacks of a given type, such as STACK_OF(X509), are distinct
+		// from OPENSSL_STACK and need the STACK_OF macro.
+		typ = "STACK_OF(" + elem + ")"
+	}
+	fmt.Fprintf(w, "#define GO_%s_PTR %s*\n", name, typ)
 	return true
 }
 
@@ -225,10 +232,17 @@ func tryConvertDefineFunc(w io.Writer, l string, i int) bool {
 		return false
 	}
 	i1 := strings.IndexByte(l, '(')
-	// The first ")," match is always the end of the argument list parameter.
+	// The argument list parameter is the first parenthesized parameter.
+	// It ends at its matching closing parenthesis, as it may contain
+	// function pointers with their own argument lists.
 	// We are not interested in the last parameter and parsing them would complicate the algorithm.
-	// Matching against ')' is not enough as it also appears when the argument list parameter contains function pointers.
-	i2 := strings.Index(l, "),")
+	i2 := -1
+	if i1 >= 0 {
+		i2 = argListEnd(l[i1+1:])
+		if i2 >= 0 {
+			i2 += i1 + 1
+		}
+	}
 	if i1 < 0 || i2 < 0 {
 		log.Println("unexpected argument list in function line: " + l)
 		return false
@@ -287,6 +301,8 @@ func tryConvertDefineFunc(w io.Writer, l string, i int) bool {
 		writeDefineFunc("OPENSSL_VERSION_NUMBER >= 0x10100000L")
 	case "DEFINEFUNC_3_0":
 		writeDefineFunc("OPENSSL_VERSION_NUMBER >= 0x30000000L")
+	case "DEFINEFUNC_3_4":
+		writeDefineFunc("OPENSSL_VERSION_NUMBER >= 0x30400000L")
 	case "DEFINEFUNC_RENAMED_1_1":
 		writeDefineFuncRename("OPENSSL_VERSION_NUMBER < 0x10100000L")
 	case "DEFINEFUNC_RENAMED_3_0":
@@ -299,3 +315,24 @@ func tryConvertDefineFunc(w io.Writer, l string, i int) bool {
 	}
 	return true
 }
+
+// argListEnd returns the index of the parenthesis closing
+// the first parenthesized group in s, or -1 if there is none.
+func argListEnd(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+			if depth < 0 {
+				return -1
+			}
+		}
+	}
+	return -1
+}
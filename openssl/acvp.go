@@ -0,0 +1,191 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+
+	"github.com/microsoft/go-crypto-openssl/openssl/internal/acvp"
+)
+
+func init() {
+	acvp.Register(acvp.Hooks{
+		ECDSAKeyGen: acvpECDSAKeyGen,
+		ECDSASign:   acvpECDSASign,
+		ECDSAVerify: acvpECDSAVerify,
+		GCMSeal:     acvpGCMSeal,
+	})
+}
+
+// acvpCurveSize returns the size in bytes of the order of curve.
+func acvpCurveSize(curve string) (int, error) {
+	switch curve {
+	case "P-224":
+		return 28, nil
+	case "P-256":
+		return 32, nil
+	case "P-384":
+		return 48, nil
+	case "P-521":
+		return 66, nil
+	}
+	return 0, errUnknownCurve
+}
+
+// acvpBig converts the big-endian integer x to a BigInt.
+func acvpBig(x []byte) (BigInt, error) {
+	bn := bytesToBN(x)
+	if bn == nil {
+		return nil, newOpenSSLError("BN_bin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	return bnToBig(bn), nil
+}
+
+// acvpBytes converts x to a big-endian integer of size bytes.
+func acvpBytes(x BigInt, size int) ([]byte, error) {
+	bn := bigToBN(x)
+	if bn == nil {
+		return nil, newOpenSSLError("BN_lebin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	b := make([]byte, size)
+	if C.go_openssl_BN_bn2binpad(bn, base(b), C.int(size)) != C.int(size) {
+		return nil, newOpenSSLError("BN_bn2binpad")
+	}
+	return b, nil
+}
+
+func acvpECDSAKeyGen(curve string) (qx, qy, d []byte, err error) {
+	size, err := acvpCurveSize(curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	X, Y, D, err := GenerateKeyECDSA(curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if qx, err = acvpBytes(X, size); err != nil {
+		return nil, nil, nil, err
+	}
+	if qy, err = acvpBytes(Y, size); err != nil {
+		return nil, nil, nil, err
+	}
+	if d, err = acvpBytes(D, size); err != nil {
+		return nil, nil, nil, err
+	}
+	return qx, qy, d, nil
+}
+
+// acvpECKey returns the EC_KEY (qx, qy, d) on curve, d can be nil.
+func acvpECKey(curve string, qx, qy, d []byte) (C.GO_EC_KEY_PTR, error) {
+	X, err := acvpBig(qx)
+	if err != nil {
+		return nil, err
+	}
+	Y, err := acvpBig(qy)
+	if err != nil {
+		return nil, err
+	}
+	var D BigInt
+	if d != nil {
+		if D, err = acvpBig(d); err != nil {
+			return nil, err
+		}
+	}
+	return newRawECKey(curve, X, Y, D)
+}
+
+func acvpECDSASign(curve string, qx, qy, d, k, digest []byte) (r, s []byte, err error) {
+	size, err := acvpCurveSize(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer endOp(beginOp("ECDSA_do_sign_ex", MetricSign), &err)
+	key, err := acvpECKey(curve, qx, qy, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.go_openssl_EC_KEY_free(key)
+	var sig C.GO_ECDSA_SIG_PTR
+	if k == nil {
+		sig = C.go_openssl_ECDSA_do_sign(base(digest), C.int(len(digest)), key)
+	} else {
+		sig = C.go_openssl_ECDSA_sign_with_nonce(key, base(digest), C.int(len(digest)), base(k), C.int(len(k)))
+	}
+	if sig == nil {
+		return nil, nil, newOpenSSLError("ECDSA_do_sign_ex")
+	}
+	defer C.go_openssl_ECDSA_SIG_free(sig)
+	return ecdsaSigBytes(sig, size)
+}
+
+func acvpECDSAVerify(curve string, qx, qy, digest, r, s []byte) bool {
+	var err error
+	defer endOp(beginOp("ECDSA_do_verify", MetricVerify), &err)
+	key, err := acvpECKey(curve, qx, qy, nil)
+	if err != nil {
+		C.go_openssl_ERR_clear_error()
+		return false
+	}
+	defer C.go_openssl_EC_KEY_free(key)
+	sig := newECDSASig(r, s)
+	if sig == nil {
+		C.go_openssl_ERR_clear_error()
+		err = ErrVerification
+		return false
+	}
+	defer C.go_openssl_ECDSA_SIG_free(sig)
+	if C.go_openssl_ECDSA_do_verify(base(digest), C.int(len(digest)), sig, key) != 1 {
+		C.go_openssl_ERR_clear_error()
+		err = ErrVerification
+		return false
+	}
+	return true
+}
+
+const acvpGCMIVSize = 12
+
+func acvpGCMSeal(key, ivInit, plaintext, aad []byte, tagSize int) (iv, ciphertext, tag []byte, err error) {
+	var cipher C.GO_EVP_CIPHER_PTR
+	switch len(key) * 8 {
+	case 128:
+		cipher = C.go_openssl_EVP_aes_128_gcm()
+	case 192:
+		cipher = C.go_openssl_EVP_aes_192_gcm()
+	case 256:
+		cipher = C.go_openssl_EVP_aes_256_gcm()
+	default:
+		return nil, nil, nil, errors.New("crypto/cipher: Invalid key size")
+	}
+	if len(ivInit) != 4 && len(ivInit) != acvpGCMIVSize {
+		return nil, nil, nil, errors.New("acvp: invalid GCM IV fixed field size")
+	}
+	if tagSize < 4 || tagSize > gcmTagSize {
+		return nil, nil, nil, errors.New("cipher: incorrect tag size given to GCM")
+	}
+	defer endOp(beginOp("EVP_EncryptUpdate", MetricSeal), &err)
+	ctx := C.go_openssl_EVP_CIPHER_CTX_new()
+	if ctx == nil {
+		return nil, nil, nil, newOpenSSLError("EVP_CIPHER_CTX_new")
+	}
+	defer C.go_openssl_EVP_CIPHER_CTX_free(ctx)
+	// EVP_CIPHER_CTX_ctrl takes a mutable pointer, pass it a copy.
+	fixed := append([]byte(nil), ivInit...)
+	iv = make([]byte, acvpGCMIVSize)
+	// out holds the ciphertext followed by the tag, so it is never empty.
+	out := make([]byte, len(plaintext)+tagSize)
+	if C.go_openssl_gcm_seal_internal_iv(ctx, cipher, base(key),
+		base(fixed), C.int(len(fixed)), base(iv), C.int(len(iv)),
+		base(plaintext), C.int(len(plaintext)), base(aad), C.int(len(aad)),
+		base(out), base(out[len(plaintext):]), C.int(tagSize)) != 1 {
+		return nil, nil, nil, newOpenSSLError("EVP_CTRL_GCM_IV_GEN")
+	}
+	return iv, out[:len(plaintext)], out[len(plaintext):], nil
+}
@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -27,10 +27,18 @@ func (k aesKeySizeError) Error() string {
 const aesBlockSize = 16
 
 type aesCipher struct {
+	// lib is the library context the ciphers are fetched from,
+	// or nil to use the built-in ciphers of the default context.
+	// In the former case c owns a reference to cipher.
+	lib     *LibraryContext
 	key     []byte
 	enc_ctx C.GO_EVP_CIPHER_CTX_PTR
 	dec_ctx C.GO_EVP_CIPHER_CTX_PTR
-	cipher  C.GO_EVP_CIPHER_PTR
+	// enc_pooled and dec_pooled own enc_ctx and dec_ctx.
+	enc_pooled *pooledCtx
+	dec_pooled *pooledCtx
+	cipher     C.GO_EVP_CIPHER_PTR
+	closer
 }
 
 type extraModes interface {
@@ -61,23 +69,52 @@ func NewAESCipher(key []byte) (cipher.Block, error) {
 		return nil, errors.New("crypto/cipher: Invalid key size")
 	}
 
-	runtime.SetFinalizer(c, (*aesCipher).finalize)
+	manage(c, (*aesCipher).finalize)
 
 	return c, nil
 }
 
 func (c *aesCipher) finalize() {
-	if c.enc_ctx != nil {
-		C.go_openssl_EVP_CIPHER_CTX_free(c.enc_ctx)
+	c.enc_pooled.put()
+	c.dec_pooled.put()
+	c.enc_ctx, c.dec_ctx, c.enc_pooled, c.dec_pooled = nil, nil, nil, nil
+	if c.lib != nil {
+		C.go_openssl_EVP_CIPHER_free(c.cipher)
 	}
-	if c.dec_ctx != nil {
-		C.go_openssl_EVP_CIPHER_CTX_free(c.dec_ctx)
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *aesCipher) Close() error {
+	return closeObject(c, c.finalize)
+}
+
+// cipherName returns the OpenSSL name of the AES cipher
+// in the given mode for the key size of c.
+func (c *aesCipher) cipherName(mode string) string {
+	return "AES-" + strconv.Itoa(len(c.key)*8) + "-" + mode
+}
+
+// newModeCtx is like newCipherCtx with the key of c, except that
+// if c was created from a library context, the cipher in the given mode
+// is fetched from it instead of using builtin.
+func (c *aesCipher) newModeCtx(builtin C.GO_EVP_CIPHER_PTR, mode string, enc C.int, iv []byte) (C.GO_EVP_CIPHER_CTX_PTR, *pooledCtx, error) {
+	if c.lib == nil {
+		return newCipherCtx(builtin, enc, c.key, iv)
+	}
+	cipher, err := c.lib.fetchCipher(c.cipherName(mode))
+	if err != nil {
+		return nil, nil, err
 	}
+	// The cipher context holds its own reference to cipher.
+	defer C.go_openssl_EVP_CIPHER_free(cipher)
+	return newCipherCtx(cipher, enc, c.key, iv)
 }
 
 func (c *aesCipher) BlockSize() int { return aesBlockSize }
 
 func (c *aesCipher) Encrypt(dst, src []byte) {
+	c.checkOpen()
 	if subtle.InexactOverlap(dst, src) {
 		panic("crypto/cipher: invalid buffer overlap")
 	}
@@ -90,7 +127,7 @@ func (c *aesCipher) Encrypt(dst, src []byte) {
 
 	if c.enc_ctx == nil {
 		var err error
-		c.enc_ctx, err = newCipherCtx(c.cipher, C.GO_AES_ENCRYPT, c.key, nil)
+		c.enc_ctx, c.enc_pooled, err = newCipherCtx(c.cipher, C.GO_AES_ENCRYPT, c.key, nil)
 		if err != nil {
 			panic(err)
 		}
@@ -101,6 +138,7 @@ func (c *aesCipher) Encrypt(dst, src []byte) {
 }
 
 func (c *aesCipher) Decrypt(dst, src []byte) {
+	c.checkOpen()
 	if subtle.InexactOverlap(dst, src) {
 		panic("crypto/cipher: invalid buffer overlap")
 	}
@@ -112,7 +150,7 @@ func (c *aesCipher) Decrypt(dst, src []byte) {
 	}
 	if c.dec_ctx == nil {
 		var err error
-		c.dec_ctx, err = newCipherCtx(c.cipher, C.GO_AES_DECRYPT, c.key, nil)
+		c.dec_ctx, c.dec_pooled, err = newCipherCtx(c.cipher, C.GO_AES_DECRYPT, c.key, nil)
 		if err != nil {
 			panic(err)
 		}
@@ -127,12 +165,16 @@ func (c *aesCipher) Decrypt(dst, src []byte) {
 }
 
 type aesCBC struct {
-	ctx C.GO_EVP_CIPHER_CTX_PTR
+	lib    *LibraryContext // keeps the library context of ctx alive
+	ctx    C.GO_EVP_CIPHER_CTX_PTR
+	pooled *pooledCtx // owns ctx
+	closer
 }
 
 func (x *aesCBC) BlockSize() int { return aesBlockSize }
 
 func (x *aesCBC) CryptBlocks(dst, src []byte) {
+	x.checkOpen()
 	if subtle.InexactOverlap(dst, src) {
 		panic("crypto/cipher: invalid buffer overlap")
 	}
@@ -151,6 +193,7 @@ func (x *aesCBC) CryptBlocks(dst, src []byte) {
 }
 
 func (x *aesCBC) SetIV(iv []byte) {
+	x.checkOpen()
 	if len(iv) != aesBlockSize {
 		panic("cipher: incorrect length IV")
 	}
@@ -160,7 +203,7 @@ func (x *aesCBC) SetIV(iv []byte) {
 }
 
 func (c *aesCipher) NewCBCEncrypter(iv []byte) cipher.BlockMode {
-	x := new(aesCBC)
+	x := &aesCBC{lib: c.lib}
 
 	var cipher C.GO_EVP_CIPHER_PTR
 	switch len(c.key) * 8 {
@@ -174,22 +217,29 @@ func (c *aesCipher) NewCBCEncrypter(iv []byte) cipher.BlockMode {
 		panic("openssl: unsupported key length")
 	}
 	var err error
-	x.ctx, err = newCipherCtx(cipher, C.GO_AES_ENCRYPT, c.key, iv)
+	x.ctx, x.pooled, err = c.newModeCtx(cipher, "CBC", C.GO_AES_ENCRYPT, iv)
 	if err != nil {
 		panic(err)
 	}
 
-	runtime.SetFinalizer(x, (*aesCBC).finalize)
+	manage(x, (*aesCBC).finalize)
 
 	return x
 }
 
 func (c *aesCBC) finalize() {
-	C.go_openssl_EVP_CIPHER_CTX_free(c.ctx)
+	c.pooled.put()
+	c.ctx, c.pooled = nil, nil
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *aesCBC) Close() error {
+	return closeObject(c, c.finalize)
 }
 
 func (c *aesCipher) NewCBCDecrypter(iv []byte) cipher.BlockMode {
-	x := new(aesCBC)
+	x := &aesCBC{lib: c.lib}
 
 	var cipher C.GO_EVP_CIPHER_PTR
 	switch len(c.key) * 8 {
@@ -204,7 +254,7 @@ func (c *aesCipher) NewCBCDecrypter(iv []byte) cipher.BlockMode {
 	}
 
 	var err error
-	x.ctx, err = newCipherCtx(cipher, C.GO_AES_DECRYPT, c.key, iv)
+	x.ctx, x.pooled, err = c.newModeCtx(cipher, "CBC", C.GO_AES_DECRYPT, iv)
 	if err != nil {
 		panic(err)
 	}
@@ -212,15 +262,19 @@ func (c *aesCipher) NewCBCDecrypter(iv []byte) cipher.BlockMode {
 		panic("cipher: unable to set padding")
 	}
 
-	runtime.SetFinalizer(x, (*aesCBC).finalize)
+	manage(x, (*aesCBC).finalize)
 	return x
 }
 
 type aesCTR struct {
-	ctx C.GO_EVP_CIPHER_CTX_PTR
+	lib    *LibraryContext // keeps the library context of ctx alive
+	ctx    C.GO_EVP_CIPHER_CTX_PTR
+	pooled *pooledCtx // owns ctx
+	closer
 }
 
 func (x *aesCTR) XORKeyStream(dst, src []byte) {
+	x.checkOpen()
 	if subtle.InexactOverlap(dst, src) {
 		panic("crypto/cipher: invalid buffer overlap")
 	}
@@ -235,7 +289,7 @@ func (x *aesCTR) XORKeyStream(dst, src []byte) {
 }
 
 func (c *aesCipher) NewCTR(iv []byte) cipher.Stream {
-	x := new(aesCTR)
+	x := &aesCTR{lib: c.lib}
 
 	var cipher C.GO_EVP_CIPHER_PTR
 	switch len(c.key) * 8 {
@@ -249,18 +303,25 @@ func (c *aesCipher) NewCTR(iv []byte) cipher.Stream {
 		panic("openssl: unsupported key length")
 	}
 	var err error
-	x.ctx, err = newCipherCtx(cipher, C.GO_AES_ENCRYPT, c.key, iv)
+	x.ctx, x.pooled, err = c.newModeCtx(cipher, "CTR", C.GO_AES_ENCRYPT, iv)
 	if err != nil {
 		panic(err)
 	}
 
-	runtime.SetFinalizer(x, (*aesCTR).finalize)
+	manage(x, (*aesCTR).finalize)
 
 	return x
 }
 
 func (c *aesCTR) finalize() {
-	C.go_openssl_EVP_CIPHER_CTX_free(c.ctx)
+	c.pooled.put()
+	c.ctx, c.pooled = nil, nil
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *aesCTR) Close() error {
+	return closeObject(c, c.finalize)
 }
 
 type cipherGCMTLS uint8
@@ -272,8 +333,10 @@ const (
 )
 
 type aesGCM struct {
-	ctx C.GO_EVP_CIPHER_CTX_PTR
-	tls cipherGCMTLS
+	lib    *LibraryContext // keeps the library context of ctx alive
+	ctx    C.GO_EVP_CIPHER_CTX_PTR
+	pooled *pooledCtx // owns ctx
+	tls    cipherGCMTLS
 	// minNextNonce is the minimum value that the next nonce can be, enforced by
 	// all TLS modes.
 	minNextNonce uint64
@@ -282,6 +345,7 @@ type aesGCM struct {
 	// maskInitialized is true if mask has been initialized. This happens during
 	// the first Seal. The initialized mask may be 0. Used by TLS 1.3 mode.
 	maskInitialized bool
+	closer
 }
 
 const (
@@ -354,17 +418,24 @@ func (c *aesCipher) newGCM(tls cipherGCMTLS) (cipher.AEAD, error) {
 	default:
 		panic("openssl: unsupported key length")
 	}
-	ctx, err := newCipherCtx(cipher, -1, c.key, nil)
+	ctx, pooled, err := c.newModeCtx(cipher, "GCM", -1, nil)
 	if err != nil {
 		return nil, err
 	}
-	g := &aesGCM{ctx: ctx, tls: tls}
-	runtime.SetFinalizer(g, (*aesGCM).finalize)
+	g := &aesGCM{lib: c.lib, ctx: ctx, pooled: pooled, tls: tls}
+	manage(g, (*aesGCM).finalize)
 	return g, nil
 }
 
 func (g *aesGCM) finalize() {
-	C.go_openssl_EVP_CIPHER_CTX_free(g.ctx)
+	g.pooled.put()
+	g.ctx, g.pooled = nil, nil
+}
+
+// Close frees the native resources of g, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (g *aesGCM) Close() error {
+	return closeObject(g, g.finalize)
 }
 
 func (g *aesGCM) NonceSize() int {
@@ -384,7 +455,20 @@ func base(b []byte) *C.uchar {
 	return (*C.uchar)(unsafe.Pointer(&b[0]))
 }
 
+// Seal and Open follow the crypto/cipher.AEAD contract: the result is
+// appended to dst, in place if plaintext or ciphertext exactly overlap
+// the end of dst and dst has enough capacity. OpenSSL reads and writes
+// the buffers of the caller directly, so sealing into a reused dst
+// doesn't allocate or copy.
 func (g *aesGCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	start := metricsStart()
+	ret := g.seal(dst, nonce, plaintext, additionalData)
+	observe(MetricSeal, start, len(plaintext), nil)
+	return ret
+}
+
+func (g *aesGCM) seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	g.checkOpen()
 	if len(nonce) != gcmStandardNonceSize {
 		panic("cipher: incorrect nonce length given to GCM")
 	}
@@ -468,6 +552,14 @@ func (g *aesGCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
 var errOpen = errors.New("cipher: message authentication failed")
 
 func (g *aesGCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	start := metricsStart()
+	ret, err := g.open(dst, nonce, ciphertext, additionalData)
+	observe(MetricOpen, start, len(ciphertext), err)
+	return ret, err
+}
+
+func (g *aesGCM) open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	g.checkOpen()
 	if len(nonce) != gcmStandardNonceSize {
 		panic("cipher: incorrect nonce length given to GCM")
 	}
@@ -515,16 +607,18 @@ func sliceForAppend(in []byte, n int) (head, tail []byte) {
 	return
 }
 
-func newCipherCtx(cipher C.GO_EVP_CIPHER_PTR, mode C.int, key, iv []byte) (C.GO_EVP_CIPHER_CTX_PTR, error) {
-	ctx := C.go_openssl_EVP_CIPHER_CTX_new()
+// newCipherCtx returns a cipher context from cipherCtxPool initialized
+// with cipher, key and iv, and the pooledCtx owning it.
+func newCipherCtx(cipher C.GO_EVP_CIPHER_PTR, mode C.int, key, iv []byte) (C.GO_EVP_CIPHER_CTX_PTR, *pooledCtx, error) {
+	pooled, ctx := getCipherCtx()
 	if ctx == nil {
-		return nil, fail("unable to create EVP cipher ctx")
+		return nil, nil, fail("unable to create EVP cipher ctx")
 	}
 	if C.go_openssl_EVP_CipherInit_ex(ctx, cipher, nil, base(key), base(iv), mode) != 1 {
-		C.go_openssl_EVP_CIPHER_CTX_free(ctx)
-		return nil, fail("unable to initialize EVP cipher ctx")
+		pooled.put()
+		return nil, nil, fail("unable to initialize EVP cipher ctx")
 	}
-	return ctx, nil
+	return ctx, pooled, nil
 }
 
 func bigUint64(b []byte) uint64 {
@@ -0,0 +1,37 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package aes mirrors the API of crypto/aes with the AES implementation
+// of OpenSSL, so that applications can move to it by changing their
+// import path. The blocks returned by NewCipher implement the GCM, CTR
+// and CBC modes with OpenSSL too, which the functions of crypto/cipher
+// and of this module's cipher package use.
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// BlockSize is the AES block size in bytes.
+const BlockSize = aes.BlockSize
+
+// KeySizeError is crypto/aes.KeySizeError.
+type KeySizeError = aes.KeySizeError
+
+// NewCipher creates and returns a new cipher.Block. The key argument
+// should be the AES key, either 16, 24, or 32 bytes to select AES-128,
+// AES-192, or AES-256.
+func NewCipher(key []byte) (cipher.Block, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, KeySizeError(len(key))
+	}
+	return openssl.NewAESCipher(key)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestNewCipher(t *testing.T) {
+	// FIPS 197, Appendix C.1.
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	pt, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	want, _ := hex.DecodeString("69c4e0d86a7b0430d8cdb78070b4c55a")
+	b, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, BlockSize)
+	b.Encrypt(got, pt)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Encrypt() = %x, want %x", got, want)
+	}
+	b.Decrypt(got, got)
+	if !bytes.Equal(got, pt) {
+		t.Errorf("Decrypt() = %x, want %x", got, pt)
+	}
+}
+
+func TestKeySizeError(t *testing.T) {
+	_, err := NewCipher(make([]byte, 20))
+	if _, ok := err.(KeySizeError); !ok {
+		t.Errorf("NewCipher() error = %v, want a KeySizeError", err)
+	}
+}
@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -191,6 +191,87 @@ func TestSealAndOpenAuthenticationError(t *testing.T) {
 	}
 }
 
+func TestSealAndOpenDst(t *testing.T) {
+	ci, err := NewAESCipher([]byte("D249BF6DEC97B1EBD69BC4D6B3A3C49D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := ci.(*aesCipher).NewGCM(gcmStandardNonceSize, gcmTagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcmStandardNonceSize)
+	plainText := []byte("a message spanning more than one block")
+	sealed := gcm.Seal(nil, nonce, plainText, nil)
+
+	// Results are appended to dst.
+	prefix := []byte("prefix")
+	got := gcm.Seal(append([]byte(nil), prefix...), nonce, plainText, nil)
+	if !bytes.Equal(got, append(append([]byte(nil), prefix...), sealed...)) {
+		t.Errorf("Seal didn't append to dst: %x", got)
+	}
+	got, err = gcm.Open(append([]byte(nil), prefix...), nonce, sealed, nil)
+	if err != nil || !bytes.Equal(got, append(append([]byte(nil), prefix...), plainText...)) {
+		t.Errorf("Open didn't append to dst: %x, %v", got, err)
+	}
+
+	// Sealing and opening in place reuse the buffer.
+	buf := make([]byte, len(plainText), len(plainText)+gcmTagSize)
+	copy(buf, plainText)
+	got = gcm.Seal(buf[:0], nonce, buf, nil)
+	if !bytes.Equal(got, sealed) || &got[0] != &buf[0] {
+		t.Error("Seal in place failed")
+	}
+	got, err = gcm.Open(got[:0], nonce, got, nil)
+	if err != nil || !bytes.Equal(got, plainText) || &got[0] != &buf[0] {
+		t.Errorf("Open in place failed: %v", err)
+	}
+
+	// Partially overlapping buffers are rejected.
+	buf = make([]byte, len(sealed)+1)
+	assertPanic(t, func() {
+		gcm.Seal(buf[:1], nonce, buf[:len(plainText)], nil)
+	})
+	copy(buf, sealed)
+	assertPanic(t, func() {
+		gcm.Open(buf[:1], nonce, buf[:len(sealed)], nil)
+	})
+}
+
+func TestAESAllocs(t *testing.T) {
+	ci, err := NewAESCipher([]byte("D249BF6DEC97B1EBD69BC4D6B3A3C49D"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := ci.(*aesCipher).NewGCM(gcmStandardNonceSize, gcmTagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcmStandardNonceSize)
+	iv := make([]byte, aesBlockSize)
+	in := make([]byte, 1024)
+	out := make([]byte, len(in)+gcmTagSize)
+	ad := make([]byte, 13)
+	sealed := gcm.Seal(nil, nonce, in, ad)
+	cbc := ci.(*aesCipher).NewCBCEncrypter(iv)
+	ctr := ci.(*aesCipher).NewCTR(iv)
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"Encrypt", func() { ci.Encrypt(out, in) }},
+		{"Seal", func() { gcm.Seal(out[:0], nonce, in, ad) }},
+		{"Open", func() { gcm.Open(out[:0], nonce, sealed, ad) }},
+		{"CryptBlocks", func() { cbc.CryptBlocks(out, in) }},
+		{"XORKeyStream", func() { ctr.XORKeyStream(out, in) }},
+	}
+	for _, tt := range tests {
+		if n := testing.AllocsPerRun(10, tt.f); n > 0 {
+			t.Errorf("%s allocated %v times", tt.name, n)
+		}
+	}
+}
+
 func assertPanic(t *testing.T, f func()) {
 	t.Helper()
 	defer func() {
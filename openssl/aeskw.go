@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "errors"
+
+// WrapKeyAES wraps key under the key encryption key kek with the AES
+// Key Wrap algorithm, as defined in RFC 3394 and NIST SP 800-38F (KW),
+// using the default initial value. kek must be 16, 24 or 32 bytes long,
+// and key a multiple of 8 bytes, at least 16. The result is 8 bytes
+// longer than key.
+func WrapKeyAES(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, errors.New("openssl: invalid AES key wrap input length")
+	}
+	return aesKeyWrap(kek, key, 1)
+}
+
+// UnwrapKeyAES unwraps wrapped, as returned by WrapKeyAES,
+// with the key encryption key kek. It returns an error if wrapped
+// wasn't wrapped under kek or was modified.
+func UnwrapKeyAES(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("openssl: invalid AES key wrap input length")
+	}
+	return aesKeyWrap(kek, wrapped, 0)
+}
+
+func aesKeyWrap(kek, in []byte, enc C.int) (_ []byte, err error) {
+	op := AuditWrap
+	if enc == 0 {
+		op = AuditUnwrap
+	}
+	defer auditAES(op, kek, &err)
+	var cipher C.GO_EVP_CIPHER_PTR
+	switch len(kek) {
+	case 16:
+		cipher = C.go_openssl_EVP_aes_128_wrap()
+	case 24:
+		cipher = C.go_openssl_EVP_aes_192_wrap()
+	case 32:
+		cipher = C.go_openssl_EVP_aes_256_wrap()
+	default:
+		return nil, aesKeySizeError(len(kek))
+	}
+	pooled, ctx := getCipherCtx()
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_CIPHER_CTX_new")
+	}
+	defer pooled.put()
+	// OpenSSL 1.x refuses the wrap ciphers without this flag,
+	// since they don't follow the EVP streaming semantics.
+	C.go_openssl_EVP_CIPHER_CTX_set_flags(ctx, C.GO_EVP_CIPHER_CTX_FLAG_WRAP_ALLOW)
+	if C.go_openssl_EVP_CipherInit_ex(ctx, cipher, nil, base(kek), nil, enc) != 1 {
+		return nil, newOpenSSLError("EVP_CipherInit_ex")
+	}
+	outLen := len(in) - 8
+	if enc == 1 {
+		outLen = len(in) + 8
+	}
+	out := make([]byte, outLen)
+	var n C.int
+	if C.go_openssl_EVP_CipherUpdate(ctx, base(out), &n, base(in), C.int(len(in))) != 1 || int(n) != outLen {
+		if enc == 0 {
+			C.go_openssl_ERR_clear_error()
+			return nil, errors.New("openssl: AES key unwrap failed")
+		}
+		return nil, newOpenSSLError("EVP_CipherUpdate")
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAESKeyWrap(t *testing.T) {
+	// Test vectors from RFC 3394, Section 4.
+	tests := []struct {
+		name, kek, key, wrapped string
+	}{
+		{
+			"128-128",
+			"000102030405060708090A0B0C0D0E0F",
+			"00112233445566778899AABBCCDDEEFF",
+			"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		{
+			"192-128",
+			"000102030405060708090A0B0C0D0E0F1011121314151617",
+			"00112233445566778899AABBCCDDEEFF",
+			"96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D",
+		},
+		{
+			"256-256",
+			"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kek := decodeHex(t, tt.kek)
+			key := decodeHex(t, tt.key)
+			want := decodeHex(t, tt.wrapped)
+			wrapped, err := WrapKeyAES(kek, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(wrapped, want) {
+				t.Errorf("got %X, want %X", wrapped, want)
+			}
+			got, err := UnwrapKeyAES(kek, wrapped)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("unwrapped %X, want %X", got, key)
+			}
+			wrapped[0] ^= 1
+			if _, err := UnwrapKeyAES(kek, wrapped); err == nil {
+				t.Error("unwrapped a modified key")
+			}
+		})
+	}
+}
+
+func TestAESKeyWrapErrors(t *testing.T) {
+	kek := make([]byte, 16)
+	if _, err := WrapKeyAES(make([]byte, 15), make([]byte, 16)); err == nil {
+		t.Error("expected error for invalid KEK size")
+	}
+	for _, n := range []int{0, 8, 17} {
+		if _, err := WrapKeyAES(kek, make([]byte, n)); err == nil {
+			t.Errorf("expected error for %d bytes key", n)
+		}
+	}
+	if _, err := UnwrapKeyAES(kek, make([]byte, 16)); err == nil {
+		t.Error("expected error for short wrapped key")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// live is the number of libcrypto allocations not freed yet.
+static int64_t live;
+
+void *
+go_openssl_track_malloc(size_t num, const char *file, int line)
+{
+    void *p = malloc(num);
+    if (p != NULL)
+        __atomic_add_fetch(&live, 1, __ATOMIC_RELAXED);
+    return p;
+}
+
+void
+go_openssl_track_free(void *addr, const char *file, int line)
+{
+    if (addr != NULL)
+        __atomic_sub_fetch(&live, 1, __ATOMIC_RELAXED);
+    free(addr);
+}
+
+void *
+go_openssl_track_realloc(void *addr, size_t num, const char *file, int line)
+{
+    if (addr == NULL)
+        return go_openssl_track_malloc(num, file, line);
+    if (num == 0)
+    {
+        go_openssl_track_free(addr, file, line);
+        return NULL;
+    }
+    return realloc(addr, num);
+}
+
+int64_t go_openssl_live_allocations(void)
+{
+    return __atomic_load_n(&live, __ATOMIC_RELAXED);
+}
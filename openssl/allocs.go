@@ -0,0 +1,48 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+var (
+	// trackLoadedLibraries is set by initLibrary, under initOnce, when
+	// loadLibrary must track the allocations of the libraries it loads
+	// before probing them, see InitOptions.TrackAllocations.
+	trackLoadedLibraries bool
+	// trackedHandle is the last library whose allocations are tracked.
+	trackedHandle unsafe.Pointer
+)
+
+// trackAllocations installs the counting allocation
+// functions in the library at handle.
+func trackAllocations(handle unsafe.Pointer) error {
+	major, minor := C.go_openssl_version_major(handle), C.go_openssl_version_minor(handle)
+	if major < 1 || (major == 1 && minor == 0) {
+		return errors.New("openssl: TrackAllocations requires OpenSSL 1.1 or later")
+	}
+	if C.go_openssl_track_allocations(handle) != 1 {
+		return errors.New("openssl: TrackAllocations failed, libcrypto already allocated memory")
+	}
+	trackedHandle = handle
+	return nil
+}
+
+// LiveAllocations returns the number of memory allocations of
+// libcrypto not freed yet, which includes the EVP contexts and keys
+// held by the objects of this package until their finalizers run.
+// It requires InitOptions.TrackAllocations.
+func LiveAllocations() (int64, error) {
+	if libHandle == nil || libHandle != trackedHandle {
+		return 0, errors.New("openssl: allocations aren't tracked, see InitOptions.TrackAllocations")
+	}
+	return int64(C.go_openssl_live_allocations()), nil
+}
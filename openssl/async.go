@@ -0,0 +1,306 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Hardware offload engines and providers, such as the QAT engine of
+// Intel QuickAssist, support the OpenSSL async job infrastructure: an
+// operation run as an ASYNC_JOB pauses, rather than blocking its thread,
+// while the hardware computes, and is resumed once the hardware signals
+// its completion through a file descriptor. An AsyncQueue runs signatures
+// this way, so that many of them can be in flight on a single thread.
+//
+// A paused job can only be resumed on the thread that started it, so each
+// AsyncQueue drives its jobs from a goroutine locked to its thread. The
+// jobs of implementations without async support never pause, and finish
+// the first time they run.
+
+// asyncRetryInterval is how often the paused jobs of an AsyncQueue are
+// resumed without a call to AsyncOp.Resume. Engines in polling mode pause
+// without exposing file descriptors, and expect their jobs to be retried.
+const asyncRetryInterval = time.Millisecond
+
+var errAsyncClosed = errors.New("openssl: async queue closed")
+
+// AsyncCapable reports whether OpenSSL supports async jobs on this
+// platform, see NewAsyncQueue.
+func AsyncCapable() bool {
+	if vMajor == 1 && vMinor == 0 {
+		return false
+	}
+	return C.go_openssl_ASYNC_is_capable() == 1
+}
+
+// AsyncQueue runs signatures as OpenSSL async jobs, see AsyncOp.
+// The methods of an AsyncQueue can be called concurrently.
+type AsyncQueue struct {
+	submit chan *AsyncOp
+	// wake triggers the resumption of the paused jobs.
+	wake chan struct{}
+	// done is closed once the driving goroutine exits.
+	done chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewAsyncQueue returns an AsyncQueue, started with a goroutine driving
+// its jobs. It fails if AsyncCapable reports false. The AsyncQueue must
+// be closed with Close.
+func NewAsyncQueue() (*AsyncQueue, error) {
+	if !AsyncCapable() {
+		return nil, errors.New("openssl: async jobs are not supported")
+	}
+	q := &AsyncQueue{
+		submit: make(chan *AsyncOp, 64),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q, nil
+}
+
+// Close waits for the operations in flight to finish and stops q.
+// Operations submitted afterwards fail.
+func (q *AsyncQueue) Close() error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.submit)
+	}
+	q.mu.Unlock()
+	<-q.done
+	return nil
+}
+
+func (q *AsyncQueue) run() {
+	// The thread is dedicated to the jobs of q, and exits with it.
+	runtime.LockOSThread()
+	defer close(q.done)
+	ticker := time.NewTicker(asyncRetryInterval)
+	defer ticker.Stop()
+	var paused []*AsyncOp
+	submit := q.submit
+	for submit != nil || len(paused) > 0 {
+		var retry <-chan time.Time
+		if len(paused) > 0 {
+			retry = ticker.C
+		}
+		select {
+		case op, ok := <-submit:
+			if !ok {
+				submit = nil
+				continue
+			}
+			if op.step() {
+				paused = append(paused, op)
+			}
+			continue
+		case <-q.wake:
+		case <-retry:
+		}
+		n := 0
+		for _, op := range paused {
+			if op.step() {
+				paused[n] = op
+				n++
+			}
+		}
+		for i := n; i < len(paused); i++ {
+			paused[i] = nil
+		}
+		paused = paused[:n]
+	}
+}
+
+// AsyncOp is an operation submitted to an AsyncQueue.
+type AsyncOp struct {
+	q     *AsyncQueue
+	s     *C.go_openssl_async_sign
+	start time.Time
+	done  chan struct{}
+	sig   []byte
+	err   error
+
+	mu  sync.Mutex
+	fds []uintptr
+}
+
+// Done returns a channel closed once op finished.
+func (op *AsyncOp) Done() <-chan struct{} {
+	return op.done
+}
+
+// Result waits for op to finish and returns its result.
+func (op *AsyncOp) Result() ([]byte, error) {
+	<-op.done
+	return op.sig, op.err
+}
+
+// WaitFDs returns the file descriptors, or Windows handles, whose
+// readiness signals that op, paused, can be resumed with Resume. It
+// returns nil if op isn't paused or is paused without file descriptors.
+// Paused operations are also resumed periodically, so that polling the
+// file descriptors only lowers the latency of the operations.
+func (op *AsyncOp) WaitFDs() []uintptr {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return append([]uintptr(nil), op.fds...)
+}
+
+// Resume resumes the paused operations of the AsyncQueue of op,
+// once the file descriptors returned by WaitFDs are ready.
+func (op *AsyncOp) Resume() {
+	if op.q == nil {
+		return
+	}
+	select {
+	case op.q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// step runs the job of op until it pauses or finishes, on the thread
+// of the AsyncQueue, and reports whether it paused.
+func (op *AsyncOp) step() bool {
+	switch C.go_openssl_async_sign_run(op.s) {
+	case C.GO_ASYNC_PAUSE:
+		op.setWaitFDs()
+		return true
+	case C.GO_ASYNC_NO_JOBS:
+		// All the jobs of the thread are in use, try again later.
+		return true
+	case C.GO_ASYNC_FINISH:
+		if op.s.ret == 1 {
+			op.sig = C.GoBytes(unsafe.Pointer(op.s.sig), C.int(op.s.siglen))
+		} else {
+			op.err = newOpenSSLError("EVP_PKEY_sign failed")
+		}
+	default:
+		op.err = newOpenSSLError("ASYNC_start_job failed")
+	}
+	op.finish()
+	return false
+}
+
+func (op *AsyncOp) setWaitFDs() {
+	var buf [8]C.uintptr_t
+	fds := buf[:]
+	n := C.go_openssl_async_wait_fds(op.s.wctx, &fds[0], C.size_t(len(fds)))
+	if int(n) > len(fds) {
+		fds = make([]C.uintptr_t, n)
+		n = C.go_openssl_async_wait_fds(op.s.wctx, &fds[0], n)
+	}
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.fds = op.fds[:0]
+	for _, fd := range fds[:n] {
+		op.fds = append(op.fds, uintptr(fd))
+	}
+}
+
+func (op *AsyncOp) finish() {
+	ctx := op.s.ctx
+	auditKey(AuditSign, func(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+		return f(C.go_openssl_EVP_PKEY_CTX_get0_pkey(ctx))
+	}, &op.err)
+	observe(MetricSign, op.start, 0, op.err)
+	C.go_openssl_async_sign_free(op.s)
+	op.s = nil
+	op.mu.Lock()
+	op.fds = nil
+	op.mu.Unlock()
+	close(op.done)
+}
+
+// SignRSAPKCS1v15 submits SignRSAPKCS1v15(priv, h, hashed) to q.
+func (q *AsyncQueue) SignRSAPKCS1v15(priv *PrivateKeyRSA, h crypto.Hash, hashed []byte) *AsyncOp {
+	return q.sign(priv.withKey, C.GO_RSA_PKCS1_PADDING, 0, h, hashed)
+}
+
+// SignRSAPSS submits SignRSAPSS(priv, h, hashed, saltLen) to q.
+func (q *AsyncQueue) SignRSAPSS(priv *PrivateKeyRSA, h crypto.Hash, hashed []byte, saltLen int) *AsyncOp {
+	cSaltLen, err := saltLength(saltLen, true)
+	if err != nil {
+		return failedAsyncOp(err)
+	}
+	return q.sign(priv.withKey, C.GO_RSA_PKCS1_PSS_PADDING, cSaltLen, h, hashed)
+}
+
+// SignMarshalECDSA submits SignMarshalECDSA(priv, hash) to q.
+func (q *AsyncQueue) SignMarshalECDSA(priv *PrivateKeyECDSA, hash []byte) *AsyncOp {
+	return q.sign(priv.withKey, 0, 0, 0, hash)
+}
+
+// sign is like evpSign, but runs EVP_PKEY_sign as a job of q. The
+// EVP_PKEY_CTX is set up by the caller, and holds a reference to the
+// key, which can be closed before the job finishes.
+func (q *AsyncQueue) sign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, hashed []byte) *AsyncOp {
+	if err := checkStrictHash(h, true); err != nil {
+		return failedAsyncOp(err)
+	}
+	if padding != 0 {
+		if err := checkStrictRSAKey(withKey); err != nil {
+			return failedAsyncOp(err)
+		}
+	}
+	start := metricsStart()
+	s, err := newAsyncSign(withKey, padding, saltLen, h, hashed)
+	if err != nil {
+		observe(MetricSign, start, 0, err)
+		return failedAsyncOp(err)
+	}
+	op := &AsyncOp{q: q, s: s, start: start, done: make(chan struct{})}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		C.go_openssl_async_sign_free(s)
+		return failedAsyncOp(errAsyncClosed)
+	}
+	q.submit <- op
+	return op
+}
+
+func newAsyncSign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, hashed []byte) (_ *C.go_openssl_async_sign, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	C.go_openssl_ERR_clear_error()
+	signInit := func(ctx C.GO_EVP_PKEY_CTX_PTR) error {
+		if ret := C.go_openssl_EVP_PKEY_sign_init(ctx); ret != 1 {
+			return newOpenSSLError("EVP_PKEY_sign_init failed")
+		}
+		return nil
+	}
+	ctx, err := setupEVP(withKey, padding, nil, nil, nil, saltLen, h, signInit)
+	if err != nil {
+		return nil, err
+	}
+	size := withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_EVP_PKEY_get_size(pkey)
+	})
+	s := C.go_openssl_async_sign_new(ctx, base(hashed), C.size_t(len(hashed)), C.size_t(size))
+	if s == nil {
+		return nil, errors.New("openssl: allocating an async job failed")
+	}
+	return s, nil
+}
+
+func failedAsyncOp(err error) *AsyncOp {
+	op := &AsyncOp{err: err, done: make(chan struct{})}
+	close(op.done)
+	return op
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig/bridge"
+)
+
+func newAsyncQueue(t *testing.T) *openssl.AsyncQueue {
+	if !openssl.AsyncCapable() {
+		t.Skip("async jobs are not supported")
+	}
+	q, err := openssl.NewAsyncQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestAsyncSignRSA(t *testing.T) {
+	q := newAsyncQueue(t)
+	priv, pub := newRSAKey(t, 2048)
+	hashed := openssl.SHA256([]byte("testing"))
+	var ops []*openssl.AsyncOp
+	for i := 0; i < 16; i++ {
+		ops = append(ops, q.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:]), q.SignRSAPSS(priv, crypto.SHA256, hashed[:], 0))
+	}
+	// The key can be closed while its operations are in flight.
+	priv.Close()
+	for i, op := range ops {
+		sig, err := op.Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			err = openssl.VerifyRSAPKCS1v15(pub, crypto.SHA256, hashed[:], sig)
+		} else {
+			err = openssl.VerifyRSAPSS(pub, crypto.SHA256, hashed[:], sig, 0)
+		}
+		if err != nil {
+			t.Errorf("operation %d: %v", i, err)
+		}
+		if fds := op.WaitFDs(); fds != nil {
+			t.Errorf("operation %d: got wait fds %v after it finished", i, fds)
+		}
+	}
+}
+
+func TestAsyncSignECDSA(t *testing.T) {
+	q := newAsyncQueue(t)
+	key, err := generateKeycurve(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := bridge.NewPrivateKeyECDSA(key.Params().Name, key.X, key.Y, key.D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer priv.Close()
+	pub, err := bridge.NewPublicKeyECDSA(key.Params().Name, key.X, key.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := openssl.SHA256([]byte("testing"))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			op := q.SignMarshalECDSA(priv, hashed[:])
+			<-op.Done()
+			sig, err := op.Result()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !openssl.VerifyECDSA(pub, hashed[:], sig) {
+				t.Error("VerifyECDSA failed")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAsyncQueueClosed(t *testing.T) {
+	q := newAsyncQueue(t)
+	priv, _ := newRSAKey(t, 2048)
+	hashed := openssl.SHA256([]byte("testing"))
+	q.Close()
+	if _, err := q.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:]).Result(); err == nil {
+		t.Fatal("expected an error from a closed queue")
+	}
+	if _, err := q.SignRSAPSS(priv, crypto.SHA256, hashed[:], -3).Result(); err == nil {
+		t.Fatal("expected an error from an invalid salt length")
+	}
+}
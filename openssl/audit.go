@@ -0,0 +1,140 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"encoding/hex"
+	"sync/atomic"
+)
+
+// Operations reported in AuditEvent.Op.
+const (
+	AuditKeygen  = "keygen"
+	AuditImport  = "import"
+	AuditWrap    = "wrap"
+	AuditUnwrap  = "unwrap"
+	AuditSign    = "sign"
+	AuditDecrypt = "decrypt"
+)
+
+// AuditEvent describes an operation on a private or secret key,
+// for audit trails. It never holds key material.
+type AuditEvent struct {
+	// Op is one of the Audit constants.
+	Op string
+	// Algorithm is the key type, such as "RSA", "EC" or "AES".
+	Algorithm string
+	// Fingerprint identifies the key: the hex SHA-256 of the DER
+	// SubjectPublicKeyInfo of asymmetric keys, or the first 16 bytes
+	// of the hex SHA-256 of AES key encryption keys. It is empty if
+	// the public key can't be extracted.
+	Fingerprint string
+	// Err is the error of the operation, nil on success. Key
+	// generation and import are only reported when they succeed.
+	Err error
+}
+
+// auditState is stored in auditCallback, which can't hold a nil func.
+type auditState struct {
+	cb func(AuditEvent)
+}
+
+var (
+	// auditEnabled is non-zero when an audit callback is set.
+	auditEnabled int32
+	// auditCallback holds the auditState set with SetAuditCallback.
+	auditCallback atomic.Value
+)
+
+// SetAuditCallback registers cb to be called after the generation and
+// import of private keys, AES key wrapping and unwrapping, signing and
+// decryption. A nil cb removes the callback.
+//
+// cb may be called concurrently from any goroutine and must not
+// call back into this package.
+func SetAuditCallback(cb func(AuditEvent)) {
+	auditCallback.Store(auditState{cb})
+	var enabled int32
+	if cb != nil {
+		enabled = 1
+	}
+	atomic.StoreInt32(&auditEnabled, enabled)
+}
+
+func audit(ev AuditEvent) {
+	if s, _ := auditCallback.Load().(auditState); s.cb != nil {
+		s.cb(ev)
+	}
+}
+
+// auditKey reports the operation op with the key of withKey, whose
+// result is *err, or success if err is nil.
+func auditKey(op string, withKey withKeyFunc, err *error) {
+	if atomic.LoadInt32(&auditEnabled) == 0 {
+		return
+	}
+	ev := AuditEvent{Op: op}
+	if err != nil {
+		ev.Err = *err
+	}
+	withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		ev.Algorithm, ev.Fingerprint = pkeyAlgorithm(pkey), pkeyFingerprint(pkey)
+		return 1
+	})
+	audit(ev)
+}
+
+// auditPKey reports the successful operation op with pkey.
+func auditPKey(op string, pkey C.GO_EVP_PKEY_PTR) {
+	auditKey(op, func(f func(C.GO_EVP_PKEY_PTR) C.int) C.int { return f(pkey) }, nil)
+}
+
+// auditAES reports the operation op with the AES key encryption key
+// kek, whose result is *err.
+func auditAES(op string, kek []byte, err *error) {
+	if atomic.LoadInt32(&auditEnabled) == 0 {
+		return
+	}
+	sum := SHA256(kek)
+	audit(AuditEvent{Op: op, Algorithm: "AES", Fingerprint: hex.EncodeToString(sum[:16]), Err: *err})
+}
+
+// pkeyAlgorithm returns the name of the key type of pkey.
+func pkeyAlgorithm(pkey C.GO_EVP_PKEY_PTR) string {
+	if vMajor == 3 {
+		if name := C.go_openssl_EVP_PKEY_get0_type_name(pkey); name != nil {
+			return C.GoString(name)
+		}
+		return ""
+	}
+	switch C.go_openssl_EVP_PKEY_get_base_id(pkey) {
+	case C.GO_EVP_PKEY_RSA:
+		return "RSA"
+	case C.GO_EVP_PKEY_EC:
+		return "EC"
+	}
+	return ""
+}
+
+// pkeyFingerprint returns the hex SHA-256 of the DER
+// SubjectPublicKeyInfo of pkey, or "" if it can't be encoded.
+func pkeyFingerprint(pkey C.GO_EVP_PKEY_PTR) string {
+	n := C.go_openssl_i2d_PUBKEY_buf(pkey, nil)
+	if n <= 0 {
+		C.go_openssl_ERR_clear_error()
+		return ""
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_PUBKEY_buf(pkey, base(der)) != n {
+		C.go_openssl_ERR_clear_error()
+		return ""
+	}
+	sum := SHA256(der)
+	return hex.EncodeToString(sum[:])
+}
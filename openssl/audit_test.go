@@ -0,0 +1,84 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestAuditCallback(t *testing.T) {
+	var mu sync.Mutex
+	var events []openssl.AuditEvent
+	openssl.SetAuditCallback(func(ev openssl.AuditEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	})
+	defer openssl.SetAuditCallback(nil)
+
+	priv, pub := newRSAKey(t, 2048)
+	der, err := openssl.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := openssl.SHA256(der)
+	fingerprint := hex.EncodeToString(sum[:])
+	hashed := openssl.SHA256([]byte("message"))
+	if _, err := openssl.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openssl.DecryptRSAOAEP(openssl.NewSHA256(), priv, make([]byte, 256), nil); err == nil {
+		t.Fatal("DecryptRSAOAEP succeeded with an invalid ciphertext")
+	}
+	kek := make([]byte, 32)
+	wrapped, err := openssl.WrapKeyAES(kek, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped[0] ^= 1
+	if _, err := openssl.UnwrapKeyAES(kek, wrapped); err == nil {
+		t.Fatal("UnwrapKeyAES succeeded with a modified key")
+	}
+	openssl.SetAuditCallback(nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []struct {
+		op     string
+		failed bool
+	}{
+		{openssl.AuditKeygen, false},
+		{openssl.AuditImport, false},
+		{openssl.AuditSign, false},
+		{openssl.AuditDecrypt, true},
+		{openssl.AuditWrap, false},
+		{openssl.AuditUnwrap, true},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		ev := events[i]
+		if ev.Op != w.op || (ev.Err != nil) != w.failed {
+			t.Errorf("event %d = %+v, want %s with failure %v", i, ev, w.op, w.failed)
+		}
+		if ev.Fingerprint == "" {
+			t.Errorf("event %d has no fingerprint", i)
+		}
+		if i < 4 && (ev.Algorithm != "RSA" || ev.Fingerprint != fingerprint) {
+			t.Errorf("event %d key = %s %s, want RSA %s", i, ev.Algorithm, ev.Fingerprint, fingerprint)
+		}
+	}
+	if events[4].Algorithm != "AES" || events[4].Fingerprint != events[5].Fingerprint {
+		t.Errorf("AES events = %+v, %+v", events[4], events[5])
+	}
+}
@@ -6,7 +6,6 @@
 package bridge
 
 import (
-	"encoding/asn1"
 	"math/big"
 
 	"github.com/microsoft/go-crypto-openssl/openssl"
@@ -21,20 +20,12 @@ func GenerateKeyECDSA(curve string) (X, Y, D *big.Int, err error) {
 	return bbig.Dec(x), bbig.Dec(y), bbig.Dec(d), nil
 }
 
-type ecdsaSignature struct {
-	R, S *big.Int
-}
-
 func SignECDSA(priv *openssl.PrivateKeyECDSA, hash []byte) (r, s *big.Int, err error) {
-	sig, err := openssl.SignMarshalECDSA(priv, hash)
+	rb, sb, err := openssl.SignRawECDSA(priv, hash)
 	if err != nil {
 		return nil, nil, err
 	}
-	var esig ecdsaSignature
-	if _, err := asn1.Unmarshal(sig, &esig); err != nil {
-		return nil, nil, err
-	}
-	return esig.R, esig.S, nil
+	return new(big.Int).SetBytes(rb), new(big.Int).SetBytes(sb), nil
 }
 
 func NewPrivateKeyECDSA(curve string, X, Y, D *big.Int) (*openssl.PrivateKeyECDSA, error) {
@@ -46,11 +37,10 @@ func NewPublicKeyECDSA(curve string, X, Y *big.Int) (*openssl.PublicKeyECDSA, er
 }
 
 func VerifyECDSA(pub *openssl.PublicKeyECDSA, hash []byte, r, s *big.Int) bool {
-	sig, err := asn1.Marshal(ecdsaSignature{r, s})
-	if err != nil {
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 {
 		return false
 	}
-	return openssl.VerifyECDSA(pub, hash, sig)
+	return openssl.VerifyRawECDSA(pub, hash, r.Bytes(), s.Bytes())
 }
 
 func GenerateKeyRSA(bits int) (N, E, D, P, Q, Dp, Dq, Qinv *big.Int, err error) {
@@ -0,0 +1,36 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "unsafe"
+
+// newMemBIO returns a memory BIO holding a copy of data,
+// which the caller must free with BIO_free.
+// The BIO doesn't reference data, which can be Go memory.
+func newMemBIO(data []byte) (C.GO_BIO_PTR, error) {
+	bio := C.go_openssl_BIO_new(C.go_openssl_BIO_s_mem())
+	if bio == nil {
+		return nil, newOpenSSLError("BIO_new")
+	}
+	if len(data) > 0 && C.go_openssl_BIO_write(bio, unsafe.Pointer(&data[0]), C.int(len(data))) != C.int(len(data)) {
+		C.go_openssl_BIO_free(bio)
+		return nil, newOpenSSLError("BIO_write")
+	}
+	return bio, nil
+}
+
+// memBIOBytes returns a copy of the data written to the memory BIO bio.
+func memBIOBytes(bio C.GO_BIO_PTR) []byte {
+	var p *C.char
+	n := C.go_openssl_BIO_ctrl(bio, C.GO_BIO_CTRL_INFO, 0, unsafe.Pointer(&p))
+	if n <= 0 {
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(p), C.int(n))
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto/cipher"
+	"errors"
+	"runtime"
+
+	"github.com/microsoft/go-crypto-openssl/openssl/internal/subtle"
+)
+
+const (
+	chacha20Poly1305KeySize   = 32
+	chacha20Poly1305NonceSize = 12
+	chacha20Poly1305Overhead  = 16
+)
+
+// chacha20Poly1305 implements the ChaCha20-Poly1305 AEAD of RFC 8439.
+// It is not exported on its own as it is not available in FIPS mode,
+// and is only used by HPKE.
+type chacha20Poly1305 struct {
+	ctx    C.GO_EVP_CIPHER_CTX_PTR
+	pooled *pooledCtx // owns ctx
+	closer
+}
+
+func newChaCha20Poly1305(key []byte) (cipher.AEAD, error) {
+	if len(key) != chacha20Poly1305KeySize {
+		return nil, errors.New("openssl: invalid ChaCha20-Poly1305 key size")
+	}
+	if StrictFIPS() {
+		return nil, notApprovedError("ChaCha20-Poly1305")
+	}
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	cipher := C.go_openssl_EVP_chacha20_poly1305()
+	if cipher == nil {
+		return nil, newOpenSSLError("EVP_chacha20_poly1305")
+	}
+	ctx, pooled, err := newCipherCtx(cipher, -1, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &chacha20Poly1305{ctx: ctx, pooled: pooled}
+	manage(c, (*chacha20Poly1305).finalize)
+	return c, nil
+}
+
+func (c *chacha20Poly1305) finalize() {
+	c.pooled.put()
+	c.ctx, c.pooled = nil, nil
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *chacha20Poly1305) Close() error {
+	return closeObject(c, c.finalize)
+}
+
+func (c *chacha20Poly1305) NonceSize() int { return chacha20Poly1305NonceSize }
+
+func (c *chacha20Poly1305) Overhead() int { return chacha20Poly1305Overhead }
+
+func (c *chacha20Poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	start := metricsStart()
+	ret := c.seal(dst, nonce, plaintext, additionalData)
+	observe(MetricSeal, start, len(plaintext), nil)
+	return ret
+}
+
+func (c *chacha20Poly1305) seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	c.checkOpen()
+	if len(nonce) != chacha20Poly1305NonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Seal")
+	}
+	if uint64(len(plaintext)) > (1<<38)-64 {
+		panic("chacha20poly1305: plaintext too large")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+chacha20Poly1305Overhead)
+	if subtle.InexactOverlap(out, plaintext) {
+		panic("chacha20poly1305: invalid buffer overlap")
+	}
+	// The AEAD tag controls of ChaCha20-Poly1305 share
+	// their values with the GCM ones used by the wrapper.
+	if C.go_openssl_EVP_CIPHER_CTX_seal_wrapper(c.ctx, base(out), base(nonce),
+		base(plaintext), C.int(len(plaintext)),
+		base(additionalData), C.int(len(additionalData))) != 1 {
+
+		panic(fail("EVP_CIPHER_CTX_seal"))
+	}
+	runtime.KeepAlive(c)
+	return ret
+}
+
+func (c *chacha20Poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	start := metricsStart()
+	ret, err := c.open(dst, nonce, ciphertext, additionalData)
+	observe(MetricOpen, start, len(ciphertext), err)
+	return ret, err
+}
+
+func (c *chacha20Poly1305) open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	c.checkOpen()
+	if len(nonce) != chacha20Poly1305NonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Open")
+	}
+	if len(ciphertext) < chacha20Poly1305Overhead {
+		return nil, errOpen
+	}
+	if uint64(len(ciphertext)) > (1<<38)-48 {
+		return nil, errOpen
+	}
+	tag := ciphertext[len(ciphertext)-chacha20Poly1305Overhead:]
+	ciphertext = ciphertext[:len(ciphertext)-chacha20Poly1305Overhead]
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if subtle.InexactOverlap(out, ciphertext) {
+		panic("chacha20poly1305: invalid buffer overlap")
+	}
+	if C.go_openssl_EVP_CIPHER_CTX_open_wrapper(c.ctx, base(out), base(nonce),
+		base(ciphertext), C.int(len(ciphertext)),
+		base(additionalData), C.int(len(additionalData)), base(tag)) != 1 {
+
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	runtime.KeepAlive(c)
+	return ret, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package cipher mirrors the API of crypto/cipher, so that applications
+// can move to OpenSSL by changing their import path. The GCM, CTR and
+// CBC modes of blocks returned by this module's aes package, or by
+// openssl.NewAESCipher, are implemented by OpenSSL. Other blocks and
+// modes are handled by crypto/cipher.
+package cipher
+
+import "crypto/cipher"
+
+type (
+	// Block is crypto/cipher.Block.
+	Block = cipher.Block
+	// BlockMode is crypto/cipher.BlockMode.
+	BlockMode = cipher.BlockMode
+	// Stream is crypto/cipher.Stream.
+	Stream = cipher.Stream
+	// AEAD is crypto/cipher.AEAD.
+	AEAD = cipher.AEAD
+	// StreamReader is crypto/cipher.StreamReader.
+	StreamReader = cipher.StreamReader
+	// StreamWriter is crypto/cipher.StreamWriter.
+	StreamWriter = cipher.StreamWriter
+)
+
+// The mode interfaces implemented by the AES blocks of the openssl package.
+type (
+	gcmAble interface {
+		NewGCM(nonceSize, tagSize int) (cipher.AEAD, error)
+	}
+	ctrAble interface {
+		NewCTR(iv []byte) cipher.Stream
+	}
+	cbcEncAble interface {
+		NewCBCEncrypter(iv []byte) cipher.BlockMode
+	}
+	cbcDecAble interface {
+		NewCBCDecrypter(iv []byte) cipher.BlockMode
+	}
+)
+
+const (
+	gcmStandardNonceSize = 12
+	gcmTagSize           = 16
+)
+
+// NewGCM returns the given 128-bit block cipher wrapped in Galois
+// Counter Mode with the standard nonce length.
+func NewGCM(b Block) (AEAD, error) {
+	if g, ok := b.(gcmAble); ok {
+		return g.NewGCM(gcmStandardNonceSize, gcmTagSize)
+	}
+	return cipher.NewGCM(b)
+}
+
+// NewGCMWithNonceSize returns the given 128-bit block cipher wrapped in
+// Galois Counter Mode, which accepts nonces of the given length.
+// Only use it for compatibility with existing cryptosystems that use
+// non-standard nonce lengths.
+func NewGCMWithNonceSize(b Block, size int) (AEAD, error) {
+	if g, ok := b.(gcmAble); ok {
+		return g.NewGCM(size, gcmTagSize)
+	}
+	return cipher.NewGCMWithNonceSize(b, size)
+}
+
+// NewGCMWithTagSize returns the given 128-bit block cipher wrapped in
+// Galois Counter Mode, which generates tags with the given length.
+// Only use it for compatibility with existing cryptosystems that use
+// non-standard tag lengths.
+func NewGCMWithTagSize(b Block, tagSize int) (AEAD, error) {
+	if g, ok := b.(gcmAble); ok {
+		return g.NewGCM(gcmStandardNonceSize, tagSize)
+	}
+	return cipher.NewGCMWithTagSize(b, tagSize)
+}
+
+// NewCTR returns a Stream which encrypts/decrypts using the given Block
+// in counter mode. The length of iv must be the same as the Block's
+// block size.
+func NewCTR(b Block, iv []byte) Stream {
+	if c, ok := b.(ctrAble); ok {
+		if len(iv) != b.BlockSize() {
+			panic("cipher.NewCTR: IV length must equal block size")
+		}
+		return c.NewCTR(iv)
+	}
+	return cipher.NewCTR(b, iv)
+}
+
+// NewCBCEncrypter returns a BlockMode which encrypts in cipher block
+// chaining mode, using the given Block. The length of iv must be the
+// same as the Block's block size.
+func NewCBCEncrypter(b Block, iv []byte) BlockMode {
+	if c, ok := b.(cbcEncAble); ok {
+		if len(iv) != b.BlockSize() {
+			panic("cipher.NewCBCEncrypter: IV length must equal block size")
+		}
+		return c.NewCBCEncrypter(iv)
+	}
+	return cipher.NewCBCEncrypter(b, iv)
+}
+
+// NewCBCDecrypter returns a BlockMode which decrypts in cipher block
+// chaining mode, using the given Block. The length of iv must be the
+// same as the Block's block size and must match the iv used to encrypt
+// the data.
+func NewCBCDecrypter(b Block, iv []byte) BlockMode {
+	if c, ok := b.(cbcDecAble); ok {
+		if len(iv) != b.BlockSize() {
+			panic("cipher.NewCBCDecrypter: IV length must equal block size")
+		}
+		return c.NewCBCDecrypter(iv)
+	}
+	return cipher.NewCBCDecrypter(b, iv)
+}
+
+// NewCFBEncrypter calls crypto/cipher.NewCFBEncrypter.
+func NewCFBEncrypter(block Block, iv []byte) Stream {
+	return cipher.NewCFBEncrypter(block, iv)
+}
+
+// NewCFBDecrypter calls crypto/cipher.NewCFBDecrypter.
+func NewCFBDecrypter(block Block, iv []byte) Stream {
+	return cipher.NewCFBDecrypter(block, iv)
+}
+
+// NewOFB calls crypto/cipher.NewOFB.
+func NewOFB(b Block, iv []byte) Stream {
+	return cipher.NewOFB(b, iv)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package cipher
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	stdcipher "crypto/cipher"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/aes"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+var (
+	key = bytes.Repeat([]byte{1}, 32)
+	iv  = bytes.Repeat([]byte{2}, 16)
+	msg = bytes.Repeat([]byte("0123456789abcdef"), 4)
+)
+
+// blocks returns an OpenSSL block and a crypto/aes block for key.
+func blocks(t *testing.T) (Block, Block) {
+	t.Helper()
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, err := stdaes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, std
+}
+
+func TestGCM(t *testing.T) {
+	b, std := blocks(t)
+	for _, tt := range []struct {
+		name string
+		gcm  func(Block) (AEAD, error)
+	}{
+		{"standard", NewGCM},
+		{"nonce size", func(b Block) (AEAD, error) { return NewGCMWithNonceSize(b, 16) }},
+		{"tag size", func(b Block) (AEAD, error) { return NewGCMWithTagSize(b, 12) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := tt.gcm(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdg, err := tt.gcm(std)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce := make([]byte, g.NonceSize())
+			ct := g.Seal(nil, nonce, msg, []byte("ad"))
+			if want := stdg.Seal(nil, nonce, msg, []byte("ad")); !bytes.Equal(ct, want) {
+				t.Fatalf("Seal() = %x, want %x", ct, want)
+			}
+			pt, err := g.Open(nil, nonce, ct, []byte("ad"))
+			if err != nil || !bytes.Equal(pt, msg) {
+				t.Errorf("Open() = %x, %v", pt, err)
+			}
+		})
+	}
+}
+
+func TestCTR(t *testing.T) {
+	b, std := blocks(t)
+	got := make([]byte, len(msg))
+	NewCTR(b, iv).XORKeyStream(got, msg)
+	want := make([]byte, len(msg))
+	stdcipher.NewCTR(std, iv).XORKeyStream(want, msg)
+	if !bytes.Equal(got, want) {
+		t.Errorf("CTR = %x, want %x", got, want)
+	}
+}
+
+func TestCBC(t *testing.T) {
+	b, std := blocks(t)
+	got := make([]byte, len(msg))
+	NewCBCEncrypter(b, iv).CryptBlocks(got, msg)
+	want := make([]byte, len(msg))
+	stdcipher.NewCBCEncrypter(std, iv).CryptBlocks(want, msg)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("CBC = %x, want %x", got, want)
+	}
+	NewCBCDecrypter(b, iv).CryptBlocks(got, got)
+	if !bytes.Equal(got, msg) {
+		t.Errorf("CBC decryption = %x, want %x", got, msg)
+	}
+}
+
+func TestInvalidIV(t *testing.T) {
+	b, _ := blocks(t)
+	defer func() {
+		if recover() == nil {
+			t.Error("NewCTR() with a short IV didn't panic")
+		}
+	}()
+	NewCTR(b, iv[:8])
+}
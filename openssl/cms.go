@@ -0,0 +1,294 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"strconv"
+)
+
+// CMSSignOptions configures SignCMS.
+// The zero value uses the defaults documented on each field.
+type CMSSignOptions struct {
+	// Detached omits the content from the SignedData,
+	// which then only holds the signature.
+	Detached bool
+	// Hash is the digest algorithm of the signature. If zero, the
+	// OpenSSL default of the signer key is used, such as SHA-256.
+	Hash crypto.Hash
+	// Certificates are included in the SignedData in addition to the
+	// signer certificate, such as the intermediates of its chain.
+	Certificates []*Certificate
+}
+
+// SignCMS returns the DER encoding of a CMS SignedData, as defined in
+// RFC 5652, of data signed by priv, the private key of signer.
+// priv must be a *PrivateKeyRSA, a *PrivateKeyECDSA or a *PKey.
+// data is signed as is, without the MIME canonicalization of S/MIME.
+//
+// SignCMS is not supported on OpenSSL 1.0.2.
+func SignCMS(data []byte, signer *Certificate, priv interface{}, opts *CMSSignOptions) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	privWithKey := privateKeyWithKey(priv)
+	if privWithKey == nil {
+		return nil, errors.New("openssl: unsupported private key type")
+	}
+	if opts == nil {
+		opts = &CMSSignOptions{}
+	}
+	var md C.GO_EVP_MD_PTR
+	if opts.Hash != 0 {
+		if md = cryptoHashToMD(opts.Hash); md == nil {
+			return nil, errors.New("openssl: unsupported CMS signature hash")
+		}
+	}
+	certs, err := newX509Stack(opts.Certificates)
+	if err != nil {
+		return nil, err
+	}
+	defer certs.free()
+	in, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(in)
+	// CMS_PARTIAL leaves the SignedData open to add a signer with
+	// an explicit digest, and CMS_final computes the signature.
+	flags := C.uint(C.GO_CMS_BINARY | C.GO_CMS_PARTIAL)
+	if opts.Detached {
+		flags |= C.GO_CMS_DETACHED
+	}
+	cms := C.go_openssl_CMS_sign(nil, nil, C.GO_STACK_OF_X509_PTR(certs.st), nil, flags)
+	if cms == nil {
+		return nil, newOpenSSLError("CMS_sign")
+	}
+	defer C.go_openssl_CMS_ContentInfo_free(cms)
+	if privWithKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return signer.withX509(func(x C.GO_X509_PTR) C.int {
+			if C.go_openssl_CMS_add1_signer(cms, x, pkey, md, flags) == nil {
+				return 0
+			}
+			return 1
+		})
+	}) != 1 {
+		return nil, newOpenSSLError("CMS_add1_signer")
+	}
+	if C.go_openssl_CMS_final(cms, in, nil, flags) != 1 {
+		return nil, newOpenSSLError("CMS_final")
+	}
+	return marshalCMS(cms)
+}
+
+// VerifyCMS verifies the signatures of der, a DER or BER encoded CMS
+// or PKCS #7 SignedData, and returns its content and the certificates
+// of its signers. If der is detached, content must be the signed data,
+// and nil otherwise.
+//
+// If opts is nil, only the signatures are verified, and the caller
+// must authenticate the signers by other means. Otherwise, each signer
+// certificate must verify as described in Certificate.Verify, with
+// the certificates of der added to opts.Intermediates, and with the
+// "smimesign" purpose if opts.Purpose is empty.
+//
+// VerifyCMS is not supported on OpenSSL 1.0.2.
+func VerifyCMS(der, content []byte, opts *VerifyOptions) ([]byte, []*Certificate, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, nil, errUnsuportedVersion()
+	}
+	cms, err := parseCMS(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.go_openssl_CMS_ContentInfo_free(cms)
+	var dcont C.GO_BIO_PTR
+	if content != nil {
+		if dcont, err = newMemBIO(content); err != nil {
+			return nil, nil, err
+		}
+		defer C.go_openssl_BIO_free(dcont)
+	}
+	out, err := newMemBIO(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.go_openssl_BIO_free(out)
+	var intermediates []*Certificate
+	if opts != nil {
+		intermediates = opts.Intermediates
+	}
+	// The intermediates may hold signer certificates missing from der.
+	untrusted, err := newX509Stack(intermediates)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer untrusted.free()
+	// CMS_verify only builds the signer chains from the certificates
+	// of der, so they are verified below with Certificate.Verify.
+	if C.go_openssl_CMS_verify(cms, C.GO_STACK_OF_X509_PTR(untrusted.st), nil, dcont, out, C.GO_CMS_BINARY|C.GO_CMS_NO_SIGNER_CERT_VERIFY) != 1 {
+		return nil, nil, newOpenSSLError("CMS_verify")
+	}
+	// The signers are owned by cms, only the stack must be freed.
+	st := C.GO_OPENSSL_STACK_PTR(C.go_openssl_CMS_get0_signers(cms))
+	if st == nil {
+		return nil, nil, newOpenSSLError("CMS_get0_signers")
+	}
+	signers, err := newCertificates(x509Stack{st}.certs())
+	C.go_openssl_OPENSSL_sk_free(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts != nil {
+		vopts := *opts
+		if vopts.Purpose == "" {
+			vopts.Purpose = "smimesign"
+		}
+		// CMS_get1_certs returns NULL if der has no certificates.
+		embedded := x509Stack{C.GO_OPENSSL_STACK_PTR(C.go_openssl_CMS_get1_certs(cms))}
+		certs, err := newCertificates(embedded.certs())
+		embedded.free()
+		if err != nil {
+			return nil, nil, err
+		}
+		vopts.Intermediates = append(append([]*Certificate(nil), opts.Intermediates...), certs...)
+		for _, signer := range signers {
+			if _, err := signer.Verify(vopts); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return memBIOBytes(out), signers, nil
+}
+
+// CMSCipher identifies the content encryption algorithm used by EncryptCMS.
+type CMSCipher int
+
+const (
+	CMSAES128CBC CMSCipher = iota + 1
+	CMSAES192CBC
+	CMSAES256CBC
+)
+
+// CMSEncryptOptions configures EncryptCMS.
+// The zero value uses the defaults documented on each field.
+type CMSEncryptOptions struct {
+	// Cipher is the content encryption algorithm.
+	// CMSAES256CBC is used if zero.
+	Cipher CMSCipher
+}
+
+// EncryptCMS returns the DER encoding of a CMS EnvelopedData, as defined
+// in RFC 5652, of data encrypted for recipients. The content encryption
+// key is transported with RSA for RSA recipients, and agreed with ECDH
+// for EC recipients.
+//
+// EncryptCMS is not supported on OpenSSL 1.0.2.
+func EncryptCMS(data []byte, recipients []*Certificate, opts *CMSEncryptOptions) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("openssl: no CMS recipients")
+	}
+	if opts == nil {
+		opts = &CMSEncryptOptions{}
+	}
+	var cipher C.GO_EVP_CIPHER_PTR
+	switch opts.Cipher {
+	case CMSAES128CBC:
+		cipher = C.go_openssl_EVP_aes_128_cbc()
+	case CMSAES192CBC:
+		cipher = C.go_openssl_EVP_aes_192_cbc()
+	case 0, CMSAES256CBC:
+		cipher = C.go_openssl_EVP_aes_256_cbc()
+	default:
+		return nil, errors.New("openssl: unsupported CMS cipher " + strconv.Itoa(int(opts.Cipher)))
+	}
+	certs, err := newX509Stack(recipients)
+	if err != nil {
+		return nil, err
+	}
+	defer certs.free()
+	in, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(in)
+	cms := C.go_openssl_CMS_encrypt(C.GO_STACK_OF_X509_PTR(certs.st), in, cipher, C.GO_CMS_BINARY)
+	if cms == nil {
+		return nil, newOpenSSLError("CMS_encrypt")
+	}
+	defer C.go_openssl_CMS_ContentInfo_free(cms)
+	return marshalCMS(cms)
+}
+
+// DecryptCMS decrypts der, a DER or BER encoded CMS or PKCS #7
+// EnvelopedData, with priv, the private key of recipient.
+// priv must be a *PrivateKeyRSA, a *PrivateKeyECDSA or a *PKey.
+//
+// DecryptCMS is not supported on OpenSSL 1.0.2.
+func DecryptCMS(der []byte, recipient *Certificate, priv interface{}) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	privWithKey := privateKeyWithKey(priv)
+	if privWithKey == nil {
+		return nil, errors.New("openssl: unsupported private key type")
+	}
+	cms, err := parseCMS(der)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_CMS_ContentInfo_free(cms)
+	out, err := newMemBIO(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(out)
+	// Passing recipient selects its RecipientInfo, instead
+	// of trying the key against all of them.
+	if privWithKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return recipient.withX509(func(x C.GO_X509_PTR) C.int {
+			return C.go_openssl_CMS_decrypt(cms, pkey, x, nil, out, C.GO_CMS_BINARY)
+		})
+	}) != 1 {
+		return nil, newOpenSSLError("CMS_decrypt")
+	}
+	return memBIOBytes(out), nil
+}
+
+// parseCMS decodes the CMS ContentInfo der. BER is accepted,
+// as produced by streaming S/MIME implementations, so der
+// isn't compared with its re-encoding.
+// The caller must free the returned ContentInfo.
+func parseCMS(der []byte) (C.GO_CMS_ContentInfo_PTR, error) {
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty CMS message")
+	}
+	cms := C.go_openssl_d2i_CMS_ContentInfo_buf(base(der), C.long(len(der)))
+	if cms == nil {
+		return nil, newOpenSSLError("d2i_CMS_ContentInfo")
+	}
+	return cms, nil
+}
+
+// marshalCMS returns the DER encoding of cms.
+func marshalCMS(cms C.GO_CMS_ContentInfo_PTR) ([]byte, error) {
+	n := C.go_openssl_i2d_CMS_ContentInfo_buf(cms, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_CMS_ContentInfo")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_CMS_ContentInfo_buf(cms, base(der)) != n {
+		return nil, newOpenSSLError("i2d_CMS_ContentInfo")
+	}
+	return der, nil
+}
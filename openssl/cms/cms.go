@@ -0,0 +1,185 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package cms signs, verifies, encrypts and decrypts CMS messages,
+// defined in RFC 5652 and also known as PKCS #7, with OpenSSL,
+// for S/MIME style document signing and enveloped data.
+//
+// Messages are read in DER, BER or PEM, and written in DER,
+// which EncodePEM converts to the PEM format of "openssl cms".
+// Certificates are crypto/x509 certificates, and private keys
+// are keys of the openssl package, so that they never leave OpenSSL.
+//
+// See openssl.SignCMS, openssl.VerifyCMS, openssl.EncryptCMS and
+// openssl.DecryptCMS for the details of each operation.
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// SignOptions configures Sign.
+// The zero value uses the defaults documented on each field.
+type SignOptions struct {
+	// Detached omits the content from the message,
+	// which then only holds the signature.
+	Detached bool
+	// Hash is the digest algorithm of the signature. If zero, the
+	// OpenSSL default of the signer key is used, such as SHA-256.
+	Hash crypto.Hash
+	// Certificates are included in the message in addition to the
+	// signer certificate, such as the intermediates of its chain.
+	Certificates []*x509.Certificate
+}
+
+// Sign returns a SignedData message of data signed by key, the
+// private key of cert. key must be an *openssl.PrivateKeyRSA,
+// an *openssl.PrivateKeyECDSA or an *openssl.PKey.
+func Sign(data []byte, cert *x509.Certificate, key interface{}, opts *SignOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+	signer, err := openssl.ParseCertificate(cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parseCertificates(opts.Certificates)
+	if err != nil {
+		return nil, err
+	}
+	return openssl.SignCMS(data, signer, key, &openssl.CMSSignOptions{
+		Detached:     opts.Detached,
+		Hash:         opts.Hash,
+		Certificates: certs,
+	})
+}
+
+// VerifyOptions configures the verification of the signer
+// certificates by Verify, as described in openssl.VerifyOptions.
+type VerifyOptions struct {
+	// Roots are the trust anchors. If nil, the default
+	// OpenSSL trust store is used.
+	Roots []*x509.Certificate
+	// Intermediates complete the certificates of the message
+	// to build the chains of the signers.
+	Intermediates []*x509.Certificate
+	// CurrentTime is the time at which the chains must be valid.
+	// If zero, the current time is used.
+	CurrentTime time.Time
+	// Purpose is the OpenSSL short name of the purpose the signer
+	// certificates must be valid for. "smimesign" is used if empty.
+	Purpose string
+}
+
+// Verify verifies the signatures of msg, a SignedData message, and
+// returns its content and the certificates of its signers. If msg is
+// detached, content must be the signed data, and nil otherwise.
+//
+// If opts is nil, only the signatures are verified, and the caller
+// must authenticate the signers by other means.
+func Verify(msg, content []byte, opts *VerifyOptions) ([]byte, []*x509.Certificate, error) {
+	der, err := decode(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var vopts *openssl.VerifyOptions
+	if opts != nil {
+		vopts = &openssl.VerifyOptions{CurrentTime: opts.CurrentTime, Purpose: opts.Purpose}
+		if vopts.Roots, err = parseCertificates(opts.Roots); err != nil {
+			return nil, nil, err
+		}
+		if vopts.Intermediates, err = parseCertificates(opts.Intermediates); err != nil {
+			return nil, nil, err
+		}
+	}
+	data, signers, err := openssl.VerifyCMS(der, content, vopts)
+	if err != nil {
+		return nil, nil, err
+	}
+	certs := make([]*x509.Certificate, 0, len(signers))
+	for _, s := range signers {
+		cert, err := x509.ParseCertificate(s.Raw())
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return data, certs, nil
+}
+
+// Encrypt returns an EnvelopedData message of data encrypted for recipients.
+// If opts is nil, the defaults described in openssl.CMSEncryptOptions are used.
+func Encrypt(data []byte, recipients []*x509.Certificate, opts *openssl.CMSEncryptOptions) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("cms: no recipients")
+	}
+	certs, err := parseCertificates(recipients)
+	if err != nil {
+		return nil, err
+	}
+	return openssl.EncryptCMS(data, certs, opts)
+}
+
+// Decrypt decrypts msg, an EnvelopedData message, with key, the private
+// key of cert. key must be an *openssl.PrivateKeyRSA,
+// an *openssl.PrivateKeyECDSA or an *openssl.PKey.
+func Decrypt(msg []byte, cert *x509.Certificate, key interface{}) ([]byte, error) {
+	der, err := decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := openssl.ParseCertificate(cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return openssl.DecryptCMS(der, recipient, key)
+}
+
+// pemType is the PEM block type written by EncodePEM.
+const pemType = "CMS"
+
+// EncodePEM returns the PEM encoding of the message der.
+func EncodePEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+}
+
+// decode returns the DER or BER encoding of msg, which
+// may be PEM encoded as a CMS or a PKCS7 block.
+func decode(msg []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(msg), []byte("-----BEGIN ")) {
+		return msg, nil
+	}
+	block, _ := pem.Decode(msg)
+	if block == nil {
+		return nil, errors.New("cms: invalid PEM message")
+	}
+	if block.Type != pemType && block.Type != "PKCS7" {
+		return nil, errors.New("cms: unexpected PEM block type " + block.Type)
+	}
+	return block.Bytes, nil
+}
+
+func parseCertificates(certs []*x509.Certificate) ([]*openssl.Certificate, error) {
+	if certs == nil {
+		return nil, nil
+	}
+	parsed := make([]*openssl.Certificate, 0, len(certs))
+	for _, c := range certs {
+		cert, err := openssl.ParseCertificate(c.Raw)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, cert)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package cms
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newCertificate returns a certificate issued by parent, or self-signed
+// if parent is nil, with its private key in Go and in OpenSSL.
+func newCertificate(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, *openssl.PrivateKeyECDSA) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyAgreement | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, priv
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &priv.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := openssl.NewPrivateKeyECDSA("P-256", bbig.Enc(priv.X), bbig.Enc(priv.Y), bbig.Enc(priv.D))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, priv, key
+}
+
+func TestSignVerify(t *testing.T) {
+	ca, caKey, _ := newCertificate(t, "CA", true, nil, nil)
+	intermediate, intKey, _ := newCertificate(t, "Intermediate", true, ca, caKey)
+	signer, _, key := newCertificate(t, "Signer", false, intermediate, intKey)
+	data := []byte("signed document")
+	for _, detached := range []bool{false, true} {
+		msg, err := Sign(data, signer, key, &SignOptions{Detached: detached, Certificates: []*x509.Certificate{intermediate}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content []byte
+		if detached {
+			content = data
+		}
+		for _, encoded := range [][]byte{msg, EncodePEM(msg)} {
+			got, signers, err := Verify(encoded, content, &VerifyOptions{Roots: []*x509.Certificate{ca}})
+			if err != nil {
+				t.Fatalf("detached %v: %v", detached, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("detached %v: got content %q, want %q", detached, got, data)
+			}
+			if len(signers) != 1 || !signers[0].Equal(signer) {
+				t.Errorf("detached %v: got %d signers, want the signer certificate", detached, len(signers))
+			}
+		}
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	ca, caKey, _ := newCertificate(t, "CA", true, nil, nil)
+	intermediate, intKey, _ := newCertificate(t, "Intermediate", true, ca, caKey)
+	signer, _, key := newCertificate(t, "Signer", false, intermediate, intKey)
+	other, _, _ := newCertificate(t, "Other CA", true, nil, nil)
+	// The message doesn't include the intermediate.
+	msg, err := Sign([]byte("data"), signer, key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Verify(msg, nil, &VerifyOptions{Roots: []*x509.Certificate{ca}}); err == nil {
+		t.Error("signer verified without the intermediate")
+	}
+	if _, _, err := Verify(msg, nil, &VerifyOptions{Roots: []*x509.Certificate{ca}, Intermediates: []*x509.Certificate{intermediate}}); err != nil {
+		t.Errorf("signer rejected: %v", err)
+	}
+	if _, _, err := Verify(msg, nil, &VerifyOptions{Roots: []*x509.Certificate{other}, Intermediates: []*x509.Certificate{intermediate}}); err == nil {
+		t.Error("signer verified with another root")
+	}
+	if _, _, err := Verify(msg, nil, &VerifyOptions{Roots: []*x509.Certificate{ca}, Intermediates: []*x509.Certificate{intermediate}, CurrentTime: time.Now().AddDate(1, 0, 0)}); err == nil {
+		t.Error("expired signer verified")
+	}
+	if _, _, err := Verify(msg, nil, nil); err != nil {
+		t.Errorf("signature rejected: %v", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	recipient, _, key := newCertificate(t, "Recipient", false, nil, nil)
+	data := []byte("enveloped secret")
+	msg, err := Encrypt(data, []*x509.Certificate{recipient}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, encoded := range [][]byte{msg, EncodePEM(msg)} {
+		got, err := Decrypt(encoded, recipient, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("got %q, want %q", got, data)
+		}
+	}
+	if _, err := Encrypt(data, nil, nil); err == nil {
+		t.Error("encrypted without recipients")
+	}
+}
+
+func TestDecodePEM(t *testing.T) {
+	der := []byte{0x30, 0x00}
+	for _, typ := range []string{"CMS", "PKCS7"} {
+		msg := bytes.Replace(EncodePEM(der), []byte(pemType), []byte(typ), -1)
+		if got, err := decode(msg); err != nil || !bytes.Equal(got, der) {
+			t.Errorf("%s: decode() = %x, %v, want %x", typ, got, err, der)
+		}
+	}
+	if _, err := decode(bytes.Replace(EncodePEM(der), []byte(pemType), []byte("CERTIFICATE"), -1)); err == nil {
+		t.Error("decoded a certificate PEM block")
+	}
+	if got, err := decode(der); err != nil || !bytes.Equal(got, der) {
+		t.Errorf("decode() = %x, %v, want the DER message", got, err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// newCMSTestCert returns a self-signed S/MIME certificate
+// and its private key, an RSA key if rsa is true.
+func newCMSTestCert(t *testing.T, name string, rsa bool) (*openssl.Certificate, interface{}) {
+	t.Helper()
+	var priv, pub interface{}
+	if rsa {
+		N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv, err = openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv); err != nil {
+			t.Fatal(err)
+		}
+		if pub, err = openssl.NewPublicKeyRSA(N, E); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		priv, pub = newTestECDSAKey(t)
+	}
+	der, err := openssl.CreateCertificate(&openssl.CertificateTemplate{
+		SerialNumber:   bbig.Enc(big.NewInt(1)),
+		Subject:        []openssl.NameAttribute{{Type: "CN", Value: name}},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       []string{"digitalSignature", "keyEncipherment", "keyAgreement"},
+		ExtKeyUsage:    []string{"emailProtection"},
+		EmailAddresses: []string{"user@example.com"},
+	}, pub, nil, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := openssl.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, priv
+}
+
+func TestSignCMS(t *testing.T) {
+	cert, priv := newCMSTestCert(t, "Signer", false)
+	data := []byte("signed document\r\nwith a second line\n")
+	for _, tt := range []struct {
+		name string
+		opts *openssl.CMSSignOptions
+	}{
+		{"Attached", nil},
+		{"Detached", &openssl.CMSSignOptions{Detached: true}},
+		{"SHA384", &openssl.CMSSignOptions{Hash: crypto.SHA384}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			der, err := openssl.SignCMS(data, cert, priv, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			detached := tt.opts != nil && tt.opts.Detached
+			var content []byte
+			if detached {
+				content = data
+			}
+			got, signers, err := openssl.VerifyCMS(der, content, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got content %q, want %q", got, data)
+			}
+			if len(signers) != 1 || !bytes.Equal(signers[0].Raw(), cert.Raw()) {
+				t.Errorf("got %d signers, want the signer certificate", len(signers))
+			}
+			if _, _, err := openssl.VerifyCMS(der, content, &openssl.VerifyOptions{Roots: []*openssl.Certificate{cert}, Email: "user@example.com"}); err != nil {
+				t.Errorf("signer rejected: %v", err)
+			}
+			if detached {
+				if _, _, err := openssl.VerifyCMS(der, []byte("other document"), nil); err == nil {
+					t.Error("signature verified for other content")
+				}
+				if _, _, err := openssl.VerifyCMS(der, nil, nil); err == nil {
+					t.Error("detached signature verified without content")
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyCMSUntrusted(t *testing.T) {
+	cert, priv := newCMSTestCert(t, "Signer", false)
+	other, _ := newCMSTestCert(t, "Other", false)
+	der, err := openssl.SignCMS([]byte("data"), cert, priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openssl.VerifyCMS(der, nil, &openssl.VerifyOptions{Roots: []*openssl.Certificate{other}}); err == nil {
+		t.Error("untrusted signer accepted")
+	}
+	if _, _, err := openssl.VerifyCMS(der, nil, &openssl.VerifyOptions{Roots: []*openssl.Certificate{cert}, Purpose: "sslserver"}); err == nil {
+		t.Error("signer accepted for the wrong purpose")
+	}
+	der[len(der)-1] ^= 0xff
+	if _, _, err := openssl.VerifyCMS(der, nil, nil); err == nil {
+		t.Error("corrupted signature verified")
+	}
+	if _, err := openssl.SignCMS([]byte("data"), other, priv, nil); err == nil {
+		t.Error("signed with a key that doesn't match the certificate")
+	}
+}
+
+func TestEncryptCMS(t *testing.T) {
+	rsaCert, rsaPriv := newCMSTestCert(t, "RSA recipient", true)
+	ecCert, ecPriv := newCMSTestCert(t, "EC recipient", false)
+	otherCert, otherPriv := newCMSTestCert(t, "Other", true)
+	data := []byte("enveloped secret")
+	for _, cipher := range []openssl.CMSCipher{0, openssl.CMSAES128CBC, openssl.CMSAES192CBC, openssl.CMSAES256CBC} {
+		der, err := openssl.EncryptCMS(data, []*openssl.Certificate{rsaCert, ecCert}, &openssl.CMSEncryptOptions{Cipher: cipher})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range []struct {
+			cert *openssl.Certificate
+			priv interface{}
+		}{{rsaCert, rsaPriv}, {ecCert, ecPriv}} {
+			got, err := openssl.DecryptCMS(der, r.cert, r.priv)
+			if err != nil {
+				t.Fatalf("cipher %d: %v", cipher, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("cipher %d: got %q, want %q", cipher, got, data)
+			}
+		}
+		if _, err := openssl.DecryptCMS(der, otherCert, otherPriv); err == nil {
+			t.Errorf("cipher %d: decrypted by a non-recipient", cipher)
+		}
+	}
+	if _, err := openssl.EncryptCMS(data, nil, nil); err == nil {
+		t.Error("encrypted without recipients")
+	}
+	if _, err := openssl.EncryptCMS(data, []*openssl.Certificate{rsaCert}, &openssl.CMSEncryptOptions{Cipher: 42}); err == nil {
+		t.Error("encrypted with an unknown cipher")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+// RevocationList is an X.509 certificate revocation list parsed by OpenSSL.
+// It can be passed to Certificate.Verify in VerifyOptions.CRLs.
+type RevocationList struct {
+	raw []byte
+	// _crl MUST NOT be accessed directly. Instead, use the withCRL method.
+	_crl C.GO_X509_CRL_PTR
+	closer
+}
+
+// ParseRevocationList parses der, a single ASN.1 DER encoded CRL.
+//
+// ParseRevocationList is not supported on OpenSSL 1.0.2.
+func ParseRevocationList(der []byte) (*RevocationList, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty CRL")
+	}
+	crl := C.go_openssl_d2i_X509_CRL_buf(base(der), C.long(len(der)))
+	if crl == nil {
+		return nil, newOpenSSLError("d2i_X509_CRL")
+	}
+	n := C.go_openssl_i2d_X509_CRL_buf(crl, nil)
+	if n <= 0 {
+		C.go_openssl_X509_CRL_free(crl)
+		return nil, newOpenSSLError("i2d_X509_CRL")
+	}
+	if int(n) != len(der) {
+		C.go_openssl_X509_CRL_free(crl)
+		return nil, errors.New("openssl: trailing data after CRL")
+	}
+	l := &RevocationList{raw: append([]byte(nil), der...), _crl: crl}
+	manage(l, (*RevocationList).finalize)
+	return l, nil
+}
+
+func (l *RevocationList) finalize() {
+	C.go_openssl_X509_CRL_free(l._crl)
+}
+
+// Close frees the native resources of l, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (l *RevocationList) Close() error {
+	return closeObject(l, l.finalize)
+}
+
+func (l *RevocationList) withCRL(f func(C.GO_X509_CRL_PTR) C.int) C.int {
+	// Because of the finalizer, any time _crl is passed to cgo, that call must
+	// be followed by a call to runtime.KeepAlive, to make sure l is not
+	// collected (and finalized) before the cgo call returns.
+	defer runtime.KeepAlive(l)
+	return f(l._crl)
+}
+
+// Raw returns the DER encoding of l. It must not be modified.
+func (l *RevocationList) Raw() []byte {
+	return l.raw
+}
+
+// Issuer returns the issuer name of l in the format described in Certificate.Subject.
+func (l *RevocationList) Issuer() (string, error) {
+	var name string
+	var err error
+	l.withCRL(func(crl C.GO_X509_CRL_PTR) C.int {
+		name, err = x509NameString(C.go_openssl_X509_CRL_get_issuer(crl))
+		return 1
+	})
+	return name, err
+}
+
+// ThisUpdate returns the issue date of l.
+func (l *RevocationList) ThisUpdate() (time.Time, error) {
+	return l.time(func(crl C.GO_X509_CRL_PTR) C.GO_ASN1_STRING_PTR {
+		return C.go_openssl_X509_CRL_get0_lastUpdate(crl)
+	})
+}
+
+// NextUpdate returns the date by which the next CRL will be issued,
+// or the zero time if l doesn't specify it.
+func (l *RevocationList) NextUpdate() (time.Time, error) {
+	return l.time(func(crl C.GO_X509_CRL_PTR) C.GO_ASN1_STRING_PTR {
+		return C.go_openssl_X509_CRL_get0_nextUpdate(crl)
+	})
+}
+
+func (l *RevocationList) time(get func(C.GO_X509_CRL_PTR) C.GO_ASN1_STRING_PTR) (time.Time, error) {
+	var t time.Time
+	var err error
+	l.withCRL(func(crl C.GO_X509_CRL_PTR) C.int {
+		if at := get(crl); at != nil {
+			t, err = asn1TimeToTime(at)
+		}
+		return 1
+	})
+	return t, err
+}
+
+// IsRevoked reports whether l revokes the certificate with the given
+// serial number. Entries of delta CRLs that remove a certificate from
+// the base CRL don't count as revocations.
+func (l *RevocationList) IsRevoked(serial BigInt) (bool, error) {
+	bn := bigToBN(serial)
+	if bn == nil {
+		return false, newOpenSSLError("BN_lebin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	ai := C.go_openssl_BN_to_ASN1_INTEGER(bn, nil)
+	if ai == nil {
+		return false, newOpenSSLError("BN_to_ASN1_INTEGER")
+	}
+	defer C.go_openssl_ASN1_INTEGER_free(ai)
+	// X509_CRL_get0_by_serial returns 2 for removeFromCRL entries.
+	return l.withCRL(func(crl C.GO_X509_CRL_PTR) C.int {
+		return C.go_openssl_X509_CRL_get0_by_serial(crl, nil, ai)
+	}) == 1, nil
+}
+
+// CheckSignatureFrom verifies that issuer issued l. The issuer name of
+// l must match the subject name of issuer, and the signature of l must
+// verify with the public key of issuer.
+func (l *RevocationList) CheckSignatureFrom(issuer *Certificate) error {
+	defer runtime.KeepAlive(issuer)
+	if l.withCRL(func(crl C.GO_X509_CRL_PTR) C.int {
+		return C.go_openssl_X509_NAME_cmp(C.go_openssl_X509_CRL_get_issuer(crl), C.go_openssl_X509_get_subject_name(issuer._x509))
+	}) != 0 {
+		return errors.New("openssl: CRL not issued by the certificate subject")
+	}
+	pkey := C.go_openssl_X509_get_pubkey(issuer._x509)
+	if pkey == nil {
+		return newOpenSSLError("X509_get_pubkey")
+	}
+	defer C.go_openssl_EVP_PKEY_free(pkey)
+	if l.withCRL(func(crl C.GO_X509_CRL_PTR) C.int {
+		return C.go_openssl_X509_CRL_verify(crl, pkey)
+	}) != 1 {
+		return newOpenSSLError("X509_CRL_verify")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// newTestCRL returns a CRL issued by issuer with key, valid around
+// verifyTestTime, revoking the given serial numbers.
+func newTestCRL(t *testing.T, issuer *x509.Certificate, key crypto.Signer, nextUpdate time.Time, revoked ...int64) *openssl.RevocationList {
+	t.Helper()
+	tmpl := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: verifyTestTime.AddDate(0, 0, -1),
+		NextUpdate: nextUpdate,
+	}
+	for _, serial := range revoked {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: verifyTestTime.AddDate(0, 0, -2),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, issuer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := openssl.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func TestRevocationList(t *testing.T) {
+	c := newVerifyTestChain(t)
+	nextUpdate := verifyTestTime.AddDate(0, 0, 7)
+	crl := newTestCRL(t, c.goIntermediate, c.intKey, nextUpdate, 3, 42)
+	if got, err := crl.Issuer(); err != nil || got != "CN=Intermediate" {
+		t.Errorf("Issuer() = %q, %v", got, err)
+	}
+	if got, err := crl.ThisUpdate(); err != nil || !got.Equal(verifyTestTime.AddDate(0, 0, -1)) {
+		t.Errorf("ThisUpdate() = %v, %v", got, err)
+	}
+	if got, err := crl.NextUpdate(); err != nil || !got.Equal(nextUpdate) {
+		t.Errorf("NextUpdate() = %v, %v, want %v", got, err, nextUpdate)
+	}
+	for _, tt := range []struct {
+		serial  int64
+		revoked bool
+	}{{3, true}, {42, true}, {4, false}} {
+		if got, err := crl.IsRevoked(bbig.Enc(big.NewInt(tt.serial))); err != nil || got != tt.revoked {
+			t.Errorf("IsRevoked(%d) = %v, %v, want %v", tt.serial, got, err, tt.revoked)
+		}
+	}
+	if err := crl.CheckSignatureFrom(c.intermediate); err != nil {
+		t.Errorf("CRL not issued by the intermediate: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(c.root); err == nil {
+		t.Error("CRL issued by the root")
+	}
+	parsed, err := openssl.ParseRevocationList(crl.Raw())
+	if err != nil || !bytes.Equal(parsed.Raw(), crl.Raw()) {
+		t.Errorf("Raw doesn't round trip: %v", err)
+	}
+}
+
+func TestParseRevocationListInvalid(t *testing.T) {
+	c := newVerifyTestChain(t)
+	der := newTestCRL(t, c.goRoot, c.rootKey, verifyTestTime.AddDate(0, 0, 7)).Raw()
+	if _, err := openssl.ParseRevocationList(nil); err == nil {
+		t.Error("empty CRL parsed")
+	}
+	if _, err := openssl.ParseRevocationList(der[:len(der)-1]); err == nil {
+		t.Error("truncated CRL parsed")
+	}
+	if _, err := openssl.ParseRevocationList(append(der[:len(der):len(der)], 0)); err == nil {
+		t.Error("CRL with trailing data parsed")
+	}
+}
+
+func TestCertificateVerifyRevocation(t *testing.T) {
+	c := newVerifyTestChain(t)
+	const (
+		errCRLNotFound = 3  // X509_V_ERR_UNABLE_TO_GET_CRL
+		errCRLExpired  = 12 // X509_V_ERR_CRL_HAS_EXPIRED
+		errRevoked     = 23 // X509_V_ERR_CERT_REVOKED
+	)
+	nextUpdate := verifyTestTime.AddDate(0, 0, 7)
+	intCRL := newTestCRL(t, c.goIntermediate, c.intKey, nextUpdate)
+	intCRLRevokingLeaf := newTestCRL(t, c.goIntermediate, c.intKey, nextUpdate, 3)
+	intCRLExpired := newTestCRL(t, c.goIntermediate, c.intKey, verifyTestTime.Add(-time.Hour))
+	rootCRL := newTestCRL(t, c.goRoot, c.rootKey, nextUpdate)
+	rootCRLRevokingInt := newTestCRL(t, c.goRoot, c.rootKey, nextUpdate, 2)
+	tests := []struct {
+		name  string
+		check openssl.RevocationCheck
+		crls  []*openssl.RevocationList
+		code  int // 0 if the verification succeeds
+		depth int
+	}{
+		{"None", openssl.RevocationCheckNone, []*openssl.RevocationList{intCRLRevokingLeaf}, 0, 0},
+		{"Leaf", openssl.RevocationCheckLeaf, []*openssl.RevocationList{intCRL}, 0, 0},
+		{"LeafNoCRL", openssl.RevocationCheckLeaf, nil, errCRLNotFound, 0},
+		{"LeafWrongIssuer", openssl.RevocationCheckLeaf, []*openssl.RevocationList{rootCRL}, errCRLNotFound, 0},
+		{"LeafRevoked", openssl.RevocationCheckLeaf, []*openssl.RevocationList{intCRLRevokingLeaf}, errRevoked, 0},
+		{"LeafExpiredCRL", openssl.RevocationCheckLeaf, []*openssl.RevocationList{intCRLExpired}, errCRLExpired, 0},
+		{"LeafIgnoresIntermediate", openssl.RevocationCheckLeaf, []*openssl.RevocationList{intCRL, rootCRLRevokingInt}, 0, 0},
+		{"Chain", openssl.RevocationCheckChain, []*openssl.RevocationList{intCRL, rootCRL}, 0, 0},
+		{"ChainNoRootCRL", openssl.RevocationCheckChain, []*openssl.RevocationList{intCRL}, errCRLNotFound, 1},
+		{"ChainIntermediateRevoked", openssl.RevocationCheckChain, []*openssl.RevocationList{intCRL, rootCRLRevokingInt}, errRevoked, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.leaf.Verify(openssl.VerifyOptions{
+				Roots:           []*openssl.Certificate{c.root},
+				Intermediates:   []*openssl.Certificate{c.intermediate},
+				CurrentTime:     verifyTestTime,
+				CRLs:            tt.crls,
+				RevocationCheck: tt.check,
+			})
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var verr *openssl.VerifyError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got error %v, want a *VerifyError", err)
+			}
+			if verr.Code != tt.code || verr.Depth != tt.depth {
+				t.Errorf("got code %d at depth %d (%v), want code %d at depth %d", verr.Code, verr.Depth, verr, tt.code, tt.depth)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"net"
+	"runtime"
+	"unsafe"
+)
+
+// CertificateRequestTemplate describes the PKCS #10 certificate
+// signing request created by CreateCertificateRequest.
+type CertificateRequestTemplate struct {
+	// Subject is the requested subject name, ordered
+	// as described in CertificateTemplate.Subject.
+	Subject []NameAttribute
+
+	// DNSNames, EmailAddresses and IPAddresses are the requested
+	// subject alternative names.
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+
+	// Hash is the hash function of the signature,
+	// as described in CertificateTemplate.Hash.
+	Hash crypto.Hash
+}
+
+// CreateCertificateRequest returns the DER encoding of a new PKCS #10
+// certificate signing request based on template, for the public key
+// of priv and signed by priv, which must be a *PrivateKeyRSA,
+// a *PrivateKeyECDSA or a *PKey. The subject alternative names are
+// requested with the extensionRequest attribute.
+//
+// CreateCertificateRequest is not supported on OpenSSL 1.0.2.
+func CreateCertificateRequest(template *CertificateRequestTemplate, priv interface{}) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	withKey := privateKeyWithKey(priv)
+	if withKey == nil {
+		return nil, errors.New("openssl: unsupported private key type")
+	}
+	req := C.go_openssl_X509_REQ_new()
+	if req == nil {
+		return nil, newOpenSSLError("X509_REQ_new")
+	}
+	defer C.go_openssl_X509_REQ_free(req)
+	if C.go_openssl_X509_REQ_set_version(req, 0) != 1 {
+		return nil, newOpenSSLError("X509_REQ_set_version")
+	}
+	subject, err := newX509Name(template.Subject)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_X509_NAME_free(subject)
+	if C.go_openssl_X509_REQ_set_subject_name(req, subject) != 1 {
+		return nil, newOpenSSLError("X509_REQ_set_subject_name")
+	}
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_X509_REQ_set_pubkey(req, pkey)
+	}) != 1 {
+		return nil, newOpenSSLError("X509_REQ_set_pubkey")
+	}
+	san, err := subjectAltNameConf(template.DNSNames, template.EmailAddresses, template.IPAddresses)
+	if err != nil {
+		return nil, err
+	}
+	if san != "" {
+		if err := addRequestExtension(req, x509ExtensionConf{C.GO_NID_subject_alt_name, san}); err != nil {
+			return nil, err
+		}
+	}
+	var signErr error
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		md, err := signatureMD(pkey, template.Hash)
+		if err != nil {
+			signErr = err
+			return 1
+		}
+		if C.go_openssl_X509_REQ_sign(req, pkey, md) <= 0 {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("X509_REQ_sign")
+	}
+	if signErr != nil {
+		return nil, signErr
+	}
+	n := C.go_openssl_i2d_X509_REQ_buf(req, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_X509_REQ")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_X509_REQ_buf(req, base(der)) != n {
+		return nil, newOpenSSLError("i2d_X509_REQ")
+	}
+	return der, nil
+}
+
+func addRequestExtension(req C.GO_X509_REQ_PTR, e x509ExtensionConf) error {
+	ext, err := newX509Extension(nil, nil, req, e)
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_X509_EXTENSION_free(ext)
+	st := C.go_openssl_OPENSSL_sk_new_null()
+	if st == nil {
+		return newOpenSSLError("OPENSSL_sk_new_null")
+	}
+	defer C.go_openssl_OPENSSL_sk_free(st)
+	if C.go_openssl_OPENSSL_sk_push(st, unsafe.Pointer(ext)) <= 0 {
+		return newOpenSSLError("OPENSSL_sk_push")
+	}
+	if C.go_openssl_X509_REQ_add_extensions(req, C.GO_STACK_OF_X509_EXTENSION_PTR(st)) != 1 {
+		return newOpenSSLError("X509_REQ_add_extensions")
+	}
+	return nil
+}
+
+// CertificateRequest is a PKCS #10 certificate signing request parsed by OpenSSL.
+type CertificateRequest struct {
+	raw []byte
+	// _req MUST NOT be accessed directly. Instead, use the withReq method.
+	_req C.GO_X509_REQ_PTR
+	closer
+}
+
+// ParseCertificateRequest parses der, a single ASN.1 DER encoded
+// certificate signing request.
+//
+// ParseCertificateRequest is not supported on OpenSSL 1.0.2.
+func ParseCertificateRequest(der []byte) (*CertificateRequest, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty certificate request")
+	}
+	req := C.go_openssl_d2i_X509_REQ_buf(base(der), C.long(len(der)))
+	if req == nil {
+		return nil, newOpenSSLError("d2i_X509_REQ")
+	}
+	if C.go_openssl_i2d_X509_REQ_buf(req, nil) != C.int(len(der)) {
+		C.go_openssl_X509_REQ_free(req)
+		return nil, errors.New("openssl: trailing data after certificate request")
+	}
+	r := &CertificateRequest{raw: append([]byte(nil), der...), _req: req}
+	manage(r, (*CertificateRequest).finalize)
+	return r, nil
+}
+
+func (r *CertificateRequest) finalize() {
+	C.go_openssl_X509_REQ_free(r._req)
+}
+
+// Close frees the native resources of r, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (r *CertificateRequest) Close() error {
+	return closeObject(r, r.finalize)
+}
+
+func (r *CertificateRequest) withReq(f func(C.GO_X509_REQ_PTR) C.int) C.int {
+	// Because of the finalizer, any time _req is passed to cgo, that call must
+	// be followed by a call to runtime.KeepAlive, to make sure r is not
+	// collected (and finalized) before the cgo call returns.
+	defer runtime.KeepAlive(r)
+	return f(r._req)
+}
+
+// Raw returns the DER encoding of r. It must not be modified.
+func (r *CertificateRequest) Raw() []byte {
+	return r.raw
+}
+
+// Subject returns the requested subject name
+// in the format described in Certificate.Subject.
+func (r *CertificateRequest) Subject() (string, error) {
+	var name string
+	var err error
+	r.withReq(func(req C.GO_X509_REQ_PTR) C.int {
+		name, err = x509NameString(C.go_openssl_X509_REQ_get_subject_name(req))
+		return 1
+	})
+	return name, err
+}
+
+// PublicKey returns the public key of r as described in Certificate.PublicKey.
+func (r *CertificateRequest) PublicKey() (interface{}, error) {
+	var pkey C.GO_EVP_PKEY_PTR
+	if r.withReq(func(req C.GO_X509_REQ_PTR) C.int {
+		pkey = C.go_openssl_X509_REQ_get_pubkey(req)
+		if pkey == nil {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("X509_REQ_get_pubkey")
+	}
+	return publicKeyFromPKey(pkey)
+}
+
+// CheckSignature verifies that the signature of r was made
+// by the private key of the requested public key.
+func (r *CertificateRequest) CheckSignature() error {
+	if r.withReq(func(req C.GO_X509_REQ_PTR) C.int {
+		pkey := C.go_openssl_X509_REQ_get_pubkey(req)
+		if pkey == nil {
+			return 0
+		}
+		defer C.go_openssl_EVP_PKEY_free(pkey)
+		return C.go_openssl_X509_REQ_verify(req, pkey)
+	}) != 1 {
+		return newOpenSSLError("X509_REQ_verify")
+	}
+	return nil
+}
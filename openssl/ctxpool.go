@@ -0,0 +1,179 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Allocating and freeing an EVP_MD_CTX, an EVP_CIPHER_CTX or an HMAC
+// context takes two cgo calls and a malloc and free pair, a measurable
+// part of the cost of hashing or encrypting small inputs. The contexts
+// of the hashes, ciphers and MACs freed by Close or by their finalizer
+// are therefore reset, which also cleanses the keys they hold, and kept
+// in pools for the next ones of the same kind.
+//
+// sync.Pool drops its entries at garbage collection, so, as the child
+// DRBGs of RandReader, pooled contexts are owned by a pooledCtx freeing
+// them from a finalizer.
+
+// A ctxPool holds native contexts of a single kind.
+type ctxPool struct {
+	pool  sync.Pool
+	alloc func() unsafe.Pointer
+	// reset returns the context to its state after alloc,
+	// or reports false if it can't be reused.
+	reset func(unsafe.Pointer) bool
+	free  func(unsafe.Pointer)
+}
+
+// A pooledCtx owns a native context allocated by pool.
+type pooledCtx struct {
+	ptr  unsafe.Pointer
+	pool *ctxPool
+	// tag identifies the objects ptr is bound to, if any,
+	// see getTagged.
+	tag unsafe.Pointer
+}
+
+// get returns a context from p, or a new one. It returns nil if
+// allocating a context fails. The context must be returned with put.
+func (p *ctxPool) get() *pooledCtx {
+	if c, _ := p.pool.Get().(*pooledCtx); c != nil {
+		return c
+	}
+	return p.newCtx()
+}
+
+// getTagged is like get, but only returns a pooled context with tag,
+// which identifies the object the contexts of p are bound to, such as
+// the EVP_MAC of an EVP_MAC_CTX. Pooled contexts with another tag are
+// freed. tag must stay valid until the returned context is freed.
+func (p *ctxPool) getTagged(tag unsafe.Pointer) *pooledCtx {
+	for {
+		c, _ := p.pool.Get().(*pooledCtx)
+		if c == nil {
+			return nil
+		}
+		if c.tag == tag {
+			return c
+		}
+		c.free()
+	}
+}
+
+func (p *ctxPool) newCtx() *pooledCtx {
+	ptr := p.alloc()
+	if ptr == nil {
+		return nil
+	}
+	return p.wrap(ptr, nil)
+}
+
+// wrap returns a pooledCtx owning ptr, a context allocated
+// by the caller, which returns to p once put.
+func (p *ctxPool) wrap(ptr, tag unsafe.Pointer) *pooledCtx {
+	c := &pooledCtx{ptr: ptr, pool: p, tag: tag}
+	runtime.SetFinalizer(c, (*pooledCtx).finalize)
+	return c
+}
+
+// put resets c and returns it to its pool. c can't be used afterwards.
+func (c *pooledCtx) put() {
+	if c == nil {
+		return
+	}
+	if !c.pool.reset(c.ptr) {
+		C.go_openssl_ERR_clear_error()
+		c.free()
+		return
+	}
+	c.pool.pool.Put(c)
+}
+
+// free frees c right away, rather than when it is collected.
+func (c *pooledCtx) free() {
+	runtime.SetFinalizer(c, nil)
+	c.finalize()
+}
+
+func (c *pooledCtx) finalize() {
+	c.pool.free(c.ptr)
+}
+
+var mdCtxPool = ctxPool{
+	alloc: func() unsafe.Pointer {
+		return unsafe.Pointer(C.go_openssl_EVP_MD_CTX_new())
+	},
+	reset: func(ctx unsafe.Pointer) bool {
+		return C.go_openssl_EVP_MD_CTX_reset(C.GO_EVP_MD_CTX_PTR(ctx)) == 1
+	},
+	free: func(ctx unsafe.Pointer) {
+		C.go_openssl_EVP_MD_CTX_free(C.GO_EVP_MD_CTX_PTR(ctx))
+	},
+}
+
+var cipherCtxPool = ctxPool{
+	alloc: func() unsafe.Pointer {
+		return unsafe.Pointer(C.go_openssl_EVP_CIPHER_CTX_new())
+	},
+	reset: func(ctx unsafe.Pointer) bool {
+		return C.go_openssl_EVP_CIPHER_CTX_reset(C.GO_EVP_CIPHER_CTX_PTR(ctx)) == 1
+	},
+	free: func(ctx unsafe.Pointer) {
+		C.go_openssl_EVP_CIPHER_CTX_free(C.GO_EVP_CIPHER_CTX_PTR(ctx))
+	},
+}
+
+var hmac1CtxPool = ctxPool{
+	alloc: func() unsafe.Pointer {
+		return unsafe.Pointer(hmac1CtxNew())
+	},
+	reset: func(ctx unsafe.Pointer) bool {
+		hmac1CtxReset(C.GO_HMAC_CTX_PTR(ctx))
+		return true
+	},
+	free: func(ctx unsafe.Pointer) {
+		hmac1CtxFree(C.GO_HMAC_CTX_PTR(ctx))
+	},
+}
+
+// macCtxPool holds the EVP_MAC_CTX objects of the HMAC of the default
+// library context, tagged with their EVP_MAC. The EVP_MAC changes with
+// the default properties, for example when FIPS mode is toggled.
+var macCtxPool = ctxPool{
+	// The contexts are allocated by newHMAC3, see getTagged.
+	reset: func(ctx unsafe.Pointer) bool {
+		// There is no EVP_MAC_CTX_reset, overwrite the key
+		// with an all-zero one instead, keeping the digest.
+		var zero [C.GO_EVP_MAX_MD_SIZE]byte
+		return C.go_openssl_EVP_MAC_init(C.GO_EVP_MAC_CTX_PTR(ctx), base(zero[:]), C.size_t(len(zero)), nil) == 1
+	},
+	free: func(ctx unsafe.Pointer) {
+		C.go_openssl_EVP_MAC_CTX_free(C.GO_EVP_MAC_CTX_PTR(ctx))
+	},
+}
+
+func getMDCtx() (*pooledCtx, C.GO_EVP_MD_CTX_PTR) {
+	c := mdCtxPool.get()
+	if c == nil {
+		return nil, nil
+	}
+	return c, C.GO_EVP_MD_CTX_PTR(c.ptr)
+}
+
+func getCipherCtx() (*pooledCtx, C.GO_EVP_CIPHER_CTX_PTR) {
+	c := cipherCtxPool.get()
+	if c == nil {
+		return nil, nil
+	}
+	return c, C.GO_EVP_CIPHER_CTX_PTR(c.ptr)
+}
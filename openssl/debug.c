@@ -0,0 +1,23 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// Defined in debug.go.
+extern void goTraceCallback(int category, char *buf, size_t count);
+
+static size_t
+trace_cb(const char *buf, size_t count, int category, int cmd, void *data)
+{
+    if (cmd == GO_OSSL_TRACE_CTRL_WRITE)
+        goTraceCallback(category, (char *)buf, count);
+    return count;
+}
+
+int go_openssl_set_trace_callback(int category, int enable)
+{
+    return go_openssl_OSSL_trace_set_callback(category, enable ? trace_cb : NULL, NULL);
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Logger receives the debug output enabled by SetDebugLogger.
+// *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// debugState is stored in debugLogger, which can't hold a nil Logger.
+type debugState struct {
+	l Logger
+}
+
+var (
+	debugMu sync.Mutex
+	// debugEnabled is non-zero when a Logger is set.
+	debugEnabled int32
+	// debugLogger holds the debugState set with SetDebugLogger.
+	debugLogger atomic.Value
+	// traceCategories are the OSSL_trace categories with
+	// a callback installed. debugMu must be held.
+	traceCategories []C.int
+)
+
+//export goTraceCallback
+func goTraceCallback(category C.int, buf *C.char, count C.size_t) {
+	name := "ALL"
+	if s := C.go_openssl_OSSL_trace_get_category_name(category); s != nil {
+		name = C.GoString(s)
+	}
+	msg := strings.TrimSuffix(C.GoStringN(buf, C.int(count)), "\n")
+	debugf("openssl: trace %s: %s", name, msg)
+}
+
+// debugf logs to the Logger set with SetDebugLogger, if any.
+func debugf(format string, v ...interface{}) {
+	if s, _ := debugLogger.Load().(debugState); s.l != nil {
+		s.l.Printf(format, v...)
+	}
+}
+
+// SetDebugLogger enables debug mode, in which the key operations of
+// this package, such as EVP_PKEY_sign and EVP_PKEY_decrypt, are logged
+// to l with their duration. The output of the OpenSSL trace categories
+// traceCategories, such as "PROVIDER", "DECODER" or "ALL", is logged to
+// l as well. A nil l disables debug mode and tracing.
+//
+// Tracing is only supported on OpenSSL 3 built with enable-trace,
+// which most distributions don't do, and SetDebugLogger returns an
+// error if a category can't be traced.
+//
+// l may be called concurrently from any goroutine or OpenSSL thread
+// and must not call back into this package.
+func SetDebugLogger(l Logger, traceCategories ...string) error {
+	var cats []C.int
+	if l != nil {
+		for _, name := range traceCategories {
+			if vMajor != 3 {
+				return errUnsuportedVersion()
+			}
+			cname := C.CString(name)
+			cat := C.go_openssl_OSSL_trace_get_category_num(cname)
+			C.free(unsafe.Pointer(cname))
+			if cat < 0 {
+				return errors.New("openssl: trace category " + name + " not supported, OpenSSL may be built without enable-trace")
+			}
+			cats = append(cats, cat)
+		}
+	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	setTraceCallbacks(nil)
+	debugLogger.Store(debugState{l})
+	if l == nil {
+		atomic.StoreInt32(&debugEnabled, 0)
+		return nil
+	}
+	atomic.StoreInt32(&debugEnabled, 1)
+	return setTraceCallbacks(cats)
+}
+
+// setTraceCallbacks installs the trace callback for the categories
+// cats, removing it from the categories it was installed for.
+// debugMu must be held.
+func setTraceCallbacks(cats []C.int) error {
+	for _, cat := range traceCategories {
+		C.go_openssl_set_trace_callback(cat, 0)
+	}
+	traceCategories = nil
+	for _, cat := range cats {
+		if C.go_openssl_set_trace_callback(cat, 1) != 1 {
+			return errors.New("openssl: tracing not supported, OpenSSL may be built without enable-trace")
+		}
+		traceCategories = append(traceCategories, cat)
+	}
+	return nil
+}
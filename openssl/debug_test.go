@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *testLogger) contains(s string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDebugLogger(t *testing.T) {
+	priv, _ := newRSAKey(t, 2048)
+	l := new(testLogger)
+	if err := openssl.SetDebugLogger(l); err != nil {
+		t.Fatal(err)
+	}
+	defer openssl.SetDebugLogger(nil)
+	hashed := openssl.SHA256([]byte("message"))
+	if _, err := openssl.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:]); err != nil {
+		t.Fatal(err)
+	}
+	if !l.contains("EVP_PKEY_sign took") {
+		t.Errorf("no EVP_PKEY_sign log line in %q", l.lines)
+	}
+	if err := openssl.SetDebugLogger(nil); err != nil {
+		t.Fatal(err)
+	}
+	n := len(l.lines)
+	if _, err := openssl.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:]); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.lines) != n {
+		t.Errorf("operation logged after SetDebugLogger(nil): %q", l.lines[n:])
+	}
+	if err := openssl.SetDebugLogger(l, "NO-SUCH-CATEGORY"); err == nil {
+		t.Error("SetDebugLogger with an unknown trace category succeeded")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// DRBGConfig selects and configures the deterministic random bit generator
+// OpenSSL uses to serve RandReader, key generation and signing nonces.
+type DRBGConfig struct {
+	// Type is the EVP_RAND algorithm name of the DRBG, such as
+	// "CTR-DRBG", "HASH-DRBG" or "HMAC-DRBG".
+	// If empty, the DRBG type is not changed.
+	Type string
+	// Cipher is the block cipher used by CTR-DRBG.
+	// "AES-256-CTR" is used if empty.
+	Cipher string
+	// Digest is the hash function used by HASH-DRBG and HMAC-DRBG.
+	// "SHA256" is used if empty.
+	Digest string
+	// Properties is the property query used to fetch the DRBG,
+	// for example "provider=fips".
+	Properties string
+	// ReseedRequests is the number of generate requests after which
+	// the DRBG is reseeded. If zero, the OpenSSL default is kept.
+	ReseedRequests uint32
+	// ReseedInterval is the maximum time between reseeds of the DRBG.
+	// If zero, the OpenSSL default is kept.
+	ReseedInterval time.Duration
+}
+
+var errDRBGAlreadyInstantiated = errors.New("openssl: the DRBG must be configured before any random number is generated")
+
+// ConfigureDRBG selects and configures the DRBG used by the default
+// OpenSSL library context.
+//
+// Selecting the DRBG type is only possible before OpenSSL
+// generates its first random number, so it should be done right after Init.
+// The reseed settings are applied to the primary DRBG,
+// which causes the per-thread public and private DRBGs
+// to be reseeded from it whenever it is reseeded.
+//
+// ConfigureDRBG is only supported on OpenSSL 3.
+func ConfigureDRBG(cfg DRBGConfig) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	if cfg.ReseedInterval < 0 {
+		return errors.New("openssl: negative DRBG reseed interval")
+	}
+	if cfg.Type != "" {
+		var cipher, digest string
+		switch cfg.Type {
+		case "CTR-DRBG":
+			cipher = cfg.Cipher
+			if cipher == "" {
+				cipher = "AES-256-CTR"
+			}
+		case "HASH-DRBG", "HMAC-DRBG":
+			digest = cfg.Digest
+			if digest == "" {
+				digest = "SHA256"
+			}
+		default:
+			cipher, digest = cfg.Cipher, cfg.Digest
+		}
+		cdrbg := C.CString(cfg.Type)
+		defer C.free(unsafe.Pointer(cdrbg))
+		cprops, ccipher, cdigest := optCString(cfg.Properties), optCString(cipher), optCString(digest)
+		defer C.free(unsafe.Pointer(cprops))
+		defer C.free(unsafe.Pointer(ccipher))
+		defer C.free(unsafe.Pointer(cdigest))
+		if C.go_openssl_RAND_set_DRBG_type(nil, cdrbg, cprops, ccipher, cdigest) != 1 {
+			// RAND_set_DRBG_type only fails if the DRBG already exists.
+			C.go_openssl_ERR_clear_error()
+			return errDRBGAlreadyInstantiated
+		}
+	}
+	primary := C.go_openssl_RAND_get0_primary(nil)
+	if primary == nil {
+		return newOpenSSLError("RAND_get0_primary")
+	}
+	if cfg.Type != "" {
+		// Make sure the selected DRBG is the one that got instantiated.
+		name := C.GoString(C.go_openssl_EVP_RAND_get0_name(C.go_openssl_EVP_RAND_CTX_get0_rand(primary)))
+		if name != cfg.Type {
+			return errors.New("openssl: DRBG type is " + name + " instead of " + cfg.Type)
+		}
+	}
+	if cfg.ReseedRequests == 0 && cfg.ReseedInterval == 0 {
+		return nil
+	}
+	bld, err := newParamBuilder()
+	if err != nil {
+		return err
+	}
+	defer bld.free()
+	if cfg.ReseedRequests != 0 {
+		bld.addUint64("reseed_requests", uint64(cfg.ReseedRequests))
+	}
+	if cfg.ReseedInterval != 0 {
+		secs := int64((cfg.ReseedInterval + time.Second - 1) / time.Second)
+		bld.addInt64("reseed_time_interval", secs)
+	}
+	params, err := bld.build()
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	if C.go_openssl_EVP_RAND_CTX_set_params(primary, params) != 1 {
+		return newOpenSSLError("EVP_RAND_CTX_set_params")
+	}
+	return nil
+}
+
+// DRBGInfo describes the DRBG used by the default OpenSSL library context.
+type DRBGInfo struct {
+	// Type is the EVP_RAND algorithm name of the primary DRBG.
+	Type string
+	// Strength is the security strength of the primary DRBG in bits.
+	Strength int
+	// EntropySource is the seed source selected with SetEntropySource,
+	// or EntropySourceDefault if none was selected.
+	// Seed sources configured in openssl.cnf are not reflected here.
+	EntropySource string
+}
+
+// CurrentDRBG returns information about the primary DRBG
+// of the default OpenSSL library context.
+//
+// CurrentDRBG is only supported on OpenSSL 3.
+func CurrentDRBG() (DRBGInfo, error) {
+	if vMajor != 3 {
+		return DRBGInfo{}, errUnsuportedVersion()
+	}
+	primary := C.go_openssl_RAND_get0_primary(nil)
+	if primary == nil {
+		return DRBGInfo{}, newOpenSSLError("RAND_get0_primary")
+	}
+	entropySourceMu.Lock()
+	source := entropySource
+	entropySourceMu.Unlock()
+	return DRBGInfo{
+		Type:          C.GoString(C.go_openssl_EVP_RAND_get0_name(C.go_openssl_EVP_RAND_CTX_get0_rand(primary))),
+		Strength:      int(C.go_openssl_EVP_RAND_get_strength(primary)),
+		EntropySource: source,
+	}, nil
+}
+
+// Entropy sources usable with SetEntropySource.
+const (
+	// EntropySourceDefault is the operating system entropy source,
+	// such as getrandom(2) on Linux.
+	EntropySourceDefault = "SEED-SRC"
+	// EntropySourceJitter is the CPU jitter entropy source provided by
+	// the FIPS provider, available starting with OpenSSL 3.2 when built
+	// with enable-jitter.
+	EntropySourceJitter = "JITTER"
+)
+
+var (
+	entropySourceMu sync.Mutex
+	entropySource   = EntropySourceDefault
+)
+
+// SetEntropySource selects the EVP_RAND seed source, fetched by name
+// using the property query properties, that seeds the primary DRBG.
+// It is intended for environments, such as air-gapped hosts and
+// virtual machines, whose policy mandates a specific entropy source.
+//
+// Like the DRBG type, the entropy source can only be selected before
+// OpenSSL generates its first random number.
+// An error is returned if no provider implements the source.
+//
+// SetEntropySource is only supported on OpenSSL 3.
+func SetEntropySource(name, properties string) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cprops := optCString(properties)
+	defer C.free(unsafe.Pointer(cprops))
+	// RAND_set_seed_source_type only records the name, so check
+	// that the source exists to fail now rather than on first use.
+	rand := C.go_openssl_EVP_RAND_fetch(nil, cname, cprops)
+	if rand == nil {
+		return newOpenSSLError("EVP_RAND_fetch(" + name + ")")
+	}
+	C.go_openssl_EVP_RAND_free(rand)
+	entropySourceMu.Lock()
+	defer entropySourceMu.Unlock()
+	if C.go_openssl_RAND_set_seed_source_type(nil, cname, cprops) != 1 {
+		C.go_openssl_ERR_clear_error()
+		return errDRBGAlreadyInstantiated
+	}
+	entropySource = name
+	return nil
+}
+
+// optCString is like C.CString but returns nil for the empty string.
+func optCString(s string) *C.char {
+	if s == "" {
+		return nil
+	}
+	return C.CString(s)
+}
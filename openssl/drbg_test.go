@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// inFreshProcess reports whether the calling test is running in a
+// child process started for it. Otherwise it runs the test again in
+// a new process, in which OpenSSL hasn't generated any random number yet,
+// and reports false.
+func inFreshProcess(t *testing.T) bool {
+	if os.Getenv("GO_OPENSSL_TEST_FRESH") == t.Name() {
+		return true
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), "GO_OPENSSL_TEST_FRESH="+t.Name())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	return false
+}
+
+func TestConfigureDRBG(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("DRBG configuration is only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	err := ConfigureDRBG(DRBGConfig{
+		Type:           "HMAC-DRBG",
+		Digest:         "SHA512",
+		ReseedRequests: 1024,
+		ReseedInterval: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := CurrentDRBG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Type != "HMAC-DRBG" {
+		t.Errorf("got DRBG %q, want HMAC-DRBG", info.Type)
+	}
+	if info.Strength < 256 {
+		t.Errorf("got strength %d, want at least 256", info.Strength)
+	}
+	if _, err := Read(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureDRBG(DRBGConfig{Type: "CTR-DRBG"}); err != errDRBGAlreadyInstantiated {
+		t.Errorf("got %v, want %v", err, errDRBGAlreadyInstantiated)
+	}
+}
+
+func TestSetEntropySource(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("entropy source selection is only supported on OpenSSL 3")
+	}
+	if err := SetEntropySource("NOT-A-SEED-SOURCE", ""); err == nil {
+		t.Error("expected error for unknown entropy source")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := SetEntropySource(EntropySourceDefault, "provider=default"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := CurrentDRBG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.EntropySource != EntropySourceDefault {
+		t.Errorf("got entropy source %q, want %q", info.EntropySource, EntropySourceDefault)
+	}
+	if _, err := Read(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetEntropySource(EntropySourceDefault, ""); err != errDRBGAlreadyInstantiated {
+		t.Errorf("got %v, want %v", err, errDRBGAlreadyInstantiated)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (linux && !android && !openssl_static) || android || darwin || windows
+// +build linux,!android,!openssl_static android darwin windows
+
+package openssl
+
+import "unsafe"
+
+// staticLibrary reports that libcrypto is loaded by Init,
+// see the openssl_static build tag.
+func staticLibrary() (unsafe.Pointer, bool) {
+	return nil, false
+}
@@ -1,45 +1,56 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 // #include "goopenssl.h"
 import "C"
-import (
-	"errors"
-	"runtime"
-)
+import "errors"
 
 type PublicKeyECDH struct {
-	_pkey C.GO_EVP_PKEY_PTR
 	bytes []byte
 
 	// priv is only set when PublicKeyECDH is derived from a private key,
-	// in which case priv's finalizer is responsible for freeing _pkey.
-	// This ensures priv is not finalized while the public key is alive,
-	// which could cause use-after-free and double-free behavior.
+	// in which case the public key shares the handle of priv, which is
+	// responsible for freeing the EVP_PKEY. This ensures priv is not
+	// finalized while the public key is alive, which would close the
+	// public key as well.
 	//
 	// We could avoid this altogether if using EVP_PKEY_up_ref
 	// when instantiating a derived public key, unfortunately
 	// it is not available on OpenSSL 1.0.2.
 	priv *PrivateKeyECDH
+	closer
 }
 
 func (k *PublicKeyECDH) finalize() {
 	if k.priv == nil {
-		C.go_openssl_EVP_PKEY_free(k._pkey)
+		k.freeHandle()
 	}
 }
 
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PublicKeyECDH) Close() error {
+	return closeObject(k, k.finalize)
+}
+
 type PrivateKeyECDH struct {
-	_pkey C.GO_EVP_PKEY_PTR
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
 }
 
 func (k *PrivateKeyECDH) finalize() {
-	C.go_openssl_EVP_PKEY_free(k._pkey)
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PrivateKeyECDH) Close() error {
+	return closeObject(k, k.finalize)
 }
 
 func NewPublicKeyECDH(curve string, bytes []byte) (*PublicKeyECDH, error) {
@@ -70,6 +81,7 @@ func NewPublicKeyECDH(curve string, bytes []byte) (*PublicKeyECDH, error) {
 		}
 		defer C.go_openssl_EC_POINT_free(pt)
 		if C.go_openssl_EC_POINT_oct2point(group, pt, base(bytes), C.size_t(len(bytes)), nil) != 1 {
+			C.go_openssl_ERR_clear_error()
 			return nil, errors.New("point not on curve")
 		}
 		if C.go_openssl_EC_KEY_set_public_key(key, pt) != 1 {
@@ -84,23 +96,26 @@ func NewPublicKeyECDH(curve string, bytes []byte) (*PublicKeyECDH, error) {
 	if err != nil {
 		return nil, err
 	}
-	k = &PublicKeyECDH{pkey, append([]byte(nil), bytes...), nil}
-	runtime.SetFinalizer(k, (*PublicKeyECDH).finalize)
+	k = &PublicKeyECDH{closer: pkeyHandle(pkey), bytes: append([]byte(nil), bytes...)}
+	manage(k, (*PublicKeyECDH).finalize)
 	return k, nil
 }
 
 func (k *PublicKeyECDH) Bytes() []byte { return k.bytes }
 
 func NewPrivateKeyECDH(curve string, bytes []byte) (*PrivateKeyECDH, error) {
+	if err := checkStrictCurve(curve); err != nil {
+		return nil, err
+	}
 	nid, err := curveNID(curve)
 	if err != nil {
 		return nil, err
 	}
-	b := bytesToBN(bytes)
+	b := secretBytesToBN(bytes)
 	if b == nil {
 		return nil, newOpenSSLError("BN_bin2bn")
 	}
-	defer C.go_openssl_BN_free(b)
+	defer C.go_openssl_BN_clear_free(b)
 	key := C.go_openssl_EC_KEY_new_by_curve_name(nid)
 	if key == nil {
 		return nil, newOpenSSLError("EC_KEY_new_by_curve_name")
@@ -118,14 +133,16 @@ func NewPrivateKeyECDH(curve string, bytes []byte) (*PrivateKeyECDH, error) {
 	if err != nil {
 		return nil, err
 	}
-	k := &PrivateKeyECDH{pkey}
-	runtime.SetFinalizer(k, (*PrivateKeyECDH).finalize)
+	auditPKey(AuditImport, pkey)
+	k := &PrivateKeyECDH{closer: pkeyHandle(pkey)}
+	manage(k, (*PrivateKeyECDH).finalize)
 	return k, nil
 }
 
 func (k *PrivateKeyECDH) PublicKey() (*PublicKeyECDH, error) {
-	defer runtime.KeepAlive(k)
-	key := C.go_openssl_EVP_PKEY_get1_EC_KEY(k._pkey)
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
 	if key == nil {
 		return nil, newOpenSSLError("EVP_PKEY_get1_EC_KEY")
 	}
@@ -161,16 +178,17 @@ func (k *PrivateKeyECDH) PublicKey() (*PublicKeyECDH, error) {
 	if int(n) != len(bytes) {
 		return nil, newOpenSSLError("EC_POINT_point2oct")
 	}
-	pub := &PublicKeyECDH{k._pkey, bytes, k}
-	// Note: Same as in NewPublicKeyECDH regarding finalizer and KeepAlive.
-	runtime.SetFinalizer(pub, (*PublicKeyECDH).finalize)
+	pub := &PublicKeyECDH{closer: closer{h: k.h}, bytes: bytes, priv: k}
+	manage(pub, (*PublicKeyECDH).finalize)
 	return pub, nil
 }
 
 func ECDH(priv *PrivateKeyECDH, pub *PublicKeyECDH) ([]byte, error) {
-	defer runtime.KeepAlive(priv)
-	defer runtime.KeepAlive(pub)
-	ctx := C.go_openssl_EVP_PKEY_CTX_new(priv._pkey, nil)
+	privKey := C.GO_EVP_PKEY_PTR(priv.pin())
+	defer priv.unpin()
+	pubKey := C.GO_EVP_PKEY_PTR(pub.pin())
+	defer pub.unpin()
+	ctx := C.go_openssl_EVP_PKEY_CTX_new(privKey, nil)
 	if ctx == nil {
 		return nil, newOpenSSLError("EVP_PKEY_CTX_new")
 	}
@@ -178,7 +196,7 @@ func ECDH(priv *PrivateKeyECDH, pub *PublicKeyECDH) ([]byte, error) {
 	if C.go_openssl_EVP_PKEY_derive_init(ctx) != 1 {
 		return nil, newOpenSSLError("EVP_PKEY_derive_init")
 	}
-	if C.go_openssl_EVP_PKEY_derive_set_peer(ctx, pub._pkey) != 1 {
+	if C.go_openssl_EVP_PKEY_derive_set_peer(ctx, pubKey) != 1 {
 		return nil, newOpenSSLError("EVP_PKEY_derive_set_peer")
 	}
 	var outLen C.size_t
@@ -217,7 +235,7 @@ func GenerateKeyECDH(curve string) (*PrivateKeyECDH, []byte, error) {
 	if C.go_openssl_BN_bn2binpad(b, base(out), C.int(len(out))) == 0 {
 		return nil, nil, newOpenSSLError("BN_bn2binpad")
 	}
-	k = &PrivateKeyECDH{pkey}
-	runtime.SetFinalizer(k, (*PrivateKeyECDH).finalize)
+	k = &PrivateKeyECDH{closer: pkeyHandle(pkey)}
+	manage(k, (*PrivateKeyECDH).finalize)
 	return k, out, nil
 }
@@ -1,44 +1,59 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 // #include "goopenssl.h"
 import "C"
 import (
+	"crypto"
 	"errors"
-	"runtime"
+	"unsafe"
 )
 
 type PrivateKeyECDSA struct {
-	// _pkey MUST NOT be accessed directly. Instead, use the withKey method.
-	_pkey C.GO_EVP_PKEY_PTR
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
 }
 
 func (k *PrivateKeyECDSA) finalize() {
-	C.go_openssl_EVP_PKEY_free(k._pkey)
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PrivateKeyECDSA) Close() error {
+	return closeObject(k, k.finalize)
 }
 
 func (k *PrivateKeyECDSA) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
-	defer runtime.KeepAlive(k)
-	return f(k._pkey)
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
 }
 
 type PublicKeyECDSA struct {
-	// _pkey MUST NOT be accessed directly. Instead, use the withKey method.
-	_pkey C.GO_EVP_PKEY_PTR
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
 }
 
 func (k *PublicKeyECDSA) finalize() {
-	C.go_openssl_EVP_PKEY_free(k._pkey)
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PublicKeyECDSA) Close() error {
+	return closeObject(k, k.finalize)
 }
 
 func (k *PublicKeyECDSA) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
-	defer runtime.KeepAlive(k)
-	return f(k._pkey)
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
 }
 
 var errUnknownCurve = errors.New("openssl: unknown elliptic curve")
@@ -58,21 +73,47 @@ func curveNID(curve string) (C.int, error) {
 	return 0, errUnknownCurve
 }
 
+// curveName is the inverse of curveNID.
+func curveName(nid C.int) (string, error) {
+	switch nid {
+	case C.GO_NID_secp224r1:
+		return "P-224", nil
+	case C.GO_NID_X9_62_prime256v1:
+		return "P-256", nil
+	case C.GO_NID_secp384r1:
+		return "P-384", nil
+	case C.GO_NID_secp521r1:
+		return "P-521", nil
+	}
+	return "", errUnknownCurve
+}
+
 func NewPublicKeyECDSA(curve string, X, Y BigInt) (*PublicKeyECDSA, error) {
 	pkey, err := newECKey(curve, X, Y, nil)
 	if err != nil {
 		return nil, err
 	}
-	k := &PublicKeyECDSA{_pkey: pkey}
-	// Note: Because of the finalizer, any time k.key is passed to cgo,
-	// that call must be followed by a call to runtime.KeepAlive(k),
-	// to make sure k is not collected (and finalized) before the cgo
-	// call returns.
-	runtime.SetFinalizer(k, (*PublicKeyECDSA).finalize)
+	k := &PublicKeyECDSA{closer: pkeyHandle(pkey)}
+	manage(k, (*PublicKeyECDSA).finalize)
 	return k, nil
 }
 
 func newECKey(curve string, X, Y, D BigInt) (C.GO_EVP_PKEY_PTR, error) {
+	key, err := newRawECKey(curve, X, Y, D)
+	if err != nil {
+		return nil, err
+	}
+	pkey, err := newEVPPKEY(key)
+	if err != nil {
+		C.go_openssl_EC_KEY_free(key)
+		return nil, err
+	}
+	return pkey, nil
+}
+
+// newRawECKey returns the EC_KEY with the public point (X, Y) on curve,
+// and the private scalar D if not nil. The caller must free it.
+func newRawECKey(curve string, X, Y, D BigInt) (C.GO_EC_KEY_PTR, error) {
 	nid, err := curveNID(curve)
 	if err != nil {
 		return nil, err
@@ -86,12 +127,12 @@ func newECKey(curve string, X, Y, D BigInt) (C.GO_EVP_PKEY_PTR, error) {
 			C.go_openssl_BN_free(by)
 		}
 		if bd != nil {
-			C.go_openssl_BN_free(bd)
+			C.go_openssl_BN_clear_free(bd)
 		}
 	}()
 	bx = bigToBN(X)
 	by = bigToBN(Y)
-	bd = bigToBN(D)
+	bd = secretBigToBN(D)
 	if bx == nil || by == nil || (D != nil && bd == nil) {
 		return nil, newOpenSSLError("BN_lebin2bn failed")
 	}
@@ -99,36 +140,28 @@ func newECKey(curve string, X, Y, D BigInt) (C.GO_EVP_PKEY_PTR, error) {
 	if key == nil {
 		return nil, newOpenSSLError("EC_KEY_new_by_curve_name failed")
 	}
-	var pkey C.GO_EVP_PKEY_PTR
-	defer func() {
-		if pkey == nil {
-			defer C.go_openssl_EC_KEY_free(key)
-		}
-	}()
 	if C.go_openssl_EC_KEY_set_public_key_affine_coordinates(key, bx, by) != 1 {
+		C.go_openssl_EC_KEY_free(key)
 		return nil, newOpenSSLError("EC_KEY_set_public_key_affine_coordinates failed")
 	}
 	if D != nil && C.go_openssl_EC_KEY_set_private_key(key, bd) != 1 {
+		C.go_openssl_EC_KEY_free(key)
 		return nil, newOpenSSLError("EC_KEY_set_private_key failed")
 	}
-	pkey, err = newEVPPKEY(key)
-	if err != nil {
-		return nil, err
-	}
-	return pkey, nil
+	return key, nil
 }
 
 func NewPrivateKeyECDSA(curve string, X, Y, D BigInt) (*PrivateKeyECDSA, error) {
+	if err := checkStrictCurve(curve); err != nil {
+		return nil, err
+	}
 	pkey, err := newECKey(curve, X, Y, D)
 	if err != nil {
 		return nil, err
 	}
-	k := &PrivateKeyECDSA{_pkey: pkey}
-	// Note: Because of the finalizer, any time k.key is passed to cgo,
-	// that call must be followed by a call to runtime.KeepAlive(k),
-	// to make sure k is not collected (and finalized) before the cgo
-	// call returns.
-	runtime.SetFinalizer(k, (*PrivateKeyECDSA).finalize)
+	auditPKey(AuditImport, pkey)
+	k := &PrivateKeyECDSA{closer: pkeyHandle(pkey)}
+	manage(k, (*PrivateKeyECDSA).finalize)
 	return k, nil
 }
 
@@ -140,17 +173,187 @@ func VerifyECDSA(pub *PublicKeyECDSA, hash []byte, sig []byte) bool {
 	return evpVerify(pub.withKey, 0, 0, 0, sig, hash) == nil
 }
 
+// SignMessageECDSA is like SignMarshalECDSA but signs msg itself,
+// hashing it with h, in a single call to OpenSSL rather than one to
+// hash msg and another to sign the digest.
+func SignMessageECDSA(priv *PrivateKeyECDSA, h crypto.Hash, msg []byte) ([]byte, error) {
+	return evpDigestSign(priv.withKey, 0, 0, h, msg)
+}
+
+// VerifyMessageECDSA verifies an ASN.1 encoded signature of msg,
+// hashed with h, as returned by SignMessageECDSA.
+func VerifyMessageECDSA(pub *PublicKeyECDSA, h crypto.Hash, msg, sig []byte) bool {
+	return evpDigestVerify(pub.withKey, 0, 0, h, msg, sig) == nil
+}
+
+// SignRawECDSA is like SignMarshalECDSA but returns the signature as
+// the big-endian integers r and s, zero-padded to the size of the curve
+// order, rather than ASN.1 encoded, so that callers needing r and s
+// don't have to parse it.
+//
+// Only on OpenSSL 1 are r and s produced without an encoding, by
+// ECDSA_do_sign. On OpenSSL 3, ECDSA_do_sign would bypass the providers,
+// including the FIPS provider, which only return encoded signatures,
+// so SignRawECDSA still calls SignMarshalECDSA and decodes its signature
+// with d2i_ECDSA_SIG.
+func SignRawECDSA(priv *PrivateKeyECDSA, hash []byte) (r, s []byte, err error) {
+	if vMajor == 3 {
+		der, err := SignMarshalECDSA(priv, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		sig := C.go_openssl_d2i_ECDSA_SIG_buf(base(der), C.long(len(der)))
+		if sig == nil {
+			return nil, nil, newOpenSSLError("d2i_ECDSA_SIG")
+		}
+		defer C.go_openssl_ECDSA_SIG_free(sig)
+		return ecdsaSigBytes(sig, ecdsaOrderSize(priv.withKey))
+	}
+	defer endOp(beginOp("ECDSA_do_sign", MetricSign), &err)
+	defer auditKey(AuditSign, priv.withKey, &err)
+	var sig C.GO_ECDSA_SIG_PTR
+	if withECKey(priv.withKey, func(key C.GO_EC_KEY_PTR) C.int {
+		sig = C.go_openssl_ECDSA_do_sign(base(hash), C.int(len(hash)), key)
+		if sig == nil {
+			return 0
+		}
+		return 1
+	}) == 0 {
+		return nil, nil, newOpenSSLError("ECDSA_do_sign")
+	}
+	defer C.go_openssl_ECDSA_SIG_free(sig)
+	return ecdsaSigBytes(sig, ecdsaOrderSize(priv.withKey))
+}
+
+// VerifyRawECDSA is like VerifyECDSA but takes the signature as
+// the big-endian integers r and s, as returned by SignRawECDSA.
+//
+// Like SignRawECDSA, it only verifies r and s without an encoding,
+// with ECDSA_do_verify, on OpenSSL 1. On OpenSSL 3, the signature is
+// ASN.1 encoded with i2d_ECDSA_SIG and verified by VerifyECDSA.
+func VerifyRawECDSA(pub *PublicKeyECDSA, hash, r, s []byte) bool {
+	sig := newECDSASig(r, s)
+	if sig == nil {
+		C.go_openssl_ERR_clear_error()
+		return false
+	}
+	defer C.go_openssl_ECDSA_SIG_free(sig)
+	if vMajor == 3 {
+		der := make([]byte, C.go_openssl_i2d_ECDSA_SIG_buf(sig, nil))
+		if len(der) == 0 || int(C.go_openssl_i2d_ECDSA_SIG_buf(sig, base(der))) != len(der) {
+			C.go_openssl_ERR_clear_error()
+			return false
+		}
+		return VerifyECDSA(pub, hash, der)
+	}
+	var err error
+	defer endOp(beginOp("ECDSA_do_verify", MetricVerify), &err)
+	if withECKey(pub.withKey, func(key C.GO_EC_KEY_PTR) C.int {
+		return C.go_openssl_ECDSA_do_verify(base(hash), C.int(len(hash)), sig, key)
+	}) != 1 {
+		C.go_openssl_ERR_clear_error()
+		err = ErrVerification
+		return false
+	}
+	return true
+}
+
+// withECKey calls f with the EC_KEY of the EC key of withKey.
+func withECKey(withKey withKeyFunc, f func(C.GO_EC_KEY_PTR) C.int) C.int {
+	return withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
+		if key == nil {
+			return 0
+		}
+		defer C.go_openssl_EC_KEY_free(key)
+		return f(key)
+	})
+}
+
+// ecdsaOrderSize returns the size in bytes of the order
+// of the curve of the EC key of withKey.
+func ecdsaOrderSize(withKey withKeyFunc) int {
+	bits := withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_EVP_PKEY_get_bits(pkey)
+	})
+	return (int(bits) + 7) / 8
+}
+
+// ecdsa_sig_st_1_0_2 is the ECDSA_SIG memory layout in OpenSSL 1.0.2.
+type ecdsa_sig_st_1_0_2 struct {
+	r, s C.GO_BIGNUM_PTR
+}
+
+// ecdsaSigBytes returns the integers of sig, zero-padded to size bytes.
+func ecdsaSigBytes(sig C.GO_ECDSA_SIG_PTR, size int) (r, s []byte, err error) {
+	var br, bs C.GO_BIGNUM_PTR
+	if vMajor == 1 && vMinor == 0 {
+		st := (*ecdsa_sig_st_1_0_2)(unsafe.Pointer(sig))
+		br, bs = st.r, st.s
+	} else {
+		C.go_openssl_ECDSA_SIG_get0(sig, &br, &bs)
+	}
+	if br == nil || bs == nil {
+		return nil, nil, errors.New("openssl: invalid ECDSA signature")
+	}
+	r, s = make([]byte, size), make([]byte, size)
+	if C.go_openssl_BN_bn2binpad(br, base(r), C.int(size)) != C.int(size) ||
+		C.go_openssl_BN_bn2binpad(bs, base(s), C.int(size)) != C.int(size) {
+		return nil, nil, newOpenSSLError("BN_bn2binpad")
+	}
+	return r, s, nil
+}
+
+// newECDSASig returns an ECDSA_SIG holding the big-endian integers r and s,
+// or nil if it can't be created.
+func newECDSASig(r, s []byte) C.GO_ECDSA_SIG_PTR {
+	if len(r) == 0 || len(s) == 0 {
+		return nil
+	}
+	sig := C.go_openssl_ECDSA_SIG_new()
+	if sig == nil {
+		return nil
+	}
+	if vMajor == 1 && vMinor == 0 {
+		// ECDSA_SIG_new allocates r and s in OpenSSL 1.0.2.
+		st := (*ecdsa_sig_st_1_0_2)(unsafe.Pointer(sig))
+		if C.go_openssl_BN_bin2bn(base(r), C.int(len(r)), st.r) == nil ||
+			C.go_openssl_BN_bin2bn(base(s), C.int(len(s)), st.s) == nil {
+			C.go_openssl_ECDSA_SIG_free(sig)
+			return nil
+		}
+		return sig
+	}
+	br, bs := bytesToBN(r), bytesToBN(s)
+	if br == nil || bs == nil || C.go_openssl_ECDSA_SIG_set0(sig, br, bs) != 1 {
+		C.go_openssl_BN_free(br)
+		C.go_openssl_BN_free(bs)
+		C.go_openssl_ECDSA_SIG_free(sig)
+		return nil
+	}
+	return sig
+}
+
 func GenerateKeyECDSA(curve string) (X, Y, D BigInt, err error) {
 	pkey, err := generateEVPPKey(C.GO_EVP_PKEY_EC, 0, curve)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	defer C.go_openssl_EVP_PKEY_free(pkey)
+	if err := pairwiseConsistencyTest(C.GO_EVP_PKEY_EC, pkey); err != nil {
+		return nil, nil, nil, err
+	}
 	key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
 	if key == nil {
 		return nil, nil, nil, newOpenSSLError("EVP_PKEY_get1_EC_KEY failed")
 	}
 	defer C.go_openssl_EC_KEY_free(key)
+	return ecKeyComponents(key)
+}
+
+// ecKeyComponents returns the public point (X, Y) and
+// the private scalar D of the private key key.
+func ecKeyComponents(key C.GO_EC_KEY_PTR) (X, Y, D BigInt, err error) {
 	group := C.go_openssl_EC_KEY_get0_group(key)
 	pt := C.go_openssl_EC_KEY_get0_public_key(key)
 	bd := C.go_openssl_EC_KEY_get0_private_key(key)
@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package ecdsa mirrors the API of crypto/ecdsa with the ECDSA
+// implementation of OpenSSL, so that applications can move to it by
+// changing their import path. Keys are the same structs as in
+// crypto/ecdsa, with math/big coordinates, and are converted to
+// OpenSSL keys on first use, which are then reused for as long as the
+// fields of the key aren't modified.
+//
+// The curves are P-224, P-256, P-384 and P-521 from crypto/elliptic.
+// The rand parameters are ignored, OpenSSL uses its own random generator.
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"errors"
+	"io"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig/bridge"
+)
+
+// PublicKey represents an ECDSA public key.
+type PublicKey struct {
+	elliptic.Curve
+	X, Y *big.Int
+
+	cache atomic.Value // *pubCache
+}
+
+// PrivateKey represents an ECDSA private key.
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+
+	cache atomic.Value // *privCache
+}
+
+type pubCache struct {
+	curve elliptic.Curve
+	x, y  big.Int
+	key   *openssl.PublicKeyECDSA
+}
+
+type privCache struct {
+	pubCache
+	d   big.Int
+	key *openssl.PrivateKeyECDSA
+}
+
+func (c *pubCache) matches(pub *PublicKey) bool {
+	return c.curve == pub.Curve && c.x.Cmp(pub.X) == 0 && c.y.Cmp(pub.Y) == 0
+}
+
+func curveName(c elliptic.Curve) (string, error) {
+	if c == nil {
+		return "", errors.New("ecdsa: missing curve")
+	}
+	switch name := c.Params().Name; name {
+	case "P-224", "P-256", "P-384", "P-521":
+		return name, nil
+	}
+	return "", errors.New("ecdsa: unsupported curve")
+}
+
+// opensslKey returns the OpenSSL key of pub.
+func (pub *PublicKey) opensslKey() (*openssl.PublicKeyECDSA, error) {
+	if c, _ := pub.cache.Load().(*pubCache); c != nil && c.matches(pub) {
+		return c.key, nil
+	}
+	name, err := curveName(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if pub.X == nil || pub.Y == nil {
+		return nil, errors.New("ecdsa: invalid public key")
+	}
+	key, err := bridge.NewPublicKeyECDSA(name, pub.X, pub.Y)
+	if err != nil {
+		return nil, err
+	}
+	c := &pubCache{curve: pub.Curve, key: key}
+	c.x.Set(pub.X)
+	c.y.Set(pub.Y)
+	pub.cache.Store(c)
+	return key, nil
+}
+
+// opensslKey returns the OpenSSL key of priv.
+func (priv *PrivateKey) opensslKey() (*openssl.PrivateKeyECDSA, error) {
+	if c, _ := priv.cache.Load().(*privCache); c != nil && c.matches(&priv.PublicKey) && c.d.Cmp(priv.D) == 0 {
+		return c.key, nil
+	}
+	name, err := curveName(priv.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if priv.X == nil || priv.Y == nil || priv.D == nil {
+		return nil, errors.New("ecdsa: invalid private key")
+	}
+	key, err := bridge.NewPrivateKeyECDSA(name, priv.X, priv.Y, priv.D)
+	if err != nil {
+		return nil, err
+	}
+	c := &privCache{pubCache: pubCache{curve: priv.Curve}, key: key}
+	c.x.Set(priv.X)
+	c.y.Set(priv.Y)
+	c.d.Set(priv.D)
+	priv.cache.Store(c)
+	return key, nil
+}
+
+// GenerateKey generates a new ECDSA private key for the curve c.
+func GenerateKey(c elliptic.Curve, rand io.Reader) (*PrivateKey, error) {
+	name, err := curveName(c)
+	if err != nil {
+		return nil, err
+	}
+	X, Y, D, err := bridge.GenerateKeyECDSA(name)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{PublicKey: PublicKey{Curve: c, X: X, Y: Y}, D: D}, nil
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return bigIntEqual(pub.X, xx.X) && bigIntEqual(pub.Y, xx.Y) && pub.Curve == xx.Curve
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	return priv.PublicKey.Equal(&xx.PublicKey) && bigIntEqual(priv.D, xx.D)
+}
+
+func bigIntEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// Sign signs digest with priv, returning an ASN.1 encoded signature,
+// as described in crypto.Signer. opts is ignored.
+func (priv *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return SignASN1(rand, priv, digest)
+}
+
+// SignASN1 signs hash, the result of hashing a larger message, with priv
+// and returns the ASN.1 encoded signature.
+func SignASN1(rand io.Reader, priv *PrivateKey, hash []byte) ([]byte, error) {
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	return openssl.SignMarshalECDSA(key, hash)
+}
+
+// VerifyASN1 reports whether the ASN.1 encoded signature sig of hash
+// by pub is valid.
+func VerifyASN1(pub *PublicKey, hash, sig []byte) bool {
+	key, err := pub.opensslKey()
+	if err != nil {
+		return false
+	}
+	return openssl.VerifyECDSA(key, hash, sig)
+}
+
+// Sign signs hash with priv and returns the signature as a pair of
+// integers. Most applications should use SignASN1 instead.
+func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	rb, sb, err := openssl.SignRawECDSA(key, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return new(big.Int).SetBytes(rb), new(big.Int).SetBytes(sb), nil
+}
+
+// Verify reports whether the signature r, s of hash by pub is valid.
+// Most applications should use VerifyASN1 instead.
+func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
+	if r == nil || s == nil || r.Sign() <= 0 || s.Sign() <= 0 {
+		return false
+	}
+	key, err := pub.opensslKey()
+	if err != nil {
+		return false
+	}
+	return openssl.VerifyRawECDSA(key, hash, r.Bytes(), s.Bytes())
+}
@@ -0,0 +1,104 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package ecdsa
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestSignVerify(t *testing.T) {
+	hashed := sha256.Sum256([]byte("testing"))
+	for _, c := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(c.Params().Name, func(t *testing.T) {
+			priv, err := GenerateKey(c, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.IsOnCurve(priv.X, priv.Y) {
+				t.Fatal("public key isn't on the curve")
+			}
+			var signer crypto.Signer = priv
+			sig, err := signer.Sign(rand.Reader, hashed[:], crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			std := &stdecdsa.PublicKey{Curve: c, X: priv.X, Y: priv.Y}
+			if !stdecdsa.VerifyASN1(std, hashed[:], sig) {
+				t.Error("crypto/ecdsa rejected the signature")
+			}
+			if !VerifyASN1(&priv.PublicKey, hashed[:], sig) {
+				t.Error("VerifyASN1() rejected the signature")
+			}
+			r, s, err := Sign(rand.Reader, priv, hashed[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(&priv.PublicKey, hashed[:], r, s) {
+				t.Error("Verify() rejected the signature")
+			}
+			if Verify(&priv.PublicKey, hashed[:], s, r) {
+				t.Error("Verify() accepted an invalid signature")
+			}
+		})
+	}
+}
+
+func TestStdKey(t *testing.T) {
+	std, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := &PrivateKey{PublicKey: PublicKey{Curve: std.Curve, X: std.X, Y: std.Y}, D: std.D}
+	hashed := sha256.Sum256([]byte("testing"))
+	sig, err := SignASN1(rand.Reader, priv, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stdecdsa.VerifyASN1(&std.PublicKey, hashed[:], sig) {
+		t.Error("crypto/ecdsa rejected the signature")
+	}
+	// Modifying the key invalidates its cached OpenSSL key.
+	other, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	priv.PublicKey.X, priv.PublicKey.Y = other.X, other.Y
+	if VerifyASN1(&priv.PublicKey, hashed[:], sig) {
+		t.Error("VerifyASN1() with a modified key accepted the signature")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	same := &PrivateKey{PublicKey: PublicKey{Curve: priv.Curve, X: new(big.Int).Set(priv.X), Y: priv.Y}, D: priv.D}
+	other, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	if !priv.Equal(same) || !priv.PublicKey.Equal(same.Public()) {
+		t.Error("Equal() = false for the same key")
+	}
+	if priv.Equal(other) || priv.PublicKey.Equal(other.Public()) {
+		t.Error("Equal() = true for another key")
+	}
+}
+
+func TestUnsupportedCurve(t *testing.T) {
+	if _, err := GenerateKey(nil, rand.Reader); err == nil {
+		t.Error("GenerateKey(nil) succeeded")
+	}
+}
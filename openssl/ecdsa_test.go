@@ -1,16 +1,20 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl_test
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
 	"testing"
 
+	"github.com/microsoft/go-crypto-openssl/openssl"
 	"github.com/microsoft/go-crypto-openssl/openssl/bbig/bridge"
 )
 
@@ -81,6 +85,87 @@ func testECDSASignAndVerify(t *testing.T, c elliptic.Curve) {
 	}
 }
 
+func TestECDSARawSignature(t *testing.T) {
+	testAllCurves(t, testECDSARawSignature)
+}
+
+func testECDSARawSignature(t *testing.T, c elliptic.Curve) {
+	key, err := generateKeycurve(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := bridge.NewPrivateKeyECDSA(key.Params().Name, key.X, key.Y, key.D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := bridge.NewPublicKeyECDSA(key.Params().Name, key.X, key.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := []byte("testing")
+	r, s, err := openssl.SignRawECDSA(priv, hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := (c.Params().N.BitLen() + 7) / 8; len(r) != size || len(s) != size {
+		t.Errorf("got r and s of %d and %d bytes, want %d", len(r), len(s), size)
+	}
+	if !ecdsa.Verify(&key.PublicKey, hashed, new(big.Int).SetBytes(r), new(big.Int).SetBytes(s)) {
+		t.Error("crypto/ecdsa rejected the signature")
+	}
+	if !openssl.VerifyRawECDSA(pub, hashed, r, s) {
+		t.Error("VerifyRawECDSA failed")
+	}
+	sr, ss, err := ecdsa.Sign(rand.Reader, key, hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !openssl.VerifyRawECDSA(pub, hashed, sr.Bytes(), ss.Bytes()) {
+		t.Error("VerifyRawECDSA rejected a crypto/ecdsa signature")
+	}
+	s[len(s)-1] ^= 1
+	if openssl.VerifyRawECDSA(pub, hashed, r, s) {
+		t.Error("VerifyRawECDSA accepted an invalid signature")
+	}
+	if openssl.VerifyRawECDSA(pub, hashed, nil, s) {
+		t.Error("VerifyRawECDSA accepted an empty r")
+	}
+}
+
+func TestSignMessageECDSA(t *testing.T) {
+	testAllCurves(t, testSignMessageECDSA)
+}
+
+func testSignMessageECDSA(t *testing.T, c elliptic.Curve) {
+	key, err := generateKeycurve(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := bridge.NewPrivateKeyECDSA(key.Params().Name, key.X, key.Y, key.D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := bridge.NewPublicKeyECDSA(key.Params().Name, key.X, key.Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("testing")
+	sig, err := openssl.SignMessageECDSA(priv, crypto.SHA256, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := openssl.SHA256(msg)
+	if !openssl.VerifyECDSA(pub, hashed[:], sig) {
+		t.Error("VerifyECDSA rejected the signature of the digest")
+	}
+	if !openssl.VerifyMessageECDSA(pub, crypto.SHA256, msg, sig) {
+		t.Error("VerifyMessageECDSA failed")
+	}
+	if openssl.VerifyMessageECDSA(pub, crypto.SHA384, msg, sig) {
+		t.Error("VerifyMessageECDSA accepted another hash")
+	}
+}
+
 func generateKeycurve(c elliptic.Curve) (*ecdsa.PrivateKey, error) {
 	x, y, d, err := bridge.GenerateKeyECDSA(c.Params().Name)
 	if err != nil {
@@ -0,0 +1,201 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// Engine is an initialized OpenSSL ENGINE, such as the pkcs11 engine
+// of libp11, https://github.com/OpenSC/libp11, which gives access to
+// the keys of HSMs and smart cards through a PKCS #11 module.
+//
+// ENGINEs are deprecated in OpenSSL 3 in favor of providers, but
+// remain the only way to use some hardware on OpenSSL 1.1 and 1.0.2.
+type Engine struct {
+	// _e MUST NOT be accessed directly. Instead, use the withEngine method.
+	_e C.GO_ENGINE_PTR
+	closer
+}
+
+// EngineCommand is an ENGINE control command, as accepted by the
+// -pre option of "openssl engine". An empty Value is passed as NULL,
+// for commands without argument such as "LOAD".
+type EngineCommand struct {
+	Name  string
+	Value string
+}
+
+var loadBuiltinEnginesOnce sync.Once
+
+// LoadEngine looks up the ENGINE id, runs commands on it and initializes
+// it. Engines that aren't built into OpenSSL or configured in the OpenSSL
+// configuration file are loaded from the OpenSSL engines directory, or
+// from an explicit path through the "dynamic" engine:
+//
+//	e, err := openssl.LoadEngine("dynamic",
+//		openssl.EngineCommand{Name: "SO_PATH", Value: "/usr/lib/engines-3/pkcs11.so"},
+//		openssl.EngineCommand{Name: "ID", Value: "pkcs11"},
+//		openssl.EngineCommand{Name: "LOAD"},
+//		openssl.EngineCommand{Name: "MODULE_PATH", Value: "/usr/lib/softhsm/libsofthsm2.so"},
+//		openssl.EngineCommand{Name: "PIN", Value: pin},
+//	)
+//
+// The commands run before the initialization, as in the OpenSSL
+// configuration file. Use Engine.Command for the others.
+func LoadEngine(id string, commands ...EngineCommand) (*Engine, error) {
+	loadBuiltinEnginesOnce.Do(func() {
+		C.go_openssl_ENGINE_load_builtin_engines()
+	})
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+	e := C.go_openssl_ENGINE_by_id(cid)
+	if e == nil {
+		return nil, newOpenSSLError("ENGINE_by_id(" + id + ")")
+	}
+	// Until ENGINE_init succeeds, e is a structural reference only.
+	for _, cmd := range commands {
+		if err := engineCommand(e, cmd.Name, cmd.Value); err != nil {
+			C.go_openssl_ENGINE_free(e)
+			return nil, err
+		}
+	}
+	if C.go_openssl_ENGINE_init(e) != 1 {
+		C.go_openssl_ENGINE_free(e)
+		return nil, newOpenSSLError("ENGINE_init(" + id + ")")
+	}
+	eng := &Engine{_e: e}
+	manage(eng, (*Engine).finalize)
+	return eng, nil
+}
+
+func (e *Engine) finalize() {
+	C.go_openssl_ENGINE_finish(e._e)
+	C.go_openssl_ENGINE_free(e._e)
+}
+
+// Close frees the native resources of e, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (e *Engine) Close() error {
+	return closeObject(e, e.finalize)
+}
+
+func (e *Engine) withEngine(f func(C.GO_ENGINE_PTR) C.int) C.int {
+	// Because of the finalizer, any time _e is passed to cgo, that call must
+	// be followed by a call to runtime.KeepAlive, to make sure e is not
+	// collected (and finalized) before the cgo call returns.
+	defer runtime.KeepAlive(e)
+	return f(e._e)
+}
+
+// ID returns the identifier of e, such as "pkcs11".
+func (e *Engine) ID() string {
+	var id string
+	e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		id = C.GoString(C.go_openssl_ENGINE_get_id(ptr))
+		return 1
+	})
+	return id
+}
+
+// Name returns the descriptive name of e.
+func (e *Engine) Name() string {
+	var name string
+	e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		name = C.GoString(C.go_openssl_ENGINE_get_name(ptr))
+		return 1
+	})
+	return name
+}
+
+// Command runs the control command name on e with the argument value,
+// or without argument if value is empty.
+func (e *Engine) Command(name, value string) error {
+	var err error
+	e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		err = engineCommand(ptr, name, value)
+		return 1
+	})
+	return err
+}
+
+// SetDefault makes e the default implementation of the algorithms
+// listed in algorithms, a comma-separated list such as "RSA,EC,CIPHERS"
+// or "ALL", as the default_algorithms of the OpenSSL configuration.
+// The operations of this package then use e for the algorithms it
+// implements, in place of the providers or built-in implementations.
+// Operations from a LibraryContext other than the default one don't
+// use ENGINEs.
+func (e *Engine) SetDefault(algorithms string) error {
+	calgs := C.CString(algorithms)
+	defer C.free(unsafe.Pointer(calgs))
+	if e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		return C.go_openssl_ENGINE_set_default_string(ptr, calgs)
+	}) != 1 {
+		return newOpenSSLError("ENGINE_set_default_string(" + algorithms + ")")
+	}
+	return nil
+}
+
+func engineCommand(e C.GO_ENGINE_PTR, name, value string) error {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var cvalue *C.char
+	if value != "" {
+		cvalue = C.CString(value)
+		defer C.free(unsafe.Pointer(cvalue))
+	}
+	if C.go_openssl_ENGINE_ctrl_cmd_string(e, cname, cvalue, 0) != 1 {
+		return newOpenSSLError("ENGINE_ctrl_cmd_string(" + name + ")")
+	}
+	return nil
+}
+
+// PrivateKey loads the private key keyID from e, such as the PKCS #11
+// URI "pkcs11:object=signing-key;type=private" with libp11. The key
+// material stays in e, which performs the private key operations.
+//
+// It returns a *PrivateKeyRSA for RSA keys and a *PrivateKeyECDSA for
+// EC keys on the curves supported by NewPrivateKeyECDSA. On OpenSSL 3,
+// other key types are returned as a *PKey. The key remains usable
+// after e is no longer referenced.
+//
+// A PIN, if needed, must be set with a command of e, such as "PIN"
+// with libp11, since interactive prompts are not supported.
+func (e *Engine) PrivateKey(keyID string) (interface{}, error) {
+	ckey := C.CString(keyID)
+	defer C.free(unsafe.Pointer(ckey))
+	var pkey C.GO_EVP_PKEY_PTR
+	e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		pkey = C.go_openssl_ENGINE_load_private_key(ptr, ckey, nil, nil)
+		return 1
+	})
+	if pkey == nil {
+		return nil, newOpenSSLError("ENGINE_load_private_key")
+	}
+	return privateKeyFromPKey(nil, pkey)
+}
+
+// PublicKey loads the public key keyID from e. It returns the public
+// key types described in ParsePKIXPublicKey.
+func (e *Engine) PublicKey(keyID string) (interface{}, error) {
+	ckey := C.CString(keyID)
+	defer C.free(unsafe.Pointer(ckey))
+	var pkey C.GO_EVP_PKEY_PTR
+	e.withEngine(func(ptr C.GO_ENGINE_PTR) C.int {
+		pkey = C.go_openssl_ENGINE_load_public_key(ptr, ckey, nil, nil)
+		return 1
+	})
+	if pkey == nil {
+		return nil, newOpenSSLError("ENGINE_load_public_key")
+	}
+	return publicKeyFromPKey(pkey)
+}
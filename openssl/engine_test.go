@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestLoadEngineMissing(t *testing.T) {
+	if _, err := openssl.LoadEngine("no-such-engine"); err == nil {
+		t.Error("loaded a missing engine")
+	}
+	if _, err := openssl.LoadEngine("dynamic",
+		openssl.EngineCommand{Name: "SO_PATH", Value: "/no/such/engine.so"},
+		openssl.EngineCommand{Name: "LOAD"},
+	); err == nil {
+		t.Error("loaded a missing dynamic engine")
+	}
+}
+
+func TestEngine(t *testing.T) {
+	// The afalg engine ships with OpenSSL on Linux, and has no keys.
+	e, err := openssl.LoadEngine("afalg")
+	if err != nil {
+		t.Skipf("afalg engine not available: %v", err)
+	}
+	if id := e.ID(); id != "afalg" {
+		t.Errorf("ID() = %q, want afalg", id)
+	}
+	if e.Name() == "" {
+		t.Error("empty engine name")
+	}
+	if err := e.Command("NO_SUCH_COMMAND", ""); err == nil {
+		t.Error("ran an unknown command")
+	}
+	if err := e.SetDefault("NO_SUCH_ALGORITHMS"); err == nil {
+		t.Error("set an unknown default algorithm list")
+	}
+	if _, err := e.PrivateKey("key"); err == nil {
+		t.Error("loaded a private key from an engine without keys")
+	}
+	if _, err := e.PublicKey("key"); err == nil {
+		t.Error("loaded a public key from an engine without keys")
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package envelope implements envelope encryption with OpenSSL.
+//
+// Seal encrypts data with AES-256-GCM under a random data encryption
+// key (DEK), wraps the DEK under a key encryption key (KEK), with AES
+// Key Wrap or RSA-OAEP, and prepends a header describing the algorithms
+// and holding the wrapped DEK. Open reverses it with the same KEK. The
+// header is authenticated along with the data, so the envelope can be
+// stored or sent as a single self-describing blob. NewWriter and
+// NewReader do the same for large objects, such as backups, encrypting
+// them in authenticated chunks.
+//
+// An envelope is laid out as follows, with big-endian lengths:
+//
+//	magic       [4]byte  "OENV"
+//	version     uint8    1
+//	data alg    uint8    1 for AES-256-GCM
+//	wrap alg    uint8    see WrapAlgorithm
+//	key ID      uint8 length, then the ID of the KEK
+//	wrapped DEK uint16 length, then the wrapped DEK
+//	nonce       [12]byte
+//	ciphertext  the encrypted data followed by the 16 bytes GCM tag
+//
+// The AES-GCM additional data is the header, from the magic to the
+// nonce, followed by the additional data of the caller.
+package envelope
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// WrapAlgorithm identifies the algorithm wrapping the DEK of an envelope.
+type WrapAlgorithm uint8
+
+const (
+	// AESKeyWrap wraps the DEK with the AES Key Wrap algorithm of
+	// RFC 3394, see openssl.WrapKeyAES.
+	AESKeyWrap WrapAlgorithm = 1
+	// RSAOAEPSHA256 encrypts the DEK with RSA-OAEP,
+	// using SHA-256 for both the hash and MGF1, and no label.
+	RSAOAEPSHA256 WrapAlgorithm = 2
+)
+
+func (a WrapAlgorithm) String() string {
+	switch a {
+	case AESKeyWrap:
+		return "AES-KW"
+	case RSAOAEPSHA256:
+		return "RSA-OAEP-SHA256"
+	}
+	return "WrapAlgorithm(" + strconv.Itoa(int(a)) + ")"
+}
+
+const (
+	version1  = 1
+	aes256GCM = 1
+
+	dekSize   = 32
+	nonceSize = 12
+	tagSize   = 16
+)
+
+var envelopeMagic = [4]byte{'O', 'E', 'N', 'V'}
+
+// ErrKeyMismatch is returned by Open when the envelope
+// wasn't sealed with the given KEK.
+var ErrKeyMismatch = errors.New("envelope: sealed with another key encryption key")
+
+var errOpen = errors.New("envelope: message authentication failed")
+
+// KEK is a key encryption key, which wraps and unwraps DEKs.
+// Its private part can be held by OpenSSL or by an HSM.
+type KEK interface {
+	// ID returns the identifier of the KEK stored in the envelope
+	// headers, at most 255 bytes, such as the name or version of
+	// a KMS key. It can be empty.
+	ID() string
+	// Algorithm returns the algorithm wrapping the DEKs.
+	Algorithm() WrapAlgorithm
+	// Wrap wraps the DEK dek.
+	Wrap(dek []byte) ([]byte, error)
+	// Unwrap unwraps a DEK wrapped by Wrap.
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// Header is the decoded header of an envelope.
+type Header struct {
+	Version       uint8
+	KeyID         string
+	WrapAlgorithm WrapAlgorithm
+	WrappedKey    []byte
+	// Nonce is the AES-GCM nonce of an envelope,
+	// or the nonce prefix of the chunks of a stream.
+	Nonce []byte
+
+	// raw is the encoded header, used as additional data.
+	raw []byte
+}
+
+// ParseHeader decodes the header of envelope, for example to find the
+// KEK it was sealed with from its KeyID. It doesn't authenticate it.
+func ParseHeader(envelope []byte) (*Header, error) {
+	h, _, err := parseHeader(envelope)
+	return h, err
+}
+
+// Seal encrypts plaintext, authenticating additionalData, under a new
+// random DEK wrapped by kek, and returns the envelope.
+// additionalData isn't stored in the envelope and must be given to Open.
+func Seal(kek KEK, plaintext, additionalData []byte) ([]byte, error) {
+	dek, wrapped, release, err := newDEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(openssl.RandReader, nonce); err != nil {
+		return nil, err
+	}
+	h := &Header{Version: version1, KeyID: kek.ID(), WrapAlgorithm: kek.Algorithm(), WrappedKey: wrapped, Nonce: nonce}
+	raw, err := h.marshal(envelopeMagic)
+	if err != nil {
+		return nil, err
+	}
+	raw = append(raw, nonce...)
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAEAD(aead)
+	return aead.Seal(raw, nonce, plaintext, concat(raw, additionalData)), nil
+}
+
+// Open decrypts envelope, as returned by Seal with kek and
+// additionalData, and returns the plaintext. It returns ErrKeyMismatch
+// if the header names another KEK or wrapping algorithm.
+func Open(kek KEK, envelope, additionalData []byte) ([]byte, error) {
+	return open(singleKEK(kek), envelope, additionalData)
+}
+
+// lookupFunc returns the KEK that sealed the envelope or stream of
+// header h, or ErrKeyMismatch.
+type lookupFunc func(h *Header) (KEK, error)
+
+func singleKEK(kek KEK) lookupFunc {
+	return func(h *Header) (KEK, error) {
+		if h.KeyID != kek.ID() || h.WrapAlgorithm != kek.Algorithm() {
+			return nil, ErrKeyMismatch
+		}
+		return kek, nil
+	}
+}
+
+func open(lookup lookupFunc, envelope, additionalData []byte) ([]byte, error) {
+	h, ciphertext, err := parseHeader(envelope)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := lookup(h)
+	if err != nil {
+		return nil, err
+	}
+	dek, release, err := unwrapDEK(kek, h.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAEAD(aead)
+	plaintext, err := aead.Open(nil, h.Nonce, ciphertext, concat(h.raw, additionalData))
+	if err != nil {
+		return nil, errOpen
+	}
+	return plaintext, nil
+}
+
+// newDEK returns a new random DEK and the DEK wrapped by kek.
+// The DEK is protected with openssl.ProtectSecret
+// and the caller must call release once done with it.
+func newDEK(kek KEK) (dek, wrapped []byte, release func(), err error) {
+	dek = make([]byte, dekSize)
+	if release, err = openssl.ProtectSecret(dek); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := io.ReadFull(openssl.RandReader, dek); err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	wrapped, err = kek.Wrap(dek)
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return dek, wrapped, release, nil
+}
+
+// unwrapDEK unwraps the DEK wrapped with kek and protects it
+// like newDEK does. The caller must call release once done with it.
+func unwrapDEK(kek KEK, wrapped []byte) (dek []byte, release func(), err error) {
+	if dek, err = kek.Unwrap(wrapped); err != nil {
+		return nil, nil, err
+	}
+	if release, err = openssl.ProtectSecret(dek); err != nil {
+		openssl.Cleanse(dek)
+		return nil, nil, err
+	}
+	return dek, release, nil
+}
+
+// marshal encodes h, up to the wrapped key included, with the magic m.
+func (h *Header) marshal(m [4]byte) ([]byte, error) {
+	if len(h.KeyID) > 0xFF {
+		return nil, errors.New("envelope: key ID too long")
+	}
+	if len(h.WrappedKey) > 0xFFFF {
+		return nil, errors.New("envelope: wrapped key too long")
+	}
+	var b bytes.Buffer
+	b.Write(m[:])
+	b.WriteByte(h.Version)
+	b.WriteByte(aes256GCM)
+	b.WriteByte(byte(h.WrapAlgorithm))
+	b.WriteByte(byte(len(h.KeyID)))
+	b.WriteString(h.KeyID)
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(h.WrappedKey)))
+	b.Write(n[:])
+	b.Write(h.WrappedKey)
+	return b.Bytes(), nil
+}
+
+// parseHeader decodes the header of envelope
+// and returns it with the rest of envelope.
+func parseHeader(envelope []byte) (*Header, []byte, error) {
+	errFormat := errors.New("envelope: invalid envelope")
+	s := envelope
+	if len(s) < len(envelopeMagic)+4 || !bytes.Equal(s[:len(envelopeMagic)], envelopeMagic[:]) {
+		return nil, nil, errFormat
+	}
+	s = s[len(envelopeMagic):]
+	if s[0] != version1 {
+		return nil, nil, errors.New("envelope: unsupported version " + strconv.Itoa(int(s[0])))
+	}
+	if s[1] != aes256GCM {
+		return nil, nil, errors.New("envelope: unsupported data algorithm " + strconv.Itoa(int(s[1])))
+	}
+	h := &Header{Version: s[0], WrapAlgorithm: WrapAlgorithm(s[2])}
+	idLen := int(s[3])
+	s = s[4:]
+	if len(s) < idLen+2 {
+		return nil, nil, errFormat
+	}
+	h.KeyID = string(s[:idLen])
+	s = s[idLen:]
+	wrappedLen := int(binary.BigEndian.Uint16(s))
+	s = s[2:]
+	if len(s) < wrappedLen+nonceSize+tagSize {
+		return nil, nil, errFormat
+	}
+	h.WrappedKey = s[:wrappedLen:wrappedLen]
+	s = s[wrappedLen:]
+	h.Nonce = s[:nonceSize:nonceSize]
+	s = s[nonceSize:]
+	h.raw = envelope[: len(envelope)-len(s) : len(envelope)-len(s)]
+	return h, s, nil
+}
+
+// gcmAble is implemented by the AES ciphers of the openssl package.
+type gcmAble interface {
+	NewGCM(nonceSize, tagSize int) (cipher.AEAD, error)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := openssl.NewAESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	defer block.(io.Closer).Close()
+	return block.(gcmAble).NewGCM(nonceSize, tagSize)
+}
+
+// closeAEAD frees the native resources of an AEAD returned by newGCM,
+// see openssl.InitOptions.ExplicitClose.
+func closeAEAD(aead cipher.AEAD) {
+	aead.(io.Closer).Close()
+}
+
+func concat(a, b []byte) []byte {
+	return append(a[:len(a):len(a)], b...)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	// Lock the DEKs and keys in memory to exercise openssl.ProtectSecret.
+	if err := openssl.InitWithOptions(openssl.InitOptions{LockSecrets: true}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newRSAKEK(t *testing.T, id string) KEK {
+	t.Helper()
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kek, err := NewRSAKEK(id, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kek
+}
+
+func TestSealOpen(t *testing.T) {
+	aesKEK, err := NewAESKEK("aes-1", bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kek := range []KEK{aesKEK, newRSAKEK(t, "rsa-1")} {
+		t.Run(kek.Algorithm().String(), func(t *testing.T) {
+			plaintext := []byte("attack at dawn")
+			ad := []byte("context")
+			env, err := Seal(kek, plaintext, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, err := ParseHeader(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Version != 1 || h.KeyID != kek.ID() || h.WrapAlgorithm != kek.Algorithm() {
+				t.Errorf("ParseHeader() = %+v", h)
+			}
+			got, err := Open(kek, env, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Open() = %q, want %q", got, plaintext)
+			}
+			if _, err := Open(kek, env, []byte("other")); err == nil {
+				t.Error("Open() with other additional data succeeded")
+			}
+			for i := range env {
+				tampered := append([]byte(nil), env...)
+				tampered[i] ^= 1
+				if _, err := Open(kek, tampered, ad); err == nil {
+					t.Fatalf("Open() with byte %d modified succeeded", i)
+				}
+			}
+			if _, err := Open(kek, env[:len(env)-1], ad); err == nil {
+				t.Error("Open() of truncated envelope succeeded")
+			}
+		})
+	}
+}
+
+func TestOpenKeyMismatch(t *testing.T) {
+	kek1, _ := NewAESKEK("1", make([]byte, 16))
+	kek2, _ := NewAESKEK("2", make([]byte, 16))
+	env, err := Seal(kek1, []byte("data"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(kek2, env, nil); err != ErrKeyMismatch {
+		t.Errorf("Open() error = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestNewKEKErrors(t *testing.T) {
+	if _, err := NewAESKEK("", make([]byte, 20)); err == nil {
+		t.Error("NewAESKEK() with a 20 bytes key succeeded")
+	}
+	if _, err := NewRSAKEK("", nil, nil); err == nil {
+		t.Error("NewRSAKEK() without keys succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"errors"
+	"io"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+type aesKEK struct {
+	id  string
+	key []byte
+	// release cleanses key, if owned by the KEK.
+	release func()
+}
+
+// NewAESKEK returns a KEK identified by id that wraps DEKs under key,
+// a 16, 24 or 32 bytes AES key, with the AES Key Wrap algorithm.
+// The KEK holds a copy of key, protected with openssl.ProtectSecret,
+// and implements io.Closer to zeroize it.
+func NewAESKEK(id string, key []byte) (KEK, error) {
+	if err := checkAESKEKSize(key); err != nil {
+		return nil, err
+	}
+	k := &aesKEK{id: id, key: make([]byte, len(key))}
+	var err error
+	if k.release, err = openssl.ProtectSecret(k.key); err != nil {
+		return nil, err
+	}
+	copy(k.key, key)
+	return k, nil
+}
+
+func checkAESKEKSize(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	}
+	return errors.New("envelope: invalid AES key size")
+}
+
+// Close zeroizes the key of k, which can't be used anymore.
+func (k *aesKEK) Close() error {
+	if k.release != nil {
+		k.release()
+		k.release = nil
+	}
+	return nil
+}
+
+func (k *aesKEK) ID() string               { return k.id }
+func (k *aesKEK) Algorithm() WrapAlgorithm { return AESKeyWrap }
+
+func (k *aesKEK) Wrap(dek []byte) ([]byte, error) {
+	return openssl.WrapKeyAES(k.key, dek)
+}
+
+func (k *aesKEK) Unwrap(wrapped []byte) ([]byte, error) {
+	return openssl.UnwrapKeyAES(k.key, wrapped)
+}
+
+type rsaKEK struct {
+	id   string
+	pub  *openssl.PublicKeyRSA
+	priv *openssl.PrivateKeyRSA
+}
+
+// NewRSAKEK returns a KEK identified by id that encrypts DEKs to pub
+// and decrypts them with priv, with RSA-OAEP. Either can be nil, for
+// a KEK that only seals or only opens envelopes. priv can be a key
+// held by an HSM, such as those returned by openssl.LoadKey.
+func NewRSAKEK(id string, pub *openssl.PublicKeyRSA, priv *openssl.PrivateKeyRSA) (KEK, error) {
+	if pub == nil && priv == nil {
+		return nil, errors.New("envelope: missing RSA key")
+	}
+	return &rsaKEK{id: id, pub: pub, priv: priv}, nil
+}
+
+func (k *rsaKEK) ID() string               { return k.id }
+func (k *rsaKEK) Algorithm() WrapAlgorithm { return RSAOAEPSHA256 }
+
+func (k *rsaKEK) Wrap(dek []byte) ([]byte, error) {
+	if k.pub == nil {
+		return nil, errors.New("envelope: RSA KEK has no public key")
+	}
+	h := openssl.NewSHA256()
+	defer h.(io.Closer).Close()
+	return openssl.EncryptRSAOAEP(h, k.pub, dek, nil)
+}
+
+func (k *rsaKEK) Unwrap(wrapped []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errors.New("envelope: RSA KEK has no private key")
+	}
+	h := openssl.NewSHA256()
+	defer h.(io.Closer).Close()
+	return openssl.DecryptRSAOAEP(h, k.priv, wrapped, nil)
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// ErrKeyringClosed is returned by the methods of a closed Keyring.
+var ErrKeyringClosed = errors.New("envelope: keyring closed")
+
+// A Keyring manages named data keys wrapped under a master KEK.
+// Only the wrapped keys are meant to be persisted, see Wrapped and Add.
+// Keys are unwrapped on demand and kept in memory until Delete or Close,
+// which zeroize them. They are protected with openssl.ProtectSecret,
+// so they are also locked in memory with openssl.InitOptions.LockSecrets.
+// A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu        sync.Mutex
+	master    KEK
+	wrapped   map[string][]byte
+	unwrapped map[string]unwrappedKey
+	closed    bool
+}
+
+type unwrappedKey struct {
+	key     []byte
+	release func()
+}
+
+// NewKeyring returns an empty Keyring wrapping its keys under master.
+func NewKeyring(master KEK) *Keyring {
+	return &Keyring{
+		master:    master,
+		wrapped:   make(map[string][]byte),
+		unwrapped: make(map[string]unwrappedKey),
+	}
+}
+
+// Create generates a random key of size bytes named name, wraps it
+// under the master KEK and returns the wrapped key.
+// The AESKeyWrap algorithm requires a multiple of 8 bytes, from 16 bytes.
+func (r *Keyring) Create(name string, size int) ([]byte, error) {
+	key := make([]byte, size)
+	release, err := openssl.ProtectSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if _, err := io.ReadFull(openssl.RandReader, key); err != nil {
+		return nil, err
+	}
+	return r.Import(name, key)
+}
+
+// Import wraps key under the master KEK, adds it as name
+// and returns the wrapped key. key isn't retained.
+func (r *Keyring) Import(name string, key []byte) ([]byte, error) {
+	wrapped, err := r.master.Wrap(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Add(name, wrapped); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), wrapped...), nil
+}
+
+// Add adds the key name, wrapped under the master KEK, as returned
+// by Create, Import or Wrapped. It is unwrapped on first use.
+func (r *Keyring) Add(name string, wrapped []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrKeyringClosed
+	}
+	if _, ok := r.wrapped[name]; ok {
+		return errors.New("envelope: key " + name + " already exists")
+	}
+	r.wrapped[name] = append([]byte(nil), wrapped...)
+	return nil
+}
+
+// Names returns the sorted names of the keys of r.
+func (r *Keyring) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.wrapped))
+	for name := range r.wrapped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wrapped returns the key name wrapped under the master KEK.
+func (r *Keyring) Wrapped(name string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrKeyringClosed
+	}
+	wrapped, ok := r.wrapped[name]
+	if !ok {
+		return nil, errNoKey(name)
+	}
+	return append([]byte(nil), wrapped...), nil
+}
+
+// Key returns the key name, unwrapping it if needed. The returned
+// slice is owned by r and zeroized by Delete and Close: it must not
+// be modified nor retained.
+func (r *Keyring) Key(name string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrKeyringClosed
+	}
+	if u, ok := r.unwrapped[name]; ok {
+		return u.key, nil
+	}
+	wrapped, ok := r.wrapped[name]
+	if !ok {
+		return nil, errNoKey(name)
+	}
+	key, release, err := unwrapDEK(r.master, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	r.unwrapped[name] = unwrappedKey{key, release}
+	return key, nil
+}
+
+// KEK returns a KEK wrapping DEKs with the key name, an
+// AES key, for key hierarchies of more than two levels.
+// The KEK is identified by name and is only valid until Delete or Close.
+func (r *Keyring) KEK(name string) (KEK, error) {
+	key, err := r.Key(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAESKEKSize(key); err != nil {
+		return nil, err
+	}
+	// Share key, rather than copying it as NewAESKEK does,
+	// so that the KEK is zeroized along with the key.
+	// Closing the KEK leaves the key alone.
+	return &aesKEK{id: name, key: key}, nil
+}
+
+// Delete removes the key name from r and zeroizes it.
+func (r *Keyring) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrKeyringClosed
+	}
+	if _, ok := r.wrapped[name]; !ok {
+		return errNoKey(name)
+	}
+	delete(r.wrapped, name)
+	if u, ok := r.unwrapped[name]; ok {
+		u.release()
+		delete(r.unwrapped, name)
+	}
+	return nil
+}
+
+// Close zeroizes the unwrapped keys of r.
+// r can't be used anymore after Close.
+func (r *Keyring) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.unwrapped {
+		u.release()
+	}
+	r.unwrapped = nil
+	r.wrapped = nil
+	r.closed = true
+	return nil
+}
+
+func errNoKey(name string) error {
+	return errors.New("envelope: no key named " + name)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	master, _ := NewAESKEK("master", bytes.Repeat([]byte{1}, 32))
+	r := NewKeyring(master)
+	wrapped, err := r.Create("data", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create("data", 32); err == nil {
+		t.Error("Create() of an existing key succeeded")
+	}
+	key, err := r.Key("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("len(Key()) = %d, want 32", len(key))
+	}
+	kek, err := r.KEK("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := Seal(kek, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new keyring with the persisted wrapped key opens the envelope.
+	r2 := NewKeyring(master)
+	defer r2.Close()
+	if err := r2.Add("data", wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if names := r2.Names(); len(names) != 1 || names[0] != "data" {
+		t.Errorf("Names() = %v, want [data]", names)
+	}
+	kek2, err := r2.KEK("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Open(kek2, env, nil); err != nil || string(got) != "secret" {
+		t.Errorf("Open() = %q, %v", got, err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, make([]byte, 32)) {
+		t.Error("Close() didn't zeroize the key")
+	}
+	if _, err := r.Key("data"); err != ErrKeyringClosed {
+		t.Errorf("Key() after Close() error = %v, want ErrKeyringClosed", err)
+	}
+}
+
+func TestKeyringDelete(t *testing.T) {
+	master, _ := NewAESKEK("master", bytes.Repeat([]byte{1}, 16))
+	r := NewKeyring(master)
+	defer r.Close()
+	if _, err := r.Import("k", bytes.Repeat([]byte{2}, 16)); err != nil {
+		t.Fatal(err)
+	}
+	key, err := r.Key("k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, bytes.Repeat([]byte{2}, 16)) {
+		t.Errorf("Key() = %x", key)
+	}
+	if err := r.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, make([]byte, 16)) {
+		t.Error("Delete() didn't zeroize the key")
+	}
+	if _, err := r.Wrapped("k"); err == nil {
+		t.Error("Wrapped() of a deleted key succeeded")
+	}
+}
+
+func TestAESKEKClose(t *testing.T) {
+	k, err := NewAESKEK("kek", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := k.(*aesKEK).key
+	if err := k.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, make([]byte, 32)) {
+		t.Error("Close() didn't zeroize the key")
+	}
+	// Closing twice is harmless.
+	if err := k.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+}
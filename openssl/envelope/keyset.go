@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"errors"
+	"io"
+)
+
+// A Keyset holds the current KEK, which seals new envelopes and streams,
+// and the retired KEKs, which only open existing ones. Since headers
+// carry the ID of their KEK, the KEK can be rotated without
+// re-encrypting sealed data, by keeping the previous one as retired.
+// A Keyset is immutable and safe for concurrent use.
+type Keyset struct {
+	primary KEK
+	keys    []KEK
+}
+
+// NewKeyset returns a Keyset sealing with primary and opening with
+// primary and retired. The KEKs must differ by ID or algorithm.
+func NewKeyset(primary KEK, retired ...KEK) (*Keyset, error) {
+	if primary == nil {
+		return nil, errors.New("envelope: missing primary KEK")
+	}
+	keys := make([]KEK, 0, 1+len(retired))
+	for _, kek := range append([]KEK{primary}, retired...) {
+		for _, k := range keys {
+			if k.ID() == kek.ID() && k.Algorithm() == kek.Algorithm() {
+				return nil, errors.New("envelope: duplicate KEK " + kek.ID())
+			}
+		}
+		keys = append(keys, kek)
+	}
+	return &Keyset{primary: primary, keys: keys}, nil
+}
+
+// Rotate returns a new Keyset sealing with primary, in which the
+// current primary KEK of s becomes retired.
+func (s *Keyset) Rotate(primary KEK) (*Keyset, error) {
+	return NewKeyset(primary, s.keys...)
+}
+
+// Primary returns the KEK sealing new envelopes and streams.
+func (s *Keyset) Primary() KEK {
+	return s.primary
+}
+
+// Lookup returns the KEK of s with the given ID and algorithm, if any.
+func (s *Keyset) Lookup(id string, alg WrapAlgorithm) (KEK, bool) {
+	for _, kek := range s.keys {
+		if kek.ID() == id && kek.Algorithm() == alg {
+			return kek, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Keyset) lookup(h *Header) (KEK, error) {
+	if kek, ok := s.Lookup(h.KeyID, h.WrapAlgorithm); ok {
+		return kek, nil
+	}
+	return nil, ErrKeyMismatch
+}
+
+// Seal is like the Seal function, with the primary KEK of s.
+func (s *Keyset) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	return Seal(s.primary, plaintext, additionalData)
+}
+
+// Open is like the Open function, with the KEK of s named by the
+// header of envelope. It returns ErrKeyMismatch if s has no such KEK.
+func (s *Keyset) Open(envelope, additionalData []byte) ([]byte, error) {
+	return open(s.lookup, envelope, additionalData)
+}
+
+// NewWriter is like the NewWriter function, with the primary KEK of s.
+func (s *Keyset) NewWriter(w io.Writer, additionalData []byte) (*Writer, error) {
+	return NewWriter(w, s.primary, additionalData)
+}
+
+// NewReader is like the NewReader function, with the KEK of s named by
+// the header of the stream. It returns ErrKeyMismatch if s has no such KEK.
+func (s *Keyset) NewReader(r io.Reader, additionalData []byte) (*Reader, error) {
+	return newReader(r, s.lookup, additionalData)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestKeysetRotation(t *testing.T) {
+	kek1, _ := NewAESKEK("v1", bytes.Repeat([]byte{1}, 32))
+	kek2, _ := NewAESKEK("v2", bytes.Repeat([]byte{2}, 32))
+	ks1, err := NewKeyset(kek1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env1, err := ks1.Seal([]byte("old"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stream1 bytes.Buffer
+	w, err := ks1.NewWriter(&stream1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("old stream"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ks2, err := ks1.Rotate(kek2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks2.Primary().ID() != "v2" {
+		t.Errorf("Primary().ID() = %q, want v2", ks2.Primary().ID())
+	}
+	env2, err := ks2.Seal([]byte("new"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := ParseHeader(env2); h.KeyID != "v2" {
+		t.Errorf("KeyID = %q, want v2", h.KeyID)
+	}
+	for _, tt := range []struct {
+		env  []byte
+		want string
+	}{{env1, "old"}, {env2, "new"}} {
+		got, err := ks2.Open(tt.env, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Open() = %q, want %q", got, tt.want)
+		}
+	}
+	r, err := ks2.NewReader(&stream1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(r); err != nil || string(got) != "old stream" {
+		t.Errorf("ReadAll() = %q, %v", got, err)
+	}
+	if _, err := ks1.Open(env2, nil); err != ErrKeyMismatch {
+		t.Errorf("Open() with retired keyset error = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestKeysetDuplicate(t *testing.T) {
+	kek1, _ := NewAESKEK("v1", bytes.Repeat([]byte{1}, 32))
+	kek2, _ := NewAESKEK("v1", bytes.Repeat([]byte{2}, 32))
+	if _, err := NewKeyset(kek1, kek2); err == nil {
+		t.Error("NewKeyset() with duplicate IDs succeeded")
+	}
+	if _, err := NewKeyset(nil); err == nil {
+		t.Error("NewKeyset(nil) succeeded")
+	}
+}
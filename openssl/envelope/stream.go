@@ -0,0 +1,363 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// Streams encrypt large objects, such as backups, in chunks so that
+// they never have to be held in memory. A stream is laid out as
+// follows, with big-endian lengths:
+//
+//	magic       [4]byte  "OSTR"
+//	version     uint8    1
+//	data alg    uint8    1 for AES-256-GCM
+//	wrap alg    uint8    see WrapAlgorithm
+//	key ID      uint8 length, then the ID of the KEK
+//	wrapped DEK uint16 length, then the wrapped DEK
+//	chunk size  uint32
+//	nonce       [7]byte  nonce prefix
+//	chunks      the encrypted chunks, each followed by its 16 bytes GCM tag
+//
+// Every chunk but the last holds chunk size bytes of plaintext, the last
+// one holds the rest, possibly nothing. The nonce of a chunk is the nonce
+// prefix, the index of the chunk as uint32 and a byte set to 1 for the
+// last chunk and 0 otherwise, as in the STREAM construction, so that
+// reordered, removed or truncated chunks fail authentication. The
+// additional data of every chunk is the header followed by the
+// additional data of the caller.
+
+const (
+	// DefaultChunkSize is the plaintext size of the chunks of streams.
+	DefaultChunkSize = 64 << 10
+	// maxChunkSize bounds the memory allocated by NewReader.
+	maxChunkSize = 16 << 20
+
+	noncePrefixSize = nonceSize - 5
+)
+
+var streamMagic = [4]byte{'O', 'S', 'T', 'R'}
+
+// A Writer encrypts the data written to it as a stream.
+// Close must be called to write the last chunk.
+type Writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	ad      []byte
+	prefix  []byte
+	counter uint32
+	nonce   []byte
+	buf     []byte
+	out     []byte
+	err     error
+}
+
+// NewWriter writes the header of a new stream to w
+// and returns a Writer encrypting data to w under a new
+// random DEK wrapped by kek, authenticating additionalData.
+// additionalData isn't stored in the stream and must be given to NewReader.
+func NewWriter(w io.Writer, kek KEK, additionalData []byte) (*Writer, error) {
+	return NewWriterSize(w, kek, additionalData, DefaultChunkSize)
+}
+
+// NewWriterSize is like NewWriter but encrypts chunks of chunkSize
+// bytes, which can't exceed 16 MiB.
+func NewWriterSize(w io.Writer, kek KEK, additionalData []byte, chunkSize int) (*Writer, error) {
+	if chunkSize <= 0 || chunkSize > maxChunkSize {
+		return nil, errors.New("envelope: invalid chunk size " + strconv.Itoa(chunkSize))
+	}
+	dek, wrapped, release, err := newDEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(openssl.RandReader, prefix); err != nil {
+		return nil, err
+	}
+	h := &Header{Version: version1, KeyID: kek.ID(), WrapAlgorithm: kek.Algorithm(), WrappedKey: wrapped, Nonce: prefix}
+	raw, err := h.marshal(streamMagic)
+	if err != nil {
+		return nil, err
+	}
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(chunkSize))
+	raw = append(raw, n[:]...)
+	raw = append(raw, prefix...)
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(raw); err != nil {
+		closeAEAD(aead)
+		return nil, err
+	}
+	return &Writer{
+		w:      w,
+		aead:   aead,
+		ad:     concat(raw, additionalData),
+		prefix: prefix,
+		nonce:  make([]byte, nonceSize),
+		buf:    make([]byte, 0, chunkSize),
+		out:    make([]byte, 0, chunkSize+tagSize),
+	}, nil
+}
+
+// Write encrypts p. Chunks are written to the underlying writer
+// as they fill up, apart from the last one, which is written by Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for len(p) > 0 {
+		// Only flush a full chunk once more data arrives,
+		// since Close must write the last chunk as such.
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes the last chunk. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		if w.err == errClosed {
+			return nil
+		}
+		return w.err
+	}
+	if err := w.flush(true); err != nil {
+		return err
+	}
+	w.err = errClosed
+	closeAEAD(w.aead)
+	return nil
+}
+
+var errClosed = errors.New("envelope: write to closed Writer")
+
+func (w *Writer) flush(last bool) error {
+	if w.counter == ^uint32(0) && !last {
+		w.err = errors.New("envelope: stream too long")
+		return w.err
+	}
+	w.out = w.aead.Seal(w.out[:0], chunkNonce(w.nonce, w.prefix, w.counter, last), w.buf, w.ad)
+	openssl.Cleanse(w.buf)
+	w.buf = w.buf[:0]
+	w.counter++
+	if _, err := w.w.Write(w.out); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// A Reader decrypts a stream written by a Writer.
+type Reader struct {
+	r         *bufio.Reader
+	h         *Header
+	aead      cipher.AEAD
+	ad        []byte
+	counter   uint32
+	nonce     []byte
+	buf       []byte
+	plaintext []byte
+	done      bool
+	err       error
+}
+
+// NewReader reads the header of the stream in r, unwraps its DEK with
+// kek and returns a Reader decrypting the stream, which must have been
+// written with additionalData. It returns ErrKeyMismatch if the header
+// names another KEK or wrapping algorithm. Read only returns data once
+// its chunk is authenticated, but the stream as a whole is only
+// authenticated once Read returns io.EOF.
+func NewReader(r io.Reader, kek KEK, additionalData []byte) (*Reader, error) {
+	return newReader(r, singleKEK(kek), additionalData)
+}
+
+// newReader is like NewReader but gets the KEK of the stream
+// from its header with lookup.
+func newReader(r io.Reader, lookup lookupFunc, additionalData []byte) (*Reader, error) {
+	br := bufio.NewReader(r)
+	h, chunkSize, err := readStreamHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := lookup(h)
+	if err != nil {
+		return nil, err
+	}
+	dek, release, err := unwrapDEK(kek, h.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		r:     br,
+		h:     h,
+		aead:  aead,
+		ad:    concat(h.raw, additionalData),
+		nonce: make([]byte, nonceSize),
+		buf:   make([]byte, chunkSize+tagSize),
+	}, nil
+}
+
+// Close frees the native resources of r, which can't be used anymore.
+// It doesn't close the underlying reader. Close is only required with
+// openssl.InitOptions.ExplicitClose.
+func (r *Reader) Close() error {
+	if r.err == errReaderClosed {
+		return nil
+	}
+	r.err = errReaderClosed
+	r.plaintext = nil
+	closeAEAD(r.aead)
+	return nil
+}
+
+var errReaderClosed = errors.New("envelope: read from closed Reader")
+
+// Header returns the header of the stream.
+func (r *Reader) Header() *Header {
+	return r.h
+}
+
+// Read decrypts data from the stream into p.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			r.err = err
+			return 0, err
+		}
+	}
+	n := copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+func (r *Reader) readChunk() error {
+	n, err := io.ReadFull(r.r, r.buf)
+	last := false
+	switch err {
+	case nil:
+		// A full chunk is the last one if nothing follows it.
+		if _, err := r.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.ErrUnexpectedEOF:
+		last = true
+	case io.EOF:
+		// Even an empty last chunk has a tag.
+		return io.ErrUnexpectedEOF
+	default:
+		return err
+	}
+	if n < tagSize {
+		return io.ErrUnexpectedEOF
+	}
+	if !last && r.counter == ^uint32(0) {
+		return errors.New("envelope: stream too long")
+	}
+	plaintext, err := r.aead.Open(r.buf[:0], chunkNonce(r.nonce, r.h.Nonce, r.counter, last), r.buf[:n], r.ad)
+	if err != nil {
+		return errOpen
+	}
+	r.counter++
+	r.plaintext = plaintext
+	r.done = last
+	return nil
+}
+
+// chunkNonce writes the nonce of the chunk of index counter to nonce,
+// nonceSize bytes reused across chunks, and returns it.
+func chunkNonce(nonce, prefix []byte, counter uint32, last bool) []byte {
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	nonce[nonceSize-1] = 0
+	if last {
+		nonce[nonceSize-1] = 1
+	}
+	return nonce
+}
+
+// readStreamHeader reads and decodes the header of a stream
+// and returns it with the chunk size.
+func readStreamHeader(r io.Reader) (*Header, int, error) {
+	errFormat := errors.New("envelope: invalid stream")
+	raw := make([]byte, len(streamMagic)+4)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, 0, errFormat
+	}
+	if string(raw[:len(streamMagic)]) != string(streamMagic[:]) {
+		return nil, 0, errFormat
+	}
+	s := raw[len(streamMagic):]
+	if s[0] != version1 {
+		return nil, 0, errors.New("envelope: unsupported version " + strconv.Itoa(int(s[0])))
+	}
+	if s[1] != aes256GCM {
+		return nil, 0, errors.New("envelope: unsupported data algorithm " + strconv.Itoa(int(s[1])))
+	}
+	h := &Header{Version: s[0], WrapAlgorithm: WrapAlgorithm(s[2])}
+	// read appends the next n bytes of r to raw and returns them.
+	read := func(n int) ([]byte, error) {
+		off := len(raw)
+		raw = append(raw, make([]byte, n)...)
+		if _, err := io.ReadFull(r, raw[off:]); err != nil {
+			return nil, errFormat
+		}
+		return raw[off:], nil
+	}
+	idLen := int(s[3])
+	id, err := read(idLen + 2)
+	if err != nil {
+		return nil, 0, err
+	}
+	h.KeyID = string(id[:idLen])
+	wrappedLen := int(binary.BigEndian.Uint16(id[idLen:]))
+	rest, err := read(wrappedLen + 4 + noncePrefixSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	h.WrappedKey = append([]byte(nil), rest[:wrappedLen]...)
+	chunkSize := binary.BigEndian.Uint32(rest[wrappedLen:])
+	if chunkSize == 0 || chunkSize > maxChunkSize {
+		return nil, 0, errors.New("envelope: invalid chunk size " + strconv.Itoa(int(chunkSize)))
+	}
+	h.Nonce = append([]byte(nil), rest[wrappedLen+4:]...)
+	h.raw = raw
+	return h, int(chunkSize), nil
+}
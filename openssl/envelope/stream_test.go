@@ -0,0 +1,117 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package envelope
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func sealStream(t *testing.T, kek KEK, plaintext []byte, chunkSize int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriterSize(&buf, kek, []byte("ad"), chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write in odd sizes to cross chunk boundaries.
+	for p := plaintext; len(p) > 0; {
+		n := 7
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte{0}); err == nil {
+		t.Error("Write() after Close() succeeded")
+	}
+	return buf.Bytes()
+}
+
+func openStream(kek KEK, stream []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(stream), kek, []byte("ad"))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	kek, err := NewAESKEK("stream", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const chunkSize = 32
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, 1000} {
+		plaintext := bytes.Repeat([]byte{'x'}, size)
+		stream := sealStream(t, kek, plaintext, chunkSize)
+		got, err := openStream(kek, stream)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("size %d: got %d bytes", size, len(got))
+		}
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	kek, err := NewAESKEK("stream", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const chunkSize = 16
+	stream := sealStream(t, kek, bytes.Repeat([]byte{'x'}, 3*chunkSize), chunkSize)
+	for i := range stream {
+		tampered := append([]byte(nil), stream...)
+		tampered[i] ^= 1
+		if _, err := openStream(kek, tampered); err == nil {
+			t.Fatalf("byte %d modified: open succeeded", i)
+		}
+	}
+	// Truncating at a chunk boundary must be detected too.
+	for n := 0; n < len(stream); n++ {
+		if _, err := openStream(kek, stream[:n]); err == nil {
+			t.Fatalf("truncated to %d bytes: open succeeded", n)
+		}
+	}
+	r, err := NewReader(bytes.NewReader(stream), kek, []byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("open with other additional data succeeded")
+	}
+	if r.Header().KeyID != "stream" {
+		t.Errorf("Header().KeyID = %q, want stream", r.Header().KeyID)
+	}
+}
+
+func TestStreamAllocs(t *testing.T) {
+	kek, err := NewAESKEK("stream", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const chunkSize = 64
+	w, err := NewWriterSize(io.Discard, kek, nil, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	chunk := make([]byte, chunkSize)
+	// Every write but the first one flushes a chunk.
+	if n := testing.AllocsPerRun(10, func() { w.Write(chunk) }); n > 0 {
+		t.Errorf("Write allocated %v times per chunk", n)
+	}
+}
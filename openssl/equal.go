@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+)
+
+// The Equal methods compare keys with EVP_PKEY_eq, which checks that
+// both keys are of the same type, with the same parameters and public
+// components. The public components determine the private ones of a
+// key pair, so private keys are compared that way too, without
+// comparing secrets, even for keys whose material can't be exported.
+
+// pkeyEqual reports whether the keys of a and b are equal.
+func pkeyEqual(a, b withKeyFunc) bool {
+	var eq C.int
+	a(func(pa C.GO_EVP_PKEY_PTR) C.int {
+		return b(func(pb C.GO_EVP_PKEY_PTR) C.int {
+			eq = C.go_openssl_EVP_PKEY_eq(pa, pb)
+			return 1
+		})
+	})
+	if eq != 1 {
+		// EVP_PKEY_eq can leave an error when the key types differ.
+		C.go_openssl_ERR_clear_error()
+	}
+	return eq == 1
+}
+
+// Equal reports whether k and x, a *PublicKeyRSA, are the same key.
+func (k *PublicKeyRSA) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKeyRSA)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PrivateKeyRSA, are the same key.
+func (k *PrivateKeyRSA) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKeyRSA)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PublicKeyECDSA, are the same key.
+func (k *PublicKeyECDSA) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKeyECDSA)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PrivateKeyECDSA, are the same key.
+func (k *PrivateKeyECDSA) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKeyECDSA)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+func (k *PublicKeyECDH) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
+}
+
+func (k *PrivateKeyECDH) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
+}
+
+// Equal reports whether k and x, a *PublicKeyECDH, are the same key.
+func (k *PublicKeyECDH) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKeyECDH)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PrivateKeyECDH, are the same key.
+func (k *PrivateKeyECDH) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKeyECDH)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PKey, are the same key.
+// A public and a private key are equal if they are of the same key pair,
+// use the PublicKeyBytes of public keys to tell them apart.
+func (k *PKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PKey)
+	return ok && pkeyEqual(k.withKey, xx.withKey)
+}
+
+// Equal reports whether k and x, a *PrivateKeyMLDSA, are the same key.
+func (priv *PrivateKeyMLDSA) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKeyMLDSA)
+	return ok && priv.params == xx.params && priv.pkey.Equal(xx.pkey)
+}
+
+// Equal reports whether k and x, a *PublicKeyMLDSA, are the same key.
+func (pub *PublicKeyMLDSA) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKeyMLDSA)
+	return ok && pub.params == xx.params && pub.pkey.Equal(xx.pkey)
+}
+
+// Equal reports whether k and x, a *DecapsulationKeyMLKEM, are the same key.
+func (dk *DecapsulationKeyMLKEM) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*DecapsulationKeyMLKEM)
+	return ok && dk.params == xx.params && dk.pkey.Equal(xx.pkey)
+}
+
+// Equal reports whether k and x, an *EncapsulationKeyMLKEM, are the same key.
+func (ek *EncapsulationKeyMLKEM) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*EncapsulationKeyMLKEM)
+	return ok && ek.params == xx.params && ek.pkey.Equal(xx.pkey)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestEqualRSA(t *testing.T) {
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv1, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv2, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub1, err := openssl.NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := openssl.NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv1.Equal(priv2) || !pub1.Equal(pub2) {
+		t.Error("Equal() = false for the same key")
+	}
+	N, E, _, _, _, _, _, _, err = openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := openssl.NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub1.Equal(other) {
+		t.Error("Equal() = true for another key")
+	}
+	if pub1.Equal(priv1) {
+		t.Error("Equal() = true for a key of another type")
+	}
+}
+
+func TestEqualECDSA(t *testing.T) {
+	newKey := func(curve string) (*openssl.PrivateKeyECDSA, *openssl.PublicKeyECDSA) {
+		X, Y, D, err := openssl.GenerateKeyECDSA(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := openssl.NewPrivateKeyECDSA(curve, X, Y, D)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := openssl.NewPublicKeyECDSA(curve, X, Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return priv, pub
+	}
+	priv1, pub1 := newKey("P-256")
+	priv2, pub2 := newKey("P-256")
+	_, pub3 := newKey("P-384")
+	if !priv1.Equal(priv1) || !pub1.Equal(pub1) {
+		t.Error("Equal() = false for the same key")
+	}
+	if priv1.Equal(priv2) || pub1.Equal(pub2) || pub1.Equal(pub3) {
+		t.Error("Equal() = true for another key")
+	}
+	var _ interface{ Equal(crypto.PublicKey) bool } = pub1
+}
+
+func TestEqualECDH(t *testing.T) {
+	priv, _, err := openssl.GenerateKeyECDH("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub1, err := priv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := openssl.GenerateKeyECDH("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := other.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.Equal(priv) || !pub1.Equal(pub1) {
+		t.Error("Equal() = false for the same key")
+	}
+	if priv.Equal(other) || pub1.Equal(pub2) {
+		t.Error("Equal() = true for another key")
+	}
+}
+
+func TestEqualPKey(t *testing.T) {
+	k1, err := openssl.GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	k2, err := openssl.GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := k1.PublicKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk1, err := openssl.NewPublicPKey("ED25519", pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k1.Equal(pk1) {
+		t.Error("Equal() = false for the public key of the pair")
+	}
+	if k1.Equal(k2) {
+		t.Error("Equal() = true for another key")
+	}
+	x, err := openssl.GenerateKey("X25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.Equal(x) {
+		t.Error("Equal() = true for a key of another type")
+	}
+}
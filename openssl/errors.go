@@ -0,0 +1,348 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// The following errors are matched, using errors.Is, by the errors
+// returned by this package, so that callers can branch on the kind of
+// a failure rather than on its message. An *Error matches them when
+// the OpenSSL error queue holds a reason code of that kind, as well as
+// when the failing operation implies it, such as a failed
+// EVP_PKEY_verify.
+var (
+	// ErrUnsupported is matched when an algorithm, key type or
+	// function isn't supported. It is ErrNotSupported.
+	ErrUnsupported = ErrNotSupported
+	// ErrVerification is matched when a signature is invalid.
+	ErrVerification = errors.New("openssl: invalid signature")
+	// ErrDecryption is matched when a ciphertext can't be decrypted,
+	// for example because of invalid padding.
+	ErrDecryption = errors.New("openssl: decryption error")
+	// ErrFIPSUnapproved is matched when an operation is rejected
+	// because it isn't FIPS approved, either by strict FIPS mode
+	// or by the FIPS provider. It is ErrNotApproved.
+	ErrFIPSUnapproved = ErrNotApproved
+	// ErrNoEntropy is matched when the random number generator
+	// can't get entropy from its seed source.
+	ErrNoEntropy = errors.New("openssl: error retrieving entropy")
+)
+
+// Error is returned when an OpenSSL call fails. It describes the first
+// error of the OpenSSL error queue at the time of the failure, which
+// is usually the root cause of the failure, with the library and
+// reason codes of openssl/err.h, such as ERR_LIB_RSA and
+// RSA_R_PADDING_CHECK_FAILED, so that callers can tell failures apart
+// without matching error strings. Stack holds the whole queue.
+type Error struct {
+	// Op describes the OpenSSL call that failed, usually with the
+	// name of the function, such as "EVP_PKEY_decrypt failed".
+	Op string
+	// Code is the packed OpenSSL error code, or 0 if the
+	// error queue was empty.
+	Code uint64
+	// Lib is the library code of Code, such as ERR_LIB_EVP.
+	Lib int
+	// Reason is the reason code of Code, such as EVP_R_BAD_DECRYPT.
+	Reason int
+	// ReasonString is the human-readable reason of Code,
+	// as returned by ERR_reason_error_string, if known.
+	ReasonString string
+	// Stack holds the errors of the OpenSSL error queue at
+	// the time of the failure, from the oldest to the newest.
+	Stack []ErrorRecord
+
+	// msg holds the description of the whole error queue.
+	msg string
+	// kind is the sentinel error implied by the failing operation, if any.
+	kind error
+}
+
+// ErrorRecord is an error of the OpenSSL error queue.
+type ErrorRecord struct {
+	// Code, Lib, Reason and ReasonString are as in Error.
+	Code         uint64
+	Lib          int
+	Reason       int
+	ReasonString string
+	// Func is the name of the OpenSSL function reporting the error,
+	// if OpenSSL was built with it.
+	Func string
+	// File and Line are the source location reporting the error,
+	// if OpenSSL was built with them.
+	File string
+	Line int
+	// Data is the additional text data of the error, such as the
+	// name of an algorithm that couldn't be fetched, if any.
+	Data string
+	// Flags are the ERR_TXT_* flags of the error data.
+	Flags int
+}
+
+// String returns the ERR_error_string_n description of r,
+// with its data and source location.
+func (r ErrorRecord) String() string {
+	var buf [256]byte
+	C.go_openssl_ERR_error_string_n(C.ulong(r.Code), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
+	s := C.GoString((*C.char)(unsafe.Pointer(&buf[0])))
+	if r.Data != "" {
+		s += ":" + r.Data
+	}
+	if r.File != "" {
+		s += " (" + r.File + ":" + strconv.Itoa(r.Line)
+		if r.Func != "" {
+			s += " in " + r.Func
+		}
+		s += ")"
+	}
+	return s
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether e matches target, one of the sentinel errors
+// such as ErrVerification, because of the failing operation or
+// of the reason code of one of the errors of Stack.
+func (e *Error) Is(target error) bool {
+	if e.kind != nil && target == e.kind {
+		return true
+	}
+	for _, r := range e.Stack {
+		if reasonError(r.Lib, r.Reason) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// reasonError returns the sentinel error matching the library
+// and reason codes lib and reason, or nil if there is none.
+func reasonError(lib, reason int) error {
+	if vMajor >= 3 {
+		// Common reasons are shared by all the libraries.
+		switch reason {
+		case C.GO_ERR_R_UNSUPPORTED, C.GO_ERR_R_FETCH_FAILED:
+			return ErrUnsupported
+		}
+		if lib == C.GO_ERR_LIB_PROV {
+			switch reason {
+			case C.GO_PROV_R_BAD_DECRYPT:
+				return ErrDecryption
+			case C.GO_PROV_R_DIGEST_NOT_ALLOWED:
+				return ErrFIPSUnapproved
+			case C.GO_PROV_R_ERROR_RETRIEVING_ENTROPY:
+				return ErrNoEntropy
+			}
+			return nil
+		}
+	}
+	switch lib {
+	case C.GO_ERR_LIB_RSA:
+		switch reason {
+		case C.GO_RSA_R_BAD_SIGNATURE, C.GO_RSA_R_WRONG_SIGNATURE_LENGTH:
+			return ErrVerification
+		case C.GO_RSA_R_OAEP_DECODING_ERROR, C.GO_RSA_R_PKCS_DECODING_ERROR:
+			return ErrDecryption
+		case C.GO_RSA_R_DIGEST_NOT_ALLOWED:
+			return ErrFIPSUnapproved
+		}
+	case C.GO_ERR_LIB_EVP:
+		switch reason {
+		case C.GO_EVP_R_BAD_DECRYPT:
+			return ErrDecryption
+		case C.GO_EVP_R_UNSUPPORTED_ALGORITHM, C.GO_EVP_R_UNSUPPORTED_KEY_TYPE:
+			return ErrUnsupported
+		}
+	case C.GO_ERR_LIB_EC:
+		if reason == C.GO_EC_R_BAD_SIGNATURE {
+			return ErrVerification
+		}
+	case C.GO_ERR_LIB_RAND:
+		if reason == C.GO_RAND_R_ERROR_RETRIEVING_ENTROPY {
+			return ErrNoEntropy
+		}
+	}
+	return nil
+}
+
+// errSystemFlag is ERR_SYSTEM_FLAG, set on the codes of
+// system errors since OpenSSL 3.
+const errSystemFlag = 1 << 31
+
+// errLibSys is ERR_LIB_SYS.
+const errLibSys = 2
+
+// errLibReason returns the library and reason codes of the packed
+// OpenSSL error code e, as ERR_GET_LIB and ERR_GET_REASON do.
+func errLibReason(e C.ulong) (lib, reason int) {
+	if vMajor < 3 {
+		return int(e>>24) & 0xFF, int(e) & 0xFFF
+	}
+	if uint64(e)&errSystemFlag != 0 {
+		return errLibSys, int(e) & (errSystemFlag - 1)
+	}
+	return int(e>>23) & 0xFF, int(e) & 0x7FFFFF
+}
+
+// The OpenSSL error queue is per thread, and goroutines can switch
+// threads between cgo calls, so that errors left in a queue by one
+// operation would show up in the error of an unrelated operation of
+// another goroutine. To prevent that:
+//   - every failing call is followed either by newOpenSSLError, which
+//     drains the queue into the returned error, or by ERR_clear_error,
+//   - calls whose failure isn't an error, such as capability probes,
+//     are run by probe, which leaves the queue as it found it,
+//   - operations are bracketed by beginOp and endOp, which keep the
+//     goroutine on the thread of the queue and clear the errors
+//     left on the thread before the operation starts.
+
+// An op is an operation started by beginOp.
+type op struct {
+	name string
+	kind string
+	// start is zero unless the operation is logged or measured.
+	start time.Time
+}
+
+// beginOp starts the operation name, such as "EVP_PKEY_sign", of the
+// Metrics kind, such as MetricSign, locking the calling goroutine to
+// its thread and clearing the OpenSSL error queue of the thread. It
+// must be paired with endOp, usually as in
+// defer endOp(beginOp(name, kind), &err).
+func beginOp(name, kind string) op {
+	o := beginClearedOp(name, kind)
+	C.go_openssl_ERR_clear_error()
+	return o
+}
+
+// beginClearedOp is like beginOp but leaves clearing the error queue to
+// the operation, whose first cgo call must clear it itself, saving a cgo
+// call on paths made of a single one.
+func beginClearedOp(name, kind string) op {
+	runtime.LockOSThread()
+	o := op{name: name, kind: kind}
+	if atomic.LoadInt32(&debugEnabled) != 0 || atomic.LoadInt32(&metricsEnabled) != 0 {
+		o.start = time.Now()
+	}
+	return o
+}
+
+// endOp ends o, whose result is *err, unlocking the calling goroutine
+// from its thread, logging the duration of o in debug mode and
+// reporting o to the Metrics.
+func endOp(o op, err *error) {
+	runtime.UnlockOSThread()
+	if o.start.IsZero() {
+		return
+	}
+	if atomic.LoadInt32(&debugEnabled) != 0 {
+		debugf("openssl: %s took %v", o.name, time.Since(o.start))
+	}
+	observe(o.kind, o.start, 0, *err)
+}
+
+// probe calls f and removes the errors added to the OpenSSL error
+// queue meanwhile, with ERR_set_mark and ERR_pop_to_mark, keeping
+// those already there.
+func probe(f func() bool) bool {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	C.go_openssl_ERR_set_mark()
+	defer C.go_openssl_ERR_pop_to_mark()
+	return f()
+}
+
+func newOpenSSLError(msg string) error {
+	return newOpenSSLErrorKind(msg, nil)
+}
+
+// newOpenSSLErrorKind is like newOpenSSLError but the returned
+// error also matches kind, the sentinel error implied by the failure.
+func newOpenSSLErrorKind(msg string, kind error) error {
+	if err := missingFunctionError(msg); err != nil {
+		C.go_openssl_ERR_clear_error()
+		return err
+	}
+	err := &Error{Op: msg, kind: kind}
+	for {
+		r, ok := getErrorRecord()
+		if !ok {
+			break
+		}
+		err.Stack = append(err.Stack, r)
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\nopenssl error(s):\n")
+	for _, r := range err.Stack {
+		b.WriteString(r.String())
+		b.WriteByte('\n')
+	}
+	if len(err.Stack) > 0 {
+		r := err.Stack[0]
+		err.Code, err.Lib, err.Reason, err.ReasonString = r.Code, r.Lib, r.Reason, r.ReasonString
+	}
+	err.msg = b.String()
+	return err
+}
+
+// getErrorRecord removes the oldest error of the OpenSSL error queue
+// and returns it, or returns false if the queue is empty.
+func getErrorRecord() (ErrorRecord, bool) {
+	var file, fn, data *C.char
+	var line, flags C.int
+	var e C.ulong
+	if vMajor >= 3 {
+		e = C.go_openssl_ERR_get_error_all(&file, &line, &fn, &data, &flags)
+	} else {
+		e = C.go_openssl_ERR_get_error_line_data(&file, &line, &data, &flags)
+		if e != 0 {
+			fn = C.go_openssl_ERR_func_error_string(e)
+		}
+	}
+	if e == 0 {
+		return ErrorRecord{}, false
+	}
+	r := ErrorRecord{Code: uint64(e), Line: int(line), Flags: int(flags)}
+	r.Lib, r.Reason = errLibReason(e)
+	if s := C.go_openssl_ERR_reason_error_string(e); s != nil {
+		r.ReasonString = C.GoString(s)
+	}
+	if fn != nil {
+		r.Func = C.GoString(fn)
+	}
+	if file != nil {
+		r.File = C.GoString(file)
+	}
+	if data != nil && flags&C.GO_ERR_TXT_STRING != 0 {
+		r.Data = C.GoString(data)
+	}
+	return r, true
+}
+
+// LibString returns the name of the library of e, as returned
+// by ERR_lib_error_string, or its code if unknown.
+func (e *Error) LibString() string {
+	if e.Code != 0 {
+		if s := C.go_openssl_ERR_lib_error_string(C.ulong(e.Code)); s != nil {
+			return C.GoString(s)
+		}
+	}
+	return "lib(" + strconv.Itoa(e.Lib) + ")"
+}
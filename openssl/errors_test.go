@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestError(t *testing.T) {
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = openssl.DecryptRSAOAEP(openssl.NewSHA256(), priv, make([]byte, 256), nil)
+	var e *openssl.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error = %v, want an *openssl.Error", err)
+	}
+	// ERR_LIB_RSA and RSA_R_OAEP_DECODING_ERROR.
+	if e.Lib != 4 || e.Reason != 121 {
+		t.Errorf("Lib, Reason = %d, %d, want 4, 121", e.Lib, e.Reason)
+	}
+	if e.ReasonString != "oaep decoding error" {
+		t.Errorf("ReasonString = %q, want %q", e.ReasonString, "oaep decoding error")
+	}
+	if !strings.Contains(e.LibString(), "rsa") {
+		t.Errorf("LibString() = %q, want the RSA library", e.LibString())
+	}
+	if !strings.HasPrefix(e.Error(), e.Op) || strings.ContainsRune(e.Error(), 0) {
+		t.Errorf("Error() = %q", e.Error())
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	_, err := openssl.GenerateKey("NO-SUCH-ALGORITHM", nil)
+	var e *openssl.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error = %v, want an *openssl.Error", err)
+	}
+	if len(e.Stack) == 0 {
+		t.Fatal("empty error stack")
+	}
+	r := e.Stack[0]
+	if r.Code != e.Code || r.Reason != e.Reason {
+		t.Errorf("Stack[0] = %+v doesn't match the error %+v", r, e)
+	}
+	// The fetch failure names the algorithm in the error data.
+	found := false
+	for _, r := range e.Stack {
+		found = found || strings.Contains(r.Data, "NO-SUCH-ALGORITHM")
+	}
+	if !found {
+		t.Errorf("no error data names the algorithm: %v", e)
+	}
+	if !strings.Contains(e.Error(), e.Stack[0].String()) {
+		t.Errorf("Error() = %q lacks %q", e.Error(), e.Stack[0].String())
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = openssl.DecryptRSAOAEP(openssl.NewSHA256(), priv, make([]byte, 256), nil)
+	if !errors.Is(err, openssl.ErrDecryption) || errors.Is(err, openssl.ErrVerification) {
+		t.Errorf("DecryptRSAOAEP error = %v, want ErrDecryption", err)
+	}
+	hashed := openssl.SHA256([]byte("message"))
+	err = openssl.VerifyRSAPKCS1v15(pub, crypto.SHA256, hashed[:], make([]byte, 256))
+	if !errors.Is(err, openssl.ErrVerification) || errors.Is(err, openssl.ErrDecryption) {
+		t.Errorf("VerifyRSAPKCS1v15 error = %v, want ErrVerification", err)
+	}
+	if openssl.VersionNumber()>>28 == 3 {
+		_, err = openssl.GenerateKey("NO-SUCH-ALGORITHM", nil)
+		if !errors.Is(err, openssl.ErrUnsupported) {
+			t.Errorf("GenerateKey error = %v, want ErrUnsupported", err)
+		}
+	}
+}
+
+func TestErrorIsolation(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The errors of concurrent failures mustn't leak into each other.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if i%2 == 0 {
+					_, err := openssl.DecryptRSAOAEP(openssl.NewSHA256(), priv, make([]byte, 256), nil)
+					if errors.Is(err, openssl.ErrUnsupported) {
+						t.Errorf("DecryptRSAOAEP error = %v, includes unrelated errors", err)
+						return
+					}
+				} else {
+					_, err := openssl.GenerateKey("NO-SUCH-ALGORITHM", nil)
+					if errors.Is(err, openssl.ErrDecryption) {
+						t.Errorf("GenerateKey error = %v, includes unrelated errors", err)
+						return
+					}
+					openssl.SupportsKDF("NO-SUCH-KDF")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
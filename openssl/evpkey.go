@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -12,6 +12,7 @@ import (
 	"crypto"
 	"errors"
 	"hash"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -61,10 +62,18 @@ func cryptoHashToMD(ch crypto.Hash) C.GO_EVP_MD_PTR {
 	return nil
 }
 
-func generateEVPPKey(id C.int, bits int, curve string) (C.GO_EVP_PKEY_PTR, error) {
+func generateEVPPKey(id C.int, bits int, curve string) (_ C.GO_EVP_PKEY_PTR, err error) {
 	if (bits == 0 && curve == "") || (bits != 0 && curve != "") {
 		return nil, fail("incorrect generateEVPPKey parameters")
 	}
+	if bits != 0 {
+		if err := checkStrictRSABits(bits); err != nil {
+			return nil, err
+		}
+	} else if err := checkStrictCurve(curve); err != nil {
+		return nil, err
+	}
+	defer endOp(beginOp("EVP_PKEY_keygen", MetricKeygen), &err)
 	ctx := C.go_openssl_EVP_PKEY_CTX_new_id(id, nil)
 	if ctx == nil {
 		return nil, newOpenSSLError("EVP_PKEY_CTX_new_id failed")
@@ -88,12 +97,41 @@ func generateEVPPKey(id C.int, bits int, curve string) (C.GO_EVP_PKEY_PTR, error
 		}
 	}
 	var pkey C.GO_EVP_PKEY_PTR
-	if C.go_openssl_EVP_PKEY_keygen(ctx, &pkey) != 1 {
-		return nil, newOpenSSLError("EVP_PKEY_keygen failed")
+	keygen := func() {
+		if C.go_openssl_EVP_PKEY_keygen(ctx, &pkey) != 1 {
+			err = newOpenSSLError("EVP_PKEY_keygen failed")
+		}
+	}
+	if bits != 0 {
+		// Generating RSA keys is a long operation, unlike EC keys.
+		runLong(keygen)
+	} else {
+		keygen()
+	}
+	if err != nil {
+		return nil, err
 	}
+	auditPKey(AuditKeygen, pkey)
 	return pkey, nil
 }
 
+// mdCache holds the results of cryptoHashToMD, static objects of
+// OpenSSL, so that hash-then-sign doesn't need a cgo call to get them.
+var mdCache [crypto.BLAKE2b_512 + 1]unsafe.Pointer
+
+// cachedHashToMD is like cryptoHashToMD but caches its result.
+func cachedHashToMD(ch crypto.Hash) C.GO_EVP_MD_PTR {
+	if ch >= crypto.Hash(len(mdCache)) {
+		return nil
+	}
+	if md := atomic.LoadPointer(&mdCache[ch]); md != nil {
+		return C.GO_EVP_MD_PTR(md)
+	}
+	md := cryptoHashToMD(ch)
+	atomic.StorePointer(&mdCache[ch], unsafe.Pointer(md))
+	return md
+}
+
 type withKeyFunc func(func(C.GO_EVP_PKEY_PTR) C.int) C.int
 type initFunc func(C.GO_EVP_PKEY_CTX_PTR) error
 type cryptFunc func(C.GO_EVP_PKEY_CTX_PTR, *C.uchar, *C.size_t, *C.uchar, C.size_t) error
@@ -257,7 +295,11 @@ func verifyEVP(withKey withKeyFunc, padding C.int,
 	return verify(ctx, base(sig), C.size_t(len(sig)), base(in), C.size_t(len(in)))
 }
 
-func evpEncrypt(withKey withKeyFunc, padding C.int, h, mgfHash hash.Hash, label, msg []byte) ([]byte, error) {
+func evpEncrypt(withKey withKeyFunc, padding C.int, h, mgfHash hash.Hash, label, msg []byte) (_ []byte, err error) {
+	if err := checkStrictRSAKey(withKey); err != nil {
+		return nil, err
+	}
+	defer endOp(beginOp("EVP_PKEY_encrypt", MetricEncrypt), &err)
 	encryptInit := func(ctx C.GO_EVP_PKEY_CTX_PTR) error {
 		if ret := C.go_openssl_EVP_PKEY_encrypt_init(ctx); ret != 1 {
 			return newOpenSSLError("EVP_PKEY_encrypt_init failed")
@@ -273,23 +315,43 @@ func evpEncrypt(withKey withKeyFunc, padding C.int, h, mgfHash hash.Hash, label,
 	return cryptEVP(withKey, padding, h, mgfHash, label, 0, 0, encryptInit, encrypt, msg)
 }
 
-func evpDecrypt(withKey withKeyFunc, padding C.int, h, mgfHash hash.Hash, label, msg []byte) ([]byte, error) {
+func evpDecrypt(withKey withKeyFunc, padding C.int, h, mgfHash hash.Hash, label, msg []byte) (_ []byte, err error) {
+	defer endOp(beginOp("EVP_PKEY_decrypt", MetricDecrypt), &err)
+	defer auditKey(AuditDecrypt, withKey, &err)
 	decryptInit := func(ctx C.GO_EVP_PKEY_CTX_PTR) error {
 		if ret := C.go_openssl_EVP_PKEY_decrypt_init(ctx); ret != 1 {
 			return newOpenSSLError("EVP_PKEY_decrypt_init failed")
 		}
 		return nil
 	}
-	decrypt := func(ctx C.GO_EVP_PKEY_CTX_PTR, out *C.uchar, outLen *C.size_t, in *C.uchar, inLen C.size_t) error {
-		if ret := C.go_openssl_EVP_PKEY_decrypt(ctx, out, outLen, in, inLen); ret != 1 {
-			return newOpenSSLError("EVP_PKEY_decrypt failed")
+	decrypt := func(ctx C.GO_EVP_PKEY_CTX_PTR, out *C.uchar, outLen *C.size_t, in *C.uchar, inLen C.size_t) (err error) {
+		f := func() {
+			if ret := C.go_openssl_EVP_PKEY_decrypt(ctx, out, outLen, in, inLen); ret != 1 {
+				err = newOpenSSLErrorKind("EVP_PKEY_decrypt failed", ErrDecryption)
+			}
 		}
-		return nil
+		if isLongRSAKey(withKey) {
+			runLong(f)
+		} else {
+			f()
+		}
+		return err
 	}
 	return cryptEVP(withKey, padding, h, mgfHash, label, 0, 0, decryptInit, decrypt, msg)
 }
 
-func evpSign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, hashed []byte) ([]byte, error) {
+func evpSign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, hashed []byte) (_ []byte, err error) {
+	if err := checkStrictHash(h, true); err != nil {
+		return nil, err
+	}
+	if padding != 0 {
+		// Only RSA keys have a padding mode.
+		if err := checkStrictRSAKey(withKey); err != nil {
+			return nil, err
+		}
+	}
+	defer endOp(beginOp("EVP_PKEY_sign", MetricSign), &err)
+	defer auditKey(AuditSign, withKey, &err)
 	signtInit := func(ctx C.GO_EVP_PKEY_CTX_PTR) error {
 		if ret := C.go_openssl_EVP_PKEY_sign_init(ctx); ret != 1 {
 			return newOpenSSLError("EVP_PKEY_sign_init failed")
@@ -305,7 +367,11 @@ func evpSign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, h
 	return cryptEVP(withKey, padding, nil, nil, nil, saltLen, h, signtInit, sign, hashed)
 }
 
-func evpVerify(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, sig, hashed []byte) error {
+func evpVerify(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, sig, hashed []byte) (err error) {
+	if err := checkStrictHash(h, false); err != nil {
+		return err
+	}
+	defer endOp(beginOp("EVP_PKEY_verify", MetricVerify), &err)
 	verifyInit := func(ctx C.GO_EVP_PKEY_CTX_PTR) error {
 		if ret := C.go_openssl_EVP_PKEY_verify_init(ctx); ret != 1 {
 			return newOpenSSLError("EVP_PKEY_verify_init failed")
@@ -314,7 +380,7 @@ func evpVerify(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash,
 	}
 	verify := func(ctx C.GO_EVP_PKEY_CTX_PTR, out *C.uchar, outLen C.size_t, in *C.uchar, inLen C.size_t) error {
 		if ret := C.go_openssl_EVP_PKEY_verify(ctx, out, outLen, in, inLen); ret != 1 {
-			return newOpenSSLError("EVP_PKEY_verify failed")
+			return newOpenSSLErrorKind("EVP_PKEY_verify failed", ErrVerification)
 		}
 		return nil
 	}
@@ -332,3 +398,76 @@ func newEVPPKEY(key C.GO_EC_KEY_PTR) (C.GO_EVP_PKEY_PTR, error) {
 	}
 	return pkey, nil
 }
+
+// digestSignSize is the size of the signatures evpDigestSign expects,
+// enough for RSA keys of up to 4096 bits and for ECDSA.
+const digestSignSize = 512
+
+// evpDigestSign hashes msg with h and signs the digest with the key of
+// withKey, as evpSign, but in a single cgo call on the hot path.
+func evpDigestSign(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, msg []byte) (_ []byte, err error) {
+	if err := checkStrictHash(h, true); err != nil {
+		return nil, err
+	}
+	if padding != 0 {
+		if err := checkStrictRSAKey(withKey); err != nil {
+			return nil, err
+		}
+	}
+	md := cachedHashToMD(h)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported hash function")
+	}
+	defer endOp(beginClearedOp("EVP_DigestSign", MetricSign), &err)
+	defer auditKey(AuditSign, withKey, &err)
+	pooled, ctx := getMDCtx()
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_MD_CTX_new")
+	}
+	defer pooled.put()
+	sig := make([]byte, digestSignSize)
+	for {
+		var n C.long
+		withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+			n = C.go_openssl_digest_sign_wrapper(ctx, md, pkey, padding, saltLen,
+				base(msg), C.size_t(len(msg)), base(sig), C.size_t(len(sig)))
+			return 1
+		})
+		switch {
+		case n > 0:
+			return sig[:n], nil
+		case n == 0:
+			return nil, newOpenSSLError("EVP_DigestSign")
+		}
+		// The key is larger than expected, retry with the required size.
+		sig = make([]byte, -n)
+		if C.go_openssl_EVP_MD_CTX_reset(ctx) != 1 {
+			return nil, newOpenSSLError("EVP_MD_CTX_reset")
+		}
+	}
+}
+
+// evpDigestVerify verifies that sig is a signature of msg hashed with h
+// by the key of withKey, as evpVerify, in a single cgo call.
+func evpDigestVerify(withKey withKeyFunc, padding C.int, saltLen C.int, h crypto.Hash, msg, sig []byte) (err error) {
+	if err := checkStrictHash(h, false); err != nil {
+		return err
+	}
+	md := cachedHashToMD(h)
+	if md == nil {
+		return errors.New("openssl: unsupported hash function")
+	}
+	defer endOp(beginClearedOp("EVP_DigestVerify", MetricVerify), &err)
+	pooled, ctx := getMDCtx()
+	if ctx == nil {
+		return newOpenSSLError("EVP_MD_CTX_new")
+	}
+	defer pooled.put()
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_digest_verify_wrapper(ctx, md, pkey, padding, saltLen,
+			base(msg), C.size_t(len(msg)), base(sig), C.size_t(len(sig)))
+	}) != 1 {
+		return newOpenSSLErrorKind("EVP_DigestVerify failed", ErrVerification)
+	}
+	return nil
+}
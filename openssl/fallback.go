@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"errors"
+	"hash"
+	"sync"
+)
+
+// ErrNotSupported is matched, using errors.Is, by the errors returned
+// when an algorithm is missing from the loaded OpenSSL library or
+// from the active providers, for example by NewHash, NewKDF, GenerateKey
+// and the LibraryContext methods.
+// It is also matched by the errors of the operations calling a function
+// missing from the loaded OpenSSL library.
+var ErrNotSupported = errors.New("openssl: algorithm not supported")
+
+// NotSupportedError reports that the algorithm Algorithm
+// couldn't be fetched by name, or that the function Algorithm
+// is missing from the loaded OpenSSL library.
+type NotSupportedError struct {
+	Algorithm string
+	// Err is the error reported by OpenSSL, if any.
+	Err error
+}
+
+func (e *NotSupportedError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "openssl: " + e.Algorithm + " not supported"
+}
+
+func (e *NotSupportedError) Unwrap() error { return e.Err }
+
+// Is makes NotSupportedError match ErrNotSupported.
+func (e *NotSupportedError) Is(target error) bool { return target == ErrNotSupported }
+
+// newNotSupportedError returns a NotSupportedError for algorithm,
+// with the errors in the OpenSSL error queue prefixed by msg.
+func newNotSupportedError(algorithm, msg string) error {
+	return &NotSupportedError{Algorithm: algorithm, Err: newOpenSSLError(msg)}
+}
+
+var (
+	fallbackMu    sync.RWMutex
+	hashFallbacks map[string]func() hash.Hash
+)
+
+// RegisterHashFallback registers h as the implementation NewHash returns
+// for the hash name when it isn't available in the loaded OpenSSL, which
+// lets a program run against both minimal and complete OpenSSL builds.
+// A nil h removes the fallback of name.
+//
+// Fallbacks are never used in FIPS mode or in strict FIPS mode, nor by
+// LibraryContext.NewHash, so that hashes are only computed by the
+// selected providers.
+func RegisterHashFallback(name string, h func() hash.Hash) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	if h == nil {
+		delete(hashFallbacks, name)
+		return
+	}
+	if hashFallbacks == nil {
+		hashFallbacks = make(map[string]func() hash.Hash)
+	}
+	hashFallbacks[name] = h
+}
+
+// hashFallback returns the fallback registered for name, if any.
+func hashFallback(name string) func() hash.Hash {
+	fallbackMu.RLock()
+	defer fallbackMu.RUnlock()
+	return hashFallbacks[name]
+}
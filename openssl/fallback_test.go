@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestNotSupported(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("fetching algorithms is only supported on OpenSSL 3")
+	}
+	_, err := NewHash("not-a-hash")
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("NewHash: got %v, want ErrNotSupported", err)
+	}
+	var nse *NotSupportedError
+	if !errors.As(err, &nse) || nse.Algorithm != "not-a-hash" {
+		t.Errorf("NewHash: got %#v, want a NotSupportedError", err)
+	}
+	if _, err := NewKDF("not-a-kdf", nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("NewKDF: got %v, want ErrNotSupported", err)
+	}
+	if _, err := GenerateKey("not-a-key-type", nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GenerateKey: got %v, want ErrNotSupported", err)
+	}
+	if _, err := NewKDF("HKDF", map[string]interface{}{"not-a-param": 1}); errors.Is(err, ErrNotSupported) {
+		t.Errorf("NewKDF: got ErrNotSupported for an invalid parameter")
+	}
+}
+
+func TestHashFallback(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("NewHash is only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("fallbacks are not used in FIPS mode")
+	}
+	const name = "test-fallback-hash"
+	RegisterHashFallback(name, sha256.New)
+	defer RegisterHashFallback(name, nil)
+	h, err := NewHash(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Size() != sha256.Size {
+		t.Errorf("got size %d, want %d", h.Size(), sha256.Size)
+	}
+	l, err := DefaultLibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.NewHash(name); err == nil {
+		t.Error("LibraryContext.NewHash used the fallback")
+	}
+	RegisterHashFallback(name, nil)
+	if _, err := NewHash(name); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("got %v, want ErrNotSupported after removing the fallback", err)
+	}
+}
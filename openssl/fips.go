@@ -0,0 +1,218 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// fipsConfigTemplate activates the FIPS provider described by
+// an included fipsmodule.cnf, as generated by "openssl fipsinstall".
+const fipsConfigTemplate = `config_diagnostics = 1
+openssl_conf = openssl_init
+.include %s
+
+[openssl_init]
+providers = provider_sect
+
+[provider_sect]
+fips = fips_sect
+`
+
+// LoadFIPSProvider loads and activates the OpenSSL 3 FIPS provider
+// using the FIPS module configuration at configPath, and sets the
+// default property query to "fips=yes" so that all the algorithms
+// used by this package are served by the FIPS provider.
+//
+// configPath is the fipsmodule.cnf file generated by
+// "openssl fipsinstall" for the installed FIPS module.
+// If empty, fipsmodule.cnf in the OpenSSL configuration directory is used.
+//
+// SetFIPS(true) only succeeds if the FIPS provider is already configured
+// in openssl.cnf; LoadFIPSProvider doesn't depend on openssl.cnf.
+//
+// LoadFIPSProvider is only supported on OpenSSL 3.
+func LoadFIPSProvider(configPath string) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	if configPath == "" {
+		dir := C.go_openssl_OPENSSL_info(C.GO_OPENSSL_INFO_CONFIG_DIR)
+		if dir == nil {
+			return errors.New("openssl: can't determine the OpenSSL configuration directory")
+		}
+		configPath = filepath.Join(C.GoString(dir), "fipsmodule.cnf")
+	}
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return errors.New("openssl: FIPS module configuration not found: " + err.Error())
+	}
+	// The path is copied into a configuration file, where
+	// line breaks and variable references would be interpreted.
+	if strings.ContainsAny(configPath, "\n\r$") {
+		return errors.New("openssl: invalid FIPS module configuration path")
+	}
+	if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+		if err := loadFIPSConfig(configPath); err != nil {
+			return err
+		}
+		if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+			return newOpenSSLError("openssl: FIPS provider not activated by " + configPath)
+		}
+	}
+	// The base provider supplies the encoders and decoders
+	// the FIPS provider lacks. It is only loaded once the FIPS provider
+	// is active, as loading any provider disables the implicit
+	// loading of the default provider.
+	if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameBase) == 0 {
+		if C.go_openssl_OSSL_PROVIDER_load(nil, providerNameBase) == nil {
+			return newOpenSSLError("openssl: OSSL_PROVIDER_load(base)")
+		}
+	}
+	if C.go_openssl_EVP_default_properties_enable_fips(nil, 1) != 1 {
+		return newOpenSSLError("openssl: EVP_default_properties_enable_fips")
+	}
+	return nil
+}
+
+// loadFIPSConfig loads into the default library context a configuration
+// that includes the FIPS module configuration at path.
+// OSSL_LIB_CTX_load_config only accepts files, so the configuration
+// is written to a temporary file.
+func loadFIPSConfig(path string) error {
+	f, err := os.CreateTemp("", "go-openssl-fips-*.cnf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintf(f, fipsConfigTemplate, path)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	cname := C.CString(f.Name())
+	defer C.free(unsafe.Pointer(cname))
+	if C.go_openssl_OSSL_LIB_CTX_load_config(nil, cname) != 1 {
+		return newOpenSSLError("openssl: loading FIPS module configuration " + path)
+	}
+	return nil
+}
+
+// FIPSInfo describes the FIPS mode of the loaded OpenSSL library.
+type FIPSInfo struct {
+	// Enabled reports whether FIPS mode is active, as returned by FIPS.
+	Enabled bool
+	// Provider is the name of the FIPS provider, such as
+	// "OpenSSL FIPS Provider", or empty if it isn't loaded.
+	// On OpenSSL 1, FIPS mode is implemented by the library itself
+	// and Provider is empty.
+	Provider string
+	// Version is the version of the FIPS provider,
+	// or of the library itself on OpenSSL 1.
+	Version string
+	// BuildInfo is the build information reported by the FIPS provider.
+	BuildInfo string
+}
+
+// FIPSStatus returns the FIPS mode status of the loaded OpenSSL library.
+// On OpenSSL 3 it reports the FIPS provider even if the default
+// property query doesn't require it, in which case Enabled is false.
+func FIPSStatus() (FIPSInfo, error) {
+	info := FIPSInfo{Enabled: FIPS()}
+	switch vMajor {
+	case 1:
+		info.Version = VersionText()
+	case 3:
+		if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+			break
+		}
+		m, err := fipsModule()
+		if err != nil {
+			return info, err
+		}
+		info.Provider, info.Version, info.BuildInfo = m.Name, m.Version, m.BuildInfo
+	default:
+		return info, errUnsuportedVersion()
+	}
+	return info, nil
+}
+
+// FIPSModuleInfo identifies a FIPS cryptographic module.
+type FIPSModuleInfo struct {
+	// Name is the module name, such as "OpenSSL FIPS Provider".
+	Name string
+	// Version is the module version, which together with Name
+	// identifies the module in its CMVP certificate.
+	Version string
+	// BuildInfo is the build information reported by the module,
+	// which may differ from Version for vendor builds.
+	BuildInfo string
+}
+
+// String returns the module identity in a form suitable
+// for logs and support bundles.
+func (m FIPSModuleInfo) String() string {
+	s := m.Name
+	if m.Version != "" {
+		if s != "" {
+			s += " "
+		}
+		s += m.Version
+	}
+	if m.BuildInfo != "" && m.BuildInfo != m.Version {
+		s += " (" + m.BuildInfo + ")"
+	}
+	return s
+}
+
+// FIPSModule returns the identity of the FIPS provider loaded in the
+// default library context, exactly as the provider reports it,
+// so that it can be matched against its CMVP certificate.
+// It returns an error if no FIPS provider is loaded.
+//
+// FIPSModule is only supported on OpenSSL 3. OpenSSL 1 FIPS builds
+// embed the module in the library, see VersionText.
+func FIPSModule() (FIPSModuleInfo, error) {
+	if vMajor != 3 {
+		return FIPSModuleInfo{}, errUnsuportedVersion()
+	}
+	if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+		return FIPSModuleInfo{}, errors.New("openssl: FIPS provider not available")
+	}
+	return fipsModule()
+}
+
+func fipsModule() (FIPSModuleInfo, error) {
+	// The provider is already active, so loading it again only
+	// retrieves it. The extra reference is released right away.
+	prov := C.go_openssl_OSSL_PROVIDER_load(nil, providerNameFips)
+	if prov == nil {
+		return FIPSModuleInfo{}, newOpenSSLError("openssl: OSSL_PROVIDER_load")
+	}
+	defer C.go_openssl_OSSL_PROVIDER_unload(prov)
+	var name, version, buildinfo *C.char
+	if C.go_openssl_provider_info(prov, &name, &version, &buildinfo) != 1 {
+		return FIPSModuleInfo{}, newOpenSSLError("openssl: OSSL_PROVIDER_get_params")
+	}
+	return FIPSModuleInfo{
+		Name:      C.GoString(name),
+		Version:   C.GoString(version),
+		BuildInfo: C.GoString(buildinfo),
+	}, nil
+}
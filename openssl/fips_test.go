@@ -0,0 +1,92 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFIPSProviderErrors(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("LoadFIPSProvider is only supported on OpenSSL 3")
+	}
+	dir := t.TempDir()
+	if err := LoadFIPSProvider(filepath.Join(dir, "missing.cnf")); err == nil {
+		t.Error("expected error for missing configuration")
+	}
+	if FIPS() {
+		// The rest of the test checks failures to load the provider.
+		return
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	// A FIPS module configuration pointing to a module that doesn't exist.
+	path := filepath.Join(dir, "fipsmodule.cnf")
+	config := "[fips_sect]\nactivate = 1\nmodule = " + filepath.Join(dir, "fips.so") + "\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := LoadFIPSProvider(path)
+	if err == nil {
+		t.Fatal("expected error for invalid FIPS module")
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Errorf("error %q doesn't mention the configuration path", err)
+	}
+	if FIPS() {
+		t.Error("FIPS mode enabled after failed LoadFIPSProvider")
+	}
+}
+
+func TestFIPSStatus(t *testing.T) {
+	info, err := FIPSStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Enabled != FIPS() {
+		t.Errorf("got Enabled %v, want %v", info.Enabled, FIPS())
+	}
+	if vMajor == 3 && info.Enabled && info.Provider == "" {
+		t.Error("FIPS mode enabled without a FIPS provider")
+	}
+	if info.Enabled && info.Version == "" {
+		t.Error("missing FIPS version")
+	}
+}
+
+func TestFIPSModule(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("FIPSModule is only supported on OpenSSL 3")
+	}
+	m, err := FIPSModule()
+	if info, _ := FIPSStatus(); info.Provider == "" {
+		if err == nil {
+			t.Error("expected error without the FIPS provider")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name == "" || m.Version == "" {
+		t.Errorf("incomplete module identity: %+v", m)
+	}
+	if s := m.String(); !strings.Contains(s, m.Version) {
+		t.Errorf("String() = %q doesn't contain the version", s)
+	}
+}
+
+func TestFIPSModuleInfoString(t *testing.T) {
+	m := FIPSModuleInfo{Name: "OpenSSL FIPS Provider", Version: "3.0.9", BuildInfo: "3.0.9-vendor"}
+	if got, want := m.String(), "OpenSSL FIPS Provider 3.0.9 (3.0.9-vendor)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
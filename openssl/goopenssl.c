@@ -1,13 +1,29 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 #include "goopenssl.h"
 
-#include <dlfcn.h>
 #include <stdio.h>
+#include <string.h>
+
+#ifdef _WIN32
+// OpenSSL exports its functions with the default __cdecl calling
+// convention, which is also the one of the function pointers used
+// here, so symbols resolved with GetProcAddress can be called as is.
+#include <windows.h>
+#define dlsym(handle, name) ((void*)GetProcAddress((HMODULE)(handle), (name)))
+#else
+#include <dlfcn.h>
+#endif
+
+#ifdef GO_OPENSSL_STATIC
+// See static.c.
+void* go_openssl_static_dlsym(void* handle, const char* name);
+#define dlsym go_openssl_static_dlsym
+#endif
 
 int
 go_openssl_fips_enabled(void* handle)
@@ -30,6 +46,62 @@ go_openssl_fips_enabled(void* handle)
     return 0;
 }
 
+// Defined in allocs.c.
+extern void *go_openssl_track_malloc(size_t num, const char *file, int line);
+extern void *go_openssl_track_realloc(void *addr, size_t num, const char *file, int line);
+extern void go_openssl_track_free(void *addr, const char *file, int line);
+
+// go_openssl_track_allocations installs the counting allocation
+// functions of allocs.c in the OpenSSL 1.1 or later library at handle,
+// which fails if the library already allocated memory.
+int
+go_openssl_track_allocations(void* handle)
+{
+    int (*fn)(void *(*)(size_t, const char *, int),
+              void *(*)(void *, size_t, const char *, int),
+              void (*)(void *, const char *, int));
+    fn = (int (*)(void *(*)(size_t, const char *, int),
+                  void *(*)(void *, size_t, const char *, int),
+                  void (*)(void *, const char *, int)))dlsym(handle, "CRYPTO_set_mem_functions");
+    if (fn == NULL)
+        return 0;
+    return fn(go_openssl_track_malloc, go_openssl_track_realloc, go_openssl_track_free);
+}
+
+// go_openssl_libressl_version returns the version text of handle
+// if it is a LibreSSL libcrypto, else NULL. LibreSSL reports
+// a fixed OpenSSL 2.0.0 version number, which isn't a real OpenSSL
+// version, so it is identified by its version text.
+const char*
+go_openssl_libressl_version(void* handle)
+{
+    const char* (*fn)(int);
+    fn = (const char* (*)(int))dlsym(handle, "OpenSSL_version");
+    if (fn == NULL)
+        fn = (const char* (*)(int))dlsym(handle, "SSLeay_version");
+    if (fn == NULL)
+        return NULL;
+
+    // 0 is OPENSSL_VERSION in all versions.
+    const char* v = fn(0);
+    if (v == NULL || strncmp(v, "LibreSSL", 8) != 0)
+        return NULL;
+    return v;
+}
+
+// go_openssl_boringssl_flavor returns "AWS-LC" or "BoringSSL" if handle
+// is a libcrypto of either project, else NULL. They implement a subset of
+// the OpenSSL 1.1 API and report an OpenSSL 1.1.1 version number.
+const char*
+go_openssl_boringssl_flavor(void* handle)
+{
+    if (dlsym(handle, "awslc_api_version_num") != NULL)
+        return "AWS-LC";
+    if (dlsym(handle, "BORINGSSL_self_test") != NULL)
+        return "BoringSSL";
+    return NULL;
+}
+
 static unsigned long
 version_num(void* handle)
 {
@@ -99,6 +171,7 @@ go_openssl_version_minor(void* handle)
 #define DEFINEFUNC_LEGACY_1(ret, func, args, argscall)         DEFINEFUNC(ret, func, args, argscall)
 #define DEFINEFUNC_1_1(ret, func, args, argscall)              DEFINEFUNC(ret, func, args, argscall)
 #define DEFINEFUNC_3_0(ret, func, args, argscall)              DEFINEFUNC(ret, func, args, argscall)
+#define DEFINEFUNC_3_4(ret, func, args, argscall)              DEFINEFUNC(ret, func, args, argscall)
 #define DEFINEFUNC_RENAMED_1_1(ret, func, oldfunc, args, argscall) DEFINEFUNC(ret, func, args, argscall)
 #define DEFINEFUNC_RENAMED_3_0(ret, func, oldfunc, args, argscall) DEFINEFUNC(ret, func, args, argscall)
 
@@ -109,18 +182,14 @@ FOR_ALL_OPENSSL_FUNCTIONS
 #undef DEFINEFUNC_LEGACY_1
 #undef DEFINEFUNC_1_1
 #undef DEFINEFUNC_3_0
+#undef DEFINEFUNC_3_4
 #undef DEFINEFUNC_RENAMED_1_1
 #undef DEFINEFUNC_RENAMED_3_0
 
-// Load all the functions stored in FOR_ALL_OPENSSL_FUNCTIONS
-// and assign them to their corresponding function pointer
-// defined in goopenssl.h.
-void
-go_openssl_load_functions(void* handle, int major, int minor)
-{
-#define DEFINEFUNC_INTERNAL(name, func) \
-    _g_##name = dlsym(handle, func);         \
-    if (_g_##name == NULL) { fprintf(stderr, "Cannot get required symbol " #func " from libcrypto version %d.%d\n", major, minor); abort(); }
+// The following macros call DEFINEFUNC_INTERNAL(name, func) for each
+// function of FOR_ALL_OPENSSL_FUNCTIONS available in the libcrypto
+// version major.minor, where func is the symbol name to look up.
+// DEFINEFUNC_INTERNAL is defined by each function using them.
 #define DEFINEFUNC(ret, func, args, argscall) \
     DEFINEFUNC_INTERNAL(func, #func)
 #define DEFINEFUNC_LEGACY_1_0(ret, func, args, argscall)  \
@@ -143,6 +212,11 @@ go_openssl_load_functions(void* handle, int major, int minor)
     {                                                 \
         DEFINEFUNC_INTERNAL(func, #func)              \
     }
+#define DEFINEFUNC_3_4(ret, func, args, argscall)     \
+    if (major == 3 && minor >= 4)                     \
+    {                                                 \
+        DEFINEFUNC_INTERNAL(func, #func)              \
+    }
 #define DEFINEFUNC_RENAMED_1_1(ret, func, oldfunc, args, argscall)  \
     if (major == 1 && minor == 0)                                   \
     {                                                               \
@@ -162,13 +236,78 @@ go_openssl_load_functions(void* handle, int major, int minor)
         DEFINEFUNC_INTERNAL(func, #func)                            \
     }
 
+// Load all the functions stored in FOR_ALL_OPENSSL_FUNCTIONS
+// and assign them to their corresponding function pointer
+// defined in goopenssl.h. Missing functions are left NULL,
+// see go_openssl_missing_function.
+void
+go_openssl_load_functions(void* handle, int major, int minor)
+{
+#define DEFINEFUNC_INTERNAL(name, func) \
+    _g_##name = dlsym(handle, func);
+
+FOR_ALL_OPENSSL_FUNCTIONS
+
+#undef DEFINEFUNC_INTERNAL
+}
+
+// go_openssl_missing_functions stores in missing the names of up to n
+// functions that go_openssl_load_functions looks up but handle lacks,
+// and returns the number of missing functions.
+int
+go_openssl_missing_functions(void* handle, int major, int minor, const char** missing, int n)
+{
+    int count = 0;
+#define DEFINEFUNC_INTERNAL(name, func) \
+    if (dlsym(handle, func) == NULL) { if (count < n) missing[count] = func; count++; }
+
 FOR_ALL_OPENSSL_FUNCTIONS
 
+#undef DEFINEFUNC_INTERNAL
+    return count;
+}
+
 #undef DEFINEFUNC
 #undef DEFINEFUNC_LEGACY_1_0
 #undef DEFINEFUNC_LEGACY_1
 #undef DEFINEFUNC_1_1
 #undef DEFINEFUNC_3_0
+#undef DEFINEFUNC_3_4
 #undef DEFINEFUNC_RENAMED_1_1
 #undef DEFINEFUNC_RENAMED_3_0
+
+// missing_name and missing_note record the last missing function called
+// on the current thread, which is the one the failing call reports,
+// like the OpenSSL error queue.
+static __thread const char* missing_name;
+static __thread const char* missing_note;
+
+static uintptr_t
+missing_stub(void)
+{
+    return 0;
+}
+
+void*
+go_openssl_missing_function(const char* name, const char* note)
+{
+    missing_name = name;
+    missing_note = note;
+    // The stub is called through a pointer of the missing function type.
+    // All the supported calling conventions let the caller clean up the
+    // arguments, and it returns 0 in the return register, which makes all
+    // the functions returning an integer or a pointer fail.
+    return (void*)missing_stub;
+}
+
+// go_openssl_take_missing_function returns the name of the last missing
+// function called on the current thread, and its note, and forgets about it.
+const char*
+go_openssl_take_missing_function(const char** note)
+{
+    const char* name = missing_name;
+    if (name != NULL)
+        *note = missing_note;
+    missing_name = NULL;
+    return name;
 }
@@ -3,46 +3,86 @@
 
 // This header file describes the OpenSSL ABI as built for use in Go.
 
+#ifndef GO_OPENSSL_H
+#define GO_OPENSSL_H
+
 #include <stdlib.h> // size_t
+#include <string.h> // strlen, memcpy
+#include <time.h> // time_t
 
 #include "openssl_funcs.h"
 
 int go_openssl_fips_enabled(void* handle);
 int go_openssl_version_major(void* handle);
+const char* go_openssl_libressl_version(void* handle);
+const char* go_openssl_boringssl_flavor(void* handle);
 int go_openssl_version_minor(void* handle);
 int go_openssl_thread_setup(void);
+int go_openssl_set_deterministic_rand(const unsigned char *seed, size_t seed_len);
+int go_openssl_clear_deterministic_rand(void);
+void go_openssl_set_self_test_callback(int enable);
+int go_openssl_set_trace_callback(int category, int enable);
+int go_openssl_track_allocations(void* handle);
+int64_t go_openssl_live_allocations(void);
+void go_openssl_set_indicator_callback(int reject, int observe);
+int go_openssl_providers_do_all(GO_OSSL_LIB_CTX_PTR ctx);
+GO_UI_METHOD_PTR go_openssl_store_ui_method(void);
+GO_OSSL_STORE_CTX_PTR go_openssl_store_open(const char *uri, GO_OSSL_LIB_CTX_PTR libctx, const char *propq, GO_UI_METHOD_PTR ui_method, uintptr_t handle);
+void go_openssl_reset_unapproved(void);
+int go_openssl_unapproved(void);
+void go_openssl_add_unapproved(int n);
 void go_openssl_load_functions(void* handle, int major, int minor);
+int go_openssl_missing_functions(void* handle, int major, int minor, const char** missing, int n);
+
+// go_openssl_missing_function records that the function name, which
+// the loaded libcrypto lacks, has been called on the current thread, and
+// returns a stub that does nothing and returns 0. note tells which
+// OpenSSL versions have name.
+void* go_openssl_missing_function(const char* name, const char* note);
+const char* go_openssl_take_missing_function(const char** note);
 
 // Define pointers to all the used OpenSSL functions.
 // Calling C function pointers from Go is currently not supported.
 // It is possible to circumvent this by using a C function wrapper.
 // https://pkg.go.dev/cmd/cgo
-#define DEFINEFUNC(ret, func, args, argscall)      \
-    extern ret (*_g_##func)args;                   \
-    static inline ret go_openssl_##func args  \
-    {                                              \
-        return _g_##func argscall;                 \
+//
+// Functions missing from the loaded libcrypto are left NULL,
+// and calling them calls the stub returned by go_openssl_missing_function
+// instead, which makes the call fail as if OpenSSL reported an error.
+#define DEFINEFUNC_NOTE(ret, func, args, argscall, note)                  \
+    extern ret (*_g_##func)args;                                          \
+    static inline ret go_openssl_##func args                              \
+    {                                                                     \
+        if (_g_##func == NULL)                                            \
+            return ((ret (*)args)go_openssl_missing_function(#func, note))argscall; \
+        return _g_##func argscall;                                        \
     }
+#define DEFINEFUNC(ret, func, args, argscall)      \
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "which every supported OpenSSL version has")
 #define DEFINEFUNC_LEGACY_1_0(ret, func, args, argscall)  \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "removed in OpenSSL 1.1.0")
 #define DEFINEFUNC_LEGACY_1(ret, func, args, argscall)  \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "removed in OpenSSL 3.0")
 #define DEFINEFUNC_1_1(ret, func, args, argscall)     \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "introduced in OpenSSL 1.1.0")
 #define DEFINEFUNC_3_0(ret, func, args, argscall)     \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "introduced in OpenSSL 3.0")
+#define DEFINEFUNC_3_4(ret, func, args, argscall)     \
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "introduced in OpenSSL 3.4")
 #define DEFINEFUNC_RENAMED_1_1(ret, func, oldfunc, args, argscall)     \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "named " #oldfunc " before OpenSSL 1.1.0")
 #define DEFINEFUNC_RENAMED_3_0(ret, func, oldfunc, args, argscall)     \
-    DEFINEFUNC(ret, func, args, argscall)
+    DEFINEFUNC_NOTE(ret, func, args, argscall, "named " #oldfunc " before OpenSSL 3.0")
 
 FOR_ALL_OPENSSL_FUNCTIONS
 
+#undef DEFINEFUNC_NOTE
 #undef DEFINEFUNC
 #undef DEFINEFUNC_LEGACY_1_0
 #undef DEFINEFUNC_LEGACY_1
 #undef DEFINEFUNC_1_1
 #undef DEFINEFUNC_3_0
+#undef DEFINEFUNC_3_4
 #undef DEFINEFUNC_RENAMED_1_1
 #undef DEFINEFUNC_RENAMED_3_0
 
@@ -145,4 +185,460 @@ go_openssl_EVP_CIPHER_CTX_open_wrapper(const GO_EVP_CIPHER_CTX_PTR ctx,
         return 0;
 
     return 1;
-};
\ No newline at end of file
+};
+// go_openssl_RAND_bytes_pr is like RAND_bytes but requests prediction
+// resistance from the public DRBG of the calling thread.
+// Both calls must be made on the same thread, so they are batched here.
+static inline int
+go_openssl_RAND_bytes_pr(unsigned char *out, size_t n)
+{
+    GO_EVP_RAND_CTX_PTR ctx = go_openssl_RAND_get0_public(NULL);
+    if (ctx == NULL)
+        return 0;
+    return go_openssl_EVP_RAND_generate(ctx, out, n, 0, 1, NULL, 0);
+}
+
+// go_openssl_new_child_drbg creates a DRBG of the same type and
+// configuration as the primary DRBG of the default library context,
+// seeded from the primary DRBG.
+static inline GO_EVP_RAND_CTX_PTR
+go_openssl_new_child_drbg(void)
+{
+    GO_EVP_RAND_CTX_PTR primary = go_openssl_RAND_get0_primary(NULL);
+    if (primary == NULL)
+        return NULL;
+
+    char cipher[80] = "", digest[80] = "";
+    OSSL_PARAM params[3];
+    params[0] = go_openssl_OSSL_PARAM_construct_utf8_string("cipher", cipher, sizeof(cipher));
+    params[1] = go_openssl_OSSL_PARAM_construct_utf8_string("digest", digest, sizeof(digest));
+    params[2] = go_openssl_OSSL_PARAM_construct_end();
+    if (go_openssl_EVP_RAND_CTX_get_params(primary, params) != 1)
+        return NULL;
+
+    GO_EVP_RAND_CTX_PTR ctx = go_openssl_EVP_RAND_CTX_new(go_openssl_EVP_RAND_CTX_get0_rand(primary), primary);
+    if (ctx == NULL)
+        return NULL;
+
+    int n = 0;
+    if (cipher[0] != '\0')
+        params[n++] = go_openssl_OSSL_PARAM_construct_utf8_string("cipher", cipher, 0);
+    if (digest[0] != '\0')
+        params[n++] = go_openssl_OSSL_PARAM_construct_utf8_string("digest", digest, 0);
+    params[n] = go_openssl_OSSL_PARAM_construct_end();
+    if (go_openssl_EVP_RAND_instantiate(ctx, 0, 0, NULL, 0, params) != 1)
+    {
+        go_openssl_EVP_RAND_CTX_free(ctx);
+        return NULL;
+    }
+    return ctx;
+}
+
+// go_openssl_provider_info stores in name, version and buildinfo
+// the corresponding parameters of the provider prov.
+// The strings are owned by the provider.
+static inline int
+go_openssl_provider_info(const GO_OSSL_PROVIDER_PTR prov, char **name, char **version, char **buildinfo)
+{
+    OSSL_PARAM params[4];
+    params[0] = go_openssl_OSSL_PARAM_construct_utf8_ptr("name", name, 0);
+    params[1] = go_openssl_OSSL_PARAM_construct_utf8_ptr("version", version, 0);
+    params[2] = go_openssl_OSSL_PARAM_construct_utf8_ptr("buildinfo", buildinfo, 0);
+    params[3] = go_openssl_OSSL_PARAM_construct_end();
+    return go_openssl_OSSL_PROVIDER_get_params(prov, params);
+}
+
+// go_openssl_provider_status returns the "status" parameter of the
+// provider prov, which is 1 if it is running and has passed its
+// self-tests, or -1 if the provider doesn't report it.
+static inline int
+go_openssl_provider_status(const GO_OSSL_PROVIDER_PTR prov)
+{
+    int status = -1;
+    OSSL_PARAM params[2];
+    params[0] = go_openssl_OSSL_PARAM_construct_int("status", &status);
+    params[1] = go_openssl_OSSL_PARAM_construct_end();
+    if (go_openssl_OSSL_PROVIDER_get_params(prov, params) != 1)
+        return 0;
+    return status;
+}
+
+// go_openssl_SRP_gN_get0 returns the parameters of the SRP group gN.
+// SRP_gN is a public structure with the same layout in all OpenSSL
+// versions, but the standalone headers don't define it.
+static inline void
+go_openssl_SRP_gN_get0(const GO_SRP_gN_PTR gN, GO_BIGNUM_PTR *g, GO_BIGNUM_PTR *N)
+{
+    const struct {
+        char *id;
+        GO_BIGNUM_PTR g;
+        GO_BIGNUM_PTR N;
+    } *p = gN;
+    *g = p->g;
+    *N = p->N;
+}
+
+// GO_DER_FUNCS defines go_openssl_d2i_##type##_buf, which decodes the len
+// bytes of DER at in, and go_openssl_i2d_##type##_buf, which encodes a into
+// out and returns the encoding size, or only returns it if out is NULL.
+// They avoid passing Go pointers to Go pointers to the d2i and i2d functions.
+#define GO_DER_FUNCS(type)                                                  \
+    static inline GO_##type##_PTR                                           \
+    go_openssl_d2i_##type##_buf(const unsigned char *in, long len)          \
+    {                                                                       \
+        return go_openssl_d2i_##type(NULL, &in, len);                       \
+    }                                                                       \
+    static inline int                                                       \
+    go_openssl_i2d_##type##_buf(const GO_##type##_PTR a, unsigned char *out) \
+    {                                                                       \
+        return go_openssl_i2d_##type(a, out == NULL ? NULL : &out);        \
+    }
+
+GO_DER_FUNCS(PKCS8_PRIV_KEY_INFO)
+GO_DER_FUNCS(X509_SIG)
+GO_DER_FUNCS(X509)
+GO_DER_FUNCS(PKCS12)
+GO_DER_FUNCS(X509_REQ)
+GO_DER_FUNCS(X509_CRL)
+GO_DER_FUNCS(OCSP_REQUEST)
+GO_DER_FUNCS(OCSP_RESPONSE)
+GO_DER_FUNCS(CMS_ContentInfo)
+GO_DER_FUNCS(TS_REQ)
+GO_DER_FUNCS(TS_RESP)
+GO_DER_FUNCS(PKCS7)
+
+// go_openssl_X509V3_EXT_nconf_nid_ctx creates the extension ext_nid from
+// its configuration string value, in the context of the certificate subject
+// issued by issuer, or of the request req. X509V3_CTX is allocated here
+// because its layout differs between OpenSSL versions: the buffer
+// is larger than any of them.
+static inline GO_X509_EXTENSION_PTR
+go_openssl_X509V3_EXT_nconf_nid_ctx(GO_X509_PTR issuer, GO_X509_PTR subject, GO_X509_REQ_PTR req, int ext_nid, const char *value)
+{
+    void *ctx[32] = {0};
+    go_openssl_X509V3_set_ctx(ctx, issuer, subject, req, NULL, 0);
+    return go_openssl_X509V3_EXT_nconf_nid(NULL, ctx, ext_nid, value);
+}
+
+// go_openssl_d2i_ECPrivateKey_buf decodes the RFC 5915 ECPrivateKey of len
+// bytes at in. If key is not NULL, its group is used when the encoding
+// has no parameters, and key is returned on success but not freed on error.
+static inline GO_EC_KEY_PTR
+go_openssl_d2i_ECPrivateKey_buf(GO_EC_KEY_PTR key, const unsigned char *in, long len)
+{
+    return go_openssl_d2i_ECPrivateKey(key == NULL ? NULL : &key, &in, len);
+}
+
+static inline int
+go_openssl_i2d_ECPrivateKey_buf(const GO_EC_KEY_PTR key, unsigned char *out)
+{
+    return go_openssl_i2d_ECPrivateKey(key, out == NULL ? NULL : &out);
+}
+
+static inline GO_EVP_PKEY_PTR
+go_openssl_d2i_PUBKEY_buf(const unsigned char *in, long len)
+{
+    return go_openssl_d2i_PUBKEY(NULL, &in, len);
+}
+
+static inline int
+go_openssl_i2d_PUBKEY_buf(const GO_EVP_PKEY_PTR a, unsigned char *out)
+{
+    return go_openssl_i2d_PUBKEY(a, out == NULL ? NULL : &out);
+}
+
+static inline GO_ECDSA_SIG_PTR
+go_openssl_d2i_ECDSA_SIG_buf(const unsigned char *in, long len)
+{
+    return go_openssl_d2i_ECDSA_SIG(NULL, &in, len);
+}
+
+static inline int
+go_openssl_i2d_ECDSA_SIG_buf(const GO_ECDSA_SIG_PTR sig, unsigned char *out)
+{
+    return go_openssl_i2d_ECDSA_SIG(sig, out == NULL ? NULL : &out);
+}
+
+// go_openssl_digest_sign_init starts a hash-then-sign or hash-then-verify
+// operation of pkey with md on ctx, and sets the RSA padding, if not
+// zero, and the PSS salt length, if not zero.
+static inline int
+go_openssl_digest_sign_init(GO_EVP_MD_CTX_PTR ctx, const GO_EVP_MD_PTR md, GO_EVP_PKEY_PTR pkey,
+                            int sign, int padding, int salt_len)
+{
+    GO_EVP_PKEY_CTX_PTR pctx = NULL;
+    int ret = sign ? go_openssl_EVP_DigestSignInit(ctx, &pctx, md, NULL, pkey)
+                   : go_openssl_EVP_DigestVerifyInit(ctx, &pctx, md, NULL, pkey);
+    if (ret != 1)
+        return 0;
+    if (padding == 0)
+        return 1;
+    if (go_openssl_EVP_PKEY_CTX_ctrl(pctx, GO_EVP_PKEY_RSA, -1, GO_EVP_PKEY_CTRL_RSA_PADDING, padding, NULL) != 1)
+        return 0;
+    if (padding == GO_RSA_PKCS1_PSS_PADDING && salt_len != 0)
+        return go_openssl_EVP_PKEY_CTX_ctrl(pctx, GO_EVP_PKEY_RSA, -1, GO_EVP_PKEY_CTRL_RSA_PSS_SALTLEN, salt_len, NULL);
+    return 1;
+}
+
+// go_openssl_digest_sign_wrapper hashes in with md and signs the digest
+// with pkey into sig in a single cgo call, starting by clearing the
+// error queue. It returns the size of the signature, 0 on error, or
+// minus the required size if sig_len is too small. The size isn't
+// returned through a pointer, which would escape to the heap.
+static inline long
+go_openssl_digest_sign_wrapper(GO_EVP_MD_CTX_PTR ctx, const GO_EVP_MD_PTR md, GO_EVP_PKEY_PTR pkey,
+                               int padding, int salt_len,
+                               const unsigned char *in, size_t in_len,
+                               unsigned char *sig, size_t sig_len)
+{
+    go_openssl_ERR_clear_error();
+    size_t len;
+    if (go_openssl_digest_sign_init(ctx, md, pkey, 1, padding, salt_len) != 1
+        || go_openssl_EVP_DigestUpdate(ctx, in, in_len) != 1
+        || go_openssl_EVP_DigestSignFinal(ctx, NULL, &len) != 1)
+        return 0;
+    if (len > sig_len)
+        return -(long)len;
+    len = sig_len;
+    if (go_openssl_EVP_DigestSignFinal(ctx, sig, &len) != 1)
+        return 0;
+    return (long)len;
+}
+
+// go_openssl_digest_verify_wrapper is like go_openssl_digest_sign_wrapper
+// but verifies that sig is a signature of in. It returns 1 on success.
+static inline int
+go_openssl_digest_verify_wrapper(GO_EVP_MD_CTX_PTR ctx, const GO_EVP_MD_PTR md, GO_EVP_PKEY_PTR pkey,
+                                 int padding, int salt_len,
+                                 const unsigned char *in, size_t in_len,
+                                 const unsigned char *sig, size_t sig_len)
+{
+    go_openssl_ERR_clear_error();
+    if (go_openssl_digest_sign_init(ctx, md, pkey, 0, padding, salt_len) != 1
+        || go_openssl_EVP_DigestUpdate(ctx, in, in_len) != 1)
+        return 0;
+    return go_openssl_EVP_DigestVerifyFinal(ctx, sig, sig_len);
+}
+
+// go_openssl_HMAC_batch writes the HMAC of n messages to out, size bytes
+// each, restarting every one from the keyed state of ctx. The messages
+// are concatenated in in, the first one ending at ends[0], the next one
+// at ends[1], and so on.
+static inline int
+go_openssl_HMAC_batch(GO_HMAC_CTX_PTR ctx, const unsigned char *in, const size_t *ends, size_t n,
+                      unsigned char *out, size_t size)
+{
+    size_t i, start = 0;
+    for (i = 0; i < n; i++)
+    {
+        // A NULL key and digest reuse those of ctx.
+        if (go_openssl_HMAC_Init_ex(ctx, NULL, 0, NULL, NULL) != 1
+            || go_openssl_HMAC_Update(ctx, in + start, ends[i] - start) != 1
+            || go_openssl_HMAC_Final(ctx, out + i * size, NULL) != 1)
+            return 0;
+        start = ends[i];
+    }
+    return 1;
+}
+
+// go_openssl_EVP_MAC_batch is like go_openssl_HMAC_batch but for an
+// EVP_MAC_CTX.
+static inline int
+go_openssl_EVP_MAC_batch(GO_EVP_MAC_CTX_PTR ctx, const unsigned char *in, const size_t *ends, size_t n,
+                         unsigned char *out, size_t size)
+{
+    size_t i, start = 0;
+    for (i = 0; i < n; i++)
+    {
+        size_t outl;
+        if (go_openssl_EVP_MAC_init(ctx, NULL, 0, NULL) != 1
+            || go_openssl_EVP_MAC_update(ctx, in + start, ends[i] - start) != 1
+            || go_openssl_EVP_MAC_final(ctx, out + i * size, &outl, size) != 1)
+            return 0;
+        start = ends[i];
+    }
+    return 1;
+}
+
+// go_openssl_async_sign is an EVP_PKEY_sign call run as an ASYNC_JOB,
+// see async.go. It is allocated by go_openssl_async_sign_new and owns
+// all its fields, so that a paused job only refers to C memory.
+typedef struct
+{
+    GO_EVP_PKEY_CTX_PTR ctx;
+    GO_ASYNC_WAIT_CTX_PTR wctx;
+    GO_ASYNC_JOB_PTR job;
+    unsigned char *sig;
+    size_t siglen;
+    unsigned char *tbs;
+    size_t tbslen;
+    // ret is the result of EVP_PKEY_sign once the job finished.
+    int ret;
+} go_openssl_async_sign;
+
+// go_openssl_async_sign_free frees s, whose job must not be paused.
+static inline void
+go_openssl_async_sign_free(go_openssl_async_sign *s)
+{
+    go_openssl_EVP_PKEY_CTX_free(s->ctx);
+    if (s->wctx != NULL)
+        go_openssl_ASYNC_WAIT_CTX_free(s->wctx);
+    free(s->sig);
+    free(s->tbs);
+    free(s);
+}
+
+// go_openssl_async_sign_new returns a go_openssl_async_sign taking
+// ownership of ctx, which is initialized for signing, with a copy of
+// tbs and siglen bytes for the signature. It returns NULL and frees
+// ctx on failure.
+static inline go_openssl_async_sign *
+go_openssl_async_sign_new(GO_EVP_PKEY_CTX_PTR ctx, const unsigned char *tbs, size_t tbslen, size_t siglen)
+{
+    go_openssl_async_sign *s = calloc(1, sizeof(go_openssl_async_sign));
+    if (s == NULL)
+    {
+        go_openssl_EVP_PKEY_CTX_free(ctx);
+        return NULL;
+    }
+    s->ctx = ctx;
+    s->wctx = go_openssl_ASYNC_WAIT_CTX_new();
+    s->sig = malloc(siglen);
+    s->siglen = siglen;
+    s->tbs = malloc(tbslen > 0 ? tbslen : 1);
+    s->tbslen = tbslen;
+    if (s->wctx == NULL || s->sig == NULL || s->tbs == NULL)
+    {
+        go_openssl_async_sign_free(s);
+        return NULL;
+    }
+    memcpy(s->tbs, tbs, tbslen);
+    return s;
+}
+
+static inline int
+go_openssl_async_sign_job(void *arg)
+{
+    // arg is the copy of the pointer passed to ASYNC_start_job.
+    go_openssl_async_sign *s = *(go_openssl_async_sign **)arg;
+    s->ret = go_openssl_EVP_PKEY_sign(s->ctx, s->sig, &s->siglen, s->tbs, s->tbslen);
+    return s->ret;
+}
+
+// go_openssl_async_sign_run starts the job of s, or resumes it if it
+// is paused, after clearing the error queue. It returns the result of
+// ASYNC_start_job, ASYNC_FINISH once s->ret is set.
+static inline int
+go_openssl_async_sign_run(go_openssl_async_sign *s)
+{
+    int ret;
+    go_openssl_ERR_clear_error();
+    return go_openssl_ASYNC_start_job(&s->job, s->wctx, &ret, go_openssl_async_sign_job, &s, sizeof(s));
+}
+
+// go_openssl_async_wait_fds stores in fds up to n of the file descriptors
+// a paused job waits for, as uintptr_t, and returns their number.
+static inline size_t
+go_openssl_async_wait_fds(GO_ASYNC_WAIT_CTX_PTR wctx, uintptr_t *fds, size_t n)
+{
+    size_t i, num = 0;
+    if (go_openssl_ASYNC_WAIT_CTX_get_all_fds(wctx, NULL, &num) != 1 || num == 0)
+        return 0;
+    GO_OSSL_ASYNC_FD *all = malloc(num * sizeof(GO_OSSL_ASYNC_FD));
+    if (all == NULL)
+        return 0;
+    if (go_openssl_ASYNC_WAIT_CTX_get_all_fds(wctx, all, &num) != 1)
+        num = 0;
+    for (i = 0; i < num && i < n; i++)
+        fds[i] = (uintptr_t)all[i];
+    free(all);
+    return num;
+}
+
+// go_openssl_ECDSA_sign_with_nonce signs dgst with key, as ECDSA_do_sign,
+// but with the big-endian nonce k rather than a random one. It is only
+// meant for known answer tests, see internal/acvp.
+static inline GO_ECDSA_SIG_PTR
+go_openssl_ECDSA_sign_with_nonce(GO_EC_KEY_PTR key, const unsigned char *dgst, int dgst_len,
+                                 const unsigned char *k, int k_len)
+{
+    GO_ECDSA_SIG_PTR sig = NULL;
+    const GO_EC_GROUP_PTR group = go_openssl_EC_KEY_get0_group(key);
+    GO_BN_CTX_PTR ctx = go_openssl_BN_CTX_new();
+    GO_BIGNUM_PTR order = go_openssl_BN_new();
+    GO_BIGNUM_PTR kbn = go_openssl_BN_bin2bn(k, k_len, NULL);
+    GO_BIGNUM_PTR x = go_openssl_BN_new();
+    GO_BIGNUM_PTR rp = go_openssl_BN_new();
+    GO_BIGNUM_PTR kinv = NULL;
+    GO_EC_POINT_PTR pt = group != NULL ? go_openssl_EC_POINT_new(group) : NULL;
+    // rp is the x coordinate of k*G modulo the order, kinv the inverse of k.
+    if (ctx != NULL && order != NULL && kbn != NULL && x != NULL && rp != NULL && pt != NULL
+        && go_openssl_EC_GROUP_get_order(group, order, ctx) == 1
+        && go_openssl_EC_POINT_mul(group, pt, kbn, NULL, NULL, ctx) == 1
+        && go_openssl_EC_POINT_get_affine_coordinates_GFp(group, pt, x, NULL, ctx) == 1
+        && go_openssl_BN_nnmod(rp, x, order, ctx) == 1
+        && (kinv = go_openssl_BN_mod_inverse(NULL, kbn, order, ctx)) != NULL)
+        sig = go_openssl_ECDSA_do_sign_ex(dgst, dgst_len, kinv, rp, key);
+    go_openssl_EC_POINT_free(pt);
+    go_openssl_BN_free(kinv);
+    go_openssl_BN_free(rp);
+    go_openssl_BN_free(x);
+    go_openssl_BN_clear_free(kbn);
+    go_openssl_BN_free(order);
+    go_openssl_BN_CTX_free(ctx);
+    return sig;
+}
+
+// go_openssl_gcm_seal_internal_iv encrypts in with the GCM cipher, key
+// and aad, generating the IV with the internal generator of OpenSSL from
+// iv_init: iv_init is the fixed field of the IV, and the rest is random,
+// or the whole initial IV if iv_init_len is iv_len. The IV is stored
+// in iv, the ciphertext in out and the tag in tag.
+static inline int
+go_openssl_gcm_seal_internal_iv(GO_EVP_CIPHER_CTX_PTR ctx, const GO_EVP_CIPHER_PTR cipher, const unsigned char *key,
+                                unsigned char *iv_init, int iv_init_len, unsigned char *iv, int iv_len,
+                                const unsigned char *in, int in_len, const unsigned char *aad, int aad_len,
+                                unsigned char *out, unsigned char *tag, int tag_len)
+{
+    int len;
+    return go_openssl_EVP_EncryptInit_ex(ctx, cipher, NULL, key, NULL) == 1
+        && go_openssl_EVP_CIPHER_CTX_ctrl(ctx, GO_EVP_CTRL_GCM_SET_IV_FIXED, iv_init_len == iv_len ? -1 : iv_init_len, iv_init) == 1
+        && go_openssl_EVP_CIPHER_CTX_ctrl(ctx, GO_EVP_CTRL_GCM_IV_GEN, iv_len, iv) == 1
+        && (aad_len == 0 || go_openssl_EVP_EncryptUpdate(ctx, NULL, &len, aad, aad_len) == 1)
+        && (in_len == 0 || go_openssl_EVP_EncryptUpdate(ctx, out, &len, in, in_len) == 1)
+        && go_openssl_EVP_EncryptFinal_ex(ctx, out + (in_len == 0 ? 0 : len), &len) == 1
+        && go_openssl_EVP_CIPHER_CTX_ctrl(ctx, GO_EVP_CTRL_GCM_GET_TAG, tag_len, tag) == 1;
+}
+
+// go_openssl_pem_password_cb is a pem_password_cb returning the
+// NUL-terminated password u. Unlike the OpenSSL default callback,
+// it fails if u is NULL instead of prompting on the terminal.
+static inline int
+go_openssl_pem_password_cb(char *buf, int size, int rwflag, void *u)
+{
+    (void)rwflag;
+    if (u == NULL)
+        return -1;
+    size_t len = strlen((const char *)u);
+    if (len > (size_t)size)
+        return -1;
+    memcpy(buf, u, len);
+    return (int)len;
+}
+
+// GO_PEM_READ_FUNC defines go_openssl_PEM_read_bio_##name##_pass, which
+// reads the next PEM block containing a type from bp, decrypting it
+// with the NUL-terminated password pass if needed.
+#define GO_PEM_READ_FUNC(name, type)                                              \
+    static inline GO_##type##_PTR                                                 \
+    go_openssl_PEM_read_bio_##name##_pass(GO_BIO_PTR bp, const char *pass)        \
+    {                                                                             \
+        return go_openssl_PEM_read_bio_##name(bp, NULL,                           \
+            (GO_pem_password_cb_PTR)go_openssl_pem_password_cb, (void *)pass);    \
+    }
+
+GO_PEM_READ_FUNC(PrivateKey, EVP_PKEY)
+GO_PEM_READ_FUNC(PUBKEY, EVP_PKEY)
+GO_PEM_READ_FUNC(X509, X509)
+
+#endif // GO_OPENSSL_H
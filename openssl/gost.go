@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// GOSTProvider is the module name of the GOST provider built by
+// gost-engine, https://github.com/gost-engine/engine, which implements
+// the Russian GOST algorithms on OpenSSL 3.
+const GOSTProvider = "gostprov"
+
+// Names of the GOST algorithms registered by the GOST provider.
+const (
+	// GOST R 34.11-2012 (Streebog) digests, see RFC 6986.
+	GOSTStreebog256 = "md_gost12_256"
+	GOSTStreebog512 = "md_gost12_512"
+
+	// GOST R 34.10-2012 signature keys, see RFC 7091.
+	GOSTR341012_256 = "gost2012_256"
+	GOSTR341012_512 = "gost2012_512"
+)
+
+// LoadGOSTProvider loads the GOST provider into the default library
+// context with LoadProvider. modulePath is the path of the provider
+// module; if empty, it is looked up in the OpenSSL modules directory
+// as GOSTProvider.
+//
+// Once loaded, its algorithms are available through the generic APIs,
+// for example:
+//
+//	h, err := openssl.NewHash(openssl.GOSTStreebog256)
+//
+//	k, err := openssl.GenerateKey(openssl.GOSTR341012_256, map[string]interface{}{"paramset": "A"})
+//	sig, err := k.Sign(msg, openssl.GOSTStreebog256)
+//
+// The gost-engine ENGINE is not supported, since ENGINE algorithms
+// aren't reachable through the fetch APIs. Applications that can only
+// use the engine must configure it in the OpenSSL configuration file.
+// The GOST algorithms are not FIPS approved, and LoadGOSTProvider
+// fails in strict FIPS mode.
+//
+// LoadGOSTProvider is only supported on OpenSSL 3.
+func LoadGOSTProvider(modulePath string) error {
+	if StrictFIPS() {
+		return notApprovedError("GOST provider")
+	}
+	if modulePath == "" {
+		modulePath = GOSTProvider
+	}
+	return LoadProvider(modulePath)
+}
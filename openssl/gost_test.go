@@ -0,0 +1,54 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGOST(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("GOST is only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := LoadGOSTProvider("/nonexistent/gostprov.so"); err == nil {
+		t.Error("expected error loading a missing module")
+	}
+	if err := LoadGOSTProvider(""); err != nil {
+		t.Skipf("GOST provider not available: %v", err)
+	}
+
+	// RFC 6986, Section 10.1.2.
+	h, err := NewHash(GOSTStreebog256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("012345678901234567890123456789012345678901234567890123456789012"))
+	want := decodeHex(t, "9d151eefd8590b89daa6ba6cb74af9275dd051026bb149a452fd84e5e57b5500")
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	k, err := GenerateKey(GOSTR341012_256, map[string]interface{}{"paramset": "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello")
+	sig, err := k.Sign(msg, GOSTStreebog256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(msg, sig, GOSTStreebog256); err != nil {
+		t.Error(err)
+	}
+	if err := k.Verify([]byte("other"), sig, GOSTStreebog256); err == nil {
+		t.Error("verification of a different message succeeded")
+	}
+}
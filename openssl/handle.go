@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// The keys of this package don't hold their EVP_PKEY directly but a
+// handle, an index in the handle table below, which owns the native
+// object. Every use of the native object pins its handle, with
+// closer.pin and closer.unpin, and freeing a handle, from Close or from
+// the finalizer, only frees the native object once no use is in flight.
+// A key that becomes unreachable halfway through one of its methods can
+// therefore be finalized without freeing its EVP_PKEY under the cgo call
+// still using it, so the methods of the keys need no runtime.KeepAlive
+// calls. Using a key after Close panics rather than using freed memory.
+//
+// Hashes, ciphers and certificates still hold their native objects
+// directly, and must call runtime.KeepAlive after their cgo calls.
+
+// handle identifies a native object in the handle table, 0 is no object.
+type handle uint64
+
+type handleEntry struct {
+	ptr  unsafe.Pointer
+	free func(unsafe.Pointer)
+	// state is the number of uses in flight,
+	// plus handleReleased once the handle is freed.
+	state int64
+}
+
+const handleReleased = 1 << 62
+
+var handles struct {
+	sync.RWMutex
+	last    handle
+	entries map[handle]*handleEntry
+}
+
+// newHandle registers ptr in the handle table,
+// to be freed with free once its handle is freed.
+func newHandle(ptr unsafe.Pointer, free func(unsafe.Pointer)) handle {
+	handles.Lock()
+	defer handles.Unlock()
+	if handles.entries == nil {
+		handles.entries = make(map[handle]*handleEntry)
+	}
+	handles.last++
+	handles.entries[handles.last] = &handleEntry{ptr: ptr, free: free}
+	return handles.last
+}
+
+func lookupHandle(h handle) *handleEntry {
+	handles.RLock()
+	defer handles.RUnlock()
+	return handles.entries[h]
+}
+
+// pkeyHandle returns a closer owning pkey through a new handle.
+func pkeyHandle(pkey C.GO_EVP_PKEY_PTR) closer {
+	return closer{h: newHandle(unsafe.Pointer(pkey), freePKey)}
+}
+
+func freePKey(ptr unsafe.Pointer) {
+	C.go_openssl_EVP_PKEY_free(C.GO_EVP_PKEY_PTR(ptr))
+}
+
+// pin returns the native object of c, which isn't freed
+// before the matching call to unpin.
+func (c *closer) pin() unsafe.Pointer {
+	e := lookupHandle(c.h)
+	if e == nil {
+		panic("openssl: use of a closed object")
+	}
+	for {
+		s := atomic.LoadInt64(&e.state)
+		if s&handleReleased != 0 {
+			panic("openssl: use of a closed object")
+		}
+		if atomic.CompareAndSwapInt64(&e.state, s, s+1) {
+			break
+		}
+	}
+	// The object embedding c can't be finalized before
+	// its handle is pinned, the pin protects it from then on.
+	runtime.KeepAlive(c)
+	return e.ptr
+}
+
+// unpin ends a use of the native object of c started by pin.
+func (c *closer) unpin() {
+	e := lookupHandle(c.h)
+	if atomic.AddInt64(&e.state, -1) == handleReleased {
+		releaseHandle(c.h, e)
+	}
+}
+
+// freeHandle frees the native object of c once it isn't pinned anymore.
+// c can't be used afterwards.
+func (c *closer) freeHandle() {
+	e := lookupHandle(c.h)
+	if e == nil {
+		return
+	}
+	for {
+		s := atomic.LoadInt64(&e.state)
+		if s&handleReleased != 0 {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&e.state, s, s|handleReleased) {
+			if s == 0 {
+				releaseHandle(c.h, e)
+			}
+			return
+		}
+	}
+}
+
+// releaseHandle removes h from the handle table and frees its object.
+// It is called exactly once, when the handle is freed and not pinned.
+func releaseHandle(h handle, e *handleEntry) {
+	handles.Lock()
+	delete(handles.entries, h)
+	handles.Unlock()
+	e.free(e.ptr)
+}
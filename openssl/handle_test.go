@@ -0,0 +1,44 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "testing"
+
+func TestHandlePinnedClose(t *testing.T) {
+	k, _, err := GenerateKeyECDH("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := k.h
+	k.pin()
+	k.Close()
+	if lookupHandle(h) == nil {
+		t.Fatal("the key was freed while pinned")
+	}
+	k.unpin()
+	if lookupHandle(h) != nil {
+		t.Fatal("the key wasn't freed once unpinned")
+	}
+}
+
+func TestHandleUseAfterClose(t *testing.T) {
+	k, _, err := GenerateKeyECDH("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := k.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("using a public key of a closed key didn't panic")
+		}
+	}()
+	ECDH(k, pub)
+}
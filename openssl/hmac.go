@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -17,6 +17,7 @@ import (
 var (
 	paramAlgHMAC = C.CString("HMAC")
 	paramDigest  = C.CString("digest")
+	paramProps   = C.CString("properties")
 )
 
 // NewHMAC returns a new HMAC using OpenSSL.
@@ -25,6 +26,8 @@ var (
 // If h is not recognized, NewHMAC returns nil.
 func NewHMAC(h func() hash.Hash, key []byte) hash.Hash {
 	ch := h()
+	// ch only tells the digest and sizes, the HMAC doesn't keep it.
+	defer closeAll(ch)
 	md := hashToMD(ch)
 	if md == nil {
 		return nil
@@ -47,7 +50,11 @@ func NewHMAC(h func() hash.Hash, key []byte) hash.Hash {
 	case 1:
 		return newHMAC1(hkey, ch, md)
 	case 3:
-		return newHMAC3(hkey, ch, md)
+		h, err := newHMAC3(nil, hkey, ch, md)
+		if err != nil {
+			panic(err)
+		}
+		return h
 	default:
 		panic(errUnsuportedVersion())
 	}
@@ -56,28 +63,37 @@ func NewHMAC(h func() hash.Hash, key []byte) hash.Hash {
 // hmac1 implements hash.Hash
 // using functions available in OpenSSL 1.
 type hmac1 struct {
-	md        C.GO_EVP_MD_PTR
-	ctx       C.GO_HMAC_CTX_PTR
+	md  C.GO_EVP_MD_PTR
+	ctx C.GO_HMAC_CTX_PTR
+	// pooled owns ctx.
+	pooled    *pooledCtx
 	size      int
 	blockSize int
 	key       []byte
 	sum       []byte
+	closer
 }
 
 func newHMAC1(key []byte, h hash.Hash, md C.GO_EVP_MD_PTR) *hmac1 {
+	pooled := hmac1CtxPool.get()
+	if pooled == nil {
+		panic("openssl: HMAC_CTX_new failed")
+	}
 	hmac := &hmac1{
 		md:        md,
 		size:      h.Size(),
 		blockSize: h.BlockSize(),
 		key:       key,
-		ctx:       hmac1CtxNew(),
+		ctx:       C.GO_HMAC_CTX_PTR(pooled.ptr),
+		pooled:    pooled,
 	}
-	runtime.SetFinalizer(hmac, (*hmac1).finalize)
+	manage(hmac, (*hmac1).finalize)
 	hmac.Reset()
 	return hmac
 }
 
 func (h *hmac1) Reset() {
+	h.checkOpen()
 	hmac1CtxReset(h.ctx)
 
 	if C.go_openssl_HMAC_Init_ex(h.ctx, unsafe.Pointer(&h.key[0]), C.int(len(h.key)), h.md, nil) == 0 {
@@ -92,10 +108,19 @@ func (h *hmac1) Reset() {
 }
 
 func (h *hmac1) finalize() {
-	hmac1CtxFree(h.ctx)
+	h.pooled.put()
+	h.ctx, h.pooled = nil, nil
+}
+
+// Close frees the native resources of h, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (h *hmac1) Close() error {
+	return closeObject(h, h.finalize)
 }
 
 func (h *hmac1) Write(p []byte) (int, error) {
+	h.checkOpen()
+	addBytes(MetricHash, len(p))
 	if len(p) > 0 {
 		C.go_openssl_HMAC_Update(h.ctx, base(p), C.size_t(len(p)))
 	}
@@ -112,6 +137,7 @@ func (h *hmac1) BlockSize() int {
 }
 
 func (h *hmac1) Sum(in []byte) []byte {
+	h.checkOpen()
 	if h.sum == nil {
 		size := h.Size()
 		h.sum = make([]byte, size)
@@ -120,8 +146,12 @@ func (h *hmac1) Sum(in []byte) []byte {
 	// that Sum has no effect on the underlying stream.
 	// In particular it is OK to Sum, then Write more, then Sum again,
 	// and the second Sum acts as if the first didn't happen.
-	ctx2 := hmac1CtxNew()
-	defer hmac1CtxFree(ctx2)
+	pooled := hmac1CtxPool.get()
+	if pooled == nil {
+		panic("openssl: HMAC_CTX_new failed")
+	}
+	defer pooled.put()
+	ctx2 := C.GO_HMAC_CTX_PTR(pooled.ptr)
 	if C.go_openssl_HMAC_CTX_copy(ctx2, h.ctx) == 0 {
 		panic("openssl: HMAC_CTX_copy failed")
 	}
@@ -168,40 +198,79 @@ func hmac1CtxFree(ctx C.GO_HMAC_CTX_PTR) {
 // hmac3 implements hash.Hash
 // using functions available in OpenSSL 3.
 type hmac3 struct {
-	md        C.GO_EVP_MAC_PTR
-	ctx       C.GO_EVP_MAC_CTX_PTR
-	params    [2]C.OSSL_PARAM
+	// lib is the library context the MAC was fetched from,
+	// or nil for the default context.
+	lib *LibraryContext
+	// digest owns the message digest named in params
+	// when it has been fetched from lib.
+	digest hash.Hash
+	md     C.GO_EVP_MAC_PTR
+	ctx    C.GO_EVP_MAC_CTX_PTR
+	// pooled owns ctx, if it comes from macCtxPool.
+	pooled    *pooledCtx
+	params    [3]C.OSSL_PARAM
 	size      int
 	blockSize int
 	key       []byte
 	sum       []byte
+	closer
 }
 
-func newHMAC3(key []byte, h hash.Hash, md C.GO_EVP_MD_PTR) *hmac3 {
-	mac := C.go_openssl_EVP_MAC_fetch(nil, paramAlgHMAC, nil)
-	ctx := C.go_openssl_EVP_MAC_CTX_new(mac)
-	if ctx == nil {
-		panic("openssl: EVP_MAC_CTX_new failed")
+func newHMAC3(lib *LibraryContext, key []byte, h hash.Hash, md C.GO_EVP_MD_PTR) (*hmac3, error) {
+	defer runtime.KeepAlive(lib)
+	mac := C.go_openssl_EVP_MAC_fetch(lib.ptr(), paramAlgHMAC, lib.propq())
+	if mac == nil {
+		return nil, newOpenSSLError("EVP_MAC_fetch failed")
+	}
+	var pooled *pooledCtx
+	if lib == nil {
+		// Only the contexts of the default library context are pooled,
+		// so that they don't keep other contexts alive.
+		pooled = macCtxPool.getTagged(unsafe.Pointer(mac))
+	}
+	var ctx C.GO_EVP_MAC_CTX_PTR
+	if pooled != nil {
+		ctx = C.GO_EVP_MAC_CTX_PTR(pooled.ptr)
+	} else {
+		ctx = C.go_openssl_EVP_MAC_CTX_new(mac)
+		if ctx == nil {
+			C.go_openssl_EVP_MAC_free(mac)
+			return nil, newOpenSSLError("EVP_MAC_CTX_new failed")
+		}
+		if lib == nil {
+			pooled = macCtxPool.wrap(unsafe.Pointer(ctx), unsafe.Pointer(mac))
+		}
 	}
 	digest := C.go_openssl_EVP_MD_get0_name(md)
-	params := [2]C.OSSL_PARAM{
+	params := [3]C.OSSL_PARAM{
 		C.go_openssl_OSSL_PARAM_construct_utf8_string(paramDigest, digest, 0),
 		C.go_openssl_OSSL_PARAM_construct_end(),
+		C.go_openssl_OSSL_PARAM_construct_end(),
+	}
+	if props := lib.propq(); props != nil {
+		// Fetch the digest with the same properties as the MAC.
+		params[1] = C.go_openssl_OSSL_PARAM_construct_utf8_string(paramProps, props, 0)
 	}
 	hmac := &hmac3{
+		lib:       lib,
 		md:        mac,
 		ctx:       ctx,
+		pooled:    pooled,
 		params:    params,
 		size:      h.Size(),
 		blockSize: h.BlockSize(),
 		key:       key,
 	}
-	runtime.SetFinalizer(hmac, (*hmac3).finalize)
+	if lib != nil {
+		hmac.digest = h
+	}
+	manage(hmac, (*hmac3).finalize)
 	hmac.Reset()
-	return hmac
+	return hmac, nil
 }
 
 func (h *hmac3) Reset() {
+	h.checkOpen()
 	if C.go_openssl_EVP_MAC_init(h.ctx, base(h.key), C.size_t(len(h.key)), &h.params[0]) == 0 {
 		panic(newOpenSSLError("EVP_MAC_init failed"))
 	}
@@ -210,14 +279,27 @@ func (h *hmac3) Reset() {
 }
 
 func (h *hmac3) finalize() {
+	if h.digest != nil {
+		closeAll(h.digest)
+	}
 	C.go_openssl_EVP_MAC_free(h.md)
-	if h.ctx == nil {
-		return
+	if h.pooled != nil {
+		h.pooled.put()
+	} else if h.ctx != nil {
+		C.go_openssl_EVP_MAC_CTX_free(h.ctx)
 	}
-	C.go_openssl_EVP_MAC_CTX_free(h.ctx)
+	h.ctx, h.pooled = nil, nil
+}
+
+// Close frees the native resources of h, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (h *hmac3) Close() error {
+	return closeObject(h, h.finalize)
 }
 
 func (h *hmac3) Write(p []byte) (int, error) {
+	h.checkOpen()
+	addBytes(MetricHash, len(p))
 	if len(p) > 0 {
 		C.go_openssl_EVP_MAC_update(h.ctx, base(p), C.size_t(len(p)))
 	}
@@ -234,6 +316,7 @@ func (h *hmac3) BlockSize() int {
 }
 
 func (h *hmac3) Sum(in []byte) []byte {
+	h.checkOpen()
 	if h.sum == nil {
 		size := h.Size()
 		h.sum = make([]byte, size)
@@ -1,14 +1,15 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 import (
 	"bytes"
 	"hash"
+	"hash/fnv"
 	"testing"
 )
 
@@ -92,3 +93,37 @@ func BenchmarkHMACNewWriteSum(b *testing.B) {
 		buf[0] = mac[0]
 	}
 }
+
+func TestHMACBatch(t *testing.T) {
+	key := []byte("batch key")
+	msgs := [][]byte{[]byte("hello"), nil, []byte("hello world"), bytes.Repeat([]byte{'x'}, 1000)}
+	for _, fn := range []func() hash.Hash{NewSHA1, NewSHA256, NewSHA512} {
+		b := NewHMACBatch(fn, key)
+		defer b.Close()
+		prefix := []byte("prefix")
+		// Run twice to check that batches don't leak state.
+		for i := 0; i < 2; i++ {
+			got := b.Sum(append([]byte(nil), prefix...), msgs)
+			if !bytes.Equal(got[:len(prefix)], prefix) {
+				t.Fatal("Sum didn't append to dst")
+			}
+			got = got[len(prefix):]
+			if len(got) != len(msgs)*b.Size() {
+				t.Fatalf("got %d bytes, want %d", len(got), len(msgs)*b.Size())
+			}
+			for j, msg := range msgs {
+				h := NewHMAC(fn, key)
+				h.Write(msg)
+				if want := h.Sum(nil); !bytes.Equal(got[j*b.Size():(j+1)*b.Size()], want) {
+					t.Errorf("message %d: got %x, want %x", j, got[j*b.Size():(j+1)*b.Size()], want)
+				}
+			}
+		}
+		if got := b.Sum(nil, nil); len(got) != 0 {
+			t.Errorf("Sum of no messages returned %x", got)
+		}
+	}
+	if NewHMACBatch(func() hash.Hash { return fnv.New128() }, key) != nil {
+		t.Error("NewHMACBatch accepted an unsupported hash")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"hash"
+	"runtime"
+)
+
+// An HMACBatch computes the HMACs of many messages under the same key,
+// such as log records or requests to authenticate, in a single cgo call
+// per batch. The key is only processed once, when the HMACBatch is
+// created, and every message starts from the resulting keyed state.
+// An HMACBatch is not safe for concurrent use.
+type HMACBatch struct {
+	// mac is an *hmac1 or an *hmac3 holding the keyed context.
+	mac  hash.Hash
+	size int
+	// buf and ends are scratch space for the messages of a batch,
+	// which are concatenated since cgo can't be passed a [][]byte.
+	buf  []byte
+	ends []C.size_t
+}
+
+// NewHMACBatch returns an HMACBatch computing HMACs with the hash h,
+// as described in NewHMAC, and key. If h is not recognized,
+// NewHMACBatch returns nil.
+func NewHMACBatch(h func() hash.Hash, key []byte) *HMACBatch {
+	mac := NewHMAC(h, key)
+	if mac == nil {
+		return nil
+	}
+	return &HMACBatch{mac: mac, size: mac.Size()}
+}
+
+// Size returns the size of each HMAC.
+func (b *HMACBatch) Size() int {
+	return b.size
+}
+
+// Close frees the native resources of b, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (b *HMACBatch) Close() error {
+	closeAll(b.mac)
+	return nil
+}
+
+// Sum appends the HMACs of msgs to dst, Size bytes for each message
+// in order, and returns the resulting slice.
+func (b *HMACBatch) Sum(dst []byte, msgs [][]byte) []byte {
+	if len(msgs) == 0 {
+		return dst
+	}
+	b.buf, b.ends = b.buf[:0], b.ends[:0]
+	for _, msg := range msgs {
+		b.buf = append(b.buf, msg...)
+		b.ends = append(b.ends, C.size_t(len(b.buf)))
+	}
+	addBytes(MetricHash, len(b.buf))
+	ret, out := sliceForAppend(dst, len(msgs)*b.size)
+	var ok C.int
+	switch mac := b.mac.(type) {
+	case *hmac1:
+		ok = C.go_openssl_HMAC_batch(mac.ctx, base(b.buf), &b.ends[0], C.size_t(len(msgs)), base(out), C.size_t(b.size))
+	case *hmac3:
+		ok = C.go_openssl_EVP_MAC_batch(mac.ctx, base(b.buf), &b.ends[0], C.size_t(len(msgs)), base(out), C.size_t(b.size))
+	}
+	runtime.KeepAlive(b.mac)
+	if ok != 1 {
+		panic(newOpenSSLError("HMAC batch failed"))
+	}
+	return ret
+}
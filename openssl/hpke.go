@@ -0,0 +1,570 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// HPKEKEM identifies an HPKE key encapsulation mechanism, as registered
+// in the IANA HPKE KEM Identifiers registry.
+type HPKEKEM uint16
+
+// HPKEKDF identifies an HPKE key derivation function.
+type HPKEKDF uint16
+
+// HPKEAEAD identifies an HPKE authenticated encryption algorithm.
+type HPKEAEAD uint16
+
+// HPKE algorithms, as specified in RFC 9180, Section 7.
+const (
+	HPKEDHKEMP256   HPKEKEM = 0x0010 // DHKEM(P-256, HKDF-SHA256)
+	HPKEDHKEMX25519 HPKEKEM = 0x0020 // DHKEM(X25519, HKDF-SHA256)
+
+	HPKEHKDFSHA256 HPKEKDF = 0x0001
+	HPKEHKDFSHA384 HPKEKDF = 0x0002
+	HPKEHKDFSHA512 HPKEKDF = 0x0003
+
+	HPKEAES128GCM        HPKEAEAD = 0x0001
+	HPKEAES256GCM        HPKEAEAD = 0x0002
+	HPKEChaCha20Poly1305 HPKEAEAD = 0x0003
+	// HPKEExportOnly restricts the context to Export.
+	HPKEExportOnly HPKEAEAD = 0xFFFF
+)
+
+// HPKESuite is a combination of HPKE algorithms.
+type HPKESuite struct {
+	KEM  HPKEKEM
+	KDF  HPKEKDF
+	AEAD HPKEAEAD
+}
+
+// HPKE modes, as specified in RFC 9180, Section 5.
+const (
+	hpkeModeBase = 0x00
+	hpkeModeAuth = 0x02
+)
+
+var errHPKESuite = errors.New("openssl: unsupported HPKE suite")
+
+// hpkeKEMSuiteID returns the suite_id the KEM uses in its labeled
+// key derivations, which is always HKDF-SHA256 for DHKEM.
+func hpkeKEMSuiteID(kem HPKEKEM) []byte {
+	return []byte{'K', 'E', 'M', byte(kem >> 8), byte(kem)}
+}
+
+func (s HPKESuite) id() []byte {
+	return []byte{'H', 'P', 'K', 'E',
+		byte(s.KEM >> 8), byte(s.KEM),
+		byte(s.KDF >> 8), byte(s.KDF),
+		byte(s.AEAD >> 8), byte(s.AEAD)}
+}
+
+func (s HPKESuite) check() error {
+	if _, err := hpkePublicKeySize(s.KEM); err != nil {
+		return err
+	}
+	if s.KDF.hash() == nil {
+		return errHPKESuite
+	}
+	switch s.AEAD {
+	case HPKEAES128GCM, HPKEAES256GCM, HPKEChaCha20Poly1305, HPKEExportOnly:
+	default:
+		return errHPKESuite
+	}
+	if StrictFIPS() {
+		if s.KEM == HPKEDHKEMX25519 {
+			return notApprovedError("DHKEM(X25519)")
+		}
+		if s.AEAD == HPKEChaCha20Poly1305 {
+			return notApprovedError("ChaCha20-Poly1305")
+		}
+	}
+	return nil
+}
+
+func (kdf HPKEKDF) hash() func() hash.Hash {
+	switch kdf {
+	case HPKEHKDFSHA256:
+		return NewSHA256
+	case HPKEHKDFSHA384:
+		return NewSHA384
+	case HPKEHKDFSHA512:
+		return NewSHA512
+	}
+	return nil
+}
+
+func hpkePublicKeySize(kem HPKEKEM) (int, error) {
+	switch kem {
+	case HPKEDHKEMP256:
+		return 65, nil
+	case HPKEDHKEMX25519:
+		return 32, nil
+	}
+	return 0, errHPKESuite
+}
+
+// hkdfExtract and hkdfExpand implement RFC 5869 on top of NewHMAC,
+// so they are available on every supported OpenSSL version.
+func hkdfExtract(h func() hash.Hash, salt, ikm []byte) []byte {
+	mac := NewHMAC(h, salt)
+	defer closeAll(mac)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+func hkdfExpand(h func() hash.Hash, prk, info []byte, length int) ([]byte, error) {
+	mac := NewHMAC(h, prk)
+	defer closeAll(mac)
+	if length > 255*mac.Size() {
+		return nil, errors.New("openssl: HKDF output too long")
+	}
+	out := make([]byte, 0, length+mac.Size())
+	var prev []byte
+	for i := byte(1); len(out) < length; i++ {
+		mac.Reset()
+		mac.Write(prev)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		prev = mac.Sum(nil)
+		out = append(out, prev...)
+	}
+	return out[:length], nil
+}
+
+func hpkeLabeledExtract(h func() hash.Hash, suiteID, salt []byte, label string, ikm []byte) []byte {
+	b := make([]byte, 0, 7+len(suiteID)+len(label)+len(ikm))
+	b = append(b, "HPKE-v1"...)
+	b = append(b, suiteID...)
+	b = append(b, label...)
+	b = append(b, ikm...)
+	return hkdfExtract(h, salt, b)
+}
+
+func hpkeLabeledExpand(h func() hash.Hash, suiteID, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	if length > 0xFFFF {
+		return nil, errors.New("openssl: HPKE output too long")
+	}
+	b := make([]byte, 2, 9+len(suiteID)+len(label)+len(info))
+	binary.BigEndian.PutUint16(b, uint16(length))
+	b = append(b, "HPKE-v1"...)
+	b = append(b, suiteID...)
+	b = append(b, label...)
+	b = append(b, info...)
+	return hkdfExpand(h, prk, b, length)
+}
+
+// PrivateKeyHPKE is a DHKEM private key.
+type PrivateKeyHPKE struct {
+	kem HPKEKEM
+	// Exactly one of x25519 and p256 is set.
+	x25519 *PKey
+	p256   *PrivateKeyECDH
+	priv   []byte
+	pub    []byte
+}
+
+// GenerateKeyHPKE generates a private key for kem.
+//
+// HPKEDHKEMX25519 is only supported on OpenSSL 3, and HPKEDHKEMX25519
+// and HPKEChaCha20Poly1305 are rejected in strict FIPS mode.
+func GenerateKeyHPKE(kem HPKEKEM) (*PrivateKeyHPKE, error) {
+	switch kem {
+	case HPKEDHKEMX25519:
+		k, err := GenerateKey("X25519", nil)
+		if err != nil {
+			return nil, err
+		}
+		return newX25519PrivateKeyHPKE(k)
+	case HPKEDHKEMP256:
+		k, priv, err := GenerateKeyECDH("P-256")
+		if err != nil {
+			return nil, err
+		}
+		return newP256PrivateKeyHPKE(k, priv)
+	}
+	return nil, errHPKESuite
+}
+
+// NewPrivateKeyHPKE parses a private key for kem,
+// serialized as specified in RFC 9180, Section 7.1.2.
+func NewPrivateKeyHPKE(kem HPKEKEM, b []byte) (*PrivateKeyHPKE, error) {
+	switch kem {
+	case HPKEDHKEMX25519:
+		k, err := NewPrivatePKey("X25519", b)
+		if err != nil {
+			return nil, err
+		}
+		return newX25519PrivateKeyHPKE(k)
+	case HPKEDHKEMP256:
+		k, err := NewPrivateKeyECDH("P-256", b)
+		if err != nil {
+			return nil, err
+		}
+		return newP256PrivateKeyHPKE(k, append([]byte(nil), b...))
+	}
+	return nil, errHPKESuite
+}
+
+// newX25519PrivateKeyHPKE takes ownership of k, closing it on error.
+func newX25519PrivateKeyHPKE(k *PKey) (*PrivateKeyHPKE, error) {
+	priv, err := k.PrivateKeyBytes()
+	if err != nil {
+		k.Close()
+		return nil, err
+	}
+	pub, err := k.PublicKeyBytes()
+	if err != nil {
+		k.Close()
+		return nil, err
+	}
+	return &PrivateKeyHPKE{kem: HPKEDHKEMX25519, x25519: k, priv: priv, pub: pub}, nil
+}
+
+// newP256PrivateKeyHPKE takes ownership of k, closing it on error.
+func newP256PrivateKeyHPKE(k *PrivateKeyECDH, priv []byte) (*PrivateKeyHPKE, error) {
+	pub, err := k.PublicKey()
+	if err != nil {
+		k.Close()
+		return nil, err
+	}
+	defer pub.Close()
+	return &PrivateKeyHPKE{kem: HPKEDHKEMP256, p256: k, priv: priv, pub: pub.Bytes()}, nil
+}
+
+// Close frees the native key of k, see InitOptions.ExplicitClose.
+func (k *PrivateKeyHPKE) Close() error {
+	if k.x25519 != nil {
+		return k.x25519.Close()
+	}
+	return k.p256.Close()
+}
+
+// KEM returns the KEM the key belongs to.
+func (k *PrivateKeyHPKE) KEM() HPKEKEM { return k.kem }
+
+// Bytes returns the serialized private key.
+func (k *PrivateKeyHPKE) Bytes() []byte { return append([]byte(nil), k.priv...) }
+
+// PublicKey returns the serialized public key corresponding to k.
+func (k *PrivateKeyHPKE) PublicKey() []byte { return append([]byte(nil), k.pub...) }
+
+// dh performs a Diffie-Hellman exchange between k and the serialized public key pub.
+func (k *PrivateKeyHPKE) dh(pub []byte) ([]byte, error) {
+	if k.x25519 != nil {
+		peer, err := NewPublicPKey("X25519", pub)
+		if err != nil {
+			return nil, err
+		}
+		defer peer.Close()
+		// OpenSSL rejects peer keys resulting in an all-zero shared secret.
+		return k.x25519.Derive(peer)
+	}
+	peer, err := NewPublicKeyECDH("P-256", pub)
+	if err != nil {
+		return nil, err
+	}
+	defer peer.Close()
+	return ECDH(k.p256, peer)
+}
+
+// hpkeExtractAndExpand derives the KEM shared secret, as specified
+// in RFC 9180, Section 4.1. Both DHKEMs use HKDF-SHA256.
+func hpkeExtractAndExpand(kem HPKEKEM, dh, kemContext []byte) ([]byte, error) {
+	suiteID := hpkeKEMSuiteID(kem)
+	prk := hpkeLabeledExtract(NewSHA256, suiteID, nil, "eae_prk", dh)
+	return hpkeLabeledExpand(NewSHA256, suiteID, prk, "shared_secret", kemContext, 32)
+}
+
+// hpkeEncap implements Encap and AuthEncap. skS is nil in base mode.
+func hpkeEncap(kem HPKEKEM, pkR []byte, skS, skE *PrivateKeyHPKE) (sharedSecret, enc []byte, err error) {
+	if n, err := hpkePublicKeySize(kem); err != nil {
+		return nil, nil, err
+	} else if len(pkR) != n {
+		return nil, nil, errors.New("openssl: invalid HPKE public key size")
+	}
+	if skE == nil {
+		if skE, err = GenerateKeyHPKE(kem); err != nil {
+			return nil, nil, err
+		}
+		defer skE.Close()
+	}
+	dh, err := skE.dh(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc = skE.pub
+	kemContext := append(append([]byte(nil), enc...), pkR...)
+	if skS != nil {
+		dhS, err := skS.dh(pkR)
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, skS.pub...)
+	}
+	sharedSecret, err = hpkeExtractAndExpand(kem, dh, kemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sharedSecret, enc, nil
+}
+
+// hpkeDecap implements Decap and AuthDecap. pkS is nil in base mode.
+func hpkeDecap(skR *PrivateKeyHPKE, enc, pkS []byte) ([]byte, error) {
+	n, _ := hpkePublicKeySize(skR.kem)
+	if len(enc) != n {
+		return nil, errors.New("openssl: invalid HPKE encapsulated key size")
+	}
+	dh, err := skR.dh(enc)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte(nil), enc...), skR.pub...)
+	if pkS != nil {
+		if len(pkS) != n {
+			return nil, errors.New("openssl: invalid HPKE public key size")
+		}
+		dhS, err := skR.dh(pkS)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS...)
+	}
+	return hpkeExtractAndExpand(skR.kem, dh, kemContext)
+}
+
+// hpkeContext is the encryption context shared by senders and recipients.
+type hpkeContext struct {
+	aead           cipher.AEAD // nil for HPKEExportOnly
+	baseNonce      []byte
+	nonce          []byte // scratch space for the nonce of the next message
+	seq            uint64
+	exporterSecret []byte
+	suite          HPKESuite
+}
+
+// newHPKEContext implements KeySchedule from RFC 9180, Section 5.1,
+// without support for pre-shared keys.
+func newHPKEContext(suite HPKESuite, mode byte, sharedSecret, info []byte) (*hpkeContext, error) {
+	h := suite.KDF.hash()
+	suiteID := suite.id()
+	pskIDHash := hpkeLabeledExtract(h, suiteID, nil, "psk_id_hash", nil)
+	infoHash := hpkeLabeledExtract(h, suiteID, nil, "info_hash", info)
+	ksContext := append(append([]byte{mode}, pskIDHash...), infoHash...)
+	secret := hpkeLabeledExtract(h, suiteID, sharedSecret, "secret", nil)
+
+	c := &hpkeContext{suite: suite}
+	var err error
+	hh := h()
+	size := hh.Size()
+	closeAll(hh)
+	c.exporterSecret, err = hpkeLabeledExpand(h, suiteID, secret, "exp", ksContext, size)
+	if err != nil {
+		return nil, err
+	}
+	if suite.AEAD == HPKEExportOnly {
+		return c, nil
+	}
+	keySize := 32
+	if suite.AEAD == HPKEAES128GCM {
+		keySize = 16
+	}
+	key, err := hpkeLabeledExpand(h, suiteID, secret, "key", ksContext, keySize)
+	if err != nil {
+		return nil, err
+	}
+	if c.baseNonce, err = hpkeLabeledExpand(h, suiteID, secret, "base_nonce", ksContext, 12); err != nil {
+		return nil, err
+	}
+	if suite.AEAD == HPKEChaCha20Poly1305 {
+		c.aead, err = newChaCha20Poly1305(key)
+	} else {
+		var block cipher.Block
+		if block, err = NewAESCipher(key); err == nil {
+			// The AEAD doesn't need block.
+			c.aead, err = cipher.NewGCM(block)
+			closeAll(block)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close frees the native AEAD of c, see InitOptions.ExplicitClose.
+func (c *hpkeContext) Close() error {
+	closeAll(c.aead)
+	return nil
+}
+
+// nextNonce returns the nonce for the current sequence number
+// and increments it. The nonce is only valid until the next call.
+func (c *hpkeContext) nextNonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, errors.New("openssl: HPKE context is export-only")
+	}
+	if c.seq == 1<<64-1 {
+		return nil, errors.New("openssl: HPKE message limit reached")
+	}
+	nonce := append(c.nonce[:0], c.baseNonce...)
+	c.nonce = nonce
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(c.seq >> (8 * i))
+	}
+	c.seq++
+	return nonce, nil
+}
+
+// Export derives length bytes of secret keying material bound to exporterContext,
+// as specified in RFC 9180, Section 5.3.
+func (c *hpkeContext) Export(exporterContext []byte, length int) ([]byte, error) {
+	return hpkeLabeledExpand(c.suite.KDF.hash(), c.suite.id(), c.exporterSecret, "sec", exporterContext, length)
+}
+
+// SenderHPKE is the sender side of an HPKE context. It encrypts
+// a sequence of messages for a recipient, and is not safe
+// for concurrent use.
+type SenderHPKE struct {
+	hpkeContext
+}
+
+// RecipientHPKE is the recipient side of an HPKE context. It decrypts
+// the messages of a sender in order, and is not safe for concurrent use.
+type RecipientHPKE struct {
+	hpkeContext
+}
+
+// NewSenderHPKE sets up an HPKE context in base mode to encrypt messages
+// for the owner of the serialized public key pkR. It returns the
+// encapsulated key enc that must be sent to the recipient.
+func NewSenderHPKE(suite HPKESuite, pkR, info []byte) (enc []byte, s *SenderHPKE, err error) {
+	return newSenderHPKE(suite, pkR, info, nil, nil)
+}
+
+// NewAuthSenderHPKE is like NewSenderHPKE but uses auth mode,
+// which also authenticates the sender as the owner of skS.
+func NewAuthSenderHPKE(suite HPKESuite, pkR, info []byte, skS *PrivateKeyHPKE) (enc []byte, s *SenderHPKE, err error) {
+	if skS == nil || skS.kem != suite.KEM {
+		return nil, nil, errors.New("openssl: HPKE sender key does not match suite")
+	}
+	return newSenderHPKE(suite, pkR, info, skS, nil)
+}
+
+// newSenderHPKE uses the ephemeral key skE if not nil, for testing.
+func newSenderHPKE(suite HPKESuite, pkR, info []byte, skS, skE *PrivateKeyHPKE) ([]byte, *SenderHPKE, error) {
+	if err := suite.check(); err != nil {
+		return nil, nil, err
+	}
+	sharedSecret, enc, err := hpkeEncap(suite.KEM, pkR, skS, skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	mode := byte(hpkeModeBase)
+	if skS != nil {
+		mode = hpkeModeAuth
+	}
+	c, err := newHPKEContext(suite, mode, sharedSecret, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &SenderHPKE{*c}, nil
+}
+
+// NewRecipientHPKE sets up an HPKE context in base mode to decrypt
+// messages encrypted to skR, given the encapsulated key enc.
+func NewRecipientHPKE(suite HPKESuite, skR *PrivateKeyHPKE, enc, info []byte) (*RecipientHPKE, error) {
+	return newRecipientHPKE(suite, skR, enc, info, nil)
+}
+
+// NewAuthRecipientHPKE is like NewRecipientHPKE but uses auth mode,
+// which also checks that the sender owns the serialized public key pkS.
+func NewAuthRecipientHPKE(suite HPKESuite, skR *PrivateKeyHPKE, enc, info, pkS []byte) (*RecipientHPKE, error) {
+	if pkS == nil {
+		return nil, errors.New("openssl: missing HPKE sender public key")
+	}
+	return newRecipientHPKE(suite, skR, enc, info, pkS)
+}
+
+func newRecipientHPKE(suite HPKESuite, skR *PrivateKeyHPKE, enc, info, pkS []byte) (*RecipientHPKE, error) {
+	if err := suite.check(); err != nil {
+		return nil, err
+	}
+	if skR.kem != suite.KEM {
+		return nil, errors.New("openssl: HPKE recipient key does not match suite")
+	}
+	sharedSecret, err := hpkeDecap(skR, enc, pkS)
+	if err != nil {
+		return nil, err
+	}
+	mode := byte(hpkeModeBase)
+	if pkS != nil {
+		mode = hpkeModeAuth
+	}
+	c, err := newHPKEContext(suite, mode, sharedSecret, info)
+	if err != nil {
+		return nil, err
+	}
+	return &RecipientHPKE{*c}, nil
+}
+
+// Seal encrypts and authenticates plaintext and authenticates aad,
+// using the next nonce of the context.
+func (s *SenderHPKE) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := s.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Open decrypts and authenticates ciphertext and authenticates aad,
+// using the next nonce of the context. Messages must be opened
+// in the order they were sealed. The sequence number is only
+// advanced if ciphertext is authentic.
+func (r *RecipientHPKE) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := r.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := r.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		r.seq--
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// SealHPKE encrypts a single message for the owner of pkR in base mode.
+// It returns the encapsulated key and the ciphertext, which must
+// both be sent to the recipient.
+func SealHPKE(suite HPKESuite, pkR, info, aad, plaintext []byte) (enc, ciphertext []byte, err error) {
+	enc, s, err := NewSenderHPKE(suite, pkR, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = s.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// OpenHPKE decrypts a single message sealed with SealHPKE.
+func OpenHPKE(suite HPKESuite, skR *PrivateKeyHPKE, enc, info, aad, ciphertext []byte) ([]byte, error) {
+	r, err := NewRecipientHPKE(suite, skR, enc, info)
+	if err != nil {
+		return nil, err
+	}
+	return r.Open(aad, ciphertext)
+}
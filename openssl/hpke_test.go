@@ -0,0 +1,222 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHPKEVectors(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("X25519 is only supported on OpenSSL 3")
+	}
+	// RFC 9180, Appendix A.1.1 and A.1.3.
+	info := decodeHex(t, "4f6465206f6e2061204772656369616e2055726e")
+	pt := []byte("Beauty is truth, truth beauty")
+	aad := []byte("Count-0")
+	suite := HPKESuite{HPKEDHKEMX25519, HPKEHKDFSHA256, HPKEAES128GCM}
+	tests := []struct {
+		name            string
+		skE, skR, skS   string
+		enc, ct, export string
+	}{
+		{
+			name:   "base",
+			skE:    "52c4a758a802cd8b936eceea314432798d5baf2d7e9235dc084ab1b9cfa2f736",
+			skR:    "4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8",
+			enc:    "37fda3567bdbd628e88668c3c8d7e97d1d1253b6d4ea6d44c150f741f1bf4431",
+			ct:     "f938558b5d72f1a23810b4be2ab4f84331acc02fc97babc53a52ae8218a355a96d8770ac83d07bea87e13c512a",
+			export: "3853fe2b4035195a573ffc53856e77058e15d9ea064de3e59f4961d0095250ee",
+		},
+		{
+			name: "auth",
+			skE:  "ff4442ef24fbc3c1ff86375b0be1e77e88a0de1e79b30896d73411c5ff4c3518",
+			skR:  "fdea67cf831f1ca98d8e27b1f6abeb5b7745e9d35348b80fa407ff6958f9137e",
+			skS:  "dc4a146313cce60a278a5323d321f051c5707e9c45ba21a3479fecdf76fc69dd",
+			enc:  "23fb952571a14a25e3d678140cd0e5eb47a0961bb18afcf85896e5453c312e76",
+			ct:   "5fd92cc9d46dbf8943e72a07e42f363ed5f721212cd90bcfd072bfd9f44e06b80fd17824947496e21b680c141b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skE, err := NewPrivateKeyHPKE(suite.KEM, decodeHex(t, tt.skE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			skR, err := NewPrivateKeyHPKE(suite.KEM, decodeHex(t, tt.skR))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var skS *PrivateKeyHPKE
+			var pkS []byte
+			if tt.skS != "" {
+				if skS, err = NewPrivateKeyHPKE(suite.KEM, decodeHex(t, tt.skS)); err != nil {
+					t.Fatal(err)
+				}
+				pkS = skS.PublicKey()
+			}
+			enc, s, err := newSenderHPKE(suite, skR.PublicKey(), info, skS, skE)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeHex(t, tt.enc); !bytes.Equal(enc, want) {
+				t.Errorf("got enc %x, want %x", enc, want)
+			}
+			ct, err := s.Seal(aad, pt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeHex(t, tt.ct); !bytes.Equal(ct, want) {
+				t.Errorf("got ciphertext %x, want %x", ct, want)
+			}
+			if tt.export != "" {
+				got, err := s.Export(nil, 32)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := decodeHex(t, tt.export); !bytes.Equal(got, want) {
+					t.Errorf("got exported secret %x, want %x", got, want)
+				}
+			}
+
+			r, err := newRecipientHPKE(suite, skR, enc, info, pkS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.Open(aad, ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, pt) {
+				t.Errorf("got plaintext %q, want %q", got, pt)
+			}
+		})
+	}
+}
+
+func TestHPKERoundTrip(t *testing.T) {
+	kems := []HPKEKEM{HPKEDHKEMP256}
+	if vMajor == 3 {
+		kems = append(kems, HPKEDHKEMX25519)
+	}
+	aeads := []HPKEAEAD{HPKEAES128GCM, HPKEAES256GCM}
+	if !FIPS() {
+		aeads = append(aeads, HPKEChaCha20Poly1305)
+	}
+	info := []byte("info")
+	for _, kem := range kems {
+		skR, err := GenerateKeyHPKE(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skS, err := GenerateKeyHPKE(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, kdf := range []HPKEKDF{HPKEHKDFSHA256, HPKEHKDFSHA384, HPKEHKDFSHA512} {
+			for _, aead := range aeads {
+				suite := HPKESuite{kem, kdf, aead}
+				enc, ct, err := SealHPKE(suite, skR.PublicKey(), info, []byte("aad"), []byte("hello"))
+				if err != nil {
+					t.Fatalf("%v: %v", suite, err)
+				}
+				pt, err := OpenHPKE(suite, skR, enc, info, []byte("aad"), ct)
+				if err != nil || string(pt) != "hello" {
+					t.Fatalf("%v: got %q, %v", suite, pt, err)
+				}
+				if _, err := OpenHPKE(suite, skR, enc, []byte("other info"), []byte("aad"), ct); err == nil {
+					t.Errorf("%v: opened with a different info", suite)
+				}
+
+				enc, s, err := NewAuthSenderHPKE(suite, skR.PublicKey(), info, skS)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := NewAuthRecipientHPKE(suite, skR, enc, info, skS.PublicKey())
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, msg := range []string{"first", "second", ""} {
+					ct, err := s.Seal(nil, []byte(msg))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, err := r.Open([]byte("wrong aad"), ct); err == nil {
+						t.Errorf("%v: opened with a different aad", suite)
+					}
+					pt, err := r.Open(nil, ct)
+					if err != nil || string(pt) != msg {
+						t.Fatalf("%v: got %q, %v", suite, pt, err)
+					}
+				}
+				e1, err := s.Export([]byte("context"), 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+				e2, err := r.Export([]byte("context"), 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(e1, e2) {
+					t.Errorf("%v: exported secrets differ", suite)
+				}
+			}
+		}
+	}
+}
+
+func TestHPKEExportOnly(t *testing.T) {
+	suite := HPKESuite{HPKEDHKEMP256, HPKEHKDFSHA256, HPKEExportOnly}
+	skR, err := GenerateKeyHPKE(suite.KEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, s, err := NewSenderHPKE(suite, skR.PublicKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Seal(nil, []byte("hello")); err == nil {
+		t.Error("expected error sealing with an export-only context")
+	}
+	r, err := NewRecipientHPKE(suite, skR, enc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e1, _ := s.Export([]byte("context"), 32)
+	e2, _ := r.Export([]byte("context"), 32)
+	if !bytes.Equal(e1, e2) {
+		t.Error("exported secrets differ")
+	}
+}
+
+func TestHPKEErrors(t *testing.T) {
+	if _, err := GenerateKeyHPKE(0x0011); err == nil {
+		t.Error("expected error for unsupported KEM")
+	}
+	skR, err := GenerateKeyHPKE(HPKEDHKEMP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewSenderHPKE(HPKESuite{HPKEDHKEMP256, 0x0004, HPKEAES128GCM}, skR.PublicKey(), nil); err == nil {
+		t.Error("expected error for unsupported KDF")
+	}
+	suite := HPKESuite{HPKEDHKEMP256, HPKEHKDFSHA256, HPKEAES128GCM}
+	if _, _, err := NewSenderHPKE(suite, skR.PublicKey()[1:], nil); err == nil {
+		t.Error("expected error for short public key")
+	}
+	if _, err := NewRecipientHPKE(suite, skR, make([]byte, 64), nil); err == nil {
+		t.Error("expected error for short encapsulated key")
+	}
+	sk2, err := NewPrivateKeyHPKE(HPKEDHKEMP256, skR.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sk2.PublicKey(), skR.PublicKey()) {
+		t.Error("parsed key has a different public key")
+	}
+}
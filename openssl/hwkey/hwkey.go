@@ -0,0 +1,224 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package hwkey adapts the private keys of OpenSSL ENGINEs, providers
+// and stores, such as HSM, smart card and TPM keys whose material can't
+// be exported, to crypto.Signer and crypto.Decrypter. They can then be
+// used directly as the PrivateKey of a tls.Certificate or as the signer
+// of x509.CreateCertificate and x509.CreateCertificateRequest, and
+// X509KeyPair builds such tls.Certificate values from their chains.
+//
+// The sshkey package, a separate module depending on golang.org/x/crypto,
+// adapts a Key to the ssh.Signer and ssh.PublicKey of SSH host and user
+// keys, with or without an SSH certificate.
+//
+// A Key is identified by the URI it was loaded from, which is what
+// applications persist, for example in their configuration, to load
+// it again with Load or LoadFromEngine.
+package hwkey
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// Key is a private key held by OpenSSL, whose private operations are
+// performed by the ENGINE, provider or store it was loaded from.
+// It is safe for concurrent use.
+type Key struct {
+	uri string
+	// priv is a *openssl.PrivateKeyRSA, a *openssl.PrivateKeyECDSA
+	// or a *openssl.PKey, which keeps the EVP_PKEY alive.
+	priv interface{}
+	pub  crypto.PublicKey
+	// engine keeps the ENGINE of the keys of LoadFromEngine
+	// initialized for as long as the key is used.
+	engine *openssl.Engine
+}
+
+// Load loads the private key at uri with openssl.LoadKeyWithPassphrase,
+// calling passphrase, if not nil, for the passphrase or PIN protecting it.
+//
+// Load is only supported on OpenSSL 3.
+func Load(uri string, passphrase openssl.PassphraseFunc) (*Key, error) {
+	priv, err := openssl.LoadKeyWithPassphrase(uri, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return New(uri, priv)
+}
+
+// LoadFromEngine loads the private key keyID from e with
+// openssl.Engine.PrivateKey. The key ID is used as the key URI.
+func LoadFromEngine(e *openssl.Engine, keyID string) (*Key, error) {
+	priv, err := e.PrivateKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := New(keyID, priv)
+	if err != nil {
+		return nil, err
+	}
+	k.engine = e
+	return k, nil
+}
+
+// New returns a Key for priv, a *openssl.PrivateKeyRSA, a
+// *openssl.PrivateKeyECDSA or a *openssl.PKey obtained otherwise,
+// such as a key generated in a TPM, identified by uri.
+//
+// A *openssl.PKey can only sign messages directly, such as an
+// Ed25519 key, since its Sign method hashes the message itself.
+func New(uri string, priv interface{}) (*Key, error) {
+	switch priv.(type) {
+	case *openssl.PrivateKeyRSA, *openssl.PrivateKeyECDSA, *openssl.PKey:
+	default:
+		return nil, errors.New("hwkey: unsupported private key type")
+	}
+	der, err := openssl.MarshalPKIXPublicKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{uri: uri, priv: priv, pub: pub}, nil
+}
+
+// URI returns the URI or key ID k was loaded from.
+func (k *Key) URI() string {
+	return k.uri
+}
+
+// String returns the URI of k.
+func (k *Key) String() string {
+	return k.uri
+}
+
+// MarshalText returns the URI of k, so that a Key is serialized as
+// its reference rather than as its private key material.
+func (k *Key) MarshalText() ([]byte, error) {
+	return []byte(k.uri), nil
+}
+
+// PrivateKey returns the key of the openssl package k wraps, to use
+// it with the functions of the openssl package, such as SignCMS.
+func (k *Key) PrivateKey() interface{} {
+	return k.priv
+}
+
+// Public returns the public key of k, a *rsa.PublicKey, an
+// *ecdsa.PublicKey or an ed25519.PublicKey.
+func (k *Key) Public() crypto.PublicKey {
+	return k.pub
+}
+
+// Equal reports whether k and x, a *Key, hold the same private key,
+// whatever their URIs.
+func (k *Key) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*Key)
+	if !ok {
+		return false
+	}
+	switch priv := k.priv.(type) {
+	case *openssl.PrivateKeyRSA:
+		return priv.Equal(xx.priv)
+	case *openssl.PrivateKeyECDSA:
+		return priv.Equal(xx.priv)
+	case *openssl.PKey:
+		return priv.Equal(xx.priv)
+	}
+	return false
+}
+
+// Sign signs digest with k, as described in crypto.Signer.
+// If opts is a *rsa.PSSOptions, RSA keys use the PSS padding,
+// and PKCS #1 v1.5 otherwise. ECDSA signatures are ASN.1 encoded.
+// Keys that sign messages directly, such as Ed25519 keys, require
+// opts.HashFunc() to be zero and digest to be the message.
+// rand is ignored, OpenSSL uses its own random generator.
+func (k *Key) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	switch priv := k.priv.(type) {
+	case *openssl.PrivateKeyRSA:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			return openssl.SignRSAPSS(priv, pss.Hash, digest, pss.SaltLength)
+		}
+		return openssl.SignRSAPKCS1v15(priv, opts.HashFunc(), digest)
+	case *openssl.PrivateKeyECDSA:
+		return openssl.SignMarshalECDSA(priv, digest)
+	case *openssl.PKey:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("hwkey: " + priv.Algorithm() + " keys can't sign a digest")
+		}
+		return priv.Sign(digest, "")
+	}
+	panic("unreachable")
+}
+
+// Decrypt decrypts ciphertext with k, which must be an RSA key, as
+// described in crypto.Decrypter. opts is either nil or a
+// *rsa.PKCS1v15DecryptOptions for PKCS #1 v1.5, or a *rsa.OAEPOptions
+// for OAEP, whose MGF1 hash is its Hash.
+//
+// rand is only used, when SessionKeyLen is set, for the random session
+// key returned instead of an error, as in rsa.DecryptPKCS1v15SessionKey.
+func (k *Key) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	priv, ok := k.priv.(*openssl.PrivateKeyRSA)
+	if !ok {
+		return nil, errors.New("hwkey: only RSA keys can decrypt")
+	}
+	switch opts := opts.(type) {
+	case nil:
+		return openssl.DecryptRSAPKCS1(priv, ciphertext)
+	case *rsa.PKCS1v15DecryptOptions:
+		plaintext, err := openssl.DecryptRSAPKCS1(priv, ciphertext)
+		if opts.SessionKeyLen == 0 {
+			return plaintext, err
+		}
+		if err != nil || len(plaintext) != opts.SessionKeyLen {
+			// Hide the padding error as rsa.DecryptPKCS1v15SessionKey does,
+			// although the timing of OpenSSL isn't constant for it.
+			plaintext = make([]byte, opts.SessionKeyLen)
+			if _, err := io.ReadFull(rand, plaintext); err != nil {
+				return nil, err
+			}
+		}
+		return plaintext, nil
+	case *rsa.OAEPOptions:
+		h := newHash(opts.Hash)
+		if h == nil {
+			return nil, errors.New("hwkey: unsupported OAEP hash")
+		}
+		defer h.(io.Closer).Close()
+		return openssl.DecryptRSAOAEP(h, priv, ciphertext, opts.Label)
+	}
+	return nil, errors.New("hwkey: unsupported decrypter options")
+}
+
+// newHash returns the OpenSSL implementation of h,
+// which the OAEP functions of the openssl package require.
+func newHash(h crypto.Hash) hash.Hash {
+	switch h {
+	case crypto.SHA1:
+		return openssl.NewSHA1()
+	case crypto.SHA224:
+		return openssl.NewSHA224()
+	case crypto.SHA256:
+		return openssl.NewSHA256()
+	case crypto.SHA384:
+		return openssl.NewSHA384()
+	case crypto.SHA512:
+		return openssl.NewSHA512()
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package hwkey
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// loadKey generates a key of the algorithm name, stores it in
+// a PEM file and loads it back with Load.
+func loadKey(t *testing.T, name string, params map[string]interface{}) *Key {
+	t.Helper()
+	if _, err := openssl.DefaultLibraryContext(); err != nil {
+		t.Skip("OSSL_STORE is only supported on OpenSSL 3")
+	}
+	priv, err := openssl.GenerateKey(name, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := openssl.MarshalPEMPrivateKey(priv, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	k, err := Load("file:"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.URI() != "file:"+path {
+		t.Errorf("URI() = %q, want file:%s", k.URI(), path)
+	}
+	if text, err := k.MarshalText(); err != nil || string(text) != k.URI() {
+		t.Errorf("MarshalText() = %q, %v, want the URI", text, err)
+	}
+	return k
+}
+
+// checkCertificate self-signs a certificate with signer
+// and checks its signature with crypto/x509.
+func checkCertificate(t *testing.T, signer crypto.Signer) {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hsm"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRSA(t *testing.T) {
+	k := loadKey(t, "RSA", map[string]interface{}{"bits": 2048})
+	pub, ok := k.Public().(*rsa.PublicKey)
+	if !ok {
+		t.Fatalf("Public() = %T, want *rsa.PublicKey", k.Public())
+	}
+	checkCertificate(t, k)
+
+	hashed := sha256.Sum256([]byte("hello world"))
+	pss := &rsa.PSSOptions{Hash: crypto.SHA256, SaltLength: rsa.PSSSaltLengthEqualsHash}
+	sig, err := k.Sign(rand.Reader, hashed[:], pss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPSS(pub, crypto.SHA256, hashed[:], sig, pss); err != nil {
+		t.Error(err)
+	}
+
+	msg := []byte("session key")
+	ct, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, msg, []byte("label"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Decrypt(rand.Reader, ct, &rsa.OAEPOptions{Hash: crypto.SHA256, Label: []byte("label")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("OAEP: got %q, want %q", got, msg)
+	}
+	ct, err = rsa.EncryptPKCS1v15(rand.Reader, pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err = k.Decrypt(rand.Reader, ct, nil); err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("PKCS #1 v1.5: got %q, %v, want %q", got, err, msg)
+	}
+	// A wrong session key length yields a random key instead of an error.
+	got, err = k.Decrypt(rand.Reader, ct, &rsa.PKCS1v15DecryptOptions{SessionKeyLen: 16})
+	if err != nil || len(got) != 16 {
+		t.Errorf("got session key %x, %v, want 16 random bytes", got, err)
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	k := loadKey(t, "EC", map[string]interface{}{"group": "P-256"})
+	pub, ok := k.Public().(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatalf("Public() = %T, want *ecdsa.PublicKey", k.Public())
+	}
+	checkCertificate(t, k)
+	hashed := sha256.Sum256([]byte("hello world"))
+	sig, err := k.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(pub, hashed[:], sig) {
+		t.Error("signature doesn't verify")
+	}
+	if _, err := k.Decrypt(rand.Reader, sig, nil); err == nil {
+		t.Error("decrypted with an ECDSA key")
+	}
+}
+
+func TestEd25519(t *testing.T) {
+	k := loadKey(t, "ED25519", nil)
+	pub, ok := k.Public().(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("Public() = %T, want ed25519.PublicKey", k.Public())
+	}
+	checkCertificate(t, k)
+	msg := []byte("hello world")
+	sig, err := k.Sign(rand.Reader, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		t.Error("signature doesn't verify")
+	}
+	if _, err := k.Sign(rand.Reader, msg, crypto.SHA256); err == nil {
+		t.Error("signed a digest with an Ed25519 key")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New("pkcs11:object=key", "not a key"); err == nil {
+		t.Error("wrapped an unsupported key type")
+	}
+}
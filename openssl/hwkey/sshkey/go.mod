@@ -0,0 +1,12 @@
+module github.com/microsoft/go-crypto-openssl/openssl/hwkey/sshkey
+
+go 1.26.0
+
+require (
+	github.com/microsoft/go-crypto-openssl v0.2.5
+	golang.org/x/crypto v0.57.0
+)
+
+require golang.org/x/sys v0.48.0 // indirect
+
+replace github.com/microsoft/go-crypto-openssl => ../../../
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+
+// Package sshkey adapts the keys of the hwkey package to the ssh.Signer
+// and ssh.PublicKey of golang.org/x/crypto/ssh, so that SSH clients and
+// servers can keep their host and user keys in OpenSSL ENGINEs,
+// providers and stores, such as HSMs, smart cards and TPMs.
+//
+// The package is a separate module so that the openssl module
+// doesn't depend on golang.org/x/crypto.
+package sshkey
+
+import (
+	"crypto/rsa"
+	"errors"
+
+	"github.com/microsoft/go-crypto-openssl/openssl/hwkey"
+	"golang.org/x/crypto/ssh"
+)
+
+// rsaAlgorithms are the signature algorithms of the RSA signers,
+// ssh-rsa signatures use SHA-1, which FIPS modules don't allow.
+var rsaAlgorithms = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256}
+
+// NewSigner returns an ssh.Signer signing with k, an RSA, ECDSA or
+// Ed25519 key. The signer of an RSA key is an ssh.MultiAlgorithmSigner
+// only supporting the rsa-sha2-512 and rsa-sha2-256 algorithms.
+func NewSigner(k *hwkey.Key) (ssh.Signer, error) {
+	s, err := ssh.NewSignerFromSigner(k)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := k.Public().(*rsa.PublicKey); !ok {
+		return s, nil
+	}
+	as, ok := s.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, errors.New("sshkey: RSA signer doesn't support algorithms")
+	}
+	return ssh.NewSignerWithAlgorithms(as, rsaAlgorithms)
+}
+
+// NewCertSigner returns an ssh.Signer signing with k and presenting
+// cert, an SSH certificate of the public key of k, such as a host or a
+// user certificate. See NewSigner for the algorithms of RSA keys.
+func NewCertSigner(cert *ssh.Certificate, k *hwkey.Key) (ssh.Signer, error) {
+	s, err := NewSigner(k)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewCertSigner(cert, s)
+}
+
+// NewPublicKey returns the ssh.PublicKey of k, for example to
+// add it to an authorized_keys file with ssh.MarshalAuthorizedKey
+// or to request an SSH certificate for it.
+func NewPublicKey(k *hwkey.Key) (ssh.PublicKey, error) {
+	return ssh.NewPublicKey(k.Public())
+}
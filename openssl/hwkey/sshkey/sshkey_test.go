@@ -0,0 +1,194 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/hwkey"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newECDSAKey(t *testing.T) *hwkey.Key {
+	t.Helper()
+	X, Y, D, err := openssl.GenerateKeyECDSA("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyECDSA("P-256", X, Y, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := hwkey.New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newRSAKey(t *testing.T) *hwkey.Key {
+	t.Helper()
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := hwkey.New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newEd25519Key(t *testing.T) *hwkey.Key {
+	t.Helper()
+	if _, err := openssl.DefaultLibraryContext(); err != nil {
+		t.Skip("Ed25519 keys are only supported on OpenSSL 3")
+	}
+	priv, err := openssl.GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := hwkey.New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// checkSigner checks that s signs with each algorithm of algs, or with
+// its default algorithm if algs is empty, and that the signatures
+// verify with pub.
+func checkSigner(t *testing.T, s ssh.Signer, pub ssh.PublicKey, algs []string) {
+	t.Helper()
+	data := []byte("data")
+	if len(algs) == 0 {
+		sig, err := s.Sign(rand.Reader, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.Verify(data, sig); err != nil {
+			t.Error(err)
+		}
+		return
+	}
+	as := s.(ssh.AlgorithmSigner)
+	for _, alg := range algs {
+		sig, err := as.SignWithAlgorithm(rand.Reader, data, alg)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if sig.Format != alg {
+			t.Errorf("got signature format %s, want %s", sig.Format, alg)
+		}
+		if err := pub.Verify(data, sig); err != nil {
+			t.Errorf("%s: %v", alg, err)
+		}
+	}
+}
+
+func TestSigner(t *testing.T) {
+	tests := []struct {
+		name string
+		key  func(*testing.T) *hwkey.Key
+		typ  string
+		algs []string
+	}{
+		{"ECDSA", newECDSAKey, ssh.KeyAlgoECDSA256, nil},
+		{"RSA", newRSAKey, ssh.KeyAlgoRSA, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256}},
+		{"Ed25519", newEd25519Key, ssh.KeyAlgoED25519, nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			k := tt.key(t)
+			s, err := NewSigner(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := NewPublicKey(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pub.Type() != tt.typ {
+				t.Errorf("got key type %s, want %s", pub.Type(), tt.typ)
+			}
+			if string(s.PublicKey().Marshal()) != string(pub.Marshal()) {
+				t.Error("signer and NewPublicKey have different public keys")
+			}
+			checkSigner(t, s, pub, tt.algs)
+		})
+	}
+}
+
+func TestSignerRSANoSHA1(t *testing.T) {
+	s, err := NewSigner(newRSAKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.(ssh.AlgorithmSigner).SignWithAlgorithm(rand.Reader, []byte("data"), ssh.KeyAlgoRSA); err == nil {
+		t.Error("signed with ssh-rsa")
+	}
+}
+
+func TestCertSigner(t *testing.T) {
+	_, caPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ssh.NewSignerFromKey(caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := newECDSAKey(t)
+	pub, err := NewPublicKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"example.com"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewCertSigner(cert, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.PublicKey().(*ssh.Certificate); !ok || got != cert {
+		t.Fatalf("PublicKey() = %v, want the certificate", s.PublicKey())
+	}
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, address string) bool {
+			return string(auth.Marshal()) == string(ca.PublicKey().Marshal())
+		},
+	}
+	if err := checker.CheckHostKey("example.com:22", nil, s.PublicKey()); err != nil {
+		t.Error(err)
+	}
+	checkSigner(t, s, pub, nil)
+
+	if _, err := NewCertSigner(cert, newECDSAKey(t)); err == nil {
+		t.Error("NewCertSigner accepted the certificate of another key")
+	}
+}
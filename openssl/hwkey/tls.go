@@ -0,0 +1,100 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package hwkey
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// X509KeyPair returns a tls.Certificate for the PEM encoded certificate
+// chain certPEMBlock, leaf first, and its private key signer, such as a
+// Key. Unlike tls.X509KeyPair, the private key is never parsed into a
+// Go key: the TLS handshakes sign with signer. The Leaf field is set.
+func X509KeyPair(certPEMBlock []byte, signer crypto.Signer) (tls.Certificate, error) {
+	var cert tls.Certificate
+	for {
+		var block *pem.Block
+		block, certPEMBlock = pem.Decode(certPEMBlock)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return tls.Certificate{}, errors.New("hwkey: no certificate found")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(signer.Public()) {
+		return tls.Certificate{}, errors.New("hwkey: private key does not match public key")
+	}
+	cert.Leaf = leaf
+	cert.PrivateKey = signer
+	return cert, nil
+}
+
+// LoadX509KeyPair is like X509KeyPair but reads the certificate chain
+// from the PEM file certFile, and loads the private key at keyURI with
+// Load, calling passphrase, if not nil, for its passphrase or PIN.
+//
+// LoadX509KeyPair is only supported on OpenSSL 3.
+func LoadX509KeyPair(certFile, keyURI string, passphrase openssl.PassphraseFunc) (tls.Certificate, error) {
+	certPEMBlock, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := Load(keyURI, passphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return X509KeyPair(certPEMBlock, key)
+}
+
+// GetCertificate returns a function, for tls.Config.GetCertificate,
+// selecting the first of certs supported by the client, for servers
+// with keys of several types. The Leaf of certs should be set, as done
+// by X509KeyPair, for the selection not to parse them on every handshake.
+func GetCertificate(certs ...tls.Certificate) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		var err error
+		for i := range certs {
+			if err = hello.SupportsCertificate(&certs[i]); err == nil {
+				return &certs[i], nil
+			}
+		}
+		if err == nil {
+			err = errors.New("hwkey: no certificate")
+		}
+		return nil, err
+	}
+}
+
+// GetClientCertificate returns a function, for
+// tls.Config.GetClientCertificate, selecting the first of certs
+// accepted by the server. No certificate is sent if none is.
+func GetClientCertificate(certs ...tls.Certificate) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		for i := range certs {
+			if cri.SupportsCertificate(&certs[i]) == nil {
+				return &certs[i], nil
+			}
+		}
+		return new(tls.Certificate), nil
+	}
+}
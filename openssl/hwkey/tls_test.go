@@ -0,0 +1,180 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package hwkey
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func newECDSAKey(t *testing.T) *Key {
+	t.Helper()
+	X, Y, D, err := openssl.GenerateKeyECDSA("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyECDSA("P-256", X, Y, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newRSAKey(t *testing.T) *Key {
+	t.Helper()
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// selfSigned returns a TLS certificate for signer, self-signed for the
+// host "example.com", and a pool holding it.
+func selfSigned(t *testing.T, signer crypto.Signer) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := X509KeyPair(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	return cert, pool
+}
+
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, error) {
+	t.Helper()
+	c, s := net.Pipe()
+	defer c.Close()
+	errc := make(chan error, 1)
+	go func() {
+		defer s.Close()
+		errc <- tls.Server(s, server).Handshake()
+	}()
+	conn := tls.Client(c, client)
+	err := conn.Handshake()
+	if serr := <-errc; err == nil {
+		err = serr
+	}
+	return conn.ConnectionState(), err
+}
+
+func TestTLS(t *testing.T) {
+	rsaCert, rsaPool := selfSigned(t, newRSAKey(t))
+	ecCert, ecPool := selfSigned(t, newECDSAKey(t))
+	clientCert, clientPool := selfSigned(t, newECDSAKey(t))
+	for _, tt := range []struct {
+		name   string
+		certs  []tls.Certificate
+		client *tls.Config
+		want   *tls.Certificate
+	}{
+		{"ECDSA", []tls.Certificate{ecCert, rsaCert}, &tls.Config{RootCAs: ecPool}, &ecCert},
+		// TLS 1.3 clients always support ECDSA, so only offer RSA.
+		{"RSA-PSS", []tls.Certificate{rsaCert}, &tls.Config{RootCAs: rsaPool}, &rsaCert},
+		{"RSA-TLS1.2", []tls.Certificate{ecCert, rsaCert}, &tls.Config{
+			RootCAs:      rsaPool,
+			MaxVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		}, &rsaCert},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &tls.Config{
+				GetCertificate: GetCertificate(tt.certs...),
+				ClientAuth:     tls.RequireAndVerifyClientCert,
+				ClientCAs:      clientPool,
+			}
+			tt.client.ServerName = "example.com"
+			tt.client.GetClientCertificate = GetClientCertificate(clientCert)
+			state, err := handshake(t, server, tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !state.PeerCertificates[0].Equal(tt.want.Leaf) {
+				t.Error("server sent the wrong certificate")
+			}
+		})
+	}
+}
+
+func TestTLSEd25519(t *testing.T) {
+	priv, err := openssl.GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	k, err := New("", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, pool := selfSigned(t, k)
+	server := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if _, err := handshake(t, server, &tls.Config{RootCAs: pool, ServerName: "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	k := newECDSAKey(t)
+	same, err := New("other", k.PrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.Equal(same) {
+		t.Error("Equal() = false for the same key")
+	}
+	if k.Equal(newECDSAKey(t)) || k.Equal(newRSAKey(t)) {
+		t.Error("Equal() = true for another key")
+	}
+}
+
+func TestX509KeyPairMismatch(t *testing.T) {
+	cert, _ := selfSigned(t, newECDSAKey(t))
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if _, err := X509KeyPair(certPEM, newECDSAKey(t)); err == nil {
+		t.Error("X509KeyPair() with another key succeeded")
+	}
+	if _, err := X509KeyPair(nil, newECDSAKey(t)); err == nil {
+		t.Error("X509KeyPair() without certificate succeeded")
+	}
+}
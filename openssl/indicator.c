@@ -0,0 +1,49 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// Defined in indicator.go.
+extern int goFIPSIndicatorCallback(char *type, char *desc);
+
+// unapproved counts the unapproved services reported
+// on the current thread since the last reset.
+static __thread int unapproved;
+
+static volatile int indicator_reject;
+static volatile int indicator_observe;
+
+static int
+indicator_cb(const char *type, const char *desc, const OSSL_PARAM params[])
+{
+    unapproved++;
+    if (indicator_observe)
+        goFIPSIndicatorCallback((char *)(type ? type : ""), (char *)(desc ? desc : ""));
+    // Returning 0 makes the provider fail the operation.
+    return !indicator_reject;
+}
+
+void go_openssl_set_indicator_callback(int reject, int observe)
+{
+    indicator_reject = reject;
+    indicator_observe = observe;
+    go_openssl_OSSL_INDICATOR_set_callback(NULL, (GO_OSSL_INDICATOR_CALLBACK_PTR)indicator_cb);
+}
+
+void go_openssl_reset_unapproved(void)
+{
+    unapproved = 0;
+}
+
+int go_openssl_unapproved(void)
+{
+    return unapproved;
+}
+
+void go_openssl_add_unapproved(int n)
+{
+    unapproved += n;
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"sync"
+)
+
+// FIPSIndicatorEvent describes an operation the FIPS provider
+// performed, or refused to perform, as a non-approved service.
+type FIPSIndicatorEvent struct {
+	// Type is the kind of algorithm, such as "RSA Sign" or "HKDF".
+	Type string
+	// Description names the failed check, such as "Key size"
+	// or "Digest SHA1".
+	Description string
+}
+
+var (
+	indicatorMu       sync.Mutex
+	indicatorObserver func(FIPSIndicatorEvent)
+	indicatorReject   bool
+)
+
+//export goFIPSIndicatorCallback
+func goFIPSIndicatorCallback(typ, desc *C.char) C.int {
+	indicatorMu.Lock()
+	observer := indicatorObserver
+	indicatorMu.Unlock()
+	if observer != nil {
+		observer(FIPSIndicatorEvent{Type: C.GoString(typ), Description: C.GoString(desc)})
+	}
+	return 1
+}
+
+// supportsFIPSIndicator reports whether the approved-services
+// indicator API, introduced in OpenSSL 3.4, is available.
+func supportsFIPSIndicator() bool {
+	return vMajor == 3 && vMinor >= 4
+}
+
+// updateIndicatorCallback installs the indicator callback
+// of the default library context. indicatorMu must be held.
+func updateIndicatorCallback() {
+	var reject, observe C.int
+	if indicatorReject {
+		reject = 1
+	}
+	if indicatorObserver != nil {
+		observe = 1
+	}
+	C.go_openssl_set_indicator_callback(reject, observe)
+}
+
+// SetFIPSIndicatorCallback registers cb to be called every time the
+// FIPS provider of the default library context reports an operation
+// as a non-approved service. A nil cb removes the callback.
+//
+// cb is called synchronously from the goroutine performing the operation
+// and must not call back into this package.
+//
+// SetFIPSIndicatorCallback is only supported on OpenSSL 3.4 and later.
+// Earlier FIPS providers don't report non-approved services.
+func SetFIPSIndicatorCallback(cb func(FIPSIndicatorEvent)) error {
+	if !supportsFIPSIndicator() {
+		return errUnsuportedVersion()
+	}
+	indicatorMu.Lock()
+	defer indicatorMu.Unlock()
+	indicatorObserver = cb
+	updateIndicatorCallback()
+	return nil
+}
+
+// SetRejectUnapproved sets whether operations that the FIPS provider
+// of the default library context would perform as non-approved
+// services fail instead. The rejected operations return an error
+// reported by OpenSSL. The callback set with SetFIPSIndicatorCallback,
+// if any, is still called for them.
+//
+// Unlike SetStrictFIPS, which applies this package's own policy,
+// SetRejectUnapproved defers to the checks built into the FIPS provider.
+//
+// SetRejectUnapproved is only supported on OpenSSL 3.4 and later.
+func SetRejectUnapproved(reject bool) error {
+	if !supportsFIPSIndicator() {
+		return errUnsuportedVersion()
+	}
+	indicatorMu.Lock()
+	defer indicatorMu.Unlock()
+	indicatorReject = reject
+	updateIndicatorCallback()
+	return nil
+}
+
+// FIPSApproved calls f and reports whether every cryptographic operation
+// performed by f on the calling goroutine was executed as an approved
+// service by the FIPS provider. It also returns the error returned by f.
+//
+// Operations performed by other goroutines started by f are not tracked,
+// except for the long operations this package runs on its own workers,
+// see InitOptions.LongOperationWorkers.
+// f runs locked to the current operating system thread.
+//
+// Only the FIPS provider of OpenSSL 3.4 and later reports non-approved
+// services; with other providers FIPSApproved always reports true.
+// FIPSApproved is only supported on OpenSSL 3.4 and later.
+func FIPSApproved(f func() error) (approved bool, err error) {
+	if !supportsFIPSIndicator() {
+		return false, errUnsuportedVersion()
+	}
+	indicatorMu.Lock()
+	updateIndicatorCallback()
+	indicatorMu.Unlock()
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	C.go_openssl_reset_unapproved()
+	err = f()
+	return C.go_openssl_unapproved() == 0, err
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFIPSApproved(t *testing.T) {
+	if !supportsFIPSIndicator() {
+		if _, err := FIPSApproved(func() error { return nil }); err == nil {
+			t.Error("expected error before OpenSSL 3.4")
+		}
+		t.Skip("the FIPS indicator is only supported on OpenSSL 3.4 and later")
+	}
+	var events []FIPSIndicatorEvent
+	if err := SetFIPSIndicatorCallback(func(ev FIPSIndicatorEvent) { events = append(events, ev) }); err != nil {
+		t.Fatal(err)
+	}
+	defer SetFIPSIndicatorCallback(nil)
+	approved, err := FIPSApproved(func() error {
+		SHA256([]byte("abc"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approved || len(events) != 0 {
+		t.Errorf("SHA-256 reported as non-approved: %v", events)
+	}
+	if !FIPS() {
+		return
+	}
+	N, E, D, P, Q, Dp, Dq, Qinv, err := GenerateKeyRSA(1024)
+	if err != nil {
+		t.Skip(err)
+	}
+	priv, err := NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := SHA256([]byte("abc"))
+	approved, err = FIPSApproved(func() error {
+		_, err := SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:])
+		return err
+	})
+	if err == nil && approved {
+		t.Error("1024-bit RSA signature reported as approved")
+	}
+}
+
+func TestFIPSApprovedLongOperation(t *testing.T) {
+	if !supportsFIPSIndicator() || !FIPS() {
+		t.Skip("the FIPS indicator is only supported by the FIPS provider of OpenSSL 3.4 and later")
+	}
+	if longOps.workers <= 0 {
+		t.Skip("long operation workers are disabled")
+	}
+	N, E, D, P, Q, Dp, Dq, Qinv, err := GenerateKeyRSA(4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := EncryptRSAPKCS1(pub, []byte("msg"))
+	if err != nil {
+		t.Skip(err)
+	}
+	decrypt := func() error {
+		_, err := DecryptRSAPKCS1(priv, ciphertext)
+		return err
+	}
+	// The 4096-bit decryption runs inline while longOps.inline is set,
+	// which tells whether the provider flags it at all.
+	atomic.AddInt32(&longOps.inline, 1)
+	approved, err := FIPSApproved(decrypt)
+	atomic.AddInt32(&longOps.inline, -1)
+	if err != nil || approved {
+		t.Skip("PKCS #1 v1.5 decryption is not reported as non-approved")
+	}
+	if approved, err = FIPSApproved(decrypt); err != nil {
+		t.Fatal(err)
+	}
+	if approved {
+		t.Error("non-approved decryption run by a worker reported as approved")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// Package acvp exposes the low-level entry points of package openssl
+// needed by an ACVP (Automated Cryptographic Validation Protocol) client,
+// https://pages.nist.gov/ACVP, to run the NIST test vectors against this
+// module for algorithm certification: ECDSA key generation and component
+// signatures with fixed nonces, and AES-GCM with an internally generated
+// IV. The other ACVP tests, such as hashes, HMAC or AES-GCM with external
+// IVs, are covered by the public API of package openssl.
+//
+// Integers are big-endian byte strings, and the ones returned are
+// zero-padded to the size of the order of the curve. The ECDSA functions
+// take digests, hashed by the client, as ACVP component tests do.
+//
+// Package openssl implements the entry points, and registers them from
+// its init function, so callers must import it and initialize it with
+// openssl.Init. These entry points bypass the checks the public API
+// applies to keys and nonces, and must only be used for testing.
+package acvp
+
+import "errors"
+
+// Hooks are the entry points implemented by package openssl.
+type Hooks struct {
+	ECDSAKeyGen func(curve string) (qx, qy, d []byte, err error)
+	ECDSASign   func(curve string, qx, qy, d, k, digest []byte) (r, s []byte, err error)
+	ECDSAVerify func(curve string, qx, qy, digest, r, s []byte) bool
+	GCMSeal     func(key, ivInit, plaintext, aad []byte, tagSize int) (iv, ciphertext, tag []byte, err error)
+}
+
+var hooks Hooks
+
+// Register sets the entry points of the functions of this package.
+// It is called by package openssl.
+func Register(h Hooks) {
+	hooks = h
+}
+
+var errNotRegistered = errors.New("acvp: package openssl isn't linked")
+
+// ECDSAKeyGen generates a key pair on curve, "P-224", "P-256", "P-384"
+// or "P-521", and returns its public point (qx, qy) and private scalar d.
+func ECDSAKeyGen(curve string) (qx, qy, d []byte, err error) {
+	if hooks.ECDSAKeyGen == nil {
+		return nil, nil, nil, errNotRegistered
+	}
+	return hooks.ECDSAKeyGen(curve)
+}
+
+// ECDSASign signs digest with the key (qx, qy, d) on curve, with the
+// nonce k if not nil and with a random nonce otherwise. digest isn't
+// hashed, and is truncated to the size of the curve order as in ECDSA.
+func ECDSASign(curve string, qx, qy, d, k, digest []byte) (r, s []byte, err error) {
+	if hooks.ECDSASign == nil {
+		return nil, nil, errNotRegistered
+	}
+	return hooks.ECDSASign(curve, qx, qy, d, k, digest)
+}
+
+// ECDSAVerify reports whether (r, s) is a valid signature of digest
+// by the public key (qx, qy) on curve.
+func ECDSAVerify(curve string, qx, qy, digest, r, s []byte) bool {
+	if hooks.ECDSAVerify == nil {
+		return false
+	}
+	return hooks.ECDSAVerify(curve, qx, qy, digest, r, s)
+}
+
+// GCMSeal encrypts plaintext with AES-GCM, key and aad, with a 96-bit IV
+// generated by OpenSSL, and returns the IV, the ciphertext and the tag
+// of tagSize bytes. ivInit is either the fixed field of the IV, of 4
+// bytes, whose 8-byte invocation field is then random as in the
+// construction of SP 800-38D 8.2.2, or the whole 12-byte initial IV of
+// the deterministic construction of 8.2.1, which OpenSSL increments.
+func GCMSeal(key, ivInit, plaintext, aad []byte, tagSize int) (iv, ciphertext, tag []byte, err error) {
+	if hooks.GCMSeal == nil {
+		return nil, nil, nil, errNotRegistered
+	}
+	return hooks.GCMSeal(key, ivInit, plaintext, aad, tagSize)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package acvp_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/internal/acvp"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestECDSA(t *testing.T) {
+	digest := sha256.Sum256([]byte("testing"))
+	for _, c := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(c.Params().Name, func(t *testing.T) {
+			name := c.Params().Name
+			qx, qy, d, err := acvp.ECDSAKeyGen(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := (c.Params().N.BitLen() + 7) / 8
+			if len(qx) != size || len(qy) != size || len(d) != size {
+				t.Fatalf("got key sizes %d, %d, %d, want %d", len(qx), len(qy), len(d), size)
+			}
+			pub := &stdecdsa.PublicKey{Curve: c, X: new(big.Int).SetBytes(qx), Y: new(big.Int).SetBytes(qy)}
+			k := []byte{0x12, 0x34, 0x56, 0x78}
+			r, s, err := acvp.ECDSASign(name, qx, qy, d, k, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			// r is the x coordinate of k*G modulo the order.
+			x, _ := c.ScalarBaseMult(k)
+			if want := x.Mod(x, c.Params().N); new(big.Int).SetBytes(r).Cmp(want) != 0 {
+				t.Errorf("r = %x, want %x", r, want)
+			}
+			r2, s2, err := acvp.ECDSASign(name, qx, qy, d, k, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(r, r2) || !bytes.Equal(s, s2) {
+				t.Error("signatures with the same nonce differ")
+			}
+			if !stdecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(r), new(big.Int).SetBytes(s)) {
+				t.Error("crypto/ecdsa rejected the signature")
+			}
+			if !acvp.ECDSAVerify(name, qx, qy, digest[:], r, s) {
+				t.Error("ECDSAVerify rejected the signature")
+			}
+			r, s, err = acvp.ECDSASign(name, qx, qy, d, nil, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !acvp.ECDSAVerify(name, qx, qy, digest[:], r, s) {
+				t.Error("ECDSAVerify rejected the signature with a random nonce")
+			}
+			s[0] ^= 0xff
+			if acvp.ECDSAVerify(name, qx, qy, digest[:], r, s) {
+				t.Error("ECDSAVerify accepted a modified signature")
+			}
+		})
+	}
+	if _, _, _, err := acvp.ECDSAKeyGen("P-192"); err == nil {
+		t.Error("generated a key on an unknown curve")
+	}
+}
+
+func TestGCMSeal(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 16)
+	plaintext := []byte("plaintext")
+	aad := []byte("aad")
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		ivInit []byte
+	}{
+		{"deterministic", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+		{"random", []byte{0xca, 0xfe, 0xba, 0xbe}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, tagSize := range []int{16, 12} {
+				iv, ciphertext, tag, err := acvp.GCMSeal(key, tt.ivInit, plaintext, aad, tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(iv) != 12 || !bytes.HasPrefix(iv, tt.ivInit) {
+					t.Errorf("IV %x doesn't start with %x", iv, tt.ivInit)
+				}
+				if len(tag) != tagSize {
+					t.Errorf("got a tag of %d bytes, want %d", len(tag), tagSize)
+				}
+				aead, err := cipher.NewGCMWithTagSize(block, tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := aead.Open(nil, iv, append(ciphertext, tag...), aad)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Errorf("got %q, want %q", got, plaintext)
+				}
+			}
+		})
+	}
+	if _, _, _, err := acvp.GCMSeal(key, []byte{1, 2}, plaintext, aad, 16); err == nil {
+		t.Error("accepted an IV fixed field of 2 bytes")
+	}
+	if _, _, _, err := acvp.GCMSeal(key, make([]byte, 12), nil, nil, 16); err != nil {
+		t.Errorf("empty plaintext: %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// jwk holds the key type and key material members of an RFC 7517
+// JSON Web Key, with the parameters of RFC 7518 and RFC 8037.
+// Other members, such as "kid" and "use", are ignored.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	// RSA.
+	N   string            `json:"n,omitempty"`
+	E   string            `json:"e,omitempty"`
+	P   string            `json:"p,omitempty"`
+	Q   string            `json:"q,omitempty"`
+	Dp  string            `json:"dp,omitempty"`
+	Dq  string            `json:"dq,omitempty"`
+	Qi  string            `json:"qi,omitempty"`
+	Oth []json.RawMessage `json:"oth,omitempty"`
+	// EC and OKP.
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+	// RSA, EC and OKP.
+	D string `json:"d,omitempty"`
+}
+
+// jwkOKPCurves maps the "crv" of OKP keys to the OpenSSL algorithm name.
+var jwkOKPCurves = map[string]string{
+	"Ed25519": "ED25519",
+	"Ed448":   "ED448",
+	"X25519":  "X25519",
+	"X448":    "X448",
+}
+
+// jwkECCoordSize returns the size in bytes of the coordinates
+// and private scalar of keys on a curve registered for JWK.
+func jwkECCoordSize(curve string) (int, error) {
+	switch curve {
+	case "P-256":
+		return 32, nil
+	case "P-384":
+		return 48, nil
+	case "P-521":
+		return 66, nil
+	}
+	return 0, errUnsupportedCurve
+}
+
+// MarshalJWK returns the RFC 7517 JSON Web Key of key, which must be a
+// *PublicKeyRSA, *PrivateKeyRSA, *PublicKeyECDSA, *PrivateKeyECDSA or
+// a *PKey holding an Ed25519, Ed448, X25519 or X448 key, which are
+// encoded with the "OKP" key type of RFC 8037. Private keys include
+// their private members. Only the key material is encoded, so the
+// caller can add members such as "kid" and "use".
+//
+// The components are converted directly from OpenSSL, and EC
+// coordinates are padded to the size of the curve as RFC 7518 requires.
+// EC keys must be on P-256, P-384 or P-521.
+func MarshalJWK(key interface{}) ([]byte, error) {
+	var j *jwk
+	var err error
+	switch k := key.(type) {
+	case *PublicKeyRSA:
+		j, err = rsaJWK(k.withKey, false)
+	case *PrivateKeyRSA:
+		j, err = rsaJWK(k.withKey, true)
+	case *PublicKeyECDSA:
+		j, err = ecJWK(k.withKey, false)
+	case *PrivateKeyECDSA:
+		j, err = ecJWK(k.withKey, true)
+	case *PKey:
+		j, err = okpJWK(k)
+	default:
+		return nil, errors.New("openssl: unsupported JWK key type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+func jwkEncode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(withKey func(func(C.GO_EVP_PKEY_PTR) C.int) C.int, private bool) (*jwk, error) {
+	j := &jwk{Kty: "RSA"}
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		key := C.go_openssl_EVP_PKEY_get1_RSA(pkey)
+		if key == nil {
+			return 0
+		}
+		defer C.go_openssl_RSA_free(key)
+		n, e, d := rsaGet0Key(key)
+		if n == nil || e == nil || (private && d == nil) {
+			return 0
+		}
+		j.N, j.E = jwkEncode(bnToBytes(n)), jwkEncode(bnToBytes(e))
+		if !private {
+			return 1
+		}
+		j.D = jwkEncode(bnToBytes(d))
+		p, q := rsaGet0Factors(key)
+		dp, dq, qi := rsaGet0CRTParams(key)
+		// The other private members are optional, but all or none must be present.
+		if p != nil && q != nil && dp != nil && dq != nil && qi != nil {
+			j.P, j.Q = jwkEncode(bnToBytes(p)), jwkEncode(bnToBytes(q))
+			j.Dp, j.Dq, j.Qi = jwkEncode(bnToBytes(dp)), jwkEncode(bnToBytes(dq)), jwkEncode(bnToBytes(qi))
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_get1_RSA")
+	}
+	return j, nil
+}
+
+func ecJWK(withKey func(func(C.GO_EVP_PKEY_PTR) C.int) C.int, private bool) (*jwk, error) {
+	var curve string
+	var pub, priv []byte
+	var err error
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
+		if key == nil {
+			return 0
+		}
+		defer C.go_openssl_EC_KEY_free(key)
+		group := C.go_openssl_EC_KEY_get0_group(key)
+		if curve, err = curveName(C.go_openssl_EC_GROUP_get_curve_name(group)); err != nil {
+			return 1
+		}
+		size, err1 := jwkECCoordSize(curve)
+		if err1 != nil {
+			err = err1
+			return 1
+		}
+		pt := C.go_openssl_EC_KEY_get0_public_key(key)
+		if pt == nil {
+			return 0
+		}
+		pub = make([]byte, 1+2*size)
+		if C.go_openssl_EC_POINT_point2oct(group, pt, C.GO_POINT_CONVERSION_UNCOMPRESSED, base(pub), C.size_t(len(pub)), nil) != C.size_t(len(pub)) {
+			return 0
+		}
+		if !private {
+			return 1
+		}
+		d := C.go_openssl_EC_KEY_get0_private_key(key)
+		if d == nil {
+			return 0
+		}
+		priv = make([]byte, size)
+		return C.go_openssl_BN_bn2binpad(d, base(priv), C.int(size))
+	}) <= 0 {
+		return nil, newOpenSSLError("EVP_PKEY_get1_EC_KEY")
+	}
+	if err != nil {
+		return nil, err
+	}
+	size := len(pub) / 2
+	j := &jwk{Kty: "EC", Crv: curve, X: jwkEncode(pub[1 : 1+size]), Y: jwkEncode(pub[1+size:])}
+	if private {
+		j.D = jwkEncode(priv)
+	}
+	return j, nil
+}
+
+func okpJWK(k *PKey) (*jwk, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	alg := k.Algorithm()
+	var crv string
+	for c, name := range jwkOKPCurves {
+		if name == alg {
+			crv = c
+		}
+	}
+	if crv == "" {
+		return nil, errors.New("openssl: unsupported JWK key algorithm " + alg)
+	}
+	pub, err := k.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	j := &jwk{Kty: "OKP", Crv: crv, X: jwkEncode(pub)}
+	if k.hasRawPrivateKey() {
+		priv, err := k.PrivateKeyBytes()
+		if err != nil {
+			return nil, err
+		}
+		j.D = jwkEncode(priv)
+	}
+	return j, nil
+}
+
+// hasRawPrivateKey reports whether k has a raw private key,
+// that is, whether PrivateKeyBytes succeeds.
+func (k *PKey) hasRawPrivateKey() bool {
+	return k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		var n C.size_t
+		if C.go_openssl_EVP_PKEY_get_raw_private_key(pkey, nil, &n) != 1 {
+			C.go_openssl_ERR_clear_error()
+			return 0
+		}
+		return 1
+	}) == 1
+}
+
+// ParseJWK parses data, an RFC 7517 JSON Web Key, and returns the key
+// it holds. Members other than the key type and the key material,
+// such as "kid", "use" and "alg", are ignored.
+//
+// It returns a *PublicKeyRSA or *PrivateKeyRSA for the "RSA" key type,
+// a *PublicKeyECDSA or *PrivateKeyECDSA for the "EC" key type, and a
+// *PKey for the "OKP" key type of RFC 8037, which is only supported on
+// OpenSSL 3. The private key is returned if the "d" member is present.
+// RSA keys with more than two primes are not supported.
+func ParseJWK(data []byte) (interface{}, error) {
+	var j jwk
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	switch j.Kty {
+	case "RSA":
+		return parseRSAJWK(&j)
+	case "EC":
+		return parseECJWK(&j)
+	case "OKP":
+		return parseOKPJWK(&j)
+	case "":
+		return nil, errors.New("openssl: JWK has no key type")
+	}
+	return nil, errors.New("openssl: unsupported JWK key type " + j.Kty)
+}
+
+// jwkDecode decodes the base64url member name of a JWK. If size is not
+// zero, the decoded value must have this size. Missing members decode
+// to nil unless required is true.
+func jwkDecode(name, s string, size int, required bool) ([]byte, error) {
+	if s == "" {
+		if required {
+			return nil, errors.New("openssl: JWK member " + name + " is missing")
+		}
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.Strict().DecodeString(s)
+	if err != nil {
+		return nil, errors.New("openssl: invalid JWK member " + name + ": " + err.Error())
+	}
+	if size != 0 && len(b) != size {
+		return nil, errors.New("openssl: JWK member " + name + " has the wrong size")
+	}
+	return b, nil
+}
+
+// jwkBig decodes the base64url big-endian integer member name of a JWK.
+func jwkBig(name, s string, size int, required bool) (BigInt, error) {
+	b, err := jwkDecode(name, s, size, required)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	bn := bytesToBN(b)
+	if bn == nil {
+		return nil, newOpenSSLError("BN_bin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	return bnToBig(bn), nil
+}
+
+func parseRSAJWK(j *jwk) (interface{}, error) {
+	if len(j.Oth) > 0 {
+		return nil, errors.New("openssl: multi-prime RSA JWK is not supported")
+	}
+	N, err := jwkBig("n", j.N, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	E, err := jwkBig("e", j.E, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if j.D == "" {
+		if j.P != "" || j.Q != "" || j.Dp != "" || j.Dq != "" || j.Qi != "" {
+			return nil, errors.New("openssl: RSA JWK has private members but no d")
+		}
+		return NewPublicKeyRSA(N, E)
+	}
+	D, err := jwkBig("d", j.D, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	// The other private members are optional, but all or none must be present.
+	crt := j.P != "" || j.Q != "" || j.Dp != "" || j.Dq != "" || j.Qi != ""
+	var v [5]BigInt
+	for i, m := range [...]struct{ name, s string }{{"p", j.P}, {"q", j.Q}, {"dp", j.Dp}, {"dq", j.Dq}, {"qi", j.Qi}} {
+		if v[i], err = jwkBig(m.name, m.s, 0, crt); err != nil {
+			return nil, err
+		}
+	}
+	return NewPrivateKeyRSA(N, E, D, v[0], v[1], v[2], v[3], v[4])
+}
+
+func parseECJWK(j *jwk) (interface{}, error) {
+	size, err := jwkECCoordSize(j.Crv)
+	if err != nil {
+		return nil, err
+	}
+	X, err := jwkBig("x", j.X, size, true)
+	if err != nil {
+		return nil, err
+	}
+	Y, err := jwkBig("y", j.Y, size, true)
+	if err != nil {
+		return nil, err
+	}
+	if j.D == "" {
+		return NewPublicKeyECDSA(j.Crv, X, Y)
+	}
+	D, err := jwkBig("d", j.D, size, true)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newRawECKey(j.Crv, X, Y, D)
+	if err != nil {
+		return nil, err
+	}
+	// Reject a private scalar that doesn't match the public point.
+	ok := C.go_openssl_EC_KEY_check_key(key) == 1
+	C.go_openssl_EC_KEY_free(key)
+	if !ok {
+		return nil, newOpenSSLError("EC_KEY_check_key")
+	}
+	return NewPrivateKeyECDSA(j.Crv, X, Y, D)
+}
+
+func parseOKPJWK(j *jwk) (interface{}, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	name, ok := jwkOKPCurves[j.Crv]
+	if !ok {
+		return nil, errors.New("openssl: unsupported OKP JWK curve " + j.Crv)
+	}
+	pub, err := jwkDecode("x", j.X, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if j.D == "" {
+		return NewPublicPKey(name, pub)
+	}
+	priv, err := jwkDecode("d", j.D, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	k, err := NewPrivatePKey(name, priv)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := k.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(derived, pub) {
+		return nil, errors.New("openssl: OKP JWK public key doesn't match the private key")
+	}
+	return k, nil
+}
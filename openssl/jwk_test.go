@@ -0,0 +1,184 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// testJWKRoundTrip checks that data parses and marshals back to data.
+func testJWKRoundTrip(t *testing.T, data string) interface{} {
+	t.Helper()
+	key, err := openssl.ParseJWK([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := openssl.MarshalJWK(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != data {
+		t.Errorf("got %s, want %s", got, data)
+	}
+	return key
+}
+
+func jwkInt(x *big.Int, size int) string {
+	if size == 0 {
+		return base64.RawURLEncoding.EncodeToString(x.Bytes())
+	}
+	return base64.RawURLEncoding.EncodeToString(x.FillBytes(make([]byte, size)))
+}
+
+func TestJWKRFC7517EC(t *testing.T) {
+	// RFC 7517, Appendix A.2.
+	const priv = `{"kty":"EC","crv":"P-256",` +
+		`"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",` +
+		`"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",` +
+		`"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE"}`
+	if _, ok := testJWKRoundTrip(t, priv).(*openssl.PrivateKeyECDSA); !ok {
+		t.Error("private key doesn't parse to a *PrivateKeyECDSA")
+	}
+	// RFC 7517, Appendix A.1, without "use" and "kid".
+	const pub = `{"kty":"EC","crv":"P-256",` +
+		`"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",` +
+		`"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}`
+	if _, ok := testJWKRoundTrip(t, pub).(*openssl.PublicKeyECDSA); !ok {
+		t.Error("public key doesn't parse to a *PublicKeyECDSA")
+	}
+}
+
+func TestJWKRFC8037Ed25519(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("OKP keys are only supported on OpenSSL 3")
+	}
+	// RFC 8037, Appendix A.1 and A.2.
+	const priv = `{"kty":"OKP","crv":"Ed25519",` +
+		`"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",` +
+		`"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`
+	if _, ok := testJWKRoundTrip(t, priv).(*openssl.PKey); !ok {
+		t.Error("private key doesn't parse to a *PKey")
+	}
+	const pub = `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	testJWKRoundTrip(t, pub)
+}
+
+func TestJWKRSA(t *testing.T) {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Precompute()
+	priv, err := openssl.NewPrivateKeyRSA(bbig.Enc(k.N), bbig.Enc(big.NewInt(int64(k.E))), bbig.Enc(k.D),
+		bbig.Enc(k.Primes[0]), bbig.Enc(k.Primes[1]),
+		bbig.Enc(k.Precomputed.Dp), bbig.Enc(k.Precomputed.Dq), bbig.Enc(k.Precomputed.Qinv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := openssl.MarshalJWK(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"kty": "RSA",
+		"n":   jwkInt(k.N, 0),
+		"e":   jwkInt(big.NewInt(int64(k.E)), 0),
+		"d":   jwkInt(k.D, 0),
+		"p":   jwkInt(k.Primes[0], 0),
+		"q":   jwkInt(k.Primes[1], 0),
+		"dp":  jwkInt(k.Precomputed.Dp, 0),
+		"dq":  jwkInt(k.Precomputed.Dq, 0),
+		"qi":  jwkInt(k.Precomputed.Qinv, 0),
+	}
+	if len(got) != len(want) {
+		t.Errorf("got members %v, want %v", got, want)
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s = %q, want %q", name, got[name], v)
+		}
+	}
+	parsed := testJWKRoundTrip(t, string(data))
+	if _, ok := parsed.(*openssl.PrivateKeyRSA); !ok {
+		t.Error("private key doesn't parse to a *PrivateKeyRSA")
+	}
+	pub := `{"kty":"RSA","n":"` + want["n"] + `","e":"` + want["e"] + `"}`
+	if _, ok := testJWKRoundTrip(t, pub).(*openssl.PublicKeyRSA); !ok {
+		t.Error("public key doesn't parse to a *PublicKeyRSA")
+	}
+}
+
+func TestJWKECDSA(t *testing.T) {
+	testAllCurves(t, func(t *testing.T, c elliptic.Curve) {
+		k, err := ecdsa.GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := c.Params().Name
+		priv, err := openssl.NewPrivateKeyECDSA(name, bbig.Enc(k.X), bbig.Enc(k.Y), bbig.Enc(k.D))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := openssl.MarshalJWK(priv)
+		if name == "P-224" {
+			if err == nil {
+				t.Error("P-224 key marshaled without error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (c.Params().BitSize + 7) / 8
+		want := `{"kty":"EC","crv":"` + name + `","x":"` + jwkInt(k.X, size) +
+			`","y":"` + jwkInt(k.Y, size) + `","d":"` + jwkInt(k.D, size) + `"}`
+		if string(data) != want {
+			t.Errorf("got %s, want %s", data, want)
+		}
+		testJWKRoundTrip(t, want)
+	})
+}
+
+func TestJWKInvalid(t *testing.T) {
+	const x, y = "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4", "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"
+	tests := []struct {
+		name, data string
+	}{
+		{"NoKeyType", `{"crv":"P-256","x":"` + x + `","y":"` + y + `"}`},
+		{"UnknownKeyType", `{"kty":"oct","k":"AAAA"}`},
+		{"UnknownCurve", `{"kty":"EC","crv":"P-192","x":"` + x + `","y":"` + y + `"}`},
+		{"MissingY", `{"kty":"EC","crv":"P-256","x":"` + x + `"}`},
+		{"Padded", `{"kty":"EC","crv":"P-256","x":"` + x + `=","y":"` + y + `"}`},
+		{"ShortX", `{"kty":"EC","crv":"P-256","x":"` + x[1:] + `","y":"` + y + `"}`},
+		{"MismatchedD", `{"kty":"EC","crv":"P-256","x":"` + x + `","y":"` + y + `","d":"` + strings.Repeat("A", 42) + `E"}`},
+		{"RSAMissingE", `{"kty":"RSA","n":"AQAB"}`},
+		{"RSAPartialCRT", `{"kty":"RSA","n":"AQAB","e":"AQAB","d":"AQAB","p":"AQAB"}`},
+		{"RSAMultiPrime", `{"kty":"RSA","n":"AQAB","e":"AQAB","d":"AQAB","oth":[{}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openssl.ParseJWK([]byte(tt.data)); err == nil {
+				t.Error("ParseJWK succeeded")
+			}
+		})
+	}
+}
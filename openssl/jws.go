@@ -0,0 +1,231 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// jwsAlg describes an RFC 7518 JWS algorithm.
+type jwsAlg struct {
+	hash crypto.Hash
+	// family is "RSA", "PSS", "EC" or "EdDSA".
+	family string
+	// curveSize is the size in bytes of the coordinates
+	// of the curve of EC algorithms.
+	curveSize int
+}
+
+var jwsAlgs = map[string]jwsAlg{
+	"RS256": {crypto.SHA256, "RSA", 0},
+	"RS384": {crypto.SHA384, "RSA", 0},
+	"RS512": {crypto.SHA512, "RSA", 0},
+	"PS256": {crypto.SHA256, "PSS", 0},
+	"PS384": {crypto.SHA384, "PSS", 0},
+	"PS512": {crypto.SHA512, "PSS", 0},
+	"ES256": {crypto.SHA256, "EC", 32},
+	"ES384": {crypto.SHA384, "EC", 48},
+	"ES512": {crypto.SHA512, "EC", 66},
+	"EdDSA": {0, "EdDSA", 0},
+}
+
+func lookupJWSAlg(alg string) (jwsAlg, error) {
+	a, ok := jwsAlgs[alg]
+	if !ok {
+		return jwsAlg{}, errors.New("openssl: unsupported JWS algorithm " + alg)
+	}
+	return a, nil
+}
+
+func (a jwsAlg) sum(msg []byte) []byte {
+	switch a.hash {
+	case crypto.SHA256:
+		sum := SHA256(msg)
+		return sum[:]
+	case crypto.SHA384:
+		sum := SHA384(msg)
+		return sum[:]
+	case crypto.SHA512:
+		sum := SHA512(msg)
+		return sum[:]
+	}
+	return msg
+}
+
+var errJWSKey = errors.New("openssl: key type doesn't match the JWS algorithm")
+
+// SignJWS returns the RFC 7515 JWS signature of signingInput, the
+// encoded protected header and payload separated by a period, with
+// the RFC 7518 algorithm alg: RS256, RS384 or RS512 and PS256, PS384
+// or PS512 for a *PrivateKeyRSA, ES256, ES384 or ES512 for a
+// *PrivateKeyECDSA on the matching curve, and EdDSA for a *PKey holding
+// an Ed25519 or Ed448 key, as in RFC 8037. ECDSA signatures are
+// returned in the fixed size R || S encoding of JWS, rather than in
+// ASN.1. The signature isn't base64url encoded.
+func SignJWS(alg string, key interface{}, signingInput []byte) ([]byte, error) {
+	a, err := lookupJWSAlg(alg)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *PrivateKeyRSA:
+		switch a.family {
+		case "RSA":
+			return SignMessageRSAPKCS1v15(k, a.hash, signingInput)
+		case "PSS":
+			return SignMessageRSAPSS(k, a.hash, signingInput, a.hash.Size())
+		}
+	case *PrivateKeyECDSA:
+		if a.family != "EC" {
+			break
+		}
+		if err := checkJWSCurve(k.withKey, a); err != nil {
+			return nil, err
+		}
+		r, s, err := SignRawECDSA(k, a.sum(signingInput))
+		if err != nil {
+			return nil, err
+		}
+		return append(r, s...), nil
+	case *PKey:
+		if a.family != "EdDSA" || !isEdDSA(k) {
+			break
+		}
+		return k.Sign(signingInput, "")
+	}
+	return nil, errJWSKey
+}
+
+// VerifyJWS verifies that sig is the JWS signature of signingInput by
+// key with the algorithm alg, as described in SignJWS, with the
+// matching *PublicKeyRSA, *PublicKeyECDSA or *PKey.
+func VerifyJWS(alg string, key interface{}, signingInput, sig []byte) error {
+	a, err := lookupJWSAlg(alg)
+	if err != nil {
+		return err
+	}
+	switch k := key.(type) {
+	case *PublicKeyRSA:
+		switch a.family {
+		case "RSA":
+			return VerifyMessageRSAPKCS1v15(k, a.hash, signingInput, sig)
+		case "PSS":
+			return VerifyMessageRSAPSS(k, a.hash, signingInput, sig, a.hash.Size())
+		}
+	case *PublicKeyECDSA:
+		if a.family != "EC" {
+			break
+		}
+		if err := checkJWSCurve(k.withKey, a); err != nil {
+			return err
+		}
+		if len(sig) != 2*a.curveSize ||
+			!VerifyRawECDSA(k, a.sum(signingInput), sig[:a.curveSize], sig[a.curveSize:]) {
+			return ErrVerification
+		}
+		return nil
+	case *PKey:
+		if a.family != "EdDSA" || !isEdDSA(k) {
+			break
+		}
+		return k.Verify(signingInput, sig, "")
+	}
+	return errJWSKey
+}
+
+// SignCompactJWS returns the RFC 7515 JWS Compact Serialization of
+// payload signed by key with alg, as described in SignJWS. header holds
+// the protected header members other than "alg", such as "kid" and
+// "typ", and can be nil.
+func SignCompactJWS(alg string, key interface{}, header map[string]interface{}, payload []byte) (string, error) {
+	h := make(map[string]interface{}, len(header)+1)
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"] = alg
+	hdr, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	input := jwsEncode(hdr) + "." + jwsEncode(payload)
+	sig, err := SignJWS(alg, key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+	return input + "." + jwsEncode(sig), nil
+}
+
+// VerifyCompactJWS verifies the JWS Compact Serialization token with
+// key and returns its decoded protected header and payload. The "alg"
+// of the header must be one of algs, so that a token can't choose a
+// weaker algorithm than the caller expects, and "crit" isn't supported.
+func VerifyCompactJWS(key interface{}, token string, algs ...string) (header, payload []byte, err error) {
+	parts := bytes.Split([]byte(token), []byte("."))
+	if len(parts) != 3 {
+		return nil, nil, errors.New("openssl: invalid compact JWS")
+	}
+	if header, err = base64.RawURLEncoding.DecodeString(string(parts[0])); err != nil {
+		return nil, nil, errors.New("openssl: invalid JWS header encoding")
+	}
+	var h struct {
+		Alg  string          `json:"alg"`
+		Crit json.RawMessage `json:"crit"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil {
+		return nil, nil, errors.New("openssl: invalid JWS header")
+	}
+	if h.Crit != nil {
+		return nil, nil, errors.New("openssl: unsupported JWS critical header parameters")
+	}
+	allowed := false
+	for _, alg := range algs {
+		allowed = allowed || alg == h.Alg
+	}
+	if !allowed {
+		return nil, nil, errors.New("openssl: unexpected JWS algorithm " + h.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(string(parts[2]))
+	if err != nil {
+		return nil, nil, errors.New("openssl: invalid JWS signature encoding")
+	}
+	if payload, err = base64.RawURLEncoding.DecodeString(string(parts[1])); err != nil {
+		return nil, nil, errors.New("openssl: invalid JWS payload encoding")
+	}
+	input := token[:len(parts[0])+1+len(parts[1])]
+	if err := VerifyJWS(h.Alg, key, []byte(input), sig); err != nil {
+		return nil, nil, err
+	}
+	return header, payload, nil
+}
+
+func jwsEncode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func isEdDSA(k *PKey) bool {
+	alg := k.Algorithm()
+	return alg == "ED25519" || alg == "ED448"
+}
+
+// checkJWSCurve checks that the EC key of withKey is on the curve of a.
+func checkJWSCurve(withKey withKeyFunc, a jwsAlg) error {
+	var bits C.int
+	withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		bits = C.go_openssl_EVP_PKEY_get_bits(pkey)
+		return 1
+	})
+	if (int(bits)+7)/8 != a.curveSize {
+		return errJWSKey
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+func TestJWSRFC8037Ed25519(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("OKP keys are only supported on OpenSSL 3")
+	}
+	// RFC 8037, Appendix A.4.
+	const token = "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc." +
+		"hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+	key, err := openssl.ParseJWK([]byte(`{"kty":"OKP","crv":"Ed25519",` +
+		`"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",` +
+		`"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := openssl.SignCompactJWS("EdDSA", key, nil, []byte("Example of Ed25519 signing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != token {
+		t.Errorf("SignCompactJWS() = %s, want %s", got, token)
+	}
+	header, payload, err := openssl.VerifyCompactJWS(key, token, "EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(header) != `{"alg":"EdDSA"}` || string(payload) != "Example of Ed25519 signing" {
+		t.Errorf("VerifyCompactJWS() = %s, %s", header, payload)
+	}
+	if _, _, err := openssl.VerifyCompactJWS(key, token, "ES256"); err == nil {
+		t.Error("VerifyCompactJWS() accepted an unexpected algorithm")
+	}
+}
+
+func TestJWSECDSA(t *testing.T) {
+	for _, tt := range []struct {
+		alg   string
+		curve elliptic.Curve
+		hash  func([]byte) []byte
+	}{
+		{"ES256", elliptic.P256(), func(b []byte) []byte { h := sha256.Sum256(b); return h[:] }},
+		{"ES384", elliptic.P384(), func(b []byte) []byte { h := sha512.Sum384(b); return h[:] }},
+		{"ES512", elliptic.P521(), func(b []byte) []byte { h := sha512.Sum512(b); return h[:] }},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			k, err := ecdsa.GenerateKey(tt.curve, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := tt.curve.Params().Name
+			priv, err := openssl.NewPrivateKeyECDSA(name, bbig.Enc(k.X), bbig.Enc(k.Y), bbig.Enc(k.D))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := openssl.NewPublicKeyECDSA(name, bbig.Enc(k.X), bbig.Enc(k.Y))
+			if err != nil {
+				t.Fatal(err)
+			}
+			input := []byte("eyJhbGciOiJFUzI1NiJ9.cGF5bG9hZA")
+			sig, err := openssl.SignJWS(tt.alg, priv, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := (tt.curve.Params().BitSize + 7) / 8
+			if len(sig) != 2*size {
+				t.Fatalf("len(sig) = %d, want %d", len(sig), 2*size)
+			}
+			r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+			if !ecdsa.Verify(&k.PublicKey, tt.hash(input), r, s) {
+				t.Error("crypto/ecdsa rejected the signature")
+			}
+			// And the other way around.
+			r, s, err = ecdsa.Sign(rand.Reader, k, tt.hash(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+			if err := openssl.VerifyJWS(tt.alg, pub, input, sig); err != nil {
+				t.Error(err)
+			}
+			sig[0] ^= 1
+			if err := openssl.VerifyJWS(tt.alg, pub, input, sig); err == nil {
+				t.Error("VerifyJWS() accepted an invalid signature")
+			}
+			if _, err := openssl.SignJWS("ES256K", priv, input); err == nil {
+				t.Error("SignJWS() accepted an unsupported algorithm")
+			}
+		})
+	}
+	// The algorithm must match the curve.
+	X, Y, D, err := openssl.GenerateKeyECDSA("P-384")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyECDSA("P-384", X, Y, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openssl.SignJWS("ES256", priv, []byte("x")); err == nil {
+		t.Error("SignJWS() with ES256 and a P-384 key succeeded")
+	}
+}
+
+func TestJWSRSA(t *testing.T) {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(bbig.Enc(k.N), bbig.Enc(big.NewInt(int64(k.E))), bbig.Enc(k.D),
+		bbig.Enc(k.Primes[0]), bbig.Enc(k.Primes[1]),
+		bbig.Enc(k.Precomputed.Dp), bbig.Enc(k.Precomputed.Dq), bbig.Enc(k.Precomputed.Qinv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicKeyRSA(bbig.Enc(k.N), bbig.Enc(big.NewInt(int64(k.E))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range []string{"RS256", "PS256"} {
+		token, err := openssl.SignCompactJWS(alg, priv, map[string]interface{}{"kid": "1"}, []byte(`{"sub":"me"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(token, ".")
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		sig, err := openssl.SignJWS(alg, priv, []byte(parts[0]+"."+parts[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alg == "RS256" {
+			err = rsa.VerifyPKCS1v15(&k.PublicKey, crypto.SHA256, digest[:], sig)
+		} else {
+			err = rsa.VerifyPSS(&k.PublicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			t.Errorf("%s: crypto/rsa rejected the signature: %v", alg, err)
+		}
+		header, payload, err := openssl.VerifyCompactJWS(pub, token, "RS256", "PS256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(header) != `{"alg":"`+alg+`","kid":"1"}` || string(payload) != `{"sub":"me"}` {
+			t.Errorf("VerifyCompactJWS() = %s, %s", header, payload)
+		}
+		if _, _, err := openssl.VerifyCompactJWS(pub, token[:len(token)-2], alg); err == nil {
+			t.Error("VerifyCompactJWS() accepted a truncated signature")
+		}
+	}
+	if _, err := openssl.SignJWS("ES256", priv, []byte("x")); err == nil {
+		t.Error("SignJWS() with ES256 and an RSA key succeeded")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"runtime"
+	"unsafe"
+)
+
+// KDF is a key derivation function fetched by name from
+// the active OpenSSL providers.
+type KDF struct {
+	// lib is the library context the KDF was fetched from,
+	// or nil for the default context.
+	lib *LibraryContext
+	ctx C.GO_EVP_KDF_CTX_PTR
+	closer
+}
+
+// NewKDF fetches the key derivation function name, for example "HKDF",
+// "SSHKDF" or "PKCS12KDF", and initializes it with params.
+//
+// Each entry in params is converted to the OSSL_PARAM type the KDF
+// declares for that key. Supported Go types are string and []byte
+// for string parameters and the integer types and bool for numeric
+// parameters. Unknown keys are reported as an error.
+//
+// NewKDF is only supported on OpenSSL 3.
+func NewKDF(name string, params map[string]interface{}) (*KDF, error) {
+	return newKDF(nil, name, params)
+}
+
+func newKDF(lib *LibraryContext, name string, params map[string]interface{}) (*KDF, error) {
+	if digest, ok := params["digest"].(string); ok {
+		if err := lib.checkStrictDigest(digest); err != nil {
+			return nil, err
+		}
+	}
+	ctx, err := newKDFCtx(lib.ptr(), lib.propq(), name)
+	if err != nil {
+		return nil, err
+	}
+	k := &KDF{lib: lib, ctx: ctx}
+	manage(k, (*KDF).finalize)
+	if props := lib.Properties(); props != "" {
+		if _, ok := params["properties"]; !ok && k.settable("properties") {
+			p := make(map[string]interface{}, len(params)+1)
+			for key, v := range params {
+				p[key] = v
+			}
+			p["properties"] = props
+			params = p
+		}
+	}
+	if len(params) > 0 {
+		if err := k.setParams(params); err != nil {
+			k.Close()
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func newKDFCtx(libctx C.GO_OSSL_LIB_CTX_PTR, propq *C.char, name string) (C.GO_EVP_KDF_CTX_PTR, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	kdf := C.go_openssl_EVP_KDF_fetch(libctx, cname, propq)
+	if kdf == nil && loadLegacyFor(libctx, name) {
+		kdf = C.go_openssl_EVP_KDF_fetch(libctx, cname, propq)
+	}
+	if kdf == nil {
+		return nil, newNotSupportedError(name, "EVP_KDF_fetch("+name+")")
+	}
+	// The context holds its own reference to kdf.
+	defer C.go_openssl_EVP_KDF_free(kdf)
+	ctx := C.go_openssl_EVP_KDF_CTX_new(kdf)
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_KDF_CTX_new")
+	}
+	return ctx, nil
+}
+
+func (k *KDF) finalize() {
+	C.go_openssl_EVP_KDF_CTX_free(k.ctx)
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *KDF) Close() error {
+	return closeObject(k, k.finalize)
+}
+
+// settable reports whether the KDF accepts the parameter key.
+func (k *KDF) settable(key string) bool {
+	defer runtime.KeepAlive(k)
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+	return C.go_openssl_OSSL_PARAM_locate_const(C.go_openssl_EVP_KDF_CTX_settable_params(k.ctx), ckey) != nil
+}
+
+func (k *KDF) setParams(params map[string]interface{}) error {
+	defer runtime.KeepAlive(k)
+	bld, err := newParamBuilder()
+	if err != nil {
+		return err
+	}
+	defer bld.free()
+	bld.addValues(C.go_openssl_EVP_KDF_CTX_settable_params(k.ctx), params)
+	cparams, err := bld.build()
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(cparams)
+	if C.go_openssl_EVP_KDF_CTX_set_params(k.ctx, cparams) != 1 {
+		return newOpenSSLError("EVP_KDF_CTX_set_params")
+	}
+	return nil
+}
+
+// Size returns the fixed output size of the KDF,
+// or 0 if the KDF can produce outputs of any length.
+func (k *KDF) Size() int {
+	defer runtime.KeepAlive(k)
+	n := C.go_openssl_EVP_KDF_CTX_get_kdf_size(k.ctx)
+	if n == 0 || uint64(n) > uint64(maxInt) {
+		return 0
+	}
+	return int(n)
+}
+
+// Derive returns keyLen bytes derived using the parameters
+// passed to NewKDF. keyLen must be positive.
+func (k *KDF) Derive(keyLen int) ([]byte, error) {
+	if keyLen <= 0 {
+		return nil, errors.New("openssl: invalid KDF key length")
+	}
+	defer runtime.KeepAlive(k)
+	out := make([]byte, keyLen)
+	if C.go_openssl_EVP_KDF_derive(k.ctx, base(out), C.size_t(len(out)), nil) != 1 {
+		return nil, newOpenSSLError("EVP_KDF_derive")
+	}
+	return out, nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// SSH key types accepted by SSHKDF, as defined in RFC 4253, Section 7.2.
+const (
+	SSHKDFInitialIVClientToServer     byte = 'A'
+	SSHKDFInitialIVServerToClient     byte = 'B'
+	SSHKDFEncryptionKeyClientToServer byte = 'C'
+	SSHKDFEncryptionKeyServerToClient byte = 'D'
+	SSHKDFIntegrityKeyClientToServer  byte = 'E'
+	SSHKDFIntegrityKeyServerToClient  byte = 'F'
+)
+
+// SSHKDF derives keyLen bytes of SSH transport key material of type typ
+// from the shared secret key, the exchange hash and the session identifier,
+// as specified in RFC 4253, Section 7.2.
+//
+// key must be the shared secret encoded as an SSH mpint,
+// including its length prefix.
+//
+// SSHKDF is only supported on OpenSSL 3.
+func SSHKDF(h crypto.Hash, key, exchangeHash, sessionID []byte, typ byte, keyLen int) ([]byte, error) {
+	if typ < SSHKDFInitialIVClientToServer || typ > SSHKDFIntegrityKeyServerToClient {
+		return nil, errors.New("openssl: invalid SSHKDF key type")
+	}
+	if keyLen <= 0 {
+		return nil, errors.New("openssl: invalid SSHKDF key length")
+	}
+	if err := checkStrictHash(h, false); err != nil {
+		return nil, err
+	}
+	md := cryptoHashToMD(h)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported hash function")
+	}
+	ctx, err := newKDFCtx(nil, nil, "SSHKDF")
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EVP_KDF_CTX_free(ctx)
+	bld, err := newParamBuilder()
+	if err != nil {
+		return nil, err
+	}
+	defer bld.free()
+	bld.addUTF8String("digest", C.GoString(C.go_openssl_EVP_MD_get0_name(md)))
+	bld.addOctetString("key", key)
+	bld.addOctetString("xcghash", exchangeHash)
+	bld.addOctetString("session_id", sessionID)
+	bld.addUTF8String("type", string(typ))
+	params, err := bld.build()
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	out := make([]byte, keyLen)
+	if C.go_openssl_EVP_KDF_derive(ctx, base(out), C.size_t(len(out)), params) != 1 {
+		return nil, newOpenSSLError("EVP_KDF_derive")
+	}
+	return out, nil
+}
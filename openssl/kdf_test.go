@@ -0,0 +1,146 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNewKDF(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("EVP_KDF is only supported on OpenSSL 3")
+	}
+	// RFC 5869, Test Case 1.
+	kdf, err := NewKDF("HKDF", map[string]interface{}{
+		"digest": "SHA256",
+		"key":    decodeHex(t, "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b"),
+		"salt":   decodeHex(t, "000102030405060708090a0b0c"),
+		"info":   decodeHex(t, "f0f1f2f3f4f5f6f7f8f9"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := kdf.Derive(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeHex(t, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if size := kdf.Size(); size != 0 {
+		t.Errorf("got size %d, want 0", size)
+	}
+}
+
+func TestNewKDFIntegerParam(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("EVP_KDF is only supported on OpenSSL 3")
+	}
+	// RFC 6070, PBKDF2-HMAC-SHA1 with 2 iterations.
+	kdf, err := NewKDF("PBKDF2", map[string]interface{}{
+		"digest": "SHA1",
+		"pass":   "password",
+		"salt":   "salt",
+		"iter":   2,
+		"pkcs5":  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := kdf.Derive(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeHex(t, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestNewKDFErrors(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("EVP_KDF is only supported on OpenSSL 3")
+	}
+	if _, err := NewKDF("NOT-A-KDF", nil); err == nil {
+		t.Error("expected error for unknown KDF")
+	}
+	if _, err := NewKDF("HKDF", map[string]interface{}{"unknown": "value"}); err == nil {
+		t.Error("expected error for unknown parameter")
+	}
+	if _, err := NewKDF("HKDF", map[string]interface{}{"key": 1}); err == nil {
+		t.Error("expected error for mistyped parameter")
+	}
+	kdf, err := NewKDF("HKDF", map[string]interface{}{"digest": "SHA256", "key": []byte("key")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyLen := range []int{0, -1} {
+		if _, err := kdf.Derive(keyLen); err == nil {
+			t.Errorf("expected error for key length %d", keyLen)
+		}
+	}
+}
+
+// sshKDF is a reference implementation of RFC 4253, Section 7.2.
+func sshKDF(key, exchangeHash, sessionID []byte, typ byte, keyLen int) []byte {
+	h := sha256.New()
+	h.Write(key)
+	h.Write(exchangeHash)
+	h.Write([]byte{typ})
+	h.Write(sessionID)
+	out := h.Sum(nil)
+	for len(out) < keyLen {
+		h.Reset()
+		h.Write(key)
+		h.Write(exchangeHash)
+		h.Write(out)
+		out = h.Sum(out)
+	}
+	return out[:keyLen]
+}
+
+func TestSSHKDF(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("SSHKDF is only supported on OpenSSL 3")
+	}
+	key := decodeHex(t, "0000002100e8e2b2ad2d4e8cc4f5df1bd4bc0fbe8f8207ed620ed1d6c9427a38e2cbebc2f9")
+	exchangeHash := decodeHex(t, "1a5e0ff818e127a3cc7a9a3e7d3bc39c158daa4b5f71e3a7dab1c4ba2f0d7ae1")
+	sessionID := decodeHex(t, "9d1c3e93e62c1d0fbd097b3f14f335e4da30c0c3b9116d9bd0bb89d267e7113c")
+	for typ := SSHKDFInitialIVClientToServer; typ <= SSHKDFIntegrityKeyServerToClient; typ++ {
+		for _, keyLen := range []int{12, 32, 64} {
+			got, err := SSHKDF(crypto.SHA256, key, exchangeHash, sessionID, typ, keyLen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := sshKDF(key, exchangeHash, sessionID, typ, keyLen)
+			if !bytes.Equal(got, want) {
+				t.Errorf("type %c, keyLen %d: got %x, want %x", typ, keyLen, got, want)
+			}
+		}
+	}
+	if _, err := SSHKDF(crypto.SHA256, key, exchangeHash, sessionID, 'G', 16); err == nil {
+		t.Error("expected error for invalid key type")
+	}
+	for _, keyLen := range []int{0, -1} {
+		if _, err := SSHKDF(crypto.SHA256, key, exchangeHash, sessionID, SSHKDFInitialIVClientToServer, keyLen); err == nil {
+			t.Errorf("keyLen %d: expected error for invalid key length", keyLen)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package leaktest detects native memory leaks of code using the
+// openssl package in tests.
+//
+// The objects of the openssl package free their EVP contexts and keys
+// in finalizers, so that forgetting a Close, or a bug holding on to
+// a native object, doesn't show up in the Go heap. Check counts the
+// libcrypto allocations instead, which requires initializing the
+// openssl package with InitOptions.TrackAllocations, usually in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		if err := openssl.InitWithOptions(openssl.InitOptions{TrackAllocations: true}); err != nil {
+//			panic(err)
+//		}
+//		os.Exit(m.Run())
+//	}
+package leaktest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// settleTimeout bounds how long Check waits for the finalizers
+// of the objects released by f to free their allocations.
+const settleTimeout = 2 * time.Second
+
+// Check calls f once to warm up the caches of libcrypto, such as the
+// fetched algorithms and the per-thread state, then calls it n more
+// times and fails t if the libcrypto allocations not freed yet grew by
+// n or more, which means that f leaks at least one allocation per call.
+// Objects released by f are garbage collected before counting.
+func Check(t testing.TB, n int, f func()) {
+	t.Helper()
+	if n <= 0 {
+		t.Fatalf("leaktest: invalid number of calls %d", n)
+	}
+	f()
+	before, err := settle(-1)
+	if err != nil {
+		t.Fatal("leaktest: ", err)
+	}
+	for i := 0; i < n; i++ {
+		f()
+	}
+	after, err := settle(before + int64(n) - 1)
+	if err != nil {
+		t.Fatal("leaktest: ", err)
+	}
+	if leaked := after - before; leaked >= int64(n) {
+		t.Errorf("leaktest: %d libcrypto allocations leaked by %d calls", leaked, n)
+	}
+}
+
+// settle runs the garbage collector until the live allocations drop
+// to target or below, or settleTimeout elapses, and returns them.
+// A negative target only runs the garbage collector once.
+func settle(target int64) (int64, error) {
+	deadline := time.Now().Add(settleTimeout)
+	for {
+		// The first collection queues the finalizers,
+		// the second one waits for them to run.
+		runtime.GC()
+		runtime.GC()
+		live, err := openssl.LiveAllocations()
+		if err != nil || live <= target || target < 0 || time.Now().After(deadline) {
+			return live, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
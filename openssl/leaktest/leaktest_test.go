@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package leaktest
+
+import (
+	"fmt"
+	"hash"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.InitWithOptions(openssl.InitOptions{TrackAllocations: true}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// recorder records the failures of Check.
+type recorder struct {
+	testing.TB
+	failed string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+
+func TestCheck(t *testing.T) {
+	Check(t, 100, func() {
+		h := openssl.NewSHA256()
+		h.Write([]byte("message"))
+		h.Sum(nil)
+	})
+}
+
+func TestCheckLeak(t *testing.T) {
+	var kept []hash.Hash
+	r := &recorder{TB: t}
+	Check(r, 100, func() {
+		kept = append(kept, openssl.NewSHA256())
+	})
+	runtime.KeepAlive(kept)
+	if r.failed == "" {
+		t.Error("Check didn't report hashes kept alive")
+	}
+}
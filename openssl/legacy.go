@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "strings"
+
+// legacyAlgorithms lists the name prefixes of the algorithms
+// OpenSSL 3 only implements in the legacy provider.
+var legacyAlgorithms = [...]string{
+	"MD2", "MD4", "MDC2", "WHIRLPOOL",
+	"BF", "BLOWFISH", "CAST5", "DES-CBC", "DES-CFB", "DES-ECB", "DES-OFB", "DESX",
+	"IDEA", "RC2", "RC4", "RC5", "SEED",
+	"PBKDF1",
+}
+
+func isLegacyAlgorithm(name string) bool {
+	name = strings.ToUpper(name)
+	for _, prefix := range legacyAlgorithms {
+		if name == prefix || strings.HasPrefix(name, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyProvider is the name of the OpenSSL legacy provider.
+const legacyProvider = "legacy"
+
+// LoadLegacyProvider loads the OpenSSL legacy provider into the default
+// library context with LoadProvider, which makes algorithms such as MD4,
+// RC4, Blowfish and single DES available without changing the OpenSSL
+// configuration.
+//
+// Fetching one of these algorithms by name, for example with
+// LibraryContext.NewHash, loads the legacy provider automatically
+// unless FIPS mode or strict FIPS mode is enabled.
+//
+// LoadLegacyProvider is only supported on OpenSSL 3.
+func LoadLegacyProvider() error {
+	return LoadProvider(legacyProvider)
+}
+
+// UnloadLegacyProvider unloads the legacy provider loaded by
+// LoadLegacyProvider or on demand.
+// The legacy provider stays available if it was also loaded by other means,
+// such as the OpenSSL configuration.
+//
+// UnloadLegacyProvider is only supported on OpenSSL 3.
+func UnloadLegacyProvider() error {
+	return UnloadProvider(legacyProvider)
+}
+
+// loadLegacyFor loads the legacy provider if the algorithm name
+// failed to be fetched from libctx because it lives there.
+// It reports whether the fetch should be retried.
+func loadLegacyFor(libctx C.GO_OSSL_LIB_CTX_PTR, name string) bool {
+	if libctx != nil || !isLegacyAlgorithm(name) || FIPS() || StrictFIPS() {
+		return false
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if _, ok := loadedProviders[legacyProvider]; ok || loadProvider(legacyProvider) != nil {
+		return false
+	}
+	C.go_openssl_ERR_clear_error()
+	return true
+}
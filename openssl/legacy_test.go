@@ -0,0 +1,60 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLegacyProvider(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("the legacy provider is only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("the legacy provider is not loaded in FIPS mode")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	lib, err := DefaultLibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC 1320, Appendix A.5.
+	want := decodeHex(t, "a448017aaf21d8525fc10ae87aa6729d")
+	md4 := func() {
+		t.Helper()
+		h, err := lib.NewHash("MD4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write([]byte("abc"))
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("got %x, want %x", got, want)
+		}
+	}
+	// The legacy provider is loaded on demand.
+	md4()
+	if err := UnloadLegacyProvider(); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLegacyProvider(); err != nil {
+		t.Fatal(err)
+	}
+	md4()
+	if err := UnloadLegacyProvider(); err != nil {
+		t.Fatal(err)
+	}
+	// Non-legacy algorithms don't load it.
+	if _, err := lib.NewHash("NOT-A-HASH"); err == nil {
+		t.Error("expected error for unknown hash")
+	}
+	if _, ok := loadedProviders[legacyProvider]; ok {
+		t.Error("legacy provider loaded for a non-legacy algorithm")
+	}
+}
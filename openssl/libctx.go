@@ -0,0 +1,310 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto/cipher"
+	"errors"
+	"hash"
+	"runtime"
+	"unsafe"
+)
+
+// LibraryContext is an isolated OpenSSL library context with its own
+// configuration, providers and default properties.
+//
+// Algorithms created from a LibraryContext are served only by the
+// providers loaded in it, which allows, for example, using a FIPS
+// context for some data and the default context for everything else
+// in the same process. The package-level functions always use the
+// default library context.
+//
+// A LibraryContext can also carry a property query, set with
+// WithProperties, that selects which provider implementation
+// is fetched for each algorithm.
+type LibraryContext struct {
+	ctx       C.GO_OSSL_LIB_CTX_PTR
+	providers []C.GO_OSSL_PROVIDER_PTR
+	// parent is the context owning ctx, for
+	// contexts returned by WithProperties.
+	parent *LibraryContext
+	// props is the property query used in all fetches, or nil.
+	props *C.char
+	closer
+}
+
+var defaultLibraryContext = &LibraryContext{}
+
+// DefaultLibraryContext returns the default OpenSSL library context,
+// used by all the package-level functions, typically to get a
+// derived context with WithProperties.
+//
+// DefaultLibraryContext is only supported on OpenSSL 3.
+func DefaultLibraryContext() (*LibraryContext, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	return defaultLibraryContext, nil
+}
+
+// NewLibraryContext creates a library context, loads into it the
+// OpenSSL configuration file at configPath, if not empty,
+// and then loads the named providers, such as "fips", "default" or "base".
+//
+// If neither the configuration nor providers activate any provider,
+// OpenSSL loads the default provider on first use.
+//
+// NewLibraryContext is only supported on OpenSSL 3.
+func NewLibraryContext(configPath string, providers ...string) (*LibraryContext, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	ctx := C.go_openssl_OSSL_LIB_CTX_new()
+	if ctx == nil {
+		return nil, newOpenSSLError("OSSL_LIB_CTX_new")
+	}
+	l := &LibraryContext{ctx: ctx}
+	manage(l, (*LibraryContext).finalize)
+	if configPath != "" {
+		cpath := C.CString(configPath)
+		defer C.free(unsafe.Pointer(cpath))
+		if C.go_openssl_OSSL_LIB_CTX_load_config(ctx, cpath) != 1 {
+			err := newOpenSSLError("OSSL_LIB_CTX_load_config(" + configPath + ")")
+			l.Close()
+			return nil, err
+		}
+	}
+	for _, name := range providers {
+		cname := C.CString(name)
+		prov := C.go_openssl_OSSL_PROVIDER_load(ctx, cname)
+		C.free(unsafe.Pointer(cname))
+		if prov == nil {
+			err := newOpenSSLError("OSSL_PROVIDER_load(" + name + ")")
+			l.Close()
+			return nil, err
+		}
+		l.providers = append(l.providers, prov)
+	}
+	return l, nil
+}
+
+func (l *LibraryContext) finalize() {
+	if l.parent != nil {
+		// Only the owner frees ctx and the providers.
+		C.free(unsafe.Pointer(l.props))
+		return
+	}
+	if l.ctx == nil {
+		// The default context can't be freed.
+		return
+	}
+	for _, prov := range l.providers {
+		C.go_openssl_OSSL_PROVIDER_unload(prov)
+	}
+	C.go_openssl_OSSL_LIB_CTX_free(l.ctx)
+}
+
+// Close frees the native resources of l, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (l *LibraryContext) Close() error {
+	return closeObject(l, l.finalize)
+}
+
+// WithProperties returns a context sharing the providers and
+// configuration of l that fetches every algorithm using the
+// property query properties, such as "provider=fips" or "fips=yes",
+// instead of the default property query of l.
+// Algorithms that depend on other algorithms, like the digest of an HMAC,
+// forward properties to them when supported.
+func (l *LibraryContext) WithProperties(properties string) *LibraryContext {
+	owner := l
+	if l.parent != nil {
+		owner = l.parent
+	}
+	v := &LibraryContext{ctx: l.ctx, parent: owner}
+	if properties != "" {
+		v.props = C.CString(properties)
+		manage(v, (*LibraryContext).finalize)
+	}
+	return v
+}
+
+// Properties returns the property query set by WithProperties.
+func (l *LibraryContext) Properties() string {
+	if l == nil || l.props == nil {
+		return ""
+	}
+	return C.GoString(l.props)
+}
+
+// propq returns the property query of l as a C string, or nil.
+func (l *LibraryContext) propq() *C.char {
+	if l == nil {
+		return nil
+	}
+	return l.props
+}
+
+// ptr returns the OSSL_LIB_CTX of l. A nil l stands for
+// the default library context, which OpenSSL represents as NULL.
+func (l *LibraryContext) ptr() C.GO_OSSL_LIB_CTX_PTR {
+	if l == nil {
+		return nil
+	}
+	return l.ctx
+}
+
+// SetFIPS enables or disables the "fips=yes" default property of l,
+// which restricts it to FIPS approved algorithm implementations.
+// Unlike the package-level SetFIPS, it doesn't load any provider.
+func (l *LibraryContext) SetFIPS(enabled bool) error {
+	defer runtime.KeepAlive(l)
+	var mode C.int
+	if enabled {
+		mode = 1
+	}
+	if C.go_openssl_EVP_default_properties_enable_fips(l.ctx, mode) != 1 {
+		return newOpenSSLError("EVP_default_properties_enable_fips")
+	}
+	return nil
+}
+
+// FIPS reports whether l requires FIPS approved algorithm implementations
+// and has the FIPS provider available.
+func (l *LibraryContext) FIPS() bool {
+	defer runtime.KeepAlive(l)
+	return C.go_openssl_EVP_default_properties_is_fips_enabled(l.ctx) == 1 &&
+		C.go_openssl_OSSL_PROVIDER_available(l.ctx, providerNameFips) == 1
+}
+
+// NewHash returns the hash named name, such as "SHA256", "SHA3-256"
+// or an algorithm of a third-party provider, fetched from the default
+// library context. If name isn't available, NewHash returns the
+// fallback registered with RegisterHashFallback, if any, or an error
+// matching ErrNotSupported.
+//
+// NewHash is only supported on OpenSSL 3.
+func NewHash(name string) (hash.Hash, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	h, err := defaultLibraryContext.NewHash(name)
+	if err != nil && errors.Is(err, ErrNotSupported) && !FIPS() && !StrictFIPS() {
+		if fb := hashFallback(name); fb != nil {
+			return fb(), nil
+		}
+	}
+	return h, err
+}
+
+// NewHash returns the hash named name, such as "SHA256" or "SHA3-256",
+// fetched from l.
+func (l *LibraryContext) NewHash(name string) (hash.Hash, error) {
+	md, err := l.fetchMD(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStrictMD(md); err != nil {
+		C.go_openssl_EVP_MD_free(md)
+		return nil, err
+	}
+	size := int(C.go_openssl_EVP_MD_get_size(md))
+	blockSize := int(C.go_openssl_EVP_MD_get_block_size(md))
+	return &namedHash{evpHash: newEvpHashMD(l, md, size, blockSize)}, nil
+}
+
+func (l *LibraryContext) fetchMD(name string) (C.GO_EVP_MD_PTR, error) {
+	defer runtime.KeepAlive(l)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	md := C.go_openssl_EVP_MD_fetch(l.ctx, cname, l.props)
+	if md == nil && loadLegacyFor(l.ctx, name) {
+		md = C.go_openssl_EVP_MD_fetch(l.ctx, cname, l.props)
+	}
+	if md == nil {
+		return nil, newNotSupportedError(name, "EVP_MD_fetch("+name+")")
+	}
+	return md, nil
+}
+
+// namedHash is a hash fetched by name from a library context.
+type namedHash struct {
+	*evpHash
+}
+
+func (h *namedHash) Sum(in []byte) []byte {
+	out := make([]byte, h.size)
+	h.sum(out)
+	return append(in, out...)
+}
+
+// NewHMAC returns a new HMAC using the hash named digest,
+// both fetched from l.
+func (l *LibraryContext) NewHMAC(digest string, key []byte) (hash.Hash, error) {
+	h, err := l.NewHash(digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		// See the empty key workaround in NewHMAC.
+		key = make([]byte, C.GO_EVP_MAX_MD_SIZE)
+	}
+	hkey := make([]byte, len(key))
+	copy(hkey, key)
+	hmac, err := newHMAC3(l, hkey, h, h.(*namedHash).md)
+	if err != nil {
+		return nil, err
+	}
+	return hmac, nil
+}
+
+// NewKDF is like the package-level NewKDF
+// but fetches the key derivation function from l.
+// The property query of l is also passed to the KDF in the
+// "properties" parameter, if it accepts one and params doesn't set it.
+func (l *LibraryContext) NewKDF(name string, params map[string]interface{}) (*KDF, error) {
+	return newKDF(l, name, params)
+}
+
+// NewAESCipher is like the package-level NewAESCipher
+// but fetches the AES implementations, including the ones
+// used by the CBC, CTR and GCM modes, from l.
+func (l *LibraryContext) NewAESCipher(key []byte) (cipher.Block, error) {
+	c := &aesCipher{lib: l, key: make([]byte, len(key))}
+	copy(c.key, key)
+	switch len(c.key) * 8 {
+	case 128, 192, 256:
+	default:
+		return nil, errors.New("crypto/cipher: Invalid key size")
+	}
+	var err error
+	if c.cipher, err = l.fetchCipher(c.cipherName("ECB")); err != nil {
+		return nil, err
+	}
+	manage(c, (*aesCipher).finalize)
+	return c, nil
+}
+
+func (l *LibraryContext) fetchCipher(name string) (C.GO_EVP_CIPHER_PTR, error) {
+	defer runtime.KeepAlive(l)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cipher := C.go_openssl_EVP_CIPHER_fetch(l.ptr(), cname, l.propq())
+	if cipher == nil && loadLegacyFor(l.ptr(), name) {
+		cipher = C.go_openssl_EVP_CIPHER_fetch(l.ptr(), cname, l.propq())
+	}
+	if cipher == nil {
+		return nil, newNotSupportedError(name, "EVP_CIPHER_fetch("+name+")")
+	}
+	if err := checkStrictCipher(cipher); err != nil {
+		C.go_openssl_EVP_CIPHER_free(cipher)
+		return nil, err
+	}
+	return cipher, nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+)
+
+func TestLibraryContext(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("library contexts are only supported on OpenSSL 3")
+	}
+	l, err := NewLibraryContext("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := l.NewHash("SHA2-512")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Size() != sha512.Size || h.BlockSize() != sha512.BlockSize {
+		t.Errorf("got size %d and block size %d", h.Size(), h.BlockSize())
+	}
+	h.Write([]byte("hello"))
+	want := sha512.Sum512([]byte("hello"))
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	h, err = NewHash("SHA2-512")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("hello"))
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	mac, err := l.NewHMAC("SHA256", []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac.Write([]byte("hello"))
+	ref := hmac.New(sha256.New, []byte("key"))
+	ref.Write([]byte("hello"))
+	if got, want := mac.Sum(nil), ref.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+
+	kdf, err := l.NewKDF("PBKDF2", map[string]interface{}{
+		"digest": "SHA1",
+		"pass":   "password",
+		"salt":   "salt",
+		"iter":   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := kdf.Derive(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decodeHex(t, "0c60c80f961f0e71f3a9b524af6012062fe037a6"); !bytes.Equal(key, want) {
+		t.Errorf("got %x, want %x", key, want)
+	}
+}
+
+func TestLibraryContextIsolation(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("library contexts are only supported on OpenSSL 3")
+	}
+	// The base provider doesn't implement any digest.
+	l, err := NewLibraryContext("", "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.NewHash("SHA256"); err == nil {
+		t.Error("expected error fetching SHA256 from a context without the default provider")
+	}
+	if err := l.SetFIPS(true); err != nil {
+		t.Fatal(err)
+	}
+	if l.FIPS() {
+		t.Error("FIPS reported without the FIPS provider")
+	}
+	if _, err := NewLibraryContext("", "not-a-provider"); err == nil {
+		t.Error("expected error loading an unknown provider")
+	}
+}
+
+func TestLibraryContextProperties(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("library contexts are only supported on OpenSSL 3")
+	}
+	def, err := DefaultLibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := def.WithProperties("provider=default")
+	if got := l.Properties(); got != "provider=default" {
+		t.Errorf("got properties %q", got)
+	}
+	if _, err := l.NewHash("SHA256"); err != nil {
+		t.Error(err)
+	}
+	if _, err := l.NewHMAC("SHA256", []byte("key")); err != nil {
+		t.Error(err)
+	}
+	if _, err := l.NewKDF("HKDF", map[string]interface{}{"digest": "SHA256"}); err != nil {
+		t.Error(err)
+	}
+	none := def.WithProperties("provider=not-a-provider")
+	if _, err := none.NewHash("SHA256"); err == nil {
+		t.Error("expected error fetching from a provider that isn't loaded")
+	}
+	if _, err := none.NewAESCipher(make([]byte, 16)); err == nil {
+		t.Error("expected error fetching from a provider that isn't loaded")
+	}
+}
+
+func TestLibraryContextAES(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("library contexts are only supported on OpenSSL 3")
+	}
+	l, err := NewLibraryContext("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	msg := []byte("0123456789abcdef0123456789abcdef")
+	block, err := l.NewAESCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := make([]byte, len(msg)), make([]byte, len(msg))
+	block.Encrypt(got, msg)
+	ref.Encrypt(want, msg)
+	if !bytes.Equal(got[:aes.BlockSize], want[:aes.BlockSize]) {
+		t.Errorf("ECB: got %x, want %x", got[:aes.BlockSize], want[:aes.BlockSize])
+	}
+	block.(extraModes).NewCBCEncrypter(iv).CryptBlocks(got, msg)
+	cipher.NewCBCEncrypter(ref, iv).CryptBlocks(want, msg)
+	if !bytes.Equal(got, want) {
+		t.Errorf("CBC: got %x, want %x", got, want)
+	}
+	block.(extraModes).NewCTR(iv).XORKeyStream(got, msg)
+	cipher.NewCTR(ref, iv).XORKeyStream(want, msg)
+	if !bytes.Equal(got, want) {
+		t.Errorf("CTR: got %x, want %x", got, want)
+	}
+	gcm, err := block.(extraModes).NewGCM(gcmStandardNonceSize, gcmTagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refGCM, err := cipher.NewGCM(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcmStandardNonceSize)
+	sealed := gcm.Seal(nil, nonce, msg, nil)
+	if want := refGCM.Seal(nil, nonce, msg, nil); !bytes.Equal(sealed, want) {
+		t.Errorf("GCM: got %x, want %x", sealed, want)
+	}
+}
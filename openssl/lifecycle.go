@@ -0,0 +1,143 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// The objects of this package holding native OpenSSL objects, such as
+// keys, certificates, hashes and ciphers, free them from a finalizer by
+// default. They all implement io.Closer as well, whose Close frees them
+// right away and removes the finalizer, so the next garbage collections
+// don't free them instead. Hashes, ciphers and AEADs are returned as
+// interfaces of the standard library, type assert io.Closer to close them.
+//
+// With InitOptions.ExplicitClose, no finalizer is set at all: objects
+// are only freed by Close, and are tracked until then so that leaks can
+// be found with OutstandingObjects. Latency-sensitive programs use it to
+// keep cgo calls out of the finalizers the garbage collector runs.
+
+// explicitClose is set by initLibrary, under initOnce,
+// see InitOptions.ExplicitClose.
+var explicitClose bool
+
+// closer is embedded in the objects holding native resources,
+// it records whether they were closed.
+type closer struct {
+	closed uint32
+	// h is the handle of the native object, for the objects
+	// owning theirs through the handle table, see pin.
+	h handle
+}
+
+func (c *closer) lifecycle() *closer { return c }
+
+// checkOpen panics if c was closed. The objects whose native contexts
+// return to a pool once closed call it before using them, as another
+// object may already have taken them from the pool.
+func (c *closer) checkOpen() {
+	if atomic.LoadUint32(&c.closed) != 0 {
+		panic("openssl: use of a closed object")
+	}
+}
+
+// managed is implemented by the objects embedding closer.
+type managed interface {
+	lifecycle() *closer
+}
+
+// outstanding holds the objects not closed yet with InitOptions.ExplicitClose,
+// with the program counters of the functions creating them.
+var outstanding struct {
+	sync.Mutex
+	objs map[managed][]uintptr
+}
+
+// manage arranges for the native resources of obj, a pointer to a new
+// object, to be freed by finalizer, the finalize method expression of
+// obj such as (*PKey).finalize, unless obj is closed first.
+// With InitOptions.ExplicitClose, obj is tracked until closed instead.
+func manage(obj managed, finalizer interface{}) {
+	if !explicitClose {
+		runtime.SetFinalizer(obj, finalizer)
+		return
+	}
+	pcs := make([]uintptr, 16)
+	// Skip runtime.Callers and manage.
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	outstanding.Lock()
+	defer outstanding.Unlock()
+	if outstanding.objs == nil {
+		outstanding.objs = make(map[managed][]uintptr)
+	}
+	outstanding.objs[obj] = pcs
+}
+
+// closeObject frees the native resources of obj with finalize,
+// unless obj was already closed. obj must not be used afterwards.
+func closeObject(obj managed, finalize func()) error {
+	if !atomic.CompareAndSwapUint32(&obj.lifecycle().closed, 0, 1) {
+		return nil
+	}
+	if explicitClose {
+		outstanding.Lock()
+		delete(outstanding.objs, obj)
+		outstanding.Unlock()
+	} else {
+		runtime.SetFinalizer(obj, nil)
+	}
+	finalize()
+	return nil
+}
+
+// closeAll closes the objects of objs implementing io.Closer,
+// such as the temporary hashes and ciphers of this package.
+func closeAll(objs ...interface{}) {
+	for _, obj := range objs {
+		if c, ok := obj.(interface{ Close() error }); ok {
+			c.Close()
+		}
+	}
+}
+
+// An OutstandingObject is an object of this package
+// not closed yet, see OutstandingObjects.
+type OutstandingObject struct {
+	// Type is the Go type of the object, such as "*openssl.PKey".
+	Type string
+	// Stack lists the functions that created the object, innermost first.
+	Stack []string
+}
+
+// OutstandingObjects returns the objects not closed yet with
+// InitOptions.ExplicitClose, which are leaked if the program doesn't
+// use them anymore. It returns nil without InitOptions.ExplicitClose.
+func OutstandingObjects() []OutstandingObject {
+	outstanding.Lock()
+	defer outstanding.Unlock()
+	var objs []OutstandingObject
+	for obj, pcs := range outstanding.objs {
+		o := OutstandingObject{Type: reflect.TypeOf(obj).String()}
+		frames := runtime.CallersFrames(pcs)
+		for {
+			frame, more := frames.Next()
+			if !strings.HasPrefix(frame.Function, "runtime.") {
+				o.Stack = append(o.Stack, frame.Function)
+			}
+			if !more {
+				break
+			}
+		}
+		objs = append(objs, o)
+	}
+	return objs
+}
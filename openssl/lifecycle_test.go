@@ -0,0 +1,91 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestClose(t *testing.T) {
+	h := NewSHA256()
+	h.Write([]byte("hello"))
+	c := h.(io.Closer)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing twice is a no-op.
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if objs := OutstandingObjects(); !explicitClose && objs != nil {
+		t.Errorf("OutstandingObjects() = %v without ExplicitClose", objs)
+	}
+}
+
+func TestExplicitClose(t *testing.T) {
+	if vMajor < 3 {
+		t.Skip("GenerateKey requires OpenSSL 3")
+	}
+	if os.Getenv("GO_OPENSSL_TEST_EXPLICIT_CLOSE") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestExplicitClose$", "-test.v")
+		cmd.Env = append(os.Environ(), "GO_OPENSSL_TEST_EXPLICIT_CLOSE=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+	// Running in the child process started above.
+	before := len(OutstandingObjects())
+	key, err := GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := OutstandingObjects()
+	if len(objs) != before+1 {
+		t.Fatalf("got %d outstanding objects, want %d", len(objs), before+1)
+	}
+	found := false
+	for _, o := range objs {
+		if o.Type == "*openssl.PKey" && strings.Contains(strings.Join(o.Stack, " "), "TestExplicitClose") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the Ed25519 key isn't listed in %v", objs)
+	}
+
+	sig, err := key.Sign([]byte("hello"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Verify([]byte("hello"), sig, ""); err != nil {
+		t.Fatal(err)
+	}
+	mac := NewHMAC(NewSHA256, []byte("key"))
+	mac.Write([]byte("hello"))
+	mac.Sum(nil)
+	block, err := NewAESCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := block.(extraModes).NewGCM(gcmStandardNonceSize, gcmTagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead.Seal(nil, make([]byte, gcmStandardNonceSize), []byte("hello"), nil)
+	if _, err := PBKDF2([]byte("password"), []byte("salt"), 1, 32, NewSHA256); err != nil {
+		t.Fatal(err)
+	}
+	closeAll(key, mac, block, aead)
+	if objs := OutstandingObjects(); len(objs) != before {
+		t.Errorf("got %d outstanding objects after Close, want %d: %v", len(objs), before, objs)
+	}
+}
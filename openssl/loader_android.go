@@ -0,0 +1,25 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build android
+// +build android
+
+package openssl
+
+// Android doesn't let applications load the system libcrypto, which is
+// a private BoringSSL build, so applications bundle their own OpenSSL.
+// APKs only package native libraries named lib*.so, which are extracted
+// to the application native library directory, so the unversioned
+// libcrypto.so is probed. Libraries stored elsewhere must be loaded by
+// path with InitOptions.LibraryPath.
+
+// libraryName returns the libcrypto shared library name with the given version suffix.
+func libraryName(version string) string {
+	return "libcrypto.so." + version
+}
+
+// defaultLibraryNames returns the libcrypto shared library names
+// probed when no library is requested.
+func defaultLibraryNames() []string {
+	return []string{"libcrypto.so"}
+}
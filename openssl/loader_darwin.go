@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build darwin
+// +build darwin
+
+package openssl
+
+import "strings"
+
+// knownVersions is a list of supported and well-known libcrypto dylib versions in decreasing version order.
+var knownVersions = [...]string{"3", "1.1"}
+
+// darwinLibraryDirs are the directories a libcrypto dylib is looked up in, in order:
+// next to the executable and in its app bundle, the dyld search path,
+// Homebrew on Apple silicon and Intel, and MacPorts.
+//
+// The unversioned libcrypto.dylib and the libcrypto dylibs in /usr/lib are
+// Apple's LibreSSL, which is never probed. Loading the unversioned stub
+// aborts the process.
+var darwinLibraryDirs = [...]string{
+	"@executable_path/",
+	"@executable_path/../Frameworks/",
+	"",
+	"/opt/homebrew/opt/openssl@{v}/lib/",
+	"/usr/local/opt/openssl@{v}/lib/",
+	"/opt/local/lib/",
+}
+
+// libraryName returns the libcrypto dylib name with the given version suffix.
+func libraryName(version string) string {
+	return "libcrypto." + version + ".dylib"
+}
+
+// defaultLibraryNames returns the libcrypto dylib paths
+// probed when no library is requested, see knownVersions and darwinLibraryDirs.
+func defaultLibraryNames() []string {
+	var names []string
+	for _, v := range knownVersions {
+		for _, dir := range darwinLibraryDirs {
+			names = append(names, strings.ReplaceAll(dir, "{v}", v)+libraryName(v))
+		}
+	}
+	return names
+}
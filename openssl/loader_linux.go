@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux && !android
+// +build linux,!android
+
+package openssl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// knownVersions is a list of supported and well-known libcrypto.so suffixes in decreasing version order.
+//
+// FreeBSD library version numbering does not directly align to the version of OpenSSL.
+// Its preferred search order is 11 -> 111.
+//
+// Some distributions use 1.0.0 and others (such as Debian) 1.0.2 to refer to the same OpenSSL 1.0.2 version.
+//
+// Fedora derived distros use different naming for the version 1.0.x.
+var knownVersions = [...]string{"3", "1.1", "11", "111", "1.0.2", "1.0.0", "10"}
+
+// libraryName returns the libcrypto shared library name with the given version suffix.
+func libraryName(version string) string {
+	return "libcrypto.so." + version
+}
+
+// linuxLibraryDirs are the directories searched for libcrypto after the
+// dlopen search path. musl has no ldconfig cache and only searches the
+// directories listed in /etc/ld-musl-$ARCH.path, or a built-in list
+// when that file doesn't exist, which misses some soname layouts.
+var linuxLibraryDirs = [...]string{"/lib", "/usr/lib", "/usr/local/lib", "/lib64", "/usr/lib64"}
+
+// libraryDirs returns the directories searched by defaultLibraryNames:
+// LD_LIBRARY_PATH, the musl search path configuration and linuxLibraryDirs.
+func libraryDirs() []string {
+	dirs := filepath.SplitList(os.Getenv("LD_LIBRARY_PATH"))
+	paths, _ := filepath.Glob("/etc/ld-musl-*.path")
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Entries are separated by colons or newlines.
+		dirs = append(dirs, strings.FieldsFunc(string(b), func(r rune) bool {
+			return r == ':' || r == '\n'
+		})...)
+	}
+	return append(dirs, linuxLibraryDirs[:]...)
+}
+
+// defaultLibraryNames returns the libcrypto shared library names
+// probed when no library is requested, see knownVersions.
+// The names are followed by the paths of the libraries
+// found in libraryDirs, for when dlopen doesn't find them by name.
+func defaultLibraryNames() []string {
+	names := make([]string, len(knownVersions))
+	for i, v := range knownVersions {
+		names[i] = libraryName(v)
+	}
+	dirs := libraryDirs()
+	seen := make(map[string]bool)
+	for _, v := range knownVersions {
+		for _, dir := range dirs {
+			dir = strings.TrimSpace(dir)
+			if dir == "" {
+				continue
+			}
+			path := filepath.Join(dir, libraryName(v))
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			if _, err := os.Stat(path); err == nil {
+				names = append(names, path)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux && !android
+// +build linux,!android
+
+package openssl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultLibraryNamesLibraryPath(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, libraryName("3"))
+	if err := os.WriteFile(lib, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LD_LIBRARY_PATH", dir)
+	names := defaultLibraryNames()
+	if names[0] != libraryName(knownVersions[0]) {
+		t.Errorf("got first name %s, want %s", names[0], libraryName(knownVersions[0]))
+	}
+	var found bool
+	for _, name := range names {
+		if name == lib {
+			found = true
+		}
+		if name == filepath.Join(dir, libraryName("1.1")) {
+			t.Errorf("missing library %s is probed", name)
+		}
+	}
+	if !found {
+		t.Errorf("%s not probed: %v", lib, names)
+	}
+}
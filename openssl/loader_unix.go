@@ -0,0 +1,44 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package openssl
+
+// #define _GNU_SOURCE
+// #include <dlfcn.h>
+// #include <stdlib.h>
+//
+// // go_openssl_library_file returns the file name of the library loaded at
+// // handle, found from the address of a function every version exports.
+// static const char* go_openssl_library_file(void* handle) {
+//     Dl_info info;
+//     void* sym = dlsym(handle, "ERR_get_error");
+//     if (sym == NULL || dladdr(sym, &info) == 0)
+//         return NULL;
+//     return info.dli_fname;
+// }
+// #cgo linux LDFLAGS: -ldl
+import "C"
+import "unsafe"
+
+func dlopenPath(path string) unsafe.Pointer {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	return C.dlopen(cpath, C.RTLD_LAZY|C.RTLD_LOCAL)
+}
+
+// dlerror returns the error of the last failed dlopenPath call.
+func dlerror() string {
+	return C.GoString(C.dlerror())
+}
+
+func dlclose(handle unsafe.Pointer) {
+	C.dlclose(handle)
+}
+
+// libraryFile returns the file path of the library loaded at handle.
+func libraryFile(handle unsafe.Pointer) string {
+	return C.GoString(C.go_openssl_library_file(handle))
+}
@@ -0,0 +1,100 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build windows
+// +build windows
+
+package openssl
+
+// #include <windows.h>
+// #include <stdlib.h>
+//
+// // go_openssl_dlopen loads the DLL at path and returns the GetLastError
+// // code in err, which would be lost if read by a later cgo call.
+// static void* go_openssl_dlopen(const char* path, DWORD flags, DWORD* err) {
+//     HMODULE h = LoadLibraryExA(path, NULL, flags);
+//     *err = h == NULL ? GetLastError() : 0;
+//     return (void*)h;
+// }
+import "C"
+import (
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// knownVersions is a list of supported and well-known libcrypto DLL versions
+// in decreasing version order. OpenSSL 1.0.2 uses a different name, libeay32.dll.
+var knownVersions = [...]string{"3", "1_1"}
+
+// lastDLError holds the error of the last failed dlopenPath call.
+// dlopenPath is only called from Init, so it needs no synchronization.
+var lastDLError syscall.Errno
+
+// archSuffix is the architecture suffix of the OpenSSL 1.1 and 3 DLL names.
+func archSuffix() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "-x64"
+	case "arm64":
+		return "-arm64"
+	}
+	return ""
+}
+
+// libraryName returns the libcrypto DLL name with the given version,
+// for example libcrypto-3-x64.dll for version 3 on amd64.
+// Dots in version are replaced with underscores, so 1.1 maps to libcrypto-1_1-x64.dll.
+func libraryName(version string) string {
+	return "libcrypto-" + strings.ReplaceAll(version, ".", "_") + archSuffix() + ".dll"
+}
+
+// defaultLibraryNames returns the libcrypto DLL names
+// probed when no library is requested, see knownVersions.
+// Names without the architecture suffix are probed too,
+// as used by some third party builds.
+func defaultLibraryNames() []string {
+	var names []string
+	for _, v := range knownVersions {
+		names = append(names, libraryName(v))
+		if archSuffix() != "" {
+			names = append(names, "libcrypto-"+v+".dll")
+		}
+	}
+	return append(names, "libeay32.dll")
+}
+
+func dlopenPath(path string) unsafe.Pointer {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	var flags C.DWORD
+	if strings.ContainsAny(path, `/\`) {
+		// Resolve the dependencies of a DLL loaded by path from its
+		// directory, as OpenSSL builds ship them side by side.
+		flags = C.LOAD_WITH_ALTERED_SEARCH_PATH
+	}
+	var err C.DWORD
+	handle := C.go_openssl_dlopen(cpath, flags, &err)
+	lastDLError = syscall.Errno(err)
+	return handle
+}
+
+// dlerror returns the error of the last failed dlopenPath call.
+func dlerror() string {
+	return lastDLError.Error()
+}
+
+func dlclose(handle unsafe.Pointer) {
+	C.FreeLibrary(C.HMODULE(handle))
+}
+
+// libraryFile returns the file path of the DLL loaded at handle.
+func libraryFile(handle unsafe.Pointer) string {
+	var buf [C.MAX_PATH]uint16
+	n := C.GetModuleFileNameW(C.HMODULE(handle), (*C.WCHAR)(unsafe.Pointer(&buf[0])), C.DWORD(len(buf)))
+	if n == 0 || int(n) >= len(buf) {
+		return ""
+	}
+	return syscall.UTF16ToString(buf[:n])
+}
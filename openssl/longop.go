@@ -0,0 +1,175 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Some operations, such as RSA key generation or decryption with large
+// RSA keys, run in OpenSSL for milliseconds to seconds. The Go runtime
+// hands the P of a goroutine blocked in such a cgo call to a new thread,
+// so a burst of them piles up threads burning CPU next to the ones
+// running goroutines. The cgo calls of these operations are therefore
+// run by a bounded pool of worker goroutines locked to their threads,
+// see InitOptions.LongOperationWorkers. Callers queue for a free worker.
+//
+// Only the cgo calls doing the long computation are dispatched, never
+// code that could dispatch again, so that workers can't wait on each
+// other. The function run by a worker must drain the errors it leaves
+// in the OpenSSL error queue, which belongs to the thread of the worker.
+// The other per-thread state, the count of unapproved services of
+// FIPSApproved and the last missing function called, is handed back
+// to the thread of the caller.
+
+// longRSABits is the size from which RSA private key
+// operations are run by the workers.
+const longRSABits = 4096
+
+var longOps struct {
+	// workers is set by initLibrary,
+	// see InitOptions.LongOperationWorkers.
+	workers int
+	once    sync.Once
+	jobs    chan *longJob
+
+	queued    int32
+	busy      int32
+	completed uint64
+	// inline is non-zero while long operations must run on the
+	// calling goroutine, such as while a generator bound to a
+	// thread is in use.
+	inline int32
+}
+
+type longJob struct {
+	f func()
+	// start is the time the job was queued
+	// if Metrics are set, as in metricsStart.
+	start    time.Time
+	panicked interface{}
+	done     chan struct{}
+	// unapproved, missing and missingNote are the per-thread state
+	// f left on the thread of the worker.
+	unapproved  C.int
+	missing     *C.char
+	missingNote *C.char
+}
+
+// runLong runs f, which calls into OpenSSL for a long time, on a worker
+// and returns once it is done. A panic of f is raised again by runLong.
+// f runs on the calling goroutine if workers are disabled.
+func runLong(f func()) {
+	if longOps.workers <= 0 || atomic.LoadInt32(&longOps.inline) != 0 {
+		f()
+		return
+	}
+	longOps.once.Do(startLongWorkers)
+	j := &longJob{f: f, start: metricsStart(), done: make(chan struct{})}
+	atomic.AddInt32(&longOps.queued, 1)
+	longOps.jobs <- j
+	<-j.done
+	C.go_openssl_add_unapproved(j.unapproved)
+	if j.missing != nil {
+		C.go_openssl_missing_function(j.missing, j.missingNote)
+	}
+	if j.panicked != nil {
+		panic(j.panicked)
+	}
+}
+
+func startLongWorkers() {
+	longOps.jobs = make(chan *longJob)
+	for i := 0; i < longOps.workers; i++ {
+		go longWorker()
+	}
+}
+
+func longWorker() {
+	// The thread is dedicated to the worker, which never exits.
+	runtime.LockOSThread()
+	for j := range longOps.jobs {
+		atomic.AddInt32(&longOps.queued, -1)
+		atomic.AddInt32(&longOps.busy, 1)
+		observe(MetricQueue, j.start, 0, nil)
+		j.run()
+		atomic.AddInt32(&longOps.busy, -1)
+		atomic.AddUint64(&longOps.completed, 1)
+		close(j.done)
+	}
+}
+
+func (j *longJob) run() {
+	defer func() {
+		j.panicked = recover()
+	}()
+	// Don't let f report the errors of another operation.
+	C.go_openssl_ERR_clear_error()
+	C.go_openssl_reset_unapproved()
+	C.go_openssl_take_missing_function(&j.missingNote)
+	j.f()
+	j.unapproved = C.go_openssl_unapproved()
+	j.missing = C.go_openssl_take_missing_function(&j.missingNote)
+}
+
+// longKeygens are the algorithms whose key generation, including the
+// generation of their parameters if none are given, is run by the workers.
+var longKeygens = [...]string{"RSA", "RSA-PSS", "DH", "DHX", "DSA"}
+
+func isLongKeygen(name string) bool {
+	for _, alg := range longKeygens {
+		if strings.EqualFold(name, alg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLongRSAKey reports whether the private key operations
+// of the RSA key of withKey are run by the workers.
+func isLongRSAKey(withKey withKeyFunc) bool {
+	if longOps.workers <= 0 || atomic.LoadInt32(&longOps.inline) != 0 {
+		return false
+	}
+	return withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_EVP_PKEY_get_bits(pkey)
+	}) >= longRSABits
+}
+
+// LongOperationStats describes the workers running the long operations
+// of this package, see InitOptions.LongOperationWorkers.
+type LongOperationStats struct {
+	// Workers is the number of workers, 0 if they are disabled.
+	Workers int
+	// Busy is the number of workers running an operation.
+	Busy int
+	// Queued is the number of operations waiting for a worker.
+	// The time they wait is reported to the Metrics as MetricQueue.
+	Queued int
+	// Completed is the number of operations run by the workers so far.
+	Completed uint64
+}
+
+// LongOperations returns the current state of the workers
+// running long operations.
+func LongOperations() LongOperationStats {
+	s := LongOperationStats{
+		Busy:      int(atomic.LoadInt32(&longOps.busy)),
+		Queued:    int(atomic.LoadInt32(&longOps.queued)),
+		Completed: atomic.LoadUint64(&longOps.completed),
+	}
+	if longOps.workers > 0 {
+		s.Workers = longOps.workers
+	}
+	return s
+}
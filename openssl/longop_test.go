@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunLong(t *testing.T) {
+	if longOps.workers <= 0 {
+		t.Skip("long operation workers are disabled")
+	}
+	before := LongOperations().Completed
+	var running, max int32
+	var wg sync.WaitGroup
+	n := 4 * longOps.workers
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runLong(func() {
+				r := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if r <= m || atomic.CompareAndSwapInt32(&max, m, r) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+	wg.Wait()
+	if int(max) > longOps.workers {
+		t.Errorf("%d operations ran at once, want at most %d", max, longOps.workers)
+	}
+	s := LongOperations()
+	if s.Workers != longOps.workers || s.Completed-before < uint64(n) {
+		t.Errorf("LongOperations() = %+v, want %d workers and %d more completed operations", s, longOps.workers, n)
+	}
+}
+
+func TestRunLongPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want boom", r)
+		}
+	}()
+	runLong(func() { panic("boom") })
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of operations reported to Metrics.
+const (
+	MetricKeygen  = "keygen"
+	MetricSign    = "sign"
+	MetricVerify  = "verify"
+	MetricEncrypt = "encrypt"
+	MetricDecrypt = "decrypt"
+	MetricSeal    = "seal"
+	MetricOpen    = "open"
+	MetricHash    = "hash"
+	MetricRand    = "rand"
+	// MetricQueue is the time long operations, such as RSA key
+	// generation, wait for a worker, see LongOperations.
+	MetricQueue = "queue"
+)
+
+// Metrics receives measurements of the operations of this package,
+// so that they can be exported to a monitoring system, such as
+// Prometheus counters and histograms. See SetMetrics.
+type Metrics interface {
+	// Observe is called after each operation of kind, one of the
+	// Metric constants, with its duration and its error, nil on
+	// success. MetricHash operations are only reported to Add.
+	Observe(kind string, d time.Duration, err error)
+	// Add is called with the number of bytes processed by an
+	// operation of kind, such as the bytes written to a hash,
+	// sealed or opened by an AEAD, or read from RandReader.
+	Add(kind string, n int)
+}
+
+// metricsState is stored in metrics, which can't hold a nil Metrics.
+type metricsState struct {
+	m Metrics
+}
+
+var (
+	// metricsEnabled is non-zero when Metrics are set.
+	metricsEnabled int32
+	// metrics holds the metricsState set with SetMetrics.
+	metrics atomic.Value
+)
+
+// SetMetrics registers m to receive the measurements of the
+// operations of this package. A nil m removes it.
+//
+// m may be called concurrently from any goroutine, on the hot path of
+// the operations, and must not call back into this package.
+func SetMetrics(m Metrics) {
+	metrics.Store(metricsState{m})
+	var enabled int32
+	if m != nil {
+		enabled = 1
+	}
+	atomic.StoreInt32(&metricsEnabled, enabled)
+}
+
+// metricsStart returns the start time of an operation
+// if Metrics are set, or the zero Time otherwise.
+func metricsStart() time.Time {
+	if atomic.LoadInt32(&metricsEnabled) == 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe reports the result err of an operation of kind,
+// started at start and processing n bytes, to the Metrics.
+// It does nothing if start is zero.
+func observe(kind string, start time.Time, n int, err error) {
+	if start.IsZero() {
+		return
+	}
+	if s, _ := metrics.Load().(metricsState); s.m != nil {
+		s.m.Observe(kind, time.Since(start), err)
+		if n > 0 {
+			s.m.Add(kind, n)
+		}
+	}
+}
+
+// addBytes reports n bytes processed by an operation of kind.
+func addBytes(kind string, n int) {
+	if atomic.LoadInt32(&metricsEnabled) == 0 || n == 0 {
+		return
+	}
+	if s, _ := metrics.Load().(metricsState); s.m != nil {
+		s.m.Add(kind, n)
+	}
+}
+
+// ErrorReason returns a short description of the reason of err, to
+// label failures in Metrics: "verification", "decryption",
+// "unsupported", "fips-unapproved" or "no-entropy" if err matches the
+// corresponding sentinel error, such as ErrVerification, otherwise the
+// ReasonString of an *Error, if any, and "unknown" otherwise. It
+// returns "" for a nil err.
+func ErrorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, r := range []struct {
+		err    error
+		reason string
+	}{
+		{ErrVerification, "verification"},
+		{ErrDecryption, "decryption"},
+		{ErrUnsupported, "unsupported"},
+		{ErrFIPSUnapproved, "fips-unapproved"},
+		{ErrNoEntropy, "no-entropy"},
+	} {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	var e *Error
+	if errors.As(err, &e) && e.ReasonString != "" {
+		return e.ReasonString
+	}
+	return "unknown"
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"crypto/cipher"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+type testMetrics struct {
+	mu       sync.Mutex
+	ops      map[string]int
+	failures map[string]string
+	bytes    map[string]int
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{ops: map[string]int{}, failures: map[string]string{}, bytes: map[string]int{}}
+}
+
+func (m *testMetrics) Observe(kind string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops[kind]++
+	if err != nil {
+		m.failures[kind] = openssl.ErrorReason(err)
+	}
+}
+
+func (m *testMetrics) Add(kind string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes[kind] += n
+}
+
+func TestMetrics(t *testing.T) {
+	priv, pub := newRSAKey(t, 2048)
+	m := newTestMetrics()
+	openssl.SetMetrics(m)
+	defer openssl.SetMetrics(nil)
+
+	hashed := openssl.SHA256([]byte("message"))
+	sig, err := openssl.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := openssl.VerifyRSAPKCS1v15(pub, crypto.SHA256, hashed[:], sig); err != nil {
+		t.Fatal(err)
+	}
+	sig[0] ^= 1
+	if err := openssl.VerifyRSAPKCS1v15(pub, crypto.SHA256, hashed[:], sig); err == nil {
+		t.Fatal("VerifyRSAPKCS1v15 succeeded with a corrupted signature")
+	}
+	c, err := openssl.NewAESCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := c.(interface {
+		NewGCM(nonceSize, tagSize int) (cipher.AEAD, error)
+	}).NewGCM(12, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	ciphertext := gcm.Seal(nil, nonce, make([]byte, 100), nil)
+	if _, err := gcm.Open(nil, nonce, ciphertext, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openssl.RandReader.Read(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for kind, want := range map[string]int{
+		openssl.MetricSign:   1,
+		openssl.MetricVerify: 2,
+		openssl.MetricSeal:   1,
+		openssl.MetricOpen:   1,
+		openssl.MetricRand:   1,
+	} {
+		if m.ops[kind] != want {
+			t.Errorf("%s operations = %d, want %d", kind, m.ops[kind], want)
+		}
+	}
+	if got := m.failures[openssl.MetricVerify]; got != "verification" {
+		t.Errorf("verify failure reason = %q, want %q", got, "verification")
+	}
+	for kind, want := range map[string]int{
+		openssl.MetricHash: len("message"),
+		openssl.MetricSeal: 100,
+		openssl.MetricOpen: 100 + gcm.Overhead(),
+		openssl.MetricRand: 32,
+	} {
+		if m.bytes[kind] != want {
+			t.Errorf("%s bytes = %d, want %d", kind, m.bytes[kind], want)
+		}
+	}
+}
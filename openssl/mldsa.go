@@ -0,0 +1,181 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "errors"
+
+// ML-DSA parameter sets, as named in FIPS 204.
+const (
+	MLDSA44 = "ML-DSA-44"
+	MLDSA65 = "ML-DSA-65"
+	MLDSA87 = "ML-DSA-87"
+)
+
+// SeedSizeMLDSA is the size of the seed ML-DSA private keys are derived from.
+const SeedSizeMLDSA = 32
+
+// maxSignatureContext is the maximum size of the context string
+// of ML-DSA and SLH-DSA signatures.
+const maxSignatureContext = 255
+
+func checkMLDSAParams(params string) error {
+	switch params {
+	case MLDSA44, MLDSA65, MLDSA87:
+		return nil
+	}
+	return errors.New("openssl: unsupported ML-DSA parameter set " + params)
+}
+
+// PrivateKeyMLDSA is an ML-DSA private key.
+type PrivateKeyMLDSA struct {
+	params string
+	pkey   *PKey
+}
+
+// PublicKeyMLDSA is an ML-DSA public key.
+type PublicKeyMLDSA struct {
+	params string
+	pkey   *PKey
+}
+
+// GenerateKeyMLDSA generates a private key for the ML-DSA
+// parameter set params, one of MLDSA44, MLDSA65 or MLDSA87.
+//
+// ML-DSA is natively supported since OpenSSL 3.5, and on earlier
+// versions of OpenSSL 3 when a provider implementing it is loaded.
+func GenerateKeyMLDSA(params string) (*PrivateKeyMLDSA, error) {
+	if err := checkMLDSAParams(params); err != nil {
+		return nil, err
+	}
+	k, err := GenerateKey(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeyMLDSA{params: params, pkey: k}, nil
+}
+
+// NewPrivateKeyMLDSA derives the private key for the ML-DSA
+// parameter set params from the 32-byte seed, as returned by Seed.
+func NewPrivateKeyMLDSA(params string, seed []byte) (*PrivateKeyMLDSA, error) {
+	if err := checkMLDSAParams(params); err != nil {
+		return nil, err
+	}
+	if len(seed) != SeedSizeMLDSA {
+		return nil, errors.New("openssl: invalid ML-DSA seed size")
+	}
+	k, err := GenerateKey(params, map[string]interface{}{"seed": seed})
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeyMLDSA{params: params, pkey: k}, nil
+}
+
+// NewPublicKeyMLDSA parses a public key for the ML-DSA
+// parameter set params, encoded as specified in FIPS 204.
+func NewPublicKeyMLDSA(params string, b []byte) (*PublicKeyMLDSA, error) {
+	if err := checkMLDSAParams(params); err != nil {
+		return nil, err
+	}
+	k, err := NewPublicPKey(params, b)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeyMLDSA{params: params, pkey: k}, nil
+}
+
+// Params returns the ML-DSA parameter set of the key.
+func (priv *PrivateKeyMLDSA) Params() string { return priv.params }
+
+// Seed returns the seed the key was derived from.
+// It is the recommended storage format for private keys.
+func (priv *PrivateKeyMLDSA) Seed() ([]byte, error) {
+	return priv.pkey.octetParam("seed")
+}
+
+// Bytes returns the expanded private key, encoded as specified in FIPS 204.
+func (priv *PrivateKeyMLDSA) Bytes() ([]byte, error) {
+	return priv.pkey.PrivateKeyBytes()
+}
+
+// PublicKey returns the public key corresponding to priv.
+func (priv *PrivateKeyMLDSA) PublicKey() (*PublicKeyMLDSA, error) {
+	b, err := priv.pkey.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKeyMLDSA(priv.params, b)
+}
+
+// Params returns the ML-DSA parameter set of the key.
+func (pub *PublicKeyMLDSA) Params() string { return pub.params }
+
+// Bytes returns the public key, encoded as specified in FIPS 204.
+func (pub *PublicKeyMLDSA) Bytes() ([]byte, error) {
+	return pub.pkey.PublicKeyBytes()
+}
+
+// SignMLDSA signs msg with priv using the hedged variant of ML-DSA.
+// context is the optional context string of FIPS 204, at most
+// 255 bytes long, which binds the signature to an application domain.
+func SignMLDSA(priv *PrivateKeyMLDSA, msg, context []byte) ([]byte, error) {
+	return signWithContext(priv.pkey, msg, context)
+}
+
+// VerifyMLDSA verifies that sig is a valid ML-DSA signature
+// of msg by pub with the context string context.
+func VerifyMLDSA(pub *PublicKeyMLDSA, msg, sig, context []byte) error {
+	return verifyWithContext(pub.pkey, msg, sig, context)
+}
+
+// contextParams returns the parameters setting the signature
+// context string to context, or nil if context is empty.
+// The caller must free the result using OSSL_PARAM_free.
+func contextParams(context []byte) (*C.OSSL_PARAM, error) {
+	if len(context) > maxSignatureContext {
+		return nil, errors.New("openssl: signature context string too long")
+	}
+	if len(context) == 0 {
+		return nil, nil
+	}
+	bld, err := newParamBuilder()
+	if err != nil {
+		return nil, err
+	}
+	defer bld.free()
+	bld.addOctetString("context-string", context)
+	return bld.build()
+}
+
+func signWithContext(k *PKey, msg, context []byte) ([]byte, error) {
+	params, err := contextParams(context)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		defer C.go_openssl_OSSL_PARAM_free(params)
+	}
+	return k.sign(msg, "", params)
+}
+
+func verifyWithContext(k *PKey, msg, sig, context []byte) error {
+	params, err := contextParams(context)
+	if err != nil {
+		return err
+	}
+	if params != nil {
+		defer C.go_openssl_OSSL_PARAM_free(params)
+	}
+	return k.verify(msg, sig, "", params)
+}
+
+// Close frees the native key of priv, see InitOptions.ExplicitClose.
+func (priv *PrivateKeyMLDSA) Close() error { return priv.pkey.Close() }
+
+// Close frees the native key of pub, see InitOptions.ExplicitClose.
+func (pub *PublicKeyMLDSA) Close() error { return pub.pkey.Close() }
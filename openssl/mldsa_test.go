@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMLDSA(t *testing.T) {
+	if _, err := GenerateKeyMLDSA("ML-DSA-99"); err == nil {
+		t.Error("expected error for unknown parameter set")
+	}
+	msg := []byte("hello world")
+	context := []byte("go-crypto-openssl test")
+	for _, params := range []string{MLDSA44, MLDSA65, MLDSA87} {
+		t.Run(params, func(t *testing.T) {
+			priv, err := GenerateKeyMLDSA(params)
+			if err != nil {
+				t.Skipf("ML-DSA not supported: %v", err)
+			}
+			pub, err := priv.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := SignMLDSA(priv, msg, context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyMLDSA(pub, msg, sig, context); err != nil {
+				t.Error(err)
+			}
+			if err := VerifyMLDSA(pub, msg, sig, nil); err == nil {
+				t.Error("signature verified with a different context")
+			}
+			if _, err := SignMLDSA(priv, msg, make([]byte, 256)); err == nil {
+				t.Error("expected error for too long context")
+			}
+
+			seed, err := priv.Seed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			priv2, err := NewPrivateKeyMLDSA(params, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub2, err := priv2.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b1, _ := pub.Bytes()
+			b2, _ := pub2.Bytes()
+			if !bytes.Equal(b1, b2) {
+				t.Error("key derived from seed has a different public key")
+			}
+		})
+	}
+}
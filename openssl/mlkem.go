@@ -0,0 +1,141 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "errors"
+
+// ML-KEM parameter sets, as named in FIPS 203.
+const (
+	MLKEM512  = "ML-KEM-512"
+	MLKEM768  = "ML-KEM-768"
+	MLKEM1024 = "ML-KEM-1024"
+)
+
+const (
+	// SharedKeySizeMLKEM is the size of the shared secret of all
+	// the ML-KEM parameter sets.
+	SharedKeySizeMLKEM = 32
+	// SeedSizeMLKEM is the size of the (d, z) seed ML-KEM
+	// decapsulation keys are derived from.
+	SeedSizeMLKEM = 64
+)
+
+func checkMLKEMParams(params string) error {
+	switch params {
+	case MLKEM512, MLKEM768, MLKEM1024:
+		return nil
+	}
+	return errors.New("openssl: unsupported ML-KEM parameter set " + params)
+}
+
+// DecapsulationKeyMLKEM is an ML-KEM decapsulation (private) key.
+type DecapsulationKeyMLKEM struct {
+	params string
+	pkey   *PKey
+}
+
+// EncapsulationKeyMLKEM is an ML-KEM encapsulation (public) key.
+type EncapsulationKeyMLKEM struct {
+	params string
+	pkey   *PKey
+}
+
+// GenerateKeyMLKEM generates a decapsulation key for the ML-KEM
+// parameter set params, one of MLKEM512, MLKEM768 or MLKEM1024.
+//
+// ML-KEM is natively supported since OpenSSL 3.5, and on earlier
+// versions of OpenSSL 3 when a provider implementing it is loaded.
+func GenerateKeyMLKEM(params string) (*DecapsulationKeyMLKEM, error) {
+	if err := checkMLKEMParams(params); err != nil {
+		return nil, err
+	}
+	k, err := GenerateKey(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DecapsulationKeyMLKEM{params: params, pkey: k}, nil
+}
+
+// NewDecapsulationKeyMLKEM derives the decapsulation key for the ML-KEM
+// parameter set params from seed, the 64-byte concatenation
+// of d and z in FIPS 203, as returned by Seed.
+func NewDecapsulationKeyMLKEM(params string, seed []byte) (*DecapsulationKeyMLKEM, error) {
+	if err := checkMLKEMParams(params); err != nil {
+		return nil, err
+	}
+	if len(seed) != SeedSizeMLKEM {
+		return nil, errors.New("openssl: invalid ML-KEM seed size")
+	}
+	k, err := GenerateKey(params, map[string]interface{}{"seed": seed})
+	if err != nil {
+		return nil, err
+	}
+	return &DecapsulationKeyMLKEM{params: params, pkey: k}, nil
+}
+
+// NewEncapsulationKeyMLKEM parses an encapsulation key for the ML-KEM
+// parameter set params, encoded as specified in FIPS 203.
+func NewEncapsulationKeyMLKEM(params string, b []byte) (*EncapsulationKeyMLKEM, error) {
+	if err := checkMLKEMParams(params); err != nil {
+		return nil, err
+	}
+	k, err := NewPublicPKey(params, b)
+	if err != nil {
+		return nil, err
+	}
+	return &EncapsulationKeyMLKEM{params: params, pkey: k}, nil
+}
+
+// Params returns the ML-KEM parameter set of the key.
+func (dk *DecapsulationKeyMLKEM) Params() string { return dk.params }
+
+// Seed returns the 64-byte seed the key was derived from.
+// It is the recommended storage format for decapsulation keys.
+func (dk *DecapsulationKeyMLKEM) Seed() ([]byte, error) {
+	return dk.pkey.octetParam("seed")
+}
+
+// Bytes returns the expanded decapsulation key, encoded as specified in FIPS 203.
+func (dk *DecapsulationKeyMLKEM) Bytes() ([]byte, error) {
+	return dk.pkey.PrivateKeyBytes()
+}
+
+// EncapsulationKey returns the encapsulation key corresponding to dk.
+func (dk *DecapsulationKeyMLKEM) EncapsulationKey() (*EncapsulationKeyMLKEM, error) {
+	b, err := dk.pkey.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewEncapsulationKeyMLKEM(dk.params, b)
+}
+
+// Decapsulate returns the shared key encapsulated in ciphertext.
+// As specified by ML-KEM, an invalid ciphertext of the right size
+// results in a pseudo-random shared key rather than an error.
+func (dk *DecapsulationKeyMLKEM) Decapsulate(ciphertext []byte) ([]byte, error) {
+	return dk.pkey.Decapsulate(ciphertext, nil)
+}
+
+// Params returns the ML-KEM parameter set of the key.
+func (ek *EncapsulationKeyMLKEM) Params() string { return ek.params }
+
+// Bytes returns the encapsulation key, encoded as specified in FIPS 203.
+func (ek *EncapsulationKeyMLKEM) Bytes() ([]byte, error) {
+	return ek.pkey.PublicKeyBytes()
+}
+
+// Encapsulate generates a shared key and the ciphertext that
+// encapsulates it for the owner of the decapsulation key.
+func (ek *EncapsulationKeyMLKEM) Encapsulate() (ciphertext, sharedKey []byte, err error) {
+	return ek.pkey.Encapsulate(nil)
+}
+
+// Close frees the native key of dk, see InitOptions.ExplicitClose.
+func (dk *DecapsulationKeyMLKEM) Close() error { return dk.pkey.Close() }
+
+// Close frees the native key of ek, see InitOptions.ExplicitClose.
+func (ek *EncapsulationKeyMLKEM) Close() error { return ek.pkey.Close() }
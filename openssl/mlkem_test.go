@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMLKEM(t *testing.T) {
+	if _, err := GenerateKeyMLKEM("ML-KEM-2048"); err == nil {
+		t.Error("expected error for unknown parameter set")
+	}
+	sizes := map[string]struct{ ek, ct int }{
+		MLKEM512:  {800, 768},
+		MLKEM768:  {1184, 1088},
+		MLKEM1024: {1568, 1568},
+	}
+	for _, params := range []string{MLKEM512, MLKEM768, MLKEM1024} {
+		t.Run(params, func(t *testing.T) {
+			dk, err := GenerateKeyMLKEM(params)
+			if err != nil {
+				t.Skipf("ML-KEM not supported: %v", err)
+			}
+			ek, err := dk.EncapsulationKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ekBytes, err := ek.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ekBytes) != sizes[params].ek {
+				t.Errorf("got %d-byte encapsulation key, want %d", len(ekBytes), sizes[params].ek)
+			}
+			ct, shared, err := ek.Encapsulate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ct) != sizes[params].ct || len(shared) != SharedKeySizeMLKEM {
+				t.Errorf("got %d-byte ciphertext and %d-byte shared key", len(ct), len(shared))
+			}
+			got, err := dk.Decapsulate(ct)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, shared) {
+				t.Errorf("got shared key %x, want %x", got, shared)
+			}
+
+			seed, err := dk.Seed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			dk2, err := NewDecapsulationKeyMLKEM(params, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := dk2.Decapsulate(ct); err != nil || !bytes.Equal(got, shared) {
+				t.Errorf("key derived from seed: got shared key %x, %v, want %x", got, err, shared)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"crypto/cipher"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+const (
+	aesBlockSize         = 16
+	gcmTagSize           = 16
+	gcmStandardNonceSize = 12
+
+	_EVP_CTRL_GCM_GET_TAG = 0x10
+	_EVP_CTRL_GCM_SET_TAG = 0x11
+)
+
+var errOpen = errors.New("cipher: message authentication failed")
+
+// NewAESCipher returns an AES block of the AES-128, 192 or 256 key key.
+// The block has a NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) method.
+func NewAESCipher(key []byte) (cipher.Block, error) {
+	c := &aesCipher{key: make([]byte, len(key))}
+	copy(c.key, key)
+	var ecb func() uintptr
+	switch len(c.key) * 8 {
+	case 128:
+		ecb, c.gcm = _EVP_aes_128_ecb, _EVP_aes_128_gcm
+	case 192:
+		ecb, c.gcm = _EVP_aes_192_ecb, _EVP_aes_192_gcm
+	case 256:
+		ecb, c.gcm = _EVP_aes_256_ecb, _EVP_aes_256_gcm
+	default:
+		return nil, errors.New("crypto/cipher: Invalid key size")
+	}
+	runtime.SetFinalizer(c, (*aesCipher).finalize)
+	var err error
+	if c.enc, err = newCipherCtx(ecb(), c.key, nil, 1); err != nil {
+		return nil, err
+	}
+	if c.dec, err = newCipherCtx(ecb(), c.key, nil, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newCipherCtx returns an EVP_CIPHER_CTX of cipher, initialized
+// with key and iv for encryption if enc is 1 and decryption if 0.
+func newCipherCtx(cipher uintptr, key, iv []byte, enc int32) (uintptr, error) {
+	ctx := _EVP_CIPHER_CTX_new()
+	if ctx == 0 {
+		return 0, newOpenSSLError("EVP_CIPHER_CTX_new")
+	}
+	if _EVP_CipherInit_ex(ctx, cipher, 0, base(key), base(iv), enc) != 1 {
+		_EVP_CIPHER_CTX_free(ctx)
+		return 0, newOpenSSLError("EVP_CipherInit_ex")
+	}
+	if _EVP_CIPHER_CTX_set_padding(ctx, 0) != 1 {
+		_EVP_CIPHER_CTX_free(ctx)
+		return 0, newOpenSSLError("EVP_CIPHER_CTX_set_padding")
+	}
+	return ctx, nil
+}
+
+type aesCipher struct {
+	key []byte
+	gcm func() uintptr
+	// mu serializes the uses of the ECB contexts enc and dec.
+	mu       sync.Mutex
+	enc, dec uintptr
+}
+
+func (c *aesCipher) finalize() {
+	if c.enc != 0 {
+		_EVP_CIPHER_CTX_free(c.enc)
+	}
+	if c.dec != 0 {
+		_EVP_CIPHER_CTX_free(c.dec)
+	}
+}
+
+func (c *aesCipher) BlockSize() int { return aesBlockSize }
+
+func (c *aesCipher) Encrypt(dst, src []byte) {
+	c.crypt(c.enc, dst, src)
+}
+
+func (c *aesCipher) Decrypt(dst, src []byte) {
+	c.crypt(c.dec, dst, src)
+}
+
+func (c *aesCipher) crypt(ctx uintptr, dst, src []byte) {
+	if len(src) < aesBlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < aesBlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var outl int32
+	if _EVP_CipherUpdate(ctx, &dst[0], &outl, &src[0], aesBlockSize) != 1 {
+		panic(newOpenSSLError("EVP_CipherUpdate"))
+	}
+	runtime.KeepAlive(c)
+}
+
+type noGCM struct {
+	cipher.Block
+}
+
+// NewGCM returns the AES-GCM mode of c. Like the tag and nonce sizes
+// of the GCM of the openssl package, non-standard sizes are
+// implemented by the standard library with the AES block of c.
+func (c *aesCipher) NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) {
+	if nonceSize != gcmStandardNonceSize && tagSize != gcmTagSize {
+		return nil, errors.New("crypto/aes: GCM tag and nonce sizes can't be non-standard at the same time")
+	}
+	// Fall back to standard library for GCM with non-standard nonce or tag size.
+	if nonceSize != gcmStandardNonceSize {
+		return cipher.NewGCMWithNonceSize(&noGCM{c}, nonceSize)
+	}
+	if tagSize != gcmTagSize {
+		return cipher.NewGCMWithTagSize(&noGCM{c}, tagSize)
+	}
+	return &aesGCM{c: c}, nil
+}
+
+// aesGCM implements cipher.AEAD with a new EVP_CIPHER_CTX per operation.
+type aesGCM struct {
+	c *aesCipher
+}
+
+func (g *aesGCM) NonceSize() int { return gcmStandardNonceSize }
+func (g *aesGCM) Overhead() int  { return gcmTagSize }
+
+func (g *aesGCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmStandardNonceSize {
+		panic("cipher: incorrect nonce length given to GCM")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmTagSize)
+	ctx, err := newCipherCtx(g.c.gcm(), g.c.key, nonce, 1)
+	if err != nil {
+		panic(err)
+	}
+	defer _EVP_CIPHER_CTX_free(ctx)
+	var outl int32
+	if len(additionalData) > 0 && _EVP_CipherUpdate(ctx, nil, &outl, &additionalData[0], int32(len(additionalData))) != 1 {
+		panic(newOpenSSLError("EVP_CipherUpdate"))
+	}
+	if len(plaintext) > 0 && _EVP_CipherUpdate(ctx, &out[0], &outl, &plaintext[0], int32(len(plaintext))) != 1 {
+		panic(newOpenSSLError("EVP_CipherUpdate"))
+	}
+	tag := out[len(plaintext):]
+	// GCM outputs no data when finalized.
+	if _EVP_CipherFinal_ex(ctx, &tag[0], &outl) != 1 {
+		panic(newOpenSSLError("EVP_CipherFinal_ex"))
+	}
+	if _EVP_CIPHER_CTX_ctrl(ctx, _EVP_CTRL_GCM_GET_TAG, gcmTagSize, &tag[0]) != 1 {
+		panic(newOpenSSLError("EVP_CIPHER_CTX_ctrl"))
+	}
+	runtime.KeepAlive(g.c)
+	return ret
+}
+
+func (g *aesGCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmStandardNonceSize {
+		panic("cipher: incorrect nonce length given to GCM")
+	}
+	if len(ciphertext) < gcmTagSize {
+		return nil, errOpen
+	}
+	tag := make([]byte, gcmTagSize)
+	copy(tag, ciphertext[len(ciphertext)-gcmTagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-gcmTagSize]
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	ctx, err := newCipherCtx(g.c.gcm(), g.c.key, nonce, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer _EVP_CIPHER_CTX_free(ctx)
+	if _EVP_CIPHER_CTX_ctrl(ctx, _EVP_CTRL_GCM_SET_TAG, gcmTagSize, &tag[0]) != 1 {
+		return nil, newOpenSSLError("EVP_CIPHER_CTX_ctrl")
+	}
+	var outl int32
+	ok := len(additionalData) == 0 || _EVP_CipherUpdate(ctx, nil, &outl, &additionalData[0], int32(len(additionalData))) == 1
+	ok = ok && (len(ciphertext) == 0 || _EVP_CipherUpdate(ctx, &out[0], &outl, &ciphertext[0], int32(len(ciphertext))) == 1)
+	// A tag mismatch makes EVP_CipherFinal_ex fail.
+	ok = ok && _EVP_CipherFinal_ex(ctx, &tag[0], &outl) == 1
+	runtime.KeepAlive(g.c)
+	if !ok {
+		_ERR_clear_error()
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	return ret, nil
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
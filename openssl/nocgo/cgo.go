@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build cgo && !openssl_purego && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"crypto/cipher"
+	"hash"
+	"io"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// InitWithOptions is like Init but configured by opts, see openssl.InitWithOptions.
+func InitWithOptions(opts InitOptions) error {
+	return openssl.InitWithOptions(openssl.InitOptions{
+		NoLoadConfig: opts.NoLoadConfig,
+		LibraryPath:  opts.LibraryPath,
+	})
+}
+
+// VersionText returns the version text of the OpenSSL currently loaded.
+func VersionText() string { return openssl.VersionText() }
+
+// FIPS returns true if OpenSSL is running in FIPS mode, else returns false.
+func FIPS() bool { return openssl.FIPS() }
+
+// RandReader is a reader of random numbers generated by OpenSSL.
+var RandReader io.Reader = openssl.RandReader
+
+// NewSHA1 returns a new SHA1 hash.
+func NewSHA1() hash.Hash { return openssl.NewSHA1() }
+
+// NewSHA224 returns a new SHA224 hash.
+func NewSHA224() hash.Hash { return openssl.NewSHA224() }
+
+// NewSHA256 returns a new SHA256 hash.
+func NewSHA256() hash.Hash { return openssl.NewSHA256() }
+
+// NewSHA384 returns a new SHA384 hash.
+func NewSHA384() hash.Hash { return openssl.NewSHA384() }
+
+// NewSHA512 returns a new SHA512 hash.
+func NewSHA512() hash.Hash { return openssl.NewSHA512() }
+
+// NewHMAC returns a new HMAC using OpenSSL.
+// The function h must return a hash of this package.
+// If h is not recognized, NewHMAC returns nil.
+func NewHMAC(h func() hash.Hash, key []byte) hash.Hash { return openssl.NewHMAC(h, key) }
+
+// NewAESCipher returns an AES block of the AES-128, 192 or 256 key key.
+// The block has a NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) method.
+func NewAESCipher(key []byte) (cipher.Block, error) { return openssl.NewAESCipher(key) }
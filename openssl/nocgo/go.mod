@@ -0,0 +1,10 @@
+module github.com/microsoft/go-crypto-openssl/openssl/nocgo
+
+go 1.25.0
+
+require (
+	github.com/ebitengine/purego v0.11.1
+	github.com/microsoft/go-crypto-openssl v0.2.5
+)
+
+replace github.com/microsoft/go-crypto-openssl => ../../
@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"hash"
+	"runtime"
+)
+
+// evpMaxMDSize is EVP_MAX_MD_SIZE.
+const evpMaxMDSize = 64
+
+// NewHMAC returns a new HMAC using OpenSSL.
+// The function h must return a hash of this package.
+// If h is not recognized, NewHMAC returns nil.
+func NewHMAC(h func() hash.Hash, key []byte) hash.Hash {
+	ch, ok := h().(*evpHash)
+	if !ok {
+		return nil
+	}
+	hkey := make([]byte, len(key))
+	copy(hkey, key)
+	if len(hkey) == 0 {
+		// HMAC_Init_ex reuses the key of the context when given none,
+		// and zero bytes are the same HMAC key as an empty one.
+		hkey = make([]byte, evpMaxMDSize)
+	}
+	ctx := _HMAC_CTX_new()
+	if ctx == 0 {
+		panic(newOpenSSLError("HMAC_CTX_new"))
+	}
+	hm := &hmacHash{ctx: ctx, size: ch.size, blockSize: ch.blockSize}
+	runtime.SetFinalizer(hm, (*hmacHash).finalize)
+	if _HMAC_Init_ex(ctx, &hkey[0], int32(len(hkey)), ch.md, 0) != 1 {
+		panic(newOpenSSLError("HMAC_Init_ex"))
+	}
+	return hm
+}
+
+// hmacHash implements hash.Hash with an HMAC_CTX, which OpenSSL 3
+// still provides although it is deprecated there.
+type hmacHash struct {
+	ctx       uintptr
+	size      int
+	blockSize int
+}
+
+func (h *hmacHash) finalize() {
+	_HMAC_CTX_free(h.ctx)
+}
+
+func (h *hmacHash) Reset() {
+	// Passing no key and no digest restarts with the ones of ctx.
+	if _HMAC_Init_ex(h.ctx, nil, 0, 0, 0) != 1 {
+		panic(newOpenSSLError("HMAC_Init_ex"))
+	}
+	runtime.KeepAlive(h)
+}
+
+func (h *hmacHash) Write(p []byte) (int, error) {
+	if len(p) > 0 && _HMAC_Update(h.ctx, &p[0], uint(len(p))) != 1 {
+		panic(newOpenSSLError("HMAC_Update"))
+	}
+	runtime.KeepAlive(h)
+	return len(p), nil
+}
+
+func (h *hmacHash) Size() int      { return h.size }
+func (h *hmacHash) BlockSize() int { return h.blockSize }
+
+func (h *hmacHash) Sum(in []byte) []byte {
+	// Finalize a copy so that h can still be written to.
+	ctx := _HMAC_CTX_new()
+	if ctx == 0 {
+		panic(newOpenSSLError("HMAC_CTX_new"))
+	}
+	defer _HMAC_CTX_free(ctx)
+	out := make([]byte, h.size)
+	if _HMAC_CTX_copy(ctx, h.ctx) != 1 || _HMAC_Final(ctx, &out[0], nil) != 1 {
+		panic(newOpenSSLError("HMAC_Final"))
+	}
+	runtime.KeepAlive(h)
+	return append(in, out...)
+}
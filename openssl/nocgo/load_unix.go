@@ -0,0 +1,29 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin)
+
+package nocgo
+
+import (
+	"runtime"
+
+	"github.com/ebitengine/purego"
+)
+
+// libraryNames returns the libcrypto shared library names
+// probed when no library is requested, newest first.
+func libraryNames() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{"libcrypto.3.dylib", "libcrypto.1.1.dylib"}
+	}
+	return []string{"libcrypto.so.3", "libcrypto.so.1.1", "libcrypto.so.11", "libcrypto.so.111"}
+}
+
+func dlopen(path string) (uintptr, error) {
+	return purego.Dlopen(path, purego.RTLD_LAZY|purego.RTLD_LOCAL)
+}
+
+func dlsym(handle uintptr, name string) (uintptr, error) {
+	return purego.Dlsym(handle, name)
+}
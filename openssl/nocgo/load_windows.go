@@ -0,0 +1,33 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && windows
+
+package nocgo
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// libraryNames returns the libcrypto DLL names
+// probed when no library is requested, newest first.
+func libraryNames() []string {
+	var arch string
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = "-x64"
+	case "arm64":
+		arch = "-arm64"
+	}
+	return []string{"libcrypto-3" + arch + ".dll", "libcrypto-1_1" + arch + ".dll"}
+}
+
+func dlopen(path string) (uintptr, error) {
+	h, err := syscall.LoadLibrary(path)
+	return uintptr(h), err
+}
+
+func dlsym(handle uintptr, name string) (uintptr, error) {
+	return syscall.GetProcAddress(syscall.Handle(handle), name)
+}
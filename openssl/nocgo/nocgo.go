@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// Package nocgo provides OpenSSL-backed hashes, HMAC, AES and random
+// numbers to programs that must build with CGO_ENABLED=0, for example
+// in cross-compilation pipelines.
+//
+// Without cgo, or with the openssl_purego build tag, libcrypto is loaded
+// with dlopen, or LoadLibrary on Windows, and its functions are called
+// through the trampolines of github.com/ebitengine/purego, which needs
+// neither cgo nor a C toolchain at build time. libcrypto is still
+// required at run time, OpenSSL 1.1 and 3 are supported. With cgo, and
+// without the openssl_purego tag, the functions of this package call
+// the ones of the openssl package instead.
+//
+// The package only covers a subset of the openssl package, whose API it
+// mirrors, and is a separate module so that the openssl module doesn't
+// depend on purego nor on the Go version purego requires.
+package nocgo
+
+// InitOptions configures how InitWithOptions initializes OpenSSL.
+// Its fields have the meaning of the same fields of openssl.InitOptions.
+type InitOptions struct {
+	// NoLoadConfig skips loading any OpenSSL configuration file.
+	NoLoadConfig bool
+	// LibraryPath, if not empty, is the file name or path of the
+	// libcrypto shared library to load instead of probing the
+	// well-known version suffixes. If empty, the
+	// GO_OPENSSL_LIBRARY_PATH environment variable is used.
+	LibraryPath string
+}
+
+// Init loads and initializes OpenSSL, and loads
+// the system OpenSSL configuration file.
+// It must be called before any other function of this package.
+func Init() error {
+	return InitWithOptions(InitOptions{})
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+
+package nocgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if err := Init(); err != nil {
+		panic(err)
+	}
+	fmt.Println("OpenSSL version:", VersionText())
+	fmt.Println("FIPS enabled:", FIPS())
+	os.Exit(m.Run())
+}
+
+var hashes = []struct {
+	name string
+	fn   func() hash.Hash
+	std  func() hash.Hash
+}{
+	{"SHA1", NewSHA1, sha1.New},
+	{"SHA224", NewSHA224, sha256.New224},
+	{"SHA256", NewSHA256, sha256.New},
+	{"SHA384", NewSHA384, sha512.New384},
+	{"SHA512", NewSHA512, sha512.New},
+}
+
+func TestSHA(t *testing.T) {
+	msg := []byte("testing")
+	for _, tt := range hashes {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h, want := tt.fn(), tt.std()
+			if h.Size() != want.Size() || h.BlockSize() != want.BlockSize() {
+				t.Errorf("got sizes %d and %d, want %d and %d", h.Size(), h.BlockSize(), want.Size(), want.BlockSize())
+			}
+			h.Write(msg)
+			want.Write(msg)
+			// Sum doesn't change the state.
+			h.Sum(nil)
+			h.Write(msg)
+			want.Write(msg)
+			if got, want := h.Sum(nil), want.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("got %x, want %x", got, want)
+			}
+			h.Reset()
+			want.Reset()
+			if got, want := h.Sum([]byte("prefix")), want.Sum([]byte("prefix")); !bytes.Equal(got, want) {
+				t.Errorf("after Reset: got %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	msg := []byte("testing")
+	for _, tt := range hashes {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range [][]byte{nil, []byte("key"), bytes.Repeat([]byte("k"), 200)} {
+				h, want := NewHMAC(tt.fn, key), hmac.New(tt.std, key)
+				h.Write(msg)
+				want.Write(msg)
+				if got, want := h.Sum(nil), want.Sum(nil); !bytes.Equal(got, want) {
+					t.Errorf("key %q: got %x, want %x", key, got, want)
+				}
+				h.Reset()
+				want.Reset()
+				h.Write(msg[:3])
+				want.Write(msg[:3])
+				if got, want := h.Sum(nil), want.Sum(nil); !bytes.Equal(got, want) {
+					t.Errorf("key %q after Reset: got %x, want %x", key, got, want)
+				}
+			}
+		})
+	}
+	if h := NewHMAC(sha256.New, nil); h != nil {
+		t.Error("NewHMAC accepted a hash of the standard library")
+	}
+}
+
+func TestAES(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		key := bytes.Repeat([]byte{byte(size)}, size)
+		block, err := NewAESCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := []byte("0123456789abcdef")
+		got, exp := make([]byte, 16), make([]byte, 16)
+		block.Encrypt(got, src)
+		want.Encrypt(exp, src)
+		if !bytes.Equal(got, exp) {
+			t.Errorf("AES-%d: got %x, want %x", size*8, got, exp)
+		}
+		block.Decrypt(got, got)
+		if !bytes.Equal(got, src) {
+			t.Errorf("AES-%d: decrypted %x, want %x", size*8, got, src)
+		}
+	}
+	if _, err := NewAESCipher(make([]byte, 10)); err == nil {
+		t.Error("NewAESCipher accepted a 10-byte key")
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	block, err := NewAESCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := block.(interface {
+		NewGCM(nonceSize, tagSize int) (cipher.AEAD, error)
+	}).NewGCM(12, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdBlock, _ := aes.NewCipher(key)
+	stdGCM, _ := cipher.NewGCM(stdBlock)
+	nonce := make([]byte, gcm.NonceSize())
+	for _, tt := range []struct{ plaintext, ad []byte }{
+		{nil, nil},
+		{[]byte("plaintext"), nil},
+		{nil, []byte("additional data")},
+		{[]byte("plaintext"), []byte("additional data")},
+	} {
+		sealed := gcm.Seal([]byte("dst"), nonce, tt.plaintext, tt.ad)
+		if want := stdGCM.Seal([]byte("dst"), nonce, tt.plaintext, tt.ad); !bytes.Equal(sealed, want) {
+			t.Errorf("got %x, want %x", sealed, want)
+		}
+		opened, err := gcm.Open(nil, nonce, sealed[3:], tt.ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(opened, tt.plaintext) {
+			t.Errorf("opened %q, want %q", opened, tt.plaintext)
+		}
+		sealed[len(sealed)-1] ^= 1
+		if _, err := gcm.Open(nil, nonce, sealed[3:], tt.ad); err == nil {
+			t.Error("opened a tampered ciphertext")
+		}
+	}
+}
+
+func TestRandReader(t *testing.T) {
+	b := make([]byte, 64)
+	if _, err := RandReader.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(b, make([]byte, len(b))) {
+		t.Error("got only zeros")
+	}
+}
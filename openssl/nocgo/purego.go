@@ -0,0 +1,268 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ebitengine/purego"
+)
+
+// The libcrypto functions called by this package, bound by bind.
+// The C int, unsigned long and size_t types are int32, uint and uint,
+// and the pointers to OpenSSL objects are uintptr.
+var (
+	_OpenSSL_version_num        func() uint
+	_OpenSSL_version            func(t int32) string
+	_OPENSSL_init_crypto        func(opts uint64, settings uintptr) int32
+	_ERR_get_error              func() uint
+	_ERR_error_string_n         func(e uint, buf *byte, n uint)
+	_ERR_clear_error            func()
+	_RAND_bytes                 func(buf *byte, num int32) int32
+	_EVP_MD_CTX_new             func() uintptr
+	_EVP_MD_CTX_free            func(ctx uintptr)
+	_EVP_MD_CTX_copy_ex         func(out, in uintptr) int32
+	_EVP_DigestInit_ex          func(ctx, md, impl uintptr) int32
+	_EVP_DigestUpdate           func(ctx uintptr, d *byte, n uint) int32
+	_EVP_DigestFinal_ex         func(ctx uintptr, md *byte, s *uint32) int32
+	_EVP_sha1                   func() uintptr
+	_EVP_sha224                 func() uintptr
+	_EVP_sha256                 func() uintptr
+	_EVP_sha384                 func() uintptr
+	_EVP_sha512                 func() uintptr
+	_HMAC_CTX_new               func() uintptr
+	_HMAC_CTX_free              func(ctx uintptr)
+	_HMAC_CTX_copy              func(dst, src uintptr) int32
+	_HMAC_Init_ex               func(ctx uintptr, key *byte, n int32, md, impl uintptr) int32
+	_HMAC_Update                func(ctx uintptr, data *byte, n uint) int32
+	_HMAC_Final                 func(ctx uintptr, md *byte, n *uint32) int32
+	_EVP_CIPHER_CTX_new         func() uintptr
+	_EVP_CIPHER_CTX_free        func(ctx uintptr)
+	_EVP_CIPHER_CTX_ctrl        func(ctx uintptr, typ, arg int32, ptr *byte) int32
+	_EVP_CIPHER_CTX_set_padding func(ctx uintptr, padding int32) int32
+	_EVP_CipherInit_ex          func(ctx, cipher, impl uintptr, key, iv *byte, enc int32) int32
+	_EVP_CipherUpdate           func(ctx uintptr, out *byte, outl *int32, in *byte, inl int32) int32
+	_EVP_CipherFinal_ex         func(ctx uintptr, out *byte, outl *int32) int32
+	_EVP_aes_128_ecb            func() uintptr
+	_EVP_aes_192_ecb            func() uintptr
+	_EVP_aes_256_ecb            func() uintptr
+	_EVP_aes_128_gcm            func() uintptr
+	_EVP_aes_192_gcm            func() uintptr
+	_EVP_aes_256_gcm            func() uintptr
+
+	// OpenSSL 1.1 only.
+	_FIPS_mode func() int32
+	// OpenSSL 3 only.
+	_EVP_default_properties_is_fips_enabled func(libctx uintptr) int32
+)
+
+// functions maps the names of the functions of every supported version
+// to the variables they are bound to.
+var functions = map[string]interface{}{
+	"OpenSSL_version_num":        &_OpenSSL_version_num,
+	"OpenSSL_version":            &_OpenSSL_version,
+	"OPENSSL_init_crypto":        &_OPENSSL_init_crypto,
+	"ERR_get_error":              &_ERR_get_error,
+	"ERR_error_string_n":         &_ERR_error_string_n,
+	"ERR_clear_error":            &_ERR_clear_error,
+	"RAND_bytes":                 &_RAND_bytes,
+	"EVP_MD_CTX_new":             &_EVP_MD_CTX_new,
+	"EVP_MD_CTX_free":            &_EVP_MD_CTX_free,
+	"EVP_MD_CTX_copy_ex":         &_EVP_MD_CTX_copy_ex,
+	"EVP_DigestInit_ex":          &_EVP_DigestInit_ex,
+	"EVP_DigestUpdate":           &_EVP_DigestUpdate,
+	"EVP_DigestFinal_ex":         &_EVP_DigestFinal_ex,
+	"EVP_sha1":                   &_EVP_sha1,
+	"EVP_sha224":                 &_EVP_sha224,
+	"EVP_sha256":                 &_EVP_sha256,
+	"EVP_sha384":                 &_EVP_sha384,
+	"EVP_sha512":                 &_EVP_sha512,
+	"HMAC_CTX_new":               &_HMAC_CTX_new,
+	"HMAC_CTX_free":              &_HMAC_CTX_free,
+	"HMAC_CTX_copy":              &_HMAC_CTX_copy,
+	"HMAC_Init_ex":               &_HMAC_Init_ex,
+	"HMAC_Update":                &_HMAC_Update,
+	"HMAC_Final":                 &_HMAC_Final,
+	"EVP_CIPHER_CTX_new":         &_EVP_CIPHER_CTX_new,
+	"EVP_CIPHER_CTX_free":        &_EVP_CIPHER_CTX_free,
+	"EVP_CIPHER_CTX_ctrl":        &_EVP_CIPHER_CTX_ctrl,
+	"EVP_CIPHER_CTX_set_padding": &_EVP_CIPHER_CTX_set_padding,
+	"EVP_CipherInit_ex":          &_EVP_CipherInit_ex,
+	"EVP_CipherUpdate":           &_EVP_CipherUpdate,
+	"EVP_CipherFinal_ex":         &_EVP_CipherFinal_ex,
+	"EVP_aes_128_ecb":            &_EVP_aes_128_ecb,
+	"EVP_aes_192_ecb":            &_EVP_aes_192_ecb,
+	"EVP_aes_256_ecb":            &_EVP_aes_256_ecb,
+	"EVP_aes_128_gcm":            &_EVP_aes_128_gcm,
+	"EVP_aes_192_gcm":            &_EVP_aes_192_gcm,
+	"EVP_aes_256_gcm":            &_EVP_aes_256_gcm,
+}
+
+// The functions of only some versions.
+var (
+	functions1 = map[string]interface{}{"FIPS_mode": &_FIPS_mode}
+	functions3 = map[string]interface{}{"EVP_default_properties_is_fips_enabled": &_EVP_default_properties_is_fips_enabled}
+)
+
+const (
+	_OPENSSL_INIT_LOAD_CRYPTO_STRINGS = 0x00000002
+	_OPENSSL_INIT_ADD_ALL_CIPHERS     = 0x00000004
+	_OPENSSL_INIT_ADD_ALL_DIGESTS     = 0x00000008
+	_OPENSSL_INIT_LOAD_CONFIG         = 0x00000040
+	_OPENSSL_INIT_NO_LOAD_CONFIG      = 0x00000080
+)
+
+var (
+	initOnce sync.Once
+	// errInit is set when initOnce runs.
+	errInit error
+	// vMajor is the major version of the loaded libcrypto, set by initOnce.
+	vMajor int
+)
+
+// InitWithOptions is like Init but configured by opts.
+func InitWithOptions(opts InitOptions) error {
+	initOnce.Do(func() {
+		errInit = initLibrary(opts)
+	})
+	return errInit
+}
+
+func initLibrary(opts InitOptions) error {
+	path := opts.LibraryPath
+	if path == "" {
+		path = os.Getenv("GO_OPENSSL_LIBRARY_PATH")
+	}
+	var handle uintptr
+	if path != "" {
+		h, err := dlopen(path)
+		if err != nil {
+			return errors.New("openssl: can't load " + path + ": " + err.Error())
+		}
+		handle = h
+	} else {
+		var errs []string
+		for _, name := range libraryNames() {
+			h, err := dlopen(name)
+			if err == nil {
+				handle = h
+				break
+			}
+			errs = append(errs, name+": "+err.Error())
+		}
+		if handle == 0 {
+			return errors.New("openssl: can't load libcrypto:\n" + strings.Join(errs, "\n"))
+		}
+	}
+	if err := bind(handle, map[string]interface{}{"OpenSSL_version_num": &_OpenSSL_version_num}); err != nil {
+		return err
+	}
+	num := _OpenSSL_version_num()
+	major, minor := int(num>>28), int(num>>20&0xff)
+	var more map[string]interface{}
+	switch {
+	case major == 3:
+		more = functions3
+	case major == 1 && minor == 1:
+		more = functions1
+	default:
+		return errors.New("openssl: OpenSSL " + strconv.Itoa(major) + "." + strconv.Itoa(minor) + " is not supported without cgo")
+	}
+	if err := bind(handle, functions, more); err != nil {
+		return err
+	}
+	vMajor = major
+	flags := uint64(_OPENSSL_INIT_ADD_ALL_CIPHERS | _OPENSSL_INIT_ADD_ALL_DIGESTS | _OPENSSL_INIT_LOAD_CRYPTO_STRINGS)
+	if opts.NoLoadConfig {
+		flags |= _OPENSSL_INIT_NO_LOAD_CONFIG
+	} else {
+		flags |= _OPENSSL_INIT_LOAD_CONFIG
+	}
+	if _OPENSSL_init_crypto(flags, 0) != 1 {
+		return newOpenSSLError("OPENSSL_init_crypto")
+	}
+	return nil
+}
+
+// bind binds the functions of the tables to the functions of handle.
+// It fails listing the missing functions, if any, before binding any.
+func bind(handle uintptr, tables ...map[string]interface{}) error {
+	syms := make(map[string]uintptr)
+	var missing []string
+	for _, table := range tables {
+		for name := range table {
+			sym, err := dlsym(handle, name)
+			if err != nil || sym == 0 {
+				missing = append(missing, name)
+				continue
+			}
+			syms[name] = sym
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.New("openssl: libcrypto lacks required functions: " + strings.Join(missing, ", "))
+	}
+	for _, table := range tables {
+		for name, fptr := range table {
+			purego.RegisterFunc(fptr, syms[name])
+		}
+	}
+	return nil
+}
+
+// VersionText returns the version text of the OpenSSL currently loaded.
+func VersionText() string {
+	return _OpenSSL_version(0)
+}
+
+// FIPS returns true if OpenSSL is running in FIPS mode, else returns false.
+func FIPS() bool {
+	if vMajor == 3 {
+		return _EVP_default_properties_is_fips_enabled(0) == 1
+	}
+	return _FIPS_mode() == 1
+}
+
+func newOpenSSLError(msg string) error {
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\nopenssl error(s):")
+	var buf [256]byte
+	for {
+		e := _ERR_get_error()
+		if e == 0 {
+			break
+		}
+		_ERR_error_string_n(e, &buf[0], uint(len(buf)))
+		b.WriteByte('\n')
+		b.WriteString(cstring(buf[:]))
+	}
+	return errors.New(b.String())
+}
+
+// cstring returns the NUL-terminated string at the start of b.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// base returns the address of the first byte of b, or nil.
+func base(b []byte) *byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return &b[0]
+}
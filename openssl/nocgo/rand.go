@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"io"
+	"math"
+)
+
+type randReader int
+
+func (randReader) Read(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > math.MaxInt32 {
+			chunk = chunk[:math.MaxInt32]
+		}
+		if _RAND_bytes(&chunk[0], int32(len(chunk))) != 1 {
+			return 0, newOpenSSLError("RAND_bytes")
+		}
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+// RandReader is a reader of random numbers generated by OpenSSL.
+var RandReader io.Reader = randReader(0)
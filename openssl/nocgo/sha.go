@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build (!cgo || openssl_purego) && (linux || darwin || windows)
+
+package nocgo
+
+import (
+	"hash"
+	"runtime"
+)
+
+// NewSHA1 returns a new SHA1 hash.
+func NewSHA1() hash.Hash { return newEvpHash(_EVP_sha1(), 20, 64) }
+
+// NewSHA224 returns a new SHA224 hash.
+func NewSHA224() hash.Hash { return newEvpHash(_EVP_sha224(), 28, 64) }
+
+// NewSHA256 returns a new SHA256 hash.
+func NewSHA256() hash.Hash { return newEvpHash(_EVP_sha256(), 32, 64) }
+
+// NewSHA384 returns a new SHA384 hash.
+func NewSHA384() hash.Hash { return newEvpHash(_EVP_sha384(), 48, 128) }
+
+// NewSHA512 returns a new SHA512 hash.
+func NewSHA512() hash.Hash { return newEvpHash(_EVP_sha512(), 64, 128) }
+
+// evpHash implements hash.Hash with an EVP_MD_CTX.
+type evpHash struct {
+	md        uintptr
+	ctx       uintptr
+	size      int
+	blockSize int
+}
+
+func newEvpHash(md uintptr, size, blockSize int) *evpHash {
+	ctx := _EVP_MD_CTX_new()
+	if ctx == 0 {
+		panic(newOpenSSLError("EVP_MD_CTX_new"))
+	}
+	h := &evpHash{md: md, ctx: ctx, size: size, blockSize: blockSize}
+	runtime.SetFinalizer(h, (*evpHash).finalize)
+	h.Reset()
+	return h
+}
+
+func (h *evpHash) finalize() {
+	_EVP_MD_CTX_free(h.ctx)
+}
+
+func (h *evpHash) Reset() {
+	if _EVP_DigestInit_ex(h.ctx, h.md, 0) != 1 {
+		panic(newOpenSSLError("EVP_DigestInit_ex"))
+	}
+	runtime.KeepAlive(h)
+}
+
+func (h *evpHash) Write(p []byte) (int, error) {
+	if len(p) > 0 && _EVP_DigestUpdate(h.ctx, &p[0], uint(len(p))) != 1 {
+		panic(newOpenSSLError("EVP_DigestUpdate"))
+	}
+	runtime.KeepAlive(h)
+	return len(p), nil
+}
+
+func (h *evpHash) Size() int      { return h.size }
+func (h *evpHash) BlockSize() int { return h.blockSize }
+
+func (h *evpHash) Sum(in []byte) []byte {
+	// Finalize a copy so that h can still be written to.
+	ctx := _EVP_MD_CTX_new()
+	if ctx == 0 {
+		panic(newOpenSSLError("EVP_MD_CTX_new"))
+	}
+	defer _EVP_MD_CTX_free(ctx)
+	out := make([]byte, h.size)
+	if _EVP_MD_CTX_copy_ex(ctx, h.ctx) != 1 || _EVP_DigestFinal_ex(ctx, &out[0], nil) != 1 {
+		panic(newOpenSSLError("EVP_DigestFinal_ex"))
+	}
+	runtime.KeepAlive(h)
+	return append(in, out...)
+}
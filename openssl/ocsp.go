@@ -0,0 +1,347 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"runtime"
+	"time"
+)
+
+// CreateOCSPRequest returns the DER encoding of an OCSP request for the
+// status of cert, issued by issuer, as defined in RFC 6960. The
+// certificate is identified with the hash h, or SHA-1 if zero, which
+// is the only hash that RFC 5019 requires responders to support.
+//
+// CreateOCSPRequest is not supported on OpenSSL 1.0.2.
+func CreateOCSPRequest(cert, issuer *Certificate, h crypto.Hash) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	id, err := newOCSPCertID(cert, issuer, h)
+	if err != nil {
+		return nil, err
+	}
+	req := C.go_openssl_OCSP_REQUEST_new()
+	if req == nil {
+		C.go_openssl_OCSP_CERTID_free(id)
+		return nil, newOpenSSLError("OCSP_REQUEST_new")
+	}
+	defer C.go_openssl_OCSP_REQUEST_free(req)
+	// OCSP_request_add0_id only takes ownership of id on success.
+	if C.go_openssl_OCSP_request_add0_id(req, id) == nil {
+		C.go_openssl_OCSP_CERTID_free(id)
+		return nil, newOpenSSLError("OCSP_request_add0_id")
+	}
+	n := C.go_openssl_i2d_OCSP_REQUEST_buf(req, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_OCSP_REQUEST")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_OCSP_REQUEST_buf(req, base(der)) != n {
+		return nil, newOpenSSLError("i2d_OCSP_REQUEST")
+	}
+	return der, nil
+}
+
+// newOCSPCertID returns the OCSP identifier of cert, issued by issuer,
+// as described in CreateOCSPRequest. The caller must free it.
+func newOCSPCertID(cert, issuer *Certificate, h crypto.Hash) (C.GO_OCSP_CERTID_PTR, error) {
+	if h == 0 {
+		h = crypto.SHA1
+	}
+	md := cryptoHashToMD(h)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported OCSP certificate ID hash")
+	}
+	defer runtime.KeepAlive(cert)
+	defer runtime.KeepAlive(issuer)
+	id := C.go_openssl_OCSP_cert_to_id(md, cert._x509, issuer._x509)
+	if id == nil {
+		return nil, newOpenSSLError("OCSP_cert_to_id")
+	}
+	return id, nil
+}
+
+// OCSPResponseStatus is the status of an OCSP response,
+// as defined in RFC 6960, Section 4.2.1.
+type OCSPResponseStatus int
+
+const (
+	OCSPSuccessful       OCSPResponseStatus = 0
+	OCSPMalformedRequest OCSPResponseStatus = 1
+	OCSPInternalError    OCSPResponseStatus = 2
+	OCSPTryLater         OCSPResponseStatus = 3
+	OCSPSigRequired      OCSPResponseStatus = 5
+	OCSPUnauthorized     OCSPResponseStatus = 6
+)
+
+func (s OCSPResponseStatus) String() string {
+	return C.GoString(C.go_openssl_OCSP_response_status_str(C.long(s)))
+}
+
+// OCSPCertStatus is the revocation status of a certificate
+// in an OCSP response.
+type OCSPCertStatus int
+
+const (
+	OCSPGood OCSPCertStatus = iota
+	OCSPRevoked
+	OCSPUnknown
+)
+
+// OCSPSingleResponse is the status of a certificate given by an OCSP response.
+type OCSPSingleResponse struct {
+	Status OCSPCertStatus
+
+	// RevokedAt is the revocation time if Status is OCSPRevoked.
+	RevokedAt time.Time
+	// RevocationReason is the CRLReason code of the revocation
+	// if Status is OCSPRevoked, or -1 if the responder doesn't give it.
+	RevocationReason int
+
+	// ThisUpdate is the time at which the status was known to be correct.
+	ThisUpdate time.Time
+	// NextUpdate is the time at or before which newer information
+	// will be available, or zero if the responder doesn't give it.
+	// A response past NextUpdate must not be trusted.
+	NextUpdate time.Time
+}
+
+// CheckValidity returns an error if s is not fresh at now, with the checks
+// of OCSP_check_validity, which only compares to the system clock: ThisUpdate
+// must not be later than now, NextUpdate, if set, must not be earlier than
+// now nor than ThisUpdate, and, if maxAge is positive, ThisUpdate must not
+// be earlier than maxAge before now. skew is the tolerated difference
+// between the clocks of the responder and of the caller in the checks
+// against now, such as 5 minutes.
+func (s *OCSPSingleResponse) CheckValidity(now time.Time, skew, maxAge time.Duration) error {
+	if s.ThisUpdate.After(now.Add(skew)) {
+		return errors.New("openssl: OCSP status not yet valid")
+	}
+	if maxAge > 0 && s.ThisUpdate.Before(now.Add(-skew-maxAge)) {
+		return errors.New("openssl: OCSP status too old")
+	}
+	if s.NextUpdate.IsZero() {
+		return nil
+	}
+	if s.NextUpdate.Before(now.Add(-skew)) {
+		return errors.New("openssl: OCSP status expired")
+	}
+	if s.NextUpdate.Before(s.ThisUpdate) {
+		return errors.New("openssl: OCSP status NextUpdate before ThisUpdate")
+	}
+	return nil
+}
+
+// OCSPResponse is an OCSP response parsed by OpenSSL, such as the
+// response of an OCSP responder to CreateOCSPRequest or a response
+// stapled to a TLS handshake.
+type OCSPResponse struct {
+	raw    []byte
+	status OCSPResponseStatus
+	// _basic MUST NOT be accessed directly. Instead, use the withBasic method.
+	// It is nil if status isn't OCSPSuccessful.
+	_basic C.GO_OCSP_BASICRESP_PTR
+	closer
+}
+
+// ParseOCSPResponse parses der, a single ASN.1 DER encoded OCSP response.
+// Responses with an unsuccessful status are parsed too, but only
+// their Status is available.
+//
+// ParseOCSPResponse is not supported on OpenSSL 1.0.2.
+func ParseOCSPResponse(der []byte) (*OCSPResponse, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty OCSP response")
+	}
+	resp := C.go_openssl_d2i_OCSP_RESPONSE_buf(base(der), C.long(len(der)))
+	if resp == nil {
+		return nil, newOpenSSLError("d2i_OCSP_RESPONSE")
+	}
+	defer C.go_openssl_OCSP_RESPONSE_free(resp)
+	n := C.go_openssl_i2d_OCSP_RESPONSE_buf(resp, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_OCSP_RESPONSE")
+	}
+	if int(n) != len(der) {
+		return nil, errors.New("openssl: trailing data after OCSP response")
+	}
+	r := &OCSPResponse{
+		raw:    append([]byte(nil), der...),
+		status: OCSPResponseStatus(C.go_openssl_OCSP_response_status(resp)),
+	}
+	if r.status != OCSPSuccessful {
+		return r, nil
+	}
+	r._basic = C.go_openssl_OCSP_response_get1_basic(resp)
+	if r._basic == nil {
+		return nil, newOpenSSLError("OCSP_response_get1_basic")
+	}
+	manage(r, (*OCSPResponse).finalize)
+	return r, nil
+}
+
+func (r *OCSPResponse) finalize() {
+	C.go_openssl_OCSP_BASICRESP_free(r._basic)
+}
+
+// Close frees the native resources of r, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (r *OCSPResponse) Close() error {
+	return closeObject(r, r.finalize)
+}
+
+func (r *OCSPResponse) withBasic(f func(C.GO_OCSP_BASICRESP_PTR) C.int) C.int {
+	// Because of the finalizer, any time _basic is passed to cgo, that call must
+	// be followed by a call to runtime.KeepAlive, to make sure r is not
+	// collected (and finalized) before the cgo call returns.
+	defer runtime.KeepAlive(r)
+	return f(r._basic)
+}
+
+// Raw returns the DER encoding of r. It must not be modified.
+func (r *OCSPResponse) Raw() []byte {
+	return r.raw
+}
+
+// Status returns the response status of r.
+func (r *OCSPResponse) Status() OCSPResponseStatus {
+	return r.status
+}
+
+func (r *OCSPResponse) checkSuccessful() error {
+	if r.status != OCSPSuccessful {
+		return errors.New("openssl: OCSP response status is " + r.status.String())
+	}
+	return nil
+}
+
+// ProducedAt returns the time at which the responder signed r.
+func (r *OCSPResponse) ProducedAt() (time.Time, error) {
+	if err := r.checkSuccessful(); err != nil {
+		return time.Time{}, err
+	}
+	var t time.Time
+	var err error
+	r.withBasic(func(bs C.GO_OCSP_BASICRESP_PTR) C.int {
+		t, err = asn1TimeToTime(C.go_openssl_OCSP_resp_get0_produced_at(bs))
+		return 1
+	})
+	return t, err
+}
+
+// CertificateStatus returns the status of cert, issued by issuer, in r.
+// h is the hash of the certificate identifier, as in CreateOCSPRequest.
+//
+// CertificateStatus doesn't check the signature of r, which must be
+// checked with CheckSignatureFrom before trusting the status, nor the
+// freshness of the status, which must be checked with CheckValidity.
+func (r *OCSPResponse) CertificateStatus(cert, issuer *Certificate, h crypto.Hash) (*OCSPSingleResponse, error) {
+	if err := r.checkSuccessful(); err != nil {
+		return nil, err
+	}
+	id, err := newOCSPCertID(cert, issuer, h)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_OCSP_CERTID_free(id)
+	var single OCSPSingleResponse
+	var status, reason C.int
+	var revtime, thisupd, nextupd C.GO_ASN1_STRING_PTR
+	if r.withBasic(func(bs C.GO_OCSP_BASICRESP_PTR) C.int {
+		if C.go_openssl_OCSP_resp_find_status(bs, id, &status, &reason, &revtime, &thisupd, &nextupd) != 1 {
+			return 0
+		}
+		// The times are owned by bs.
+		for _, t := range []struct {
+			at  C.GO_ASN1_STRING_PTR
+			dst *time.Time
+		}{{revtime, &single.RevokedAt}, {thisupd, &single.ThisUpdate}, {nextupd, &single.NextUpdate}} {
+			if t.at == nil {
+				continue
+			}
+			if *t.dst, err = asn1TimeToTime(t.at); err != nil {
+				return 1
+			}
+		}
+		return 1
+	}) != 1 {
+		C.go_openssl_ERR_clear_error()
+		return nil, errors.New("openssl: certificate not found in OCSP response")
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case C.GO_V_OCSP_CERTSTATUS_GOOD:
+		single.Status = OCSPGood
+	case C.GO_V_OCSP_CERTSTATUS_REVOKED:
+		single.Status = OCSPRevoked
+	case C.GO_V_OCSP_CERTSTATUS_UNKNOWN:
+		single.Status = OCSPUnknown
+	default:
+		return nil, errors.New("openssl: invalid OCSP certificate status")
+	}
+	single.RevocationReason = -1
+	if single.Status == OCSPRevoked && reason != C.GO_OCSP_REVOKED_STATUS_NOSTATUS {
+		single.RevocationReason = int(reason)
+	}
+	return &single, nil
+}
+
+// CheckSignatureFrom verifies that r is signed by issuer, or by a
+// responder certificate included in r, issued by issuer and authorized
+// for OCSP signing, as defined in RFC 6960, Section 4.2.2.2. The
+// responder certificate must be valid at the ProducedAt time of r.
+//
+// issuer must be the issuer of the certificates whose status is
+// looked up, and is trusted as is.
+func (r *OCSPResponse) CheckSignatureFrom(issuer *Certificate) error {
+	producedAt, err := r.ProducedAt()
+	if err != nil {
+		return err
+	}
+	store := C.go_openssl_X509_STORE_new()
+	if store == nil {
+		return newOpenSSLError("X509_STORE_new")
+	}
+	defer C.go_openssl_X509_STORE_free(store)
+	if issuer.withX509(func(x C.GO_X509_PTR) C.int {
+		return C.go_openssl_X509_STORE_add_cert(store, x)
+	}) != 1 {
+		return newOpenSSLError("X509_STORE_add_cert")
+	}
+	// The issuer isn't necessarily a root.
+	if C.go_openssl_X509_STORE_set_flags(store, C.GO_X509_V_FLAG_PARTIAL_CHAIN) != 1 {
+		return newOpenSSLError("X509_STORE_set_flags")
+	}
+	param := C.go_openssl_X509_STORE_get0_param(store)
+	if param == nil {
+		return newOpenSSLError("X509_STORE_get0_param")
+	}
+	C.go_openssl_X509_VERIFY_PARAM_set_time(param, C.time_t(producedAt.Unix()))
+	// OCSP_basic_verify looks up the signer in certs too,
+	// for responses signed by the issuer itself.
+	certs, err := newX509Stack([]*Certificate{issuer})
+	if err != nil {
+		return err
+	}
+	defer certs.free()
+	if r.withBasic(func(bs C.GO_OCSP_BASICRESP_PTR) C.int {
+		return C.go_openssl_OCSP_basic_verify(bs, C.GO_STACK_OF_X509_PTR(certs.st), store, 0)
+	}) != 1 {
+		return newOpenSSLError("OCSP_basic_verify")
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// The ASN.1 structures of RFC 6960, with only
+// the fields these tests use.
+
+type ocspTestCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspTestRequest struct {
+	TBSRequest struct {
+		RequestList []struct {
+			ReqCert asn1.RawValue
+		}
+	}
+}
+
+type ocspTestSingleResponse struct {
+	CertID     asn1.RawValue
+	CertStatus asn1.RawValue
+	ThisUpdate time.Time `asn1:"generalized"`
+	NextUpdate time.Time `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type ocspTestResponseData struct {
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspTestSingleResponse
+}
+
+type ocspTestBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certs              []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspTestResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspTestResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes ocspTestResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+var (
+	oidOCSPBasic       = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA1            = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+func mustMarshalASN1(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// ocspTestKeyHash returns the SHA-1 hash of the public key of c,
+// without the SubjectPublicKeyInfo wrapping.
+func ocspTestKeyHash(t *testing.T, c *x509.Certificate) []byte {
+	t.Helper()
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(c.RawSubjectPublicKeyInfo, &spki); err != nil {
+		t.Fatal(err)
+	}
+	h := sha1.Sum(spki.PublicKey.RightAlign())
+	return h[:]
+}
+
+// ocspTestGood is the good certificate status.
+var ocspTestGood = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0}
+
+// ocspTestRevoked returns the revoked certificate status
+// with the given revocation time and CRLReason.
+func ocspTestRevoked(t *testing.T, at time.Time, reason int) asn1.RawValue {
+	info := mustMarshalASN1(t, struct {
+		RevocationTime time.Time       `asn1:"generalized"`
+		Reason         asn1.Enumerated `asn1:"explicit,tag:0"`
+	}{at, asn1.Enumerated(reason)})
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(info, &seq); err != nil {
+		t.Fatal(err)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: seq.Bytes}
+}
+
+// newTestOCSPResponse returns a successful OCSP response signed by the
+// responder certificate with key, including certs, produced at producedAt.
+func newTestOCSPResponse(t *testing.T, responder *x509.Certificate, key *ecdsa.PrivateKey, certs []*x509.Certificate, producedAt time.Time, responses ...ocspTestSingleResponse) []byte {
+	t.Helper()
+	tbs := mustMarshalASN1(t, ocspTestResponseData{
+		ResponderID: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        2, // byKey
+			IsCompound: true,
+			Bytes:      mustMarshalASN1(t, ocspTestKeyHash(t, responder)),
+		},
+		ProducedAt: producedAt,
+		Responses:  responses,
+	})
+	digest := sha256.Sum256(tbs)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic := ocspTestBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	}
+	for _, c := range certs {
+		basic.Certs = append(basic.Certs, asn1.RawValue{FullBytes: c.Raw})
+	}
+	return mustMarshalASN1(t, ocspTestResponse{
+		ResponseBytes: ocspTestResponseBytes{oidOCSPBasic, mustMarshalASN1(t, basic)},
+	})
+}
+
+// newTestOCSPRequest returns the certificate ID of the OCSP request
+// for the status of the leaf of c.
+func newTestOCSPRequest(t *testing.T, c *verifyTestChain) asn1.RawValue {
+	t.Helper()
+	der, err := openssl.CreateOCSPRequest(c.leaf, c.intermediate, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req ocspTestRequest
+	if rest, err := asn1.Unmarshal(der, &req); err != nil || len(rest) != 0 {
+		t.Fatalf("invalid OCSP request: %v", err)
+	}
+	if len(req.TBSRequest.RequestList) != 1 {
+		t.Fatalf("got %d requests, want 1", len(req.TBSRequest.RequestList))
+	}
+	return req.TBSRequest.RequestList[0].ReqCert
+}
+
+func TestCreateOCSPRequest(t *testing.T) {
+	c := newVerifyTestChain(t)
+	rawID := newTestOCSPRequest(t, c)
+	var id ocspTestCertID
+	if _, err := asn1.Unmarshal(rawID.FullBytes, &id); err != nil {
+		t.Fatal(err)
+	}
+	nameHash := sha1.Sum(c.goIntermediate.RawSubject)
+	if !id.HashAlgorithm.Algorithm.Equal(oidSHA1) {
+		t.Errorf("hash algorithm = %v, want SHA-1", id.HashAlgorithm.Algorithm)
+	}
+	if !bytes.Equal(id.IssuerNameHash, nameHash[:]) {
+		t.Errorf("issuer name hash = %x, want %x", id.IssuerNameHash, nameHash)
+	}
+	if want := ocspTestKeyHash(t, c.goIntermediate); !bytes.Equal(id.IssuerKeyHash, want) {
+		t.Errorf("issuer key hash = %x, want %x", id.IssuerKeyHash, want)
+	}
+	if id.SerialNumber.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("serial number = %v, want 3", id.SerialNumber)
+	}
+	if _, err := openssl.CreateOCSPRequest(c.leaf, c.intermediate, crypto.SHA256); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOCSPResponse(t *testing.T) {
+	c := newVerifyTestChain(t)
+	id := newTestOCSPRequest(t, c)
+	thisUpdate := verifyTestTime.Add(-time.Hour)
+	nextUpdate := verifyTestTime.Add(time.Hour)
+	revokedAt := verifyTestTime.AddDate(0, -1, 0)
+	tests := []struct {
+		name   string
+		status asn1.RawValue
+		want   openssl.OCSPSingleResponse
+	}{
+		{"Good", ocspTestGood, openssl.OCSPSingleResponse{Status: openssl.OCSPGood, RevocationReason: -1, ThisUpdate: thisUpdate, NextUpdate: nextUpdate}},
+		{"Revoked", ocspTestRevoked(t, revokedAt, 1), openssl.OCSPSingleResponse{Status: openssl.OCSPRevoked, RevokedAt: revokedAt, RevocationReason: 1, ThisUpdate: thisUpdate, NextUpdate: nextUpdate}},
+		{"Unknown", asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2}, openssl.OCSPSingleResponse{Status: openssl.OCSPUnknown, RevocationReason: -1, ThisUpdate: thisUpdate, NextUpdate: nextUpdate}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			der := newTestOCSPResponse(t, c.goIntermediate, c.intKey, nil, verifyTestTime, ocspTestSingleResponse{id, tt.status, thisUpdate, nextUpdate})
+			resp, err := openssl.ParseOCSPResponse(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(resp.Raw(), der) {
+				t.Error("Raw doesn't match the response")
+			}
+			if got := resp.Status(); got != openssl.OCSPSuccessful {
+				t.Errorf("Status() = %v, want successful", got)
+			}
+			if got, err := resp.ProducedAt(); err != nil || !got.Equal(verifyTestTime) {
+				t.Errorf("ProducedAt() = %v, %v, want %v", got, err, verifyTestTime)
+			}
+			if err := resp.CheckSignatureFrom(c.intermediate); err != nil {
+				t.Errorf("response not signed by the issuer: %v", err)
+			}
+			got, err := resp.CertificateStatus(c.leaf, c.intermediate, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want.Status || got.RevocationReason != tt.want.RevocationReason ||
+				!got.RevokedAt.Equal(tt.want.RevokedAt) || !got.ThisUpdate.Equal(tt.want.ThisUpdate) || !got.NextUpdate.Equal(tt.want.NextUpdate) {
+				t.Errorf("CertificateStatus() = %+v, want %+v", got, tt.want)
+			}
+			for _, v := range []struct {
+				now          time.Time
+				skew, maxAge time.Duration
+				ok           bool
+			}{
+				{verifyTestTime, 0, 0, true},
+				{verifyTestTime, 0, 30 * time.Minute, false},
+				{verifyTestTime, 0, 2 * time.Hour, true},
+				{thisUpdate.Add(-time.Minute), 0, 0, false},
+				{thisUpdate.Add(-time.Minute), 5 * time.Minute, 0, true},
+				{nextUpdate.Add(time.Minute), 0, 0, false},
+				{nextUpdate.Add(time.Minute), 5 * time.Minute, 0, true},
+			} {
+				if err := got.CheckValidity(v.now, v.skew, v.maxAge); (err == nil) != v.ok {
+					t.Errorf("CheckValidity(%v, %v, %v) = %v, want ok %v", v.now, v.skew, v.maxAge, err, v.ok)
+				}
+			}
+			if _, err := resp.CertificateStatus(c.intermediate, c.root, 0); err == nil {
+				t.Error("found the status of a certificate missing from the response")
+			}
+			if _, err := resp.CertificateStatus(c.leaf, c.intermediate, crypto.SHA256); err == nil {
+				t.Error("found the status of a certificate ID with another hash")
+			}
+		})
+	}
+}
+
+func TestOCSPResponseSigner(t *testing.T) {
+	c := newVerifyTestChain(t)
+	id := newTestOCSPRequest(t, c)
+	single := ocspTestSingleResponse{id, ocspTestGood, verifyTestTime, time.Time{}}
+	newResponder := func(ext []x509.ExtKeyUsage) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(10),
+			Subject:      pkix.Name{CommonName: "Responder"},
+			NotBefore:    verifyTestTime.AddDate(0, 0, -1),
+			NotAfter:     verifyTestTime.AddDate(0, 0, 1),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  ext,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, c.goIntermediate, key.Public(), c.intKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+	delegated, delegatedKey := newResponder([]x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning})
+	unauthorized, unauthorizedKey := newResponder([]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	tests := []struct {
+		name  string
+		der   []byte
+		valid bool
+	}{
+		{"Issuer", newTestOCSPResponse(t, c.goIntermediate, c.intKey, nil, verifyTestTime, single), true},
+		{"Delegated", newTestOCSPResponse(t, delegated, delegatedKey, []*x509.Certificate{delegated}, verifyTestTime, single), true},
+		{"DelegatedExpired", newTestOCSPResponse(t, delegated, delegatedKey, []*x509.Certificate{delegated}, verifyTestTime.AddDate(0, 0, 2), single), false},
+		{"DelegatedMissing", newTestOCSPResponse(t, delegated, delegatedKey, nil, verifyTestTime, single), false},
+		{"Unauthorized", newTestOCSPResponse(t, unauthorized, unauthorizedKey, []*x509.Certificate{unauthorized}, verifyTestTime, single), false},
+		{"OtherCA", newTestOCSPResponse(t, c.goRoot, c.rootKey, nil, verifyTestTime, single), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := openssl.ParseOCSPResponse(tt.der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = resp.CheckSignatureFrom(c.intermediate)
+			if tt.valid && err != nil {
+				t.Errorf("valid response rejected: %v", err)
+			} else if !tt.valid && err == nil {
+				t.Error("invalid response accepted")
+			}
+		})
+	}
+}
+
+func TestOCSPResponseUnsuccessful(t *testing.T) {
+	c := newVerifyTestChain(t)
+	der := mustMarshalASN1(t, ocspTestResponse{Status: asn1.Enumerated(openssl.OCSPTryLater)})
+	resp, err := openssl.ParseOCSPResponse(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Status(); got != openssl.OCSPTryLater {
+		t.Errorf("Status() = %v, want tryLater", got)
+	}
+	if _, err := resp.CertificateStatus(c.leaf, c.intermediate, 0); err == nil {
+		t.Error("got the certificate status of an unsuccessful response")
+	}
+	if err := resp.CheckSignatureFrom(c.intermediate); err == nil {
+		t.Error("verified the signature of an unsuccessful response")
+	}
+	if _, err := openssl.ParseOCSPResponse(append(der, 0)); err == nil {
+		t.Error("OCSP response with trailing data parsed")
+	}
+}
@@ -1,19 +1,18 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 // Package openssl provides access to OpenSSL cryptographic functions.
 package openssl
 
 // #include "goopenssl.h"
-// #include <dlfcn.h>
-// #cgo LDFLAGS: -ldl
 import "C"
 import (
 	"errors"
 	"math/bits"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -24,6 +23,7 @@ import (
 var (
 	providerNameFips    = C.CString("fips")
 	providerNameDefault = C.CString("default")
+	providerNameBase    = C.CString("base")
 )
 
 var (
@@ -33,18 +33,11 @@ var (
 	// vMajor and vMinor hold the major/minor OpenSSL version.
 	// It is only populated if Init has been called.
 	vMajor, vMinor int
+	// libHandle is the handle of the loaded libcrypto.
+	// It is only populated if Init succeeded.
+	libHandle unsafe.Pointer
 )
 
-// knownVersions is a list of supported and well-known libcrypto.so suffixes in decreasing version order.
-//
-// FreeBSD library version numbering does not directly align to the version of OpenSSL.
-// Its preferred search order is 11 -> 111.
-//
-// Some distributions use 1.0.0 and others (such as Debian) 1.0.2 to refer to the same OpenSSL 1.0.2 version.
-//
-// Fedora derived distros use different naming for the version 1.0.x.
-var knownVersions = [...]string{"3", "1.1", "11", "111", "1.0.2", "1.0.0", "10"}
-
 func errUnsuportedVersion() error {
 	return errors.New("openssl: OpenSSL version: " + strconv.Itoa(vMajor) + "." + strconv.Itoa(vMinor))
 }
@@ -60,81 +53,423 @@ func errUnsuportedVersion() error {
 // makes Init look for the shared library libcrypto.so.1.1.1k-fips.
 // If GO_OPENSSL_VERSION_OVERRIDE environment variable is empty, Init will try to load the OpenSSL shared library
 // using a list if supported and well-known version suffixes, going from higher to lower versions.
+//
+// If GO_OPENSSL_LIBRARY_PATH environment variable is not empty, Init loads the shared library at that path
+// instead, which takes precedence over GO_OPENSSL_VERSION_OVERRIDE. InitWithOptions can pin the library
+// from the program itself, see InitOptions.LibraryPath and InitOptions.Version.
+//
+// Init loads the system OpenSSL configuration file.
+// Use InitWithOptions to skip it or to load another file.
 func Init() error {
+	return InitWithOptions(InitOptions{})
+}
+
+// InitOptions configures how InitWithOptions initializes OpenSSL.
+type InitOptions struct {
+	// NoLoadConfig skips loading any OpenSSL configuration file,
+	// like OPENSSL_INIT_NO_LOAD_CONFIG. It is useful when the system
+	// configuration pulls in engines or providers that break or slow
+	// down the process.
+	NoLoadConfig bool
+	// ConfigFile, if not empty, is the path of the OpenSSL configuration
+	// file loaded instead of the system one, which is never loaded.
+	// Unlike the system configuration, errors in ConfigFile,
+	// including it not existing, make InitWithOptions fail.
+	ConfigFile string
+	// LibraryPath, if not empty, is the file name or path of the libcrypto
+	// shared library to load instead of probing the well-known version
+	// suffixes, for example "/opt/openssl/lib64/libcrypto.so.3".
+	// It is passed to dlopen as is, so a file name without a slash is
+	// looked up in the dlopen search path.
+	// If empty, the GO_OPENSSL_LIBRARY_PATH environment variable is used.
+	LibraryPath string
+	// Version, if not empty, is the version suffix of the libcrypto.so
+	// library to load, like GO_OPENSSL_VERSION_OVERRIDE, which it takes
+	// precedence over.
+	Version string
+	// LibraryNames, if not empty, are the file names or paths of the
+	// libcrypto shared libraries to probe instead of the well-known
+	// version suffixes. The first FIPS enabled library is loaded, or the
+	// first existing one if none is FIPS enabled. If no library can be
+	// loaded, the error lists the dlopen error of each name.
+	LibraryNames []string
+	// TrackAllocations counts the memory allocations of libcrypto, as
+	// reported by LiveAllocations, by installing allocation functions
+	// with CRYPTO_set_mem_functions. It is meant for leak detection
+	// in tests, see the leaktest package, and requires OpenSSL 1.1 or
+	// later. InitWithOptions fails if libcrypto already allocated
+	// memory, for example because another user of the process
+	// initialized it first.
+	TrackAllocations bool
+	// SecureHeapSize, if not zero, is the size in bytes of the OpenSSL
+	// secure heap, set up with CRYPTO_secure_malloc_init. The secure
+	// heap is locked in memory so it is never swapped out, surrounded
+	// by guard pages, and cleared when freed. The private components of
+	// the RSA, ECDSA, ECDH and SM2 keys created from Go values are then
+	// allocated in it, see SecureHeapUsed. It must be a power of two and
+	// requires OpenSSL 1.1 or later. The secure heap is shared with
+	// every user of libcrypto in the process.
+	SecureHeapSize int
+	// SecureHeapMinSize is the minimum size in bytes of the allocations
+	// of the secure heap, which must be a power of two smaller than
+	// SecureHeapSize. If zero, 16 bytes are used.
+	SecureHeapMinSize int
+	// LockSecrets locks the buffers holding passwords, DEKs and derived
+	// keys on the Go side of this package in memory, with mlock on Unix
+	// and VirtualLock on Windows, so they are never swapped out. They
+	// are always cleansed with OPENSSL_cleanse once used, or when the
+	// object owning them is closed, see ProtectSecret. Locking fails
+	// beyond the RLIMIT_MEMLOCK limit of the process on Unix.
+	LockSecrets bool
+	// ExplicitClose frees the native OpenSSL objects held by the objects
+	// of this package only when they are closed, instead of from
+	// finalizers, and tracks the objects not closed yet, see
+	// OutstandingObjects. Every key, certificate, hash, cipher and
+	// context must then be closed, or it leaks. The objects of the
+	// subpackages mirroring the standard library, which can't be
+	// closed, are never freed in this mode.
+	ExplicitClose bool
+	// LongOperationWorkers is the number of OS threads running the long
+	// cgo calls of this package, such as RSA key generation and RSA
+	// decryption with keys of 4096 bits or more, so that bursts of them
+	// don't compete with the threads running goroutines. Operations
+	// queue once all the workers are busy, see LongOperations. If zero,
+	// runtime.GOMAXPROCS at initialization is used. If negative, long
+	// operations run on the calling goroutine.
+	LongOperationWorkers int
+}
+
+// InitWithOptions is like Init but configures the initialization with opts.
+//
+// Only the first call to Init or InitWithOptions is effective,
+// subsequent calls return the same error result as the first one
+// and ignore opts.
+func InitWithOptions(opts InitOptions) error {
+	if opts.NoLoadConfig && opts.ConfigFile != "" {
+		return errors.New("openssl: NoLoadConfig and ConfigFile are mutually exclusive")
+	}
+	var libOpts int
+	for _, set := range [...]bool{opts.LibraryPath != "", opts.Version != "", len(opts.LibraryNames) > 0} {
+		if set {
+			libOpts++
+		}
+	}
+	if libOpts > 1 {
+		return errors.New("openssl: LibraryPath, Version and LibraryNames are mutually exclusive")
+	}
+	if err := checkSecureHeap(opts); err != nil {
+		return err
+	}
+	initOnce.Do(func() {
+		initLibrary(opts, nil)
+	})
+	return errInit
+}
+
+// InitWithHandle is like Init but uses the libcrypto shared library
+// already loaded by the caller, for example with dlopen on Unix or
+// LoadLibrary on Windows, instead of loading one itself. It lets
+// programs that need a specific copy of libcrypto, such as one extracted
+// from a FIPS bundle, make sure this package uses the same copy.
+// The library selection options and environment variables are ignored.
+//
+// The handle must remain loaded for the lifetime of the process,
+// this package never closes it. With the openssl_static build tag,
+// the handle is ignored and the linked library is used instead.
+//
+// Only the first call to Init, InitWithOptions or InitWithHandle is effective,
+// subsequent calls return the same error result as the first one.
+func InitWithHandle(handle unsafe.Pointer) error {
+	if handle == nil {
+		return errors.New("openssl: nil library handle")
+	}
 	initOnce.Do(func() {
-		version, _ := syscall.Getenv("GO_OPENSSL_VERSION_OVERRIDE")
-		handle, err := loadLibrary(version)
-		if err != nil {
+		initLibrary(InitOptions{}, handle)
+	})
+	return errInit
+}
+
+// initLibrary initializes the libcrypto library loaded at handle,
+// or the library selected by opts if handle is nil, and sets errInit.
+func initLibrary(opts InitOptions, handle unsafe.Pointer) {
+	trackLoadedLibraries = opts.TrackAllocations
+	lockSecrets = opts.LockSecrets
+	explicitClose = opts.ExplicitClose
+	longOps.workers = opts.LongOperationWorkers
+	if longOps.workers == 0 {
+		longOps.workers = runtime.GOMAXPROCS(0)
+	}
+	if h, ok := staticLibrary(); ok {
+		handle = h
+	} else if handle == nil {
+		var err error
+		if handle, err = openLibrary(opts); err != nil {
 			errInit = err
 			return
 		}
+	}
+
+	if v := C.go_openssl_libressl_version(handle); v != nil {
+		errInit = errors.New("openssl: " + C.GoString(v) + " is not supported: " +
+			"LibreSSL lacks OpenSSL 1.1 and 3 APIs this package requires, " +
+			"including EVP_KDF, providers and FIPS mode; use OpenSSL 1.0.2, 1.1 or 3 instead")
+		return
+	}
 
-		vMajor = int(C.go_openssl_version_major(handle))
-		vMinor = int(C.go_openssl_version_minor(handle))
-		if vMajor == -1 || vMinor == -1 {
-			errInit = errors.New("openssl: can't retrieve OpenSSL version")
+	vMajor = int(C.go_openssl_version_major(handle))
+	vMinor = int(C.go_openssl_version_minor(handle))
+	if vMajor == -1 || vMinor == -1 {
+		errInit = errors.New("openssl: can't retrieve OpenSSL version")
+		return
+	}
+	var supported bool
+	if vMajor == 1 {
+		supported = vMinor == 0 || vMinor == 1
+	} else if vMajor == 3 {
+		// OpenSSL team guarantees API and ABI compatibility within the same major version since OpenSSL 3.
+		supported = true
+	}
+	if !supported {
+		errInit = errUnsuportedVersion()
+		return
+	}
+
+	if missing := missingFunctions(handle); len(missing) > 0 {
+		name := "OpenSSL " + strconv.Itoa(vMajor) + "." + strconv.Itoa(vMinor)
+		if flavor := C.go_openssl_boringssl_flavor(handle); flavor != nil {
+			name = C.GoString(flavor) + " (" + name + " API)"
+		}
+		errInit = errors.New("openssl: " + name + " lacks required functions: " + strings.Join(missing, ", "))
+		return
+	}
+	if opts.TrackAllocations && handle != trackedHandle {
+		if err := trackAllocations(handle); err != nil {
+			errInit = err
 			return
 		}
-		var supported bool
-		if vMajor == 1 {
-			supported = vMinor == 0 || vMinor == 1
-		} else if vMajor == 3 {
-			// OpenSSL team guarantees API and ABI compatibility within the same major version since OpenSSL 3.
-			supported = true
+	}
+	C.go_openssl_load_functions(handle, C.int(vMajor), C.int(vMinor))
+	if opts.SecureHeapSize != 0 {
+		if err := initSecureHeap(opts); err != nil {
+			errInit = err
+			return
 		}
-		if !supported {
-			errInit = errUnsuportedVersion()
+	}
+	loadSystemConfig := !opts.NoLoadConfig && opts.ConfigFile == ""
+	C.go_openssl_OPENSSL_init()
+	if vMajor == 1 && vMinor == 0 {
+		if C.go_openssl_thread_setup() != 1 {
+			errInit = newOpenSSLError("openssl: thread setup")
 			return
 		}
-
-		C.go_openssl_load_functions(handle, C.int(vMajor), C.int(vMinor))
-		C.go_openssl_OPENSSL_init()
-		if vMajor == 1 && vMinor == 0 {
-			if C.go_openssl_thread_setup() != 1 {
-				errInit = newOpenSSLError("openssl: thread setup")
-				return
-			}
+		if loadSystemConfig {
 			C.go_openssl_OPENSSL_add_all_algorithms_conf()
-			C.go_openssl_ERR_load_crypto_strings()
 		} else {
-			flags := C.uint64_t(C.GO_OPENSSL_INIT_ADD_ALL_CIPHERS | C.GO_OPENSSL_INIT_ADD_ALL_DIGESTS | C.GO_OPENSSL_INIT_LOAD_CONFIG | C.GO_OPENSSL_INIT_LOAD_CRYPTO_STRINGS)
-			if C.go_openssl_OPENSSL_init_crypto(flags, nil) != 1 {
-				errInit = newOpenSSLError("openssl: init crypto")
-				return
-			}
+			C.go_openssl_OPENSSL_add_all_algorithms_noconf()
 		}
-	})
-	return errInit
+		C.go_openssl_ERR_load_crypto_strings()
+	} else {
+		flags := C.uint64_t(C.GO_OPENSSL_INIT_ADD_ALL_CIPHERS | C.GO_OPENSSL_INIT_ADD_ALL_DIGESTS | C.GO_OPENSSL_INIT_LOAD_CRYPTO_STRINGS)
+		if loadSystemConfig {
+			flags |= C.GO_OPENSSL_INIT_LOAD_CONFIG
+		} else {
+			flags |= C.GO_OPENSSL_INIT_NO_LOAD_CONFIG
+		}
+		if C.go_openssl_OPENSSL_init_crypto(flags, nil) != 1 {
+			errInit = newOpenSSLError("openssl: init crypto")
+			return
+		}
+	}
+	if opts.ConfigFile != "" {
+		cpath := C.CString(opts.ConfigFile)
+		defer C.free(unsafe.Pointer(cpath))
+		if C.go_openssl_CONF_modules_load_file(cpath, nil, 0) <= 0 {
+			errInit = newOpenSSLError("openssl: loading configuration " + opts.ConfigFile)
+			return
+		}
+	}
+	libHandle = handle
 }
 
-func dlopen(version string) unsafe.Pointer {
-	cv := C.CString("libcrypto.so." + version)
-	defer C.free(unsafe.Pointer(cv))
-	return C.dlopen(cv, C.RTLD_LAZY|C.RTLD_LOCAL)
+// openLibrary loads the libcrypto shared library selected by opts.
+func openLibrary(opts InitOptions) (unsafe.Pointer, error) {
+	if path := libraryPath(opts); path != "" {
+		return loadLibraryPath(path)
+	}
+	if version := libraryVersion(opts); version != "" {
+		// If version is specified try to load it or error out.
+		return loadLibraryPath(libraryName(version))
+	}
+	if len(opts.LibraryNames) > 0 {
+		return loadLibrary(opts.LibraryNames)
+	}
+	return loadLibrary(defaultLibraryNames())
 }
 
-func loadLibrary(version string) (unsafe.Pointer, error) {
-	if version != "" {
-		// If version is specified try to load it or error out.
-		handle := dlopen(version)
-		if handle == nil {
-			errstr := C.GoString(C.dlerror())
-			return nil, errors.New("openssl: can't load libcrypto.so." + version + ": " + errstr)
+// requiredFunctions are the functions Init fails without. Other missing
+// functions only make the operations calling them fail, see
+// go_openssl_missing_function. These are needed by Init, return nothing
+// and would be unsafe to skip, or return structures the stub of
+// go_openssl_missing_function can't return. The security checks whose
+// functions report success with 0, such as X509_NAME_cmp, or that skip
+// a protection when they do nothing, such as OPENSSL_cleanse or
+// TS_VERIFY_CTX_add_flags, would pass with the stub, so their functions
+// are required too. So are the functions whose result is not checked
+// and whose output would silently be left as is, such as HMAC_Final
+// or EVP_EncryptUpdate.
+var requiredFunctions = map[string]bool{
+	"ERR_get_error":                       true,
+	"ERR_clear_error":                     true,
+	"ERR_error_string_n":                  true,
+	"OpenSSL_version":                     true,
+	"OPENSSL_init":                        true,
+	"OPENSSL_init_crypto":                 true,
+	"ERR_load_crypto_strings":             true,
+	"CRYPTO_num_locks":                    true,
+	"CRYPTO_set_id_callback":              true,
+	"CRYPTO_set_locking_callback":         true,
+	"CRYPTO_set_dynlock_create_callback":  true,
+	"CRYPTO_set_dynlock_lock_callback":    true,
+	"CRYPTO_set_dynlock_destroy_callback": true,
+	"OPENSSL_add_all_algorithms_conf":     true,
+	"OPENSSL_add_all_algorithms_noconf":   true,
+	"RSA_get0_key":                        true,
+	"RSA_get0_factors":                    true,
+	"RSA_get0_crt_params":                 true,
+	"OSSL_SELF_TEST_set_callback":         true,
+	"OSSL_INDICATOR_set_callback":         true,
+	"OSSL_PARAM_construct_utf8_string":    true,
+	"OSSL_PARAM_construct_utf8_ptr":       true,
+	"OSSL_PARAM_construct_int":            true,
+	"OSSL_PARAM_construct_end":            true,
+	"OPENSSL_cleanse":                     true,
+	"RAND_add":                            true,
+	"X509_NAME_cmp":                       true,
+	"X509_check_issued":                   true,
+	"X509_CRL_get0_by_serial":             true,
+	"X509_STORE_CTX_get_error":            true,
+	"X509_VERIFY_PARAM_set_time":          true,
+	"X509_VERIFY_PARAM_set_hostflags":     true,
+	"TS_VERIFY_CTX_add_flags":             true,
+	"TS_VERIFY_CTX_set_store":             true,
+	"TS_VERIFY_CTX_set_certs":             true,
+	"EVP_EncryptUpdate":                   true,
+	"EVP_DecryptUpdate":                   true,
+	"HMAC_Update":                         true,
+	"HMAC_Final":                          true,
+	"EVP_MAC_update":                      true,
+	"EVP_MAC_final":                       true,
+	"BN_bn2bin":                           true,
+}
+
+// missingFunctions returns the names of the requiredFunctions that
+// handle lacks, at most 16. BoringSSL and AWS-LC are loaded as
+// OpenSSL 1.1 when they implement all of them.
+func missingFunctions(handle unsafe.Pointer) []string {
+	n := C.go_openssl_missing_functions(handle, C.int(vMajor), C.int(vMinor), nil, 0)
+	if n == 0 {
+		return nil
+	}
+	all := make([]*C.char, n)
+	C.go_openssl_missing_functions(handle, C.int(vMajor), C.int(vMinor), &all[0], n)
+	var names []string
+	var more int
+	for _, name := range all {
+		if !requiredFunctions[C.GoString(name)] {
+			continue
+		}
+		if len(names) == 16 {
+			more++
+			continue
 		}
-		return handle, nil
+		names = append(names, C.GoString(name))
+	}
+	if more > 0 {
+		names = append(names, "and "+strconv.Itoa(more)+" more")
+	}
+	return names
+}
+
+// missingFunctionError returns an error reporting the last function
+// missing from the loaded libcrypto that has been called on the current
+// thread, if any. Like the OpenSSL error queue, it is only reported by
+// the failing operation if its goroutine doesn't move to another thread
+// before getting the error.
+func missingFunctionError(msg string) error {
+	var note *C.char
+	name := C.go_openssl_take_missing_function(&note)
+	if name == nil {
+		return nil
 	}
+	fn := C.GoString(name)
+	return &NotSupportedError{
+		Algorithm: fn,
+		Err:       errors.New(msg + ": the loaded libcrypto lacks " + fn + ", " + C.GoString(note)),
+	}
+}
+
+// libraryPath returns the libcrypto path requested by opts
+// or by the GO_OPENSSL_LIBRARY_PATH environment variable, if any.
+// An explicit opts.Version or opts.LibraryNames disables the environment variable.
+func libraryPath(opts InitOptions) string {
+	if opts.LibraryPath != "" || opts.Version != "" || len(opts.LibraryNames) > 0 {
+		return opts.LibraryPath
+	}
+	path, _ := syscall.Getenv("GO_OPENSSL_LIBRARY_PATH")
+	return path
+}
+
+// libraryVersion returns the libcrypto version suffix requested by opts
+// or by the GO_OPENSSL_VERSION_OVERRIDE environment variable, if any.
+// Explicit opts.LibraryNames disable the environment variable.
+func libraryVersion(opts InitOptions) string {
+	if opts.Version != "" || len(opts.LibraryNames) > 0 {
+		return opts.Version
+	}
+	version, _ := syscall.Getenv("GO_OPENSSL_VERSION_OVERRIDE")
+	return version
+}
+
+// loadLibraryPath loads the libcrypto shared library at path.
+func loadLibraryPath(path string) (unsafe.Pointer, error) {
+	handle := dlopenPath(path)
+	if handle == nil {
+		return nil, errors.New("openssl: can't load " + path + ": " + dlerror())
+	}
+	return handle, nil
+}
+
+// loadLibrary loads the first FIPS enabled library of names, or the first
+// library that exists if none is FIPS enabled. If none can be loaded,
+// the error lists the dlopen error of each name.
+func loadLibrary(names []string) (unsafe.Pointer, error) {
 	var fallbackHandle unsafe.Pointer
-	for _, v := range knownVersions {
-		handle := dlopen(v)
+	var tried []string
+	for _, name := range names {
+		handle := dlopenPath(name)
 		if handle == nil {
+			tried = append(tried, name+": "+dlerror())
 			continue
 		}
+		if v := C.go_openssl_libressl_version(handle); v != nil {
+			tried = append(tried, name+": "+C.GoString(v)+" is not supported")
+			dlclose(handle)
+			continue
+		}
+		if trackLoadedLibraries {
+			// Probing FIPS mode allocates memory.
+			if err := trackAllocations(handle); err != nil {
+				tried = append(tried, name+": "+err.Error())
+				dlclose(handle)
+				continue
+			}
+		}
 		if C.go_openssl_fips_enabled(handle) == 1 {
 			// Found a FIPS enabled version, use it.
 			if fallbackHandle != nil {
 				// If we found a FIPS enabled version but we already have a fallback
 				// version, close the fallback version.
-				C.dlclose(fallbackHandle)
+				dlclose(fallbackHandle)
 			}
 			return handle, nil
 		}
@@ -143,13 +478,16 @@ func loadLibrary(version string) (unsafe.Pointer, error) {
 			// in case we don't find any FIPS enabled version.
 			fallbackHandle = handle
 		} else {
-			C.dlclose(handle)
+			dlclose(handle)
 		}
 	}
 	if fallbackHandle != nil {
 		return fallbackHandle, nil
 	}
-	return nil, errors.New("openssl: can't load libcrypto.so using any known version suffix")
+	if len(tried) == 0 {
+		return nil, errors.New("openssl: no libcrypto library name to load")
+	}
+	return nil, errors.New("openssl: can't load libcrypto, tried:\n\t" + strings.Join(tried, "\n\t"))
 }
 
 // FIPS returns true if OpenSSL is running in FIPS mode, else returns false.
@@ -173,6 +511,8 @@ func FIPS() bool {
 //
 // On OpenSSL 3, the `fips` provider is loaded if enabled is true,
 // else the `default` provider is loaded.
+// Loading the `fips` provider requires it to be configured in openssl.cnf;
+// use LoadFIPSProvider to load it from a FIPS module configuration instead.
 func SetFIPS(enabled bool) error {
 	var mode C.int
 	if enabled {
@@ -218,24 +558,53 @@ func VersionText() string {
 	return C.GoString(C.go_openssl_OpenSSL_version(0))
 }
 
-func newOpenSSLError(msg string) error {
-	var b strings.Builder
-	var e C.ulong
+// VersionNumber returns the OPENSSL_VERSION_NUMBER of the OpenSSL currently loaded,
+// for example 0x30000070 for OpenSSL 3.0.7.
+func VersionNumber() uint64 {
+	return uint64(C.go_openssl_OpenSSL_version_num())
+}
+
+// BuildInfo describes how the OpenSSL currently loaded was built,
+// as reported by OpenSSL_version.
+type BuildInfo struct {
+	Version  string // Version text, as returned by VersionText.
+	CFlags   string // Compiler flags, prefixed with "compiler: ".
+	BuiltOn  string // Build date, prefixed with "built on: ".
+	Platform string // Target platform, prefixed with "platform: ".
+	Dir      string // OPENSSLDIR, prefixed with "OPENSSLDIR: ".
+	// ModulesDir is the directory of the OpenSSL 3 providers,
+	// prefixed with "MODULESDIR: ". It is empty on OpenSSL 1.
+	ModulesDir string
+}
 
-	b.WriteString(msg)
-	b.WriteString("\nopenssl error(s):\n")
+// ReadBuildInfo returns the build information of the OpenSSL currently loaded.
+func ReadBuildInfo() BuildInfo {
+	// OpenSSL 1.0.2 SSLeay_version numbers the types differently.
+	cflags, builtOn, platform, dir := 1, 2, 3, 4
+	if vMajor == 1 && vMinor == 0 {
+		cflags, builtOn, platform, dir = 2, 3, 4, 5
+	}
+	info := BuildInfo{
+		Version:  VersionText(),
+		CFlags:   C.GoString(C.go_openssl_OpenSSL_version(C.int(cflags))),
+		BuiltOn:  C.GoString(C.go_openssl_OpenSSL_version(C.int(builtOn))),
+		Platform: C.GoString(C.go_openssl_OpenSSL_version(C.int(platform))),
+		Dir:      C.GoString(C.go_openssl_OpenSSL_version(C.int(dir))),
+	}
+	if vMajor == 3 {
+		info.ModulesDir = C.GoString(C.go_openssl_OpenSSL_version(8))
+	}
+	return info
+}
 
-	for {
-		e = C.go_openssl_ERR_get_error()
-		if e == 0 {
-			break
-		}
-		var buf [256]byte
-		C.go_openssl_ERR_error_string_n(e, (*C.char)(unsafe.Pointer(&buf[0])), 256)
-		b.Write(buf[:])
-		b.WriteByte('\n')
+// LibraryPath returns the file path of the libcrypto shared library
+// currently loaded, or an empty string if it can't be determined,
+// for example with the openssl_static build tag.
+func LibraryPath() string {
+	if _, ok := staticLibrary(); ok || libHandle == nil {
+		return ""
 	}
-	return errors.New(b.String())
+	return libraryFile(libHandle)
 }
 
 type fail string
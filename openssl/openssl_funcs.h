@@ -17,7 +17,8 @@ enum {
     GO_OPENSSL_INIT_LOAD_CRYPTO_STRINGS = 0x00000002L,
     GO_OPENSSL_INIT_ADD_ALL_CIPHERS = 0x00000004L,
     GO_OPENSSL_INIT_ADD_ALL_DIGESTS = 0x00000008L,
-    GO_OPENSSL_INIT_LOAD_CONFIG = 0x00000040L
+    GO_OPENSSL_INIT_LOAD_CONFIG = 0x00000040L,
+    GO_OPENSSL_INIT_NO_LOAD_CONFIG = 0x00000080L
 };
 
 // #include <openssl/aes.h>
@@ -30,6 +31,9 @@ enum {
 enum {
     GO_EVP_CTRL_GCM_GET_TAG = 0x10,
     GO_EVP_CTRL_GCM_SET_TAG = 0x11,
+    GO_EVP_CTRL_GCM_SET_IV_FIXED = 0x12,
+    GO_EVP_CTRL_GCM_IV_GEN = 0x13,
+    GO_EVP_CIPHER_CTX_FLAG_WRAP_ALLOW = 0x1,
     GO_EVP_PKEY_CTRL_MD = 1,
     GO_EVP_PKEY_RSA = 6,
     GO_EVP_PKEY_EC = 408,
@@ -39,20 +43,150 @@ enum {
 // #include <openssl/ec.h>
 enum {
     GO_EVP_PKEY_CTRL_EC_PARAMGEN_CURVE_NID = 0x1001,
+    GO_OPENSSL_EC_NAMED_CURVE = 0x001
 };
 
 typedef enum {
     GO_POINT_CONVERSION_UNCOMPRESSED = 4,
 } point_conversion_form_t;
 
+// #if OPENSSL_VERSION_NUMBER >= 0x30000000L
+// #include <openssl/core.h>
+enum {
+    GO_OSSL_PARAM_INTEGER = 1,
+    GO_OSSL_PARAM_UNSIGNED_INTEGER = 2,
+    GO_OSSL_PARAM_UTF8_STRING = 4,
+    GO_OSSL_PARAM_OCTET_STRING = 5
+};
+
+enum {
+    GO_OPENSSL_INFO_CONFIG_DIR = 1001
+};
+
+// #include <openssl/evp.h>
+enum {
+    GO_EVP_PKEY_PUBLIC_KEY = 0x86,
+    GO_EVP_PKEY_KEYPAIR = 0x87
+};
+
+// #include <openssl/store.h>
+enum {
+    GO_OSSL_STORE_INFO_PKEY = 4,
+    GO_OSSL_STORE_INFO_CERT = 5
+};
+
+// #include <openssl/trace.h>
+enum {
+    GO_OSSL_TRACE_CTRL_WRITE = 1
+};
+
+// #include <openssl/err.h>
+// #include <openssl/proverr.h>
+enum {
+    GO_ERR_R_UNSUPPORTED = 268 | (0x2 << 18),
+    GO_ERR_R_FETCH_FAILED = 269 | (0x2 << 18),
+    GO_ERR_LIB_PROV = 57,
+    GO_PROV_R_BAD_DECRYPT = 100,
+    GO_PROV_R_DIGEST_NOT_ALLOWED = 174,
+    GO_PROV_R_ERROR_RETRIEVING_ENTROPY = 189
+};
+// #endif
+
 // #include <openssl/obj_mac.h>
 enum {
     GO_NID_X9_62_prime256v1 = 415,
     GO_NID_secp224r1 = 713,
     GO_NID_secp384r1 = 715,
-    GO_NID_secp521r1 = 716
+    GO_NID_secp521r1 = 716,
+    GO_NID_hmacWithSHA1 = 163,
+    GO_NID_hmacWithSHA224 = 798,
+    GO_NID_hmacWithSHA256 = 799,
+    GO_NID_hmacWithSHA384 = 800,
+    GO_NID_hmacWithSHA512 = 801,
+    GO_NID_aes_256_cbc = 427
+};
+
+// #include <openssl/bio.h>
+enum {
+    GO_BIO_CTRL_INFO = 3
 };
 
+// #include <openssl/err.h>
+// #include <openssl/pem.h>
+// #include <openssl/rsa.h>
+// #include <openssl/ec.h>
+// #include <openssl/rand.h>
+enum {
+    GO_ERR_TXT_STRING = 0x02,
+    GO_ERR_LIB_RSA = 4,
+    GO_ERR_LIB_EVP = 6,
+    GO_ERR_LIB_PEM = 9,
+    GO_ERR_LIB_EC = 16,
+    GO_ERR_LIB_RAND = 36,
+    GO_PEM_R_NO_START_LINE = 108,
+    GO_RSA_R_BAD_SIGNATURE = 104,
+    GO_RSA_R_WRONG_SIGNATURE_LENGTH = 119,
+    GO_RSA_R_OAEP_DECODING_ERROR = 121,
+    GO_RSA_R_DIGEST_NOT_ALLOWED = 145,
+    GO_RSA_R_PKCS_DECODING_ERROR = 159,
+    GO_EVP_R_BAD_DECRYPT = 100,
+    GO_EVP_R_UNSUPPORTED_ALGORITHM = 156,
+    GO_EVP_R_UNSUPPORTED_KEY_TYPE = 224,
+    GO_EC_R_BAD_SIGNATURE = 156,
+    GO_RAND_R_ERROR_RETRIEVING_ENTROPY = 110
+};
+
+// #include <openssl/x509.h>
+// #include <openssl/x509v3.h>
+enum {
+    GO_V_ASN1_UTCTIME = 23,
+    GO_V_ASN1_GENERALIZEDTIME = 24,
+    GO_ASN1_STRFLGS_ESC_MSB = 4,
+    GO_XN_FLAG_RFC2253 = 0x1110317,
+    GO_X509_V_OK = 0,
+    GO_X509_V_FLAG_CRL_CHECK = 0x4,
+    GO_X509_V_FLAG_CRL_CHECK_ALL = 0x8,
+    GO_X509_V_FLAG_PARTIAL_CHAIN = 0x80000,
+    GO_X509_CHECK_FLAG_NO_PARTIAL_WILDCARDS = 0x4,
+    GO_MBSTRING_UTF8 = 0x1000,
+    GO_NID_subject_key_identifier = 82,
+    GO_NID_key_usage = 83,
+    GO_NID_subject_alt_name = 85,
+    GO_NID_basic_constraints = 87,
+    GO_NID_authority_key_identifier = 90,
+    GO_NID_ext_key_usage = 126
+};
+
+// #include <openssl/ocsp.h>
+enum {
+    GO_OCSP_RESPONSE_STATUS_SUCCESSFUL = 0,
+    GO_V_OCSP_CERTSTATUS_GOOD = 0,
+    GO_V_OCSP_CERTSTATUS_REVOKED = 1,
+    GO_V_OCSP_CERTSTATUS_UNKNOWN = 2,
+    GO_OCSP_REVOKED_STATUS_NOSTATUS = -1
+};
+
+// #include <openssl/cms.h>
+enum {
+    GO_CMS_NO_SIGNER_CERT_VERIFY = 0x20,
+    GO_CMS_DETACHED = 0x40,
+    GO_CMS_BINARY = 0x80,
+    GO_CMS_PARTIAL = 0x4000
+};
+
+// #include <openssl/ts.h>
+enum {
+    GO_TS_VFY_SIGNATURE = 0x1
+};
+
+// #if OPENSSL_VERSION_NUMBER >= 0x10101000L
+enum {
+    GO_NID_sm2 = 1172,
+    GO_EVP_PKEY_ED25519 = 1087,
+    GO_EVP_PKEY_ED448 = 1088
+};
+// #endif
+
 // #include <openssl/rsa.h>
 enum {
     GO_RSA_PKCS1_PADDING = 1,
@@ -71,6 +205,9 @@ enum {
     GO_EVP_PKEY_CTRL_RSA_OAEP_LABEL = 0x100A
 };
 
+// CRYPTO_dynlock_value is defined by the application, see openssl_lock_setup.c.
+struct CRYPTO_dynlock_value;
+
 typedef void* GO_EVP_CIPHER_PTR;
 typedef void* GO_EVP_CIPHER_CTX_PTR;
 typedef void* GO_EVP_PKEY_PTR;
@@ -80,16 +217,88 @@ typedef void* GO_EVP_MD_CTX_PTR;
 typedef void* GO_HMAC_CTX_PTR;
 typedef void* GO_OPENSSL_INIT_SETTINGS_PTR;
 typedef void* GO_OSSL_LIB_CTX_PTR;
+typedef void* GO_CRYPTO_THREADID_PTR;
 typedef void* GO_OSSL_PROVIDER_PTR;
 typedef void* GO_ENGINE_PTR;
+typedef void* GO_UI_METHOD_PTR;
+typedef void* GO_OSSL_STORE_CTX_PTR;
+typedef void* GO_OSSL_STORE_INFO_PTR;
 typedef void* GO_BIGNUM_PTR;
 typedef void* GO_BN_CTX_PTR;
 typedef void* GO_EC_KEY_PTR;
 typedef void* GO_EC_POINT_PTR;
 typedef void* GO_EC_GROUP_PTR;
+typedef void* GO_ECDSA_SIG_PTR;
 typedef void* GO_RSA_PTR;
 typedef void* GO_EVP_MAC_PTR;
 typedef void* GO_EVP_MAC_CTX_PTR;
+typedef void* GO_EVP_KDF_PTR;
+typedef void* GO_EVP_KDF_CTX_PTR;
+typedef void* GO_OSSL_PARAM_BLD_PTR;
+typedef void* GO_RAND_METHOD_PTR;
+typedef void* GO_EVP_RAND_PTR;
+typedef void* GO_EVP_RAND_CTX_PTR;
+typedef void* GO_OSSL_CALLBACK_PTR;
+typedef void* GO_OSSL_INDICATOR_CALLBACK_PTR;
+typedef void* GO_EVP_KEYMGMT_PTR;
+typedef void* GO_SRP_gN_PTR;
+typedef void* GO_PKCS8_PRIV_KEY_INFO_PTR;
+typedef void* GO_X509_SIG_PTR;
+typedef void* GO_X509_ALGOR_PTR;
+typedef void* GO_X509_PTR;
+typedef void* GO_X509_NAME_PTR;
+typedef void* GO_X509_REQ_PTR;
+typedef void* GO_X509_EXTENSION_PTR;
+typedef void* GO_STACK_OF_X509_EXTENSION_PTR;
+typedef void* GO_X509V3_CTX_PTR;
+typedef void* GO_CONF_PTR;
+typedef void* GO_X509_CRL_PTR;
+typedef void* GO_X509_REVOKED_PTR;
+typedef void* GO_X509_STORE_PTR;
+typedef void* GO_OCSP_CERTID_PTR;
+typedef void* GO_OCSP_REQUEST_PTR;
+typedef void* GO_OCSP_ONEREQ_PTR;
+typedef void* GO_OCSP_RESPONSE_PTR;
+typedef void* GO_OCSP_BASICRESP_PTR;
+typedef void* GO_CMS_ContentInfo_PTR;
+typedef void* GO_CMS_SignerInfo_PTR;
+typedef void* GO_PKCS7_PTR;
+typedef void* GO_TS_REQ_PTR;
+typedef void* GO_TS_MSG_IMPRINT_PTR;
+typedef void* GO_TS_RESP_PTR;
+typedef void* GO_TS_TST_INFO_PTR;
+typedef void* GO_TS_VERIFY_CTX_PTR;
+typedef void* GO_ASN1_OBJECT_PTR;
+typedef void* GO_X509_STORE_CTX_PTR;
+typedef void* GO_X509_VERIFY_PARAM_PTR;
+typedef void* GO_X509_PURPOSE_PTR;
+typedef void* GO_ASN1_INTEGER_PTR;
+typedef void* GO_ASN1_STRING_PTR;
+typedef void* GO_PKCS12_PTR;
+typedef void* GO_OPENSSL_STACK_PTR;
+typedef void* GO_STACK_OF_X509_PTR;
+typedef void* GO_BIO_PTR;
+typedef void* GO_BIO_METHOD_PTR;
+typedef void* GO_pem_password_cb_PTR;
+typedef void* GO_ASYNC_JOB_PTR;
+typedef void* GO_ASYNC_WAIT_CTX_PTR;
+
+// OSSL_ASYNC_FD is a file descriptor on Unix and a HANDLE on Windows.
+// #include <openssl/async.h>
+// #define GO_OSSL_ASYNC_FD OSSL_ASYNC_FD
+#ifdef _WIN32
+typedef void *GO_OSSL_ASYNC_FD;
+#else
+typedef int GO_OSSL_ASYNC_FD;
+#endif
+
+// #include <openssl/async.h>
+enum {
+    GO_ASYNC_ERR = 0,
+    GO_ASYNC_NO_JOBS = 1,
+    GO_ASYNC_PAUSE = 2,
+    GO_ASYNC_FINISH = 3
+};
 
 // OSSL_PARAM does not follow the GO_FOO_PTR pattern
 // because it is not passed around as a pointer but on the stack.
@@ -117,22 +326,27 @@ typedef struct {
 //
 // DEFINEFUNC defines and loads openssl functions that can be directly called from Go as their signatures match
 // the OpenSSL API and do not require special logic.
-// The process will be aborted if the function can't be loaded.
+// Init fails if a function it requires can't be loaded, see requiredFunctions.
+// Calling any other function that can't be loaded fails as if OpenSSL
+// reported an error, see go_openssl_missing_function.
 //
-// DEFINEFUNC_LEGACY_1_0 acts like DEFINEFUNC but only aborts the process if the function can't be loaded
+// DEFINEFUNC_LEGACY_1_0 acts like DEFINEFUNC but only loads the function
 // when using 1.0.x. This indicates the function is required when using 1.0.x, but is unused when using later versions.
 // It also might not exist in later versions.
 //
-// DEFINEFUNC_LEGACY_1 acts like DEFINEFUNC but only aborts the process if the function can't be loaded
+// DEFINEFUNC_LEGACY_1 acts like DEFINEFUNC but only loads the function
 // when using 1.x. This indicates the function is required when using 1.x, but is unused when using later versions.
 // It also might not exist in later versions.
 //
-// DEFINEFUNC_1_1 acts like DEFINEFUNC but only aborts the process if function can't be loaded
+// DEFINEFUNC_1_1 acts like DEFINEFUNC but only loads the function
 // when using 1.1.0 or higher.
 //
-// DEFINEFUNC_3_0 acts like DEFINEFUNC but only aborts the process if function can't be loaded
+// DEFINEFUNC_3_0 acts like DEFINEFUNC but only loads the function
 // when using 3.0.0 or higher.
 //
+// DEFINEFUNC_3_4 acts like DEFINEFUNC but only loads the function
+// when using 3.4.0 or higher. Callers must check the version before using it.
+//
 // DEFINEFUNC_RENAMED_1_1 acts like DEFINEFUNC but tries to load the function using the new name when using >= 1.1.x
 // and the old name when using 1.0.2. In both cases the function will have the new name.
 //
@@ -140,25 +354,61 @@ typedef struct {
 // and the old name when using 1.x. In both cases the function will have the new name.
 //
 // #include <openssl/crypto.h>
+// #include <openssl/conf.h>
 // #include <openssl/err.h>
 // #include <openssl/rsa.h>
 // #include <openssl/hmac.h>
 // #include <openssl/ec.h>
 // #include <openssl/rand.h>
 // #include <openssl/evp.h>
+// #include <openssl/srp.h>
+// #include <openssl/x509.h>
+// #include <openssl/x509v3.h>
+// #include <openssl/pkcs12.h>
+// #include <openssl/engine.h>
+// #include <openssl/async.h>
 // #if OPENSSL_VERSION_NUMBER >= 0x30000000L
 // #include <openssl/provider.h>
+// #include <openssl/kdf.h>
+// #include <openssl/params.h>
+// #include <openssl/param_build.h>
+// #include <openssl/self_test.h>
+// #include <openssl/store.h>
+// #include <openssl/ui.h>
+// #include <openssl/trace.h>
+// #endif
+// #if OPENSSL_VERSION_NUMBER >= 0x30400000L
+// #include <openssl/indicator.h>
 // #endif
 #define FOR_ALL_OPENSSL_FUNCTIONS \
 DEFINEFUNC(unsigned long, ERR_get_error, (void), ()) \
+DEFINEFUNC(void, ERR_clear_error, (void), ()) \
+DEFINEFUNC(int, ERR_set_mark, (void), ()) \
+DEFINEFUNC(int, ERR_pop_to_mark, (void), ()) \
 DEFINEFUNC(void, ERR_error_string_n, (unsigned long e, char *buf, size_t len), (e, buf, len)) \
+DEFINEFUNC(const char *, ERR_lib_error_string, (unsigned long e), (e)) \
+DEFINEFUNC(const char *, ERR_reason_error_string, (unsigned long e), (e)) \
+DEFINEFUNC_LEGACY_1(unsigned long, ERR_get_error_line_data, (const char **file, int *line, const char **data, int *flags), (file, line, data, flags)) \
+DEFINEFUNC_LEGACY_1(const char *, ERR_func_error_string, (unsigned long e), (e)) \
+DEFINEFUNC_3_0(unsigned long, ERR_get_error_all, (const char **file, int *line, const char **func, const char **data, int *flags), (file, line, func, data, flags)) \
 DEFINEFUNC_RENAMED_1_1(const char *, OpenSSL_version, SSLeay_version, (int type), (type)) \
+DEFINEFUNC_RENAMED_1_1(unsigned long, OpenSSL_version_num, SSLeay, (void), ()) \
 DEFINEFUNC(void, OPENSSL_init, (void), ()) \
+DEFINEFUNC(void, OPENSSL_cleanse, (void *ptr, size_t len), (ptr, len)) \
 DEFINEFUNC_LEGACY_1_0(void, ERR_load_crypto_strings, (void), ()) \
 DEFINEFUNC_LEGACY_1_0(int, CRYPTO_num_locks, (void), ()) \
 DEFINEFUNC_LEGACY_1_0(void, CRYPTO_set_id_callback, (unsigned long (*id_function)(void)), (id_function)) \
 DEFINEFUNC_LEGACY_1_0(void, CRYPTO_set_locking_callback, (void (*locking_function)(int mode, int n, const char *file, int line)), (locking_function)) \
+DEFINEFUNC_LEGACY_1_0(void, CRYPTO_set_dynlock_create_callback, (struct CRYPTO_dynlock_value *(*dyn_create_function)(const char *file, int line)), (dyn_create_function)) \
+DEFINEFUNC_LEGACY_1_0(void, CRYPTO_set_dynlock_lock_callback, (void (*dyn_lock_function)(int mode, struct CRYPTO_dynlock_value *l, const char *file, int line)), (dyn_lock_function)) \
+DEFINEFUNC_LEGACY_1_0(void, CRYPTO_set_dynlock_destroy_callback, (void (*dyn_destroy_function)(struct CRYPTO_dynlock_value *l, const char *file, int line)), (dyn_destroy_function)) \
+/* minsize is an int before OpenSSL 3.0, small values are passed the same way. */ \
+DEFINEFUNC_1_1(int, CRYPTO_secure_malloc_init, (size_t sz, size_t minsize), (sz, minsize)) \
+DEFINEFUNC_1_1(int, CRYPTO_secure_malloc_initialized, (void), ()) \
+DEFINEFUNC_1_1(size_t, CRYPTO_secure_used, (void), ()) \
 DEFINEFUNC_LEGACY_1_0(void, OPENSSL_add_all_algorithms_conf, (void), ()) \
+DEFINEFUNC_LEGACY_1_0(void, OPENSSL_add_all_algorithms_noconf, (void), ()) \
+DEFINEFUNC(int, CONF_modules_load_file, (const char *filename, const char *appname, unsigned long flags), (filename, appname, flags)) \
 DEFINEFUNC_1_1(int, OPENSSL_init_crypto, (uint64_t ops, const GO_OPENSSL_INIT_SETTINGS_PTR settings), (ops, settings)) \
 DEFINEFUNC_LEGACY_1(int, FIPS_mode, (void), ()) \
 DEFINEFUNC_LEGACY_1(int, FIPS_mode_set, (int r), (r)) \
@@ -167,11 +417,17 @@ DEFINEFUNC_3_0(int, EVP_default_properties_enable_fips, (GO_OSSL_LIB_CTX_PTR lib
 DEFINEFUNC_3_0(GO_OSSL_PROVIDER_PTR, OSSL_PROVIDER_load, (GO_OSSL_LIB_CTX_PTR libctx, const char *name), (libctx, name)) \
 DEFINEFUNC_3_0(int, OSSL_PROVIDER_available, (GO_OSSL_LIB_CTX_PTR libctx, const char *name), (libctx, name)) \
 DEFINEFUNC(int, RAND_bytes, (unsigned char* arg0, int arg1), (arg0, arg1)) \
+DEFINEFUNC(void, RAND_add, (const void *buf, int num, double randomness), (buf, num, randomness)) \
+DEFINEFUNC(int, RAND_set_rand_method, (const GO_RAND_METHOD_PTR meth), (meth)) \
 DEFINEFUNC(int, EVP_DigestInit, (GO_EVP_MD_CTX_PTR ctx, const GO_EVP_MD_PTR type), (ctx, type)) \
 DEFINEFUNC(int, EVP_DigestInit_ex, (GO_EVP_MD_CTX_PTR ctx, const GO_EVP_MD_PTR type, GO_ENGINE_PTR impl), (ctx, type, impl)) \
 DEFINEFUNC(int, EVP_DigestUpdate, (GO_EVP_MD_CTX_PTR ctx, const void *d, size_t cnt), (ctx, d, cnt)) \
 DEFINEFUNC(int, EVP_DigestFinal_ex, (GO_EVP_MD_CTX_PTR ctx, unsigned char *md, unsigned int *s), (ctx, md, s)) \
 DEFINEFUNC(int, EVP_DigestFinal, (GO_EVP_MD_CTX_PTR ctx, unsigned char *md, unsigned int *s), (ctx, md, s)) \
+DEFINEFUNC(int, EVP_DigestSignInit, (GO_EVP_MD_CTX_PTR ctx, GO_EVP_PKEY_CTX_PTR *pctx, const GO_EVP_MD_PTR type, GO_ENGINE_PTR e, GO_EVP_PKEY_PTR pkey), (ctx, pctx, type, e, pkey)) \
+DEFINEFUNC(int, EVP_DigestSignFinal, (GO_EVP_MD_CTX_PTR ctx, unsigned char *sig, size_t *siglen), (ctx, sig, siglen)) \
+DEFINEFUNC(int, EVP_DigestVerifyInit, (GO_EVP_MD_CTX_PTR ctx, GO_EVP_PKEY_CTX_PTR *pctx, const GO_EVP_MD_PTR type, GO_ENGINE_PTR e, GO_EVP_PKEY_PTR pkey), (ctx, pctx, type, e, pkey)) \
+DEFINEFUNC(int, EVP_DigestVerifyFinal, (GO_EVP_MD_CTX_PTR ctx, const unsigned char *sig, size_t siglen), (ctx, sig, siglen)) \
 DEFINEFUNC_RENAMED_1_1(GO_EVP_MD_CTX_PTR, EVP_MD_CTX_new, EVP_MD_CTX_create, (), ()) \
 DEFINEFUNC_RENAMED_1_1(void, EVP_MD_CTX_free, EVP_MD_CTX_destroy, (GO_EVP_MD_CTX_PTR ctx), (ctx)) \
 DEFINEFUNC(int, EVP_MD_CTX_copy_ex, (GO_EVP_MD_CTX_PTR out, const GO_EVP_MD_CTX_PTR in), (out, in)) \
@@ -200,7 +456,12 @@ DEFINEFUNC(int, EVP_CIPHER_CTX_set_padding, (GO_EVP_CIPHER_CTX_PTR x, int paddin
 DEFINEFUNC(int, EVP_CipherInit_ex, (GO_EVP_CIPHER_CTX_PTR ctx, const GO_EVP_CIPHER_PTR type, GO_ENGINE_PTR impl, const unsigned char *key, const unsigned char *iv, int enc), (ctx, type, impl, key, iv, enc)) \
 DEFINEFUNC(int, EVP_CipherUpdate, (GO_EVP_CIPHER_CTX_PTR ctx, unsigned char *out, int *outl, const unsigned char *in, int inl), (ctx, out, outl, in, inl)) \
 DEFINEFUNC(GO_BIGNUM_PTR, BN_new, (void), ()) \
+DEFINEFUNC_1_1(GO_BIGNUM_PTR, BN_secure_new, (void), ()) \
 DEFINEFUNC(void, BN_free, (GO_BIGNUM_PTR arg0), (arg0)) \
+DEFINEFUNC(GO_BN_CTX_PTR, BN_CTX_new, (void), ()) \
+DEFINEFUNC(void, BN_CTX_free, (GO_BN_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC(int, BN_nnmod, (GO_BIGNUM_PTR r, const GO_BIGNUM_PTR m, const GO_BIGNUM_PTR d, GO_BN_CTX_PTR ctx), (r, m, d, ctx)) \
+DEFINEFUNC(GO_BIGNUM_PTR, BN_mod_inverse, (GO_BIGNUM_PTR ret, const GO_BIGNUM_PTR a, const GO_BIGNUM_PTR n, GO_BN_CTX_PTR ctx), (ret, a, n, ctx)) \
 DEFINEFUNC(void, BN_clear_free, (GO_BIGNUM_PTR arg0), (arg0)) \
 DEFINEFUNC(int, BN_num_bits, (const GO_BIGNUM_PTR arg0), (arg0)) \
 DEFINEFUNC(GO_BIGNUM_PTR, BN_bin2bn, (const unsigned char *arg0, int arg1, GO_BIGNUM_PTR arg2), (arg0, arg1, arg2)) \
@@ -210,6 +471,7 @@ DEFINEFUNC(int, BN_bn2bin, (const GO_BIGNUM_PTR arg0, unsigned char *arg1), (arg
 /*check:from=1.1.0*/ DEFINEFUNC_RENAMED_1_1(int, BN_bn2lebinpad, bn_bn2lebinpad, (const GO_BIGNUM_PTR a, unsigned char *to, int tolen), (a, to, tolen)) \
 /*check:from=1.1.0*/ DEFINEFUNC_RENAMED_1_1(int, BN_bn2binpad, bn_bn2binpad, (const GO_BIGNUM_PTR a, unsigned char *to, int tolen), (a, to, tolen)) \
 DEFINEFUNC(void, EC_GROUP_free, (GO_EC_GROUP_PTR arg0), (arg0)) \
+DEFINEFUNC(int, EC_GROUP_get_order, (const GO_EC_GROUP_PTR group, GO_BIGNUM_PTR order, GO_BN_CTX_PTR ctx), (group, order, ctx)) \
 DEFINEFUNC(GO_EC_POINT_PTR, EC_POINT_new, (const GO_EC_GROUP_PTR arg0), (arg0)) \
 DEFINEFUNC(void, EC_POINT_free, (GO_EC_POINT_PTR arg0), (arg0)) \
 DEFINEFUNC(int, EC_POINT_get_affine_coordinates_GFp, (const GO_EC_GROUP_PTR arg0, const GO_EC_POINT_PTR arg1, GO_BIGNUM_PTR arg2, GO_BIGNUM_PTR arg3, GO_BN_CTX_PTR arg4), (arg0, arg1, arg2, arg3, arg4)) \
@@ -225,6 +487,15 @@ DEFINEFUNC(int, EC_KEY_set_private_key, (GO_EC_KEY_PTR arg0, const GO_BIGNUM_PTR
 DEFINEFUNC_1_1(int, EC_KEY_oct2key, (GO_EC_KEY_PTR eckey, const unsigned char *buf, size_t len, GO_BN_CTX_PTR ctx), (eckey, buf, len, ctx)) \
 DEFINEFUNC(const GO_BIGNUM_PTR, EC_KEY_get0_private_key, (const GO_EC_KEY_PTR arg0), (arg0)) \
 DEFINEFUNC(const GO_EC_POINT_PTR, EC_KEY_get0_public_key, (const GO_EC_KEY_PTR arg0), (arg0)) \
+DEFINEFUNC(GO_ECDSA_SIG_PTR, ECDSA_do_sign, (const unsigned char *dgst, int dgst_len, GO_EC_KEY_PTR eckey), (dgst, dgst_len, eckey)) \
+DEFINEFUNC(GO_ECDSA_SIG_PTR, ECDSA_do_sign_ex, (const unsigned char *dgst, int dgstlen, const GO_BIGNUM_PTR kinv, const GO_BIGNUM_PTR rp, GO_EC_KEY_PTR eckey), (dgst, dgstlen, kinv, rp, eckey)) \
+DEFINEFUNC(int, ECDSA_do_verify, (const unsigned char *dgst, int dgst_len, const GO_ECDSA_SIG_PTR sig, GO_EC_KEY_PTR eckey), (dgst, dgst_len, sig, eckey)) \
+DEFINEFUNC(GO_ECDSA_SIG_PTR, ECDSA_SIG_new, (void), ()) \
+DEFINEFUNC(void, ECDSA_SIG_free, (GO_ECDSA_SIG_PTR sig), (sig)) \
+DEFINEFUNC_1_1(void, ECDSA_SIG_get0, (const GO_ECDSA_SIG_PTR sig, const GO_BIGNUM_PTR *pr, const GO_BIGNUM_PTR *ps), (sig, pr, ps)) \
+DEFINEFUNC_1_1(int, ECDSA_SIG_set0, (GO_ECDSA_SIG_PTR sig, GO_BIGNUM_PTR r, GO_BIGNUM_PTR s), (sig, r, s)) \
+DEFINEFUNC(GO_ECDSA_SIG_PTR, d2i_ECDSA_SIG, (GO_ECDSA_SIG_PTR *sig, const unsigned char **pp, long len), (sig, pp, len)) \
+DEFINEFUNC(int, i2d_ECDSA_SIG, (const GO_ECDSA_SIG_PTR sig, unsigned char **pp), (sig, pp)) \
 DEFINEFUNC(GO_RSA_PTR, RSA_new, (void), ()) \
 DEFINEFUNC(void, RSA_free, (GO_RSA_PTR arg0), (arg0)) \
 DEFINEFUNC_1_1(int, RSA_set0_factors, (GO_RSA_PTR rsa, GO_BIGNUM_PTR p, GO_BIGNUM_PTR q), (rsa, p, q)) \
@@ -250,7 +521,12 @@ DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_256_cbc, (void), ()) \
 DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_256_ctr, (void), ()) \
 DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_256_ecb, (void), ()) \
 DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_256_gcm, (void), ()) \
+DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_128_wrap, (void), ()) \
+DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_192_wrap, (void), ()) \
+DEFINEFUNC(const GO_EVP_CIPHER_PTR, EVP_aes_256_wrap, (void), ()) \
+DEFINEFUNC(void, EVP_CIPHER_CTX_set_flags, (GO_EVP_CIPHER_CTX_PTR ctx, int flags), (ctx, flags)) \
 DEFINEFUNC(void, EVP_CIPHER_CTX_free, (GO_EVP_CIPHER_CTX_PTR arg0), (arg0)) \
+DEFINEFUNC_RENAMED_1_1(int, EVP_CIPHER_CTX_reset, EVP_CIPHER_CTX_cleanup, (GO_EVP_CIPHER_CTX_PTR arg0), (arg0)) \
 DEFINEFUNC(int, EVP_CIPHER_CTX_ctrl, (GO_EVP_CIPHER_CTX_PTR ctx, int type, int arg, void *ptr), (ctx, type, arg, ptr)) \
 DEFINEFUNC(GO_EVP_PKEY_PTR, EVP_PKEY_new, (void), ()) \
 /* EVP_PKEY_size and EVP_PKEY_get_bits pkey parameter is const since OpenSSL 1.1.1. */ \
@@ -267,6 +543,7 @@ DEFINEFUNC(GO_EVP_PKEY_CTX_PTR, EVP_PKEY_CTX_new_id, (int id, GO_ENGINE_PTR e),
 DEFINEFUNC(int, EVP_PKEY_keygen_init, (GO_EVP_PKEY_CTX_PTR ctx), (ctx)) \
 DEFINEFUNC(int, EVP_PKEY_keygen, (GO_EVP_PKEY_CTX_PTR ctx, GO_EVP_PKEY_PTR *ppkey), (ctx, ppkey)) \
 DEFINEFUNC(void, EVP_PKEY_CTX_free, (GO_EVP_PKEY_CTX_PTR arg0), (arg0)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, EVP_PKEY_CTX_get0_pkey, (GO_EVP_PKEY_CTX_PTR ctx), (ctx)) \
 DEFINEFUNC(int, EVP_PKEY_CTX_ctrl, (GO_EVP_PKEY_CTX_PTR ctx, int keytype, int optype, int cmd, int p1, void *p2), (ctx, keytype, optype, cmd, p1, p2)) \
 DEFINEFUNC(int, EVP_PKEY_decrypt, (GO_EVP_PKEY_CTX_PTR arg0, unsigned char *arg1, size_t *arg2, const unsigned char *arg3, size_t arg4), (arg0, arg1, arg2, arg3, arg4)) \
 DEFINEFUNC(int, EVP_PKEY_encrypt, (GO_EVP_PKEY_CTX_PTR arg0, unsigned char *arg1, size_t *arg2, const unsigned char *arg3, size_t arg4), (arg0, arg1, arg2, arg3, arg4)) \
@@ -289,4 +566,327 @@ DEFINEFUNC_3_0(int, EVP_MAC_final, (GO_EVP_MAC_CTX_PTR ctx, unsigned char *out,
 DEFINEFUNC_3_0(OSSL_PARAM, OSSL_PARAM_construct_utf8_string, (const char *key, char *buf, size_t bsize), (key, buf, bsize)) \
 DEFINEFUNC_3_0(OSSL_PARAM, OSSL_PARAM_construct_end, (void), ()) \
 DEFINEFUNC_3_0(int, EVP_PKEY_CTX_set0_rsa_oaep_label, (GO_EVP_PKEY_CTX_PTR ctx, void *label, int len), (ctx, label, len)) \
+DEFINEFUNC_3_0(GO_EVP_KDF_PTR, EVP_KDF_fetch, (GO_OSSL_LIB_CTX_PTR libctx, const char *algorithm, const char *properties), (libctx, algorithm, properties)) \
+DEFINEFUNC_3_0(void, EVP_KDF_free, (GO_EVP_KDF_PTR kdf), (kdf)) \
+DEFINEFUNC_3_0(GO_EVP_KDF_CTX_PTR, EVP_KDF_CTX_new, (GO_EVP_KDF_PTR kdf), (kdf)) \
+DEFINEFUNC_3_0(void, EVP_KDF_CTX_free, (GO_EVP_KDF_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(const OSSL_PARAM *, EVP_KDF_CTX_settable_params, (GO_EVP_KDF_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_KDF_CTX_set_params, (GO_EVP_KDF_CTX_PTR ctx, const OSSL_PARAM params[]), (ctx, params)) \
+DEFINEFUNC_3_0(size_t, EVP_KDF_CTX_get_kdf_size, (GO_EVP_KDF_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_KDF_derive, (GO_EVP_KDF_CTX_PTR ctx, unsigned char *key, size_t keylen, const OSSL_PARAM params[]), (ctx, key, keylen, params)) \
+DEFINEFUNC_3_0(const OSSL_PARAM *, OSSL_PARAM_locate_const, (const OSSL_PARAM *p, const char *key), (p, key)) \
+DEFINEFUNC_3_0(void, OSSL_PARAM_free, (OSSL_PARAM *p), (p)) \
+DEFINEFUNC_3_0(GO_OSSL_PARAM_BLD_PTR, OSSL_PARAM_BLD_new, (void), ()) \
+DEFINEFUNC_3_0(void, OSSL_PARAM_BLD_free, (GO_OSSL_PARAM_BLD_PTR bld), (bld)) \
+DEFINEFUNC_3_0(OSSL_PARAM *, OSSL_PARAM_BLD_to_param, (GO_OSSL_PARAM_BLD_PTR bld), (bld)) \
+DEFINEFUNC_3_0(int, OSSL_PARAM_BLD_push_int64, (GO_OSSL_PARAM_BLD_PTR bld, const char *key, int64_t val), (bld, key, val)) \
+DEFINEFUNC_3_0(int, OSSL_PARAM_BLD_push_uint64, (GO_OSSL_PARAM_BLD_PTR bld, const char *key, uint64_t val), (bld, key, val)) \
+DEFINEFUNC_3_0(int, OSSL_PARAM_BLD_push_utf8_string, (GO_OSSL_PARAM_BLD_PTR bld, const char *key, const char *buf, size_t bsize), (bld, key, buf, bsize)) \
+DEFINEFUNC_3_0(int, OSSL_PARAM_BLD_push_octet_string, (GO_OSSL_PARAM_BLD_PTR bld, const char *key, const void *buf, size_t bsize), (bld, key, buf, bsize)) \
+DEFINEFUNC(int, PKCS5_PBKDF2_HMAC, (const char *pass, int passlen, const unsigned char *salt, int saltlen, int iter, const GO_EVP_MD_PTR digest, int keylen, unsigned char *out), (pass, passlen, salt, saltlen, iter, digest, keylen, out)) \
+DEFINEFUNC_3_0(int, RAND_set_DRBG_type, (GO_OSSL_LIB_CTX_PTR ctx, const char *drbg, const char *propq, const char *cipher, const char *digest), (ctx, drbg, propq, cipher, digest)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_CTX_PTR, RAND_get0_primary, (GO_OSSL_LIB_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_PTR, EVP_RAND_CTX_get0_rand, (GO_EVP_RAND_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(const char *, EVP_RAND_get0_name, (const GO_EVP_RAND_PTR rand), (rand)) \
+DEFINEFUNC_3_0(unsigned int, EVP_RAND_get_strength, (GO_EVP_RAND_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_RAND_CTX_set_params, (GO_EVP_RAND_CTX_PTR ctx, const OSSL_PARAM params[]), (ctx, params)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_CTX_PTR, RAND_get0_public, (GO_OSSL_LIB_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_CTX_PTR, RAND_get0_private, (GO_OSSL_LIB_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_RAND_generate, (GO_EVP_RAND_CTX_PTR ctx, unsigned char *out, size_t outlen, unsigned int strength, int prediction_resistance, const unsigned char *addin, size_t addin_len), (ctx, out, outlen, strength, prediction_resistance, addin, addin_len)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_CTX_PTR, EVP_RAND_CTX_new, (GO_EVP_RAND_PTR rand, GO_EVP_RAND_CTX_PTR parent), (rand, parent)) \
+DEFINEFUNC_3_0(void, EVP_RAND_CTX_free, (GO_EVP_RAND_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_RAND_CTX_get_params, (GO_EVP_RAND_CTX_PTR ctx, OSSL_PARAM params[]), (ctx, params)) \
+DEFINEFUNC_3_0(int, EVP_RAND_instantiate, (GO_EVP_RAND_CTX_PTR ctx, unsigned int strength, int prediction_resistance, const unsigned char *pstr, size_t pstr_len, const OSSL_PARAM params[]), (ctx, strength, prediction_resistance, pstr, pstr_len, params)) \
+DEFINEFUNC_3_0(GO_EVP_RAND_PTR, EVP_RAND_fetch, (GO_OSSL_LIB_CTX_PTR libctx, const char *algorithm, const char *properties), (libctx, algorithm, properties)) \
+DEFINEFUNC_3_0(void, EVP_RAND_free, (GO_EVP_RAND_PTR rand), (rand)) \
+DEFINEFUNC_3_0(int, RAND_set_seed_source_type, (GO_OSSL_LIB_CTX_PTR ctx, const char *seed, const char *propq), (ctx, seed, propq)) \
+DEFINEFUNC_3_0(const char *, OPENSSL_info, (int t), (t)) \
+DEFINEFUNC_3_0(int, OSSL_LIB_CTX_load_config, (GO_OSSL_LIB_CTX_PTR ctx, const char *config_file), (ctx, config_file)) \
+DEFINEFUNC_3_0(int, OSSL_PROVIDER_unload, (GO_OSSL_PROVIDER_PTR prov), (prov)) \
+DEFINEFUNC_3_0(int, OSSL_PROVIDER_get_params, (const GO_OSSL_PROVIDER_PTR prov, OSSL_PARAM params[]), (prov, params)) \
+DEFINEFUNC_3_0(OSSL_PARAM, OSSL_PARAM_construct_utf8_ptr, (const char *key, char **buf, size_t bsize), (key, buf, bsize)) \
+DEFINEFUNC_3_0(GO_OSSL_LIB_CTX_PTR, OSSL_LIB_CTX_new, (void), ()) \
+DEFINEFUNC_3_0(void, OSSL_LIB_CTX_free, (GO_OSSL_LIB_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(GO_EVP_MD_PTR, EVP_MD_fetch, (GO_OSSL_LIB_CTX_PTR ctx, const char *algorithm, const char *properties), (ctx, algorithm, properties)) \
+DEFINEFUNC_3_0(void, EVP_MD_free, (GO_EVP_MD_PTR md), (md)) \
+DEFINEFUNC_3_0(int, EVP_MD_get_block_size, (const GO_EVP_MD_PTR md), (md)) \
+DEFINEFUNC_3_0(GO_EVP_CIPHER_PTR, EVP_CIPHER_fetch, (GO_OSSL_LIB_CTX_PTR ctx, const char *algorithm, const char *properties), (ctx, algorithm, properties)) \
+DEFINEFUNC_3_0(void, EVP_CIPHER_free, (GO_EVP_CIPHER_PTR cipher), (cipher)) \
+DEFINEFUNC_3_0(const char *, EVP_CIPHER_get0_name, (const GO_EVP_CIPHER_PTR cipher), (cipher)) \
+DEFINEFUNC_3_0(const GO_OSSL_PROVIDER_PTR, EVP_CIPHER_get0_provider, (const GO_EVP_CIPHER_PTR cipher), (cipher)) \
+DEFINEFUNC_3_0(void, OSSL_SELF_TEST_set_callback, (GO_OSSL_LIB_CTX_PTR libctx, GO_OSSL_CALLBACK_PTR cb, void *cbarg), (libctx, cb, cbarg)) \
+DEFINEFUNC_3_0(int, OSSL_PROVIDER_self_test, (const GO_OSSL_PROVIDER_PTR prov), (prov)) \
+DEFINEFUNC_3_0(int, OSSL_trace_get_category_num, (const char *name), (name)) \
+DEFINEFUNC_3_0(const char *, OSSL_trace_get_category_name, (int num), (num)) \
+DEFINEFUNC_3_0(int, OSSL_trace_set_callback, (int category, size_t (*callback)(const char *buffer, size_t count, int category, int cmd, void *data), void *data), (category, callback, data)) \
+DEFINEFUNC_3_4(void, OSSL_INDICATOR_set_callback, (GO_OSSL_LIB_CTX_PTR libctx, GO_OSSL_INDICATOR_CALLBACK_PTR cb), (libctx, cb)) \
+DEFINEFUNC_3_0(int, OSSL_PROVIDER_do_all, (GO_OSSL_LIB_CTX_PTR ctx, int (*cb)(GO_OSSL_PROVIDER_PTR provider, void *cbdata), void *cbdata), (ctx, cb, cbdata)) \
+DEFINEFUNC_3_0(const char *, OSSL_PROVIDER_get0_name, (const GO_OSSL_PROVIDER_PTR prov), (prov)) \
+DEFINEFUNC_3_0(OSSL_PARAM, OSSL_PARAM_construct_int, (const char *key, int *buf), (key, buf)) \
+DEFINEFUNC_3_0(GO_EVP_PKEY_CTX_PTR, EVP_PKEY_CTX_new_from_name, (GO_OSSL_LIB_CTX_PTR libctx, const char *name, const char *propquery), (libctx, name, propquery)) \
+DEFINEFUNC_3_0(GO_EVP_PKEY_CTX_PTR, EVP_PKEY_CTX_new_from_pkey, (GO_OSSL_LIB_CTX_PTR libctx, GO_EVP_PKEY_PTR pkey, const char *propquery), (libctx, pkey, propquery)) \
+DEFINEFUNC_3_0(const OSSL_PARAM *, EVP_PKEY_CTX_settable_params, (const GO_EVP_PKEY_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_CTX_set_params, (GO_EVP_PKEY_CTX_PTR ctx, const OSSL_PARAM *params), (ctx, params)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_generate, (GO_EVP_PKEY_CTX_PTR ctx, GO_EVP_PKEY_PTR *ppkey), (ctx, ppkey)) \
+DEFINEFUNC_3_0(const char *, EVP_PKEY_get0_type_name, (const GO_EVP_PKEY_PTR key), (key)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_get_group_name, (const GO_EVP_PKEY_PTR pkey, char *name, size_t name_sz, size_t *gname_len), (pkey, name, name_sz, gname_len)) \
+DEFINEFUNC_3_0(int, EVP_DigestSignInit_ex, (GO_EVP_MD_CTX_PTR ctx, GO_EVP_PKEY_CTX_PTR *pctx, const char *mdname, GO_OSSL_LIB_CTX_PTR libctx, const char *props, GO_EVP_PKEY_PTR pkey, const OSSL_PARAM params[]), (ctx, pctx, mdname, libctx, props, pkey, params)) \
+DEFINEFUNC_3_0(int, EVP_DigestSign, (GO_EVP_MD_CTX_PTR ctx, unsigned char *sigret, size_t *siglen, const unsigned char *tbs, size_t tbslen), (ctx, sigret, siglen, tbs, tbslen)) \
+DEFINEFUNC_3_0(int, EVP_DigestVerifyInit_ex, (GO_EVP_MD_CTX_PTR ctx, GO_EVP_PKEY_CTX_PTR *pctx, const char *mdname, GO_OSSL_LIB_CTX_PTR libctx, const char *props, GO_EVP_PKEY_PTR pkey, const OSSL_PARAM params[]), (ctx, pctx, mdname, libctx, props, pkey, params)) \
+DEFINEFUNC_3_0(int, EVP_DigestVerify, (GO_EVP_MD_CTX_PTR ctx, const unsigned char *sigret, size_t siglen, const unsigned char *tbs, size_t tbslen), (ctx, sigret, siglen, tbs, tbslen)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_encapsulate_init, (GO_EVP_PKEY_CTX_PTR ctx, const OSSL_PARAM params[]), (ctx, params)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_encapsulate, (GO_EVP_PKEY_CTX_PTR ctx, unsigned char *wrappedkey, size_t *wrappedkeylen, unsigned char *genkey, size_t *genkeylen), (ctx, wrappedkey, wrappedkeylen, genkey, genkeylen)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_decapsulate_init, (GO_EVP_PKEY_CTX_PTR ctx, const OSSL_PARAM params[]), (ctx, params)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_decapsulate, (GO_EVP_PKEY_CTX_PTR ctx, unsigned char *unwrapped, size_t *unwrappedlen, const unsigned char *wrapped, size_t wrappedlen), (ctx, unwrapped, unwrappedlen, wrapped, wrappedlen)) \
+DEFINEFUNC_3_0(GO_EVP_PKEY_PTR, EVP_PKEY_new_raw_private_key_ex, (GO_OSSL_LIB_CTX_PTR libctx, const char *keytype, const char *propq, const unsigned char *priv, size_t len), (libctx, keytype, propq, priv, len)) \
+DEFINEFUNC_3_0(GO_EVP_PKEY_PTR, EVP_PKEY_new_raw_public_key_ex, (GO_OSSL_LIB_CTX_PTR libctx, const char *keytype, const char *propq, const unsigned char *pub, size_t len), (libctx, keytype, propq, pub, len)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_get_raw_private_key, (const GO_EVP_PKEY_PTR pkey, unsigned char *priv, size_t *len), (pkey, priv, len)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_get_raw_public_key, (const GO_EVP_PKEY_PTR pkey, unsigned char *pub, size_t *len), (pkey, pub, len)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_get_octet_string_param, (const GO_EVP_PKEY_PTR pkey, const char *key_name, unsigned char *buf, size_t max_buf_sz, size_t *out_len), (pkey, key_name, buf, max_buf_sz, out_len)) \
+DEFINEFUNC_3_0(GO_EVP_KEYMGMT_PTR, EVP_KEYMGMT_fetch, (GO_OSSL_LIB_CTX_PTR ctx, const char *algorithm, const char *properties), (ctx, algorithm, properties)) \
+DEFINEFUNC_3_0(void, EVP_KEYMGMT_free, (GO_EVP_KEYMGMT_PTR keymgmt), (keymgmt)) \
+DEFINEFUNC_3_0(const GO_OSSL_PROVIDER_PTR, EVP_KEYMGMT_get0_provider, (const GO_EVP_KEYMGMT_PTR keymgmt), (keymgmt)) \
+DEFINEFUNC_3_0(const GO_OSSL_PROVIDER_PTR, EVP_PKEY_get0_provider, (const GO_EVP_PKEY_PTR key), (key)) \
+DEFINEFUNC_1_1(const GO_EVP_CIPHER_PTR, EVP_chacha20_poly1305, (void), ()) \
+DEFINEFUNC_3_0(int, EVP_PKEY_fromdata_init, (GO_EVP_PKEY_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, EVP_PKEY_fromdata, (GO_EVP_PKEY_CTX_PTR ctx, GO_EVP_PKEY_PTR *pkey, int selection, OSSL_PARAM params[]), (ctx, pkey, selection, params)) \
+DEFINEFUNC_3_0(int, OSSL_PARAM_BLD_push_BN, (GO_OSSL_PARAM_BLD_PTR bld, const char *key, const GO_BIGNUM_PTR bn), (bld, key, bn)) \
+DEFINEFUNC(GO_SRP_gN_PTR, SRP_get_default_gN, (const char *id), (id)) \
+DEFINEFUNC(int, SRP_create_verifier_BN, (const char *user, const char *pass, GO_BIGNUM_PTR *salt, GO_BIGNUM_PTR *verifier, const GO_BIGNUM_PTR N, const GO_BIGNUM_PTR g), (user, pass, salt, verifier, N, g)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_B, (const GO_BIGNUM_PTR b, const GO_BIGNUM_PTR N, const GO_BIGNUM_PTR g, const GO_BIGNUM_PTR v), (b, N, g, v)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_A, (const GO_BIGNUM_PTR a, const GO_BIGNUM_PTR N, const GO_BIGNUM_PTR g), (a, N, g)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_u, (const GO_BIGNUM_PTR A, const GO_BIGNUM_PTR B, const GO_BIGNUM_PTR N), (A, B, N)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_x, (const GO_BIGNUM_PTR s, const char *user, const char *pass), (s, user, pass)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_server_key, (const GO_BIGNUM_PTR A, const GO_BIGNUM_PTR v, const GO_BIGNUM_PTR u, const GO_BIGNUM_PTR b, const GO_BIGNUM_PTR N), (A, v, u, b, N)) \
+DEFINEFUNC(GO_BIGNUM_PTR, SRP_Calc_client_key, (const GO_BIGNUM_PTR N, const GO_BIGNUM_PTR B, const GO_BIGNUM_PTR g, const GO_BIGNUM_PTR x, const GO_BIGNUM_PTR a, const GO_BIGNUM_PTR u), (N, B, g, x, a, u)) \
+DEFINEFUNC(int, SRP_Verify_A_mod_N, (const GO_BIGNUM_PTR A, const GO_BIGNUM_PTR N), (A, N)) \
+DEFINEFUNC(int, SRP_Verify_B_mod_N, (const GO_BIGNUM_PTR B, const GO_BIGNUM_PTR N), (B, N)) \
+DEFINEFUNC_LEGACY_1_0(void, ERR_remove_thread_state, (const GO_CRYPTO_THREADID_PTR tid), (tid)) \
+DEFINEFUNC_1_1(void, OPENSSL_thread_stop, (void), ()) \
+DEFINEFUNC_3_0(void, OPENSSL_thread_stop_ex, (GO_OSSL_LIB_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC(GO_PKCS8_PRIV_KEY_INFO_PTR, EVP_PKEY2PKCS8, (const GO_EVP_PKEY_PTR pkey), (pkey)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, EVP_PKCS82PKEY, (const GO_PKCS8_PRIV_KEY_INFO_PTR p8), (p8)) \
+DEFINEFUNC(void, PKCS8_PRIV_KEY_INFO_free, (GO_PKCS8_PRIV_KEY_INFO_PTR a), (a)) \
+DEFINEFUNC(GO_PKCS8_PRIV_KEY_INFO_PTR, d2i_PKCS8_PRIV_KEY_INFO, (GO_PKCS8_PRIV_KEY_INFO_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_PKCS8_PRIV_KEY_INFO, (const GO_PKCS8_PRIV_KEY_INFO_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(GO_X509_ALGOR_PTR, PKCS5_pbe2_set_iv, (const GO_EVP_CIPHER_PTR cipher, int iter, unsigned char *salt, int saltlen, unsigned char *aiv, int prf_nid), (cipher, iter, salt, saltlen, aiv, prf_nid)) \
+DEFINEFUNC(void, X509_ALGOR_free, (GO_X509_ALGOR_PTR a), (a)) \
+DEFINEFUNC_1_1(GO_X509_SIG_PTR, PKCS8_set0_pbe, (const char *pass, int passlen, GO_PKCS8_PRIV_KEY_INFO_PTR p8inf, GO_X509_ALGOR_PTR pbe), (pass, passlen, p8inf, pbe)) \
+DEFINEFUNC(GO_PKCS8_PRIV_KEY_INFO_PTR, PKCS8_decrypt, (const GO_X509_SIG_PTR p8, const char *pass, int passlen), (p8, pass, passlen)) \
+DEFINEFUNC(void, X509_SIG_free, (GO_X509_SIG_PTR a), (a)) \
+DEFINEFUNC(GO_X509_SIG_PTR, d2i_X509_SIG, (GO_X509_SIG_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_X509_SIG, (const GO_X509_SIG_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(void, X509_free, (GO_X509_PTR a), (a)) \
+DEFINEFUNC(GO_X509_PTR, d2i_X509, (GO_X509_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_X509, (const GO_X509_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC_RENAMED_1_1(int, OPENSSL_sk_num, sk_num, (const GO_OPENSSL_STACK_PTR st), (st)) \
+DEFINEFUNC_RENAMED_1_1(void *, OPENSSL_sk_value, sk_value, (const GO_OPENSSL_STACK_PTR st, int i), (st, i)) \
+DEFINEFUNC_RENAMED_1_1(GO_OPENSSL_STACK_PTR, OPENSSL_sk_new_null, sk_new_null, (void), ()) \
+DEFINEFUNC_RENAMED_1_1(int, OPENSSL_sk_push, sk_push, (GO_OPENSSL_STACK_PTR st, const void *data), (st, data)) \
+DEFINEFUNC_RENAMED_1_1(void, OPENSSL_sk_free, sk_free, (GO_OPENSSL_STACK_PTR st), (st)) \
+DEFINEFUNC(void, PKCS12_free, (GO_PKCS12_PTR a), (a)) \
+DEFINEFUNC(GO_PKCS12_PTR, d2i_PKCS12, (GO_PKCS12_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_PKCS12, (const GO_PKCS12_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(int, PKCS12_parse, (GO_PKCS12_PTR p12, const char *pass, GO_EVP_PKEY_PTR *pkey, GO_X509_PTR *cert, GO_STACK_OF_X509_PTR *ca), (p12, pass, pkey, cert, ca)) \
+DEFINEFUNC(GO_PKCS12_PTR, PKCS12_create, (const char *pass, const char *name, GO_EVP_PKEY_PTR pkey, GO_X509_PTR cert, GO_STACK_OF_X509_PTR ca, int nid_key, int nid_cert, int iter, int mac_iter, int keytype), (pass, name, pkey, cert, ca, nid_key, nid_cert, iter, mac_iter, keytype)) \
+DEFINEFUNC(int, PKCS12_set_mac, (GO_PKCS12_PTR p12, const char *pass, int passlen, unsigned char *salt, int saltlen, int iter, const GO_EVP_MD_PTR md_type), (p12, pass, passlen, salt, saltlen, iter, md_type)) \
+DEFINEFUNC(int, PKCS12_verify_mac, (GO_PKCS12_PTR p12, const char *pass, int passlen), (p12, pass, passlen)) \
+DEFINEFUNC_1_1(int, PKCS12_mac_present, (const GO_PKCS12_PTR p12), (p12)) \
+DEFINEFUNC(unsigned long, ERR_peek_last_error, (void), ()) \
+DEFINEFUNC(const GO_BIO_METHOD_PTR, BIO_s_mem, (void), ()) \
+DEFINEFUNC(GO_BIO_PTR, BIO_new, (const GO_BIO_METHOD_PTR type), (type)) \
+DEFINEFUNC(int, BIO_free, (GO_BIO_PTR a), (a)) \
+DEFINEFUNC(int, BIO_write, (GO_BIO_PTR b, const void *data, int dlen), (b, data, dlen)) \
+DEFINEFUNC(long, BIO_ctrl, (GO_BIO_PTR bp, int cmd, long larg, void *parg), (bp, cmd, larg, parg)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, PEM_read_bio_PrivateKey, (GO_BIO_PTR bp, GO_EVP_PKEY_PTR *x, GO_pem_password_cb_PTR cb, void *u), (bp, x, cb, u)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, PEM_read_bio_PUBKEY, (GO_BIO_PTR bp, GO_EVP_PKEY_PTR *x, GO_pem_password_cb_PTR cb, void *u), (bp, x, cb, u)) \
+DEFINEFUNC(GO_X509_PTR, PEM_read_bio_X509, (GO_BIO_PTR bp, GO_X509_PTR *x, GO_pem_password_cb_PTR cb, void *u), (bp, x, cb, u)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, PEM_read_bio_Parameters, (GO_BIO_PTR bp, GO_EVP_PKEY_PTR *x), (bp, x)) \
+DEFINEFUNC(int, PEM_write_bio_PKCS8PrivateKey, (GO_BIO_PTR bp, const GO_EVP_PKEY_PTR x, const GO_EVP_CIPHER_PTR enc, const char *kstr, int klen, GO_pem_password_cb_PTR cb, void *u), (bp, x, enc, kstr, klen, cb, u)) \
+DEFINEFUNC_1_1(int, PEM_write_bio_PrivateKey_traditional, (GO_BIO_PTR bp, const GO_EVP_PKEY_PTR x, const GO_EVP_CIPHER_PTR enc, const unsigned char *kstr, int klen, GO_pem_password_cb_PTR cb, void *u), (bp, x, enc, kstr, klen, cb, u)) \
+DEFINEFUNC(int, PEM_write_bio_PUBKEY, (GO_BIO_PTR bp, const GO_EVP_PKEY_PTR x), (bp, x)) \
+DEFINEFUNC(int, PEM_write_bio_X509, (GO_BIO_PTR bp, const GO_X509_PTR x), (bp, x)) \
+DEFINEFUNC(int, PEM_write_bio_Parameters, (GO_BIO_PTR bp, const GO_EVP_PKEY_PTR x), (bp, x)) \
+DEFINEFUNC(int, EC_GROUP_get_curve_name, (const GO_EC_GROUP_PTR group), (group)) \
+DEFINEFUNC(void, EC_KEY_set_asn1_flag, (GO_EC_KEY_PTR eckey, int asn1_flag), (eckey, asn1_flag)) \
+DEFINEFUNC(int, EC_KEY_check_key, (const GO_EC_KEY_PTR key), (key)) \
+DEFINEFUNC(GO_EC_KEY_PTR, d2i_ECPrivateKey, (GO_EC_KEY_PTR *key, const unsigned char **in, long len), (key, in, len)) \
+DEFINEFUNC(int, i2d_ECPrivateKey, (const GO_EC_KEY_PTR key, unsigned char **out), (key, out)) \
+/*check:from=1.1.1*/ DEFINEFUNC_RENAMED_3_0(int, EVP_PKEY_get_base_id, EVP_PKEY_base_id, (const GO_EVP_PKEY_PTR pkey), (pkey)) \
+DEFINEFUNC_RENAMED_3_0(int, EVP_PKEY_eq, EVP_PKEY_cmp, (const GO_EVP_PKEY_PTR a, const GO_EVP_PKEY_PTR b), (a, b)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, d2i_PUBKEY, (GO_EVP_PKEY_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_PUBKEY, (const GO_EVP_PKEY_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, X509_get_pubkey, (GO_X509_PTR x), (x)) \
+DEFINEFUNC(GO_X509_NAME_PTR, X509_get_subject_name, (const GO_X509_PTR a), (a)) \
+DEFINEFUNC(GO_X509_NAME_PTR, X509_get_issuer_name, (const GO_X509_PTR a), (a)) \
+DEFINEFUNC(int, X509_NAME_print_ex, (GO_BIO_PTR out, const GO_X509_NAME_PTR nm, int indent, unsigned long flags), (out, nm, indent, flags)) \
+DEFINEFUNC(GO_ASN1_INTEGER_PTR, X509_get_serialNumber, (GO_X509_PTR x), (x)) \
+DEFINEFUNC(GO_BIGNUM_PTR, ASN1_INTEGER_to_BN, (const GO_ASN1_INTEGER_PTR ai, GO_BIGNUM_PTR bn), (ai, bn)) \
+DEFINEFUNC_1_1(long, X509_get_version, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC_1_1(const GO_ASN1_STRING_PTR, X509_get0_notBefore, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC_1_1(const GO_ASN1_STRING_PTR, X509_get0_notAfter, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC_1_1(const unsigned char *, ASN1_STRING_get0_data, (const GO_ASN1_STRING_PTR x), (x)) \
+DEFINEFUNC(int, ASN1_STRING_length, (const GO_ASN1_STRING_PTR x), (x)) \
+DEFINEFUNC(int, ASN1_STRING_type, (const GO_ASN1_STRING_PTR x), (x)) \
+DEFINEFUNC(int, X509_get_signature_nid, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC(const char *, OBJ_nid2ln, (int n), (n)) \
+DEFINEFUNC(int, X509_verify, (GO_X509_PTR a, GO_EVP_PKEY_PTR r), (a, r)) \
+DEFINEFUNC(int, X509_check_issued, (GO_X509_PTR issuer, GO_X509_PTR subject), (issuer, subject)) \
+DEFINEFUNC(const char *, X509_verify_cert_error_string, (long n), (n)) \
+DEFINEFUNC_1_1(int, X509_up_ref, (GO_X509_PTR a), (a)) \
+DEFINEFUNC(GO_X509_STORE_PTR, X509_STORE_new, (void), ()) \
+DEFINEFUNC(void, X509_STORE_free, (GO_X509_STORE_PTR v), (v)) \
+DEFINEFUNC(int, X509_STORE_add_cert, (GO_X509_STORE_PTR xs, GO_X509_PTR x), (xs, x)) \
+DEFINEFUNC(int, X509_STORE_set_default_paths, (GO_X509_STORE_PTR xs), (xs)) \
+DEFINEFUNC(GO_X509_STORE_CTX_PTR, X509_STORE_CTX_new, (void), ()) \
+DEFINEFUNC(void, X509_STORE_CTX_free, (GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC(int, X509_STORE_CTX_init, (GO_X509_STORE_CTX_PTR ctx, GO_X509_STORE_PTR trust_store, GO_X509_PTR target, GO_STACK_OF_X509_PTR untrusted), (ctx, trust_store, target, untrusted)) \
+/*check:from=3.0.0*/ DEFINEFUNC(GO_X509_VERIFY_PARAM_PTR, X509_STORE_CTX_get0_param, (const GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+/*check:from=3.0.0*/ DEFINEFUNC(int, X509_STORE_CTX_get_error, (const GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+/*check:from=3.0.0*/ DEFINEFUNC(int, X509_STORE_CTX_get_error_depth, (const GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+/*check:from=3.0.0*/ DEFINEFUNC(GO_STACK_OF_X509_PTR, X509_STORE_CTX_get1_chain, (const GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC(int, X509_verify_cert, (GO_X509_STORE_CTX_PTR ctx), (ctx)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_PURPOSE_get_by_sname, (const char *sname), (sname)) \
+DEFINEFUNC(GO_X509_PURPOSE_PTR, X509_PURPOSE_get0, (int idx), (idx)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_PURPOSE_get_id, (const GO_X509_PURPOSE_PTR xp), (xp)) \
+DEFINEFUNC(int, X509_VERIFY_PARAM_set_purpose, (GO_X509_VERIFY_PARAM_PTR param, int purpose), (param, purpose)) \
+DEFINEFUNC(void, X509_VERIFY_PARAM_set_time, (GO_X509_VERIFY_PARAM_PTR param, time_t t), (param, t)) \
+DEFINEFUNC(int, X509_VERIFY_PARAM_set_flags, (GO_X509_VERIFY_PARAM_PTR param, unsigned long flags), (param, flags)) \
+DEFINEFUNC(int, X509_VERIFY_PARAM_set1_host, (GO_X509_VERIFY_PARAM_PTR param, const char *name, size_t namelen), (param, name, namelen)) \
+DEFINEFUNC(void, X509_VERIFY_PARAM_set_hostflags, (GO_X509_VERIFY_PARAM_PTR param, unsigned int flags), (param, flags)) \
+DEFINEFUNC(int, X509_VERIFY_PARAM_set1_email, (GO_X509_VERIFY_PARAM_PTR param, const char *email, size_t emaillen), (param, email, emaillen)) \
+DEFINEFUNC(int, X509_VERIFY_PARAM_set1_ip_asc, (GO_X509_VERIFY_PARAM_PTR param, const char *ipasc), (param, ipasc)) \
+DEFINEFUNC(GO_X509_NAME_PTR, X509_NAME_new, (void), ()) \
+DEFINEFUNC(void, X509_NAME_free, (GO_X509_NAME_PTR a), (a)) \
+DEFINEFUNC(int, X509_NAME_add_entry_by_txt, (GO_X509_NAME_PTR name, const char *field, int type, const unsigned char *bytes, int len, int loc, int set), (name, field, type, bytes, len, loc, set)) \
+DEFINEFUNC(GO_X509_PTR, X509_new, (void), ()) \
+DEFINEFUNC(int, X509_set_version, (GO_X509_PTR x, long version), (x, version)) \
+DEFINEFUNC(int, X509_set_serialNumber, (GO_X509_PTR x, GO_ASN1_INTEGER_PTR serial), (x, serial)) \
+DEFINEFUNC(GO_ASN1_INTEGER_PTR, BN_to_ASN1_INTEGER, (const GO_BIGNUM_PTR bn, GO_ASN1_INTEGER_PTR ai), (bn, ai)) \
+DEFINEFUNC(void, ASN1_INTEGER_free, (GO_ASN1_INTEGER_PTR a), (a)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_set_issuer_name, (GO_X509_PTR x, const GO_X509_NAME_PTR name), (x, name)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_set_subject_name, (GO_X509_PTR x, const GO_X509_NAME_PTR name), (x, name)) \
+DEFINEFUNC(int, X509_set_pubkey, (GO_X509_PTR x, GO_EVP_PKEY_PTR pkey), (x, pkey)) \
+DEFINEFUNC_1_1(GO_ASN1_STRING_PTR, X509_getm_notBefore, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC_1_1(GO_ASN1_STRING_PTR, X509_getm_notAfter, (const GO_X509_PTR x), (x)) \
+DEFINEFUNC(GO_ASN1_STRING_PTR, ASN1_TIME_set, (GO_ASN1_STRING_PTR s, time_t t), (s, t)) \
+DEFINEFUNC(int, X509_add_ext, (GO_X509_PTR x, GO_X509_EXTENSION_PTR ex, int loc), (x, ex, loc)) \
+DEFINEFUNC(void, X509_EXTENSION_free, (GO_X509_EXTENSION_PTR a), (a)) \
+DEFINEFUNC(int, X509_sign, (GO_X509_PTR x, GO_EVP_PKEY_PTR pkey, const GO_EVP_MD_PTR md), (x, pkey, md)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_check_private_key, (const GO_X509_PTR x509, const GO_EVP_PKEY_PTR pkey), (x509, pkey)) \
+DEFINEFUNC(void, X509V3_set_ctx, (GO_X509V3_CTX_PTR ctx, GO_X509_PTR issuer, GO_X509_PTR subject, GO_X509_REQ_PTR req, GO_X509_CRL_PTR crl, int flags), (ctx, issuer, subject, req, crl, flags)) \
+DEFINEFUNC(GO_X509_EXTENSION_PTR, X509V3_EXT_nconf_nid, (GO_CONF_PTR conf, GO_X509V3_CTX_PTR ctx, int ext_nid, const char *value), (conf, ctx, ext_nid, value)) \
+DEFINEFUNC(GO_X509_REQ_PTR, X509_REQ_new, (void), ()) \
+DEFINEFUNC(void, X509_REQ_free, (GO_X509_REQ_PTR a), (a)) \
+DEFINEFUNC(GO_X509_REQ_PTR, d2i_X509_REQ, (GO_X509_REQ_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_X509_REQ, (const GO_X509_REQ_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(int, X509_REQ_set_version, (GO_X509_REQ_PTR x, long version), (x, version)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_REQ_set_subject_name, (GO_X509_REQ_PTR req, const GO_X509_NAME_PTR name), (req, name)) \
+DEFINEFUNC_1_1(GO_X509_NAME_PTR, X509_REQ_get_subject_name, (const GO_X509_REQ_PTR req), (req)) \
+DEFINEFUNC(int, X509_REQ_set_pubkey, (GO_X509_REQ_PTR x, GO_EVP_PKEY_PTR pkey), (x, pkey)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, X509_REQ_get_pubkey, (GO_X509_REQ_PTR req), (req)) \
+/*check:from=1.1.0*/ DEFINEFUNC(int, X509_REQ_add_extensions, (GO_X509_REQ_PTR req, const GO_STACK_OF_X509_EXTENSION_PTR exts), (req, exts)) \
+DEFINEFUNC(int, X509_REQ_sign, (GO_X509_REQ_PTR x, GO_EVP_PKEY_PTR pkey, const GO_EVP_MD_PTR md), (x, pkey, md)) \
+DEFINEFUNC(int, X509_REQ_verify, (GO_X509_REQ_PTR a, GO_EVP_PKEY_PTR r), (a, r)) \
+DEFINEFUNC(void, X509_CRL_free, (GO_X509_CRL_PTR a), (a)) \
+DEFINEFUNC(GO_X509_CRL_PTR, d2i_X509_CRL, (GO_X509_CRL_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_X509_CRL, (const GO_X509_CRL_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(int, X509_CRL_verify, (GO_X509_CRL_PTR a, GO_EVP_PKEY_PTR r), (a, r)) \
+DEFINEFUNC_1_1(GO_X509_NAME_PTR, X509_CRL_get_issuer, (const GO_X509_CRL_PTR crl), (crl)) \
+DEFINEFUNC_1_1(const GO_ASN1_STRING_PTR, X509_CRL_get0_lastUpdate, (const GO_X509_CRL_PTR crl), (crl)) \
+DEFINEFUNC_1_1(const GO_ASN1_STRING_PTR, X509_CRL_get0_nextUpdate, (const GO_X509_CRL_PTR crl), (crl)) \
+/*check:from=3.0.0*/ DEFINEFUNC(int, X509_CRL_get0_by_serial, (GO_X509_CRL_PTR crl, GO_X509_REVOKED_PTR *ret, const GO_ASN1_INTEGER_PTR serial), (crl, ret, serial)) \
+DEFINEFUNC(int, X509_NAME_cmp, (const GO_X509_NAME_PTR a, const GO_X509_NAME_PTR b), (a, b)) \
+DEFINEFUNC(int, X509_STORE_add_crl, (GO_X509_STORE_PTR xs, GO_X509_CRL_PTR x), (xs, x)) \
+/*check:from=1.1.0*/ DEFINEFUNC(GO_OCSP_CERTID_PTR, OCSP_cert_to_id, (const GO_EVP_MD_PTR dgst, const GO_X509_PTR subject, const GO_X509_PTR issuer), (dgst, subject, issuer)) \
+DEFINEFUNC(void, OCSP_CERTID_free, (GO_OCSP_CERTID_PTR a), (a)) \
+DEFINEFUNC(GO_OCSP_REQUEST_PTR, OCSP_REQUEST_new, (void), ()) \
+DEFINEFUNC(void, OCSP_REQUEST_free, (GO_OCSP_REQUEST_PTR a), (a)) \
+DEFINEFUNC(GO_OCSP_ONEREQ_PTR, OCSP_request_add0_id, (GO_OCSP_REQUEST_PTR req, GO_OCSP_CERTID_PTR cid), (req, cid)) \
+DEFINEFUNC(GO_OCSP_REQUEST_PTR, d2i_OCSP_REQUEST, (GO_OCSP_REQUEST_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_OCSP_REQUEST, (const GO_OCSP_REQUEST_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(void, OCSP_RESPONSE_free, (GO_OCSP_RESPONSE_PTR a), (a)) \
+DEFINEFUNC(GO_OCSP_RESPONSE_PTR, d2i_OCSP_RESPONSE, (GO_OCSP_RESPONSE_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_OCSP_RESPONSE, (const GO_OCSP_RESPONSE_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(int, OCSP_response_status, (GO_OCSP_RESPONSE_PTR resp), (resp)) \
+DEFINEFUNC(const char *, OCSP_response_status_str, (long s), (s)) \
+DEFINEFUNC(GO_OCSP_BASICRESP_PTR, OCSP_response_get1_basic, (GO_OCSP_RESPONSE_PTR resp), (resp)) \
+DEFINEFUNC(void, OCSP_BASICRESP_free, (GO_OCSP_BASICRESP_PTR a), (a)) \
+DEFINEFUNC_1_1(const GO_ASN1_STRING_PTR, OCSP_resp_get0_produced_at, (const GO_OCSP_BASICRESP_PTR bs), (bs)) \
+DEFINEFUNC(int, OCSP_resp_find_status, (GO_OCSP_BASICRESP_PTR bs, GO_OCSP_CERTID_PTR id, int *status, int *reason, GO_ASN1_STRING_PTR *revtime, GO_ASN1_STRING_PTR *thisupd, GO_ASN1_STRING_PTR *nextupd), (bs, id, status, reason, revtime, thisupd, nextupd)) \
+DEFINEFUNC(int, OCSP_basic_verify, (GO_OCSP_BASICRESP_PTR bs, GO_STACK_OF_X509_PTR certs, GO_X509_STORE_PTR st, unsigned long flags), (bs, certs, st, flags)) \
+DEFINEFUNC(int, X509_STORE_set_flags, (GO_X509_STORE_PTR ctx, unsigned long flags), (ctx, flags)) \
+DEFINEFUNC_1_1(GO_X509_VERIFY_PARAM_PTR, X509_STORE_get0_param, (const GO_X509_STORE_PTR ctx), (ctx)) \
+DEFINEFUNC(void, CMS_ContentInfo_free, (GO_CMS_ContentInfo_PTR a), (a)) \
+DEFINEFUNC(GO_CMS_ContentInfo_PTR, d2i_CMS_ContentInfo, (GO_CMS_ContentInfo_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_CMS_ContentInfo, (const GO_CMS_ContentInfo_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(GO_CMS_ContentInfo_PTR, CMS_sign, (GO_X509_PTR signcert, GO_EVP_PKEY_PTR pkey, GO_STACK_OF_X509_PTR certs, GO_BIO_PTR data, unsigned int flags), (signcert, pkey, certs, data, flags)) \
+DEFINEFUNC(GO_CMS_SignerInfo_PTR, CMS_add1_signer, (GO_CMS_ContentInfo_PTR cms, GO_X509_PTR signer, GO_EVP_PKEY_PTR pk, const GO_EVP_MD_PTR md, unsigned int flags), (cms, signer, pk, md, flags)) \
+DEFINEFUNC(int, CMS_final, (GO_CMS_ContentInfo_PTR cms, GO_BIO_PTR data, GO_BIO_PTR dcont, unsigned int flags), (cms, data, dcont, flags)) \
+DEFINEFUNC(int, CMS_verify, (GO_CMS_ContentInfo_PTR cms, GO_STACK_OF_X509_PTR certs, GO_X509_STORE_PTR store, GO_BIO_PTR dcont, GO_BIO_PTR out, unsigned int flags), (cms, certs, store, dcont, out, flags)) \
+DEFINEFUNC(GO_STACK_OF_X509_PTR, CMS_get0_signers, (GO_CMS_ContentInfo_PTR cms), (cms)) \
+DEFINEFUNC(GO_CMS_ContentInfo_PTR, CMS_encrypt, (GO_STACK_OF_X509_PTR certs, GO_BIO_PTR in, const GO_EVP_CIPHER_PTR cipher, unsigned int flags), (certs, in, cipher, flags)) \
+DEFINEFUNC(int, CMS_decrypt, (GO_CMS_ContentInfo_PTR cms, GO_EVP_PKEY_PTR pkey, GO_X509_PTR cert, GO_BIO_PTR dcont, GO_BIO_PTR out, unsigned int flags), (cms, pkey, cert, dcont, out, flags)) \
+DEFINEFUNC(GO_STACK_OF_X509_PTR, CMS_get1_certs, (GO_CMS_ContentInfo_PTR cms), (cms)) \
+DEFINEFUNC(GO_TS_REQ_PTR, TS_REQ_new, (void), ()) \
+DEFINEFUNC(void, TS_REQ_free, (GO_TS_REQ_PTR a), (a)) \
+DEFINEFUNC(GO_TS_REQ_PTR, d2i_TS_REQ, (GO_TS_REQ_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_TS_REQ, (const GO_TS_REQ_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(int, TS_REQ_set_version, (GO_TS_REQ_PTR a, long version), (a, version)) \
+DEFINEFUNC(int, TS_REQ_set_msg_imprint, (GO_TS_REQ_PTR a, GO_TS_MSG_IMPRINT_PTR msg_imprint), (a, msg_imprint)) \
+DEFINEFUNC(int, TS_REQ_set_policy_id, (GO_TS_REQ_PTR a, const GO_ASN1_OBJECT_PTR policy), (a, policy)) \
+DEFINEFUNC(int, TS_REQ_set_nonce, (GO_TS_REQ_PTR a, const GO_ASN1_INTEGER_PTR nonce), (a, nonce)) \
+DEFINEFUNC(int, TS_REQ_set_cert_req, (GO_TS_REQ_PTR a, int cert_req), (a, cert_req)) \
+DEFINEFUNC(GO_TS_MSG_IMPRINT_PTR, TS_MSG_IMPRINT_new, (void), ()) \
+DEFINEFUNC(void, TS_MSG_IMPRINT_free, (GO_TS_MSG_IMPRINT_PTR a), (a)) \
+DEFINEFUNC(int, TS_MSG_IMPRINT_set_algo, (GO_TS_MSG_IMPRINT_PTR a, GO_X509_ALGOR_PTR alg), (a, alg)) \
+DEFINEFUNC(int, TS_MSG_IMPRINT_set_msg, (GO_TS_MSG_IMPRINT_PTR a, unsigned char *d, int len), (a, d, len)) \
+DEFINEFUNC(GO_X509_ALGOR_PTR, X509_ALGOR_new, (void), ()) \
+DEFINEFUNC(void, X509_ALGOR_set_md, (GO_X509_ALGOR_PTR alg, const GO_EVP_MD_PTR md), (alg, md)) \
+DEFINEFUNC(GO_ASN1_OBJECT_PTR, OBJ_txt2obj, (const char *s, int no_name), (s, no_name)) \
+DEFINEFUNC(int, OBJ_obj2txt, (char *buf, int buf_len, const GO_ASN1_OBJECT_PTR a, int no_name), (buf, buf_len, a, no_name)) \
+DEFINEFUNC(void, ASN1_OBJECT_free, (GO_ASN1_OBJECT_PTR a), (a)) \
+DEFINEFUNC(void, TS_RESP_free, (GO_TS_RESP_PTR a), (a)) \
+DEFINEFUNC(GO_TS_RESP_PTR, d2i_TS_RESP, (GO_TS_RESP_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_TS_RESP, (const GO_TS_RESP_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(GO_PKCS7_PTR, TS_RESP_get_token, (GO_TS_RESP_PTR a), (a)) \
+DEFINEFUNC(GO_TS_TST_INFO_PTR, TS_RESP_get_tst_info, (GO_TS_RESP_PTR a), (a)) \
+/*check:from=1.1.0*/ DEFINEFUNC(const GO_ASN1_STRING_PTR, TS_TST_INFO_get_time, (const GO_TS_TST_INFO_PTR a), (a)) \
+/*check:from=1.1.0*/ DEFINEFUNC(const GO_ASN1_INTEGER_PTR, TS_TST_INFO_get_serial, (const GO_TS_TST_INFO_PTR a), (a)) \
+DEFINEFUNC(GO_ASN1_OBJECT_PTR, TS_TST_INFO_get_policy_id, (GO_TS_TST_INFO_PTR a), (a)) \
+DEFINEFUNC(GO_PKCS7_PTR, d2i_PKCS7, (GO_PKCS7_PTR *a, const unsigned char **in, long len), (a, in, len)) \
+DEFINEFUNC(int, i2d_PKCS7, (const GO_PKCS7_PTR a, unsigned char **out), (a, out)) \
+DEFINEFUNC(GO_TS_VERIFY_CTX_PTR, TS_REQ_to_TS_VERIFY_CTX, (GO_TS_REQ_PTR req, GO_TS_VERIFY_CTX_PTR ctx), (req, ctx)) \
+DEFINEFUNC(void, TS_VERIFY_CTX_free, (GO_TS_VERIFY_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_1_1(int, TS_VERIFY_CTX_add_flags, (GO_TS_VERIFY_CTX_PTR ctx, int f), (ctx, f)) \
+DEFINEFUNC_1_1(GO_X509_STORE_PTR, TS_VERIFY_CTX_set_store, (GO_TS_VERIFY_CTX_PTR ctx, GO_X509_STORE_PTR s), (ctx, s)) \
+DEFINEFUNC_1_1(GO_STACK_OF_X509_PTR, TS_VERIFY_CTX_set_certs, (GO_TS_VERIFY_CTX_PTR ctx, GO_STACK_OF_X509_PTR certs), (ctx, certs)) \
+DEFINEFUNC(int, TS_RESP_verify_response, (GO_TS_VERIFY_CTX_PTR ctx, GO_TS_RESP_PTR response), (ctx, response)) \
+DEFINEFUNC(void, ENGINE_load_builtin_engines, (void), ()) \
+DEFINEFUNC(GO_ENGINE_PTR, ENGINE_by_id, (const char *id), (id)) \
+DEFINEFUNC(int, ENGINE_init, (GO_ENGINE_PTR e), (e)) \
+DEFINEFUNC(int, ENGINE_finish, (GO_ENGINE_PTR e), (e)) \
+DEFINEFUNC(int, ENGINE_free, (GO_ENGINE_PTR e), (e)) \
+DEFINEFUNC(int, ENGINE_ctrl_cmd_string, (GO_ENGINE_PTR e, const char *cmd_name, const char *arg, int cmd_optional), (e, cmd_name, arg, cmd_optional)) \
+DEFINEFUNC(const char *, ENGINE_get_id, (const GO_ENGINE_PTR e), (e)) \
+DEFINEFUNC(const char *, ENGINE_get_name, (const GO_ENGINE_PTR e), (e)) \
+DEFINEFUNC(int, ENGINE_set_default_string, (GO_ENGINE_PTR e, const char *def_list), (e, def_list)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, ENGINE_load_private_key, (GO_ENGINE_PTR e, const char *key_id, GO_UI_METHOD_PTR ui_method, void *callback_data), (e, key_id, ui_method, callback_data)) \
+DEFINEFUNC(GO_EVP_PKEY_PTR, ENGINE_load_public_key, (GO_ENGINE_PTR e, const char *key_id, GO_UI_METHOD_PTR ui_method, void *callback_data), (e, key_id, ui_method, callback_data)) \
+DEFINEFUNC_1_1(int, ASYNC_is_capable, (void), ()) \
+DEFINEFUNC_1_1(int, ASYNC_start_job, (GO_ASYNC_JOB_PTR *job, GO_ASYNC_WAIT_CTX_PTR ctx, int *ret, int (*func)(void *), void *args, size_t size), (job, ctx, ret, func, args, size)) \
+DEFINEFUNC_1_1(GO_ASYNC_WAIT_CTX_PTR, ASYNC_WAIT_CTX_new, (void), ()) \
+DEFINEFUNC_1_1(void, ASYNC_WAIT_CTX_free, (GO_ASYNC_WAIT_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_1_1(int, ASYNC_WAIT_CTX_get_all_fds, (GO_ASYNC_WAIT_CTX_PTR ctx, GO_OSSL_ASYNC_FD *fd, size_t *numfds), (ctx, fd, numfds)) \
+DEFINEFUNC_3_0(GO_UI_METHOD_PTR, UI_UTIL_wrap_read_pem_callback, (GO_pem_password_cb_PTR cb, int rwflag), (cb, rwflag)) \
+DEFINEFUNC_3_0(GO_OSSL_STORE_CTX_PTR, OSSL_STORE_open_ex, (const char *uri, GO_OSSL_LIB_CTX_PTR libctx, const char *propq, const GO_UI_METHOD_PTR ui_method, void *ui_data, const OSSL_PARAM params[], GO_OSSL_STORE_INFO_PTR (*post_process)(GO_OSSL_STORE_INFO_PTR info, void *data), void *post_process_data), (uri, libctx, propq, ui_method, ui_data, params, post_process, post_process_data)) \
+DEFINEFUNC_3_0(int, OSSL_STORE_expect, (GO_OSSL_STORE_CTX_PTR ctx, int expected_type), (ctx, expected_type)) \
+DEFINEFUNC_3_0(GO_OSSL_STORE_INFO_PTR, OSSL_STORE_load, (GO_OSSL_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, OSSL_STORE_eof, (GO_OSSL_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, OSSL_STORE_error, (GO_OSSL_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, OSSL_STORE_close, (GO_OSSL_STORE_CTX_PTR ctx), (ctx)) \
+DEFINEFUNC_3_0(int, OSSL_STORE_INFO_get_type, (const GO_OSSL_STORE_INFO_PTR info), (info)) \
+DEFINEFUNC_3_0(GO_EVP_PKEY_PTR, OSSL_STORE_INFO_get1_PKEY, (const GO_OSSL_STORE_INFO_PTR info), (info)) \
+DEFINEFUNC_3_0(GO_X509_PTR, OSSL_STORE_INFO_get1_CERT, (const GO_OSSL_STORE_INFO_PTR info), (info)) \
+DEFINEFUNC_3_0(void, OSSL_STORE_INFO_free, (GO_OSSL_STORE_INFO_PTR info), (info)) \
 
@@ -1,18 +1,25 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 #include "goopenssl.h"
 
 #include <stdio.h>
 #include <pthread.h>
+
+#if defined(_WIN32)
+#include <windows.h>
+#elif defined(__APPLE__)
+#include <stdint.h>
+#else
 #include <sys/types.h>
 #include <sys/syscall.h>
 
 #define _GNU_SOURCE
 #include <unistd.h>
+#endif
  
 #define MUTEX_TYPE       pthread_mutex_t
 #define MUTEX_SETUP(x)   pthread_mutex_init(&(x), NULL)
@@ -22,6 +29,10 @@
 #define THREAD_ID        pthread_self()
 #define CRYPTO_LOCK      0x01
 
+/* The mutexes are pthread mutexes rather than Go ones: OpenSSL 1.0.2 takes
+ * locks on every operation, often from threads not started by Go, and
+ * calling back into Go for each of them would be prohibitively slow. */
+
 /* This array will store all of the mutexes available to OpenSSL. */ 
 static MUTEX_TYPE *mutex_buf = NULL;
  
@@ -35,19 +46,70 @@ static void locking_function(int mode, int n, const char *file, int line)
  
 static unsigned long id_function(void)
 {
+#if defined(_WIN32)
+	return ((unsigned long)GetCurrentThreadId());
+#elif defined(__APPLE__)
+	uint64_t tid;
+	pthread_threadid_np(NULL, &tid);
+	return ((unsigned long)tid);
+#else
 	return ((unsigned long)syscall(__NR_gettid));
+#endif
 }
  
+/* Dynamic locks are used by some engines instead of the static ones. */
+struct CRYPTO_dynlock_value
+{
+  MUTEX_TYPE mutex;
+};
+
+static struct CRYPTO_dynlock_value *dyn_create_function(const char *file, int line)
+{
+  struct CRYPTO_dynlock_value *value = malloc(sizeof(struct CRYPTO_dynlock_value));
+  if(!value)
+    return NULL;
+  if(MUTEX_SETUP(value->mutex) != 0) {
+    free(value);
+    return NULL;
+  }
+  return value;
+}
+
+static void dyn_lock_function(int mode, struct CRYPTO_dynlock_value *l, const char *file, int line)
+{
+  if(mode & CRYPTO_LOCK)
+    MUTEX_LOCK(l->mutex);
+  else
+    MUTEX_UNLOCK(l->mutex);
+}
+
+static void dyn_destroy_function(struct CRYPTO_dynlock_value *l, const char *file, int line)
+{
+  MUTEX_CLEANUP(l->mutex);
+  free(l);
+}
+
 int go_openssl_thread_setup(void)
 {
-  int i;
- 
-  mutex_buf = malloc(go_openssl_CRYPTO_num_locks() * sizeof(MUTEX_TYPE));
+  int i, n;
+
+  n = go_openssl_CRYPTO_num_locks();
+  mutex_buf = malloc(n * sizeof(MUTEX_TYPE));
   if(!mutex_buf)
     return 0;
-  for(i = 0;  i < go_openssl_CRYPTO_num_locks();  i++)
-    MUTEX_SETUP(mutex_buf[i]);
+  for(i = 0;  i < n;  i++) {
+    if(MUTEX_SETUP(mutex_buf[i]) != 0) {
+      while(i-- > 0)
+        MUTEX_CLEANUP(mutex_buf[i]);
+      free(mutex_buf);
+      mutex_buf = NULL;
+      return 0;
+    }
+  }
   go_openssl_CRYPTO_set_id_callback(id_function);
   go_openssl_CRYPTO_set_locking_callback(locking_function);
+  go_openssl_CRYPTO_set_dynlock_create_callback(dyn_create_function);
+  go_openssl_CRYPTO_set_dynlock_lock_callback(dyn_lock_function);
+  go_openssl_CRYPTO_set_dynlock_destroy_callback(dyn_destroy_function);
   return 1;
 }
@@ -1,19 +1,44 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"unsafe"
 )
 
 func TestMain(m *testing.M) {
-	err := Init()
+	var err error
+	if path := os.Getenv("GO_OPENSSL_TEST_HANDLE"); path != "" {
+		// See TestInitWithHandle.
+		var handle unsafe.Pointer
+		if handle, err = loadLibraryPath(path); err == nil {
+			err = InitWithHandle(handle)
+		}
+	} else {
+		// See TestInitWithOptions, TestSecureHeap and TestExplicitClose.
+		opts := InitOptions{
+			ConfigFile:    os.Getenv("GO_OPENSSL_TEST_CONFIG"),
+			ExplicitClose: os.Getenv("GO_OPENSSL_TEST_EXPLICIT_CLOSE") != "",
+		}
+		if os.Getenv("GO_OPENSSL_TEST_SECURE_HEAP") != "" {
+			opts.SecureHeapSize = 1 << 16
+		}
+		err = InitWithOptions(opts)
+	}
 	if err != nil {
 		// An error here could mean that this Linux distro does not have a supported OpenSSL version
 		// or that there is a bug in the Init code.
@@ -24,3 +49,331 @@ func TestMain(m *testing.M) {
 	fmt.Println("FIPS enabled:", FIPS())
 	os.Exit(m.Run())
 }
+
+func TestVersionInfo(t *testing.T) {
+	if got := int(VersionNumber() >> 28); got != vMajor {
+		t.Errorf("VersionNumber major version = %d, want %d", got, vMajor)
+	}
+	info := ReadBuildInfo()
+	if info.Version != VersionText() {
+		t.Errorf("got version %q, want %q", info.Version, VersionText())
+	}
+	if !strings.HasPrefix(info.Platform, "platform: ") {
+		t.Errorf("unexpected platform %q", info.Platform)
+	}
+	if !strings.HasPrefix(info.Dir, "OPENSSLDIR: ") {
+		t.Errorf("unexpected OPENSSLDIR %q", info.Dir)
+	}
+	if vMajor == 3 && !strings.HasPrefix(info.ModulesDir, "MODULESDIR: ") {
+		t.Errorf("unexpected MODULESDIR %q", info.ModulesDir)
+	}
+	path := LibraryPath()
+	if _, ok := staticLibrary(); ok {
+		if path != "" {
+			t.Errorf("got library path %q with a static libcrypto", path)
+		}
+		return
+	}
+	if !strings.Contains(filepath.Base(path), "crypto") {
+		t.Errorf("unexpected library path %q", path)
+	}
+}
+
+// TestConcurrentUse exercises libcrypto from many OS threads.
+// OpenSSL 1.0.2 is only thread safe with the locking callbacks
+// installed by Init.
+func TestConcurrentUse(t *testing.T) {
+	want := SHA256([]byte("concurrent"))
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			buf := make([]byte, 32)
+			for j := 0; j < 100; j++ {
+				if got := SHA256([]byte("concurrent")); got != want {
+					errs <- fmt.Errorf("got hash %x, want %x", got, want)
+					return
+				}
+				if _, err := RandReader.Read(buf); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestInitWithOptions(t *testing.T) {
+	if err := InitWithOptions(InitOptions{NoLoadConfig: true, ConfigFile: "openssl.cnf"}); err == nil {
+		t.Error("expected error for conflicting options")
+	}
+	if err := InitWithOptions(InitOptions{LibraryPath: "libcrypto.so.3", Version: "3"}); err == nil {
+		t.Error("expected error for conflicting library options")
+	}
+	if err := InitWithOptions(InitOptions{Version: "3", LibraryNames: []string{"libcrypto.so.3"}}); err == nil {
+		t.Error("expected error for conflicting library options")
+	}
+	if vMajor != 3 {
+		t.Skip("provider configuration is only supported on OpenSSL 3")
+	}
+	if cfg := os.Getenv("GO_OPENSSL_TEST_CONFIG"); cfg != "" {
+		// Running in the child process started below.
+		provs, err := Providers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(provs) != 1 || provs[0].Name != "base" {
+			t.Errorf("got %+v, want only the base provider", provs)
+		}
+		return
+	}
+	cfg := filepath.Join(t.TempDir(), "openssl.cnf")
+	err := os.WriteFile(cfg, []byte(`openssl_conf = init
+[init]
+providers = provider_sect
+[provider_sect]
+base = base_sect
+[base_sect]
+activate = 1
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInitWithOptions$", "-test.v")
+	cmd.Env = append(os.Environ(), "GO_OPENSSL_TEST_CONFIG="+cfg)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestInitLibraryPath(t *testing.T) {
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_LIBRARY_PATH") != "" {
+		// Running in the child process started below.
+		return
+	}
+	run := func(path string) ([]byte, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestInitLibraryPath$")
+		cmd.Env = append(os.Environ(), "GO_OPENSSL_LIBRARY_PATH="+path, "GO_OPENSSL_VERSION_OVERRIDE=not-a-version")
+		return cmd.CombinedOutput()
+	}
+	missing := filepath.Join(t.TempDir(), "libcrypto.so")
+	out, err := run(missing)
+	if err == nil {
+		t.Fatalf("loading %s succeeded", missing)
+	}
+	if !strings.Contains(string(out), "can't load "+missing) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if vMajor != 3 {
+		t.Skip("skipping loading " + libraryName("3") + " on OpenSSL " + strconv.Itoa(vMajor))
+	}
+	if out, err := run(libraryName("3")); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestInitWithHandle(t *testing.T) {
+	if err := InitWithHandle(nil); err == nil {
+		t.Error("expected error for nil handle")
+	}
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_TEST_HANDLE") != "" {
+		// Running in the child process started below,
+		// which initialized the library from the handle.
+		return
+	}
+	if vMajor != 3 {
+		t.Skip("skipping loading " + libraryName("3") + " on OpenSSL " + strconv.Itoa(vMajor))
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInitWithHandle$")
+	// A library path that can't be loaded checks that the handle is used instead.
+	cmd.Env = append(os.Environ(), "GO_OPENSSL_TEST_HANDLE="+libraryName("3"), "GO_OPENSSL_LIBRARY_PATH="+filepath.Join(t.TempDir(), "missing"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestLoadLibraryErrors(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{filepath.Join(dir, "libcrypto.so.3"), filepath.Join(dir, "libcrypto.so.1.1")}
+	_, err := loadLibrary(names)
+	if err == nil {
+		t.Fatal("expected error loading missing libraries")
+	}
+	for _, name := range names {
+		if !strings.Contains(err.Error(), name+": ") {
+			t.Errorf("error doesn't report %s: %v", name, err)
+		}
+	}
+	if _, err := loadLibrary(nil); err == nil {
+		t.Error("expected error without library names")
+	}
+}
+
+func TestDefaultLibraryNames(t *testing.T) {
+	names := defaultLibraryNames()
+	if len(names) == 0 {
+		t.Fatal("no default library names")
+	}
+	for _, name := range names {
+		// Apple's LibreSSL stubs must never be probed.
+		if strings.HasPrefix(name, "/usr/lib/") || filepath.Base(name) == "libcrypto.dylib" {
+			t.Errorf("unexpected default library name %s", name)
+		}
+	}
+}
+
+// buildFakeLibrary compiles the C source src into a shared library
+// named name and returns its path.
+func buildFakeLibrary(t *testing.T, name, src string) string {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "fake.c")
+	lib := filepath.Join(dir, name)
+	if err := os.WriteFile(srcPath, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("cc", "-shared", "-fPIC", "-o", lib, srcPath).CombinedOutput(); err != nil {
+		t.Skipf("can't build a fake libcrypto: %v\n%s", err, out)
+	}
+	return lib
+}
+
+// initFails checks that Init fails in a child process loading lib
+// with an error containing want.
+func initFails(t *testing.T, lib, want string) {
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), "GO_OPENSSL_LIBRARY_PATH="+lib)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Init succeeded with %s", lib)
+	}
+	if !strings.Contains(string(out), want) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestLibreSSLRejected(t *testing.T) {
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_LIBRARY_PATH") != "" {
+		// Running in the child process started by initFails.
+		return
+	}
+	lib := buildFakeLibrary(t, "libcrypto.so.50", `const char *OpenSSL_version(int t) { return "LibreSSL 3.8.2"; }
+unsigned long OpenSSL_version_num(void) { return 0x20000000L; }
+`)
+	if _, err := loadLibrary([]string{lib}); err == nil || !strings.Contains(err.Error(), "LibreSSL 3.8.2 is not supported") {
+		t.Errorf("loadLibrary: unexpected error: %v", err)
+	}
+	initFails(t, lib, "openssl: LibreSSL 3.8.2 is not supported")
+}
+
+func TestMissingFunctions(t *testing.T) {
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_LIBRARY_PATH") != "" {
+		// Running in the child process started by initFails.
+		return
+	}
+	lib := buildFakeLibrary(t, "libcrypto.so.1.1", `const char *OpenSSL_version(int t) { return "AWS-LC 1.20.0"; }
+unsigned long OpenSSL_version_num(void) { return 0x1010107fL; }
+unsigned long awslc_api_version_num(void) { return 1; }
+int FIPS_mode(void) { return 0; }
+unsigned long ERR_get_error(void) { return 0; }
+`)
+	initFails(t, lib, "openssl: AWS-LC (OpenSSL 1.1 API) lacks required functions: ERR_clear_error, ")
+}
+
+func TestLazyMissingFunctions(t *testing.T) {
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_LIBRARY_PATH") != "" {
+		// Running in the child process started below,
+		// initialized with a libcrypto lacking most functions.
+		// The missing function is recorded on the calling thread.
+		runtime.LockOSThread()
+		_, _, _, err := GenerateKeyECDSA("P-256")
+		runtime.UnlockOSThread()
+		if !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("got %v, want ErrNotSupported", err)
+		}
+		if want := "the loaded libcrypto lacks EVP_PKEY_CTX_new_id, which every supported OpenSSL version has"; !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+		return
+	}
+	lib := buildFakeLibrary(t, "libcrypto.so.1.1", fakeRequiredFunctions)
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLazyMissingFunctions$")
+	cmd.Env = append(os.Environ(), "GO_OPENSSL_LIBRARY_PATH="+lib)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+// fakeRequiredFunctions is the source of a libcrypto 1.1
+// with only the requiredFunctions.
+const fakeRequiredFunctions = `const char *OpenSSL_version(int t) { return "OpenSSL 1.1.1x"; }
+unsigned long OpenSSL_version_num(void) { return 0x1010118fL; }
+unsigned long ERR_get_error(void) { return 0; }
+void ERR_clear_error(void) {}
+void ERR_error_string_n(unsigned long e, char *buf, int len) {}
+void OPENSSL_init(void) {}
+int OPENSSL_init_crypto(unsigned long long opts, void *settings) { return 1; }
+void RSA_get0_key(void) {}
+void RSA_get0_factors(void) {}
+void RSA_get0_crt_params(void) {}
+void OPENSSL_cleanse(void *ptr, unsigned long len) {}
+void RAND_add(const void *buf, int num, double randomness) {}
+int X509_NAME_cmp(const void *a, const void *b) { return 1; }
+int X509_check_issued(void *issuer, void *subject) { return 1; }
+int X509_CRL_get0_by_serial(void *crl, void **ret, const void *serial) { return 1; }
+int X509_STORE_CTX_get_error(const void *ctx) { return 1; }
+void X509_VERIFY_PARAM_set_time(void *param, long t) {}
+void X509_VERIFY_PARAM_set_hostflags(void *param, unsigned int flags) {}
+int TS_VERIFY_CTX_add_flags(void *ctx, int f) { return f; }
+void *TS_VERIFY_CTX_set_store(void *ctx, void *s) { return s; }
+void *TS_VERIFY_CTX_set_certs(void *ctx, void *certs) { return certs; }
+int EVP_EncryptUpdate(void *ctx, unsigned char *out, int *outl, const unsigned char *in, int inl) { return 1; }
+int EVP_DecryptUpdate(void *ctx, unsigned char *out, int *outl, const unsigned char *in, int inl) { return 1; }
+int HMAC_Update(void *ctx, const unsigned char *data, unsigned long len) { return 1; }
+int HMAC_Final(void *ctx, unsigned char *md, unsigned int *len) { return 1; }
+int BN_bn2bin(const void *a, unsigned char *to) { return 0; }
+`
+
+func TestMissingSecurityFunctions(t *testing.T) {
+	if _, ok := staticLibrary(); ok {
+		t.Skip("libcrypto is linked at build time")
+	}
+	if os.Getenv("GO_OPENSSL_LIBRARY_PATH") != "" {
+		// Running in the child process started by initFails.
+		return
+	}
+	for _, fn := range []string{
+		// The stub of a missing X509_NAME_cmp would report equal names.
+		"X509_NAME_cmp",
+		// The stub of a missing TS_VERIFY_CTX_add_flags would skip
+		// the verification of the timestamp signature.
+		"TS_VERIFY_CTX_add_flags",
+	} {
+		src := strings.Replace(fakeRequiredFunctions, " "+fn+"(", " "+fn+"_unused(", 1)
+		lib := buildFakeLibrary(t, "libcrypto.so.1.1", src)
+		initFails(t, lib, "openssl: OpenSSL 1.1 lacks required functions: "+fn)
+	}
+}
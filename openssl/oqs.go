@@ -0,0 +1,39 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// OQSProvider is the module name of the Open Quantum Safe provider,
+// https://github.com/open-quantum-safe/oqs-provider, which implements
+// post-quantum signature and key encapsulation algorithms on OpenSSL 3.
+const OQSProvider = "oqsprovider"
+
+// LoadOQSProvider loads the Open Quantum Safe provider into the default
+// library context with LoadProvider. modulePath is the path of the
+// provider module; if empty, it is looked up in the OpenSSL modules
+// directory as OQSProvider.
+//
+// Once loaded, its algorithms are available through the generic PKey API
+// using the names the provider registers, for example:
+//
+//	k, err := openssl.GenerateKey("mldsa65", nil)
+//	sig, err := k.Sign(msg, "")
+//
+//	k, err := openssl.GenerateKey("kyber768", nil)
+//	pub, err := k.PublicKeyBytes()
+//	peer, err := openssl.NewPublicPKey("kyber768", pub)
+//	ciphertext, secret, err := peer.Encapsulate(nil)
+//
+// The algorithms of the Open Quantum Safe provider are not FIPS approved
+// and are intended for prototyping.
+//
+// LoadOQSProvider is only supported on OpenSSL 3.
+func LoadOQSProvider(modulePath string) error {
+	if modulePath == "" {
+		modulePath = OQSProvider
+	}
+	return LoadProvider(modulePath)
+}
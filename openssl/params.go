@@ -0,0 +1,264 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// paramBuilder constructs OSSL_PARAM arrays using OSSL_PARAM_BLD.
+//
+// OSSL_PARAM_BLD stores string values by reference until
+// build is called, so paramBuilder copies them into C memory
+// and only releases that memory in free.
+// Keys are still referenced by the built array, so they are interned
+// with paramKey instead.
+// The first error encountered is reported by build.
+type paramBuilder struct {
+	bld    C.GO_OSSL_PARAM_BLD_PTR
+	allocs []unsafe.Pointer
+	bns    []C.GO_BIGNUM_PTR
+	err    error
+}
+
+var (
+	paramKeysMu sync.Mutex
+	paramKeys   = make(map[string]*C.char)
+)
+
+// paramKey returns a C copy of key that is never freed,
+// as OSSL_PARAM arrays reference their keys and may outlive
+// the builder that created them.
+func paramKey(key string) *C.char {
+	paramKeysMu.Lock()
+	defer paramKeysMu.Unlock()
+	p, ok := paramKeys[key]
+	if !ok {
+		p = C.CString(key)
+		paramKeys[key] = p
+	}
+	return p
+}
+
+func newParamBuilder() (*paramBuilder, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	bld := C.go_openssl_OSSL_PARAM_BLD_new()
+	if bld == nil {
+		return nil, newOpenSSLError("OSSL_PARAM_BLD_new")
+	}
+	return &paramBuilder{bld: bld}, nil
+}
+
+// free releases the builder and all the C memory it references.
+// Params returned by build are not affected.
+func (b *paramBuilder) free() {
+	if b.bld != nil {
+		C.go_openssl_OSSL_PARAM_BLD_free(b.bld)
+		b.bld = nil
+	}
+	for _, p := range b.allocs {
+		C.free(p)
+	}
+	b.allocs = nil
+	for _, bn := range b.bns {
+		C.go_openssl_BN_clear_free(bn)
+	}
+	b.bns = nil
+}
+
+func (b *paramBuilder) cstring(s string) *C.char {
+	p := C.CString(s)
+	b.allocs = append(b.allocs, unsafe.Pointer(p))
+	return p
+}
+
+func (b *paramBuilder) addUTF8String(key, value string) {
+	if b.err != nil {
+		return
+	}
+	if C.go_openssl_OSSL_PARAM_BLD_push_utf8_string(b.bld, paramKey(key), b.cstring(value), C.size_t(len(value))) != 1 {
+		b.err = newOpenSSLError("OSSL_PARAM_BLD_push_utf8_string(" + key + ")")
+	}
+}
+
+func (b *paramBuilder) addOctetString(key string, value []byte) {
+	if b.err != nil {
+		return
+	}
+	// Go guarantees C.malloc never returns nil.
+	p := C.malloc(C.size_t(len(value) + 1))
+	b.allocs = append(b.allocs, p)
+	copy((*[1 << 30]byte)(p)[:len(value):len(value)], value)
+	if C.go_openssl_OSSL_PARAM_BLD_push_octet_string(b.bld, paramKey(key), p, C.size_t(len(value))) != 1 {
+		b.err = newOpenSSLError("OSSL_PARAM_BLD_push_octet_string(" + key + ")")
+	}
+}
+
+// addBN adds the big-endian unsigned integer value,
+// which may be larger than 64 bits.
+func (b *paramBuilder) addBN(key string, value []byte) {
+	b.pushBN(key, value, bytesToBN)
+}
+
+// addSecretBN is like addBN for private key components,
+// which are allocated in the secure heap, if any.
+func (b *paramBuilder) addSecretBN(key string, value []byte) {
+	b.pushBN(key, value, secretBytesToBN)
+}
+
+func (b *paramBuilder) pushBN(key string, value []byte, toBN func([]byte) C.GO_BIGNUM_PTR) {
+	if b.err != nil {
+		return
+	}
+	bn := toBN(value)
+	if bn == nil {
+		b.err = newOpenSSLError("BN_bin2bn")
+		return
+	}
+	b.bns = append(b.bns, bn)
+	if C.go_openssl_OSSL_PARAM_BLD_push_BN(b.bld, paramKey(key), bn) != 1 {
+		b.err = newOpenSSLError("OSSL_PARAM_BLD_push_BN(" + key + ")")
+	}
+}
+
+func (b *paramBuilder) addInt64(key string, value int64) {
+	if b.err != nil {
+		return
+	}
+	if C.go_openssl_OSSL_PARAM_BLD_push_int64(b.bld, paramKey(key), C.int64_t(value)) != 1 {
+		b.err = newOpenSSLError("OSSL_PARAM_BLD_push_int64(" + key + ")")
+	}
+}
+
+func (b *paramBuilder) addUint64(key string, value uint64) {
+	if b.err != nil {
+		return
+	}
+	if C.go_openssl_OSSL_PARAM_BLD_push_uint64(b.bld, paramKey(key), C.uint64_t(value)) != 1 {
+		b.err = newOpenSSLError("OSSL_PARAM_BLD_push_uint64(" + key + ")")
+	}
+}
+
+// addValues adds all the entries in values, converting each value
+// to the type declared for its key in the settable parameter list.
+// Keys are added in lexical order so the result is deterministic.
+func (b *paramBuilder) addValues(settable *C.OSSL_PARAM, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if b.err != nil {
+			return
+		}
+		b.addValue(settable, k, values[k])
+	}
+}
+
+func (b *paramBuilder) addValue(settable *C.OSSL_PARAM, key string, value interface{}) {
+	var p *C.OSSL_PARAM
+	if settable != nil {
+		ckey := C.CString(key)
+		p = C.go_openssl_OSSL_PARAM_locate_const(settable, ckey)
+		C.free(unsafe.Pointer(ckey))
+	}
+	if p == nil {
+		b.err = errors.New("openssl: unknown parameter " + strconv.Quote(key))
+		return
+	}
+	switch p.data_type {
+	case C.GO_OSSL_PARAM_UTF8_STRING:
+		switch v := value.(type) {
+		case string:
+			b.addUTF8String(key, v)
+			return
+		case []byte:
+			b.addUTF8String(key, string(v))
+			return
+		}
+	case C.GO_OSSL_PARAM_OCTET_STRING:
+		switch v := value.(type) {
+		case []byte:
+			b.addOctetString(key, v)
+			return
+		case string:
+			b.addOctetString(key, []byte(v))
+			return
+		}
+	case C.GO_OSSL_PARAM_INTEGER, C.GO_OSSL_PARAM_UNSIGNED_INTEGER:
+		var i int64
+		var u uint64
+		var signed bool
+		switch v := value.(type) {
+		case int:
+			i, signed = int64(v), true
+		case int32:
+			i, signed = int64(v), true
+		case int64:
+			i, signed = v, true
+		case uint:
+			u = uint64(v)
+		case uint32:
+			u = uint64(v)
+		case uint64:
+			u = v
+		case bool:
+			if v {
+				u = 1
+			}
+		default:
+			b.err = errors.New("openssl: parameter " + strconv.Quote(key) + " requires an integer value")
+			return
+		}
+		if p.data_type == C.GO_OSSL_PARAM_INTEGER {
+			if !signed {
+				i = int64(u)
+				if i < 0 {
+					b.err = errors.New("openssl: parameter " + strconv.Quote(key) + " out of range")
+					return
+				}
+			}
+			b.addInt64(key, i)
+			return
+		}
+		if signed {
+			if i < 0 {
+				b.err = errors.New("openssl: parameter " + strconv.Quote(key) + " can't be negative")
+				return
+			}
+			u = uint64(i)
+		}
+		b.addUint64(key, u)
+		return
+	default:
+		b.err = errors.New("openssl: parameter " + strconv.Quote(key) + " has an unsupported type")
+		return
+	}
+	b.err = errors.New("openssl: parameter " + strconv.Quote(key) + " requires a string or []byte value")
+}
+
+// build returns the OSSL_PARAM array containing all the added parameters.
+// The caller is responsible for freeing the result using OSSL_PARAM_free.
+func (b *paramBuilder) build() (*C.OSSL_PARAM, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	params := C.go_openssl_OSSL_PARAM_BLD_to_param(b.bld)
+	if params == nil {
+		return nil, newOpenSSLError("OSSL_PARAM_BLD_to_param")
+	}
+	return params, nil
+}
@@ -0,0 +1,417 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"strconv"
+)
+
+// PBES2Cipher identifies the content encryption scheme used by PBES2.
+type PBES2Cipher int
+
+const (
+	PBES2AES128CBC PBES2Cipher = iota + 1
+	PBES2AES192CBC
+	PBES2AES256CBC
+	PBES2AES128GCM
+	PBES2AES192GCM
+	PBES2AES256GCM
+)
+
+// PBES2Options configures the PBES2 encryption performed by
+// EncryptPKCS8PrivateKey and EncryptPEMBlock.
+// The zero value uses the defaults documented on each field.
+type PBES2Options struct {
+	// Cipher is the content encryption scheme.
+	// PBES2AES256CBC is used if zero.
+	// Note that the AES-GCM schemes, as specified in RFC 5084,
+	// are not understood by the openssl command line tools,
+	// so AES-CBC should be preferred when interoperability matters.
+	Cipher PBES2Cipher
+	// Hash is the PBKDF2 pseudorandom function.
+	// It must be one of crypto.SHA1, crypto.SHA224, crypto.SHA256,
+	// crypto.SHA384 or crypto.SHA512. crypto.SHA256 is used if zero.
+	Hash crypto.Hash
+	// Iterations is the PBKDF2 iteration count.
+	// 100000 is used if zero.
+	Iterations int
+	// SaltSize is the size of the random PBKDF2 salt in bytes.
+	// 16 is used if zero.
+	SaltSize int
+}
+
+const (
+	pbes2DefaultIterations = 100000
+	pbes2DefaultSaltSize   = 16
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES128GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+	oidAES192GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}
+	oidAES256GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+type pbes2CipherInfo struct {
+	oid     asn1.ObjectIdentifier
+	keySize int
+	gcm     bool
+	// name is the OpenSSL name of the cipher.
+	name string
+}
+
+var pbes2Ciphers = map[PBES2Cipher]pbes2CipherInfo{
+	PBES2AES128CBC: {oidAES128CBC, 16, false, "AES-128-CBC"},
+	PBES2AES192CBC: {oidAES192CBC, 24, false, "AES-192-CBC"},
+	PBES2AES256CBC: {oidAES256CBC, 32, false, "AES-256-CBC"},
+	PBES2AES128GCM: {oidAES128GCM, 16, true, "AES-128-GCM"},
+	PBES2AES192GCM: {oidAES192GCM, 24, true, "AES-192-GCM"},
+	PBES2AES256GCM: {oidAES256GCM, 32, true, "AES-256-GCM"},
+}
+
+var pbes2PRFs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA1:   oidHMACWithSHA1,
+	crypto.SHA224: oidHMACWithSHA224,
+	crypto.SHA256: oidHMACWithSHA256,
+	crypto.SHA384: oidHMACWithSHA384,
+	crypto.SHA512: oidHMACWithSHA512,
+}
+
+// pbes2Options returns opts with the defaults applied,
+// and the information of the selected cipher.
+func pbes2Options(opts *PBES2Options) (PBES2Options, pbes2CipherInfo, error) {
+	var o PBES2Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Cipher == 0 {
+		o.Cipher = PBES2AES256CBC
+	}
+	if o.Hash == 0 {
+		o.Hash = crypto.SHA256
+	}
+	if o.Iterations == 0 {
+		o.Iterations = pbes2DefaultIterations
+	}
+	if o.SaltSize == 0 {
+		o.SaltSize = pbes2DefaultSaltSize
+	}
+	info, ok := pbes2Ciphers[o.Cipher]
+	if !ok {
+		return o, info, errors.New("openssl: unsupported PBES2 cipher " + strconv.Itoa(int(o.Cipher)))
+	}
+	if _, ok := pbes2PRFs[o.Hash]; !ok {
+		return o, info, errors.New("openssl: unsupported PBES2 hash function " + strconv.Itoa(int(o.Hash)))
+	}
+	if o.Iterations < 0 || o.SaltSize < 0 {
+		return o, info, errors.New("openssl: invalid PBES2 options")
+	}
+	return o, info, nil
+}
+
+// ASN.1 structures from RFC 5208, RFC 8018 and RFC 5084.
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm algorithmIdentifier
+	EncryptedData       []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                 `asn1:"optional"`
+	PRF            algorithmIdentifier `asn1:"optional"`
+}
+
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+func marshalParams(v interface{}) (asn1.RawValue, error) {
+	der, err := asn1.Marshal(v)
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{FullBytes: der}, nil
+}
+
+// EncryptPKCS8PrivateKey encrypts der, a PKCS #8 PrivateKeyInfo,
+// with password using PBES2 and returns the DER encoding of the resulting
+// PKCS #8 EncryptedPrivateKeyInfo. Randomness is drawn from RandReader.
+// If opts is nil, the defaults described in PBES2Options are used.
+func EncryptPKCS8PrivateKey(der, password []byte, opts *PBES2Options) ([]byte, error) {
+	o, info, err := pbes2Options(opts)
+	if err != nil {
+		return nil, err
+	}
+	prf := pbes2PRFs[o.Hash]
+
+	salt := make([]byte, o.SaltSize)
+	if _, err := RandReader.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2(password, salt, o.Iterations, info.keySize, cryptoHashToMD(o.Hash))
+	if err != nil {
+		return nil, err
+	}
+	release, err := ProtectSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	block, err := NewAESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAll(block)
+
+	var encParams interface{}
+	var encrypted []byte
+	if info.gcm {
+		aead, err := block.(extraModes).NewGCM(gcmStandardNonceSize, gcmTagSize)
+		if err != nil {
+			return nil, err
+		}
+		defer closeAll(aead)
+		nonce := make([]byte, gcmStandardNonceSize)
+		if _, err := RandReader.Read(nonce); err != nil {
+			return nil, err
+		}
+		encrypted = aead.Seal(nil, nonce, der, nil)
+		encParams = gcmParams{Nonce: nonce, ICVLen: gcmTagSize}
+	} else {
+		iv := make([]byte, aesBlockSize)
+		if _, err := RandReader.Read(iv); err != nil {
+			return nil, err
+		}
+		encrypted = pkcs7Pad(der, aesBlockSize)
+		cbc := block.(extraModes).NewCBCEncrypter(iv)
+		cbc.CryptBlocks(encrypted, encrypted)
+		closeAll(cbc)
+		encParams = iv
+	}
+
+	kdfParams, err := marshalParams(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: o.Iterations,
+		KeyLength:      info.keySize,
+		PRF:            algorithmIdentifier{Algorithm: prf, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	schemeParams, err := marshalParams(encParams)
+	if err != nil {
+		return nil, err
+	}
+	pbes2, err := marshalParams(pbes2Params{
+		KeyDerivationFunc: algorithmIdentifier{Algorithm: oidPBKDF2, Parameters: kdfParams},
+		EncryptionScheme:  algorithmIdentifier{Algorithm: info.oid, Parameters: schemeParams},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: algorithmIdentifier{Algorithm: oidPBES2, Parameters: pbes2},
+		EncryptedData:       encrypted,
+	})
+}
+
+// DecryptPKCS8PrivateKey decrypts der, the DER encoding of a PKCS #8
+// EncryptedPrivateKeyInfo protected with PBES2, and returns the
+// PKCS #8 PrivateKeyInfo it contains.
+// Only PBKDF2 with AES-CBC or AES-GCM is supported.
+func DecryptPKCS8PrivateKey(der, password []byte) ([]byte, error) {
+	var epki encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &epki); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("openssl: trailing data after EncryptedPrivateKeyInfo")
+	}
+	if !epki.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.New("openssl: unsupported encryption algorithm " + epki.EncryptionAlgorithm.Algorithm.String())
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(epki.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errors.New("openssl: unsupported key derivation function " + params.KeyDerivationFunc.Algorithm.String())
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+	// The PRF defaults to hmacWithSHA1 when absent.
+	h := crypto.SHA1
+	if len(kdfParams.PRF.Algorithm) > 0 {
+		h = 0
+		for ch, oid := range pbes2PRFs {
+			if oid.Equal(kdfParams.PRF.Algorithm) {
+				h = ch
+				break
+			}
+		}
+		if h == 0 {
+			return nil, errors.New("openssl: unsupported PBKDF2 PRF " + kdfParams.PRF.Algorithm.String())
+		}
+	}
+	var info pbes2CipherInfo
+	for _, ci := range pbes2Ciphers {
+		if ci.oid.Equal(params.EncryptionScheme.Algorithm) {
+			info = ci
+			break
+		}
+	}
+	if info.oid == nil {
+		return nil, errors.New("openssl: unsupported encryption scheme " + params.EncryptionScheme.Algorithm.String())
+	}
+	if kdfParams.KeyLength != 0 && kdfParams.KeyLength != info.keySize {
+		return nil, errors.New("openssl: PBKDF2 key length does not match the encryption scheme")
+	}
+	if kdfParams.IterationCount <= 0 {
+		return nil, errors.New("openssl: invalid PBKDF2 iteration count")
+	}
+	key, err := pbkdf2(password, kdfParams.Salt, kdfParams.IterationCount, info.keySize, cryptoHashToMD(h))
+	if err != nil {
+		return nil, err
+	}
+	release, err := ProtectSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	block, err := NewAESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAll(block)
+	if info.gcm {
+		var gp gcmParams
+		if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &gp); err != nil {
+			return nil, err
+		}
+		aead, err := block.(extraModes).NewGCM(len(gp.Nonce), gp.ICVLen)
+		if err != nil {
+			return nil, err
+		}
+		defer closeAll(aead)
+		out, err := aead.Open(nil, gp.Nonce, epki.EncryptedData, nil)
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		return out, nil
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aesBlockSize {
+		return nil, errors.New("openssl: invalid AES-CBC IV length")
+	}
+	data := epki.EncryptedData
+	if len(data) == 0 || len(data)%aesBlockSize != 0 {
+		return nil, ErrDecryption
+	}
+	out := make([]byte, len(data))
+	cbc := block.(extraModes).NewCBCDecrypter(iv)
+	cbc.CryptBlocks(out, data)
+	closeAll(cbc)
+	out, ok := pkcs7Unpad(out, aesBlockSize)
+	if !ok {
+		return nil, ErrDecryption
+	}
+	// A wrong password yields valid padding about once every 256 tries,
+	// so also check that the plaintext is a single DER SEQUENCE,
+	// as a PrivateKeyInfo is.
+	var v asn1.RawValue
+	if rest, err := asn1.Unmarshal(out, &v); err != nil || len(rest) != 0 || v.Class != asn1.ClassUniversal || v.Tag != asn1.TagSequence {
+		return nil, ErrDecryption
+	}
+	return out, nil
+}
+
+// EncryptPEMBlock encrypts der, a PKCS #8 PrivateKeyInfo, with password
+// using PBES2 and returns an "ENCRYPTED PRIVATE KEY" PEM block.
+// If opts is nil, the defaults described in PBES2Options are used.
+func EncryptPEMBlock(der, password []byte, opts *PBES2Options) (*pem.Block, error) {
+	enc, err := EncryptPKCS8PrivateKey(der, password, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: enc}, nil
+}
+
+// DecryptPEMBlock decrypts an "ENCRYPTED PRIVATE KEY" PEM block
+// protected with PBES2 and returns the PKCS #8 PrivateKeyInfo it contains.
+func DecryptPEMBlock(b *pem.Block, password []byte) ([]byte, error) {
+	if b.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, errors.New("openssl: unexpected PEM block type " + strconv.Quote(b.Type))
+	}
+	return DecryptPKCS8PrivateKey(b.Bytes, password)
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	out := make([]byte, len(data)+n)
+	copy(out, data)
+	for i := len(data); i < len(out); i++ {
+		out[i] = byte(n)
+	}
+	return out
+}
+
+// pkcs7Unpad removes the PKCS #7 padding of data in constant time,
+// checking the whole last block so that the time taken doesn't depend
+// on the padding length or on the first invalid byte.
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, bool) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, false
+	}
+	last := data[len(data)-blockSize:]
+	n := last[blockSize-1]
+	// good is 1 while the padding is valid: 1 <= n <= blockSize and
+	// the last n bytes are all n.
+	good := 1 - subtle.ConstantTimeByteEq(n, 0)
+	good &= subtle.ConstantTimeLessOrEq(int(n), blockSize)
+	for i := 0; i < blockSize; i++ {
+		// inPad is 1 if last[i] is one of the last n bytes.
+		inPad := subtle.ConstantTimeLessOrEq(blockSize-i, int(n))
+		eq := subtle.ConstantTimeByteEq(last[i], n)
+		good &= subtle.ConstantTimeSelect(inPad, eq, 1)
+	}
+	if good != 1 {
+		return nil, false
+	}
+	return data[:len(data)-int(n)], true
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"hash"
+	"unsafe"
+)
+
+// PBKDF2 derives a key of keyLen bytes from password and salt
+// using PBKDF2 with iter iterations, as specified in RFC 8018.
+// The function h must return a hash implemented by
+// OpenSSL (for example, h could be openssl.NewSHA256).
+func PBKDF2(password, salt []byte, iter, keyLen int, h func() hash.Hash) ([]byte, error) {
+	ch := h()
+	defer closeAll(ch)
+	md := hashToMD(ch)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported hash function")
+	}
+	return pbkdf2(password, salt, iter, keyLen, md)
+}
+
+func pbkdf2(password, salt []byte, iter, keyLen int, md C.GO_EVP_MD_PTR) ([]byte, error) {
+	if len(password) == 0 {
+		// Empty passwords are supported by crypto/hmac and x/crypto/pbkdf2,
+		// but OpenSSL treats a NULL key as "reuse the previous key" in some versions.
+		// As in NewHMAC, pass an all-zero key instead, which is equivalent
+		// because HMAC keys are zero-padded to the block size.
+		password = make([]byte, C.GO_EVP_MAX_MD_SIZE)
+	}
+	out := make([]byte, keyLen)
+	ok := C.go_openssl_PKCS5_PBKDF2_HMAC((*C.char)(unsafe.Pointer(base(password))), C.int(len(password)), base(salt), C.int(len(salt)), C.int(iter), md, C.int(keyLen), base(out))
+	if ok != 1 {
+		return nil, newOpenSSLError("PKCS5_PBKDF2_HMAC")
+	}
+	return out, nil
+}
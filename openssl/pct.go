@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"sync/atomic"
+)
+
+// PCTMode controls the pairwise consistency test run after key generation.
+type PCTMode int32
+
+const (
+	// PCTAuto runs the test only when FIPS reports true. It is the default.
+	PCTAuto PCTMode = iota
+	// PCTAlways always runs the test.
+	PCTAlways
+	// PCTNever never runs the test.
+	PCTNever
+)
+
+// pctMode holds the current PCTMode.
+var pctMode int32
+
+// SetPCTMode sets whether GenerateKeyRSA and GenerateKeyECDSA run a
+// pairwise consistency test on each new key pair, as required by
+// FIPS 140-3 for key generation services. The test signs a fixed
+// message with the new private key and verifies the signature with
+// the public key; if it fails, no key is returned.
+//
+// The FIPS provider of OpenSSL 3 runs its own test internally;
+// this one additionally covers keys generated by other providers.
+func SetPCTMode(mode PCTMode) {
+	atomic.StoreInt32(&pctMode, int32(mode))
+}
+
+// errPCTFailed is returned when a new key pair fails the pairwise consistency test.
+var errPCTFailed = errors.New("openssl: pairwise consistency test failed")
+
+func pctEnabled() bool {
+	switch PCTMode(atomic.LoadInt32(&pctMode)) {
+	case PCTAlways:
+		return true
+	case PCTNever:
+		return false
+	}
+	return FIPS()
+}
+
+// pairwiseConsistencyTest signs and verifies a fixed message using pkey,
+// an RSA or EC key of type id, if the test is enabled.
+func pairwiseConsistencyTest(id C.int, pkey C.GO_EVP_PKEY_PTR) error {
+	if !pctEnabled() {
+		return nil
+	}
+	withKey := func(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+		return f(pkey)
+	}
+	var padding C.int
+	var h crypto.Hash
+	if id == C.GO_EVP_PKEY_RSA {
+		padding, h = C.GO_RSA_PKCS1_PADDING, crypto.SHA256
+	}
+	hashed := SHA256([]byte("go-crypto-openssl pairwise consistency test"))
+	sig, err := evpSign(withKey, padding, 0, h, hashed[:])
+	if err != nil {
+		return errPCTFailed
+	}
+	if err := evpVerify(withKey, padding, 0, h, sig, hashed[:]); err != nil {
+		return errPCTFailed
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "testing"
+
+func TestPairwiseConsistencyTest(t *testing.T) {
+	SetPCTMode(PCTAlways)
+	defer SetPCTMode(PCTAuto)
+	if !pctEnabled() {
+		t.Fatal("PCTAlways did not enable the test")
+	}
+	if _, _, _, _, _, _, _, _, err := GenerateKeyRSA(2048); err != nil {
+		t.Errorf("GenerateKeyRSA: %v", err)
+	}
+	for _, curve := range []string{"P-256", "P-384", "P-521"} {
+		if _, _, _, err := GenerateKeyECDSA(curve); err != nil {
+			t.Errorf("GenerateKeyECDSA(%s): %v", curve, err)
+		}
+	}
+	SetPCTMode(PCTNever)
+	if pctEnabled() {
+		t.Error("PCTNever did not disable the test")
+	}
+	SetPCTMode(PCTAuto)
+	if pctEnabled() != FIPS() {
+		t.Error("PCTAuto does not follow FIPS")
+	}
+}
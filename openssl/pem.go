@@ -0,0 +1,269 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"bytes"
+	"errors"
+	"unsafe"
+)
+
+// The PEM functions read and write PEM through OpenSSL, so that
+// the headers written and understood, such as the Proc-Type and
+// DEK-Info headers of encrypted traditional keys, match the
+// openssl command line tool. Reading skips any text before the
+// first PEM block of the expected type.
+
+// pemDefaultCipher is the cipher MarshalPEMPrivateKey encrypts keys with.
+const pemDefaultCipher = "AES-256-CBC"
+
+// PEMOptions configures the encryption of private keys by MarshalPEMPrivateKey.
+type PEMOptions struct {
+	// Cipher is the OpenSSL name of the cipher encrypting the key,
+	// such as "AES-128-CBC". If empty, AES-256-CBC is used.
+	Cipher string
+
+	// Traditional selects the algorithm specific format, such as
+	// "EC PRIVATE KEY", instead of PKCS #8. Encrypted traditional keys
+	// are protected with the Proc-Type and DEK-Info headers of RFC 1421,
+	// which derive the key with a single MD5 iteration.
+	// It should only be used for tools that don't understand PKCS #8.
+	Traditional bool
+}
+
+// pemPassword converts password to a C string, which can't contain NUL.
+// It returns nil if password is nil. The caller must free it with
+// freeSecretCString.
+func pemPassword(password []byte) (*C.char, error) {
+	if password == nil {
+		return nil, nil
+	}
+	if bytes.IndexByte(password, 0) >= 0 {
+		return nil, errors.New("openssl: PEM password contains a NUL byte")
+	}
+	return secretCString(password)
+}
+
+// isPEMNoStartLine reports whether the last error
+// is the failure to find another PEM block.
+func isPEMNoStartLine() bool {
+	lib, reason := errLibReason(C.go_openssl_ERR_peek_last_error())
+	return lib == C.GO_ERR_LIB_PEM && reason == C.GO_PEM_R_NO_START_LINE
+}
+
+// ParsePEMPrivateKey returns the private key of the first PEM block
+// of data containing one, either in PKCS #8 or in the traditional
+// format. Encrypted keys are decrypted with password. If password is
+// nil, decrypting a key fails instead of prompting for a password.
+//
+// ParsePEMPrivateKey is only supported on OpenSSL 3.
+func ParsePEMPrivateKey(data, password []byte) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	cpass, err := pemPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	defer freeSecretCString(cpass)
+	bio, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	pkey := C.go_openssl_PEM_read_bio_PrivateKey_pass(bio, cpass)
+	if pkey == nil {
+		return nil, newOpenSSLError("PEM_read_bio_PrivateKey")
+	}
+	auditPKey(AuditImport, pkey)
+	return newPKey(nil, pkey), nil
+}
+
+// MarshalPEMPrivateKey returns the PEM encoding of the private key k,
+// encrypted with password as configured by opts. If password is nil,
+// the key isn't encrypted. If opts is nil, the defaults described in
+// PEMOptions are used.
+//
+// MarshalPEMPrivateKey is only supported on OpenSSL 3.
+func MarshalPEMPrivateKey(k *PKey, password []byte, opts *PEMOptions) ([]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	var o PEMOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Cipher == "" {
+		o.Cipher = pemDefaultCipher
+	}
+	cpass, err := pemPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	defer freeSecretCString(cpass)
+	var cipher C.GO_EVP_CIPHER_PTR
+	if password != nil {
+		if cipher, err = (*LibraryContext)(nil).fetchCipher(o.Cipher); err != nil {
+			return nil, err
+		}
+		defer C.go_openssl_EVP_CIPHER_free(cipher)
+	}
+	bio, err := newMemBIO(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	var name string
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		// The password is passed explicitly, so OpenSSL never prompts for it.
+		if o.Traditional {
+			name = "PEM_write_bio_PrivateKey_traditional"
+			return C.go_openssl_PEM_write_bio_PrivateKey_traditional(bio, pkey, cipher, (*C.uchar)(unsafe.Pointer(cpass)), C.int(len(password)), nil, nil)
+		}
+		name = "PEM_write_bio_PKCS8PrivateKey"
+		return C.go_openssl_PEM_write_bio_PKCS8PrivateKey(bio, pkey, cipher, cpass, C.int(len(password)), nil, nil)
+	}) != 1 {
+		return nil, newOpenSSLError(name)
+	}
+	return memBIOBytes(bio), nil
+}
+
+// ParsePEMPublicKey returns the public key of the first "PUBLIC KEY"
+// PEM block of data, which contains a PKIX SubjectPublicKeyInfo.
+//
+// ParsePEMPublicKey is only supported on OpenSSL 3.
+func ParsePEMPublicKey(data []byte) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	bio, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	pkey := C.go_openssl_PEM_read_bio_PUBKEY_pass(bio, nil)
+	if pkey == nil {
+		return nil, newOpenSSLError("PEM_read_bio_PUBKEY")
+	}
+	return newPKey(nil, pkey), nil
+}
+
+// MarshalPEMPublicKey returns the public key of k as a "PUBLIC KEY" PEM block.
+//
+// MarshalPEMPublicKey is only supported on OpenSSL 3.
+func MarshalPEMPublicKey(k *PKey) ([]byte, error) {
+	return marshalPEMKey(k, "PEM_write_bio_PUBKEY", func(bio C.GO_BIO_PTR, pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_PEM_write_bio_PUBKEY(bio, pkey)
+	})
+}
+
+// ParsePEMParameters returns the key parameters of the first PEM block
+// of data containing parameters, such as "EC PARAMETERS" or "DH PARAMETERS".
+// The returned PKey has no public or private key.
+//
+// ParsePEMParameters is only supported on OpenSSL 3.
+func ParsePEMParameters(data []byte) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	bio, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	pkey := C.go_openssl_PEM_read_bio_Parameters(bio, nil)
+	if pkey == nil {
+		return nil, newOpenSSLError("PEM_read_bio_Parameters")
+	}
+	return newPKey(nil, pkey), nil
+}
+
+// MarshalPEMParameters returns the PEM encoding of the key parameters of k,
+// for example the curve of an EC key.
+//
+// MarshalPEMParameters is only supported on OpenSSL 3.
+func MarshalPEMParameters(k *PKey) ([]byte, error) {
+	return marshalPEMKey(k, "PEM_write_bio_Parameters", func(bio C.GO_BIO_PTR, pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_PEM_write_bio_Parameters(bio, pkey)
+	})
+}
+
+func marshalPEMKey(k *PKey, name string, write func(C.GO_BIO_PTR, C.GO_EVP_PKEY_PTR) C.int) ([]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	bio, err := newMemBIO(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return write(bio, pkey)
+	}) != 1 {
+		return nil, newOpenSSLError(name)
+	}
+	return memBIOBytes(bio), nil
+}
+
+// ParsePEMCertificates returns the DER encoding of the certificates of
+// all the "CERTIFICATE" PEM blocks of data, in order.
+// It returns an error if data contains no certificate.
+//
+// ParsePEMCertificates is only supported on OpenSSL 3.
+func ParsePEMCertificates(data []byte) ([][]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	bio, err := newMemBIO(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	var certs [][]byte
+	for {
+		x := C.go_openssl_PEM_read_bio_X509_pass(bio, nil)
+		if x == nil {
+			if len(certs) > 0 && isPEMNoStartLine() {
+				C.go_openssl_ERR_clear_error()
+				return certs, nil
+			}
+			return nil, newOpenSSLError("PEM_read_bio_X509")
+		}
+		der, err := marshalX509(x)
+		C.go_openssl_X509_free(x)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, der)
+	}
+}
+
+// MarshalPEMCertificate returns the DER encoded certificate der
+// as a "CERTIFICATE" PEM block.
+//
+// MarshalPEMCertificate is only supported on OpenSSL 3.
+func MarshalPEMCertificate(der []byte) ([]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	x, err := parseX509(der)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_X509_free(x)
+	bio, err := newMemBIO(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	if C.go_openssl_PEM_write_bio_X509(bio, x) != 1 {
+		return nil, newOpenSSLError("PEM_write_bio_X509")
+	}
+	return memBIOBytes(bio), nil
+}
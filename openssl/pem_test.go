@@ -0,0 +1,176 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// certificatePEM is a self-signed P-256 certificate for the subject "leaf".
+const certificatePEM = `
+-----BEGIN CERTIFICATE-----
+MIIBdTCCARugAwIBAgIUMEzJM1yD8MuBmqWEPyQevg65ymowCgYIKoZIzj0EAwIw
+DzENMAsGA1UEAwwEbGVhZjAgFw0yNjEwMTQwNTQ4MDdaGA8yMTI2MDkyMDA1NDgw
+N1owDzENMAsGA1UEAwwEbGVhZjBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABJPd
+1d2uu1/Gh2tdMgc2dONE30pCysrYYeTjq+ilbdkYAus1VpKTlwNWyKX0CnSWYVmM
+XAP9VJHYW6JJiiInpz+jUzBRMB0GA1UdDgQWBBTGpcCAsMJ/BuG23KdAifYp7dSE
+0jAfBgNVHSMEGDAWgBTGpcCAsMJ/BuG23KdAifYp7dSE0jAPBgNVHRMBAf8EBTAD
+AQH/MAoGCCqGSM49BAMCA0gAMEUCIQDd1wRqKjiieRc54ToLtLhSua+/ReADTx4/
+wpmf3J5VagIgHBsGMQI4ITrsjaGWL5Ze15mPSVfGGKAocG0gcygbl2A=
+-----END CERTIFICATE-----
+`
+
+const ecParametersPEM = `-----BEGIN EC PARAMETERS-----
+BggqhkjOPQMBBw==
+-----END EC PARAMETERS-----
+`
+
+func TestPEMPrivateKey(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PEM is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	password := []byte("password")
+	tests := []struct {
+		name     string
+		password []byte
+		opts     *PEMOptions
+		typ      string
+		header   string
+	}{
+		{"PKCS8", nil, nil, "PRIVATE KEY", ""},
+		{"EncryptedPKCS8", password, nil, "ENCRYPTED PRIVATE KEY", ""},
+		{"Traditional", nil, &PEMOptions{Traditional: true}, "EC PRIVATE KEY", ""},
+		{"EncryptedTraditional", password, &PEMOptions{Cipher: "AES-128-CBC", Traditional: true}, "EC PRIVATE KEY", "AES-128-CBC,"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalPEMPrivateKey(k, tt.password, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := pem.Decode(data)
+			if b == nil || b.Type != tt.typ {
+				t.Fatalf("got PEM %q, want type %q", data, tt.typ)
+			}
+			if !strings.HasPrefix(b.Headers["DEK-Info"], tt.header) {
+				t.Errorf("got DEK-Info %q, want prefix %q", b.Headers["DEK-Info"], tt.header)
+			}
+			k2, err := ParsePEMPrivateKey(append([]byte("leading text\n"), data...), tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := k2.Sign(msg, "SHA256")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := k.Verify(msg, sig, "SHA256"); err != nil {
+				t.Errorf("signature by the parsed key doesn't verify: %v", err)
+			}
+			if tt.password != nil {
+				if _, err := ParsePEMPrivateKey(data, []byte("wrong")); err == nil {
+					t.Error("expected error for wrong password")
+				}
+				if _, err := ParsePEMPrivateKey(data, nil); err == nil {
+					t.Error("expected error without password")
+				}
+			}
+		})
+	}
+	if _, err := MarshalPEMPrivateKey(k, []byte("pass\x00word"), nil); err == nil {
+		t.Error("expected error for password with NUL byte")
+	}
+	if _, err := MarshalPEMPrivateKey(k, password, &PEMOptions{Cipher: "NOT-A-CIPHER"}); err == nil {
+		t.Error("expected error for unknown cipher")
+	}
+}
+
+func TestPEMPublicKey(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PEM is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalPEMPublicKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := pem.Decode(data); b == nil || b.Type != "PUBLIC KEY" {
+		t.Fatalf("got PEM %q, want a PUBLIC KEY block", data)
+	}
+	pub, err := ParsePEMPublicKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	sig, err := k.Sign(msg, "SHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Verify(msg, sig, "SHA256"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParsePEMPublicKey([]byte(ecParametersPEM)); err == nil {
+		t.Error("expected error for PEM without public key")
+	}
+}
+
+func TestPEMParameters(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PEM is only supported on OpenSSL 3")
+	}
+	params, err := ParsePEMParameters([]byte(ecParametersPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := params.Algorithm(); got != "EC" {
+		t.Errorf("got algorithm %q, want EC", got)
+	}
+	data, err := MarshalPEMParameters(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != ecParametersPEM {
+		t.Errorf("got %q, want %q", data, ecParametersPEM)
+	}
+}
+
+func TestPEMCertificates(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PEM is only supported on OpenSSL 3")
+	}
+	certs, err := ParsePEMCertificates([]byte(certificatePEM + "some text\n" + certificatePEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("got %d certificates, want 2", len(certs))
+	}
+	b, _ := pem.Decode([]byte(certificatePEM))
+	if !bytes.Equal(certs[0], b.Bytes) || !bytes.Equal(certs[1], b.Bytes) {
+		t.Error("parsed certificates don't match")
+	}
+	data, err := MarshalPEMCertificate(certs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != strings.TrimPrefix(certificatePEM, "\n") {
+		t.Errorf("got %q, want %q", data, certificatePEM)
+	}
+	if _, err := ParsePEMCertificates([]byte(ecParametersPEM)); err == nil {
+		t.Error("expected error for PEM without certificates")
+	}
+}
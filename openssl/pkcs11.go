@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// PKCS11Provider is the module name of pkcs11-provider,
+// https://github.com/latchset/pkcs11-provider, which gives access to
+// the keys of HSMs and smart cards through a PKCS #11 module on
+// OpenSSL 3. It replaces the deprecated pkcs11 ENGINE of libp11, see
+// LoadEngine, and is the only PKCS #11 integration some distributions
+// ship.
+const PKCS11Provider = "pkcs11"
+
+// LoadPKCS11Provider loads pkcs11-provider into the default library
+// context with LoadProvider. modulePath is the path of the provider
+// module; if empty, it is looked up in the OpenSSL modules directory
+// as PKCS11Provider. The PKCS #11 module it drives, such as
+// libsofthsm2.so, is set by the pkcs11-module-path parameter of the
+// OpenSSL configuration or by the PKCS11_PROVIDER_MODULE environment
+// variable, which must be set before loading the provider.
+//
+// Once loaded, keys and certificates are loaded by PKCS #11 URI, with
+// the PIN given by the passphrase callback:
+//
+//	lib, err := openssl.PKCS11LibraryContext()
+//	key, err := lib.LoadKeyWithPassphrase("pkcs11:token=hsm;object=signing-key;type=private", pin)
+//	cert, err := lib.LoadCert("pkcs11:token=hsm;object=signing-key;type=cert")
+//
+// The private key operations are then performed by the token.
+//
+// LoadPKCS11Provider is only supported on OpenSSL 3.
+func LoadPKCS11Provider(modulePath string) error {
+	if modulePath == "" {
+		modulePath = PKCS11Provider
+	}
+	return LoadProvider(modulePath)
+}
+
+// PKCS11LibraryContext returns the default library context with the
+// property query "?provider=pkcs11", which routes the loading of
+// "pkcs11:" URIs and the operations that pkcs11-provider implements
+// to it, and falls back to the other providers for the rest, such as
+// the hashing of the messages signed by the token. The provider must
+// be loaded under the name PKCS11Provider, that is with an empty
+// modulePath or by the OpenSSL configuration.
+//
+// PKCS11LibraryContext is only supported on OpenSSL 3.
+func PKCS11LibraryContext() (*LibraryContext, error) {
+	l, err := DefaultLibraryContext()
+	if err != nil {
+		return nil, err
+	}
+	return l.WithProperties("?provider=" + PKCS11Provider), nil
+}
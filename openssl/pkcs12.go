@@ -0,0 +1,192 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// pkcs12DefaultIterations is the default PBKDF2 and MAC iteration count
+// of CreatePKCS12, which matches the OpenSSL default.
+const pkcs12DefaultIterations = 2048
+
+var errPKCS12MAC = errors.New("openssl: PKCS #12 integrity check failed, the password may be incorrect")
+
+// PKCS12Options configures CreatePKCS12.
+type PKCS12Options struct {
+	// FriendlyName is the friendly name attribute of the key and
+	// certificate. If empty, no friendly name is set.
+	FriendlyName string
+
+	// Iterations is the iteration count of both PBKDF2 and the MAC
+	// key derivation. If zero, 2048 is used.
+	Iterations int
+}
+
+// ParsePKCS12 decodes der, a PKCS #12 archive also known as a .p12 or
+// .pfx file, protected with password. It returns the private key, the
+// certificate matching it and the other certificates of the archive,
+// such as the CA chain. The certificates are DER encoded. key and cert
+// are nil if the archive doesn't contain them.
+//
+// Archives encrypted with the legacy RC2 scheme, still produced by
+// some older tools, are supported by loading the legacy provider on
+// demand unless FIPS mode is enabled. See LoadLegacyProvider.
+//
+// ParsePKCS12 is only supported on OpenSSL 3.
+func ParsePKCS12(der []byte, password string) (key *PKey, cert []byte, caCerts [][]byte, err error) {
+	if vMajor != 3 {
+		return nil, nil, nil, errUnsuportedVersion()
+	}
+	if len(der) == 0 {
+		return nil, nil, nil, errors.New("openssl: empty PKCS #12 archive")
+	}
+	cpass, err := pkcs12Password(password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer freeSecretCString(cpass)
+	p12 := C.go_openssl_d2i_PKCS12_buf(base(der), C.long(len(der)))
+	if p12 == nil {
+		return nil, nil, nil, newOpenSSLError("d2i_PKCS12")
+	}
+	defer C.go_openssl_PKCS12_free(p12)
+	// Check the MAC first so that a wrong password is reported as such,
+	// and is not mistaken for an encryption scheme that needs the
+	// legacy provider. As PKCS12_parse does, an empty password matches
+	// both an empty and an absent password.
+	if C.go_openssl_PKCS12_mac_present(p12) == 1 &&
+		C.go_openssl_PKCS12_verify_mac(p12, cpass, -1) != 1 &&
+		(password != "" || C.go_openssl_PKCS12_verify_mac(p12, nil, 0) != 1) {
+		C.go_openssl_ERR_clear_error()
+		return nil, nil, nil, errPKCS12MAC
+	}
+	var pkey C.GO_EVP_PKEY_PTR
+	var x509 C.GO_X509_PTR
+	var ca C.GO_STACK_OF_X509_PTR
+	if C.go_openssl_PKCS12_parse(p12, cpass, &pkey, &x509, &ca) != 1 {
+		if !loadLegacyFor(nil, "RC2-40-CBC") || C.go_openssl_PKCS12_parse(p12, cpass, &pkey, &x509, &ca) != 1 {
+			return nil, nil, nil, newOpenSSLError("PKCS12_parse")
+		}
+	}
+	if pkey != nil {
+		key = newPKey(nil, pkey)
+	}
+	if x509 != nil {
+		defer C.go_openssl_X509_free(x509)
+		if cert, err = marshalX509(x509); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if ca != nil {
+		certs := x509Stack{C.GO_OPENSSL_STACK_PTR(ca)}
+		defer certs.free()
+		for _, x := range certs.certs() {
+			b, err := marshalX509(x)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			caCerts = append(caCerts, b)
+		}
+	}
+	return key, cert, caCerts, nil
+}
+
+// CreatePKCS12 returns a PKCS #12 archive protected with password,
+// containing key, the DER encoded certificate cert and the DER encoded
+// certificates caCerts. key and cert can be nil, but not both.
+// If opts is nil, the defaults described in PKCS12Options are used.
+//
+// The key and certificates are encrypted with AES-256-CBC keyed by
+// PBKDF2 with HMAC-SHA256, and the archive is authenticated with an
+// HMAC-SHA256 MAC. Some older tools only understand the legacy
+// RC2 and 3DES schemes and can't read the result.
+//
+// CreatePKCS12 is only supported on OpenSSL 3.
+func CreatePKCS12(key *PKey, cert []byte, caCerts [][]byte, password string, opts *PKCS12Options) ([]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	if key == nil && cert == nil {
+		return nil, errors.New("openssl: PKCS #12 archive needs a key or a certificate")
+	}
+	var o PKCS12Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Iterations == 0 {
+		o.Iterations = pkcs12DefaultIterations
+	} else if o.Iterations < 0 {
+		return nil, errors.New("openssl: invalid PKCS #12 iteration count")
+	}
+	cpass, err := pkcs12Password(password)
+	if err != nil {
+		return nil, err
+	}
+	defer freeSecretCString(cpass)
+	var name *C.char
+	if o.FriendlyName != "" {
+		name = C.CString(o.FriendlyName)
+		defer C.free(unsafe.Pointer(name))
+	}
+	var x509 C.GO_X509_PTR
+	if cert != nil {
+		if x509, err = parseX509(cert); err != nil {
+			return nil, err
+		}
+		defer C.go_openssl_X509_free(x509)
+	}
+	ca, err := newX509StackFromDER(caCerts)
+	if err != nil {
+		return nil, err
+	}
+	defer ca.free()
+	create := func(pkey C.GO_EVP_PKEY_PTR) C.GO_PKCS12_PTR {
+		// The MAC is set below, PKCS12_create would use SHA-1.
+		return C.go_openssl_PKCS12_create(cpass, name, pkey, x509, C.GO_STACK_OF_X509_PTR(ca.st),
+			C.GO_NID_aes_256_cbc, C.GO_NID_aes_256_cbc, C.int(o.Iterations), -1, 0)
+	}
+	var p12 C.GO_PKCS12_PTR
+	if key == nil {
+		p12 = create(nil)
+	} else {
+		key.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+			p12 = create(pkey)
+			return 1
+		})
+	}
+	if p12 == nil {
+		return nil, newOpenSSLError("PKCS12_create")
+	}
+	defer C.go_openssl_PKCS12_free(p12)
+	if C.go_openssl_PKCS12_set_mac(p12, cpass, -1, nil, 0, C.int(o.Iterations), cryptoHashToMD(crypto.SHA256)) != 1 {
+		return nil, newOpenSSLError("PKCS12_set_mac")
+	}
+	n := C.go_openssl_i2d_PKCS12_buf(p12, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_PKCS12")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_PKCS12_buf(p12, base(der)) != n {
+		return nil, newOpenSSLError("i2d_PKCS12")
+	}
+	return der, nil
+}
+
+// pkcs12Password converts password to a C string, which can't contain NUL.
+// The caller must free it with freeSecretCString.
+func pkcs12Password(password string) (*C.char, error) {
+	if strings.IndexByte(password, 0) >= 0 {
+		return nil, errors.New("openssl: PKCS #12 password contains a NUL byte")
+	}
+	return secretCStringFromString(password)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package pkcs12 reads and writes PKCS #12 archives, also known as
+// .p12 or .pfx files, with OpenSSL. It is modeled after the
+// golang.org/x/crypto/pkcs12 package, but returns the private keys
+// as openssl.PKey values so that they never leave OpenSSL.
+//
+// See openssl.ParsePKCS12 and openssl.CreatePKCS12 for the supported
+// encryption schemes.
+package pkcs12
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+// Decode extracts a private key and the matching certificate from
+// pfxData, which must contain both.
+func Decode(pfxData []byte, password string) (*openssl.PKey, *x509.Certificate, error) {
+	key, cert, _, err := DecodeChain(pfxData, password)
+	return key, cert, err
+}
+
+// DecodeChain extracts a private key, the matching certificate and the
+// other certificates, such as the CA chain, from pfxData, which must
+// contain a private key and the matching certificate.
+func DecodeChain(pfxData []byte, password string) (key *openssl.PKey, cert *x509.Certificate, caCerts []*x509.Certificate, err error) {
+	key, der, caDER, err := openssl.ParsePKCS12(pfxData, password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if key == nil {
+		return nil, nil, nil, errors.New("pkcs12: private key missing")
+	}
+	if der == nil {
+		return nil, nil, nil, errors.New("pkcs12: certificate missing")
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		return nil, nil, nil, err
+	}
+	if caCerts, err = parseCertificates(caDER); err != nil {
+		return nil, nil, nil, err
+	}
+	return key, cert, caCerts, nil
+}
+
+// DecodeTrustStore extracts the certificates of pfxData, which must
+// not contain a private key.
+func DecodeTrustStore(pfxData []byte, password string) ([]*x509.Certificate, error) {
+	key, der, caDER, err := openssl.ParsePKCS12(pfxData, password)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		return nil, errors.New("pkcs12: trust store contains a private key")
+	}
+	if der != nil {
+		caDER = append([][]byte{der}, caDER...)
+	}
+	return parseCertificates(caDER)
+}
+
+// Encode returns a PKCS #12 archive protected with password containing
+// key, the matching certificate cert and the other certificates caCerts.
+// If opts is nil, the defaults described in openssl.PKCS12Options are used.
+func Encode(key *openssl.PKey, cert *x509.Certificate, caCerts []*x509.Certificate, password string, opts *openssl.PKCS12Options) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("pkcs12: private key missing")
+	}
+	if cert == nil {
+		return nil, errors.New("pkcs12: certificate missing")
+	}
+	return openssl.CreatePKCS12(key, cert.Raw, rawCertificates(caCerts), password, opts)
+}
+
+// EncodeTrustStore returns a PKCS #12 archive protected with password
+// containing certs and no private key.
+// If opts is nil, the defaults described in openssl.PKCS12Options are used.
+func EncodeTrustStore(certs []*x509.Certificate, password string, opts *openssl.PKCS12Options) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("pkcs12: no certificates")
+	}
+	return openssl.CreatePKCS12(nil, certs[0].Raw, rawCertificates(certs[1:]), password, opts)
+}
+
+func parseCertificates(ders [][]byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(ders))
+	for _, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func rawCertificates(certs []*x509.Certificate) [][]byte {
+	ders := make([][]byte, 0, len(certs))
+	for _, cert := range certs {
+		ders = append(ders, cert.Raw)
+	}
+	return ders
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package pkcs12
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newCertificate returns a self-signed certificate and its private key.
+func newCertificate(t *testing.T, name string) (*openssl.PKey, *x509.Certificate) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Move the key to OpenSSL through an encrypted PKCS #8 blob.
+	p8, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := openssl.EncryptPKCS8PrivateKey(p8, []byte("password"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := openssl.ParsePKCS8PrivateKeyWithPassword(enc, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+func TestEncodeDecode(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("PKCS #12 is only supported on OpenSSL 3")
+	}
+	key, cert := newCertificate(t, "leaf")
+	_, ca := newCertificate(t, "ca")
+	pfx, err := Encode(key, cert, []*x509.Certificate{ca}, "password", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, cert2, caCerts, err := DecodeChain(pfx, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key2.Algorithm() != "EC" || !cert2.Equal(cert) || len(caCerts) != 1 || !caCerts[0].Equal(ca) {
+		t.Errorf("got %s key, certificate %q and %d CA certificates", key2.Algorithm(), cert2.Subject.CommonName, len(caCerts))
+	}
+	if _, err := DecodeTrustStore(pfx, "password"); err == nil {
+		t.Error("expected error decoding an archive with a private key as a trust store")
+	}
+
+	store, err := EncodeTrustStore([]*x509.Certificate{cert, ca}, "password", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := DecodeTrustStore(store, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("got %d certificates, want 2", len(certs))
+	}
+	if _, _, err := Decode(store, "password"); err == nil {
+		t.Error("expected error decoding a trust store as a key archive")
+	}
+}
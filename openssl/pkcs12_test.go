@@ -0,0 +1,152 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+)
+
+// legacyPKCS12 contains a P-256 key, its self-signed certificate and
+// an unrelated CA certificate. It was generated with:
+//
+//	openssl pkcs12 -export -legacy -inkey key.pem -in cert.pem -certfile ca.pem -passout pass:password
+//
+// The certificates are encrypted with RC2-40, which requires the
+// legacy provider, and the key with 3DES.
+const legacyPKCS12 = `
+MIIFGgIBAzCCBOAGCSqGSIb3DQEHAaCCBNEEggTNMIIEyTCCA78GCSqGSIb3DQEH
+BqCCA7AwggOsAgEAMIIDpQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQYwDgQIXa1i
+psy78eACAggAgIIDeAnTbDuCI8s2yQ27IJIjZhmJO+yEeRSpYE+4x6Hz6p5CKHEg
+XysHuiz0wWGvg+1e8htXzYXpZKXNpVOMmHh1nmHhfi3PSF95PtbEnHh/CFcLqs5k
+CCafRHx4ac6B7PJbJVeZumN/+Vw2OxPOPDSqiRrss2G2OFrlKU4pWUZqZmz4nwXC
+KUtHE8oB1tc0rOk1mlKbgE+FgKkZcEiZQrs6q14JHY+moUoMxrslZSqBRdC1jbwZ
+tzPty9FhyU3dbhxYF5oq2/Wkc4EU8XMec/bcTmvO5Q2yAUruv453jKYEV1XTa+vV
+FOZ9Cg5yhcxpjsu5Wcqb8HoFTGASyK18WVoYR9fCJh32PRYm1GZz0blIcJnAmQzJ
+sBJcUlS0S9ut8dqhOcf52QXK+cbvUwiZ4/WdIfLOAit4JXnEtitgYlo7bg5vsi0M
+WwdOu+1vNWam51fzlG38khNGIrDP+oe7U+jJ4bzLqn28NelQABIm7BcQjXfoeP2X
+WS/22h0ToUivO09hkHKNyvIf132kC3D9pK8Pvn/ovPXJPkddnbQ8o1PV0nhjdAZ7
+nrRkLWRV/XNv0EVSilXhVuAYGSpUvee6sfNTFHvr0tBzGBGLDNenKl3eApxQ4tBB
+3ZIqdbVDp8HGgM139VXnQwjv8d9d9nppG+Q370hV/yL9x9iLDzoe1A51msrKE7sD
+Od0w2G2OfvP9CuBULPU6rfE8pBXEkD8UBJlBe6zlOH7/LOuHNzI+qt4b51CU67jn
+iSpnOAt3RNKbqRrLbuiNj6eFnzm79Ip/mpFl0GqIvJhnkubCI2bxPvU7Fc8vwXYO
+civqjQA+Kv1fRpKF1vIj0x9oQXCOyjacXC5PQ7d1KRvtlrZkT6Pk5LHGjWELTfeN
+2vNPO+FnUBbJ+9/2Rj6ZfdQv4dtUX/pLQiXV2N9unGgOpX/btaCLxGEMU1GatA9e
+f4lGvT/Ax0pl5luqTwKY1mDhFJDmMgmCoqFxnOI/S23uZIYYb2IhtcECaMOB3YB2
+zSlqpuDhicpTavw9CnHCxPrDtXXzHST/anP3Qh1orclsqzhWEY4N5C/doMyn+alV
+lFRWuBgWlGdEE2/ML8M2iQtzJAv3T+U1BGJSKgcIhYWTj4S3Whg1N28SUBh6lPW7
+K1yzANV2LT07le1U2rsiCZE+OA0bajM8ug788QGEMuh15PduWDCCAQIGCSqGSIb3
+DQEHAaCB9ASB8TCB7jCB6wYLKoZIhvcNAQwKAQKggbQwgbEwHAYKKoZIhvcNAQwB
+AzAOBAi+QQ9GixRlogICCAAEgZDypBQxuNNYtB4/MvwxX7pw7NpyeSGY4RLYKY1S
+BDNKzFxKNLzkfJd2ppwZIk26xPoqnMVjiOx+Ikmypa/g4RwebAHZjdghUod+Q17G
+khDqKkMDrNPd74WqKvx/TrfLti3iL5mLXUfpL6yYeCnKV5b5vL5SudhAKvEFWQBH
+EjCDtBwNKuifVZfo59fzm/7GkZgxJTAjBgkqhkiG9w0BCRUxFgQUj23zuAtw5Cpg
+gHNiLZeOaZnqC5cwMTAhMAkGBSsOAwIaBQAEFPBKztrjdmQFGAE3hCSBT+ajOhVI
+BAiLnEsdALDQWQICCAA=
+`
+
+func decodeLegacyPKCS12(t *testing.T) []byte {
+	t.Helper()
+	if vMajor != 3 {
+		t.Skip("PKCS #12 is only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("RC2 is not available in FIPS mode")
+	}
+	b, err := base64.StdEncoding.DecodeString(legacyPKCS12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func checkCommonName(t *testing.T, der []byte, want string) {
+	t.Helper()
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cert.Subject.CommonName; got != want {
+		t.Errorf("got certificate %q, want %q", got, want)
+	}
+}
+
+func TestParsePKCS12Legacy(t *testing.T) {
+	p12 := decodeLegacyPKCS12(t)
+	key, cert, caCerts, err := ParsePKCS12(p12, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.Algorithm(); got != "EC" {
+		t.Errorf("got algorithm %q, want EC", got)
+	}
+	checkCommonName(t, cert, "leaf")
+	if len(caCerts) != 1 {
+		t.Fatalf("got %d CA certificates, want 1", len(caCerts))
+	}
+	checkCommonName(t, caCerts[0], "ca")
+	if _, _, _, err := ParsePKCS12(p12, "wrong"); err != errPKCS12MAC {
+		t.Errorf("got error %v for wrong password, want %v", err, errPKCS12MAC)
+	}
+	if _, _, _, err := ParsePKCS12(p12[:len(p12)-1], "password"); err == nil {
+		t.Error("expected error for truncated archive")
+	}
+}
+
+func TestCreatePKCS12(t *testing.T) {
+	key, cert, caCerts, err := ParsePKCS12(decodeLegacyPKCS12(t), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	for _, password := range []string{"new password", ""} {
+		p12, err := CreatePKCS12(key, cert, caCerts, password, &PKCS12Options{FriendlyName: "leaf", Iterations: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		key2, cert2, caCerts2, err := ParsePKCS12(p12, password)
+		if err != nil {
+			t.Fatalf("password %q: %v", password, err)
+		}
+		if !bytes.Equal(cert, cert2) {
+			t.Errorf("password %q: certificate changed", password)
+		}
+		if len(caCerts2) != 1 || !bytes.Equal(caCerts[0], caCerts2[0]) {
+			t.Errorf("password %q: CA certificates changed", password)
+		}
+		sig, err := key2.Sign(msg, "SHA256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := key.Verify(msg, sig, "SHA256"); err != nil {
+			t.Errorf("password %q: signature by the parsed key doesn't verify: %v", password, err)
+		}
+	}
+	// A trust store only contains certificates.
+	p12, err := CreatePKCS12(nil, cert, caCerts, "password", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, cert2, caCerts2, err := ParsePKCS12(p12, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key2 != nil || cert2 != nil || len(caCerts2) != 2 {
+		t.Errorf("got key %v, certificate %x and %d CA certificates, want only 2 CA certificates", key2, cert2, len(caCerts2))
+	}
+	if _, err := CreatePKCS12(nil, nil, caCerts, "password", nil); err == nil {
+		t.Error("expected error without key and certificate")
+	}
+	if _, err := CreatePKCS12(key, caCerts[0], nil, "password", nil); err == nil {
+		t.Error("expected error for a certificate not matching the key")
+	}
+	if _, err := CreatePKCS12(key, cert, nil, "pass\x00word", nil); err == nil {
+		t.Error("expected error for password with NUL byte")
+	}
+}
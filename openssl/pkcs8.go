@@ -0,0 +1,179 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"encoding/asn1"
+	"errors"
+	"unsafe"
+)
+
+var pbes2PRFNIDs = map[crypto.Hash]C.int{
+	crypto.SHA1:   C.GO_NID_hmacWithSHA1,
+	crypto.SHA224: C.GO_NID_hmacWithSHA224,
+	crypto.SHA256: C.GO_NID_hmacWithSHA256,
+	crypto.SHA384: C.GO_NID_hmacWithSHA384,
+	crypto.SHA512: C.GO_NID_hmacWithSHA512,
+}
+
+// MarshalPKCS8PrivateKeyEncrypted returns the DER encoding of the PKCS #8
+// EncryptedPrivateKeyInfo protecting the private key k with password.
+// The key is encrypted by OpenSSL with PBES2, as configured by opts.
+// If opts is nil, the defaults described in PBES2Options are used.
+//
+// OpenSSL doesn't implement the AES-GCM schemes, which are then
+// implemented as in EncryptPKCS8PrivateKey.
+//
+// MarshalPKCS8PrivateKeyEncrypted is only supported on OpenSSL 3.
+func MarshalPKCS8PrivateKeyEncrypted(k *PKey, password []byte, opts *PBES2Options) ([]byte, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	o, info, err := pbes2Options(opts)
+	if err != nil {
+		return nil, err
+	}
+	p8 := k.pkcs8()
+	if p8 == nil {
+		return nil, newOpenSSLError("EVP_PKEY2PKCS8")
+	}
+	defer C.go_openssl_PKCS8_PRIV_KEY_INFO_free(p8)
+	if info.gcm {
+		der, err := marshalPKCS8Info(p8)
+		if err != nil {
+			return nil, err
+		}
+		return EncryptPKCS8PrivateKey(der, password, &o)
+	}
+	cipher, err := (*LibraryContext)(nil).fetchCipher(info.name)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EVP_CIPHER_free(cipher)
+	pbe := C.go_openssl_PKCS5_pbe2_set_iv(cipher, C.int(o.Iterations), nil, C.int(o.SaltSize), nil, pbes2PRFNIDs[o.Hash])
+	if pbe == nil {
+		return nil, newOpenSSLError("PKCS5_pbe2_set_iv")
+	}
+	// PKCS8_set0_pbe only takes ownership of pbe on success.
+	sig := C.go_openssl_PKCS8_set0_pbe((*C.char)(unsafe.Pointer(base(password))), C.int(len(password)), p8, pbe)
+	if sig == nil {
+		C.go_openssl_X509_ALGOR_free(pbe)
+		return nil, newOpenSSLError("PKCS8_set0_pbe")
+	}
+	defer C.go_openssl_X509_SIG_free(sig)
+	n := C.go_openssl_i2d_X509_SIG_buf(sig, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_X509_SIG")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_X509_SIG_buf(sig, base(der)) != n {
+		return nil, newOpenSSLError("i2d_X509_SIG")
+	}
+	return der, nil
+}
+
+// ParsePKCS8PrivateKeyWithPassword decrypts der, the DER encoding of a
+// PKCS #8 EncryptedPrivateKeyInfo, with password and returns the private
+// key it contains. Decryption is performed by OpenSSL, which supports
+// PBES2 with PBKDF2 or scrypt as well as the legacy PKCS #5 and PKCS #12
+// schemes. The AES-GCM schemes of EncryptPKCS8PrivateKey are also supported.
+//
+// ParsePKCS8PrivateKeyWithPassword is only supported on OpenSSL 3.
+func ParsePKCS8PrivateKeyWithPassword(der, password []byte) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty PKCS #8 encrypted private key")
+	}
+	if isPBES2GCM(der) {
+		info, err := DecryptPKCS8PrivateKey(der, password)
+		if err != nil {
+			return nil, err
+		}
+		return parsePKCS8PrivateKey(info)
+	}
+	sig := C.go_openssl_d2i_X509_SIG_buf(base(der), C.long(len(der)))
+	if sig == nil {
+		return nil, newOpenSSLError("d2i_X509_SIG")
+	}
+	defer C.go_openssl_X509_SIG_free(sig)
+	p8 := C.go_openssl_PKCS8_decrypt(sig, (*C.char)(unsafe.Pointer(base(password))), C.int(len(password)))
+	if p8 == nil {
+		return nil, newOpenSSLError("PKCS8_decrypt")
+	}
+	defer C.go_openssl_PKCS8_PRIV_KEY_INFO_free(p8)
+	return pkcs8ToPKey(p8)
+}
+
+// isPBES2GCM reports whether der is an EncryptedPrivateKeyInfo
+// encrypted with one of the PBES2 AES-GCM schemes.
+func isPBES2GCM(der []byte) bool {
+	var epki encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &epki); err != nil || !epki.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return false
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(epki.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		return false
+	}
+	for _, info := range pbes2Ciphers {
+		if info.gcm && params.EncryptionScheme.Algorithm.Equal(info.oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// pkcs8 returns the PKCS #8 PrivateKeyInfo of k, or nil on error.
+func (k *PKey) pkcs8() C.GO_PKCS8_PRIV_KEY_INFO_PTR {
+	var p8 C.GO_PKCS8_PRIV_KEY_INFO_PTR
+	k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		p8 = C.go_openssl_EVP_PKEY2PKCS8(pkey)
+		return 1
+	})
+	return p8
+}
+
+// marshalPKCS8Info returns the DER encoding of p8.
+func marshalPKCS8Info(p8 C.GO_PKCS8_PRIV_KEY_INFO_PTR) ([]byte, error) {
+	n := C.go_openssl_i2d_PKCS8_PRIV_KEY_INFO_buf(p8, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_PKCS8_PRIV_KEY_INFO")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_PKCS8_PRIV_KEY_INFO_buf(p8, base(der)) != n {
+		return nil, newOpenSSLError("i2d_PKCS8_PRIV_KEY_INFO")
+	}
+	return der, nil
+}
+
+// parsePKCS8PrivateKey returns the private key of der,
+// the DER encoding of a PKCS #8 PrivateKeyInfo.
+func parsePKCS8PrivateKey(der []byte) (*PKey, error) {
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty PKCS #8 private key")
+	}
+	p8 := C.go_openssl_d2i_PKCS8_PRIV_KEY_INFO_buf(base(der), C.long(len(der)))
+	if p8 == nil {
+		return nil, newOpenSSLError("d2i_PKCS8_PRIV_KEY_INFO")
+	}
+	defer C.go_openssl_PKCS8_PRIV_KEY_INFO_free(p8)
+	return pkcs8ToPKey(p8)
+}
+
+func pkcs8ToPKey(p8 C.GO_PKCS8_PRIV_KEY_INFO_PTR) (*PKey, error) {
+	pkey := C.go_openssl_EVP_PKCS82PKEY(p8)
+	if pkey == nil {
+		return nil, newOpenSSLError("EVP_PKCS82PKEY")
+	}
+	auditPKey(AuditImport, pkey)
+	return newPKey(nil, pkey), nil
+}
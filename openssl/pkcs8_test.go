@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParsePKCS8PrivateKeyWithPassword(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("ParsePKCS8PrivateKeyWithPassword is only supported on OpenSSL 3")
+	}
+	b, _ := pem.Decode([]byte(encryptedKeyPEM))
+	k, err := ParsePKCS8PrivateKeyWithPassword(b.Bytes, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := k.Algorithm(); got != "EC" {
+		t.Errorf("got algorithm %q, want EC", got)
+	}
+	if _, err := ParsePKCS8PrivateKeyWithPassword(b.Bytes, []byte("wrong")); err == nil {
+		t.Error("expected error for wrong password")
+	}
+	if _, err := ParsePKCS8PrivateKeyWithPassword(b.Bytes[:len(b.Bytes)-1], []byte("password")); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
+
+func TestMarshalPKCS8PrivateKeyEncrypted(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("MarshalPKCS8PrivateKeyEncrypted is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	password := []byte("password")
+	for _, c := range []PBES2Cipher{PBES2AES128CBC, PBES2AES256CBC, PBES2AES256GCM} {
+		opts := &PBES2Options{Cipher: c, Hash: crypto.SHA512, Iterations: 1000}
+		der, err := MarshalPKCS8PrivateKeyEncrypted(k, password, opts)
+		if err != nil {
+			t.Fatalf("cipher %d: %v", c, err)
+		}
+		k2, err := ParsePKCS8PrivateKeyWithPassword(der, password)
+		if err != nil {
+			t.Fatalf("cipher %d: %v", c, err)
+		}
+		sig, err := k2.Sign(msg, "SHA256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := k.Verify(msg, sig, "SHA256"); err != nil {
+			t.Errorf("cipher %d: signature by the parsed key doesn't verify: %v", c, err)
+		}
+		// The Go implementation of PBES2 must understand the OpenSSL encoding.
+		if _, err := DecryptPKCS8PrivateKey(der, password); err != nil {
+			t.Errorf("cipher %d: DecryptPKCS8PrivateKey: %v", c, err)
+		}
+		if _, err := ParsePKCS8PrivateKeyWithPassword(der, []byte("wrong")); err == nil {
+			t.Errorf("cipher %d: expected error for wrong password", c)
+		}
+	}
+	if _, err := MarshalPKCS8PrivateKeyEncrypted(k, password, &PBES2Options{Cipher: 100}); err == nil {
+		t.Error("expected error for unsupported cipher")
+	}
+}
@@ -0,0 +1,550 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"unsafe"
+)
+
+// PKey is an asymmetric key of an algorithm addressed by name,
+// which makes algorithms supplied by any OpenSSL provider,
+// including third-party ones, usable without dedicated bindings.
+//
+// In strict FIPS mode, see SetStrictFIPS, only the approved key types,
+// such as RSA, EC on the NIST curves, EdDSA, ML-KEM, ML-DSA and SLH-DSA,
+// can be generated, imported, or used to sign, encrypt, encapsulate
+// or derive.
+type PKey struct {
+	// lib is the library context the key was created in,
+	// or nil for the default context.
+	lib *LibraryContext
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
+}
+
+// GenerateKey generates a key pair of the algorithm name, such as
+// "RSA", "EC", "ED25519" or an algorithm of a third-party provider,
+// using the key generation parameters params, for example
+// {"bits": 3072} for RSA or {"group": "P-256"} for EC.
+// params are converted as described in NewKDF.
+//
+// GenerateKey is only supported on OpenSSL 3.
+func GenerateKey(name string, params map[string]interface{}) (*PKey, error) {
+	return generateKey(nil, name, params)
+}
+
+// GenerateKey is like the package-level GenerateKey
+// but fetches the key management algorithm from l.
+func (l *LibraryContext) GenerateKey(name string, params map[string]interface{}) (*PKey, error) {
+	return generateKey(l, name, params)
+}
+
+func generateKey(lib *LibraryContext, name string, params map[string]interface{}) (_ *PKey, err error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	defer runtime.KeepAlive(lib)
+	defer endOp(beginOp("EVP_PKEY_generate", MetricKeygen), &err)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	ctx := C.go_openssl_EVP_PKEY_CTX_new_from_name(lib.ptr(), cname, lib.propq())
+	if ctx == nil {
+		return nil, newNotSupportedError(name, "EVP_PKEY_CTX_new_from_name("+name+")")
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	if C.go_openssl_EVP_PKEY_keygen_init(ctx) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_keygen_init")
+	}
+	if err := setPKeyCtxParams(ctx, params); err != nil {
+		return nil, err
+	}
+	var pkey C.GO_EVP_PKEY_PTR
+	generate := func() {
+		if C.go_openssl_EVP_PKEY_generate(ctx, &pkey) != 1 {
+			err = newOpenSSLError("EVP_PKEY_generate")
+		}
+	}
+	if isLongKeygen(name) {
+		runLong(generate)
+	} else {
+		generate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	k := newPKey(lib, pkey)
+	if err := checkStrictPKey(k.withKey); err != nil {
+		k.Close()
+		return nil, err
+	}
+	auditPKey(AuditKeygen, pkey)
+	return k, nil
+}
+
+// NewPublicPKey imports the raw public key pub of the algorithm name,
+// such as "ED25519", "X25519" or an algorithm of a third-party provider,
+// in the encoding defined by the algorithm.
+// Algorithms without a raw public key format, such as RSA and EC,
+// are not supported.
+//
+// NewPublicPKey is only supported on OpenSSL 3.
+func NewPublicPKey(name string, pub []byte) (*PKey, error) {
+	return newRawPKey(nil, name, pub, false)
+}
+
+// NewPrivatePKey is like NewPublicPKey but imports
+// the raw private key priv.
+func NewPrivatePKey(name string, priv []byte) (*PKey, error) {
+	return newRawPKey(nil, name, priv, true)
+}
+
+// NewPublicPKey is like the package-level NewPublicPKey
+// but imports the key into l.
+func (l *LibraryContext) NewPublicPKey(name string, pub []byte) (*PKey, error) {
+	return newRawPKey(l, name, pub, false)
+}
+
+// NewPrivatePKey is like the package-level NewPrivatePKey
+// but imports the key into l.
+func (l *LibraryContext) NewPrivatePKey(name string, priv []byte) (*PKey, error) {
+	return newRawPKey(l, name, priv, true)
+}
+
+func newRawPKey(lib *LibraryContext, name string, key []byte, private bool) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	defer runtime.KeepAlive(lib)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var pkey C.GO_EVP_PKEY_PTR
+	if private {
+		pkey = C.go_openssl_EVP_PKEY_new_raw_private_key_ex(lib.ptr(), cname, lib.propq(), base(key), C.size_t(len(key)))
+		if pkey == nil {
+			return nil, newOpenSSLError("EVP_PKEY_new_raw_private_key_ex(" + name + ")")
+		}
+	} else {
+		pkey = C.go_openssl_EVP_PKEY_new_raw_public_key_ex(lib.ptr(), cname, lib.propq(), base(key), C.size_t(len(key)))
+		if pkey == nil {
+			return nil, newOpenSSLError("EVP_PKEY_new_raw_public_key_ex(" + name + ")")
+		}
+	}
+	k := newPKey(lib, pkey)
+	if err := checkStrictPKey(k.withKey); err != nil {
+		k.Close()
+		return nil, err
+	}
+	if private {
+		auditPKey(AuditImport, pkey)
+	}
+	return k, nil
+}
+
+// newPKeyFromData imports the key of the algorithm name described by the
+// parameters in bld. The key is a key pair if private is true,
+// else a public key.
+func newPKeyFromData(name string, private bool, bld *paramBuilder) (*PKey, error) {
+	params, err := bld.build()
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	ctx := C.go_openssl_EVP_PKEY_CTX_new_from_name(nil, cname, nil)
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_PKEY_CTX_new_from_name(" + name + ")")
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	if C.go_openssl_EVP_PKEY_fromdata_init(ctx) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_fromdata_init")
+	}
+	selection := C.int(C.GO_EVP_PKEY_PUBLIC_KEY)
+	if private {
+		selection = C.GO_EVP_PKEY_KEYPAIR
+	}
+	var pkey C.GO_EVP_PKEY_PTR
+	if C.go_openssl_EVP_PKEY_fromdata(ctx, &pkey, selection, params) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_fromdata")
+	}
+	k := newPKey(nil, pkey)
+	if err := checkStrictPKey(k.withKey); err != nil {
+		k.Close()
+		return nil, err
+	}
+	return k, nil
+}
+
+// supportsKeyType reports whether a provider loaded in the default
+// library context implements keys of the algorithm name.
+func supportsKeyType(name string) bool {
+	if vMajor != 3 {
+		return false
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	keymgmt := C.go_openssl_EVP_KEYMGMT_fetch(nil, cname, nil)
+	if keymgmt == nil {
+		C.go_openssl_ERR_clear_error()
+		return false
+	}
+	C.go_openssl_EVP_KEYMGMT_free(keymgmt)
+	return true
+}
+
+// newPKey wraps pkey, taking ownership of it.
+func newPKey(lib *LibraryContext, pkey C.GO_EVP_PKEY_PTR) *PKey {
+	k := &PKey{lib: lib, closer: pkeyHandle(pkey)}
+	manage(k, (*PKey).finalize)
+	return k
+}
+
+func (k *PKey) finalize() {
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PKey) Close() error {
+	return closeObject(k, k.finalize)
+}
+
+func (k *PKey) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
+}
+
+// setPKeyCtxParams sets params on ctx, which must be initialized
+// for an operation, converting them to the types ctx declares.
+func setPKeyCtxParams(ctx C.GO_EVP_PKEY_CTX_PTR, params map[string]interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	bld, err := newParamBuilder()
+	if err != nil {
+		return err
+	}
+	defer bld.free()
+	bld.addValues(C.go_openssl_EVP_PKEY_CTX_settable_params(ctx), params)
+	cparams, err := bld.build()
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(cparams)
+	if C.go_openssl_EVP_PKEY_CTX_set_params(ctx, cparams) != 1 {
+		return newOpenSSLError("EVP_PKEY_CTX_set_params")
+	}
+	return nil
+}
+
+// Algorithm returns the name of the key type, such as "RSA" or "ED25519".
+func (k *PKey) Algorithm() string {
+	var name string
+	k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		name = C.GoString(C.go_openssl_EVP_PKEY_get0_type_name(pkey))
+		return 1
+	})
+	return name
+}
+
+// Provider returns the name of the provider holding the key, such as
+// "default" or "qatprovider", which serves the operations using it.
+func (k *PKey) Provider() string {
+	var name string
+	k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		if prov := C.go_openssl_EVP_PKEY_get0_provider(pkey); prov != nil {
+			name = C.GoString(C.go_openssl_OSSL_PROVIDER_get0_name(prov))
+		}
+		return 1
+	})
+	return name
+}
+
+// Size returns the maximum size in bytes of a signature
+// or ciphertext produced with the key.
+func (k *PKey) Size() int {
+	return int(k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_EVP_PKEY_get_size(pkey)
+	}))
+}
+
+// PublicKeyBytes returns the raw public key of k,
+// in the encoding used by NewPublicPKey.
+func (k *PKey) PublicKeyBytes() ([]byte, error) {
+	return k.rawKey(false)
+}
+
+// PrivateKeyBytes returns the raw private key of k,
+// in the encoding used by NewPrivatePKey.
+func (k *PKey) PrivateKeyBytes() ([]byte, error) {
+	return k.rawKey(true)
+}
+
+func (k *PKey) rawKey(private bool) ([]byte, error) {
+	get := func(pkey C.GO_EVP_PKEY_PTR, out *C.uchar, n *C.size_t) C.int {
+		if private {
+			return C.go_openssl_EVP_PKEY_get_raw_private_key(pkey, out, n)
+		}
+		return C.go_openssl_EVP_PKEY_get_raw_public_key(pkey, out, n)
+	}
+	var out []byte
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		var n C.size_t
+		if get(pkey, nil, &n) != 1 {
+			return 0
+		}
+		out = make([]byte, n)
+		if get(pkey, base(out), &n) != 1 {
+			return 0
+		}
+		out = out[:n]
+		return 1
+	}) != 1 {
+		if private {
+			return nil, newOpenSSLError("EVP_PKEY_get_raw_private_key")
+		}
+		return nil, newOpenSSLError("EVP_PKEY_get_raw_public_key")
+	}
+	return out, nil
+}
+
+// octetParam returns the octet string parameter name of k.
+func (k *PKey) octetParam(name string) ([]byte, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var out []byte
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		var n C.size_t
+		if C.go_openssl_EVP_PKEY_get_octet_string_param(pkey, cname, nil, 0, &n) != 1 {
+			return 0
+		}
+		out = make([]byte, n)
+		if C.go_openssl_EVP_PKEY_get_octet_string_param(pkey, cname, base(out), n, &n) != 1 {
+			return 0
+		}
+		out = out[:n]
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_get_octet_string_param(" + name + ")")
+	}
+	return out, nil
+}
+
+// Sign signs msg with the private key k. digest is the name of the
+// hash function applied to msg, such as "SHA256", and must be empty
+// for algorithms that hash the message themselves, or sign it directly,
+// such as Ed25519 and most post-quantum signature schemes.
+func (k *PKey) Sign(msg []byte, digest string) ([]byte, error) {
+	return k.sign(msg, digest, nil)
+}
+
+// sign implements Sign, setting params on the signature operation.
+func (k *PKey) sign(msg []byte, digest string, params *C.OSSL_PARAM) (_ []byte, err error) {
+	if err := checkStrictPKey(k.withKey); err != nil {
+		return nil, err
+	}
+	if digest != "" {
+		if err := k.lib.checkStrictDigest(digest); err != nil {
+			return nil, err
+		}
+	}
+	defer runtime.KeepAlive(k.lib)
+	defer endOp(beginOp("EVP_DigestSign", MetricSign), &err)
+	defer auditKey(AuditSign, k.withKey, &err)
+	pooled, ctx := getMDCtx()
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_MD_CTX_new")
+	}
+	defer pooled.put()
+	cdigest := optCString(digest)
+	defer C.free(unsafe.Pointer(cdigest))
+	var sig []byte
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		var pctx C.GO_EVP_PKEY_CTX_PTR
+		if C.go_openssl_EVP_DigestSignInit_ex(ctx, &pctx, cdigest, k.lib.ptr(), k.lib.propq(), pkey, nil) != 1 {
+			return 0
+		}
+		// params are set after initialization as some algorithms,
+		// such as SM2, reject them until the digest is set up.
+		if params != nil && C.go_openssl_EVP_PKEY_CTX_set_params(pctx, params) != 1 {
+			return 0
+		}
+		var sigLen C.size_t
+		if C.go_openssl_EVP_DigestSign(ctx, nil, &sigLen, base(msg), C.size_t(len(msg))) != 1 {
+			return 0
+		}
+		sig = make([]byte, sigLen)
+		if C.go_openssl_EVP_DigestSign(ctx, base(sig), &sigLen, base(msg), C.size_t(len(msg))) != 1 {
+			return 0
+		}
+		sig = sig[:sigLen]
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("EVP_DigestSign")
+	}
+	return sig, nil
+}
+
+// Verify verifies that sig is a valid signature of msg by k.
+// digest has the same meaning as in Sign.
+func (k *PKey) Verify(msg, sig []byte, digest string) error {
+	return k.verify(msg, sig, digest, nil)
+}
+
+// verify implements Verify, setting params on the verification operation.
+func (k *PKey) verify(msg, sig []byte, digest string, params *C.OSSL_PARAM) (err error) {
+	defer runtime.KeepAlive(k.lib)
+	defer endOp(beginOp("EVP_DigestVerify", MetricVerify), &err)
+	pooled, ctx := getMDCtx()
+	if ctx == nil {
+		return newOpenSSLError("EVP_MD_CTX_new")
+	}
+	defer pooled.put()
+	cdigest := optCString(digest)
+	defer C.free(unsafe.Pointer(cdigest))
+	if k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		var pctx C.GO_EVP_PKEY_CTX_PTR
+		if C.go_openssl_EVP_DigestVerifyInit_ex(ctx, &pctx, cdigest, k.lib.ptr(), k.lib.propq(), pkey, nil) != 1 {
+			return 0
+		}
+		if params != nil && C.go_openssl_EVP_PKEY_CTX_set_params(pctx, params) != 1 {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return newOpenSSLError("EVP_DigestVerifyInit_ex")
+	}
+	if C.go_openssl_EVP_DigestVerify(ctx, base(sig), C.size_t(len(sig)), base(msg), C.size_t(len(msg))) != 1 {
+		// Don't leave the reason for rejecting the signature in the error queue.
+		C.go_openssl_ERR_clear_error()
+		return ErrVerification
+	}
+	return nil
+}
+
+// newPKeyCtx returns an EVP_PKEY_CTX for k in its library context.
+func (k *PKey) newPKeyCtx() (C.GO_EVP_PKEY_CTX_PTR, error) {
+	defer runtime.KeepAlive(k.lib)
+	var ctx C.GO_EVP_PKEY_CTX_PTR
+	k.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		ctx = C.go_openssl_EVP_PKEY_CTX_new_from_pkey(k.lib.ptr(), pkey, k.lib.propq())
+		return 1
+	})
+	if ctx == nil {
+		return nil, newOpenSSLError("EVP_PKEY_CTX_new_from_pkey")
+	}
+	return ctx, nil
+}
+
+// Encapsulate generates a shared secret and encapsulates it for the
+// public key k using a key encapsulation mechanism. It returns the
+// ciphertext to send to the owner of the private key and the secret.
+// params are set on the operation, for example {"operation": "RSASVE"}
+// for RSA, which has no default mode.
+func (k *PKey) Encapsulate(params map[string]interface{}) (ciphertext, secret []byte, err error) {
+	if err := checkStrictPKey(k.withKey); err != nil {
+		return nil, nil, err
+	}
+	ctx, err := k.newPKeyCtx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	if C.go_openssl_EVP_PKEY_encapsulate_init(ctx, nil) != 1 {
+		return nil, nil, newOpenSSLError("EVP_PKEY_encapsulate_init")
+	}
+	if err := setPKeyCtxParams(ctx, params); err != nil {
+		return nil, nil, err
+	}
+	var ctLen, secretLen C.size_t
+	if C.go_openssl_EVP_PKEY_encapsulate(ctx, nil, &ctLen, nil, &secretLen) != 1 {
+		return nil, nil, newOpenSSLError("EVP_PKEY_encapsulate")
+	}
+	ciphertext = make([]byte, ctLen)
+	secret = make([]byte, secretLen)
+	if C.go_openssl_EVP_PKEY_encapsulate(ctx, base(ciphertext), &ctLen, base(secret), &secretLen) != 1 {
+		return nil, nil, newOpenSSLError("EVP_PKEY_encapsulate")
+	}
+	return ciphertext[:ctLen], secret[:secretLen], nil
+}
+
+// Decapsulate recovers the shared secret encapsulated in ciphertext
+// using the private key k. params have the same meaning as in Encapsulate.
+func (k *PKey) Decapsulate(ciphertext []byte, params map[string]interface{}) ([]byte, error) {
+	ctx, err := k.newPKeyCtx()
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	if C.go_openssl_EVP_PKEY_decapsulate_init(ctx, nil) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_decapsulate_init")
+	}
+	if err := setPKeyCtxParams(ctx, params); err != nil {
+		return nil, err
+	}
+	var secretLen C.size_t
+	if C.go_openssl_EVP_PKEY_decapsulate(ctx, nil, &secretLen, base(ciphertext), C.size_t(len(ciphertext))) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_decapsulate")
+	}
+	secret := make([]byte, secretLen)
+	if C.go_openssl_EVP_PKEY_decapsulate(ctx, base(secret), &secretLen, base(ciphertext), C.size_t(len(ciphertext))) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_decapsulate")
+	}
+	return secret[:secretLen], nil
+}
+
+// Encrypt encrypts msg with the public key k. params are set on the
+// operation, for example {"pad-mode": "oaep", "digest": "SHA256"}
+// for RSA-OAEP. Without params, the default padding of the key
+// algorithm is used, such as PKCS #1 v1.5 for RSA.
+func (k *PKey) Encrypt(msg []byte, params map[string]interface{}) ([]byte, error) {
+	if err := checkStrictPKey(k.withKey); err != nil {
+		return nil, err
+	}
+	return k.crypt(msg, params, true)
+}
+
+// Decrypt decrypts ciphertext with the private key k.
+// params have the same meaning as in Encrypt.
+func (k *PKey) Decrypt(ciphertext []byte, params map[string]interface{}) ([]byte, error) {
+	return k.crypt(ciphertext, params, false)
+}
+
+// Derive performs a key agreement between the private key k and
+// the public key peer, which must be of the same algorithm,
+// and returns the shared secret.
+func (k *PKey) Derive(peer *PKey) ([]byte, error) {
+	if err := checkStrictPKey(k.withKey); err != nil {
+		return nil, err
+	}
+	ctx, err := k.newPKeyCtx()
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	if C.go_openssl_EVP_PKEY_derive_init(ctx) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_derive_init")
+	}
+	if peer.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_EVP_PKEY_derive_set_peer(ctx, pkey)
+	}) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_derive_set_peer")
+	}
+	var outLen C.size_t
+	if C.go_openssl_EVP_PKEY_derive(ctx, nil, &outLen) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_derive")
+	}
+	out := make([]byte, outLen)
+	if C.go_openssl_EVP_PKEY_derive(ctx, base(out), &outLen) != 1 {
+		return nil, newOpenSSLError("EVP_PKEY_derive")
+	}
+	return out[:outLen], nil
+}
@@ -0,0 +1,290 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateKeySignVerify(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	msg := []byte("hello world")
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		digest string
+	}{
+		{"RSA", map[string]interface{}{"bits": 2048}, "SHA256"},
+		{"EC", map[string]interface{}{"group": "P-256"}, "SHA256"},
+		{"ED25519", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if FIPS() && tt.name == "ED25519" {
+				t.Skip("Ed25519 is not available in FIPS mode")
+			}
+			k, err := GenerateKey(tt.name, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := k.Algorithm(); got != tt.name {
+				t.Errorf("got algorithm %q, want %q", got, tt.name)
+			}
+			sig, err := k.Sign(msg, tt.digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sig) > k.Size() {
+				t.Errorf("signature of %d bytes is larger than Size %d", len(sig), k.Size())
+			}
+			if err := k.Verify(msg, sig, tt.digest); err != nil {
+				t.Error(err)
+			}
+			if err := k.Verify([]byte("other message"), sig, tt.digest); err == nil {
+				t.Error("verification of a different message succeeded")
+			}
+		})
+	}
+}
+
+func TestGenerateKeyErrors(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	if _, err := GenerateKey("NOT-A-KEY-TYPE", nil); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+	if _, err := GenerateKey("RSA", map[string]interface{}{"unknown": 1}); err == nil {
+		t.Error("expected error for unknown parameter")
+	}
+}
+
+func TestPKeyEncapsulate(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := map[string]interface{}{"operation": "RSASVE"}
+	ct, secret, err := k.Encapsulate(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Decapsulate(ct, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("got secret %x, want %x", got, secret)
+	}
+}
+
+func TestPKeyEncryptDecrypt(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("GenerateKey is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	for _, params := range []map[string]interface{}{
+		nil,
+		{"pad-mode": "oaep", "digest": "SHA256"},
+	} {
+		ct, err := k.Encrypt(msg, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := k.Decrypt(ct, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("%v: got %q, want %q", params, got, msg)
+		}
+	}
+	if _, err := k.Encrypt(msg, map[string]interface{}{"pad-mode": "not-a-mode"}); err == nil {
+		t.Error("expected error for unknown padding mode")
+	}
+}
+
+func TestPKeyRawKeys(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("raw keys are only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("Ed25519 is not available in FIPS mode")
+	}
+	k, err := GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := k.PublicKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := k.PrivateKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pub) != 32 || len(priv) != 32 {
+		t.Fatalf("got %d-byte public and %d-byte private keys, want 32 bytes", len(pub), len(priv))
+	}
+	k2, err := NewPrivatePKey("ED25519", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub2, err := k2.PublicKeyBytes(); err != nil || !bytes.Equal(pub, pub2) {
+		t.Errorf("got public key %x, %v, want %x", pub2, err, pub)
+	}
+	msg := []byte("hello world")
+	sig, err := k2.Sign(msg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := NewPublicPKey("ED25519", pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.Verify(msg, sig, ""); err != nil {
+		t.Error(err)
+	}
+	if _, err := peer.PrivateKeyBytes(); err == nil {
+		t.Error("expected error exporting the private key of a public key")
+	}
+	if _, err := NewPublicPKey("ED25519", pub[:31]); err == nil {
+		t.Error("expected error for truncated public key")
+	}
+}
+
+func TestPKeyDerive(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PKey is only supported on OpenSSL 3")
+	}
+	a, err := GenerateKey("X25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateKey("X25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := a.Derive(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := b.Derive(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ab) != 32 || !bytes.Equal(ab, ba) {
+		t.Errorf("shared secrets differ: %x and %x", ab, ba)
+	}
+	// An all-zero public key is a low-order point.
+	low, err := NewPublicPKey("X25519", make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Derive(low); err == nil {
+		t.Error("expected error for low-order peer key")
+	}
+}
+
+func TestOQSProvider(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("providers are only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := LoadOQSProvider(""); err != nil {
+		t.Skipf("oqs-provider not available: %v", err)
+	}
+	defer UnloadProvider(OQSProvider)
+	k, err := GenerateKey("mldsa65", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	sig, err := k.Sign(msg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(msg, sig, ""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTPM2Provider(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("providers are only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := LoadTPM2Provider(""); err != nil {
+		t.Skipf("tpm2 provider not available: %v", err)
+	}
+	defer UnloadProvider(TPM2Provider)
+	tpm, err := TPM2LibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := tpm.GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+	if err != nil {
+		t.Skipf("no TPM available: %v", err)
+	}
+	msg := []byte("hello world")
+	sig, err := k.Sign(msg, "SHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(msg, sig, "SHA256"); err != nil {
+		t.Error(err)
+	}
+	params := map[string]interface{}{"pad-mode": "oaep"}
+	ct, err := k.Encrypt(msg, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Decrypt(ct, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestPKCS11Provider(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("providers are only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := LoadPKCS11Provider(""); err != nil {
+		t.Skipf("pkcs11-provider not available: %v", err)
+	}
+	defer UnloadProvider(PKCS11Provider)
+	lib, err := PKCS11LibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lib.Properties(); got != "?provider=pkcs11" {
+		t.Errorf("Properties() = %q, want ?provider=pkcs11", got)
+	}
+	if _, err := lib.LoadKey("pkcs11:object=no-such-key;type=private"); err == nil {
+		t.Error("loaded a missing PKCS #11 key")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+)
+
+// MarshalPKIXPublicKey returns the PKIX, ASN.1 DER SubjectPublicKeyInfo
+// form of pub, as understood by crypto/x509.ParsePKIXPublicKey.
+//
+// pub must be a *PublicKeyRSA, *PublicKeyECDSA, *PublicKeyECDH or *PKey,
+// such as an Ed25519 or X25519 key. A *PKey holding a private key, a
+// *PrivateKeyRSA and a *PrivateKeyECDSA are marshaled as their public
+// key, which extracts the public key of hardware keys, such as those of
+// Engine.PrivateKey and LoadKey.
+func MarshalPKIXPublicKey(pub interface{}) ([]byte, error) {
+	withKey := publicKeyWithKey(pub)
+	if withKey == nil {
+		return nil, errors.New("openssl: unsupported public key type")
+	}
+	var der []byte
+	if withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		if vMajor == 1 && vMinor == 0 && C.go_openssl_EVP_PKEY_get_base_id(pkey) == C.GO_EVP_PKEY_EC {
+			// OpenSSL 1.0.2 defaults to explicit curve parameters.
+			key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
+			if key == nil {
+				return 0
+			}
+			C.go_openssl_EC_KEY_set_asn1_flag(key, C.GO_OPENSSL_EC_NAMED_CURVE)
+			C.go_openssl_EC_KEY_free(key)
+		}
+		n := C.go_openssl_i2d_PUBKEY_buf(pkey, nil)
+		if n <= 0 {
+			return 0
+		}
+		der = make([]byte, n)
+		if C.go_openssl_i2d_PUBKEY_buf(pkey, base(der)) != n {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("i2d_PUBKEY")
+	}
+	return der, nil
+}
+
+// publicKeyWithKey returns the withKey method of pub, which must be one
+// of the public key types accepted by MarshalPKIXPublicKey, or nil.
+func publicKeyWithKey(pub interface{}) func(func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+	switch k := pub.(type) {
+	case *PublicKeyRSA:
+		return k.withKey
+	case *PublicKeyECDSA:
+		return k.withKey
+	case *PrivateKeyRSA:
+		return k.withKey
+	case *PrivateKeyECDSA:
+		return k.withKey
+	case *PublicKeyECDH:
+		return k.withKey
+	case *PKey:
+		return k.withKey
+	}
+	return nil
+}
+
+// privateKeyWithKey returns the withKey method of priv, which must be a
+// *PrivateKeyRSA, a *PrivateKeyECDSA or a *PKey, or nil.
+func privateKeyWithKey(priv interface{}) func(func(C.GO_EVP_PKEY_PTR) C.int) C.int {
+	switch k := priv.(type) {
+	case *PrivateKeyRSA:
+		return k.withKey
+	case *PrivateKeyECDSA:
+		return k.withKey
+	case *PKey:
+		return k.withKey
+	}
+	return nil
+}
+
+// ParsePKIXPublicKey parses der, a PKIX, ASN.1 DER SubjectPublicKeyInfo
+// as produced by crypto/x509.MarshalPKIXPublicKey.
+//
+// It returns a *PublicKeyRSA for RSA keys and a *PublicKeyECDSA for EC
+// keys on the curves supported by NewPublicKeyECDSA. On OpenSSL 3,
+// other key types, such as Ed25519 and X25519, are returned as a *PKey.
+func ParsePKIXPublicKey(der []byte) (interface{}, error) {
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty public key")
+	}
+	pkey := C.go_openssl_d2i_PUBKEY_buf(base(der), C.long(len(der)))
+	if pkey == nil {
+		return nil, newOpenSSLError("d2i_PUBKEY")
+	}
+	return publicKeyFromPKey(pkey)
+}
+
+// publicKeyFromPKey wraps the public key pkey as described in
+// ParsePKIXPublicKey. It takes ownership of pkey, freeing it on error.
+func publicKeyFromPKey(pkey C.GO_EVP_PKEY_PTR) (interface{}, error) {
+	switch C.go_openssl_EVP_PKEY_get_base_id(pkey) {
+	case C.GO_EVP_PKEY_RSA:
+		k := &PublicKeyRSA{closer: pkeyHandle(pkey)}
+		manage(k, (*PublicKeyRSA).finalize)
+		return k, nil
+	case C.GO_EVP_PKEY_EC:
+		if err := checkPKeyCurve(pkey); err != nil {
+			C.go_openssl_EVP_PKEY_free(pkey)
+			return nil, err
+		}
+		k := &PublicKeyECDSA{closer: pkeyHandle(pkey)}
+		manage(k, (*PublicKeyECDSA).finalize)
+		return k, nil
+	}
+	if vMajor != 3 {
+		C.go_openssl_EVP_PKEY_free(pkey)
+		return nil, errors.New("openssl: unsupported public key type")
+	}
+	return newPKey(nil, pkey), nil
+}
+
+// privateKeyFromPKey is like publicKeyFromPKey but wraps the private
+// key pkey as a *PrivateKeyRSA, a *PrivateKeyECDSA or a *PKey of lib.
+func privateKeyFromPKey(lib *LibraryContext, pkey C.GO_EVP_PKEY_PTR) (interface{}, error) {
+	switch C.go_openssl_EVP_PKEY_get_base_id(pkey) {
+	case C.GO_EVP_PKEY_RSA:
+		k := &PrivateKeyRSA{closer: pkeyHandle(pkey)}
+		manage(k, (*PrivateKeyRSA).finalize)
+		return k, nil
+	case C.GO_EVP_PKEY_EC:
+		if err := checkPKeyCurve(pkey); err != nil {
+			C.go_openssl_EVP_PKEY_free(pkey)
+			return nil, err
+		}
+		k := &PrivateKeyECDSA{closer: pkeyHandle(pkey)}
+		manage(k, (*PrivateKeyECDSA).finalize)
+		return k, nil
+	}
+	if vMajor != 3 {
+		C.go_openssl_EVP_PKEY_free(pkey)
+		return nil, errors.New("openssl: unsupported private key type")
+	}
+	return newPKey(lib, pkey), nil
+}
+
+// checkPKeyCurve returns an error if the EC key pkey
+// is not on one of the curves supported by curveNID.
+func checkPKeyCurve(pkey C.GO_EVP_PKEY_PTR) error {
+	key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
+	if key == nil {
+		return newOpenSSLError("EVP_PKEY_get1_EC_KEY")
+	}
+	defer C.go_openssl_EC_KEY_free(key)
+	if _, err := curveName(C.go_openssl_EC_GROUP_get_curve_name(C.go_openssl_EC_KEY_get0_group(key))); err != nil {
+		return errUnsupportedCurve
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// testPKIXRoundTrip checks that pub marshals to want,
+// and that want parses back to a key of the same type.
+func testPKIXRoundTrip(t *testing.T, pub interface{}, want []byte) {
+	t.Helper()
+	der, err := openssl.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, want) {
+		t.Errorf("got %x, want %x", der, want)
+	}
+	parsed, err := openssl.ParsePKIXPublicKey(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := typeName(parsed), typeName(pub); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if der, err := openssl.MarshalPKIXPublicKey(parsed); err != nil || !bytes.Equal(der, want) {
+		t.Errorf("parsed key marshals to %x, %v, want %x", der, err, want)
+	}
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case *openssl.PublicKeyRSA:
+		return "*PublicKeyRSA"
+	case *openssl.PublicKeyECDSA:
+		return "*PublicKeyECDSA"
+	case *openssl.PKey:
+		return "*PKey"
+	}
+	return "unknown"
+}
+
+func TestPKIXPublicKeyRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicKeyRSA(bbig.Enc(priv.N), bbig.Enc(big.NewInt(int64(priv.E))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPKIXRoundTrip(t, pub, want)
+	k, err := openssl.NewPrivateKeyRSA(
+		bbig.Enc(priv.N), bbig.Enc(big.NewInt(int64(priv.E))), bbig.Enc(priv.D),
+		bbig.Enc(priv.Primes[0]), bbig.Enc(priv.Primes[1]),
+		bbig.Enc(priv.Precomputed.Dp), bbig.Enc(priv.Precomputed.Dq), bbig.Enc(priv.Precomputed.Qinv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if der, err := openssl.MarshalPKIXPublicKey(k); err != nil || !bytes.Equal(der, want) {
+		t.Errorf("private key marshals to %x, %v, want %x", der, err, want)
+	}
+}
+
+func TestPKIXPublicKeyECDSA(t *testing.T) {
+	testAllCurves(t, func(t *testing.T, c elliptic.Curve) {
+		priv, err := ecdsa.GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := openssl.NewPublicKeyECDSA(c.Params().Name, bbig.Enc(priv.X), bbig.Enc(priv.Y))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testPKIXRoundTrip(t, pub, want)
+	})
+}
+
+func TestPKIXPublicKeyEd25519(t *testing.T) {
+	if !openssl.SupportsEd25519() {
+		t.Skip("Ed25519 is not supported")
+	}
+	pubBytes, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicPKey("ED25519", pubBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := x509.MarshalPKIXPublicKey(pubBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPKIXRoundTrip(t, pub, want)
+}
+
+func TestPKIXPublicKeyX25519(t *testing.T) {
+	if !openssl.SupportsX25519() {
+		t.Skip("X25519 is not supported")
+	}
+	priv, err := openssl.GenerateKey("X25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := priv.PublicKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The SubjectPublicKeyInfo of RFC 8410, Section 4.
+	prefix, _ := hex.DecodeString("302a300506032b656e032100")
+	want := append(prefix, raw...)
+	testPKIXRoundTrip(t, priv, want)
+}
+
+func TestPKIXPublicKeyErrors(t *testing.T) {
+	if _, err := openssl.MarshalPKIXPublicKey("not a key"); err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+	if _, err := openssl.ParsePKIXPublicKey([]byte{0x30, 0x00}); err == nil {
+		t.Error("expected error for invalid encoding")
+	}
+	// A P-256K (secp256k1) key isn't supported by PublicKeyECDSA.
+	der, _ := hex.DecodeString("3056301006072a8648ce3d020106052b8104000a034200" +
+		"0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+		"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+	if _, err := openssl.ParsePKIXPublicKey(der); err == nil {
+		t.Error("expected error for unsupported curve")
+	}
+}
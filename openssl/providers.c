@@ -0,0 +1,21 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// Defined in providers.go.
+extern int goProviderCallback(GO_OSSL_PROVIDER_PTR prov);
+
+static int
+provider_cb(GO_OSSL_PROVIDER_PTR prov, void *cbdata)
+{
+    return goProviderCallback(prov);
+}
+
+int go_openssl_providers_do_all(GO_OSSL_LIB_CTX_PTR ctx)
+{
+    return go_openssl_OSSL_PROVIDER_do_all(ctx, provider_cb, NULL);
+}
@@ -0,0 +1,201 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// ProviderStatus is the status reported by an OpenSSL provider.
+type ProviderStatus int
+
+const (
+	// ProviderStatusUnknown means the provider doesn't report its status.
+	ProviderStatusUnknown ProviderStatus = iota
+	// ProviderStatusOK means the provider is running and,
+	// for the FIPS provider, has passed its self-tests.
+	ProviderStatusOK
+	// ProviderStatusFailed means the provider is in an error state,
+	// for example because a self-test failed, and refuses to operate.
+	ProviderStatusFailed
+)
+
+// ProviderInfo describes a loaded OpenSSL provider.
+type ProviderInfo struct {
+	// Name is the name the provider was loaded with,
+	// such as "default", "fips" or "base".
+	Name string
+	// FullName is the name the provider reports for itself,
+	// such as "OpenSSL Default Provider".
+	FullName  string
+	Version   string
+	BuildInfo string
+	Status    ProviderStatus
+}
+
+var (
+	// providersMu serializes the calls to OSSL_PROVIDER_do_all,
+	// which report the providers through providersList.
+	providersMu   sync.Mutex
+	providersList []ProviderInfo
+)
+
+//export goProviderCallback
+func goProviderCallback(prov C.GO_OSSL_PROVIDER_PTR) C.int {
+	info := ProviderInfo{Name: C.GoString(C.go_openssl_OSSL_PROVIDER_get0_name(prov))}
+	var name, version, buildinfo *C.char
+	if C.go_openssl_provider_info(prov, &name, &version, &buildinfo) == 1 {
+		info.FullName = C.GoString(name)
+		info.Version = C.GoString(version)
+		info.BuildInfo = C.GoString(buildinfo)
+	}
+	switch C.go_openssl_provider_status(prov) {
+	case 1:
+		info.Status = ProviderStatusOK
+	case 0:
+		info.Status = ProviderStatusFailed
+	}
+	providersList = append(providersList, info)
+	return 1
+}
+
+// Providers returns the providers loaded in the default library context,
+// so that it is possible to verify at runtime which implementation
+// is serving cryptographic operations.
+//
+// Providers is only supported on OpenSSL 3.
+func Providers() ([]ProviderInfo, error) {
+	return defaultLibraryContext.Providers()
+}
+
+// Providers returns the providers loaded in the library context.
+// Contexts returned by WithProperties share the providers of their parent.
+//
+// Providers is only supported on OpenSSL 3.
+func (l *LibraryContext) Providers() ([]ProviderInfo, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	defer runtime.KeepAlive(l)
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providersList = nil
+	defer func() { providersList = nil }()
+	if C.go_openssl_providers_do_all(l.ptr()) != 1 {
+		return nil, newOpenSSLError("OSSL_PROVIDER_do_all")
+	}
+	return providersList, nil
+}
+
+var (
+	loadedMu sync.Mutex
+	// loadedProviders holds the providers loaded by LoadProvider, by name,
+	// followed by the default provider if it had to be loaded with them.
+	loadedProviders = make(map[string][]C.GO_OSSL_PROVIDER_PTR)
+)
+
+// LoadProvider loads the provider name into the default library context,
+// if it wasn't already loaded by LoadProvider. name is either the name
+// of a provider module in the OpenSSL modules directory, such as "legacy"
+// or "oqsprovider", or the path of a provider module. The default
+// provider is loaded too if no other provider is active, since loading
+// any provider prevents OpenSSL from loading it implicitly.
+//
+// LoadProvider is only supported on OpenSSL 3.
+func LoadProvider(name string) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	return loadProvider(name)
+}
+
+// loadProvider implements LoadProvider. loadedMu must be held.
+func loadProvider(name string) error {
+	if _, ok := loadedProviders[name]; ok {
+		return nil
+	}
+	var provs []C.GO_OSSL_PROVIDER_PTR
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	prov := C.go_openssl_OSSL_PROVIDER_load(nil, cname)
+	if prov == nil {
+		return newOpenSSLError("openssl: OSSL_PROVIDER_load(" + name + ")")
+	}
+	provs = append(provs, prov)
+	if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameDefault) == 0 &&
+		C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+		prov := C.go_openssl_OSSL_PROVIDER_load(nil, providerNameDefault)
+		if prov == nil {
+			C.go_openssl_OSSL_PROVIDER_unload(provs[0])
+			return newOpenSSLError("openssl: OSSL_PROVIDER_load(default)")
+		}
+		provs = append(provs, prov)
+	}
+	loadedProviders[name] = provs
+	return nil
+}
+
+// UnloadProvider unloads the provider loaded by LoadProvider with the same
+// name. It does nothing if LoadProvider hasn't loaded it.
+// The provider stays available if it was also loaded by other means,
+// such as the OpenSSL configuration.
+//
+// UnloadProvider is only supported on OpenSSL 3.
+func UnloadProvider(name string) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	provs := loadedProviders[name]
+	for i := len(provs) - 1; i >= 0; i-- {
+		if C.go_openssl_OSSL_PROVIDER_unload(provs[i]) != 1 {
+			loadedProviders[name] = provs[:i+1]
+			return newOpenSSLError("openssl: OSSL_PROVIDER_unload")
+		}
+	}
+	delete(loadedProviders, name)
+	return nil
+}
+
+// CipherProvider returns the name of the provider implementing the
+// cipher name, such as "AES-256-GCM", for l and its property query.
+// With an optional property query such as "?provider=qatprovider",
+// it tells whether the preferred provider or a fallback serves name.
+//
+// CipherProvider is only supported on OpenSSL 3.
+func (l *LibraryContext) CipherProvider(name string) (string, error) {
+	cipher, err := l.fetchCipher(name)
+	if err != nil {
+		return "", err
+	}
+	defer C.go_openssl_EVP_CIPHER_free(cipher)
+	return C.GoString(C.go_openssl_OSSL_PROVIDER_get0_name(C.go_openssl_EVP_CIPHER_get0_provider(cipher))), nil
+}
+
+// KeyProvider is like CipherProvider for the keys of the algorithm
+// name, such as "RSA" or "EC". The operations using a key, such as
+// signatures, are preferably served by the provider of the key.
+//
+// KeyProvider is only supported on OpenSSL 3.
+func (l *LibraryContext) KeyProvider(name string) (string, error) {
+	defer runtime.KeepAlive(l)
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	keymgmt := C.go_openssl_EVP_KEYMGMT_fetch(l.ptr(), cname, l.propq())
+	if keymgmt == nil {
+		return "", newNotSupportedError(name, "EVP_KEYMGMT_fetch("+name+")")
+	}
+	defer C.go_openssl_EVP_KEYMGMT_free(keymgmt)
+	return C.GoString(C.go_openssl_OSSL_PROVIDER_get0_name(C.go_openssl_EVP_KEYMGMT_get0_provider(keymgmt))), nil
+}
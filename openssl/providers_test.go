@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "testing"
+
+func TestProviders(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("providers are only supported on OpenSSL 3")
+	}
+	// Make sure a provider is activated.
+	SHA256(nil)
+	provs, err := Providers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) == 0 {
+		t.Fatal("no provider reported")
+	}
+	for _, p := range provs {
+		if p.Name == "" || p.Version == "" {
+			t.Errorf("incomplete provider info: %+v", p)
+		}
+		if p.Status != ProviderStatusOK {
+			t.Errorf("provider %s reports status %v", p.Name, p.Status)
+		}
+	}
+
+	l, err := NewLibraryContext("", "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provs, err = l.Providers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provs) != 1 || provs[0].Name != "base" {
+		t.Errorf("got %+v, want only the base provider", provs)
+	}
+}
+
+func TestLoadProvider(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("providers are only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	if err := LoadProvider("not-a-provider"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if err := LoadProvider("legacy"); err != nil {
+		t.Skipf("legacy provider not available: %v", err)
+	}
+	// Loading a provider must not disable the implicit default provider.
+	SHA256(nil)
+	if err := UnloadProvider("legacy"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadedProviders["legacy"]; ok {
+		t.Error("provider still recorded after UnloadProvider")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// Intel QuickAssist Technology (QAT) accelerators offload RSA, ECDSA,
+// ECDH and AES-GCM, among others, through the QAT_Engine project,
+// https://github.com/intel/QAT_Engine, which builds both an ENGINE,
+// for OpenSSL 1.1 and 3, and a provider for OpenSSL 3.
+//
+// Offloading has pitfalls worth knowing before measuring it:
+//
+//   - A request only pays off once its transfer to the device is small
+//     compared to its computation: RSA private key operations gain the
+//     most, while AES-GCM on small records is usually faster on the CPU
+//     and, with the ENGINE, isn't offloaded below the threshold set by
+//     its SET_CRYPTO_SMALL_PACKET_OFFLOAD_THRESHOLD command.
+//   - Outside of an async job, an offloaded operation blocks its thread
+//     until the device completes it, so the throughput is bounded by the
+//     number of threads. Run signatures through an AsyncQueue to keep
+//     many of them in flight on a single thread.
+//   - An optional property query, such as the one of QATLibraryContext,
+//     and the software fallback of the ENGINE silently serve algorithms
+//     from the CPU when the device doesn't support them or is
+//     unavailable. Check QATProviderOffload, LibraryContext.CipherProvider
+//     or PKey.Provider rather than assuming the device is used.
+//   - Unless built for FIPS, the QAT provider doesn't have the
+//     "fips=yes" property, so the default property set by SetFIPS
+//     excludes it.
+//
+// The Metrics report the duration of the operations, to compare them
+// with and without offload.
+
+// QATProvider is the module name of the QAT provider.
+const QATProvider = "qatprovider"
+
+// QATEngine is the identifier of the QAT ENGINE.
+const QATEngine = "qatengine"
+
+// LoadQATProvider loads the QAT provider into the default library
+// context with LoadProvider. modulePath is the path of the provider
+// module; if empty, it is looked up in the OpenSSL modules directory as
+// QATProvider.
+//
+// Once loaded, QATLibraryContext prefers the QAT implementations:
+//
+//	qat, err := openssl.QATLibraryContext()
+//	k, err := qat.GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+//	sig, err := k.Sign(msg, "SHA256")
+//	block, err := qat.NewAESCipher(key)
+//	aead, err := cipher.NewGCM(block)
+//
+// LoadQATProvider is only supported on OpenSSL 3.
+func LoadQATProvider(modulePath string) error {
+	if modulePath == "" {
+		modulePath = QATProvider
+	}
+	return LoadProvider(modulePath)
+}
+
+// QATLibraryContext returns the default library context with the
+// property query "?provider=qatprovider", which prefers the
+// implementations of the QAT provider loaded by LoadQATProvider and
+// falls back to the other providers for the algorithms QAT doesn't
+// support. The provider must be loaded under the name QATProvider, that
+// is with an empty modulePath or by the OpenSSL configuration. Use
+// WithProperties("provider=qatprovider") to fail instead of falling back.
+//
+// QATLibraryContext is only supported on OpenSSL 3.
+func QATLibraryContext() (*LibraryContext, error) {
+	l, err := DefaultLibraryContext()
+	if err != nil {
+		return nil, err
+	}
+	return l.WithProperties("?provider=" + QATProvider), nil
+}
+
+// QATOffload reports which operations are served by QAT.
+type QATOffload struct {
+	// RSA reports whether RSA keys, and so their signatures
+	// and decryptions, are offloaded.
+	RSA bool
+	// EC reports whether EC keys, and so ECDSA and ECDH, are offloaded.
+	EC bool
+	// AESGCM reports whether AES-128-GCM and AES-256-GCM are offloaded.
+	AESGCM bool
+}
+
+// QATProviderOffload reports which operations QATLibraryContext serves
+// with the QAT provider, rather than with a fallback. All are false if
+// the provider isn't loaded.
+//
+// QATProviderOffload is only supported on OpenSSL 3.
+func QATProviderOffload() (QATOffload, error) {
+	l, err := QATLibraryContext()
+	if err != nil {
+		return QATOffload{}, err
+	}
+	fromQAT := func(prov string, err error) bool {
+		return err == nil && prov == QATProvider
+	}
+	return QATOffload{
+		RSA: fromQAT(l.KeyProvider("RSA")),
+		EC:  fromQAT(l.KeyProvider("EC")),
+		AESGCM: fromQAT(l.CipherProvider("AES-128-GCM")) &&
+			fromQAT(l.CipherProvider("AES-256-GCM")),
+	}, nil
+}
+
+// QATEngineOptions configures the QAT ENGINE loaded by LoadQATEngine.
+type QATEngineOptions struct {
+	// Algorithms is passed to Engine.SetDefault,
+	// "RSA,EC,CIPHERS,PKEY" if empty.
+	Algorithms string
+	// EventDriven enables the event driven polling mode, in which the
+	// jobs of an AsyncQueue paused on the device report the file
+	// descriptors signaling its completion through AsyncOp.WaitFDs.
+	// The ENGINE polls the device from an internal thread otherwise.
+	EventDriven bool
+	// SoftwareFallback lets the ENGINE run the operations on the CPU
+	// when the device fails or is unavailable, instead of failing them.
+	SoftwareFallback bool
+	// Commands are run after the ones implied by the other
+	// options, before the ENGINE is initialized.
+	Commands []EngineCommand
+}
+
+// LoadQATEngine loads and initializes the QAT ENGINE with opts, which
+// can be nil, and makes it the default implementation of the algorithms
+// of opts. The ENGINE is looked up in the OpenSSL engines directory as
+// QATEngine; use LoadEngine with the "dynamic" ENGINE for another path.
+// The ENGINE stays the default implementation once closed.
+func LoadQATEngine(opts *QATEngineOptions) (*Engine, error) {
+	if opts == nil {
+		opts = &QATEngineOptions{}
+	}
+	var cmds []EngineCommand
+	if opts.EventDriven {
+		cmds = append(cmds, EngineCommand{Name: "ENABLE_EVENT_DRIVEN_POLLING_MODE"})
+	}
+	if opts.SoftwareFallback {
+		cmds = append(cmds, EngineCommand{Name: "ENABLE_SW_FALLBACK"})
+	}
+	cmds = append(cmds, opts.Commands...)
+	e, err := LoadEngine(QATEngine, cmds...)
+	if err != nil {
+		return nil, err
+	}
+	algs := opts.Algorithms
+	if algs == "" {
+		algs = "RSA,EC,CIPHERS,PKEY"
+	}
+	if err := e.SetDefault(algs); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestImplementationProviders(t *testing.T) {
+	l, err := openssl.DefaultLibraryContext()
+	if err != nil {
+		t.Skip(err)
+	}
+	want := "default"
+	if openssl.FIPS() {
+		want = "fips"
+	}
+	if prov, err := l.CipherProvider("AES-256-GCM"); err != nil || prov != want {
+		t.Errorf("CipherProvider(AES-256-GCM) = %q, %v, want %q", prov, err, want)
+	}
+	if prov, err := l.KeyProvider("RSA"); err != nil || prov != want {
+		t.Errorf("KeyProvider(RSA) = %q, %v, want %q", prov, err, want)
+	}
+	if _, err := l.CipherProvider("NO-SUCH-CIPHER"); err == nil {
+		t.Error("CipherProvider found a missing cipher")
+	}
+	if _, err := l.KeyProvider("NO-SUCH-KEY"); err == nil {
+		t.Error("KeyProvider found a missing key type")
+	}
+	k, err := openssl.GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	if prov := k.Provider(); prov != want {
+		t.Errorf("Provider() = %q, want %q", prov, want)
+	}
+}
+
+func TestQATProviderOffload(t *testing.T) {
+	if _, err := openssl.DefaultLibraryContext(); err != nil {
+		t.Skip(err)
+	}
+	off, err := openssl.QATProviderOffload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off != (openssl.QATOffload{}) {
+		t.Errorf("QATProviderOffload() = %+v without the QAT provider", off)
+	}
+}
@@ -1,24 +1,180 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 // #include "goopenssl.h"
 import "C"
-import "unsafe"
+import (
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// maxRandBytes is the maximum number of bytes requested
+// in a single RAND_bytes call, which takes an int length.
+const maxRandBytes = 1 << 30
+
+// deterministicRand is non-zero while the SetDeterministicRand
+// of the openssl_randtest build is in effect.
+var deterministicRand int32
 
 type randReader int
 
-func (randReader) Read(b []byte) (int, error) {
-	// Note: RAND_bytes should never fail; the return value exists only for historical reasons.
-	// We check it even so.
-	if len(b) > 0 && C.go_openssl_RAND_bytes((*C.uchar)(unsafe.Pointer(&b[0])), C.int(len(b))) == 0 {
-		return 0, fail("RAND_bytes")
+func (r randReader) Read(b []byte) (int, error) {
+	start := metricsStart()
+	n, err := r.read(b)
+	observe(MetricRand, start, n, err)
+	return n, err
+}
+
+func (randReader) read(b []byte) (int, error) {
+	deterministic := atomic.LoadInt32(&deterministicRand) != 0
+	if vMajor == 3 && !deterministic {
+		if d, _ := drbgPool.Get().(*childDRBG); d != nil {
+			n, err := d.read(b)
+			drbgPool.Put(d)
+			return n, err
+		}
+	}
+	n := len(b)
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > maxRandBytes {
+			chunk = chunk[:maxRandBytes]
+		}
+		if !deterministic && atomic.LoadInt32(&predictionResistance) != 0 {
+			if C.go_openssl_RAND_bytes_pr((*C.uchar)(unsafe.Pointer(&chunk[0])), C.size_t(len(chunk))) != 1 {
+				return 0, newOpenSSLError("EVP_RAND_generate")
+			}
+		} else {
+			// Note: RAND_bytes should never fail; the return value exists only for historical reasons.
+			// We check it even so.
+			if C.go_openssl_RAND_bytes((*C.uchar)(unsafe.Pointer(&chunk[0])), C.int(len(chunk))) == 0 {
+				return 0, fail("RAND_bytes")
+			}
+		}
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+// drbgPool holds child DRBGs seeded from the primary DRBG.
+// Each child is used by a single goroutine at a time and is not locked,
+// and sync.Pool keeps a per-P cache of them, so parallel reads
+// don't contend on the shared DRBG locks.
+// If a child can't be created, RandReader falls back to RAND_bytes.
+var drbgPool = sync.Pool{
+	New: func() interface{} {
+		ctx := C.go_openssl_new_child_drbg()
+		if ctx == nil {
+			C.go_openssl_ERR_clear_error()
+			return nil
+		}
+		d := &childDRBG{ctx: ctx}
+		runtime.SetFinalizer(d, (*childDRBG).finalize)
+		return d
+	},
+}
+
+// childDRBG is an unlocked EVP_RAND context whose parent
+// is the primary DRBG of the default library context.
+type childDRBG struct {
+	ctx C.GO_EVP_RAND_CTX_PTR
+}
+
+func (d *childDRBG) finalize() {
+	C.go_openssl_EVP_RAND_CTX_free(d.ctx)
+}
+
+func (d *childDRBG) read(b []byte) (int, error) {
+	defer runtime.KeepAlive(d)
+	if len(b) == 0 {
+		return 0, nil
+	}
+	pr := C.int(atomic.LoadInt32(&predictionResistance))
+	// EVP_RAND_generate splits the request into chunks
+	// no larger than the DRBG maximum request size.
+	if C.go_openssl_EVP_RAND_generate(d.ctx, (*C.uchar)(unsafe.Pointer(&b[0])), C.size_t(len(b)), 0, pr, nil, 0) != 1 {
+		return 0, newOpenSSLError("EVP_RAND_generate")
 	}
 	return len(b), nil
 }
 
+// RandReader is a global, shared instance of a cryptographically
+// secure random number generator backed by the OpenSSL DRBG.
+// On OpenSSL 3 it reads from a pool of child DRBGs seeded from the
+// primary DRBG, so it scales with parallel use; on older versions
+// it uses RAND_bytes.
+// It can be used wherever crypto/rand.Reader is accepted.
 const RandReader = randReader(0)
+
+var _ io.Reader = RandReader
+
+// Read fills b with cryptographically secure random bytes
+// drawn from RandReader. It returns the number of bytes read
+// and an error if and only if n < len(b).
+func Read(b []byte) (n int, err error) {
+	return io.ReadFull(RandReader, b)
+}
+
+// AddEntropy mixes seed into the OpenSSL random number generator state.
+// entropyEstimate is the number of bytes of entropy the caller
+// believes seed contains, and must be between 0 and len(seed).
+// It is intended for systems with an external hardware entropy source
+// that want to feed it into OpenSSL, typically right after Init.
+//
+// Seed material is mixed in by reseeding the primary DRBG;
+// it never replaces the operating system entropy source.
+func AddEntropy(seed []byte, entropyEstimate float64) error {
+	if !(entropyEstimate >= 0 && entropyEstimate <= float64(len(seed))) {
+		return errors.New("openssl: entropy estimate out of range")
+	}
+	for len(seed) > 0 {
+		chunk := seed
+		if len(chunk) > maxRandBytes {
+			chunk = chunk[:maxRandBytes]
+		}
+		estimate := entropyEstimate * float64(len(chunk)) / float64(len(seed))
+		C.go_openssl_RAND_add(unsafe.Pointer(&chunk[0]), C.int(len(chunk)), C.double(estimate))
+		entropyEstimate -= estimate
+		seed = seed[len(chunk):]
+	}
+	return nil
+}
+
+// predictionResistance is non-zero when RandReader
+// requests prediction resistance on every read.
+var predictionResistance int32
+
+// SetPredictionResistance enables or disables prediction resistance
+// for RandReader and Read. When enabled, the DRBG is reseeded from
+// its entropy source before every generate request, which is
+// considerably slower but required by some high-assurance policies.
+// Random numbers generated internally by OpenSSL, for example
+// during key generation, are not affected.
+//
+// Prediction resistance is only supported on OpenSSL 3.
+func SetPredictionResistance(enabled bool) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&predictionResistance, v)
+	return nil
+}
+
+// PredictionResistance reports whether prediction resistance
+// is enabled for RandReader.
+func PredictionResistance() bool {
+	return atomic.LoadInt32(&predictionResistance) != 0
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build openssl_randtest && (linux || darwin || windows)
+// +build openssl_randtest
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+#include <pthread.h>
+#include <string.h>
+
+// go_rand_method mirrors the layout of RAND_METHOD,
+// which hasn't changed since OpenSSL 1.0.2.
+struct go_rand_method {
+    int (*seed)(const void *buf, int num);
+    int (*bytes)(unsigned char *buf, int num);
+    void (*cleanup)(void);
+    int (*add)(const void *buf, int num, double randomness);
+    int (*pseudorand)(unsigned char *buf, int num);
+    int (*status)(void);
+};
+
+// The deterministic generator outputs the concatenation of
+// SHA-256(seed || counter) for counter = 0, 1, 2...
+// with counter encoded as a big-endian 64-bit integer.
+static pthread_mutex_t det_mu = PTHREAD_MUTEX_INITIALIZER;
+static unsigned char *det_input = NULL; // seed || counter
+static size_t det_seed_len = 0;
+static uint64_t det_counter = 0;
+static unsigned char det_block[32];
+static size_t det_block_pos = sizeof(det_block);
+
+static int det_next_block(void)
+{
+    for (int i = 0; i < 8; i++)
+        det_input[det_seed_len + i] = (unsigned char)(det_counter >> (56 - 8 * i));
+    det_counter++;
+    det_block_pos = 0;
+    return go_shaX(go_openssl_EVP_sha256(), det_input, det_seed_len + 8, det_block);
+}
+
+static int det_bytes(unsigned char *buf, int num)
+{
+    int ret = 1;
+    pthread_mutex_lock(&det_mu);
+    while (num > 0)
+    {
+        if (det_block_pos == sizeof(det_block) && !det_next_block())
+        {
+            ret = 0;
+            break;
+        }
+        size_t n = sizeof(det_block) - det_block_pos;
+        if (n > (size_t)num)
+            n = (size_t)num;
+        memcpy(buf, det_block + det_block_pos, n);
+        det_block_pos += n;
+        buf += n;
+        num -= (int)n;
+    }
+    pthread_mutex_unlock(&det_mu);
+    return ret;
+}
+
+static int det_seed(const void *buf, int num)
+{
+    return 1;
+}
+
+static int det_add(const void *buf, int num, double randomness)
+{
+    return 1;
+}
+
+static int det_status(void)
+{
+    return 1;
+}
+
+static const struct go_rand_method det_method = {
+    det_seed,
+    det_bytes,
+    NULL,
+    det_add,
+    det_bytes,
+    det_status,
+};
+
+int go_openssl_set_deterministic_rand(const unsigned char *seed, size_t seed_len)
+{
+    unsigned char *input = malloc(seed_len + 8);
+    if (input == NULL)
+        return 0;
+    if (seed_len > 0)
+        memcpy(input, seed, seed_len);
+    pthread_mutex_lock(&det_mu);
+    free(det_input);
+    det_input = input;
+    det_seed_len = seed_len;
+    det_counter = 0;
+    det_block_pos = sizeof(det_block);
+    pthread_mutex_unlock(&det_mu);
+    return go_openssl_RAND_set_rand_method((GO_RAND_METHOD_PTR)&det_method);
+}
+
+int go_openssl_clear_deterministic_rand(void)
+{
+    // Passing NULL reinstates the default OpenSSL method.
+    return go_openssl_RAND_set_rand_method(NULL);
+}
@@ -0,0 +1,239 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build openssl_randtest && (linux || darwin || windows)
+// +build openssl_randtest
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// The deterministic random number generators of this file make key
+// generation and signatures reproducible in tests. Their output is
+// entirely predictable from their seed, so they are only built with the
+// openssl_randtest build tag, which must never be set in production.
+//
+// The deterministic stream is the concatenation of SHA-256(seed || counter)
+// for counter = 0, 1, 2... with counter encoded as a big-endian 64-bit
+// integer.
+
+var deterministicRandMu sync.Mutex
+
+// SetDeterministicRand replaces the OpenSSL random number generator,
+// including the one used by RandReader, key generation and signing,
+// with a generator outputting the deterministic stream of seed.
+// Two processes using the same seed observe the same random stream.
+//
+// The returned function restores the default generator.
+// The switch is process wide, so tests using it must not run in parallel
+// with other code that relies on random numbers.
+//
+// SetDeterministicRand is only supported on OpenSSL 1, it relies on the
+// deprecated RAND_METHOD. Use NewDeterministicRand on OpenSSL 3.
+func SetDeterministicRand(seed []byte) (restore func(), err error) {
+	if vMajor != 1 {
+		return nil, errors.New("openssl: SetDeterministicRand is only supported on OpenSSL 1, use NewDeterministicRand")
+	}
+	deterministicRandMu.Lock()
+	defer deterministicRandMu.Unlock()
+	if C.go_openssl_set_deterministic_rand(base(seed), C.size_t(len(seed))) != 1 {
+		return nil, newOpenSSLError("RAND_set_rand_method")
+	}
+	atomic.StoreInt32(&deterministicRand, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			deterministicRandMu.Lock()
+			defer deterministicRandMu.Unlock()
+			atomic.StoreInt32(&deterministicRand, 0)
+			C.go_openssl_clear_deterministic_rand()
+		})
+	}, nil
+}
+
+// deterministicRandSize is the number of bytes each generator
+// of a DeterministicRand outputs before failing.
+const deterministicRandSize = 1 << 20
+
+// testRandStrength is the security strength the TEST-RAND generators
+// claim, so that they serve all the requests.
+const testRandStrength = 256
+
+var testRandName = C.CString("TEST-RAND")
+
+var errDeterministicRandClosed = errors.New("openssl: DeterministicRand closed")
+
+// DeterministicRand is a library context whose public and private
+// random number generators are TEST-RAND EVP_RAND instances, which
+// output the deterministic stream of a seed rather than random numbers.
+// The default library context, and so RandReader and the package-level
+// functions, are not affected.
+//
+// The generators of a library context belong to a thread, so the ones
+// of a DeterministicRand are only set up on a thread dedicated to it,
+// and its library context must only be used from Do. Operations run on
+// other threads fail to generate random numbers. While a DeterministicRand
+// is open, the long operations of this package, see
+// InitOptions.LongOperationWorkers, run on the calling goroutine.
+//
+// DeterministicRand is only built with the openssl_randtest build tag.
+type DeterministicRand struct {
+	lib  *LibraryContext
+	jobs chan func()
+	// done is closed once the goroutine running the jobs exits.
+	done chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewDeterministicRand returns a DeterministicRand whose public generator
+// outputs the first deterministicRandSize bytes, 1 MiB, of the
+// deterministic stream of seed, and whose private generator outputs the
+// next ones. The generators fail once they output all their bytes.
+// The DeterministicRand must be closed with Close.
+//
+// NewDeterministicRand is only supported on OpenSSL 3.
+func NewDeterministicRand(seed []byte) (*DeterministicRand, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	d := &DeterministicRand{
+		jobs: make(chan func()),
+		done: make(chan struct{}),
+	}
+	errc := make(chan error)
+	go d.run(seed, errc)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&longOps.inline, 1)
+	return d, nil
+}
+
+func (d *DeterministicRand) run(seed []byte, errc chan<- error) {
+	// The thread owns the generators, and exits with the goroutine.
+	runtime.LockOSThread()
+	defer close(d.done)
+	lib, err := newDeterministicLibraryContext(seed)
+	if err != nil {
+		errc <- err
+		return
+	}
+	d.lib = lib
+	errc <- nil
+	for f := range d.jobs {
+		f()
+	}
+	// Freeing the context on its thread also frees the generators.
+	lib.Close()
+}
+
+func newDeterministicLibraryContext(seed []byte) (*LibraryContext, error) {
+	lib, err := NewLibraryContext("")
+	if err != nil {
+		return nil, err
+	}
+	if C.go_openssl_RAND_set_DRBG_type(lib.ctx, testRandName, nil, nil, nil) != 1 {
+		lib.Close()
+		return nil, newOpenSSLError("RAND_set_DRBG_type")
+	}
+	stream := deterministicStream(seed, 2*deterministicRandSize)
+	gens := [...]C.GO_EVP_RAND_CTX_PTR{
+		C.go_openssl_RAND_get0_public(lib.ctx),
+		C.go_openssl_RAND_get0_private(lib.ctx),
+	}
+	for i, gen := range gens {
+		if gen == nil {
+			lib.Close()
+			return nil, newOpenSSLError("RAND_get0_public and RAND_get0_private")
+		}
+		if err := setTestRandEntropy(gen, stream[i*deterministicRandSize:(i+1)*deterministicRandSize]); err != nil {
+			lib.Close()
+			return nil, err
+		}
+	}
+	return lib, nil
+}
+
+func setTestRandEntropy(gen C.GO_EVP_RAND_CTX_PTR, entropy []byte) error {
+	bld, err := newParamBuilder()
+	if err != nil {
+		return err
+	}
+	defer bld.free()
+	bld.addOctetString("test_entropy", entropy)
+	bld.addUint64("strength", testRandStrength)
+	params, err := bld.build()
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	if C.go_openssl_EVP_RAND_CTX_set_params(gen, params) != 1 {
+		return newOpenSSLError("EVP_RAND_CTX_set_params")
+	}
+	return nil
+}
+
+// deterministicStream returns the first n bytes of the deterministic stream of seed.
+func deterministicStream(seed []byte, n int) []byte {
+	out := make([]byte, 0, n+32)
+	in := make([]byte, len(seed)+8)
+	copy(in, seed)
+	for counter := uint64(0); len(out) < n; counter++ {
+		binary.BigEndian.PutUint64(in[len(seed):], counter)
+		sum := SHA256(in)
+		out = append(out, sum[:]...)
+	}
+	return out[:n]
+}
+
+// Do runs f with the library context of d on the thread of d, and
+// returns once f returns. A panic of f is raised again by Do. f runs on
+// another goroutine, so it must not call testing.T.FailNow nor Do, and
+// the calls of Do from several goroutines run one after the other.
+// The outputs of the generators only depend on the seed and on the
+// operations run, so a sequence of operations run in Do is reproducible.
+func (d *DeterministicRand) Do(f func(l *LibraryContext)) {
+	var panicked interface{}
+	done := make(chan struct{})
+	job := func() {
+		defer close(done)
+		defer func() { panicked = recover() }()
+		f(d.lib)
+	}
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		panic(errDeterministicRandClosed)
+	}
+	d.jobs <- job
+	d.mu.Unlock()
+	<-done
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// Close frees the library context of d, and waits for
+// the call of Do in progress, if any, to return.
+func (d *DeterministicRand) Close() error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.jobs)
+		atomic.AddInt32(&longOps.inline, -1)
+	}
+	d.mu.Unlock()
+	<-d.done
+	return nil
+}
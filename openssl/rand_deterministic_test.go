@@ -0,0 +1,168 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build openssl_randtest && (linux || darwin || windows)
+// +build openssl_randtest
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestSetDeterministicRand(t *testing.T) {
+	seed := []byte("deterministic seed")
+	if vMajor != 1 {
+		if _, err := SetDeterministicRand(seed); err == nil {
+			t.Fatal("SetDeterministicRand succeeded, want an error on OpenSSL 3")
+		}
+		return
+	}
+	read := func() ([]byte, BigInt) {
+		restore, err := SetDeterministicRand(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer restore()
+		b := make([]byte, 40)
+		if _, err := Read(b); err != nil {
+			t.Fatal(err)
+		}
+		_, _, d, err := GenerateKeyECDSA("P-256")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b, d
+	}
+	b1, d1 := read()
+	b2, d2 := read()
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("random streams differ: %x, %x", b1, b2)
+	}
+	if !bigIntEqual(d1, d2) {
+		t.Error("generated keys differ")
+	}
+	want := sha256.Sum256(append(append([]byte{}, seed...), 0, 0, 0, 0, 0, 0, 0, 0))
+	if !bytes.Equal(b1[:32], want[:]) {
+		t.Errorf("got %x, want %x", b1[:32], want)
+	}
+	b3 := make([]byte, 40)
+	if _, err := Read(b3); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(b1, b3) {
+		t.Error("default generator not restored")
+	}
+}
+
+func bigIntEqual(a, b BigInt) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// deterministicOutputs returns the private key bytes of an Ed25519 key,
+// and the ECDSA and RSA signatures of new keys, all generated with
+// the library context of a new DeterministicRand of seed.
+func deterministicOutputs(t *testing.T, seed []byte) [][]byte {
+	d, err := NewDeterministicRand(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	var outs [][]byte
+	d.Do(func(l *LibraryContext) {
+		ed, err := l.GenerateKey("ED25519", nil)
+		if err != nil {
+			panic(err)
+		}
+		defer ed.Close()
+		priv, err := ed.PrivateKeyBytes()
+		if err != nil {
+			panic(err)
+		}
+		ec, err := l.GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+		if err != nil {
+			panic(err)
+		}
+		defer ec.Close()
+		sig, err := ec.Sign([]byte("message"), "SHA256")
+		if err != nil {
+			panic(err)
+		}
+		// RSA key generation is a long operation,
+		// which must run on the thread of d.
+		rsa, err := l.GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+		if err != nil {
+			panic(err)
+		}
+		defer rsa.Close()
+		// PKCS #1 v1.5 signatures only depend on the key.
+		rsaSig, err := rsa.Sign([]byte("message"), "SHA256")
+		if err != nil {
+			panic(err)
+		}
+		outs = [][]byte{priv, sig, rsaSig}
+	})
+	return outs
+}
+
+func TestNewDeterministicRand(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("NewDeterministicRand is only supported on OpenSSL 3")
+	}
+	seed := []byte("deterministic seed")
+	outs1 := deterministicOutputs(t, seed)
+	outs2 := deterministicOutputs(t, seed)
+	outs3 := deterministicOutputs(t, []byte("another seed"))
+	for i := range outs1 {
+		if !bytes.Equal(outs1[i], outs2[i]) {
+			t.Errorf("output %d differs with the same seed: %x, %x", i, outs1[i], outs2[i])
+		}
+		if bytes.Equal(outs1[i], outs3[i]) {
+			t.Errorf("output %d is the same with another seed", i)
+		}
+	}
+
+	// The default library context is not affected.
+	k1, err := GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k1.Close()
+	priv, err := k1.PrivateKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(priv, outs1[0]) {
+		t.Error("default library context uses the deterministic generator")
+	}
+}
+
+func TestDeterministicRandClosed(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("NewDeterministicRand is only supported on OpenSSL 3")
+	}
+	d, err := NewDeterministicRand([]byte("seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	// Close is idempotent.
+	d.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("Do after Close didn't panic")
+		}
+	}()
+	d.Do(func(*LibraryContext) {})
+}
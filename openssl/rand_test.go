@@ -1,12 +1,14 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
 import (
+	"bytes"
+	"sync"
 	"testing"
 )
 
@@ -16,3 +18,99 @@ func TestRand(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRandRead(t *testing.T) {
+	b := make([]byte, 64)
+	n, err := Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(b) {
+		t.Fatalf("got %d bytes, want %d", n, len(b))
+	}
+	if bytes.Equal(b, make([]byte, len(b))) {
+		t.Error("Read returned all zeros")
+	}
+	if n, err := Read(nil); n != 0 || err != nil {
+		t.Errorf("Read(nil) = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestRandPredictionResistance(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("prediction resistance is only supported on OpenSSL 3")
+	}
+	if err := SetPredictionResistance(true); err != nil {
+		t.Fatal(err)
+	}
+	defer SetPredictionResistance(false)
+	if !PredictionResistance() {
+		t.Fatal("prediction resistance not enabled")
+	}
+	b1, b2 := make([]byte, 100000), make([]byte, 100000)
+	if _, err := Read(b1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(b2); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(b1, b2) {
+		t.Error("two reads returned the same bytes")
+	}
+}
+
+func TestAddEntropy(t *testing.T) {
+	seed := make([]byte, 64)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	if err := AddEntropy(seed, 32); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddEntropy(nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []float64{-1, 65} {
+		if err := AddEntropy(seed, e); err == nil {
+			t.Errorf("expected error for entropy estimate %v", e)
+		}
+	}
+}
+
+func TestRandParallel(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := make([]byte, 1024)
+			for j := 0; j < 100; j++ {
+				if _, err := Read(b); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkRandReadParallel(b *testing.B) {
+	b.SetBytes(32)
+	b.RunParallel(func(pb *testing.PB) {
+		buf := make([]byte, 32)
+		for pb.Next() {
+			if _, err := Read(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
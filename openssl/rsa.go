@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -13,7 +13,6 @@ import (
 	"crypto/subtle"
 	"errors"
 	"hash"
-	"runtime"
 	"unsafe"
 )
 
@@ -26,6 +25,9 @@ func GenerateKeyRSA(bits int) (N, E, D, P, Q, Dp, Dq, Qinv BigInt, err error) {
 		return bad(err)
 	}
 	defer C.go_openssl_EVP_PKEY_free(pkey)
+	if err := pairwiseConsistencyTest(C.GO_EVP_PKEY_RSA, pkey); err != nil {
+		return bad(err)
+	}
 	key := C.go_openssl_EVP_PKEY_get1_RSA(pkey)
 	if key == nil {
 		return bad(newOpenSSLError("EVP_PKEY_get1_RSA failed"))
@@ -38,8 +40,8 @@ func GenerateKeyRSA(bits int) (N, E, D, P, Q, Dp, Dq, Qinv BigInt, err error) {
 }
 
 type PublicKeyRSA struct {
-	// _pkey MUST NOT be accessed directly. Instead, use the withKey method.
-	_pkey C.GO_EVP_PKEY_PTR
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
 }
 
 func NewPublicKeyRSA(N, E BigInt) (*PublicKeyRSA, error) {
@@ -60,26 +62,30 @@ func NewPublicKeyRSA(N, E BigInt) (*PublicKeyRSA, error) {
 		C.go_openssl_EVP_PKEY_free(pkey)
 		return nil, newOpenSSLError("EVP_PKEY_assign failed")
 	}
-	k := &PublicKeyRSA{_pkey: pkey}
-	runtime.SetFinalizer(k, (*PublicKeyRSA).finalize)
+	k := &PublicKeyRSA{closer: pkeyHandle(pkey)}
+	manage(k, (*PublicKeyRSA).finalize)
 	return k, nil
 }
 
 func (k *PublicKeyRSA) finalize() {
-	C.go_openssl_EVP_PKEY_free(k._pkey)
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PublicKeyRSA) Close() error {
+	return closeObject(k, k.finalize)
 }
 
 func (k *PublicKeyRSA) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
-	// Because of the finalizer, any time _pkey is passed to cgo, that call must
-	// be followed by a call to runtime.KeepAlive, to make sure k is not
-	// collected (and finalized) before the cgo call returns.
-	defer runtime.KeepAlive(k)
-	return f(k._pkey)
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
 }
 
 type PrivateKeyRSA struct {
-	// _pkey MUST NOT be accessed directly. Instead, use the withKey method.
-	_pkey C.GO_EVP_PKEY_PTR
+	// closer owns the EVP_PKEY of the key, use it with the withKey method.
+	closer
 }
 
 func NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv BigInt) (*PrivateKeyRSA, error) {
@@ -110,21 +116,26 @@ func NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv BigInt) (*PrivateKeyRSA, error
 		C.go_openssl_EVP_PKEY_free(pkey)
 		return nil, newOpenSSLError("EVP_PKEY_assign failed")
 	}
-	k := &PrivateKeyRSA{_pkey: pkey}
-	runtime.SetFinalizer(k, (*PrivateKeyRSA).finalize)
+	auditPKey(AuditImport, pkey)
+	k := &PrivateKeyRSA{closer: pkeyHandle(pkey)}
+	manage(k, (*PrivateKeyRSA).finalize)
 	return k, nil
 }
 
 func (k *PrivateKeyRSA) finalize() {
-	C.go_openssl_EVP_PKEY_free(k._pkey)
+	k.freeHandle()
+}
+
+// Close frees the native resources of k, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (k *PrivateKeyRSA) Close() error {
+	return closeObject(k, k.finalize)
 }
 
 func (k *PrivateKeyRSA) withKey(f func(C.GO_EVP_PKEY_PTR) C.int) C.int {
-	// Because of the finalizer, any time _pkey is passed to cgo, that call must
-	// be followed by a call to runtime.KeepAlive, to make sure k is not
-	// collected (and finalized) before the cgo call returns.
-	defer runtime.KeepAlive(k)
-	return f(k._pkey)
+	pkey := C.GO_EVP_PKEY_PTR(k.pin())
+	defer k.unpin()
+	return f(pkey)
 }
 
 func DecryptRSAOAEP(h hash.Hash, priv *PrivateKeyRSA, ciphertext, label []byte) ([]byte, error) {
@@ -161,9 +172,8 @@ func DecryptRSANoPadding(priv *PrivateKeyRSA, ciphertext []byte) ([]byte, error)
 	//
 	// The following code tries to replicate the verification implemented in the upstream function decryptAndCheck, found at
 	// https://github.com/golang/go/blob/9de1ac6ac2cad3871760d0aa288f5ca713afd0a6/src/crypto/rsa/rsa.go#L569-L582.
-	pub := &PublicKeyRSA{_pkey: priv._pkey}
 	// A private EVP_PKEY can be used as a public key as it contains the public information.
-	enc, err := EncryptRSANoPadding(pub, ret)
+	enc, err := evpEncrypt(priv.withKey, C.GO_RSA_NO_PADDING, nil, nil, nil, ret)
 	if err != nil {
 		return nil, err
 	}
@@ -236,6 +246,40 @@ func VerifyRSAPKCS1v15(pub *PublicKeyRSA, h crypto.Hash, hashed, sig []byte) err
 	return evpVerify(pub.withKey, C.GO_RSA_PKCS1_PADDING, 0, h, sig, hashed)
 }
 
+// SignMessageRSAPKCS1v15 is like SignRSAPKCS1v15 but signs msg itself,
+// hashing it with h, in a single call to OpenSSL rather than one to hash
+// msg and another to sign the digest. It is meant for small messages,
+// such as the signing input of a JWT.
+func SignMessageRSAPKCS1v15(priv *PrivateKeyRSA, h crypto.Hash, msg []byte) ([]byte, error) {
+	return evpDigestSign(priv.withKey, C.GO_RSA_PKCS1_PADDING, 0, h, msg)
+}
+
+// VerifyMessageRSAPKCS1v15 verifies a signature of msg returned by
+// SignMessageRSAPKCS1v15, or by SignRSAPKCS1v15 for the digest of msg.
+func VerifyMessageRSAPKCS1v15(pub *PublicKeyRSA, h crypto.Hash, msg, sig []byte) error {
+	return evpDigestVerify(pub.withKey, C.GO_RSA_PKCS1_PADDING, 0, h, msg, sig)
+}
+
+// SignMessageRSAPSS is like SignRSAPSS but signs msg itself,
+// as described in SignMessageRSAPKCS1v15.
+func SignMessageRSAPSS(priv *PrivateKeyRSA, h crypto.Hash, msg []byte, saltLen int) ([]byte, error) {
+	cSaltLen, err := saltLength(saltLen, true)
+	if err != nil {
+		return nil, err
+	}
+	return evpDigestSign(priv.withKey, C.GO_RSA_PKCS1_PSS_PADDING, cSaltLen, h, msg)
+}
+
+// VerifyMessageRSAPSS verifies a signature of msg returned by
+// SignMessageRSAPSS, or by SignRSAPSS for the digest of msg.
+func VerifyMessageRSAPSS(pub *PublicKeyRSA, h crypto.Hash, msg, sig []byte, saltLen int) error {
+	cSaltLen, err := saltLength(saltLen, false)
+	if err != nil {
+		return err
+	}
+	return evpDigestVerify(pub.withKey, C.GO_RSA_PKCS1_PSS_PADDING, cSaltLen, h, msg, sig)
+}
+
 // rsa_st_1_0_2 is rsa_st memory layout in OpenSSL 1.0.2.
 type rsa_st_1_0_2 struct {
 	_                C.int
@@ -270,7 +314,7 @@ func rsaSetKey(key C.GO_RSA_PTR, n, e, d BigInt) bool {
 		bnSet(&r.d, d)
 		return true
 	}
-	return C.go_openssl_RSA_set0_key(key, bigToBN(n), bigToBN(e), bigToBN(d)) == 1
+	return C.go_openssl_RSA_set0_key(key, bigToBN(n), bigToBN(e), secretBigToBN(d)) == 1
 }
 
 func rsaSetFactors(key C.GO_RSA_PTR, p, q BigInt) bool {
@@ -284,7 +328,7 @@ func rsaSetFactors(key C.GO_RSA_PTR, p, q BigInt) bool {
 		bnSet(&r.q, q)
 		return true
 	}
-	return C.go_openssl_RSA_set0_factors(key, bigToBN(p), bigToBN(q)) == 1
+	return C.go_openssl_RSA_set0_factors(key, secretBigToBN(p), secretBigToBN(q)) == 1
 }
 
 func rsaSetCRTParams(key C.GO_RSA_PTR, dmp1, dmq1, iqmp BigInt) bool {
@@ -300,38 +344,49 @@ func rsaSetCRTParams(key C.GO_RSA_PTR, dmp1, dmq1, iqmp BigInt) bool {
 		bnSet(&r.iqmp, iqmp)
 		return true
 	}
-	return C.go_openssl_RSA_set0_crt_params(key, bigToBN(dmp1), bigToBN(dmq1), bigToBN(iqmp)) == 1
+	return C.go_openssl_RSA_set0_crt_params(key, secretBigToBN(dmp1), secretBigToBN(dmq1), secretBigToBN(iqmp)) == 1
 }
 
 func rsaGetKey(key C.GO_RSA_PTR) (BigInt, BigInt, BigInt) {
-	var n, e, d C.GO_BIGNUM_PTR
+	n, e, d := rsaGet0Key(key)
+	return bnToBig(n), bnToBig(e), bnToBig(d)
+}
+
+func rsaGetFactors(key C.GO_RSA_PTR) (BigInt, BigInt) {
+	p, q := rsaGet0Factors(key)
+	return bnToBig(p), bnToBig(q)
+}
+
+func rsaGetCRTParams(key C.GO_RSA_PTR) (BigInt, BigInt, BigInt) {
+	dmp1, dmq1, iqmp := rsaGet0CRTParams(key)
+	return bnToBig(dmp1), bnToBig(dmq1), bnToBig(iqmp)
+}
+
+// rsaGet0Key, rsaGet0Factors and rsaGet0CRTParams return the
+// components of key, which owns them, or nil for missing ones.
+func rsaGet0Key(key C.GO_RSA_PTR) (n, e, d C.GO_BIGNUM_PTR) {
 	if vMajor == 1 && vMinor == 0 {
 		r := (*rsa_st_1_0_2)(unsafe.Pointer(key))
-		n, e, d = r.n, r.e, r.d
-	} else {
-		C.go_openssl_RSA_get0_key(key, &n, &e, &d)
+		return r.n, r.e, r.d
 	}
-	return bnToBig(n), bnToBig(e), bnToBig(d)
+	C.go_openssl_RSA_get0_key(key, &n, &e, &d)
+	return n, e, d
 }
 
-func rsaGetFactors(key C.GO_RSA_PTR) (BigInt, BigInt) {
-	var p, q C.GO_BIGNUM_PTR
+func rsaGet0Factors(key C.GO_RSA_PTR) (p, q C.GO_BIGNUM_PTR) {
 	if vMajor == 1 && vMinor == 0 {
 		r := (*rsa_st_1_0_2)(unsafe.Pointer(key))
-		p, q = r.p, r.q
-	} else {
-		C.go_openssl_RSA_get0_factors(key, &p, &q)
+		return r.p, r.q
 	}
-	return bnToBig(p), bnToBig(q)
+	C.go_openssl_RSA_get0_factors(key, &p, &q)
+	return p, q
 }
 
-func rsaGetCRTParams(key C.GO_RSA_PTR) (BigInt, BigInt, BigInt) {
-	var dmp1, dmq1, iqmp C.GO_BIGNUM_PTR
+func rsaGet0CRTParams(key C.GO_RSA_PTR) (dmp1, dmq1, iqmp C.GO_BIGNUM_PTR) {
 	if vMajor == 1 && vMinor == 0 {
 		r := (*rsa_st_1_0_2)(unsafe.Pointer(key))
-		dmp1, dmq1, iqmp = r.dmp1, r.dmq1, r.iqmp
-	} else {
-		C.go_openssl_RSA_get0_crt_params(key, &dmp1, &dmq1, &iqmp)
+		return r.dmp1, r.dmq1, r.iqmp
 	}
-	return bnToBig(dmp1), bnToBig(dmq1), bnToBig(iqmp)
+	C.go_openssl_RSA_get0_crt_params(key, &dmp1, &dmq1, &iqmp)
+	return dmp1, dmq1, iqmp
 }
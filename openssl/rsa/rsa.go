@@ -0,0 +1,432 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+// Package rsa mirrors the API of crypto/rsa with the RSA implementation
+// of OpenSSL, so that applications can move to it by changing their
+// import path. Keys are the same structs as in crypto/rsa, limited to
+// two primes, and are converted to OpenSSL keys on first use, which are
+// then reused for as long as the fields of the key aren't modified.
+//
+// The option types are aliases of those of crypto/rsa, so that
+// consumers of crypto.Signer and crypto.Decrypter, such as crypto/tls,
+// get the padding they ask for. The rand parameters are ignored,
+// OpenSSL uses its own random generator.
+package rsa
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig/bridge"
+)
+
+type (
+	// PSSOptions is crypto/rsa.PSSOptions.
+	PSSOptions = rsa.PSSOptions
+	// OAEPOptions is crypto/rsa.OAEPOptions.
+	OAEPOptions = rsa.OAEPOptions
+	// PKCS1v15DecryptOptions is crypto/rsa.PKCS1v15DecryptOptions.
+	PKCS1v15DecryptOptions = rsa.PKCS1v15DecryptOptions
+)
+
+const (
+	// PSSSaltLengthAuto causes the salt in a PSS signature to be as large
+	// as possible when signing, and to be auto-detected when verifying.
+	PSSSaltLengthAuto = rsa.PSSSaltLengthAuto
+	// PSSSaltLengthEqualsHash causes the salt length to equal the length
+	// of the hash used in the signature.
+	PSSSaltLengthEqualsHash = rsa.PSSSaltLengthEqualsHash
+)
+
+// ErrVerification represents a failure to verify a signature.
+var ErrVerification = rsa.ErrVerification
+
+// ErrDecryption represents a failure to decrypt a message.
+var ErrDecryption = rsa.ErrDecryption
+
+// PublicKey represents the public part of an RSA key.
+type PublicKey struct {
+	N *big.Int // modulus
+	E int      // public exponent
+
+	cache atomic.Value // *pubCache
+}
+
+// PrivateKey represents an RSA key.
+type PrivateKey struct {
+	PublicKey            // public part.
+	D         *big.Int   // private exponent
+	Primes    []*big.Int // prime factors of N, exactly two.
+
+	// Precomputed contains precomputed values that speed up private
+	// operations, and which OpenSSL requires. They are computed on
+	// first use if missing.
+	Precomputed PrecomputedValues
+
+	cache atomic.Value // *privCache
+}
+
+// PrecomputedValues holds the CRT values of a private key.
+type PrecomputedValues struct {
+	Dp, Dq *big.Int // D mod (P-1) (or mod Q-1)
+	Qinv   *big.Int // Q^-1 mod P
+}
+
+type pubCache struct {
+	n   big.Int
+	e   int
+	key *openssl.PublicKeyRSA
+}
+
+type privCache struct {
+	n, d, p, q big.Int
+	e          int
+	key        *openssl.PrivateKeyRSA
+}
+
+// Size returns the modulus size in bytes.
+func (pub *PublicKey) Size() int {
+	return (pub.N.BitLen() + 7) / 8
+}
+
+// opensslKey returns the OpenSSL key of pub.
+func (pub *PublicKey) opensslKey() (*openssl.PublicKeyRSA, error) {
+	if c, _ := pub.cache.Load().(*pubCache); c != nil && c.e == pub.E && c.n.Cmp(pub.N) == 0 {
+		return c.key, nil
+	}
+	if pub.N == nil || pub.E < 2 {
+		return nil, errors.New("rsa: invalid public key")
+	}
+	key, err := bridge.NewPublicKeyRSA(pub.N, big.NewInt(int64(pub.E)))
+	if err != nil {
+		return nil, err
+	}
+	c := &pubCache{e: pub.E, key: key}
+	c.n.Set(pub.N)
+	pub.cache.Store(c)
+	return key, nil
+}
+
+func (c *privCache) matches(priv *PrivateKey) bool {
+	return c.e == priv.E && c.n.Cmp(priv.N) == 0 && c.d.Cmp(priv.D) == 0 &&
+		c.p.Cmp(priv.Primes[0]) == 0 && c.q.Cmp(priv.Primes[1]) == 0
+}
+
+// opensslKey returns the OpenSSL key of priv.
+func (priv *PrivateKey) opensslKey() (*openssl.PrivateKeyRSA, error) {
+	if priv.N == nil || priv.D == nil || priv.E < 2 || len(priv.Primes) != 2 || priv.Primes[0] == nil || priv.Primes[1] == nil {
+		return nil, errors.New("rsa: invalid or unsupported private key")
+	}
+	if c, _ := priv.cache.Load().(*privCache); c != nil && c.matches(priv) {
+		return c.key, nil
+	}
+	pre := priv.Precomputed
+	if pre.Dp == nil || pre.Dq == nil || pre.Qinv == nil {
+		pre = precompute(priv)
+		if pre.Qinv == nil {
+			return nil, errors.New("rsa: invalid private key")
+		}
+	}
+	P, Q := priv.Primes[0], priv.Primes[1]
+	key, err := bridge.NewPrivateKeyRSA(priv.N, big.NewInt(int64(priv.E)), priv.D, P, Q, pre.Dp, pre.Dq, pre.Qinv)
+	if err != nil {
+		return nil, err
+	}
+	c := &privCache{e: priv.E, key: key}
+	c.n.Set(priv.N)
+	c.d.Set(priv.D)
+	c.p.Set(P)
+	c.q.Set(Q)
+	priv.cache.Store(c)
+	return key, nil
+}
+
+// GenerateKey generates an RSA key pair of the given bit size.
+func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
+	N, E, D, P, Q, Dp, Dq, Qinv, err := bridge.GenerateKeyRSA(bits)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{
+		PublicKey:   PublicKey{N: N, E: int(E.Int64())},
+		D:           D,
+		Primes:      []*big.Int{P, Q},
+		Precomputed: PrecomputedValues{Dp: Dp, Dq: Dq, Qinv: Qinv},
+	}, nil
+}
+
+// Precompute performs some calculations that speed up private key
+// operations in the future.
+func (priv *PrivateKey) Precompute() {
+	if priv.Precomputed.Dp != nil || len(priv.Primes) != 2 || priv.D == nil {
+		return
+	}
+	priv.Precomputed = precompute(priv)
+}
+
+func precompute(priv *PrivateKey) PrecomputedValues {
+	one := big.NewInt(1)
+	P, Q := priv.Primes[0], priv.Primes[1]
+	return PrecomputedValues{
+		Dp:   new(big.Int).Mod(priv.D, new(big.Int).Sub(P, one)),
+		Dq:   new(big.Int).Mod(priv.D, new(big.Int).Sub(Q, one)),
+		Qinv: new(big.Int).ModInverse(Q, P),
+	}
+}
+
+// Equal reports whether pub and x have the same value.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return bigIntEqual(pub.N, xx.N) && pub.E == xx.E
+}
+
+// Public returns the public key corresponding to priv.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// Equal reports whether priv and x have the same value.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	if !priv.PublicKey.Equal(&xx.PublicKey) || !bigIntEqual(priv.D, xx.D) || len(priv.Primes) != len(xx.Primes) {
+		return false
+	}
+	for i := range priv.Primes {
+		if !bigIntEqual(priv.Primes[i], xx.Primes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func bigIntEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// Sign signs digest with priv, as described in crypto.Signer. If opts
+// is a *PSSOptions the PSS algorithm is used, otherwise PKCS #1 v1.5.
+func (priv *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if pssOpts, ok := opts.(*PSSOptions); ok {
+		return SignPSS(rand, priv, pssOpts.Hash, digest, pssOpts)
+	}
+	return SignPKCS1v15(rand, priv, opts.HashFunc(), digest)
+}
+
+// Decrypt decrypts ciphertext with priv, as described in
+// crypto.Decrypter. opts is either nil or a *PKCS1v15DecryptOptions
+// for PKCS #1 v1.5, or an *OAEPOptions for OAEP, whose MGF1 hash is
+// its Hash.
+func (priv *PrivateKey) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	switch opts := opts.(type) {
+	case nil:
+		return DecryptPKCS1v15(rand, priv, ciphertext)
+	case *PKCS1v15DecryptOptions:
+		if l := opts.SessionKeyLen; l > 0 {
+			plaintext := make([]byte, l)
+			if _, err := io.ReadFull(rand, plaintext); err != nil {
+				return nil, err
+			}
+			if err := DecryptPKCS1v15SessionKey(rand, priv, ciphertext, plaintext); err != nil {
+				return nil, err
+			}
+			return plaintext, nil
+		}
+		return DecryptPKCS1v15(rand, priv, ciphertext)
+	case *OAEPOptions:
+		h := newHash(opts.Hash)
+		if h == nil {
+			return nil, errors.New("rsa: unsupported OAEP hash")
+		}
+		return DecryptOAEP(h, rand, priv, ciphertext, opts.Label)
+	}
+	return nil, errors.New("rsa: invalid options for Decrypt")
+}
+
+// SignPKCS1v15 calculates the RSASSA-PKCS1-V1_5-SIGN signature of
+// hashed, the result of hashing the input message with hash.
+func SignPKCS1v15(random io.Reader, priv *PrivateKey, hash crypto.Hash, hashed []byte) ([]byte, error) {
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	return openssl.SignRSAPKCS1v15(key, hash, hashed)
+}
+
+// VerifyPKCS1v15 verifies an RSA PKCS #1 v1.5 signature.
+// A valid signature is indicated by returning a nil error.
+func VerifyPKCS1v15(pub *PublicKey, hash crypto.Hash, hashed []byte, sig []byte) error {
+	key, err := pub.opensslKey()
+	if err != nil {
+		return err
+	}
+	if openssl.VerifyRSAPKCS1v15(key, hash, hashed, sig) != nil {
+		return ErrVerification
+	}
+	return nil
+}
+
+// SignPSS calculates the signature of digest using PSS.
+// opts can be nil, for PSSSaltLengthAuto.
+func SignPSS(rand io.Reader, priv *PrivateKey, hash crypto.Hash, digest []byte, opts *PSSOptions) ([]byte, error) {
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	return openssl.SignRSAPSS(key, hash, digest, saltLength(opts))
+}
+
+// VerifyPSS verifies a PSS signature.
+// A valid signature is indicated by returning a nil error.
+func VerifyPSS(pub *PublicKey, hash crypto.Hash, digest []byte, sig []byte, opts *PSSOptions) error {
+	key, err := pub.opensslKey()
+	if err != nil {
+		return err
+	}
+	if openssl.VerifyRSAPSS(key, hash, digest, sig, saltLength(opts)) != nil {
+		return ErrVerification
+	}
+	return nil
+}
+
+func saltLength(opts *PSSOptions) int {
+	if opts == nil {
+		return PSSSaltLengthAuto
+	}
+	return opts.SaltLength
+}
+
+// EncryptOAEP encrypts msg with RSA-OAEP, using hash for both the OAEP
+// and MGF1 hash functions. hash must be SHA-1 or a SHA-2 function other
+// than SHA-512/224 and SHA-512/256, as returned by crypto/sha256 or
+// this module's openssl package. It is reset.
+func EncryptOAEP(hash hash.Hash, random io.Reader, pub *PublicKey, msg []byte, label []byte) ([]byte, error) {
+	h, err := opensslHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	key, err := pub.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	return openssl.EncryptRSAOAEP(h, key, msg, label)
+}
+
+// DecryptOAEP decrypts ciphertext, encrypted with EncryptOAEP with
+// the same hash and label.
+func DecryptOAEP(hash hash.Hash, random io.Reader, priv *PrivateKey, ciphertext []byte, label []byte) ([]byte, error) {
+	h, err := opensslHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openssl.DecryptRSAOAEP(h, key, ciphertext, label)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// EncryptPKCS1v15 encrypts msg with RSA and the padding
+// scheme of PKCS #1 v1.5.
+func EncryptPKCS1v15(random io.Reader, pub *PublicKey, msg []byte) ([]byte, error) {
+	key, err := pub.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	return openssl.EncryptRSAPKCS1(key, msg)
+}
+
+// DecryptPKCS1v15 decrypts ciphertext with RSA and the padding
+// scheme of PKCS #1 v1.5.
+func DecryptPKCS1v15(random io.Reader, priv *PrivateKey, ciphertext []byte) ([]byte, error) {
+	key, err := priv.opensslKey()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openssl.DecryptRSAPKCS1(key, ciphertext)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// DecryptPKCS1v15SessionKey decrypts a session key with RSA and the
+// padding scheme of PKCS #1 v1.5 into key, and leaves key unchanged if
+// the padding is invalid or the plaintext isn't of the size of key,
+// instead of returning an error. It only returns an error for invalid
+// keys and ciphertexts of the wrong size. Unlike in crypto/rsa,
+// the timing of the padding check isn't guaranteed to be constant.
+func DecryptPKCS1v15SessionKey(random io.Reader, priv *PrivateKey, ciphertext []byte, key []byte) error {
+	k, err := priv.opensslKey()
+	if err != nil {
+		return err
+	}
+	if len(ciphertext) != priv.Size() {
+		return ErrDecryption
+	}
+	if plaintext, err := openssl.DecryptRSAPKCS1(k, ciphertext); err == nil && len(plaintext) == len(key) {
+		copy(key, plaintext)
+	}
+	return nil
+}
+
+// newHash returns the OpenSSL implementation of h.
+func newHash(h crypto.Hash) hash.Hash {
+	switch h {
+	case crypto.SHA1:
+		return openssl.NewSHA1()
+	case crypto.SHA224:
+		return openssl.NewSHA224()
+	case crypto.SHA256:
+		return openssl.NewSHA256()
+	case crypto.SHA384:
+		return openssl.NewSHA384()
+	case crypto.SHA512:
+		return openssl.NewSHA512()
+	}
+	return nil
+}
+
+// opensslHash returns the OpenSSL implementation of the hash function
+// of h, which the OAEP functions of the openssl package require. It is
+// recognized by its digest of a fixed message, as crypto/rsa only
+// receives a hash.Hash.
+func opensslHash(h hash.Hash) (hash.Hash, error) {
+	h.Reset()
+	h.Write([]byte("go-crypto-openssl"))
+	sum := h.Sum(nil)
+	h.Reset()
+	for _, ch := range []crypto.Hash{crypto.SHA1, crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		if ch.Size() != len(sum) {
+			continue
+		}
+		oh := newHash(ch)
+		oh.Write([]byte("go-crypto-openssl"))
+		if string(oh.Sum(nil)) == string(sum) {
+			oh.Reset()
+			return oh, nil
+		}
+	}
+	return nil, errors.New("rsa: unsupported OAEP hash")
+}
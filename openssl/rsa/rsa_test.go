@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"os"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+func TestMain(m *testing.M) {
+	if err := openssl.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func stdPublicKey(pub *PublicKey) *stdrsa.PublicKey {
+	return &stdrsa.PublicKey{N: pub.N, E: pub.E}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.Size() != 256 {
+		t.Errorf("Size() = %d, want 256", priv.Size())
+	}
+	hashed := sha256.Sum256([]byte("testing"))
+	sig, err := SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stdrsa.VerifyPKCS1v15(stdPublicKey(&priv.PublicKey), crypto.SHA256, hashed[:], sig); err != nil {
+		t.Error(err)
+	}
+	if err := VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed[:], sig); err != nil {
+		t.Error(err)
+	}
+	opts := &PSSOptions{SaltLength: PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	var signer crypto.Signer = priv
+	sig, err = signer.Sign(rand.Reader, hashed[:], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stdrsa.VerifyPSS(stdPublicKey(&priv.PublicKey), crypto.SHA256, hashed[:], sig, opts); err != nil {
+		t.Error(err)
+	}
+	if err := VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed[:], sig, opts); err != nil {
+		t.Error(err)
+	}
+	sig[0] ^= 1
+	if err := VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed[:], sig, opts); err != ErrVerification {
+		t.Errorf("VerifyPSS() error = %v, want ErrVerification", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	std, err := stdrsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A key without precomputed values, as built from its components.
+	priv := &PrivateKey{PublicKey: PublicKey{N: std.N, E: std.E}, D: std.D, Primes: std.Primes}
+	msg := []byte("session key")
+	ct, err := stdrsa.EncryptOAEP(sha512.New(), rand.Reader, &std.PublicKey, msg, []byte("label"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptOAEP(sha512.New(), rand.Reader, priv, ct, []byte("label"))
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("DecryptOAEP() = %q, %v", got, err)
+	}
+	if _, err := DecryptOAEP(sha512.New384(), rand.Reader, priv, ct, []byte("label")); err != ErrDecryption {
+		t.Errorf("DecryptOAEP() with another hash error = %v, want ErrDecryption", err)
+	}
+	ct, err = EncryptOAEP(sha256.New(), rand.Reader, &priv.PublicKey, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = priv.Decrypt(rand.Reader, ct, &OAEPOptions{Hash: crypto.SHA256})
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("Decrypt() = %q, %v", got, err)
+	}
+	ct, err = EncryptPKCS1v15(rand.Reader, &priv.PublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = std.Decrypt(rand.Reader, ct, nil)
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("crypto/rsa Decrypt() = %q, %v", got, err)
+	}
+	key := make([]byte, len(msg))
+	if err := DecryptPKCS1v15SessionKey(rand.Reader, priv, ct, key); err != nil || !bytes.Equal(key, msg) {
+		t.Errorf("DecryptPKCS1v15SessionKey() = %q, %v", key, err)
+	}
+	// A wrong length leaves the key unchanged.
+	key = make([]byte, 16)
+	if err := DecryptPKCS1v15SessionKey(rand.Reader, priv, ct, key); err != nil || !bytes.Equal(key, make([]byte, 16)) {
+		t.Errorf("DecryptPKCS1v15SessionKey() = %x, %v", key, err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := &PrivateKey{PublicKey: PublicKey{N: priv.N, E: priv.E}, D: priv.D, Primes: priv.Primes}
+	if !priv.Equal(same) || !priv.PublicKey.Equal(same.Public()) {
+		t.Error("Equal() = false for the same key")
+	}
+	other := &PublicKey{N: priv.N, E: 3}
+	if priv.PublicKey.Equal(other) {
+		t.Error("Equal() = true for another key")
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	hashed := sha256.Sum256([]byte("testing"))
+	if _, err := SignPKCS1v15(rand.Reader, &PrivateKey{}, crypto.SHA256, hashed[:]); err == nil {
+		t.Error("SignPKCS1v15() with an empty key succeeded")
+	}
+}
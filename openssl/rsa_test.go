@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl_test
 
@@ -181,6 +181,50 @@ func TestSignVerifyRSAPSS(t *testing.T) {
 	}
 }
 
+func TestSignVerifyMessageRSA(t *testing.T) {
+	sizes := []int{2048, 4608}
+	if testing.Short() {
+		sizes = sizes[:1]
+	}
+	msg := []byte("hi!")
+	hashed := openssl.SHA256(msg)
+	for _, size := range sizes {
+		priv, pub := newRSAKey(t, size)
+		// PKCS #1 v1.5 signatures are deterministic.
+		signed, err := openssl.SignMessageRSAPKCS1v15(priv, crypto.SHA256, msg)
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+		want, err := openssl.SignRSAPKCS1v15(priv, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(signed, want) {
+			t.Errorf("%d: SignMessageRSAPKCS1v15 and SignRSAPKCS1v15 differ", size)
+		}
+		if err := openssl.VerifyMessageRSAPKCS1v15(pub, crypto.SHA256, msg, signed); err != nil {
+			t.Errorf("%d: %v", size, err)
+		}
+		if err := openssl.VerifyMessageRSAPKCS1v15(pub, crypto.SHA256, []byte("ho!"), signed); err == nil {
+			t.Errorf("%d: VerifyMessageRSAPKCS1v15 accepted another message", size)
+		}
+
+		signed, err = openssl.SignMessageRSAPSS(priv, crypto.SHA256, msg, rsa.PSSSaltLengthEqualsHash)
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+		if err := openssl.VerifyRSAPSS(pub, crypto.SHA256, hashed[:], signed, rsa.PSSSaltLengthEqualsHash); err != nil {
+			t.Errorf("%d: %v", size, err)
+		}
+		if err := openssl.VerifyMessageRSAPSS(pub, crypto.SHA256, msg, signed, rsa.PSSSaltLengthAuto); err != nil {
+			t.Errorf("%d: %v", size, err)
+		}
+		if err := openssl.VerifyMessageRSAPSS(pub, crypto.SHA256, msg, signed, 8); err == nil {
+			t.Errorf("%d: VerifyMessageRSAPSS accepted the wrong salt length", size)
+		}
+	}
+}
+
 func newRSAKey(t *testing.T, size int) (*openssl.PrivateKeyRSA, *openssl.PublicKeyRSA) {
 	t.Helper()
 	N, E, D, P, Q, Dp, Dq, Qinv, err := bridge.GenerateKeyRSA(size)
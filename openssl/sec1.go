@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "errors"
+
+// MarshalECPrivateKey returns the SEC 1, ASN.1 DER form of the private
+// key (X, Y, D) on curve, as specified by RFC 5915. This is the
+// "EC PRIVATE KEY" format. The encoding includes the named curve
+// parameters and the public key.
+func MarshalECPrivateKey(curve string, X, Y, D BigInt) ([]byte, error) {
+	if D == nil {
+		return nil, errors.New("openssl: missing EC private key")
+	}
+	key, err := newRawECKey(curve, X, Y, D)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EC_KEY_free(key)
+	// OpenSSL 1.0.2 defaults to explicit curve parameters.
+	C.go_openssl_EC_KEY_set_asn1_flag(key, C.GO_OPENSSL_EC_NAMED_CURVE)
+	n := C.go_openssl_i2d_ECPrivateKey_buf(key, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_ECPrivateKey")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_ECPrivateKey_buf(key, base(der)) != n {
+		return nil, newOpenSSLError("i2d_ECPrivateKey")
+	}
+	return der, nil
+}
+
+// ParseECPrivateKey parses der, a SEC 1, ASN.1 DER EC private key as
+// specified by RFC 5915, and returns its curve and components.
+//
+// The curve parameters are optional in the encoding, for example when
+// it is embedded in PKCS #8. If der has no parameters, curve names the
+// curve of the key. Otherwise curve must either be empty or match the
+// named curve of der. Explicit curve parameters are not supported.
+// If der has no public key, it is computed from D.
+func ParseECPrivateKey(der []byte, curve string) (outCurve string, X, Y, D BigInt, err error) {
+	if len(der) == 0 {
+		return "", nil, nil, nil, errors.New("openssl: empty EC private key")
+	}
+	var key C.GO_EC_KEY_PTR
+	if curve != "" {
+		nid, err := curveNID(curve)
+		if err != nil {
+			return "", nil, nil, nil, err
+		}
+		if key = C.go_openssl_EC_KEY_new_by_curve_name(nid); key == nil {
+			return "", nil, nil, nil, newOpenSSLError("EC_KEY_new_by_curve_name")
+		}
+		defer C.go_openssl_EC_KEY_free(key)
+		if C.go_openssl_d2i_ECPrivateKey_buf(key, base(der), C.long(len(der))) == nil {
+			return "", nil, nil, nil, newOpenSSLError("d2i_ECPrivateKey")
+		}
+	} else {
+		if key = C.go_openssl_d2i_ECPrivateKey_buf(nil, base(der), C.long(len(der))); key == nil {
+			return "", nil, nil, nil, newOpenSSLError("d2i_ECPrivateKey")
+		}
+		defer C.go_openssl_EC_KEY_free(key)
+	}
+	outCurve, err = curveName(C.go_openssl_EC_GROUP_get_curve_name(C.go_openssl_EC_KEY_get0_group(key)))
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	if curve != "" && outCurve != curve {
+		return "", nil, nil, nil, errors.New("openssl: EC private key is on " + outCurve + ", not " + curve)
+	}
+	if C.go_openssl_EC_KEY_check_key(key) != 1 {
+		return "", nil, nil, nil, newOpenSSLError("EC_KEY_check_key")
+	}
+	X, Y, D, err = ecKeyComponents(key)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	return outCurve, X, Y, D, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+func TestECPrivateKey(t *testing.T) {
+	testAllCurves(t, testECPrivateKey)
+}
+
+func testECPrivateKey(t *testing.T, c elliptic.Curve) {
+	key, err := generateKeycurve(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := c.Params().Name
+	der, err := openssl.MarshalECPrivateKey(name, bbig.Enc(key.X), bbig.Enc(key.Y), bbig.Enc(key.D))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The encoding must match the standard library one.
+	want, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, want) {
+		t.Errorf("got %x, want %x", der, want)
+	}
+	curve, x, y, d, err := openssl.ParseECPrivateKey(der, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if curve != name || bbig.Dec(x).Cmp(key.X) != 0 || bbig.Dec(y).Cmp(key.Y) != 0 || bbig.Dec(d).Cmp(key.D) != 0 {
+		t.Errorf("parsed key doesn't match")
+	}
+	if _, _, _, _, err := openssl.ParseECPrivateKey(der, name); err != nil {
+		t.Errorf("parsing with matching curve: %v", err)
+	}
+	other := "P-256"
+	if name == other {
+		other = "P-384"
+	}
+	if _, _, _, _, err := openssl.ParseECPrivateKey(der, other); err == nil {
+		t.Errorf("expected error parsing a %s key as %s", name, other)
+	}
+}
+
+func TestECPrivateKeyWithoutParameters(t *testing.T) {
+	key, err := generateKeycurve(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An RFC 5915 ECPrivateKey with neither parameters nor public key.
+	der, err := asn1.Marshal(struct {
+		Version    int
+		PrivateKey []byte
+	}{1, key.D.FillBytes(make([]byte, 32))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	curve, x, y, d, err := openssl.ParseECPrivateKey(der, "P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if curve != "P-256" || bbig.Dec(x).Cmp(key.X) != 0 || bbig.Dec(y).Cmp(key.Y) != 0 || bbig.Dec(d).Cmp(key.D) != 0 {
+		t.Errorf("parsed key doesn't match")
+	}
+	if _, _, _, _, err := openssl.ParseECPrivateKey(der, ""); err == nil {
+		t.Error("expected error parsing a key without parameters nor curve")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"strconv"
+)
+
+// secureHeap is set by initLibrary, under initOnce, when private
+// key components are allocated in the secure heap.
+var secureHeap bool
+
+// checkSecureHeap validates the secure heap options before any
+// OpenSSL call, as OpenSSL 1.1 aborts on invalid sizes.
+func checkSecureHeap(opts InitOptions) error {
+	if opts.SecureHeapSize == 0 {
+		if opts.SecureHeapMinSize != 0 {
+			return errors.New("openssl: SecureHeapMinSize requires SecureHeapSize")
+		}
+		return nil
+	}
+	isPow2 := func(n int) bool { return n > 0 && n&(n-1) == 0 }
+	if !isPow2(opts.SecureHeapSize) {
+		return errors.New("openssl: SecureHeapSize " + strconv.Itoa(opts.SecureHeapSize) + " is not a power of two")
+	}
+	if opts.SecureHeapMinSize != 0 && (!isPow2(opts.SecureHeapMinSize) || opts.SecureHeapMinSize >= opts.SecureHeapSize) {
+		return errors.New("openssl: SecureHeapMinSize " + strconv.Itoa(opts.SecureHeapMinSize) + " is not a power of two smaller than SecureHeapSize")
+	}
+	return nil
+}
+
+// initSecureHeap sets up the secure heap described by opts.
+func initSecureHeap(opts InitOptions) error {
+	if vMajor == 1 && vMinor == 0 {
+		return errors.New("openssl: SecureHeapSize requires OpenSSL 1.1 or later")
+	}
+	minSize := opts.SecureHeapMinSize
+	if minSize == 0 {
+		minSize = 16
+	}
+	// CRYPTO_secure_malloc_init returns 2 when the heap works but
+	// some of its protections, such as mlock, are unavailable,
+	// which is still better than the regular heap.
+	if C.go_openssl_CRYPTO_secure_malloc_init(C.size_t(opts.SecureHeapSize), C.size_t(minSize)) == 0 {
+		return newOpenSSLError("openssl: CRYPTO_secure_malloc_init")
+	}
+	secureHeap = true
+	return nil
+}
+
+// SecureHeapUsed returns the number of bytes of the secure
+// heap in use. It requires InitOptions.SecureHeapSize.
+func SecureHeapUsed() (int, error) {
+	if !secureHeap || C.go_openssl_CRYPTO_secure_malloc_initialized() != 1 {
+		return 0, errors.New("openssl: the secure heap isn't initialized, see InitOptions.SecureHeapSize")
+	}
+	return int(C.go_openssl_CRYPTO_secure_used()), nil
+}
+
+// secretBigToBN is like bigToBN but allocates the BIGNUM in the
+// secure heap, if any, for private key components.
+func secretBigToBN(x BigInt) C.GO_BIGNUM_PTR {
+	if !secureHeap || len(x) == 0 {
+		return bigToBN(x)
+	}
+	bn := C.go_openssl_BN_secure_new()
+	if bn == nil {
+		return nil
+	}
+	if C.go_openssl_BN_lebin2bn(wbase(x), C.int(len(x)*wordBytes), bn) == nil {
+		C.go_openssl_BN_clear_free(bn)
+		return nil
+	}
+	return bn
+}
+
+// secretBytesToBN is like bytesToBN but allocates the BIGNUM in the
+// secure heap, if any, for private key components.
+func secretBytesToBN(x []byte) C.GO_BIGNUM_PTR {
+	if !secureHeap || len(x) == 0 {
+		return bytesToBN(x)
+	}
+	bn := C.go_openssl_BN_secure_new()
+	if bn == nil {
+		return nil
+	}
+	if C.go_openssl_BN_bin2bn(base(x), C.int(len(x)), bn) == nil {
+		C.go_openssl_BN_clear_free(bn)
+		return nil
+	}
+	return bn
+}
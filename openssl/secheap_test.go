@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestSecureHeapOptions(t *testing.T) {
+	for _, opts := range []InitOptions{
+		{SecureHeapSize: 1000},
+		{SecureHeapSize: -1},
+		{SecureHeapMinSize: 16},
+		{SecureHeapSize: 1 << 16, SecureHeapMinSize: 24},
+		{SecureHeapSize: 1 << 16, SecureHeapMinSize: 1 << 16},
+	} {
+		if err := InitWithOptions(opts); err == nil {
+			t.Errorf("InitWithOptions(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestSecureHeap(t *testing.T) {
+	if vMajor == 1 && vMinor == 0 {
+		t.Skip("the secure heap requires OpenSSL 1.1 or later")
+	}
+	if os.Getenv("GO_OPENSSL_TEST_SECURE_HEAP") == "" {
+		if _, err := SecureHeapUsed(); err == nil {
+			t.Error("SecureHeapUsed succeeded without a secure heap")
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSecureHeap$", "-test.v")
+		cmd.Env = append(os.Environ(), "GO_OPENSSL_TEST_SECURE_HEAP=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+	// Running in the child process started above.
+	N, E, D, P, Q, Dp, Dq, Qinv, err := GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := SecureHeapUsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, err := SecureHeapUsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used <= before {
+		t.Errorf("secure heap use went from %d to %d bytes importing an RSA key", before, used)
+	}
+	runtime.KeepAlive(key)
+}
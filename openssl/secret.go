@@ -0,0 +1,155 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+// #include <stdlib.h>
+// #include <string.h>
+import "C"
+import (
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// lockSecrets is set by initLibrary, under initOnce,
+// see InitOptions.LockSecrets.
+var lockSecrets bool
+
+// lockedPages counts the secrets locked in each memory page, as
+// several secrets can share a page and mlock doesn't nest: a page is
+// only unlocked once the last secret in it is released.
+var lockedPages struct {
+	sync.Mutex
+	n map[uintptr]int
+}
+
+// Cleanse overwrites b with zeros with OPENSSL_cleanse,
+// which the compiler can't optimize away.
+func Cleanse(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	C.go_openssl_OPENSSL_cleanse(unsafe.Pointer(&b[0]), C.size_t(len(b)))
+}
+
+// ProtectSecret locks b in memory if InitOptions.LockSecrets is set,
+// and returns a function cleansing b with Cleanse and unlocking it.
+// Owners of sensitive buffers, such as the DEKs of the envelope
+// package, call it before filling b and call release once b isn't
+// needed anymore, for example when they are closed. release must be
+// called exactly once and b must not be used afterwards. b must be
+// allocated on the heap, which Go never moves, rather than the stack,
+// for example by make.
+func ProtectSecret(b []byte) (release func(), err error) {
+	if !lockSecrets || len(b) == 0 {
+		return func() { Cleanse(b) }, nil
+	}
+	p := unsafe.Pointer(&b[0])
+	if err := lockMemory(p, len(b)); err != nil {
+		return nil, err
+	}
+	return func() {
+		Cleanse(b)
+		unlockMemory(p, len(b))
+	}, nil
+}
+
+// memoryPages returns the address of the pages spanned by the n bytes at p.
+func memoryPages(p unsafe.Pointer, n int) []uintptr {
+	size := uintptr(os.Getpagesize())
+	start := uintptr(p) &^ (size - 1)
+	end := uintptr(p) + uintptr(n)
+	var pages []uintptr
+	for page := start; page < end; page += size {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// lockMemory locks the pages spanned by the n bytes at p that aren't
+// locked yet, and unlocks them again if any of them can't be locked.
+func lockMemory(p unsafe.Pointer, n int) error {
+	lockedPages.Lock()
+	defer lockedPages.Unlock()
+	if lockedPages.n == nil {
+		lockedPages.n = make(map[uintptr]int)
+	}
+	pages := memoryPages(p, n)
+	size := os.Getpagesize()
+	for i, page := range pages {
+		if lockedPages.n[page] == 0 {
+			if err := mlock(page, size); err != nil {
+				pageUnlock(pages[:i], size)
+				return err
+			}
+		}
+		lockedPages.n[page]++
+	}
+	return nil
+}
+
+// unlockMemory releases the pages locked by lockMemory(p, n).
+func unlockMemory(p unsafe.Pointer, n int) {
+	lockedPages.Lock()
+	defer lockedPages.Unlock()
+	pageUnlock(memoryPages(p, n), os.Getpagesize())
+}
+
+func pageUnlock(pages []uintptr, size int) {
+	for _, page := range pages {
+		if lockedPages.n[page]--; lockedPages.n[page] == 0 {
+			delete(lockedPages.n, page)
+			munlock(page, size)
+		}
+	}
+}
+
+// secretCString returns a NUL-terminated C copy of the secret b,
+// which can't contain NUL, locked in memory like ProtectSecret does.
+// It never copies b to the Go heap, unlike C.CString(string(b)).
+// The caller must free it with freeSecretCString.
+func secretCString(b []byte) (*C.char, error) {
+	// Go guarantees C.malloc never returns nil.
+	p := C.malloc(C.size_t(len(b) + 1))
+	if lockSecrets {
+		if err := lockMemory(p, len(b)+1); err != nil {
+			C.free(p)
+			return nil, err
+		}
+	}
+	s := unsafe.Slice((*byte)(p), len(b)+1)
+	copy(s, b)
+	s[len(b)] = 0
+	return (*C.char)(p), nil
+}
+
+// secretCStringFromString is like secretCString for a string.
+func secretCStringFromString(s string) (*C.char, error) {
+	p := C.CString(s)
+	if lockSecrets {
+		if err := lockMemory(unsafe.Pointer(p), len(s)+1); err != nil {
+			C.free(unsafe.Pointer(p))
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// freeSecretCString cleanses, unlocks and frees a C string returned by
+// secretCString or secretCStringFromString. p can be nil.
+func freeSecretCString(p *C.char) {
+	if p == nil {
+		return
+	}
+	n := int(C.strlen(p)) + 1
+	C.go_openssl_OPENSSL_cleanse(unsafe.Pointer(p), C.size_t(n))
+	if lockSecrets {
+		unlockMemory(unsafe.Pointer(p), n)
+	}
+	C.free(unsafe.Pointer(p))
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+)
+
+func TestCleanse(t *testing.T) {
+	b := bytes.Repeat([]byte{0xAA}, 100)
+	Cleanse(b)
+	if !bytes.Equal(b, make([]byte, 100)) {
+		t.Errorf("Cleanse left %x", b)
+	}
+	Cleanse(nil)
+}
+
+func TestProtectSecret(t *testing.T) {
+	defer func(old bool) { lockSecrets = old }(lockSecrets)
+	lockSecrets = true
+	// Two secrets in the same page keep it locked until both are released.
+	buf := make([]byte, 64)
+	a, b := buf[:32], buf[32:]
+	releaseA, err := ProtectSecret(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB, err := ProtectSecret(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(buf, bytes.Repeat([]byte{1}, 64))
+	pages := memoryPages(unsafe.Pointer(&buf[0]), len(buf))
+	count := func() int {
+		lockedPages.Lock()
+		defer lockedPages.Unlock()
+		return lockedPages.n[pages[0]]
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("page locked by %d secrets, want 2", n)
+	}
+	releaseA()
+	if n := count(); n != 1 {
+		t.Errorf("page locked by %d secrets, want 1", n)
+	}
+	if !bytes.Equal(a, make([]byte, 32)) || !bytes.Equal(b, bytes.Repeat([]byte{1}, 32)) {
+		t.Errorf("release cleansed %x", buf)
+	}
+	releaseB()
+	if n := count(); n != 0 {
+		t.Errorf("page locked by %d secrets, want 0", n)
+	}
+
+	p, err := secretCString([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := unsafe.Slice((*byte)(unsafe.Pointer(p)), 9); string(got) != "password\x00" {
+		t.Errorf("secretCString = %q", got)
+	}
+	freeSecretCString(p)
+	lockedPages.Lock()
+	defer lockedPages.Unlock()
+	if len(lockedPages.n) != 0 {
+		t.Errorf("%d pages still locked", len(lockedPages.n))
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package openssl
+
+// #include <errno.h>
+// #include <stdint.h>
+// #include <sys/mman.h>
+//
+// // The pages are passed as integers, they aren't Go pointers
+// // and can lie outside of the Go object holding the secret.
+// static int go_openssl_mlock(uintptr_t page, size_t size) {
+//     return mlock((void*)page, size) == 0 ? 0 : errno;
+// }
+//
+// static void go_openssl_munlock(uintptr_t page, size_t size) {
+//     munlock((void*)page, size);
+// }
+import "C"
+import (
+	"errors"
+	"syscall"
+)
+
+func mlock(page uintptr, size int) error {
+	if errno := C.go_openssl_mlock(C.uintptr_t(page), C.size_t(size)); errno != 0 {
+		return errors.New("openssl: mlock: " + syscall.Errno(errno).Error())
+	}
+	return nil
+}
+
+func munlock(page uintptr, size int) {
+	C.go_openssl_munlock(C.uintptr_t(page), C.size_t(size))
+}
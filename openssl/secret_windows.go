@@ -0,0 +1,36 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build windows
+// +build windows
+
+package openssl
+
+// #include <stdint.h>
+// #include <windows.h>
+//
+// // The pages are passed as integers, they aren't Go pointers
+// // and can lie outside of the Go object holding the secret.
+// static DWORD go_openssl_mlock(uintptr_t page, size_t size) {
+//     return VirtualLock((LPVOID)page, size) ? 0 : GetLastError();
+// }
+//
+// static void go_openssl_munlock(uintptr_t page, size_t size) {
+//     VirtualUnlock((LPVOID)page, size);
+// }
+import "C"
+import (
+	"errors"
+	"strconv"
+)
+
+func mlock(page uintptr, size int) error {
+	if code := C.go_openssl_mlock(C.uintptr_t(page), C.size_t(size)); code != 0 {
+		return errors.New("openssl: VirtualLock failed with error " + strconv.Itoa(int(code)))
+	}
+	return nil
+}
+
+func munlock(page uintptr, size int) {
+	C.go_openssl_munlock(C.uintptr_t(page), C.size_t(size))
+}
@@ -0,0 +1,32 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// Defined in selftest.go.
+extern int goSelfTestCallback(char *phase, char *type, char *desc);
+
+static const char *
+self_test_param(const OSSL_PARAM params[], const char *key)
+{
+    const OSSL_PARAM *p = go_openssl_OSSL_PARAM_locate_const(params, key);
+    if (p == NULL || p->data_type != GO_OSSL_PARAM_UTF8_STRING || p->data == NULL)
+        return "";
+    return (const char *)p->data;
+}
+
+static int
+self_test_cb(const OSSL_PARAM params[], void *arg)
+{
+    return goSelfTestCallback((char *)self_test_param(params, "st-phase"),
+                              (char *)self_test_param(params, "st-type"),
+                              (char *)self_test_param(params, "st-desc"));
+}
+
+void go_openssl_set_self_test_callback(int enable)
+{
+    go_openssl_OSSL_SELF_TEST_set_callback(NULL, enable ? (GO_OSSL_CALLBACK_PTR)self_test_cb : NULL, NULL);
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"sync"
+)
+
+// Self-test phases reported in SelfTestEvent.Phase.
+const (
+	SelfTestPhaseStart   = "Start"
+	SelfTestPhaseCorrupt = "Corrupt"
+	SelfTestPhasePass    = "Pass"
+	SelfTestPhaseFail    = "Fail"
+)
+
+// SelfTestEvent is a progress notification of a FIPS provider self-test.
+type SelfTestEvent struct {
+	// Phase is one of the SelfTestPhase constants.
+	Phase string
+	// Type is the kind of test, such as "KAT_Cipher",
+	// "Module_Integrity" or "Conditional_PCT".
+	Type string
+	// Description identifies the tested algorithm, such as "AES_GCM".
+	Description string
+}
+
+// SelfTestResult is the outcome of a single self-test.
+type SelfTestResult struct {
+	Type        string
+	Description string
+	Passed      bool
+}
+
+// SelfTestReport lists the self-tests run by RunFIPSSelfTest.
+type SelfTestReport struct {
+	// Passed reports whether the FIPS provider reported success.
+	Passed  bool
+	Results []SelfTestResult
+}
+
+var (
+	selfTestMu sync.Mutex
+	// selfTestObserver is the callback set with SetSelfTestCallback.
+	selfTestObserver func(SelfTestEvent)
+	// selfTestResults collects the results while RunFIPSSelfTest runs.
+	selfTestResults *[]SelfTestResult
+	// selfTestRunMu serializes RunFIPSSelfTest calls.
+	selfTestRunMu sync.Mutex
+)
+
+//export goSelfTestCallback
+func goSelfTestCallback(phase, typ, desc *C.char) C.int {
+	ev := SelfTestEvent{
+		Phase:       C.GoString(phase),
+		Type:        C.GoString(typ),
+		Description: C.GoString(desc),
+	}
+	selfTestMu.Lock()
+	observer, results := selfTestObserver, selfTestResults
+	if results != nil && (ev.Phase == SelfTestPhasePass || ev.Phase == SelfTestPhaseFail) {
+		*results = append(*results, SelfTestResult{
+			Type:        ev.Type,
+			Description: ev.Description,
+			Passed:      ev.Phase == SelfTestPhasePass,
+		})
+	}
+	selfTestMu.Unlock()
+	if observer != nil {
+		observer(ev)
+	}
+	// Returning 1 lets the test proceed without corruption.
+	return 1
+}
+
+// updateSelfTestCallback installs the C callback if anyone needs it.
+// selfTestMu must be held.
+func updateSelfTestCallback() {
+	var enable C.int
+	if selfTestObserver != nil || selfTestResults != nil {
+		enable = 1
+	}
+	C.go_openssl_set_self_test_callback(enable)
+}
+
+// SetSelfTestCallback registers cb to be called for every self-test event
+// reported by the FIPS provider of the default library context,
+// including the conditional tests run during key generation.
+// A nil cb removes the callback.
+//
+// cb may be called concurrently from any goroutine and must not
+// call back into this package.
+//
+// SetSelfTestCallback is only supported on OpenSSL 3.
+func SetSelfTestCallback(cb func(SelfTestEvent)) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	selfTestMu.Lock()
+	defer selfTestMu.Unlock()
+	selfTestObserver = cb
+	updateSelfTestCallback()
+	return nil
+}
+
+// RunFIPSSelfTest runs the on-demand self-tests of the FIPS provider
+// loaded in the default library context, which repeat the known answer
+// tests and the module integrity check run at load time, and returns
+// the result of each test.
+//
+// RunFIPSSelfTest is only supported on OpenSSL 3.
+func RunFIPSSelfTest() (SelfTestReport, error) {
+	if vMajor != 3 {
+		return SelfTestReport{}, errUnsuportedVersion()
+	}
+	if C.go_openssl_OSSL_PROVIDER_available(nil, providerNameFips) == 0 {
+		return SelfTestReport{}, errors.New("openssl: FIPS provider not available")
+	}
+	prov := C.go_openssl_OSSL_PROVIDER_load(nil, providerNameFips)
+	if prov == nil {
+		return SelfTestReport{}, newOpenSSLError("openssl: OSSL_PROVIDER_load")
+	}
+	defer C.go_openssl_OSSL_PROVIDER_unload(prov)
+
+	selfTestRunMu.Lock()
+	defer selfTestRunMu.Unlock()
+	var results []SelfTestResult
+	selfTestMu.Lock()
+	selfTestResults = &results
+	updateSelfTestCallback()
+	selfTestMu.Unlock()
+
+	passed := C.go_openssl_OSSL_PROVIDER_self_test(prov) == 1
+
+	selfTestMu.Lock()
+	selfTestResults = nil
+	updateSelfTestCallback()
+	selfTestMu.Unlock()
+	if !passed {
+		C.go_openssl_ERR_clear_error()
+	}
+	return SelfTestReport{Passed: passed, Results: results}, nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "testing"
+
+func TestRunFIPSSelfTest(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("FIPS self-tests are only supported on OpenSSL 3")
+	}
+	var events int
+	if err := SetSelfTestCallback(func(SelfTestEvent) { events++ }); err != nil {
+		t.Fatal(err)
+	}
+	defer SetSelfTestCallback(nil)
+	report, err := RunFIPSSelfTest()
+	if !FIPS() {
+		if err == nil {
+			t.Error("expected error without the FIPS provider")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Passed {
+		t.Errorf("self-tests failed: %+v", report.Results)
+	}
+	if len(report.Results) == 0 || events == 0 {
+		t.Error("no self-test reported")
+	}
+	for _, r := range report.Results {
+		if !r.Passed {
+			t.Errorf("%s %s failed", r.Type, r.Description)
+		}
+	}
+}
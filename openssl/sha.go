@@ -1,8 +1,8 @@
 // Copyright (c) Microsoft Corporation.
 // Licensed under the MIT License.
 
-//go:build linux && !android
-// +build linux,!android
+//go:build linux || darwin || windows
+// +build linux darwin windows
 
 package openssl
 
@@ -29,6 +29,7 @@ import (
 // This is all to preserve compatibility with the allocation behavior of the non-openssl implementations.
 
 func shaX(md C.GO_EVP_MD_PTR, p []byte, sum []byte) bool {
+	addBytes(MetricHash, len(p))
 	return C.go_shaX(md, unsafe.Pointer(&*addr(p)), C.size_t(len(p)), noescape(unsafe.Pointer(&sum[0]))) != 0
 }
 
@@ -68,14 +69,21 @@ func SHA512(p []byte) (sum [64]byte) {
 }
 
 type evpHash struct {
+	// lib is the library context md was fetched from,
+	// or nil if md is a built-in digest of the default context.
+	// In the former case the hash owns a reference to md.
+	lib *LibraryContext
 	md  C.GO_EVP_MD_PTR
 	ctx C.GO_EVP_MD_CTX_PTR
 	// ctx2 is used in evpHash.sum to avoid changing
 	// the state of ctx. Having it here allows reusing the
 	// same allocated object multiple times.
-	ctx2      C.GO_EVP_MD_CTX_PTR
+	ctx2 C.GO_EVP_MD_CTX_PTR
+	// pooled owns ctx and ctx2.
+	pooled    [2]*pooledCtx
 	size      int
 	blockSize int
+	closer
 }
 
 func newEvpHash(ch crypto.Hash, size, blockSize int) *evpHash {
@@ -83,26 +91,43 @@ func newEvpHash(ch crypto.Hash, size, blockSize int) *evpHash {
 	if md == nil {
 		panic("openssl: unsupported hash function: " + strconv.Itoa(int(ch)))
 	}
-	ctx := C.go_openssl_EVP_MD_CTX_new()
-	ctx2 := C.go_openssl_EVP_MD_CTX_new()
+	return newEvpHashMD(nil, md, size, blockSize)
+}
+
+func newEvpHashMD(lib *LibraryContext, md C.GO_EVP_MD_PTR, size, blockSize int) *evpHash {
+	pooled, ctx := getMDCtx()
+	pooled2, ctx2 := getMDCtx()
 	h := &evpHash{
+		lib:       lib,
 		md:        md,
 		ctx:       ctx,
 		ctx2:      ctx2,
+		pooled:    [2]*pooledCtx{pooled, pooled2},
 		size:      size,
 		blockSize: blockSize,
 	}
-	runtime.SetFinalizer(h, (*evpHash).finalize)
+	manage(h, (*evpHash).finalize)
 	h.Reset()
 	return h
 }
 
 func (h *evpHash) finalize() {
-	C.go_openssl_EVP_MD_CTX_free(h.ctx)
-	C.go_openssl_EVP_MD_CTX_free(h.ctx2)
+	h.pooled[0].put()
+	h.pooled[1].put()
+	h.ctx, h.ctx2, h.pooled = nil, nil, [2]*pooledCtx{}
+	if h.lib != nil {
+		C.go_openssl_EVP_MD_free(h.md)
+	}
+}
+
+// Close frees the native resources of h, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (h *evpHash) Close() error {
+	return closeObject(h, h.finalize)
 }
 
 func (h *evpHash) Reset() {
+	h.checkOpen()
 	// There is no need to reset h.ctx2 because it is always reset after
 	// use in evpHash.sum.
 	// Calling EVP_DigestInit on an already initialized EVP_MD_CTX results in
@@ -114,6 +139,8 @@ func (h *evpHash) Reset() {
 }
 
 func (h *evpHash) Write(p []byte) (int, error) {
+	h.checkOpen()
+	addBytes(MetricHash, len(p))
 	if len(p) > 0 && C.go_openssl_EVP_DigestUpdate(h.ctx, unsafe.Pointer(&*addr(p)), C.size_t(len(p))) != 1 {
 		panic("openssl: EVP_DigestUpdate failed")
 	}
@@ -122,6 +149,8 @@ func (h *evpHash) Write(p []byte) (int, error) {
 }
 
 func (h *evpHash) WriteString(s string) (int, error) {
+	h.checkOpen()
+	addBytes(MetricHash, len(s))
 	// TODO: use unsafe.StringData once we drop support
 	// for go1.19 and earlier.
 	hdr := (*struct {
@@ -136,6 +165,7 @@ func (h *evpHash) WriteString(s string) (int, error) {
 }
 
 func (h *evpHash) WriteByte(c byte) error {
+	h.checkOpen()
 	if C.go_openssl_EVP_DigestUpdate(h.ctx, unsafe.Pointer(&c), 1) == 0 {
 		panic("openssl: EVP_DigestUpdate failed")
 	}
@@ -152,6 +182,7 @@ func (h *evpHash) BlockSize() int {
 }
 
 func (h *evpHash) sum(out []byte) {
+	h.checkOpen()
 	// Make copy of context because Go hash.Hash mandates
 	// that Sum has no effect on the underlying stream.
 	// In particular it is OK to Sum, then Write more, then Sum again,
@@ -170,6 +201,7 @@ func (h *evpHash) sum(out []byte) {
 // The EVP_MD_CTX memory layout has changed in OpenSSL 3
 // and the property holding the internal structure is no longer md_data but algctx.
 func (h *evpHash) shaState() unsafe.Pointer {
+	h.checkOpen()
 	switch vMajor {
 	case 1:
 		// https://github.com/openssl/openssl/blob/0418e993c717a6863f206feaa40673a261de7395/crypto/evp/evp_local.h#L12.
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "errors"
+
+// SLH-DSA parameter sets, as named in FIPS 205.
+// The "s" sets produce smaller signatures and the "f" sets sign faster.
+const (
+	SLHDSASHA2_128s  = "SLH-DSA-SHA2-128s"
+	SLHDSASHA2_128f  = "SLH-DSA-SHA2-128f"
+	SLHDSASHA2_192s  = "SLH-DSA-SHA2-192s"
+	SLHDSASHA2_192f  = "SLH-DSA-SHA2-192f"
+	SLHDSASHA2_256s  = "SLH-DSA-SHA2-256s"
+	SLHDSASHA2_256f  = "SLH-DSA-SHA2-256f"
+	SLHDSASHAKE_128s = "SLH-DSA-SHAKE-128s"
+	SLHDSASHAKE_128f = "SLH-DSA-SHAKE-128f"
+	SLHDSASHAKE_192s = "SLH-DSA-SHAKE-192s"
+	SLHDSASHAKE_192f = "SLH-DSA-SHAKE-192f"
+	SLHDSASHAKE_256s = "SLH-DSA-SHAKE-256s"
+	SLHDSASHAKE_256f = "SLH-DSA-SHAKE-256f"
+)
+
+func checkSLHDSAParams(params string) error {
+	switch params {
+	case SLHDSASHA2_128s, SLHDSASHA2_128f, SLHDSASHA2_192s, SLHDSASHA2_192f, SLHDSASHA2_256s, SLHDSASHA2_256f,
+		SLHDSASHAKE_128s, SLHDSASHAKE_128f, SLHDSASHAKE_192s, SLHDSASHAKE_192f, SLHDSASHAKE_256s, SLHDSASHAKE_256f:
+		return nil
+	}
+	return errors.New("openssl: unsupported SLH-DSA parameter set " + params)
+}
+
+// SupportsSLHDSA reports whether the SLH-DSA parameter set params
+// is available, either natively, since OpenSSL 3.5, or through
+// a provider loaded in the default library context.
+func SupportsSLHDSA(params string) bool {
+	return checkSLHDSAParams(params) == nil && supportsKeyType(params)
+}
+
+// PrivateKeySLHDSA is an SLH-DSA private key.
+type PrivateKeySLHDSA struct {
+	params string
+	pkey   *PKey
+}
+
+// PublicKeySLHDSA is an SLH-DSA public key.
+type PublicKeySLHDSA struct {
+	params string
+	pkey   *PKey
+}
+
+// GenerateKeySLHDSA generates a private key for the SLH-DSA
+// parameter set params. See SupportsSLHDSA.
+func GenerateKeySLHDSA(params string) (*PrivateKeySLHDSA, error) {
+	if err := checkSLHDSAParams(params); err != nil {
+		return nil, err
+	}
+	k, err := GenerateKey(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeySLHDSA{params: params, pkey: k}, nil
+}
+
+// NewPrivateKeySLHDSA parses a private key for the SLH-DSA
+// parameter set params, encoded as specified in FIPS 205.
+func NewPrivateKeySLHDSA(params string, b []byte) (*PrivateKeySLHDSA, error) {
+	if err := checkSLHDSAParams(params); err != nil {
+		return nil, err
+	}
+	k, err := NewPrivatePKey(params, b)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeySLHDSA{params: params, pkey: k}, nil
+}
+
+// NewPublicKeySLHDSA parses a public key for the SLH-DSA
+// parameter set params, encoded as specified in FIPS 205.
+func NewPublicKeySLHDSA(params string, b []byte) (*PublicKeySLHDSA, error) {
+	if err := checkSLHDSAParams(params); err != nil {
+		return nil, err
+	}
+	k, err := NewPublicPKey(params, b)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeySLHDSA{params: params, pkey: k}, nil
+}
+
+// Params returns the SLH-DSA parameter set of the key.
+func (priv *PrivateKeySLHDSA) Params() string { return priv.params }
+
+// Bytes returns the private key, encoded as specified in FIPS 205.
+func (priv *PrivateKeySLHDSA) Bytes() ([]byte, error) {
+	return priv.pkey.PrivateKeyBytes()
+}
+
+// PublicKey returns the public key corresponding to priv.
+func (priv *PrivateKeySLHDSA) PublicKey() (*PublicKeySLHDSA, error) {
+	b, err := priv.pkey.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKeySLHDSA(priv.params, b)
+}
+
+// Params returns the SLH-DSA parameter set of the key.
+func (pub *PublicKeySLHDSA) Params() string { return pub.params }
+
+// Bytes returns the public key, encoded as specified in FIPS 205.
+func (pub *PublicKeySLHDSA) Bytes() ([]byte, error) {
+	return pub.pkey.PublicKeyBytes()
+}
+
+// SignSLHDSA signs msg with priv using the hedged variant of SLH-DSA.
+// context is the optional context string of FIPS 205, at most 255 bytes long.
+func SignSLHDSA(priv *PrivateKeySLHDSA, msg, context []byte) ([]byte, error) {
+	return signWithContext(priv.pkey, msg, context)
+}
+
+// VerifySLHDSA verifies that sig is a valid SLH-DSA signature
+// of msg by pub with the context string context.
+func VerifySLHDSA(pub *PublicKeySLHDSA, msg, sig, context []byte) error {
+	return verifyWithContext(pub.pkey, msg, sig, context)
+}
+
+// Close frees the native key of priv, see InitOptions.ExplicitClose.
+func (priv *PrivateKeySLHDSA) Close() error { return priv.pkey.Close() }
+
+// Close frees the native key of pub, see InitOptions.ExplicitClose.
+func (pub *PublicKeySLHDSA) Close() error { return pub.pkey.Close() }
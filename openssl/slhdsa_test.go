@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSLHDSA(t *testing.T) {
+	if SupportsSLHDSA("SLH-DSA-MD5-128f") {
+		t.Error("unknown parameter set reported as supported")
+	}
+	if _, err := GenerateKeySLHDSA("SLH-DSA-MD5-128f"); err == nil {
+		t.Error("expected error for unknown parameter set")
+	}
+	msg := []byte("hello world")
+	context := []byte("go-crypto-openssl test")
+	// The fast parameter sets keep the test quick.
+	for _, params := range []string{SLHDSASHA2_128f, SLHDSASHAKE_128f} {
+		t.Run(params, func(t *testing.T) {
+			if !SupportsSLHDSA(params) {
+				t.Skip("SLH-DSA not supported")
+			}
+			priv, err := GenerateKeySLHDSA(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := priv.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := SignSLHDSA(priv, msg, context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifySLHDSA(pub, msg, sig, context); err != nil {
+				t.Error(err)
+			}
+			if err := VerifySLHDSA(pub, []byte("other message"), sig, context); err == nil {
+				t.Error("verification of a different message succeeded")
+			}
+
+			b, err := priv.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			priv2, err := NewPrivateKeySLHDSA(params, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub2, err := priv2.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b1, _ := pub.Bytes()
+			b2, _ := pub2.Bytes()
+			if !bytes.Equal(b1, b2) {
+				t.Error("parsed key has a different public key")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "errors"
+
+// SM2DefaultUID is the user identifier GM/T 0009-2012 recommends
+// when the signer's identifier is not known in advance.
+// It is used by SignSM2 and VerifySM2 when uid is nil.
+const SM2DefaultUID = "1234567812345678"
+
+// sm2PrivateKeySize is the size of an SM2 private scalar.
+const sm2PrivateKeySize = 32
+
+// PrivateKeySM2 is an SM2 private key, as specified in GB/T 32918.
+type PrivateKeySM2 struct {
+	pkey *PKey
+}
+
+// PublicKeySM2 is an SM2 public key.
+type PublicKeySM2 struct {
+	pkey *PKey
+}
+
+// GenerateKeySM2 generates an SM2 private key.
+//
+// SM2 is only supported on OpenSSL 3 and is not available in FIPS mode
+// nor in strict FIPS mode, see SetStrictFIPS.
+func GenerateKeySM2() (*PrivateKeySM2, error) {
+	k, err := GenerateKey("SM2", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeySM2{k}, nil
+}
+
+// NewPrivateKeySM2 returns the SM2 private key with the 32-byte big-endian scalar d.
+func NewPrivateKeySM2(d []byte) (*PrivateKeySM2, error) {
+	if len(d) != sm2PrivateKeySize {
+		return nil, errors.New("openssl: invalid SM2 private key size")
+	}
+	k, err := newSM2Key(d, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKeySM2{k}, nil
+}
+
+// NewPublicKeySM2 parses an SM2 public key encoded as an uncompressed point.
+func NewPublicKeySM2(b []byte) (*PublicKeySM2, error) {
+	if len(b) != 1+2*sm2PrivateKeySize || b[0] != 4 {
+		return nil, errors.New("openssl: invalid SM2 public key")
+	}
+	k, err := newSM2Key(nil, b)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeySM2{k}, nil
+}
+
+// newSM2Key imports a key on the SM2 curve from either the private
+// scalar d or the encoded public point pub.
+func newSM2Key(d, pub []byte) (*PKey, error) {
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	if d != nil {
+		var err error
+		if pub, err = sm2PublicPoint(d); err != nil {
+			return nil, err
+		}
+	}
+	bld, err := newParamBuilder()
+	if err != nil {
+		return nil, err
+	}
+	defer bld.free()
+	bld.addUTF8String("group", "SM2")
+	bld.addOctetString("pub", pub)
+	if d != nil {
+		bld.addSecretBN("priv", d)
+	}
+	return newPKeyFromData("SM2", d != nil, bld)
+}
+
+// sm2PublicPoint returns the uncompressed public point of the private scalar d.
+// The SM2 signature algorithm hashes the public key, so it must be
+// imported together with d.
+func sm2PublicPoint(d []byte) ([]byte, error) {
+	key := C.go_openssl_EC_KEY_new_by_curve_name(C.GO_NID_sm2)
+	if key == nil {
+		return nil, newOpenSSLError("EC_KEY_new_by_curve_name")
+	}
+	defer C.go_openssl_EC_KEY_free(key)
+	bd := secretBytesToBN(d)
+	if bd == nil {
+		return nil, newOpenSSLError("BN_bin2bn")
+	}
+	defer C.go_openssl_BN_clear_free(bd)
+	group := C.go_openssl_EC_KEY_get0_group(key)
+	pt := C.go_openssl_EC_POINT_new(group)
+	if pt == nil {
+		return nil, newOpenSSLError("EC_POINT_new")
+	}
+	defer C.go_openssl_EC_POINT_free(pt)
+	if C.go_openssl_EC_POINT_mul(group, pt, bd, nil, nil, nil) == 0 {
+		return nil, newOpenSSLError("EC_POINT_mul")
+	}
+	pub := make([]byte, 1+2*sm2PrivateKeySize)
+	if C.go_openssl_EC_POINT_point2oct(group, pt, C.GO_POINT_CONVERSION_UNCOMPRESSED, base(pub), C.size_t(len(pub)), nil) != C.size_t(len(pub)) {
+		return nil, newOpenSSLError("EC_POINT_point2oct")
+	}
+	return pub, nil
+}
+
+// Bytes returns the private scalar of priv as 32 big-endian bytes.
+func (priv *PrivateKeySM2) Bytes() ([]byte, error) {
+	out := make([]byte, sm2PrivateKeySize)
+	if priv.pkey.withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		key := C.go_openssl_EVP_PKEY_get1_EC_KEY(pkey)
+		if key == nil {
+			return 0
+		}
+		defer C.go_openssl_EC_KEY_free(key)
+		return C.go_openssl_BN_bn2binpad(C.go_openssl_EC_KEY_get0_private_key(key), base(out), C.int(len(out)))
+	}) != C.int(len(out)) {
+		return nil, newOpenSSLError("EVP_PKEY_get1_EC_KEY")
+	}
+	return out, nil
+}
+
+// PublicKey returns the public key corresponding to priv.
+func (priv *PrivateKeySM2) PublicKey() (*PublicKeySM2, error) {
+	b, err := priv.pkey.octetParam("pub")
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKeySM2(b)
+}
+
+// Bytes returns the public key encoded as an uncompressed point.
+func (pub *PublicKeySM2) Bytes() ([]byte, error) {
+	return pub.pkey.octetParam("pub")
+}
+
+// sm2Params returns the parameters binding a signature to uid.
+func sm2Params(uid []byte) (*C.OSSL_PARAM, error) {
+	if uid == nil {
+		uid = []byte(SM2DefaultUID)
+	}
+	if len(uid) > 0xFFFF/8 {
+		return nil, errors.New("openssl: SM2 user identifier too long")
+	}
+	bld, err := newParamBuilder()
+	if err != nil {
+		return nil, err
+	}
+	defer bld.free()
+	bld.addOctetString("distid", uid)
+	return bld.build()
+}
+
+// SignSM2 signs msg with priv and returns an ASN.1 DER encoded signature.
+// As specified by SM2, msg is hashed with SM3 together with the
+// value ZA, which binds the signature to the signer's public key
+// and user identifier uid. If uid is nil, SM2DefaultUID is used.
+func SignSM2(priv *PrivateKeySM2, msg, uid []byte) ([]byte, error) {
+	params, err := sm2Params(uid)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	return priv.pkey.sign(msg, "SM3", params)
+}
+
+// VerifySM2 verifies that sig is a valid SM2 signature of msg by pub
+// and the user identifier uid. If uid is nil, SM2DefaultUID is used.
+func VerifySM2(pub *PublicKeySM2, msg, sig, uid []byte) error {
+	params, err := sm2Params(uid)
+	if err != nil {
+		return err
+	}
+	defer C.go_openssl_OSSL_PARAM_free(params)
+	return pub.pkey.verify(msg, sig, "SM3", params)
+}
+
+// EncryptSM2 encrypts msg for pub with the SM2 public key encryption
+// algorithm, using SM3 as hash function. The ciphertext is the
+// ASN.1 DER encoding specified in GM/T 0009-2012.
+func EncryptSM2(pub *PublicKeySM2, msg []byte) ([]byte, error) {
+	return pub.pkey.Encrypt(msg, nil)
+}
+
+// DecryptSM2 decrypts a ciphertext produced by EncryptSM2.
+func DecryptSM2(priv *PrivateKeySM2, ciphertext []byte) ([]byte, error) {
+	return priv.pkey.crypt(ciphertext, nil, false)
+}
+
+// crypt encrypts or decrypts in, with params set on the operation.
+func (k *PKey) crypt(in []byte, params map[string]interface{}, encrypt bool) ([]byte, error) {
+	ctx, err := k.newPKeyCtx()
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_EVP_PKEY_CTX_free(ctx)
+	name := "EVP_PKEY_decrypt"
+	if encrypt {
+		name = "EVP_PKEY_encrypt"
+	}
+	crypt := func(out *C.uchar, outLen *C.size_t) C.int {
+		if encrypt {
+			return C.go_openssl_EVP_PKEY_encrypt(ctx, out, outLen, base(in), C.size_t(len(in)))
+		}
+		return C.go_openssl_EVP_PKEY_decrypt(ctx, out, outLen, base(in), C.size_t(len(in)))
+	}
+	var ret C.int
+	if encrypt {
+		ret = C.go_openssl_EVP_PKEY_encrypt_init(ctx)
+	} else {
+		ret = C.go_openssl_EVP_PKEY_decrypt_init(ctx)
+	}
+	if ret != 1 {
+		return nil, newOpenSSLError(name + "_init")
+	}
+	if err := setPKeyCtxParams(ctx, params); err != nil {
+		return nil, err
+	}
+	var outLen C.size_t
+	if crypt(nil, &outLen) != 1 {
+		return nil, newOpenSSLError(name)
+	}
+	out := make([]byte, outLen)
+	if crypt(base(out), &outLen) != 1 {
+		return nil, newOpenSSLError(name)
+	}
+	return out[:outLen], nil
+}
+
+// Close frees the native key of priv, see InitOptions.ExplicitClose.
+func (priv *PrivateKeySM2) Close() error { return priv.pkey.Close() }
+
+// Close frees the native key of pub, see InitOptions.ExplicitClose.
+func (pub *PublicKeySM2) Close() error { return pub.pkey.Close() }
@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSM2(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("SM2 is only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("SM2 is not available in FIPS mode")
+	}
+	priv, err := GenerateKeySM2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := priv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello world")
+	uid := []byte("alice@example.com")
+	sig, err := SignSM2(priv, msg, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySM2(pub, msg, sig, uid); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySM2(pub, msg, sig, nil); err == nil {
+		t.Error("verification with a different user identifier succeeded")
+	}
+	sig, err = SignSM2(priv, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySM2(pub, msg, sig, []byte(SM2DefaultUID)); err != nil {
+		t.Error(err)
+	}
+
+	ct, err := EncryptSM2(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := DecryptSM2(priv, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pt, msg) {
+		t.Errorf("got %q, want %q", pt, msg)
+	}
+	ct[len(ct)-1] ^= 1
+	if _, err := DecryptSM2(priv, ct); err == nil {
+		t.Error("decryption of a modified ciphertext succeeded")
+	}
+}
+
+func TestSM2Import(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("SM2 is only supported on OpenSSL 3")
+	}
+	if FIPS() {
+		t.Skip("SM2 is not available in FIPS mode")
+	}
+	priv, err := GenerateKeySM2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := priv.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv2, err := NewPrivateKeySM2(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := priv.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pub.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := NewPublicKeySM2(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := priv2.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b2, _ := p2.Bytes(); !bytes.Equal(b, b2) {
+		t.Errorf("imported private key has public key %x, want %x", b2, b)
+	}
+	msg := []byte("hello world")
+	sig, err := SignSM2(priv2, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySM2(pub2, msg, sig, nil); err != nil {
+		t.Error(err)
+	}
+	if err := VerifySM2(pub, msg, sig, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewPublicKeySM2(b[1:]); err == nil {
+		t.Error("expected error for short public key")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto/cipher"
+	"errors"
+	"runtime"
+
+	"github.com/microsoft/go-crypto-openssl/openssl/internal/subtle"
+)
+
+const (
+	sm4BlockSize = 16
+	sm4KeySize   = 16
+)
+
+// SM4 shares the AES block size, so it reuses the AES mode
+// implementations, which only depend on the cipher context.
+type sm4Cipher struct {
+	key     []byte
+	enc_ctx C.GO_EVP_CIPHER_CTX_PTR
+	dec_ctx C.GO_EVP_CIPHER_CTX_PTR
+	// enc_pooled and dec_pooled own enc_ctx and dec_ctx.
+	enc_pooled *pooledCtx
+	dec_pooled *pooledCtx
+	cipher     C.GO_EVP_CIPHER_PTR
+	closer
+}
+
+var _ extraModes = (*sm4Cipher)(nil)
+
+// SupportsSM4 reports whether the SM4 block cipher is available.
+// OpenSSL can be built without it, and it is never available
+// in FIPS mode.
+func SupportsSM4() bool {
+	if vMajor != 3 {
+		return false
+	}
+	c, err := (*LibraryContext)(nil).fetchCipher("SM4-ECB")
+	if err != nil {
+		return false
+	}
+	C.go_openssl_EVP_CIPHER_free(c)
+	return true
+}
+
+// NewSM4Cipher creates and returns a new cipher.Block implementing
+// the SM4 block cipher specified in GB/T 32907-2016. The key must be 16 bytes.
+// The returned block also implements the CBC, CTR and GCM modes
+// in the same way as NewAESCipher does.
+//
+// SM4 is only supported on OpenSSL 3, see SupportsSM4,
+// and is rejected in strict FIPS mode.
+func NewSM4Cipher(key []byte) (cipher.Block, error) {
+	if len(key) != sm4KeySize {
+		return nil, errors.New("crypto/cipher: Invalid key size")
+	}
+	if vMajor != 3 {
+		return nil, errUnsuportedVersion()
+	}
+	c := &sm4Cipher{key: append([]byte(nil), key...)}
+	var err error
+	if c.cipher, err = (*LibraryContext)(nil).fetchCipher("SM4-ECB"); err != nil {
+		return nil, err
+	}
+	manage(c, (*sm4Cipher).finalize)
+	return c, nil
+}
+
+func (c *sm4Cipher) finalize() {
+	c.enc_pooled.put()
+	c.dec_pooled.put()
+	c.enc_ctx, c.dec_ctx, c.enc_pooled, c.dec_pooled = nil, nil, nil, nil
+	C.go_openssl_EVP_CIPHER_free(c.cipher)
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *sm4Cipher) Close() error {
+	return closeObject(c, c.finalize)
+}
+
+// newModeCtx returns a cipher context for SM4 in the given mode.
+func (c *sm4Cipher) newModeCtx(mode string, enc C.int, iv []byte) (C.GO_EVP_CIPHER_CTX_PTR, *pooledCtx, error) {
+	cipher, err := (*LibraryContext)(nil).fetchCipher("SM4-" + mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The cipher context holds its own reference to cipher.
+	defer C.go_openssl_EVP_CIPHER_free(cipher)
+	return newCipherCtx(cipher, enc, c.key, iv)
+}
+
+func (c *sm4Cipher) BlockSize() int { return sm4BlockSize }
+
+func (c *sm4Cipher) Encrypt(dst, src []byte) {
+	c.checkOpen()
+	if subtle.InexactOverlap(dst, src) {
+		panic("crypto/cipher: invalid buffer overlap")
+	}
+	if len(src) < sm4BlockSize {
+		panic("crypto/sm4: input not full block")
+	}
+	if len(dst) < sm4BlockSize {
+		panic("crypto/sm4: output not full block")
+	}
+	if c.enc_ctx == nil {
+		var err error
+		c.enc_ctx, c.enc_pooled, err = newCipherCtx(c.cipher, C.GO_AES_ENCRYPT, c.key, nil)
+		if err != nil {
+			panic(err)
+		}
+	}
+	C.go_openssl_EVP_EncryptUpdate_wrapper(c.enc_ctx, base(dst), base(src), sm4BlockSize)
+	runtime.KeepAlive(c)
+}
+
+func (c *sm4Cipher) Decrypt(dst, src []byte) {
+	c.checkOpen()
+	if subtle.InexactOverlap(dst, src) {
+		panic("crypto/cipher: invalid buffer overlap")
+	}
+	if len(src) < sm4BlockSize {
+		panic("crypto/sm4: input not full block")
+	}
+	if len(dst) < sm4BlockSize {
+		panic("crypto/sm4: output not full block")
+	}
+	if c.dec_ctx == nil {
+		var err error
+		c.dec_ctx, c.dec_pooled, err = newCipherCtx(c.cipher, C.GO_AES_DECRYPT, c.key, nil)
+		if err != nil {
+			panic(err)
+		}
+		// Disable standard block padding detection,
+		// src is always multiple of the block size.
+		if C.go_openssl_EVP_CIPHER_CTX_set_padding(c.dec_ctx, 0) != 1 {
+			panic("crypto/cipher: unable to set padding")
+		}
+	}
+	C.go_openssl_EVP_DecryptUpdate_wrapper(c.dec_ctx, base(dst), base(src), sm4BlockSize)
+	runtime.KeepAlive(c)
+}
+
+func (c *sm4Cipher) NewCBCEncrypter(iv []byte) cipher.BlockMode {
+	x := &aesCBC{}
+	var err error
+	x.ctx, x.pooled, err = c.newModeCtx("CBC", C.GO_AES_ENCRYPT, iv)
+	if err != nil {
+		panic(err)
+	}
+	manage(x, (*aesCBC).finalize)
+	return x
+}
+
+func (c *sm4Cipher) NewCBCDecrypter(iv []byte) cipher.BlockMode {
+	x := &aesCBC{}
+	var err error
+	x.ctx, x.pooled, err = c.newModeCtx("CBC", C.GO_AES_DECRYPT, iv)
+	if err != nil {
+		panic(err)
+	}
+	if C.go_openssl_EVP_CIPHER_CTX_set_padding(x.ctx, 0) != 1 {
+		panic("cipher: unable to set padding")
+	}
+	manage(x, (*aesCBC).finalize)
+	return x
+}
+
+func (c *sm4Cipher) NewCTR(iv []byte) cipher.Stream {
+	x := &aesCTR{}
+	var err error
+	x.ctx, x.pooled, err = c.newModeCtx("CTR", C.GO_AES_ENCRYPT, iv)
+	if err != nil {
+		panic(err)
+	}
+	manage(x, (*aesCTR).finalize)
+	return x
+}
+
+func (c *sm4Cipher) NewGCM(nonceSize, tagSize int) (cipher.AEAD, error) {
+	if nonceSize != gcmStandardNonceSize && tagSize != gcmTagSize {
+		return nil, errors.New("crypto/sm4: GCM tag and nonce sizes can't be non-standard at the same time")
+	}
+	// Fall back to standard library for GCM with non-standard nonce or tag size.
+	if nonceSize != gcmStandardNonceSize {
+		return cipher.NewGCMWithNonceSize(&noGCM{c}, nonceSize)
+	}
+	if tagSize != gcmTagSize {
+		return cipher.NewGCMWithTagSize(&noGCM{c}, tagSize)
+	}
+	return c.newGCM(cipherGCMTLSNone)
+}
+
+// NewGCMTLS returns a GCM cipher specific to TLS 1.2
+// and should not be used for non-TLS purposes.
+func (c *sm4Cipher) NewGCMTLS() (cipher.AEAD, error) {
+	return c.newGCM(cipherGCMTLS12)
+}
+
+func (c *sm4Cipher) newGCM(tls cipherGCMTLS) (cipher.AEAD, error) {
+	ctx, pooled, err := c.newModeCtx("GCM", -1, nil)
+	if err != nil {
+		// Older OpenSSL 3 releases implement SM4 but not SM4-GCM,
+		// in which case the standard library GCM mode is used on
+		// top of the SM4 block cipher. It doesn't implement the
+		// TLS nonce checks, so those modes require SM4-GCM.
+		if tls != cipherGCMTLSNone {
+			return nil, err
+		}
+		C.go_openssl_ERR_clear_error()
+		return cipher.NewGCMWithNonceSize(&noGCM{c}, gcmStandardNonceSize)
+	}
+	g := &aesGCM{ctx: ctx, pooled: pooled, tls: tls}
+	manage(g, (*aesGCM).finalize)
+	return g, nil
+}
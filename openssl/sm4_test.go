@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func TestSM4(t *testing.T) {
+	if !SupportsSM4() {
+		t.Skip("SM4 not supported")
+	}
+	// GB/T 32907-2016, Appendix A.1.
+	key := decodeHex(t, "0123456789abcdeffedcba9876543210")
+	block, err := NewSM4Cipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := make([]byte, 16)
+	block.Encrypt(ct, key)
+	if want := decodeHex(t, "681edf34d206965e86b3e94f536e4246"); !bytes.Equal(ct, want) {
+		t.Errorf("got %x, want %x", ct, want)
+	}
+	pt := make([]byte, 16)
+	block.Decrypt(pt, ct)
+	if !bytes.Equal(pt, key) {
+		t.Errorf("got %x, want %x", pt, key)
+	}
+	if _, err := NewSM4Cipher(key[1:]); err == nil {
+		t.Error("expected error for invalid key size")
+	}
+}
+
+func TestSM4Modes(t *testing.T) {
+	if !SupportsSM4() {
+		t.Skip("SM4 not supported")
+	}
+	key := decodeHex(t, "0123456789abcdeffedcba9876543210")
+	iv := decodeHex(t, "000102030405060708090a0b0c0d0e0f")
+	block, err := NewSM4Cipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The standard library implements the modes on top of
+	// the bare block cipher, which serves as a reference.
+	ref := &noGCM{block}
+	msg := bytes.Repeat([]byte("0123456789abcdef"), 5)
+
+	got := make([]byte, len(msg))
+	want := make([]byte, len(msg))
+	block.(extraModes).NewCBCEncrypter(iv).CryptBlocks(got, msg)
+	cipher.NewCBCEncrypter(ref, iv).CryptBlocks(want, msg)
+	if !bytes.Equal(got, want) {
+		t.Errorf("CBC: got %x, want %x", got, want)
+	}
+	block.(extraModes).NewCBCDecrypter(iv).CryptBlocks(got, want)
+	if !bytes.Equal(got, msg) {
+		t.Errorf("CBC decrypt: got %x, want %x", got, msg)
+	}
+
+	got = make([]byte, len(msg)-3)
+	want = make([]byte, len(msg)-3)
+	block.(extraModes).NewCTR(iv).XORKeyStream(got, msg[3:])
+	cipher.NewCTR(ref, iv).XORKeyStream(want, msg[3:])
+	if !bytes.Equal(got, want) {
+		t.Errorf("CTR: got %x, want %x", got, want)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refGCM, err := cipher.NewGCM(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := iv[:12]
+	aad := []byte("additional data")
+	sealed := gcm.Seal(nil, nonce, msg, aad)
+	if want := refGCM.Seal(nil, nonce, msg, aad); !bytes.Equal(sealed, want) {
+		t.Errorf("GCM: got %x, want %x", sealed, want)
+	}
+	opened, err := gcm.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, msg) {
+		t.Errorf("GCM open: got %x, want %x", opened, msg)
+	}
+	sealed[0] ^= 1
+	if _, err := gcm.Open(nil, nonce, sealed, aad); err == nil {
+		t.Error("GCM: opened a modified ciphertext")
+	}
+
+	// RFC 8998, Appendix A.1.
+	sealed = gcm.Seal(nil, decodeHex(t, "00001234567800000000abcd"),
+		decodeHex(t, "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccddddddddddddddddeeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa"),
+		decodeHex(t, "feedfacedeadbeeffeedfacedeadbeefabaddad2"))
+	want = decodeHex(t, "17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d"+
+		"83de3541e4c2b58177e065a9bf7b62ec")
+	if !bytes.Equal(sealed, want) {
+		t.Errorf("GCM: got %x, want %x", sealed, want)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"strconv"
+	"unsafe"
+)
+
+// SRP implements the SRP-6a password authenticated key exchange,
+// as profiled by RFC 5054 with SHA-1 as hash function.
+// It is only intended to support existing SRP deployments.
+// SRP is not FIPS approved and is rejected in strict FIPS mode.
+
+// srpPrivateKeySize is the size of the random SRP private values,
+// as recommended by RFC 5054, Section 2.5.4.
+const srpPrivateKeySize = 32
+
+// srpSaltSize is the size of the salts generated by NewSRPVerifier.
+const srpSaltSize = 16
+
+// SRPGroup is an SRP group, consisting of the safe prime N and
+// the generator g, both encoded as big-endian bytes.
+type SRPGroup struct {
+	N, G []byte
+}
+
+// SRPDefaultGroup returns the group of RFC 5054, Appendix A, with
+// a prime N of the given size in bits: 1024, 1536, 2048, 3072, 4096,
+// 6144 or 8192.
+func SRPDefaultGroup(bits int) (*SRPGroup, error) {
+	id := C.CString(strconv.Itoa(bits))
+	defer C.free(unsafe.Pointer(id))
+	gN := C.go_openssl_SRP_get_default_gN(id)
+	if gN == nil {
+		return nil, errors.New("openssl: unknown SRP group size")
+	}
+	var g, n C.GO_BIGNUM_PTR
+	C.go_openssl_SRP_gN_get0(gN, &g, &n)
+	if g == nil || n == nil {
+		return nil, errors.New("openssl: invalid SRP group")
+	}
+	return &SRPGroup{N: bnToBytes(n), G: bnToBytes(g)}, nil
+}
+
+// bnToBytes returns the minimal big-endian encoding of bn.
+func bnToBytes(bn C.GO_BIGNUM_PTR) []byte {
+	out := make([]byte, (C.go_openssl_BN_num_bits(bn)+7)/8)
+	C.go_openssl_BN_bn2bin(bn, base(out))
+	return out
+}
+
+// srpBNs holds the bignums used by an SRP computation,
+// so that they can all be freed at once.
+type srpBNs []C.GO_BIGNUM_PTR
+
+// add converts b to a bignum. Bignums converted from empty slices are nil.
+func (s *srpBNs) add(b []byte) C.GO_BIGNUM_PTR {
+	return s.track(bytesToBN(b))
+}
+
+func (s *srpBNs) track(bn C.GO_BIGNUM_PTR) C.GO_BIGNUM_PTR {
+	if bn != nil {
+		*s = append(*s, bn)
+	}
+	return bn
+}
+
+func (s srpBNs) free() {
+	for _, bn := range s {
+		C.go_openssl_BN_clear_free(bn)
+	}
+}
+
+func (g *SRPGroup) check() error {
+	if g == nil || len(g.N) == 0 || len(g.G) == 0 {
+		return errors.New("openssl: invalid SRP group")
+	}
+	if StrictFIPS() {
+		return notApprovedError("SRP")
+	}
+	return nil
+}
+
+// NewSRPVerifier computes the password verifier of username and password
+// to be stored by the server, together with the salt. If salt is nil,
+// a random salt is generated.
+func NewSRPVerifier(group *SRPGroup, username, password string, salt []byte) (outSalt, verifier []byte, err error) {
+	if err := group.check(); err != nil {
+		return nil, nil, err
+	}
+	if salt == nil {
+		salt = make([]byte, srpSaltSize)
+		if _, err := RandReader.Read(salt); err != nil {
+			return nil, nil, err
+		}
+	} else if len(salt) == 0 {
+		return nil, nil, errors.New("openssl: empty SRP salt")
+	}
+	var bns srpBNs
+	defer bns.free()
+	n, g, s := bns.add(group.N), bns.add(group.G), bns.add(salt)
+	if n == nil || g == nil || s == nil {
+		return nil, nil, newOpenSSLError("BN_bin2bn")
+	}
+	cpass, err := secretCStringFromString(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer freeSecretCString(cpass)
+	cuser := C.CString(username)
+	defer C.free(unsafe.Pointer(cuser))
+	var v C.GO_BIGNUM_PTR
+	if C.go_openssl_SRP_create_verifier_BN(cuser, cpass, &s, &v, n, g) != 1 {
+		return nil, nil, newOpenSSLError("SRP_create_verifier_BN")
+	}
+	bns.track(v)
+	return salt, bnToBytes(v), nil
+}
+
+// SRPServer is the server side of an SRP exchange.
+type SRPServer struct {
+	group    SRPGroup
+	verifier []byte
+	b, pub   []byte
+}
+
+// NewSRPServer starts an SRP exchange for the user with the given
+// verifier, as computed by NewSRPVerifier.
+func NewSRPServer(group *SRPGroup, verifier []byte) (*SRPServer, error) {
+	b := make([]byte, srpPrivateKeySize)
+	if _, err := RandReader.Read(b); err != nil {
+		return nil, err
+	}
+	return newSRPServer(group, verifier, b)
+}
+
+func newSRPServer(group *SRPGroup, verifier, b []byte) (*SRPServer, error) {
+	if err := group.check(); err != nil {
+		return nil, err
+	}
+	if len(verifier) == 0 {
+		return nil, errors.New("openssl: invalid SRP verifier")
+	}
+	var bns srpBNs
+	defer bns.free()
+	pub := bns.track(C.go_openssl_SRP_Calc_B(bns.add(b), bns.add(group.N), bns.add(group.G), bns.add(verifier)))
+	if pub == nil {
+		return nil, newOpenSSLError("SRP_Calc_B")
+	}
+	return &SRPServer{group: *group, verifier: verifier, b: b, pub: bnToBytes(pub)}, nil
+}
+
+// PublicKey returns the server public value B to be sent to the client.
+func (s *SRPServer) PublicKey() []byte {
+	return append([]byte(nil), s.pub...)
+}
+
+// PremasterSecret returns the premaster secret S shared with the
+// client that sent the public value clientPublic. It returns an
+// error if clientPublic is invalid.
+func (s *SRPServer) PremasterSecret(clientPublic []byte) ([]byte, error) {
+	var bns srpBNs
+	defer bns.free()
+	n, a, pub := bns.add(s.group.N), bns.add(clientPublic), bns.add(s.pub)
+	if a == nil || C.go_openssl_SRP_Verify_A_mod_N(a, n) != 1 {
+		C.go_openssl_ERR_clear_error()
+		return nil, errors.New("openssl: invalid SRP client public value")
+	}
+	u := bns.track(C.go_openssl_SRP_Calc_u(a, pub, n))
+	if u == nil {
+		return nil, newOpenSSLError("SRP_Calc_u")
+	}
+	key := bns.track(C.go_openssl_SRP_Calc_server_key(a, bns.add(s.verifier), u, bns.add(s.b), n))
+	if key == nil {
+		return nil, newOpenSSLError("SRP_Calc_server_key")
+	}
+	return bnToBytes(key), nil
+}
+
+// SRPClient is the client side of an SRP exchange.
+type SRPClient struct {
+	group              SRPGroup
+	username, password string
+	a, pub             []byte
+}
+
+// NewSRPClient starts an SRP exchange authenticating username with password.
+func NewSRPClient(group *SRPGroup, username, password string) (*SRPClient, error) {
+	a := make([]byte, srpPrivateKeySize)
+	if _, err := RandReader.Read(a); err != nil {
+		return nil, err
+	}
+	return newSRPClient(group, username, password, a)
+}
+
+func newSRPClient(group *SRPGroup, username, password string, a []byte) (*SRPClient, error) {
+	if err := group.check(); err != nil {
+		return nil, err
+	}
+	var bns srpBNs
+	defer bns.free()
+	pub := bns.track(C.go_openssl_SRP_Calc_A(bns.add(a), bns.add(group.N), bns.add(group.G)))
+	if pub == nil {
+		return nil, newOpenSSLError("SRP_Calc_A")
+	}
+	return &SRPClient{group: *group, username: username, password: password, a: a, pub: bnToBytes(pub)}, nil
+}
+
+// PublicKey returns the client public value A to be sent to the server.
+func (c *SRPClient) PublicKey() []byte {
+	return append([]byte(nil), c.pub...)
+}
+
+// PremasterSecret returns the premaster secret S shared with the server
+// that sent salt and the public value serverPublic. It returns an error
+// if serverPublic is invalid.
+func (c *SRPClient) PremasterSecret(salt, serverPublic []byte) ([]byte, error) {
+	if len(salt) == 0 {
+		return nil, errors.New("openssl: empty SRP salt")
+	}
+	var bns srpBNs
+	defer bns.free()
+	n, b, pub := bns.add(c.group.N), bns.add(serverPublic), bns.add(c.pub)
+	if b == nil || C.go_openssl_SRP_Verify_B_mod_N(b, n) != 1 {
+		C.go_openssl_ERR_clear_error()
+		return nil, errors.New("openssl: invalid SRP server public value")
+	}
+	u := bns.track(C.go_openssl_SRP_Calc_u(pub, b, n))
+	if u == nil {
+		return nil, newOpenSSLError("SRP_Calc_u")
+	}
+	cpass, err := secretCStringFromString(c.password)
+	if err != nil {
+		return nil, err
+	}
+	defer freeSecretCString(cpass)
+	cuser := C.CString(c.username)
+	defer C.free(unsafe.Pointer(cuser))
+	x := bns.track(C.go_openssl_SRP_Calc_x(bns.add(salt), cuser, cpass))
+	if x == nil {
+		return nil, newOpenSSLError("SRP_Calc_x")
+	}
+	key := bns.track(C.go_openssl_SRP_Calc_client_key(n, b, bns.add(c.group.G), x, bns.add(c.a), u))
+	if key == nil {
+		return nil, newOpenSSLError("SRP_Calc_client_key")
+	}
+	return bnToBytes(key), nil
+}
+
+// SRPClientProof returns the client evidence message
+// M1 = H(H(N) XOR H(g) | H(username) | salt | A | B | K),
+// where K is the session key derived from the premaster secret,
+// usually H(S). H is SHA-1.
+func SRPClientProof(group *SRPGroup, username string, salt, clientPublic, serverPublic, key []byte) ([]byte, error) {
+	if err := group.check(); err != nil {
+		return nil, err
+	}
+	hn, hg := SHA1(group.N), SHA1(group.G)
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	hu := SHA1([]byte(username))
+	h := NewSHA1()
+	defer closeAll(h)
+	h.Write(hn[:])
+	h.Write(hu[:])
+	h.Write(salt)
+	h.Write(clientPublic)
+	h.Write(serverPublic)
+	h.Write(key)
+	return h.Sum(nil), nil
+}
+
+// SRPServerProof returns the server evidence message M2 = H(A | M1 | K),
+// where clientProof is the M1 value computed by SRPClientProof.
+func SRPServerProof(clientPublic, clientProof, key []byte) []byte {
+	h := NewSHA1()
+	defer closeAll(h)
+	h.Write(clientPublic)
+	h.Write(clientProof)
+	h.Write(key)
+	return h.Sum(nil)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"math/big"
+	"testing"
+)
+
+// srpReference computes the SRP-6a values of RFC 5054 with math/big.
+type srpReference struct {
+	n, g *big.Int
+}
+
+func (r srpReference) hash(pad bool, values ...[]byte) *big.Int {
+	h := sha1.New()
+	for _, v := range values {
+		if pad {
+			v = append(make([]byte, len(r.n.Bytes())-len(v)), v...)
+		}
+		h.Write(v)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func (r srpReference) x(salt []byte, username, password string) *big.Int {
+	inner := sha1.Sum([]byte(username + ":" + password))
+	return r.hash(false, salt, inner[:])
+}
+
+func TestSRP(t *testing.T) {
+	group, err := SRPDefaultGroup(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.N) != 128 || !bytes.Equal(group.G, []byte{2}) {
+		t.Fatalf("unexpected group: N of %d bytes, g = %x", len(group.N), group.G)
+	}
+	if _, err := SRPDefaultGroup(1000); err == nil {
+		t.Error("expected error for an unknown group")
+	}
+	ref := srpReference{new(big.Int).SetBytes(group.N), new(big.Int).SetBytes(group.G)}
+
+	// The identity and secret values of RFC 5054, Appendix B.
+	const username, password = "alice", "password123"
+	salt := decodeHex(t, "beb25379d1a8581eb5a727673a2441ee")
+	a := decodeHex(t, "60975527035cf2ad1989806f0407210bc81edc04e2762a56afd529ddda2d4393")
+	b := decodeHex(t, "e487cb59d31ac550471e81f00f6928e01dda08e974a004f49e61f5d105284d20")
+
+	_, v, err := NewSRPVerifier(group, username, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := ref.x(salt, username, password)
+	if want := new(big.Int).Exp(ref.g, x, ref.n); !bytes.Equal(v, want.Bytes()) {
+		t.Errorf("verifier: got %x, want %x", v, want)
+	}
+
+	client, err := newSRPClient(group, username, password, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := newSRPServer(group, v, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A, B := client.PublicKey(), server.PublicKey()
+	if want := new(big.Int).Exp(ref.g, new(big.Int).SetBytes(a), ref.n); !bytes.Equal(A, want.Bytes()) {
+		t.Errorf("A: got %x, want %x", A, want)
+	}
+	k := ref.hash(true, group.N, group.G)
+	wantB := new(big.Int).Mul(k, new(big.Int).SetBytes(v))
+	wantB.Add(wantB, new(big.Int).Exp(ref.g, new(big.Int).SetBytes(b), ref.n))
+	wantB.Mod(wantB, ref.n)
+	if !bytes.Equal(B, wantB.Bytes()) {
+		t.Errorf("B: got %x, want %x", B, wantB)
+	}
+
+	clientS, err := client.PremasterSecret(salt, B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverS, err := server.PremasterSecret(A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := ref.hash(true, A, B)
+	wantS := new(big.Int).Exp(new(big.Int).SetBytes(v), u, ref.n)
+	wantS.Mul(wantS, new(big.Int).SetBytes(A))
+	wantS.Exp(wantS, new(big.Int).SetBytes(b), ref.n)
+	if !bytes.Equal(serverS, wantS.Bytes()) {
+		t.Errorf("server premaster secret: got %x, want %x", serverS, wantS)
+	}
+	if !bytes.Equal(clientS, serverS) {
+		t.Errorf("client premaster secret %x doesn't match the server one %x", clientS, serverS)
+	}
+
+	K := sha1.Sum(clientS)
+	m1, err := SRPClientProof(group, username, salt, A, B, K[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hn, hg, hu := sha1.Sum(group.N), sha1.Sum(group.G), sha1.Sum([]byte(username))
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	wantM1 := ref.hash(false, hn[:], hu[:], salt, A, B, K[:]).FillBytes(make([]byte, sha1.Size))
+	if !bytes.Equal(m1, wantM1) {
+		t.Errorf("M1: got %x, want %x", m1, wantM1)
+	}
+	m2 := SRPServerProof(A, m1, K[:])
+	if wantM2 := ref.hash(false, A, m1, K[:]).FillBytes(make([]byte, sha1.Size)); !bytes.Equal(m2, wantM2) {
+		t.Errorf("M2: got %x, want %x", m2, wantM2)
+	}
+
+	if _, err := server.PremasterSecret(group.N); err == nil {
+		t.Error("expected error for A = N")
+	}
+	if _, err := client.PremasterSecret(salt, make([]byte, 10)); err == nil {
+		t.Error("expected error for B = 0")
+	}
+}
+
+func TestSRPRandom(t *testing.T) {
+	group, err := SRPDefaultGroup(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, v, err := NewSRPVerifier(group, "bob", "secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewSRPClient(group, "bob", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewSRPServer(group, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientS, err := client.PremasterSecret(salt, server.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverS, err := server.PremasterSecret(client.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(clientS, serverS) {
+		t.Error("premaster secrets don't match")
+	}
+
+	wrong, err := NewSRPClient(group, "bob", "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongS, err := wrong.PremasterSecret(salt, server.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverS, err = server.PremasterSecret(wrong.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(wrongS, serverS) {
+		t.Error("premaster secrets match with a wrong password")
+	}
+}
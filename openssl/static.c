@@ -0,0 +1,119 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux && !android && openssl_static
+// +build linux,!android,openssl_static
+
+// This file resolves the OpenSSL functions of a libcrypto linked at build
+// time, see the openssl_static build tag. The functions of
+// FOR_ALL_OPENSSL_FUNCTIONS are referenced directly, so the linker pulls
+// them in, and looked up by name in place of dlsym.
+// Only the functions of the OpenSSL version being linked are referenced,
+// which requires the OpenSSL headers to determine it.
+
+#include <openssl/opensslv.h>
+#include <string.h>
+
+#include "openssl_funcs.h"
+
+#if OPENSSL_VERSION_NUMBER >= 0x30000000L
+#define GO_IF_3_0(...) __VA_ARGS__
+#define GO_IF_1(...)
+#else
+#define GO_IF_3_0(...)
+#define GO_IF_1(...) __VA_ARGS__
+#endif
+#if OPENSSL_VERSION_NUMBER >= 0x30400000L
+#define GO_IF_3_4(...) __VA_ARGS__
+#else
+#define GO_IF_3_4(...)
+#endif
+#if OPENSSL_VERSION_NUMBER >= 0x10100000L
+#define GO_IF_1_1(...) __VA_ARGS__
+#define GO_IF_1_0(...)
+#else
+#define GO_IF_1_1(...)
+#define GO_IF_1_0(...) __VA_ARGS__
+#endif
+
+// The functions are declared with a dummy signature, as only their
+// addresses are taken and the OpenSSL headers declaring them aren't included.
+#define GO_DECLARE(func) extern void func(void);
+#define DEFINEFUNC(ret, func, args, argscall) GO_DECLARE(func)
+#define DEFINEFUNC_LEGACY_1_0(ret, func, args, argscall) GO_IF_1_0(GO_DECLARE(func))
+#define DEFINEFUNC_LEGACY_1(ret, func, args, argscall) GO_IF_1(GO_DECLARE(func))
+#define DEFINEFUNC_1_1(ret, func, args, argscall) GO_IF_1_1(GO_DECLARE(func))
+#define DEFINEFUNC_3_0(ret, func, args, argscall) GO_IF_3_0(GO_DECLARE(func))
+#define DEFINEFUNC_3_4(ret, func, args, argscall) GO_IF_3_4(GO_DECLARE(func))
+#define DEFINEFUNC_RENAMED_1_1(ret, func, oldfunc, args, argscall) GO_IF_1_0(GO_DECLARE(oldfunc)) GO_IF_1_1(GO_DECLARE(func))
+#define DEFINEFUNC_RENAMED_3_0(ret, func, oldfunc, args, argscall) GO_IF_1(GO_DECLARE(oldfunc)) GO_IF_3_0(GO_DECLARE(func))
+
+FOR_ALL_OPENSSL_FUNCTIONS
+
+// Functions used to detect the OpenSSL version and FIPS mode,
+// see goopenssl.c.
+GO_IF_3_0(GO_DECLARE(OPENSSL_version_major) GO_DECLARE(OPENSSL_version_minor))
+GO_IF_1_1(GO_DECLARE(OpenSSL_version_num))
+GO_IF_1_0(GO_DECLARE(SSLeay))
+
+#undef GO_DECLARE
+#undef DEFINEFUNC
+#undef DEFINEFUNC_LEGACY_1_0
+#undef DEFINEFUNC_LEGACY_1
+#undef DEFINEFUNC_1_1
+#undef DEFINEFUNC_3_0
+#undef DEFINEFUNC_3_4
+#undef DEFINEFUNC_RENAMED_1_1
+#undef DEFINEFUNC_RENAMED_3_0
+
+static const struct {
+    const char *name;
+    void (*func)(void);
+} go_openssl_static_funcs[] = {
+#define GO_ENTRY(func) {#func, func},
+#define DEFINEFUNC(ret, func, args, argscall) GO_ENTRY(func)
+#define DEFINEFUNC_LEGACY_1_0(ret, func, args, argscall) GO_IF_1_0(GO_ENTRY(func))
+#define DEFINEFUNC_LEGACY_1(ret, func, args, argscall) GO_IF_1(GO_ENTRY(func))
+#define DEFINEFUNC_1_1(ret, func, args, argscall) GO_IF_1_1(GO_ENTRY(func))
+#define DEFINEFUNC_3_0(ret, func, args, argscall) GO_IF_3_0(GO_ENTRY(func))
+#define DEFINEFUNC_3_4(ret, func, args, argscall) GO_IF_3_4(GO_ENTRY(func))
+#define DEFINEFUNC_RENAMED_1_1(ret, func, oldfunc, args, argscall) GO_IF_1_0(GO_ENTRY(oldfunc)) GO_IF_1_1(GO_ENTRY(func))
+#define DEFINEFUNC_RENAMED_3_0(ret, func, oldfunc, args, argscall) GO_IF_1(GO_ENTRY(oldfunc)) GO_IF_3_0(GO_ENTRY(func))
+FOR_ALL_OPENSSL_FUNCTIONS
+GO_IF_3_0(GO_ENTRY(OPENSSL_version_major) GO_ENTRY(OPENSSL_version_minor))
+GO_IF_1_1(GO_ENTRY(OpenSSL_version_num))
+GO_IF_1_0(GO_ENTRY(SSLeay))
+#undef GO_ENTRY
+#undef DEFINEFUNC
+#undef DEFINEFUNC_LEGACY_1_0
+#undef DEFINEFUNC_LEGACY_1
+#undef DEFINEFUNC_1_1
+#undef DEFINEFUNC_3_0
+#undef DEFINEFUNC_3_4
+#undef DEFINEFUNC_RENAMED_1_1
+#undef DEFINEFUNC_RENAMED_3_0
+};
+
+// go_openssl_static_handle returns the handle passed to
+// go_openssl_static_dlsym. Only its address is relevant.
+void*
+go_openssl_static_handle(void)
+{
+    static int handle;
+    return &handle;
+}
+
+// go_openssl_static_dlsym replaces dlsym when libcrypto
+// is linked at build time. handle is ignored.
+void*
+go_openssl_static_dlsym(void* handle, const char* name)
+{
+    (void)handle;
+    size_t i;
+    for (i = 0; i < sizeof(go_openssl_static_funcs)/sizeof(go_openssl_static_funcs[0]); i++)
+    {
+        if (strcmp(go_openssl_static_funcs[i].name, name) == 0)
+            return (void*)go_openssl_static_funcs[i].func;
+    }
+    return NULL;
+}
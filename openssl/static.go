@@ -0,0 +1,25 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux && !android && openssl_static
+// +build linux,!android,openssl_static
+
+package openssl
+
+// #cgo CFLAGS: -DGO_OPENSSL_STATIC
+// #cgo LDFLAGS: -l:libcrypto.a -lpthread
+// void* go_openssl_static_handle(void);
+import "C"
+import "unsafe"
+
+// staticLibrary returns the handle of the libcrypto linked at build time.
+//
+// With the openssl_static build tag, libcrypto is linked into the program
+// instead of being loaded by Init, which ignores the library selection
+// options and environment variables. Building requires the OpenSSL headers
+// and the libcrypto.a archive of the linked version; use CGO_CFLAGS and
+// CGO_LDFLAGS to point to them, and -ldflags=-extldflags=-static for
+// a fully static binary.
+func staticLibrary() (unsafe.Pointer, bool) {
+	return C.go_openssl_static_handle(), true
+}
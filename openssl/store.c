@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+#include "goopenssl.h"
+
+// Defined in store.go.
+extern int goStorePassphraseCallback(char *buf, int size, uintptr_t handle);
+
+static int
+store_passphrase_cb(char *buf, int size, int rwflag, void *u)
+{
+    return goStorePassphraseCallback(buf, size, (uintptr_t)u);
+}
+
+// go_openssl_store_ui_method returns a UI_METHOD reading passphrases
+// with goStorePassphraseCallback, which receives the ui_data given to
+// OSSL_STORE_open_ex. It never prompts on the terminal.
+GO_UI_METHOD_PTR go_openssl_store_ui_method(void)
+{
+    return go_openssl_UI_UTIL_wrap_read_pem_callback(store_passphrase_cb, 0);
+}
+
+// go_openssl_store_open opens uri in libctx with ui_method, which
+// receives handle, a cgo.Handle or 0, as its ui_data.
+GO_OSSL_STORE_CTX_PTR go_openssl_store_open(const char *uri, GO_OSSL_LIB_CTX_PTR libctx, const char *propq, GO_UI_METHOD_PTR ui_method, uintptr_t handle)
+{
+    return go_openssl_OSSL_STORE_open_ex(uri, libctx, propq, ui_method, (void *)handle, NULL, NULL, NULL);
+}
@@ -0,0 +1,219 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"runtime"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// PassphraseFunc returns the passphrase protecting an object of a store,
+// such as an encrypted private key or the PIN of a PKCS #11 token.
+// It is called once per protected object, so it can prompt the user.
+type PassphraseFunc func() ([]byte, error)
+
+var (
+	storeUIMethodOnce sync.Once
+	// storeUIMethod is the UI_METHOD passed to OSSL_STORE_open,
+	// see go_openssl_store_ui_method. It is never freed.
+	storeUIMethod C.GO_UI_METHOD_PTR
+)
+
+// storeLoad holds the passphrase callback of an OSSL_STORE_open call
+// and the error it returned, if any.
+type storeLoad struct {
+	passphrase PassphraseFunc
+	err        error
+}
+
+//export goStorePassphraseCallback
+func goStorePassphraseCallback(buf *C.char, size C.int, h C.uintptr_t) C.int {
+	if h == 0 {
+		return -1
+	}
+	l := cgo.Handle(h).Value().(*storeLoad)
+	pass, err := l.passphrase()
+	if err != nil {
+		l.err = err
+		return -1
+	}
+	if len(pass) > int(size) {
+		l.err = errors.New("openssl: store passphrase too long")
+		return -1
+	}
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(buf)), size), pass)
+	return C.int(len(pass))
+}
+
+// LoadKey loads the first private key of the object at uri through
+// the OSSL_STORE API. uri is either a "file:" URI or a path, whose
+// PEM, DER or PKCS #12 content is decoded, or a URI handled by a
+// provider, such as a "pkcs11:" URI with pkcs11-provider. Keys of
+// hardware stores stay in the hardware, which performs the private
+// key operations.
+//
+// It returns the private key types described in Engine.PrivateKey.
+// Protected objects fail to load, use LoadKeyWithPassphrase for them.
+//
+// LoadKey is only supported on OpenSSL 3.
+func LoadKey(uri string) (interface{}, error) {
+	return LoadKeyWithPassphrase(uri, nil)
+}
+
+// LoadKeyWithPassphrase is like LoadKey but calls passphrase
+// for the passphrase of the protected objects at uri.
+//
+// LoadKeyWithPassphrase is only supported on OpenSSL 3.
+func LoadKeyWithPassphrase(uri string, passphrase PassphraseFunc) (interface{}, error) {
+	return loadKey(nil, uri, passphrase)
+}
+
+// LoadKey is like the package-level LoadKey but opens uri in l,
+// whose property query selects the provider handling uri and
+// the key, such as "?provider=pkcs11".
+func (l *LibraryContext) LoadKey(uri string) (interface{}, error) {
+	return loadKey(l, uri, nil)
+}
+
+// LoadKeyWithPassphrase is like the package-level
+// LoadKeyWithPassphrase but opens uri in l.
+func (l *LibraryContext) LoadKeyWithPassphrase(uri string, passphrase PassphraseFunc) (interface{}, error) {
+	return loadKey(l, uri, passphrase)
+}
+
+func loadKey(lib *LibraryContext, uri string, passphrase PassphraseFunc) (interface{}, error) {
+	var pkey C.GO_EVP_PKEY_PTR
+	err := storeLoadObject(lib, uri, passphrase, C.GO_OSSL_STORE_INFO_PKEY, func(info C.GO_OSSL_STORE_INFO_PTR) error {
+		if pkey = C.go_openssl_OSSL_STORE_INFO_get1_PKEY(info); pkey == nil {
+			return newOpenSSLError("OSSL_STORE_INFO_get1_PKEY")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return privateKeyFromPKey(lib, pkey)
+}
+
+// LoadCert loads the first certificate of the object at uri
+// through the OSSL_STORE API, as described in LoadKey.
+//
+// LoadCert is only supported on OpenSSL 3.
+func LoadCert(uri string) (*Certificate, error) {
+	return LoadCertWithPassphrase(uri, nil)
+}
+
+// LoadCertWithPassphrase is like LoadCert but calls passphrase
+// for the passphrase of the protected objects at uri.
+//
+// LoadCertWithPassphrase is only supported on OpenSSL 3.
+func LoadCertWithPassphrase(uri string, passphrase PassphraseFunc) (*Certificate, error) {
+	return loadCert(nil, uri, passphrase)
+}
+
+// LoadCert is like the package-level LoadCert but opens uri in l.
+func (l *LibraryContext) LoadCert(uri string) (*Certificate, error) {
+	return loadCert(l, uri, nil)
+}
+
+// LoadCertWithPassphrase is like the package-level
+// LoadCertWithPassphrase but opens uri in l.
+func (l *LibraryContext) LoadCertWithPassphrase(uri string, passphrase PassphraseFunc) (*Certificate, error) {
+	return loadCert(l, uri, passphrase)
+}
+
+func loadCert(lib *LibraryContext, uri string, passphrase PassphraseFunc) (*Certificate, error) {
+	var x C.GO_X509_PTR
+	err := storeLoadObject(lib, uri, passphrase, C.GO_OSSL_STORE_INFO_CERT, func(info C.GO_OSSL_STORE_INFO_PTR) error {
+		if x = C.go_openssl_OSSL_STORE_INFO_get1_CERT(info); x == nil {
+			return newOpenSSLError("OSSL_STORE_INFO_get1_CERT")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := marshalX509(x)
+	if err != nil {
+		C.go_openssl_X509_free(x)
+		return nil, err
+	}
+	return newCertificate(x, raw), nil
+}
+
+// storeLoadObject opens the store at uri in lib and calls get
+// with the first object of type typ, which get must not keep.
+func storeLoadObject(lib *LibraryContext, uri string, passphrase PassphraseFunc, typ C.int, get func(C.GO_OSSL_STORE_INFO_PTR) error) error {
+	if vMajor != 3 {
+		return errUnsuportedVersion()
+	}
+	storeUIMethodOnce.Do(func() {
+		storeUIMethod = C.go_openssl_store_ui_method()
+	})
+	if storeUIMethod == nil {
+		return newOpenSSLError("UI_UTIL_wrap_read_pem_callback")
+	}
+	var h C.uintptr_t
+	l := &storeLoad{passphrase: passphrase}
+	if passphrase != nil {
+		handle := cgo.NewHandle(l)
+		defer handle.Delete()
+		h = C.uintptr_t(handle)
+	}
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+	defer runtime.KeepAlive(lib)
+	ctx := C.go_openssl_store_open(curi, lib.ptr(), lib.propq(), storeUIMethod, h)
+	if ctx == nil {
+		return storeError(l, "OSSL_STORE_open("+uri+")")
+	}
+	defer C.go_openssl_OSSL_STORE_close(ctx)
+	// Let the loaders skip the other objects when they can,
+	// the type is checked below anyway.
+	C.go_openssl_OSSL_STORE_expect(ctx, typ)
+	var loadErr error
+	for C.go_openssl_OSSL_STORE_eof(ctx) != 1 {
+		info := C.go_openssl_OSSL_STORE_load(ctx)
+		if info == nil {
+			if C.go_openssl_OSSL_STORE_error(ctx) == 1 {
+				// As "openssl storeutl", skip the objects that fail to
+				// load, such as those not of the expected type, and
+				// report the error only if nothing matches.
+				if loadErr = storeError(l, "OSSL_STORE_load("+uri+")"); l.err != nil {
+					return loadErr
+				}
+			}
+			continue
+		}
+		if C.go_openssl_OSSL_STORE_INFO_get_type(info) == typ {
+			err := get(info)
+			C.go_openssl_OSSL_STORE_INFO_free(info)
+			return err
+		}
+		C.go_openssl_OSSL_STORE_INFO_free(info)
+	}
+	if loadErr != nil {
+		return loadErr
+	}
+	C.go_openssl_ERR_clear_error()
+	return errors.New("openssl: no matching object at " + uri)
+}
+
+// storeError returns the error of the passphrase callback of l,
+// if any, or the OpenSSL error of msg.
+func storeError(l *storeLoad, msg string) error {
+	if l.err != nil {
+		C.go_openssl_ERR_clear_error()
+		return l.err
+	}
+	return newOpenSSLError(msg)
+}
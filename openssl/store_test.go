@@ -0,0 +1,140 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKey(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("OSSL_STORE is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	password := []byte("password")
+	data, err := MarshalPEMPrivateKey(k, password, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, append(data, certificatePEM...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	key, err := LoadKeyWithPassphrase("file:"+path, func() ([]byte, error) {
+		calls++
+		return password, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("passphrase called %d times, want 1", calls)
+	}
+	priv, ok := key.(*PrivateKeyECDSA)
+	if !ok {
+		t.Fatalf("got %T, want *PrivateKeyECDSA", key)
+	}
+	msg := []byte("hello world")
+	hash := sha256.Sum256(msg)
+	sig, err := SignMarshalECDSA(priv, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(msg, sig, "SHA256"); err != nil {
+		t.Errorf("signature by the loaded key doesn't verify: %v", err)
+	}
+
+	if _, err := LoadKey(path); err == nil {
+		t.Error("loaded an encrypted key without passphrase")
+	}
+	if _, err := LoadKeyWithPassphrase(path, func() ([]byte, error) { return []byte("wrong"), nil }); err == nil {
+		t.Error("loaded an encrypted key with a wrong passphrase")
+	}
+	errPass := errors.New("no passphrase")
+	if _, err := LoadKeyWithPassphrase(path, func() ([]byte, error) { return nil, errPass }); err != errPass {
+		t.Errorf("got error %v, want the passphrase error", err)
+	}
+	if _, err := LoadKey(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
+
+func TestLoadCert(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("OSSL_STORE is only supported on OpenSSL 3")
+	}
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, []byte(certificatePEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := LoadCert(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, err := cert.Subject(); err != nil || subject != "CN=leaf" {
+		t.Errorf("Subject() = %q, %v, want CN=leaf", subject, err)
+	}
+	if _, err := LoadKey(path); err == nil {
+		t.Error("loaded a key from a file without keys")
+	}
+}
+
+func TestLibraryContextLoadKey(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("OSSL_STORE is only supported on OpenSSL 3")
+	}
+	k, err := GenerateKey("ED25519", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalPEMPrivateKey(k, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, append(data, certificatePEM...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := DefaultLibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultLib := lib.WithProperties("?provider=default")
+	key, err := defaultLib.LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, ok := key.(*PKey)
+	if !ok {
+		t.Fatalf("got %T, want *PKey", key)
+	}
+	msg := []byte("hello world")
+	sig, err := priv.Sign(msg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(msg, sig, ""); err != nil {
+		t.Errorf("signature by the loaded key doesn't verify: %v", err)
+	}
+	// The key before the certificate fails to load as a certificate,
+	// the certificate must still be found.
+	if _, err := defaultLib.LoadCert(path); err != nil {
+		t.Error(err)
+	}
+	// No provider matches, so nothing can be decoded.
+	if _, err := lib.WithProperties("provider=no-such-provider").LoadKey(path); err == nil {
+		t.Error("loaded a key without matching provider")
+	}
+}
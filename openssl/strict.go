@@ -0,0 +1,265 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrNotApproved is wrapped by the errors returned by operations
+// rejected because strict FIPS mode is enabled.
+var ErrNotApproved = errors.New("not FIPS approved")
+
+// strictFIPS is non-zero when strict FIPS mode is enabled.
+var strictFIPS int32
+
+// SetStrictFIPS enables or disables strict FIPS mode.
+//
+// In strict FIPS mode, this package rejects operations that are not
+// approved by FIPS 140-3 and SP 800-131A, even if the underlying
+// OpenSSL provider would perform them:
+//   - the hashes other than SHA-1, SHA-2 and SHA-3, such as MD5, MD4, SM3
+//     or RIPEMD160, in signatures, in NewHash and the NewHash and NewHMAC
+//     methods of LibraryContext, and as the "digest" parameter of NewKDF,
+//     such as for HKDF, TLS1-PRF or PBKDF2,
+//   - the ciphers fetched by name other than AES, such as SM4 or the
+//     ciphers of encrypted PEM and PKCS #8 keys, and loading the legacy
+//     provider on demand for them,
+//   - signing with SHA-1,
+//   - generating, signing or encrypting with RSA keys shorter than 2048 bits,
+//   - generating or using P-224 private keys,
+//   - generating, importing or using a PKey of a type other than RSA,
+//     EC on P-256, P-384 or P-521, DH of at least 2048 bits, EdDSA,
+//     ML-KEM, ML-DSA or SLH-DSA, such as SM2, GOST and X25519 keys,
+//     including the X25519 half of X25519MLKEM768,
+//   - ChaCha20-Poly1305 and DHKEM(X25519) in HPKE, SRP, and
+//     LoadGOSTProvider.
+//
+// The rejected operations return an error wrapping ErrNotApproved.
+// Strict FIPS mode is independent of FIPS and SetFIPS.
+func SetStrictFIPS(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&strictFIPS, v)
+}
+
+// StrictFIPS reports whether strict FIPS mode is enabled.
+func StrictFIPS() bool {
+	return atomic.LoadInt32(&strictFIPS) != 0
+}
+
+type notApprovedError string
+
+func (e notApprovedError) Error() string {
+	return "openssl: " + string(e) + " is " + ErrNotApproved.Error()
+}
+
+func (e notApprovedError) Unwrap() error { return ErrNotApproved }
+
+// minStrictRSABits is the smallest RSA modulus size approved
+// for generation, signing and encryption.
+const minStrictRSABits = 2048
+
+// strictHashes are the hashes approved in strict FIPS mode,
+// SHA-1 is only approved when not signing.
+var strictHashes = map[crypto.Hash]bool{
+	crypto.SHA1:       true,
+	crypto.SHA224:     true,
+	crypto.SHA256:     true,
+	crypto.SHA384:     true,
+	crypto.SHA512:     true,
+	crypto.SHA512_224: true,
+	crypto.SHA512_256: true,
+	crypto.SHA3_224:   true,
+	crypto.SHA3_256:   true,
+	crypto.SHA3_384:   true,
+	crypto.SHA3_512:   true,
+}
+
+// checkStrictHash returns an error if h can't be used in strict FIPS mode.
+// A zero h, for digests signed as is, is accepted.
+func checkStrictHash(h crypto.Hash, signing bool) error {
+	if !StrictFIPS() || h == 0 {
+		return nil
+	}
+	if !strictHashes[h] {
+		return notApprovedError(h.String())
+	}
+	if h == crypto.SHA1 && signing {
+		return notApprovedError("signing with SHA-1")
+	}
+	return nil
+}
+
+// strictDigests are the OpenSSL names of the digests approved
+// in strict FIPS mode, as returned by EVP_MD_get0_name.
+var strictDigests = map[string]bool{
+	"SHA1":         true,
+	"SHA2-224":     true,
+	"SHA2-256":     true,
+	"SHA2-384":     true,
+	"SHA2-512":     true,
+	"SHA2-512/224": true,
+	"SHA2-512/256": true,
+	"SHA3-224":     true,
+	"SHA3-256":     true,
+	"SHA3-384":     true,
+	"SHA3-512":     true,
+	"SHAKE-128":    true,
+	"SHAKE-256":    true,
+}
+
+// checkStrictMD is like checkStrictHash for md, fetched by name.
+// The digests not in strictDigests, such as MD5, MD4, SM3 or
+// RIPEMD160, are rejected.
+func checkStrictMD(md C.GO_EVP_MD_PTR) error {
+	if !StrictFIPS() {
+		return nil
+	}
+	if name := C.GoString(C.go_openssl_EVP_MD_get0_name(md)); !strictDigests[name] {
+		return notApprovedError("digest " + name)
+	}
+	return nil
+}
+
+// strictCiphers are the OpenSSL names of the ciphers approved
+// in strict FIPS mode, as returned by EVP_CIPHER_get0_name.
+var strictCiphers = func() map[string]bool {
+	m := map[string]bool{"AES-128-XTS": true, "AES-256-XTS": true}
+	modes := [...]string{"ECB", "CBC", "CBC-CTS", "OFB", "CFB", "CFB1", "CFB8", "CTR", "CCM", "GCM", "WRAP", "WRAP-PAD", "WRAP-INV", "WRAP-PAD-INV"}
+	for _, bits := range [...]string{"128", "192", "256"} {
+		for _, mode := range modes {
+			m["AES-"+bits+"-"+mode] = true
+		}
+	}
+	return m
+}()
+
+// checkStrictCipher returns an error if cipher, fetched by name,
+// can't be used in strict FIPS mode. Only the AES modes of
+// strictCiphers are accepted.
+func checkStrictCipher(cipher C.GO_EVP_CIPHER_PTR) error {
+	if !StrictFIPS() {
+		return nil
+	}
+	if name := C.GoString(C.go_openssl_EVP_CIPHER_get0_name(cipher)); !strictCiphers[name] {
+		return notApprovedError("cipher " + name)
+	}
+	return nil
+}
+
+// checkStrictDigest is like checkStrictMD for the digest named name,
+// fetched from l. Unknown digests are left to the caller to report.
+func (l *LibraryContext) checkStrictDigest(name string) error {
+	if !StrictFIPS() {
+		return nil
+	}
+	if l == nil {
+		l = defaultLibraryContext
+	}
+	md, err := l.fetchMD(name)
+	if err != nil {
+		return nil
+	}
+	defer C.go_openssl_EVP_MD_free(md)
+	return checkStrictMD(md)
+}
+
+// checkStrictCurve returns an error if private keys on curve
+// can't be used in strict FIPS mode.
+func checkStrictCurve(curve string) error {
+	if StrictFIPS() && curve != "P-256" && curve != "P-384" && curve != "P-521" {
+		return notApprovedError("curve " + curve)
+	}
+	return nil
+}
+
+// checkStrictRSABits returns an error if RSA keys with the given modulus size
+// can't be used for generation, signing or encryption in strict FIPS mode.
+func checkStrictRSABits(bits int) error {
+	if StrictFIPS() && bits < minStrictRSABits {
+		return notApprovedError(strconv.Itoa(bits) + "-bit RSA key")
+	}
+	return nil
+}
+
+// checkStrictRSAKey is like checkStrictRSABits but reads the modulus size
+// from the key, which must be an RSA key.
+func checkStrictRSAKey(withKey withKeyFunc) error {
+	if !StrictFIPS() {
+		return nil
+	}
+	var bits C.int
+	withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		bits = C.go_openssl_EVP_PKEY_get_bits(pkey)
+		return 1
+	})
+	return checkStrictRSABits(int(bits))
+}
+
+// strictKeyTypes are the key types of PKey approved in strict FIPS mode,
+// in upper case, as returned by EVP_PKEY_get0_type_name.
+var strictKeyTypes = func() map[string]bool {
+	m := map[string]bool{"RSA": true, "RSA-PSS": true, "EC": true, "DH": true, "DHX": true, "ED25519": true, "ED448": true}
+	for _, name := range [...]string{
+		MLKEM512, MLKEM768, MLKEM1024, MLDSA44, MLDSA65, MLDSA87,
+		SLHDSASHA2_128s, SLHDSASHA2_128f, SLHDSASHA2_192s, SLHDSASHA2_192f, SLHDSASHA2_256s, SLHDSASHA2_256f,
+		SLHDSASHAKE_128s, SLHDSASHAKE_128f, SLHDSASHAKE_192s, SLHDSASHAKE_192f, SLHDSASHAKE_256s, SLHDSASHAKE_256f,
+	} {
+		m[strings.ToUpper(name)] = true
+	}
+	return m
+}()
+
+// strictGroups are the OpenSSL names of the curves of checkStrictCurve.
+var strictGroups = map[string]bool{"prime256v1": true, "secp384r1": true, "secp521r1": true}
+
+// checkStrictPKey returns an error if the key of withKey, addressed by
+// name, can't be used in strict FIPS mode: its type must be in
+// strictKeyTypes, RSA and DH keys must have at least 2048 bits and
+// EC keys must be on a curve accepted by checkStrictCurve.
+func checkStrictPKey(withKey withKeyFunc) error {
+	if !StrictFIPS() {
+		return nil
+	}
+	var err error
+	withKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		name := C.GoString(C.go_openssl_EVP_PKEY_get0_type_name(pkey))
+		typ := strings.ToUpper(name)
+		if !strictKeyTypes[typ] {
+			err = notApprovedError("key type " + name)
+			return 1
+		}
+		switch typ {
+		case "RSA", "RSA-PSS":
+			err = checkStrictRSABits(int(C.go_openssl_EVP_PKEY_get_bits(pkey)))
+		case "DH", "DHX":
+			if bits := int(C.go_openssl_EVP_PKEY_get_bits(pkey)); bits < minStrictRSABits {
+				err = notApprovedError(strconv.Itoa(bits) + "-bit DH key")
+			}
+		case "EC":
+			var buf [64]C.char
+			var n C.size_t
+			if C.go_openssl_EVP_PKEY_get_group_name(pkey, &buf[0], C.size_t(len(buf)), &n) != 1 {
+				C.go_openssl_ERR_clear_error()
+				err = notApprovedError("EC key without a named curve")
+			} else if group := C.GoString(&buf[0]); !strictGroups[group] {
+				err = notApprovedError("curve " + group)
+			}
+		}
+		return 1
+	})
+	return err
+}
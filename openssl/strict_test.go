@@ -0,0 +1,219 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"crypto"
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func TestStrictFIPS(t *testing.T) {
+	if vMajor == 1 && vMinor == 0 {
+		t.Skip("RSA keys of arbitrary size are not supported on OpenSSL 1.0.2")
+	}
+	N, E, D, P, Q, Dp, Dq, Qinv, err := GenerateKeyRSA(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKeyRSA(N, E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := make([]byte, 32)
+
+	SetStrictFIPS(true)
+	defer SetStrictFIPS(false)
+	if !StrictFIPS() {
+		t.Fatal("strict FIPS mode not enabled")
+	}
+	check := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrNotApproved) {
+			t.Errorf("%s: got %v, want ErrNotApproved", name, err)
+		}
+	}
+	_, _, _, _, _, _, _, _, err = GenerateKeyRSA(1024)
+	check("GenerateKeyRSA(1024)", err)
+	_, err = SignRSAPKCS1v15(priv, crypto.SHA256, hashed)
+	check("SignRSAPKCS1v15 with 1024-bit key", err)
+	_, err = EncryptRSAOAEP(NewSHA256(), pub, []byte("msg"), nil)
+	check("EncryptRSAOAEP with 1024-bit key", err)
+	check("VerifyRSAPKCS1v15 with MD5", VerifyRSAPKCS1v15(pub, crypto.MD5, make([]byte, 16), make([]byte, 128)))
+	_, _, _, err = GenerateKeyECDSA("P-224")
+	check("GenerateKeyECDSA(P-224)", err)
+	_, err = NewPrivateKeyECDH("P-224", make([]byte, 28))
+	check("NewPrivateKeyECDH(P-224)", err)
+
+	X, Y, D, err := GenerateKeyECDSA("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPriv, err := NewPrivateKeyECDSA("P-256", X, Y, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignMarshalECDSA(ecPriv, hashed); err != nil {
+		t.Fatal(err)
+	}
+	// Verifying existing SHA-1 signatures is still allowed.
+	if err := checkStrictHash(crypto.SHA1, false); err != nil {
+		t.Error(err)
+	}
+	check("signing with SHA-1", checkStrictHash(crypto.SHA1, true))
+}
+
+func TestStrictFIPSDigests(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("fetching digests by name is only supported on OpenSSL 3")
+	}
+	if _, err := NewHash("MD5"); err != nil {
+		t.Skip("MD5 is not available")
+	}
+	lib, err := DefaultLibraryContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetStrictFIPS(true)
+	defer SetStrictFIPS(false)
+	check := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrNotApproved) {
+			t.Errorf("%s: got %v, want ErrNotApproved", name, err)
+		}
+	}
+	_, err = NewHash("MD5")
+	check("NewHash(MD5)", err)
+	_, err = NewHash("md5-sha1")
+	check("NewHash(md5-sha1)", err)
+	_, err = lib.NewHash("SSL3-MD5")
+	check("LibraryContext.NewHash(SSL3-MD5)", err)
+	_, err = lib.NewHMAC("MD5", []byte("key"))
+	check("LibraryContext.NewHMAC(MD5)", err)
+	_, err = NewKDF("HKDF", map[string]interface{}{"digest": "MD5", "key": []byte("key")})
+	check("NewKDF(HKDF) with MD5", err)
+	_, err = NewKDF("TLS1-PRF", map[string]interface{}{"digest": "MD5-SHA1", "secret": []byte("secret")})
+	check("NewKDF(TLS1-PRF) with MD5-SHA1", err)
+	_, err = lib.NewKDF("PBKDF2", map[string]interface{}{"digest": "MD5", "pass": []byte("pass")})
+	check("LibraryContext.NewKDF(PBKDF2) with MD5", err)
+
+	// Approved digests are still accepted.
+	if _, err := NewHash("SHA256"); err != nil {
+		t.Error(err)
+	}
+	k, err := NewKDF("HKDF", map[string]interface{}{"digest": "SHA256", "key": []byte("key")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Close()
+}
+
+func TestStrictFIPSAllowlist(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("fetching algorithms by name is only supported on OpenSSL 3")
+	}
+	if !inFreshProcess(t) {
+		return
+	}
+	SetStrictFIPS(true)
+	defer SetStrictFIPS(false)
+	for _, name := range []string{"MD4", "SM3", "RIPEMD160", "BLAKE2S-256"} {
+		// Algorithms the providers lack are not supported instead.
+		if _, err := NewHash(name); err == nil {
+			t.Errorf("NewHash(%s) succeeded", name)
+		} else if !errors.Is(err, ErrNotApproved) && !errors.Is(err, ErrNotSupported) {
+			t.Errorf("NewHash(%s): got %v, want ErrNotApproved", name, err)
+		}
+	}
+	if _, ok := loadedProviders[legacyProvider]; ok {
+		t.Error("legacy provider loaded in strict FIPS mode")
+	}
+	if _, err := NewHash("SHA3-256"); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewHash("SHA512-256"); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewSM4Cipher(make([]byte, 16)); err == nil {
+		t.Error("NewSM4Cipher succeeded")
+	}
+	lib, err := NewLibraryContext("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lib.Close()
+	c, err := lib.NewAESCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cipher.NewGCM(c); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStrictFIPSKeyTypes(t *testing.T) {
+	if vMajor != 3 {
+		t.Skip("PKey is only supported on OpenSSL 3")
+	}
+	var sm2 *PrivateKeySM2
+	if supportsKeyType("SM2") {
+		var err error
+		if sm2, err = GenerateKeySM2(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetStrictFIPS(true)
+	defer SetStrictFIPS(false)
+	check := func(name string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrNotApproved) {
+			t.Errorf("%s: got %v, want ErrNotApproved", name, err)
+		}
+	}
+	_, err := GenerateKey("X25519", nil)
+	check("GenerateKey(X25519)", err)
+	_, err = NewPrivatePKey("X25519", make([]byte, 32))
+	check("NewPrivatePKey(X25519)", err)
+	_, err = GenerateKey("EC", map[string]interface{}{"group": "secp256k1"})
+	check("GenerateKey(EC secp256k1)", err)
+	_, err = GenerateKey("RSA", map[string]interface{}{"bits": 1024})
+	check("GenerateKey(1024-bit RSA)", err)
+	if sm2 != nil {
+		_, err = GenerateKeySM2()
+		check("GenerateKeySM2", err)
+		_, err = SignSM2(sm2, []byte("msg"), nil)
+		check("SignSM2", err)
+	}
+	_, err = GenerateKeyHPKE(HPKEDHKEMX25519)
+	check("GenerateKeyHPKE(X25519)", err)
+	_, _, err = NewSenderHPKE(HPKESuite{HPKEDHKEMP256, HPKEHKDFSHA256, HPKEChaCha20Poly1305}, make([]byte, 65), nil)
+	check("NewSenderHPKE(ChaCha20-Poly1305)", err)
+	_, err = newChaCha20Poly1305(make([]byte, chacha20Poly1305KeySize))
+	check("newChaCha20Poly1305", err)
+	_, _, err = NewSRPVerifier(&SRPGroup{N: []byte{23}, G: []byte{5}}, "user", "pass", nil)
+	check("NewSRPVerifier", err)
+	check("LoadGOSTProvider", LoadGOSTProvider(""))
+
+	k, err := GenerateKey("EC", map[string]interface{}{"group": "P-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Sign([]byte("msg"), "SHA256"); err != nil {
+		t.Error(err)
+	}
+	if _, err := k.Sign([]byte("msg"), "SM3"); err == nil {
+		t.Error("signed with SM3")
+	}
+	if _, err := GenerateKey("ED25519", nil); err != nil {
+		t.Error(err)
+	}
+}
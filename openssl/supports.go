@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import "unsafe"
+
+// The Supports functions report whether an algorithm is usable with the
+// loaded OpenSSL library and, on OpenSSL 3, the providers loaded in the
+// default library context, such as the FIPS provider.
+// They let callers select a fallback before the algorithm is needed.
+// Their result can change if providers are loaded or FIPS mode is toggled.
+
+// SupportsAESGCM reports whether AES-GCM is available
+// with 128, 192 and 256-bit keys.
+func SupportsAESGCM() bool {
+	if vMajor != 3 {
+		return C.go_openssl_EVP_aes_128_gcm() != nil &&
+			C.go_openssl_EVP_aes_192_gcm() != nil &&
+			C.go_openssl_EVP_aes_256_gcm() != nil
+	}
+	for _, name := range [...]string{"AES-128-GCM", "AES-192-GCM", "AES-256-GCM"} {
+		if !supportsCipher(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// supportsCipher reports whether the cipher name can be fetched
+// from the default library context. It is only supported on OpenSSL 3.
+func supportsCipher(name string) bool {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return probe(func() bool {
+		cipher := C.go_openssl_EVP_CIPHER_fetch(nil, cname, nil)
+		if cipher == nil {
+			return false
+		}
+		C.go_openssl_EVP_CIPHER_free(cipher)
+		return true
+	})
+}
+
+// SupportsCurve reports whether the NIST curve, "P-224", "P-256", "P-384"
+// or "P-521", is available for ECDSA and ECDH.
+func SupportsCurve(curve string) bool {
+	nid, err := curveNID(curve)
+	if err != nil {
+		return false
+	}
+	if !probe(func() bool {
+		key := C.go_openssl_EC_KEY_new_by_curve_name(nid)
+		if key == nil {
+			return false
+		}
+		C.go_openssl_EC_KEY_free(key)
+		return true
+	}) {
+		return false
+	}
+	if vMajor == 3 {
+		// EC_KEY_new_by_curve_name doesn't check the providers.
+		return supportsKeyType("EC")
+	}
+	return true
+}
+
+// SupportsEd25519 reports whether Ed25519 keys are available
+// through the PKey API, which is only supported on OpenSSL 3.
+func SupportsEd25519() bool {
+	return supportsKeyType("ED25519")
+}
+
+// SupportsX25519 reports whether X25519 keys are available
+// through the PKey API, which is only supported on OpenSSL 3.
+func SupportsX25519() bool {
+	return supportsKeyType("X25519")
+}
+
+// SupportsKDF reports whether the key derivation function name
+// is available to NewKDF. It is only supported on OpenSSL 3.
+func SupportsKDF(name string) bool {
+	if vMajor != 3 {
+		return false
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return probe(func() bool {
+		kdf := C.go_openssl_EVP_KDF_fetch(nil, cname, nil)
+		if kdf == nil {
+			return false
+		}
+		C.go_openssl_EVP_KDF_free(kdf)
+		return true
+	})
+}
+
+// SupportsTLS13KDF reports whether the TLS 1.3 key schedule KDF,
+// "TLS13-KDF", is available to NewKDF. It is only supported on OpenSSL 3.
+func SupportsTLS13KDF() bool {
+	return SupportsKDF("TLS13-KDF")
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "testing"
+
+func TestSupports(t *testing.T) {
+	if !SupportsAESGCM() {
+		t.Error("AES-GCM not supported")
+	}
+	for _, curve := range []string{"P-256", "P-384", "P-521"} {
+		if !SupportsCurve(curve) {
+			t.Errorf("%s not supported", curve)
+		}
+	}
+	if SupportsCurve("P-192") {
+		t.Error("P-192 reported as supported")
+	}
+	if SupportsKDF("not-a-kdf") {
+		t.Error("unknown KDF reported as supported")
+	}
+	if vMajor != 3 {
+		if SupportsEd25519() || SupportsTLS13KDF() {
+			t.Error("OpenSSL 3 algorithms reported as supported")
+		}
+		return
+	}
+	if !FIPS() {
+		if !SupportsEd25519() || !SupportsX25519() {
+			t.Error("Ed25519 or X25519 not supported")
+		}
+	}
+	if !SupportsTLS13KDF() {
+		t.Error("TLS13-KDF not supported")
+	}
+	if _, err := NewKDF("TLS13-KDF", nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+
+// ReleaseThreadState frees the OpenSSL state of the calling OS thread,
+// such as its error queue and, on OpenSSL 3, its random generators.
+// OpenSSL allocates this state on first use in each thread and doesn't
+// always free it when the thread exits, which leaks memory in processes
+// that keep creating threads, for example by exiting goroutines locked
+// to their thread with runtime.LockOSThread.
+//
+// ReleaseThreadState only makes sense from a goroutine locked to its
+// thread, typically deferred right after locking it:
+//
+//	runtime.LockOSThread()
+//	defer openssl.ReleaseThreadState()
+//
+// The thread can still use OpenSSL afterwards, which allocates new state.
+func ReleaseThreadState() {
+	if vMajor == 1 && vMinor == 0 {
+		C.go_openssl_ERR_remove_thread_state(nil)
+		return
+	}
+	C.go_openssl_OPENSSL_thread_stop()
+}
+
+// ReleaseThreadState is like the package-level ReleaseThreadState
+// but frees the state of the calling thread in l. The package-level
+// function only frees the state in the default library context.
+func (l *LibraryContext) ReleaseThreadState() {
+	if vMajor != 3 {
+		ReleaseThreadState()
+		return
+	}
+	C.go_openssl_OPENSSL_thread_stop_ex(l.ptr())
+}
@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestReleaseThreadState(t *testing.T) {
+	want := SHA256([]byte("thread"))
+	done := make(chan [32]byte)
+	go func() {
+		// The goroutine exits locked, which terminates its thread.
+		runtime.LockOSThread()
+		defer ReleaseThreadState()
+		SHA256([]byte("thread"))
+		if vMajor == 3 {
+			// Leave an error in the thread error queue.
+			if _, err := NewHash("not-a-hash"); err == nil {
+				t.Error("expected error for an unknown hash")
+			}
+		}
+		ReleaseThreadState()
+		// The thread can still use OpenSSL.
+		done <- SHA256([]byte("thread"))
+	}()
+	if got := <-done; got != want {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	if vMajor != 3 {
+		return
+	}
+	l, err := NewLibraryContext("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		runtime.LockOSThread()
+		defer l.ReleaseThreadState()
+		if h, err := l.NewHash("SHA256"); err != nil {
+			t.Error(err)
+		} else {
+			h.Write([]byte("thread"))
+		}
+		close(done)
+	}()
+	<-done
+}
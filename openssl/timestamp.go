@@ -0,0 +1,250 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"time"
+	"unsafe"
+)
+
+// TimestampRequestOptions configures CreateTimestampRequest.
+// The zero value uses the defaults documented on each field.
+type TimestampRequestOptions struct {
+	// Policy is the dotted OID of the TSA policy under which the
+	// timestamp must be issued. If empty, the TSA chooses the policy.
+	Policy string
+	// Nonce is the nonce the response must echo.
+	// If nil, a random 64-bit nonce is used.
+	Nonce BigInt
+	// CertReq asks the TSA to include its certificate in the response,
+	// which is then needed to verify it if opts.Intermediates and
+	// opts.Roots of VerifyTimestampResponse don't hold it.
+	CertReq bool
+}
+
+// CreateTimestampRequest returns the DER encoding of a timestamp
+// request for digest, the hash h of the data to timestamp, as defined
+// in RFC 3161. To timestamp a signature, digest is the hash of the
+// signature value.
+//
+// CreateTimestampRequest is not supported on OpenSSL 1.0.2.
+func CreateTimestampRequest(digest []byte, h crypto.Hash, opts *TimestampRequestOptions) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	md := cryptoHashToMD(h)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported timestamp hash")
+	}
+	if len(digest) != h.Size() {
+		return nil, errors.New("openssl: invalid timestamp digest length")
+	}
+	if opts == nil {
+		opts = &TimestampRequestOptions{}
+	}
+	req := C.go_openssl_TS_REQ_new()
+	if req == nil {
+		return nil, newOpenSSLError("TS_REQ_new")
+	}
+	defer C.go_openssl_TS_REQ_free(req)
+	if C.go_openssl_TS_REQ_set_version(req, 1) != 1 {
+		return nil, newOpenSSLError("TS_REQ_set_version")
+	}
+	if err := setTimestampImprint(req, md, digest); err != nil {
+		return nil, err
+	}
+	var bn C.GO_BIGNUM_PTR
+	if opts.Nonce != nil {
+		bn = bigToBN(opts.Nonce)
+	} else {
+		nonce := make([]byte, 8)
+		if _, err := RandReader.Read(nonce); err != nil {
+			return nil, err
+		}
+		bn = bytesToBN(nonce)
+	}
+	if bn == nil {
+		return nil, newOpenSSLError("BN_bin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	ai := C.go_openssl_BN_to_ASN1_INTEGER(bn, nil)
+	if ai == nil {
+		return nil, newOpenSSLError("BN_to_ASN1_INTEGER")
+	}
+	defer C.go_openssl_ASN1_INTEGER_free(ai)
+	// The TS_REQ setters copy their arguments.
+	if C.go_openssl_TS_REQ_set_nonce(req, ai) != 1 {
+		return nil, newOpenSSLError("TS_REQ_set_nonce")
+	}
+	if opts.CertReq {
+		if C.go_openssl_TS_REQ_set_cert_req(req, 1) != 1 {
+			return nil, newOpenSSLError("TS_REQ_set_cert_req")
+		}
+	}
+	if opts.Policy != "" {
+		cpolicy := C.CString(opts.Policy)
+		// no_name 1 only accepts the dotted form.
+		obj := C.go_openssl_OBJ_txt2obj(cpolicy, 1)
+		C.free(unsafe.Pointer(cpolicy))
+		if obj == nil {
+			return nil, errors.New("openssl: invalid timestamp policy " + opts.Policy)
+		}
+		defer C.go_openssl_ASN1_OBJECT_free(obj)
+		if C.go_openssl_TS_REQ_set_policy_id(req, obj) != 1 {
+			return nil, newOpenSSLError("TS_REQ_set_policy_id")
+		}
+	}
+	n := C.go_openssl_i2d_TS_REQ_buf(req, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_TS_REQ")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_TS_REQ_buf(req, base(der)) != n {
+		return nil, newOpenSSLError("i2d_TS_REQ")
+	}
+	return der, nil
+}
+
+// setTimestampImprint sets the message imprint of req to digest, hashed with md.
+func setTimestampImprint(req C.GO_TS_REQ_PTR, md C.GO_EVP_MD_PTR, digest []byte) error {
+	imprint := C.go_openssl_TS_MSG_IMPRINT_new()
+	if imprint == nil {
+		return newOpenSSLError("TS_MSG_IMPRINT_new")
+	}
+	defer C.go_openssl_TS_MSG_IMPRINT_free(imprint)
+	alg := C.go_openssl_X509_ALGOR_new()
+	if alg == nil {
+		return newOpenSSLError("X509_ALGOR_new")
+	}
+	defer C.go_openssl_X509_ALGOR_free(alg)
+	C.go_openssl_X509_ALGOR_set_md(alg, md)
+	if C.go_openssl_TS_MSG_IMPRINT_set_algo(imprint, alg) != 1 {
+		return newOpenSSLError("TS_MSG_IMPRINT_set_algo")
+	}
+	if C.go_openssl_TS_MSG_IMPRINT_set_msg(imprint, base(digest), C.int(len(digest))) != 1 {
+		return newOpenSSLError("TS_MSG_IMPRINT_set_msg")
+	}
+	if C.go_openssl_TS_REQ_set_msg_imprint(req, imprint) != 1 {
+		return newOpenSSLError("TS_REQ_set_msg_imprint")
+	}
+	return nil
+}
+
+// Timestamp is a verified RFC 3161 timestamp.
+type Timestamp struct {
+	// Time is the time at which the TSA created the timestamp.
+	Time time.Time
+	// SerialNumber is the serial number the TSA assigned to the timestamp.
+	SerialNumber BigInt
+	// Policy is the dotted OID of the TSA policy of the timestamp.
+	Policy string
+	// Token is the DER encoding of the timestamp token, a CMS SignedData,
+	// as embedded in the unsigned attributes of CMS signatures.
+	Token []byte
+}
+
+// VerifyTimestampResponse verifies resp, the DER encoding of the TSA
+// response to req, a request returned by CreateTimestampRequest, and
+// returns the timestamp it holds.
+//
+// The response must be granted, and its timestamp must match the
+// message imprint, the nonce and the policy, if any, of req. The TSA
+// certificate must verify with opts.Roots, opts.Intermediates and the
+// certificates of resp for the timestamping purpose, as described in
+// Certificate.Verify. Only the CurrentTime, AllowPartialChain, CRLs
+// and RevocationCheck fields of opts are used besides the certificates.
+//
+// VerifyTimestampResponse is not supported on OpenSSL 1.0.2.
+func VerifyTimestampResponse(resp, req []byte, opts VerifyOptions) (*Timestamp, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	if len(resp) == 0 || len(req) == 0 {
+		return nil, errors.New("openssl: empty timestamp message")
+	}
+	tsreq := C.go_openssl_d2i_TS_REQ_buf(base(req), C.long(len(req)))
+	if tsreq == nil {
+		return nil, newOpenSSLError("d2i_TS_REQ")
+	}
+	defer C.go_openssl_TS_REQ_free(tsreq)
+	tsresp := C.go_openssl_d2i_TS_RESP_buf(base(resp), C.long(len(resp)))
+	if tsresp == nil {
+		return nil, newOpenSSLError("d2i_TS_RESP")
+	}
+	defer C.go_openssl_TS_RESP_free(tsresp)
+	// TS_REQ_to_TS_VERIFY_CTX enables the checks of the
+	// imprint, the nonce and the policy of the request.
+	ctx := C.go_openssl_TS_REQ_to_TS_VERIFY_CTX(tsreq, nil)
+	if ctx == nil {
+		return nil, newOpenSSLError("TS_REQ_to_TS_VERIFY_CTX")
+	}
+	defer C.go_openssl_TS_VERIFY_CTX_free(ctx)
+	C.go_openssl_TS_VERIFY_CTX_add_flags(ctx, C.GO_TS_VFY_SIGNATURE)
+	store, err := newX509Store(&opts)
+	if err != nil {
+		return nil, err
+	}
+	// The verification context takes ownership of store and certs.
+	C.go_openssl_TS_VERIFY_CTX_set_store(ctx, store)
+	// TS_RESP_verify_response sets the timestamping purpose itself.
+	if err := setVerifyParams(C.go_openssl_X509_STORE_get0_param(store), &VerifyOptions{
+		CurrentTime:       opts.CurrentTime,
+		AllowPartialChain: opts.AllowPartialChain,
+		RevocationCheck:   opts.RevocationCheck,
+	}); err != nil {
+		return nil, err
+	}
+	certs, err := newX509Stack(opts.Intermediates)
+	if err != nil {
+		return nil, err
+	}
+	C.go_openssl_TS_VERIFY_CTX_set_certs(ctx, C.GO_STACK_OF_X509_PTR(certs.st))
+	if C.go_openssl_TS_RESP_verify_response(ctx, tsresp) != 1 {
+		return nil, newOpenSSLError("TS_RESP_verify_response")
+	}
+	info := C.go_openssl_TS_RESP_get_tst_info(tsresp)
+	if info == nil {
+		return nil, errors.New("openssl: timestamp response without token")
+	}
+	var ts Timestamp
+	if ts.Time, err = asn1TimeToTime(C.go_openssl_TS_TST_INFO_get_time(info)); err != nil {
+		return nil, err
+	}
+	bn := C.go_openssl_ASN1_INTEGER_to_BN(C.go_openssl_TS_TST_INFO_get_serial(info), nil)
+	if bn == nil {
+		return nil, newOpenSSLError("ASN1_INTEGER_to_BN")
+	}
+	ts.SerialNumber = bnToBig(bn)
+	C.go_openssl_BN_free(bn)
+	if ts.Policy, err = asn1ObjectString(C.go_openssl_TS_TST_INFO_get_policy_id(info)); err != nil {
+		return nil, err
+	}
+	token := C.go_openssl_TS_RESP_get_token(tsresp)
+	n := C.go_openssl_i2d_PKCS7_buf(token, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_PKCS7")
+	}
+	ts.Token = make([]byte, n)
+	if C.go_openssl_i2d_PKCS7_buf(token, base(ts.Token)) != n {
+		return nil, newOpenSSLError("i2d_PKCS7")
+	}
+	return &ts, nil
+}
+
+// asn1ObjectString returns the dotted form of obj.
+func asn1ObjectString(obj C.GO_ASN1_OBJECT_PTR) (string, error) {
+	var buf [128]C.char
+	n := C.go_openssl_OBJ_obj2txt(&buf[0], C.int(len(buf)), obj, 1)
+	if n <= 0 || int(n) >= len(buf) {
+		return "", errors.New("openssl: invalid object identifier")
+	}
+	return C.GoStringN(&buf[0], n), nil
+}
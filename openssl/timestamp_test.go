@@ -0,0 +1,325 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// The ASN.1 structures of RFC 3161 and RFC 5652, with only
+// the fields these tests use.
+
+type tsTestRequest struct {
+	Version        int
+	MessageImprint asn1.RawValue
+	Policy         asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type tsTestInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint asn1.RawValue
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type tsTestAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type tsTestSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type tsTestSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo struct {
+		EContentType asn1.ObjectIdentifier
+		EContent     []byte `asn1:"explicit,tag:0"`
+	}
+	Certificates asn1.RawValue      `asn1:"optional"`
+	SignerInfos  []tsTestSignerInfo `asn1:"set"`
+}
+
+type tsTestContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type tsTestResponse struct {
+	Status struct {
+		Status int
+	}
+	Token asn1.RawValue `asn1:"optional"`
+}
+
+var (
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	tsTestPolicy            = asn1.ObjectIdentifier{1, 2, 3, 4, 1}
+)
+
+// tsTestAuthority is a TSA issued by the root of a verifyTestChain.
+type tsTestAuthority struct {
+	chain *verifyTestChain
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+}
+
+func newTestTSA(t *testing.T) *tsTestAuthority {
+	t.Helper()
+	c := newVerifyTestChain(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// crypto/x509 doesn't mark the extended key usage critical,
+	// as RFC 3161 requires for TSA certificates.
+	eku := mustMarshalASN1(t, []asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 8}})
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:    big.NewInt(4),
+		Subject:         pkix.Name{CommonName: "TSA"},
+		NotBefore:       verifyTestTime.AddDate(-1, 0, 0),
+		NotAfter:        verifyTestTime.AddDate(1, 0, 0),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 37}, Critical: true, Value: eku}},
+	}, c.goRoot, key.Public(), c.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tsTestAuthority{c, cert, key}
+}
+
+// respond returns a granted response to req, timestamped at genTime,
+// after edit, if not nil, modifies the timestamp.
+func (tsa *tsTestAuthority) respond(t *testing.T, req []byte, genTime time.Time, edit func(*tsTestInfo)) []byte {
+	t.Helper()
+	var r tsTestRequest
+	if rest, err := asn1.Unmarshal(req, &r); err != nil || len(rest) != 0 {
+		t.Fatalf("invalid timestamp request: %v", err)
+	}
+	info := tsTestInfo{
+		Version:        1,
+		Policy:         tsTestPolicy,
+		MessageImprint: r.MessageImprint,
+		SerialNumber:   big.NewInt(42),
+		GenTime:        genTime,
+		Nonce:          r.Nonce,
+	}
+	if r.Policy != nil {
+		info.Policy = r.Policy
+	}
+	if edit != nil {
+		edit(&info)
+	}
+	content := mustMarshalASN1(t, info)
+	contentDigest := sha256.Sum256(content)
+	certHash := sha256.Sum256(tsa.cert.Raw)
+	attr := func(typ asn1.ObjectIdentifier, v interface{}) []byte {
+		return mustMarshalASN1(t, tsTestAttribute{typ, []asn1.RawValue{{FullBytes: mustMarshalASN1(t, v)}}})
+	}
+	attrs := [][]byte{
+		attr(oidContentType, oidTSTInfo),
+		attr(oidMessageDigest, contentDigest[:]),
+		attr(oidSigningCertificateV2, struct{ Certs []struct{ CertHash []byte } }{
+			[]struct{ CertHash []byte }{{certHash[:]}},
+		}),
+	}
+	// The signed attributes are a DER SET OF, sorted by encoding.
+	sort.Slice(attrs, func(i, j int) bool { return bytes.Compare(attrs[i], attrs[j]) < 0 })
+	signedAttrs := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(attrs, nil)}
+	digest := sha256.Sum256(mustMarshalASN1(t, signedAttrs))
+	sig, err := ecdsa.SignASN1(rand.Reader, tsa.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAttrs.Class, signedAttrs.Tag = asn1.ClassContextSpecific, 0
+	sd := tsTestSignedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		SignerInfos: []tsTestSignerInfo{{
+			Version: 1,
+			SID: asn1.RawValue{FullBytes: mustMarshalASN1(t, struct {
+				Issuer asn1.RawValue
+				Serial *big.Int
+			}{asn1.RawValue{FullBytes: tsa.cert.RawIssuer}, tsa.cert.SerialNumber})},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        signedAttrs,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          sig,
+		}},
+	}
+	sd.EncapContentInfo.EContentType = oidTSTInfo
+	sd.EncapContentInfo.EContent = content
+	if r.CertReq {
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: tsa.cert.Raw}
+	}
+	token := mustMarshalASN1(t, tsTestContentInfo{oidSignedData, asn1.RawValue{
+		Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshalASN1(t, sd),
+	}})
+	return mustMarshalASN1(t, tsTestResponse{Token: asn1.RawValue{FullBytes: token}})
+}
+
+func newTestTimestampRequest(t *testing.T, data []byte, opts *openssl.TimestampRequestOptions) []byte {
+	t.Helper()
+	digest := sha256.Sum256(data)
+	req, err := openssl.CreateTimestampRequest(digest[:], crypto.SHA256, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestCreateTimestampRequest(t *testing.T) {
+	der := newTestTimestampRequest(t, []byte("data"), &openssl.TimestampRequestOptions{
+		Policy:  "1.2.3.4.5",
+		Nonce:   bbig.Enc(big.NewInt(1234)),
+		CertReq: true,
+	})
+	var req tsTestRequest
+	if rest, err := asn1.Unmarshal(der, &req); err != nil || len(rest) != 0 {
+		t.Fatalf("invalid timestamp request: %v", err)
+	}
+	if req.Version != 1 {
+		t.Errorf("version = %d, want 1", req.Version)
+	}
+	if !req.Policy.Equal(asn1.ObjectIdentifier{1, 2, 3, 4, 5}) {
+		t.Errorf("policy = %v, want 1.2.3.4.5", req.Policy)
+	}
+	if req.Nonce == nil || req.Nonce.Cmp(big.NewInt(1234)) != 0 {
+		t.Errorf("nonce = %v, want 1234", req.Nonce)
+	}
+	if !req.CertReq {
+		t.Error("certificate not requested")
+	}
+	var imprint struct {
+		HashAlgorithm pkix.AlgorithmIdentifier
+		HashedMessage []byte
+	}
+	if _, err := asn1.Unmarshal(req.MessageImprint.FullBytes, &imprint); err != nil {
+		t.Fatal(err)
+	}
+	if digest := sha256.Sum256([]byte("data")); !imprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(imprint.HashedMessage, digest[:]) {
+		t.Errorf("message imprint = %v %x, want SHA-256 %x", imprint.HashAlgorithm.Algorithm, imprint.HashedMessage, digest)
+	}
+
+	var def tsTestRequest
+	if _, err := asn1.Unmarshal(newTestTimestampRequest(t, []byte("data"), nil), &def); err != nil {
+		t.Fatal(err)
+	}
+	if def.Nonce == nil || def.Policy != nil || def.CertReq {
+		t.Errorf("default request: nonce %v, policy %v, certReq %v, want a random nonce only", def.Nonce, def.Policy, def.CertReq)
+	}
+	if _, err := openssl.CreateTimestampRequest([]byte("short"), crypto.SHA256, nil); err == nil {
+		t.Error("created a request with a digest of the wrong length")
+	}
+	digest := sha256.Sum256([]byte("data"))
+	if _, err := openssl.CreateTimestampRequest(digest[:], crypto.SHA256, &openssl.TimestampRequestOptions{Policy: "not an OID"}); err == nil {
+		t.Error("created a request with an invalid policy")
+	}
+}
+
+func TestVerifyTimestampResponse(t *testing.T) {
+	tsa := newTestTSA(t)
+	genTime := verifyTestTime.Add(-time.Minute)
+	for _, certReq := range []bool{false, true} {
+		req := newTestTimestampRequest(t, []byte("data"), &openssl.TimestampRequestOptions{CertReq: certReq})
+		resp := tsa.respond(t, req, genTime, nil)
+		opts := openssl.VerifyOptions{Roots: []*openssl.Certificate{tsa.chain.root}, CurrentTime: verifyTestTime}
+		if !certReq {
+			if _, err := openssl.VerifyTimestampResponse(resp, req, opts); err == nil {
+				t.Error("verified a response without the TSA certificate")
+			}
+			cert, err := openssl.ParseCertificate(tsa.cert.Raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts.Intermediates = []*openssl.Certificate{cert}
+		}
+		ts, err := openssl.VerifyTimestampResponse(resp, req, opts)
+		if err != nil {
+			t.Fatalf("certReq %v: %v", certReq, err)
+		}
+		if !ts.Time.Equal(genTime) {
+			t.Errorf("time = %v, want %v", ts.Time, genTime)
+		}
+		if bbig.Dec(ts.SerialNumber).Cmp(big.NewInt(42)) != 0 {
+			t.Errorf("serial number = %v, want 42", bbig.Dec(ts.SerialNumber))
+		}
+		if ts.Policy != "1.2.3.4.1" {
+			t.Errorf("policy = %q, want 1.2.3.4.1", ts.Policy)
+		}
+		var token tsTestContentInfo
+		if _, err := asn1.Unmarshal(ts.Token, &token); err != nil || !token.ContentType.Equal(oidSignedData) {
+			t.Errorf("token isn't a SignedData: %v", err)
+		}
+	}
+}
+
+func TestVerifyTimestampResponseMismatch(t *testing.T) {
+	tsa := newTestTSA(t)
+	other := newTestTSA(t)
+	req := newTestTimestampRequest(t, []byte("data"), &openssl.TimestampRequestOptions{CertReq: true})
+	opts := openssl.VerifyOptions{Roots: []*openssl.Certificate{tsa.chain.root}, CurrentTime: verifyTestTime}
+	genTime := verifyTestTime.Add(-time.Minute)
+	for _, tt := range []struct {
+		name string
+		resp []byte
+	}{
+		{"Imprint", tsa.respond(t, newTestTimestampRequest(t, []byte("other"), &openssl.TimestampRequestOptions{CertReq: true}), genTime, nil)},
+		{"Nonce", tsa.respond(t, req, genTime, func(info *tsTestInfo) { info.Nonce = big.NewInt(1) })},
+		{"NoNonce", tsa.respond(t, req, genTime, func(info *tsTestInfo) { info.Nonce = nil })},
+		{"Untrusted", other.respond(t, req, genTime, nil)},
+		{"Rejected", mustMarshalASN1(t, tsTestResponse{Status: struct{ Status int }{2}})},
+	} {
+		if _, err := openssl.VerifyTimestampResponse(tt.resp, req, opts); err == nil {
+			t.Errorf("%s: response verified", tt.name)
+		}
+	}
+	policyReq := newTestTimestampRequest(t, []byte("data"), &openssl.TimestampRequestOptions{Policy: "1.2.3.4.2", CertReq: true})
+	resp := tsa.respond(t, policyReq, genTime, func(info *tsTestInfo) { info.Policy = tsTestPolicy })
+	if _, err := openssl.VerifyTimestampResponse(resp, policyReq, opts); err == nil {
+		t.Error("response verified with another policy")
+	}
+	opts.CurrentTime = verifyTestTime.AddDate(2, 0, 0)
+	if _, err := openssl.VerifyTimestampResponse(tsa.respond(t, req, genTime, nil), req, opts); err == nil {
+		t.Error("response verified with an expired TSA certificate")
+	}
+}
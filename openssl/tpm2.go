@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// TPM2Provider is the module name of the TPM 2.0 provider,
+// https://github.com/tpm2-software/tpm2-openssl, which generates and
+// uses keys resident in a TPM 2.0 on OpenSSL 3.
+const TPM2Provider = "tpm2"
+
+// LoadTPM2Provider loads the TPM 2.0 provider into the default library
+// context with LoadProvider. modulePath is the path of the provider
+// module; if empty, it is looked up in the OpenSSL modules directory as
+// TPM2Provider. The TPM is selected by the TPM2OPENSSL_TCTI environment
+// variable of the provider, such as "device:/dev/tpmrm0".
+//
+// Once loaded, TPM2LibraryContext generates keys in the TPM, which
+// never exports their private part:
+//
+//	tpm, err := openssl.TPM2LibraryContext()
+//	k, err := tpm.GenerateKey("RSA", map[string]interface{}{"bits": 2048})
+//	sig, err := k.Sign(msg, "SHA256")
+//	plaintext, err := k.Decrypt(ciphertext, map[string]interface{}{"pad-mode": "oaep"})
+//
+// MarshalPEMPrivateKey encodes such keys as a "TSS2 PRIVATE KEY", the
+// key blob wrapped by the TPM, which is only usable with the same TPM.
+// ParsePEMPrivateKey and LoadKey load it back, and LoadKey also loads
+// persistent keys by handle, such as "handle:0x81000001".
+//
+// LoadTPM2Provider is only supported on OpenSSL 3.
+func LoadTPM2Provider(modulePath string) error {
+	if modulePath == "" {
+		modulePath = TPM2Provider
+	}
+	return LoadProvider(modulePath)
+}
+
+// TPM2LibraryContext returns the default library context with the
+// property query "?provider=tpm2", which prefers the implementations
+// of the TPM 2.0 provider loaded by LoadTPM2Provider and falls back
+// to the other providers for the algorithms the TPM doesn't support.
+// The provider must be loaded under the name TPM2Provider, that is
+// with an empty modulePath or by the OpenSSL configuration.
+//
+// TPM2LibraryContext is only supported on OpenSSL 3.
+func TPM2LibraryContext() (*LibraryContext, error) {
+	l, err := DefaultLibraryContext()
+	if err != nil {
+		return nil, err
+	}
+	return l.WithProperties("?provider=" + TPM2Provider), nil
+}
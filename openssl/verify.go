@@ -0,0 +1,247 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// VerifyOptions configures the chain verification of Certificate.Verify.
+type VerifyOptions struct {
+	// Roots are the trust anchors. If nil, the default OpenSSL trust
+	// store is used, which can be configured with the SSL_CERT_FILE
+	// and SSL_CERT_DIR environment variables.
+	Roots []*Certificate
+
+	// Intermediates are untrusted certificates that may be
+	// used to build the chain from the leaf to a root.
+	Intermediates []*Certificate
+
+	// CurrentTime is the time at which the chain must be valid.
+	// If zero, the current time is used.
+	CurrentTime time.Time
+
+	// Purpose is the OpenSSL short name of the purpose the leaf
+	// certificate must be valid for, such as "sslserver", "sslclient",
+	// "smimesign" or "any", as accepted by "openssl verify -purpose".
+	// It checks the extended key usage of the chain, and the key
+	// usage and Netscape certificate type of the leaf.
+	// If empty, the purpose isn't checked.
+	Purpose string
+
+	// DNSName, if not empty, is the host name the leaf certificate must
+	// be valid for. Wildcards match whole labels only.
+	DNSName string
+
+	// IPAddress, if not empty, is the textual IPv4 or IPv6 address
+	// the leaf certificate must be valid for.
+	IPAddress string
+
+	// Email, if not empty, is the email address
+	// the leaf certificate must be valid for.
+	Email string
+
+	// AllowPartialChain accepts chains that end at a certificate of Roots
+	// that isn't self-signed, such as an intermediate trusted on its own.
+	AllowPartialChain bool
+
+	// CRLs are the certificate revocation lists used by RevocationCheck.
+	// They must be issued by the certificate authorities of the chain.
+	CRLs []*RevocationList
+
+	// RevocationCheck selects the certificates of the chain that are
+	// checked against CRLs. A checked certificate fails verification if
+	// CRLs doesn't contain a valid CRL from its issuer.
+	RevocationCheck RevocationCheck
+}
+
+// RevocationCheck is the revocation policy of Certificate.Verify.
+type RevocationCheck int
+
+const (
+	// RevocationCheckNone doesn't check revocation.
+	RevocationCheckNone RevocationCheck = iota
+	// RevocationCheckLeaf checks the leaf certificate only.
+	RevocationCheckLeaf
+	// RevocationCheckChain checks every certificate of the chain, so
+	// every certificate authority of the chain, including the root,
+	// must provide a CRL.
+	RevocationCheckChain
+)
+
+// VerifyError is returned by Certificate.Verify when no valid chain
+// from the certificate to a root can be built.
+type VerifyError struct {
+	// Code is the OpenSSL X509_V_ERR_* verification error code.
+	Code int
+	// Depth is the position in the chain of the certificate
+	// that failed verification, the leaf being at 0.
+	Depth int
+
+	reason string
+}
+
+func (e *VerifyError) Error() string {
+	return "openssl: certificate verification failed at depth " + strconv.Itoa(e.Depth) + ": " + e.reason
+}
+
+// Verify builds a chain from c to one of opts.Roots, possibly through
+// opts.Intermediates, and verifies it with X509_verify_cert, which
+// applies the OpenSSL policy checks such as the validity periods,
+// the basic constraints and the name constraints of the chain, and
+// the revocation status selected by opts.RevocationCheck.
+//
+// On success, Verify returns the chain, starting with c and ending
+// with the root. If no valid chain exists, the error is a *VerifyError.
+func (c *Certificate) Verify(opts VerifyOptions) ([]*Certificate, error) {
+	store, err := newX509Store(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_X509_STORE_free(store)
+	untrusted, err := newX509Stack(opts.Intermediates)
+	if err != nil {
+		return nil, err
+	}
+	defer untrusted.free()
+	ctx := C.go_openssl_X509_STORE_CTX_new()
+	if ctx == nil {
+		return nil, newOpenSSLError("X509_STORE_CTX_new")
+	}
+	defer C.go_openssl_X509_STORE_CTX_free(ctx)
+	defer runtime.KeepAlive(c)
+	if C.go_openssl_X509_STORE_CTX_init(ctx, store, c._x509, C.GO_STACK_OF_X509_PTR(untrusted.st)) != 1 {
+		return nil, newOpenSSLError("X509_STORE_CTX_init")
+	}
+	if err := setVerifyParams(C.go_openssl_X509_STORE_CTX_get0_param(ctx), &opts); err != nil {
+		return nil, err
+	}
+	switch C.go_openssl_X509_verify_cert(ctx) {
+	case 1:
+	case 0:
+		code := C.go_openssl_X509_STORE_CTX_get_error(ctx)
+		// X509_verify_cert may leave errors of the failed checks in the queue.
+		C.go_openssl_ERR_clear_error()
+		return nil, &VerifyError{
+			Code:   int(code),
+			Depth:  int(C.go_openssl_X509_STORE_CTX_get_error_depth(ctx)),
+			reason: C.GoString(C.go_openssl_X509_verify_cert_error_string(C.long(code))),
+		}
+	default:
+		return nil, newOpenSSLError("X509_verify_cert")
+	}
+	chain := x509Stack{C.GO_OPENSSL_STACK_PTR(C.go_openssl_X509_STORE_CTX_get1_chain(ctx))}
+	if chain.st == nil {
+		return nil, newOpenSSLError("X509_STORE_CTX_get1_chain")
+	}
+	defer chain.free()
+	return newCertificates(chain.certs())
+}
+
+// newX509Store returns a store holding opts.Roots, or the default
+// OpenSSL trust store if nil, and opts.CRLs. The caller must free it.
+func newX509Store(opts *VerifyOptions) (_ C.GO_X509_STORE_PTR, err error) {
+	store := C.go_openssl_X509_STORE_new()
+	if store == nil {
+		return nil, newOpenSSLError("X509_STORE_new")
+	}
+	defer func() {
+		if err != nil {
+			C.go_openssl_X509_STORE_free(store)
+		}
+	}()
+	if opts.Roots == nil {
+		if C.go_openssl_X509_STORE_set_default_paths(store) != 1 {
+			return nil, newOpenSSLError("X509_STORE_set_default_paths")
+		}
+	}
+	for _, root := range opts.Roots {
+		// X509_STORE_add_cert takes its own reference.
+		if root.withX509(func(x C.GO_X509_PTR) C.int {
+			return C.go_openssl_X509_STORE_add_cert(store, x)
+		}) != 1 {
+			return nil, newOpenSSLError("X509_STORE_add_cert")
+		}
+	}
+	for _, crl := range opts.CRLs {
+		// X509_STORE_add_crl takes its own reference.
+		if crl.withCRL(func(x C.GO_X509_CRL_PTR) C.int {
+			return C.go_openssl_X509_STORE_add_crl(store, x)
+		}) != 1 {
+			return nil, newOpenSSLError("X509_STORE_add_crl")
+		}
+	}
+	return store, nil
+}
+
+func setVerifyParams(param C.GO_X509_VERIFY_PARAM_PTR, opts *VerifyOptions) error {
+	if param == nil {
+		return newOpenSSLError("X509_STORE_CTX_get0_param")
+	}
+	if !opts.CurrentTime.IsZero() {
+		C.go_openssl_X509_VERIFY_PARAM_set_time(param, C.time_t(opts.CurrentTime.Unix()))
+	}
+	var flags C.ulong
+	if opts.AllowPartialChain {
+		flags |= C.GO_X509_V_FLAG_PARTIAL_CHAIN
+	}
+	switch opts.RevocationCheck {
+	case RevocationCheckNone:
+	case RevocationCheckLeaf:
+		flags |= C.GO_X509_V_FLAG_CRL_CHECK
+	case RevocationCheckChain:
+		flags |= C.GO_X509_V_FLAG_CRL_CHECK | C.GO_X509_V_FLAG_CRL_CHECK_ALL
+	default:
+		return errors.New("openssl: unknown revocation check")
+	}
+	if flags != 0 {
+		if C.go_openssl_X509_VERIFY_PARAM_set_flags(param, flags) != 1 {
+			return newOpenSSLError("X509_VERIFY_PARAM_set_flags")
+		}
+	}
+	if opts.Purpose != "" {
+		cname := C.CString(opts.Purpose)
+		idx := C.go_openssl_X509_PURPOSE_get_by_sname(cname)
+		C.free(unsafe.Pointer(cname))
+		if idx < 0 {
+			return errors.New("openssl: unknown certificate purpose " + opts.Purpose)
+		}
+		purpose := C.go_openssl_X509_PURPOSE_get_id(C.go_openssl_X509_PURPOSE_get0(idx))
+		if C.go_openssl_X509_VERIFY_PARAM_set_purpose(param, purpose) != 1 {
+			return newOpenSSLError("X509_VERIFY_PARAM_set_purpose")
+		}
+	}
+	if opts.DNSName != "" {
+		C.go_openssl_X509_VERIFY_PARAM_set_hostflags(param, C.GO_X509_CHECK_FLAG_NO_PARTIAL_WILDCARDS)
+		cname := C.CString(opts.DNSName)
+		defer C.free(unsafe.Pointer(cname))
+		if C.go_openssl_X509_VERIFY_PARAM_set1_host(param, cname, C.size_t(len(opts.DNSName))) != 1 {
+			return newOpenSSLError("X509_VERIFY_PARAM_set1_host")
+		}
+	}
+	if opts.IPAddress != "" {
+		cip := C.CString(opts.IPAddress)
+		defer C.free(unsafe.Pointer(cip))
+		if C.go_openssl_X509_VERIFY_PARAM_set1_ip_asc(param, cip) != 1 {
+			return newOpenSSLError("X509_VERIFY_PARAM_set1_ip_asc")
+		}
+	}
+	if opts.Email != "" {
+		cemail := C.CString(opts.Email)
+		defer C.free(unsafe.Pointer(cemail))
+		if C.go_openssl_X509_VERIFY_PARAM_set1_email(param, cemail, C.size_t(len(opts.Email))) != 1 {
+			return newOpenSSLError("X509_VERIFY_PARAM_set1_email")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+)
+
+var verifyTestTime = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+type verifyTestChain struct {
+	root, intermediate, leaf *openssl.Certificate
+
+	// The crypto/x509 certificates and keys of
+	// the certificate authorities, to issue CRLs.
+	goRoot, goIntermediate *x509.Certificate
+	rootKey, intKey        *ecdsa.PrivateKey
+}
+
+func newVerifyTestChain(t *testing.T) *verifyTestChain {
+	t.Helper()
+	newKey := func() *ecdsa.PrivateKey {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	create := func(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) *x509.Certificate {
+		if parent == nil {
+			parent = tmpl
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	notBefore := verifyTestTime.AddDate(-1, 0, 0)
+	notAfter := verifyTestTime.AddDate(1, 0, 0)
+	rootKey, intKey, leafKey := newKey(), newKey(), newKey()
+	root := create(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Root"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, rootKey.Public(), rootKey)
+	intermediate := create(&x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Intermediate"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, intKey.Public(), rootKey)
+	leaf := create(&x509.Certificate{
+		SerialNumber:   big.NewInt(3),
+		Subject:        pkix.Name{CommonName: "Leaf"},
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:       []string{"www.example.com", "*.api.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("192.0.2.1")},
+		EmailAddresses: []string{"admin@example.com"},
+	}, intermediate, leafKey.Public(), intKey)
+	parse := func(c *x509.Certificate) *openssl.Certificate {
+		cert, err := openssl.ParseCertificate(c.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	return &verifyTestChain{parse(root), parse(intermediate), parse(leaf), root, intermediate, rootKey, intKey}
+}
+
+func TestCertificateVerify(t *testing.T) {
+	c := newVerifyTestChain(t)
+	chain, err := c.leaf.Verify(openssl.VerifyOptions{
+		Roots:         []*openssl.Certificate{c.root},
+		Intermediates: []*openssl.Certificate{c.intermediate},
+		CurrentTime:   verifyTestTime,
+		Purpose:       "sslserver",
+		DNSName:       "www.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*openssl.Certificate{c.leaf, c.intermediate, c.root}
+	if len(chain) != len(want) {
+		t.Fatalf("got chain of %d certificates, want %d", len(chain), len(want))
+	}
+	for i := range chain {
+		if !bytes.Equal(chain[i].Raw(), want[i].Raw()) {
+			t.Errorf("chain[%d] doesn't match", i)
+		}
+	}
+}
+
+func TestCertificateVerifyOptions(t *testing.T) {
+	c := newVerifyTestChain(t)
+	const (
+		errExpired          = 10 // X509_V_ERR_CERT_HAS_EXPIRED
+		errNotYetValid      = 9  // X509_V_ERR_CERT_NOT_YET_VALID
+		errIssuerNotFound   = 20 // X509_V_ERR_UNABLE_TO_GET_ISSUER_CERT_LOCALLY
+		errIssuerNotTrusted = 2  // X509_V_ERR_UNABLE_TO_GET_ISSUER_CERT
+		errInvalidPurpose   = 26 // X509_V_ERR_INVALID_PURPOSE
+		errHostnameMismatch = 62 // X509_V_ERR_HOSTNAME_MISMATCH
+		errEmailMismatch    = 63 // X509_V_ERR_EMAIL_MISMATCH
+		errIPMismatch       = 64 // X509_V_ERR_IP_ADDRESS_MISMATCH
+	)
+	roots := []*openssl.Certificate{c.root}
+	intermediates := []*openssl.Certificate{c.intermediate}
+	tests := []struct {
+		name  string
+		opts  openssl.VerifyOptions
+		code  int // 0 if the verification succeeds
+		depth int // OpenSSL checks the validity periods from the root down
+	}{
+		{"NoIntermediate", openssl.VerifyOptions{Roots: roots, CurrentTime: verifyTestTime}, errIssuerNotFound, 0},
+		{"Expired", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime.AddDate(2, 0, 0)}, errExpired, 2},
+		{"NotYetValid", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime.AddDate(-2, 0, 0)}, errNotYetValid, 2},
+		{"Wildcard", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, DNSName: "v1.api.example.com"}, 0, 0},
+		{"HostnameMismatch", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, DNSName: "example.com"}, errHostnameMismatch, 0},
+		{"IPAddress", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, IPAddress: "192.0.2.1"}, 0, 0},
+		{"IPAddressMismatch", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, IPAddress: "192.0.2.2"}, errIPMismatch, 0},
+		{"Email", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, Email: "admin@example.com"}, 0, 0},
+		{"EmailMismatch", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, Email: "user@example.com"}, errEmailMismatch, 0},
+		{"WrongPurpose", openssl.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: verifyTestTime, Purpose: "sslclient"}, errInvalidPurpose, 0},
+		{"IntermediateAsRoot", openssl.VerifyOptions{Roots: intermediates, CurrentTime: verifyTestTime}, errIssuerNotTrusted, 1},
+		{"PartialChain", openssl.VerifyOptions{Roots: intermediates, CurrentTime: verifyTestTime, AllowPartialChain: true}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.leaf.Verify(tt.opts)
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var verr *openssl.VerifyError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got error %v, want a *VerifyError", err)
+			}
+			if verr.Code != tt.code || verr.Depth != tt.depth {
+				t.Errorf("got code %d at depth %d (%v), want code %d at depth %d", verr.Code, verr.Depth, verr, tt.code, tt.depth)
+			}
+		})
+	}
+}
+
+func TestCertificateVerifyUnknownPurpose(t *testing.T) {
+	c := newVerifyTestChain(t)
+	_, err := c.leaf.Verify(openssl.VerifyOptions{Roots: []*openssl.Certificate{c.root}, Purpose: "nonexistent"})
+	if err == nil {
+		t.Fatal("unknown purpose accepted")
+	}
+	var verr *openssl.VerifyError
+	if errors.As(err, &verr) {
+		t.Errorf("got %v, want a configuration error", err)
+	}
+}
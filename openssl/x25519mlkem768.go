@@ -0,0 +1,204 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import "errors"
+
+// X25519MLKEM768 combines X25519 and ML-KEM-768 into a hybrid KEM,
+// as specified for TLS in draft-ietf-tls-ecdhe-mlkem.
+//
+// Encapsulation keys, ciphertexts and shared keys are the concatenation
+// of the ML-KEM-768 value followed by the X25519 value. The shared key
+// is secure as long as either of its components is, and is meant to be
+// used as input keying material, as the TLS 1.3 key schedule does.
+const (
+	// EncapsulationKeySizeX25519MLKEM768 is the size of an encapsulation key,
+	// the TLS key share of the client.
+	EncapsulationKeySizeX25519MLKEM768 = mlkem768EncapsulationKeySize + x25519KeySize
+	// CiphertextSizeX25519MLKEM768 is the size of a ciphertext,
+	// the TLS key share of the server.
+	CiphertextSizeX25519MLKEM768 = mlkem768CiphertextSize + x25519KeySize
+	// SharedKeySizeX25519MLKEM768 is the size of the shared key.
+	SharedKeySizeX25519MLKEM768 = SharedKeySizeMLKEM + x25519KeySize
+	// SeedSizeX25519MLKEM768 is the size of the seed returned by
+	// DecapsulationKeyX25519MLKEM768.Seed.
+	SeedSizeX25519MLKEM768 = SeedSizeMLKEM + x25519KeySize
+)
+
+const (
+	mlkem768EncapsulationKeySize = 1184
+	mlkem768CiphertextSize       = 1088
+	x25519KeySize                = 32
+)
+
+// DecapsulationKeyX25519MLKEM768 is an X25519MLKEM768 decapsulation (private) key.
+type DecapsulationKeyX25519MLKEM768 struct {
+	mlkem  *DecapsulationKeyMLKEM
+	x25519 *PKey
+}
+
+// EncapsulationKeyX25519MLKEM768 is an X25519MLKEM768 encapsulation (public) key.
+type EncapsulationKeyX25519MLKEM768 struct {
+	mlkem  *EncapsulationKeyMLKEM
+	x25519 *PKey
+}
+
+// GenerateKeyX25519MLKEM768 generates an X25519MLKEM768 decapsulation key.
+// It requires ML-KEM support, see GenerateKeyMLKEM.
+func GenerateKeyX25519MLKEM768() (*DecapsulationKeyX25519MLKEM768, error) {
+	dk, err := GenerateKeyMLKEM(MLKEM768)
+	if err != nil {
+		return nil, err
+	}
+	x, err := GenerateKey("X25519", nil)
+	if err != nil {
+		dk.Close()
+		return nil, err
+	}
+	return &DecapsulationKeyX25519MLKEM768{mlkem: dk, x25519: x}, nil
+}
+
+// NewDecapsulationKeyX25519MLKEM768 reconstructs a decapsulation key
+// from seed, the ML-KEM-768 seed followed by the X25519 private key,
+// as returned by Seed.
+func NewDecapsulationKeyX25519MLKEM768(seed []byte) (*DecapsulationKeyX25519MLKEM768, error) {
+	if len(seed) != SeedSizeX25519MLKEM768 {
+		return nil, errors.New("openssl: invalid X25519MLKEM768 seed size")
+	}
+	dk, err := NewDecapsulationKeyMLKEM(MLKEM768, seed[:SeedSizeMLKEM])
+	if err != nil {
+		return nil, err
+	}
+	x, err := NewPrivatePKey("X25519", seed[SeedSizeMLKEM:])
+	if err != nil {
+		dk.Close()
+		return nil, err
+	}
+	return &DecapsulationKeyX25519MLKEM768{mlkem: dk, x25519: x}, nil
+}
+
+// NewEncapsulationKeyX25519MLKEM768 parses an encapsulation key,
+// the ML-KEM-768 encapsulation key followed by the X25519 public key.
+func NewEncapsulationKeyX25519MLKEM768(b []byte) (*EncapsulationKeyX25519MLKEM768, error) {
+	if len(b) != EncapsulationKeySizeX25519MLKEM768 {
+		return nil, errors.New("openssl: invalid X25519MLKEM768 encapsulation key size")
+	}
+	ek, err := NewEncapsulationKeyMLKEM(MLKEM768, b[:mlkem768EncapsulationKeySize])
+	if err != nil {
+		return nil, err
+	}
+	x, err := NewPublicPKey("X25519", b[mlkem768EncapsulationKeySize:])
+	if err != nil {
+		ek.Close()
+		return nil, err
+	}
+	return &EncapsulationKeyX25519MLKEM768{mlkem: ek, x25519: x}, nil
+}
+
+// Seed returns the ML-KEM-768 seed followed by the X25519 private key.
+// It is the recommended storage format for decapsulation keys.
+func (dk *DecapsulationKeyX25519MLKEM768) Seed() ([]byte, error) {
+	seed, err := dk.mlkem.Seed()
+	if err != nil {
+		return nil, err
+	}
+	priv, err := dk.x25519.PrivateKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(seed, priv...), nil
+}
+
+// EncapsulationKey returns the encapsulation key corresponding to dk.
+func (dk *DecapsulationKeyX25519MLKEM768) EncapsulationKey() (*EncapsulationKeyX25519MLKEM768, error) {
+	ek, err := dk.mlkem.EncapsulationKey()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := dk.x25519.PublicKeyBytes()
+	if err != nil {
+		ek.Close()
+		return nil, err
+	}
+	x, err := NewPublicPKey("X25519", pub)
+	if err != nil {
+		ek.Close()
+		return nil, err
+	}
+	return &EncapsulationKeyX25519MLKEM768{mlkem: ek, x25519: x}, nil
+}
+
+// Decapsulate returns the shared key encapsulated in ciphertext.
+func (dk *DecapsulationKeyX25519MLKEM768) Decapsulate(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != CiphertextSizeX25519MLKEM768 {
+		return nil, errors.New("openssl: invalid X25519MLKEM768 ciphertext size")
+	}
+	mlkemKey, err := dk.mlkem.Decapsulate(ciphertext[:mlkem768CiphertextSize])
+	if err != nil {
+		return nil, err
+	}
+	peer, err := NewPublicPKey("X25519", ciphertext[mlkem768CiphertextSize:])
+	if err != nil {
+		return nil, err
+	}
+	defer peer.Close()
+	// OpenSSL rejects peer keys resulting in an all-zero shared secret.
+	x25519Key, err := dk.x25519.Derive(peer)
+	if err != nil {
+		return nil, err
+	}
+	return append(mlkemKey, x25519Key...), nil
+}
+
+// Bytes returns the encapsulation key, the ML-KEM-768 encapsulation
+// key followed by the X25519 public key.
+func (ek *EncapsulationKeyX25519MLKEM768) Bytes() ([]byte, error) {
+	b, err := ek.mlkem.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ek.x25519.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, pub...), nil
+}
+
+// Encapsulate generates a shared key and the ciphertext that
+// encapsulates it for the owner of the decapsulation key.
+func (ek *EncapsulationKeyX25519MLKEM768) Encapsulate() (ciphertext, sharedKey []byte, err error) {
+	ct, mlkemKey, err := ek.mlkem.Encapsulate()
+	if err != nil {
+		return nil, nil, err
+	}
+	eph, err := GenerateKey("X25519", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer eph.Close()
+	x25519Key, err := eph.Derive(ek.x25519)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := eph.PublicKeyBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(ct, pub...), append(mlkemKey, x25519Key...), nil
+}
+
+// Close frees the native keys of dk, see InitOptions.ExplicitClose.
+func (dk *DecapsulationKeyX25519MLKEM768) Close() error {
+	dk.mlkem.Close()
+	return dk.x25519.Close()
+}
+
+// Close frees the native keys of ek, see InitOptions.ExplicitClose.
+func (ek *EncapsulationKeyX25519MLKEM768) Close() error {
+	ek.mlkem.Close()
+	return ek.x25519.Close()
+}
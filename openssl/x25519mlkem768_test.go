@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestX25519MLKEM768(t *testing.T) {
+	if !supportsKeyType(MLKEM768) {
+		t.Skip("ML-KEM not supported")
+	}
+	dk, err := GenerateKeyX25519MLKEM768()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := dk.EncapsulationKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ek.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != EncapsulationKeySizeX25519MLKEM768 {
+		t.Fatalf("got encapsulation key size %d, want %d", len(b), EncapsulationKeySizeX25519MLKEM768)
+	}
+	ek, err = NewEncapsulationKeyX25519MLKEM768(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, key, err := ek.Encapsulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ct) != CiphertextSizeX25519MLKEM768 || len(key) != SharedKeySizeX25519MLKEM768 {
+		t.Fatalf("got ciphertext size %d and shared key size %d", len(ct), len(key))
+	}
+
+	seed, err := dk.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dk2, err := NewDecapsulationKeyX25519MLKEM768(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dk := range []*DecapsulationKeyX25519MLKEM768{dk, dk2} {
+		got, err := dk.Decapsulate(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, key) {
+			t.Errorf("got shared key %x, want %x", got, key)
+		}
+	}
+
+	if _, err := dk.Decapsulate(ct[1:]); err == nil {
+		t.Error("expected error for short ciphertext")
+	}
+	if _, err := NewEncapsulationKeyX25519MLKEM768(b[1:]); err == nil {
+		t.Error("expected error for short encapsulation key")
+	}
+}
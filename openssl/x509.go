@@ -0,0 +1,385 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// Certificate is an X.509 certificate parsed by OpenSSL.
+// Its public key is returned as a key of this package,
+// so certificates can be verified without leaving OpenSSL.
+type Certificate struct {
+	raw []byte
+	// _x509 MUST NOT be accessed directly. Instead, use the withX509 method.
+	_x509 C.GO_X509_PTR
+	closer
+}
+
+// ParseCertificate parses der, a single ASN.1 DER encoded certificate.
+//
+// ParseCertificate is not supported on OpenSSL 1.0.2.
+func ParseCertificate(der []byte) (*Certificate, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	x, err := parseX509(der)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := marshalX509(x)
+	if err != nil {
+		C.go_openssl_X509_free(x)
+		return nil, err
+	}
+	if len(raw) != len(der) {
+		C.go_openssl_X509_free(x)
+		return nil, errors.New("openssl: trailing data after certificate")
+	}
+	return newCertificate(x, raw), nil
+}
+
+// newCertificate returns a Certificate owning x,
+// whose DER encoding is raw.
+func newCertificate(x C.GO_X509_PTR, raw []byte) *Certificate {
+	c := &Certificate{raw: raw, _x509: x}
+	manage(c, (*Certificate).finalize)
+	return c
+}
+
+func (c *Certificate) finalize() {
+	C.go_openssl_X509_free(c._x509)
+}
+
+// Close frees the native resources of c, which can't be used anymore.
+// See InitOptions.ExplicitClose.
+func (c *Certificate) Close() error {
+	return closeObject(c, c.finalize)
+}
+
+func (c *Certificate) withX509(f func(C.GO_X509_PTR) C.int) C.int {
+	// Because of the finalizer, any time _x509 is passed to cgo, that call must
+	// be followed by a call to runtime.KeepAlive, to make sure c is not
+	// collected (and finalized) before the cgo call returns.
+	defer runtime.KeepAlive(c)
+	return f(c._x509)
+}
+
+// Raw returns the DER encoding of c. It must not be modified.
+func (c *Certificate) Raw() []byte {
+	return c.raw
+}
+
+// Version returns the X.509 version of c, such as 3.
+func (c *Certificate) Version() int {
+	var v C.long
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		v = C.go_openssl_X509_get_version(x)
+		return 1
+	})
+	return int(v) + 1
+}
+
+// SerialNumber returns the absolute value of the serial number of c.
+func (c *Certificate) SerialNumber() (BigInt, error) {
+	var serial BigInt
+	if c.withX509(func(x C.GO_X509_PTR) C.int {
+		bn := C.go_openssl_ASN1_INTEGER_to_BN(C.go_openssl_X509_get_serialNumber(x), nil)
+		if bn == nil {
+			return 0
+		}
+		defer C.go_openssl_BN_free(bn)
+		serial = bnToBig(bn)
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("ASN1_INTEGER_to_BN")
+	}
+	return serial, nil
+}
+
+// Subject returns the subject name of c in the RFC 2253 format,
+// such as "CN=example.com,O=Example", with UTF-8 characters unescaped.
+func (c *Certificate) Subject() (string, error) {
+	var name string
+	var err error
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		name, err = x509NameString(C.go_openssl_X509_get_subject_name(x))
+		return 1
+	})
+	return name, err
+}
+
+// Issuer returns the issuer name of c in the format described in Subject.
+func (c *Certificate) Issuer() (string, error) {
+	var name string
+	var err error
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		name, err = x509NameString(C.go_openssl_X509_get_issuer_name(x))
+		return 1
+	})
+	return name, err
+}
+
+// x509NameString formats name as described in Certificate.Subject.
+func x509NameString(name C.GO_X509_NAME_PTR) (string, error) {
+	bio, err := newMemBIO(nil)
+	if err != nil {
+		return "", err
+	}
+	defer C.go_openssl_BIO_free(bio)
+	if C.go_openssl_X509_NAME_print_ex(bio, name, 0, C.GO_XN_FLAG_RFC2253&^C.GO_ASN1_STRFLGS_ESC_MSB) < 0 {
+		return "", newOpenSSLError("X509_NAME_print_ex")
+	}
+	return string(memBIOBytes(bio)), nil
+}
+
+// NotBefore returns the start of the validity period of c.
+func (c *Certificate) NotBefore() (time.Time, error) {
+	return c.time(func(x C.GO_X509_PTR) C.GO_ASN1_STRING_PTR {
+		return C.go_openssl_X509_get0_notBefore(x)
+	})
+}
+
+// NotAfter returns the end of the validity period of c.
+func (c *Certificate) NotAfter() (time.Time, error) {
+	return c.time(func(x C.GO_X509_PTR) C.GO_ASN1_STRING_PTR {
+		return C.go_openssl_X509_get0_notAfter(x)
+	})
+}
+
+func (c *Certificate) time(get func(C.GO_X509_PTR) C.GO_ASN1_STRING_PTR) (time.Time, error) {
+	var t time.Time
+	var err error
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		t, err = asn1TimeToTime(get(x))
+		return 1
+	})
+	return t, err
+}
+
+// asn1TimeToTime converts the ASN1_TIME t.
+func asn1TimeToTime(t C.GO_ASN1_STRING_PTR) (time.Time, error) {
+	typ := C.go_openssl_ASN1_STRING_type(t)
+	s := C.GoStringN((*C.char)(unsafe.Pointer(C.go_openssl_ASN1_STRING_get0_data(t))), C.go_openssl_ASN1_STRING_length(t))
+	return parseASN1Time(s, typ)
+}
+
+// parseASN1Time parses the UTCTime or GeneralizedTime s
+// in the DER form required by RFC 5280.
+func parseASN1Time(s string, typ C.int) (time.Time, error) {
+	switch typ {
+	case C.GO_V_ASN1_UTCTIME:
+		t, err := time.Parse("060102150405Z0700", s)
+		if err != nil || !isASN1TimeUTC(s) {
+			return time.Time{}, errors.New("openssl: invalid UTCTime " + strconv.Quote(s))
+		}
+		// RFC 5280, Section 4.1.2.5.1: years 50 to 99 are 1950 to 1999.
+		if t.Year() >= 2050 {
+			t = t.AddDate(-100, 0, 0)
+		}
+		return t, nil
+	case C.GO_V_ASN1_GENERALIZEDTIME:
+		t, err := time.Parse("20060102150405Z0700", s)
+		if err != nil || !isASN1TimeUTC(s) {
+			return time.Time{}, errors.New("openssl: invalid GeneralizedTime " + strconv.Quote(s))
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("openssl: unsupported ASN.1 time type")
+}
+
+func isASN1TimeUTC(s string) bool {
+	return len(s) > 0 && s[len(s)-1] == 'Z'
+}
+
+// SignatureAlgorithm returns the OpenSSL long name of the signature
+// algorithm of c, such as "sha256WithRSAEncryption" or "ecdsa-with-SHA256".
+func (c *Certificate) SignatureAlgorithm() string {
+	var name string
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		if ln := C.go_openssl_OBJ_nid2ln(C.go_openssl_X509_get_signature_nid(x)); ln != nil {
+			name = C.GoString(ln)
+		}
+		return 1
+	})
+	return name
+}
+
+// PublicKey returns the subject public key of c as described in
+// ParsePKIXPublicKey, that is, a *PublicKeyRSA, a *PublicKeyECDSA
+// or, on OpenSSL 3, a *PKey.
+func (c *Certificate) PublicKey() (interface{}, error) {
+	var pkey C.GO_EVP_PKEY_PTR
+	if c.withX509(func(x C.GO_X509_PTR) C.int {
+		pkey = C.go_openssl_X509_get_pubkey(x)
+		if pkey == nil {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("X509_get_pubkey")
+	}
+	return publicKeyFromPKey(pkey)
+}
+
+// CheckSignatureFrom verifies that parent issued c. The issuer
+// name of c must match the subject name of parent, the key
+// identifiers and key usage of parent must allow it to sign c,
+// and the signature of c must verify with the public key of parent.
+func (c *Certificate) CheckSignatureFrom(parent *Certificate) error {
+	defer runtime.KeepAlive(parent)
+	var ret C.int
+	c.withX509(func(x C.GO_X509_PTR) C.int {
+		ret = C.go_openssl_X509_check_issued(parent._x509, x)
+		return 1
+	})
+	if ret != C.GO_X509_V_OK {
+		return errors.New("openssl: certificate not issued by parent: " + C.GoString(C.go_openssl_X509_verify_cert_error_string(C.long(ret))))
+	}
+	pkey := C.go_openssl_X509_get_pubkey(parent._x509)
+	if pkey == nil {
+		return newOpenSSLError("X509_get_pubkey")
+	}
+	defer C.go_openssl_EVP_PKEY_free(pkey)
+	if c.withX509(func(x C.GO_X509_PTR) C.int {
+		return C.go_openssl_X509_verify(x, pkey)
+	}) != 1 {
+		return newOpenSSLError("X509_verify")
+	}
+	return nil
+}
+
+// newCertificates returns Certificates referencing xs,
+// which remain owned by the caller.
+func newCertificates(xs []C.GO_X509_PTR) ([]*Certificate, error) {
+	certs := make([]*Certificate, 0, len(xs))
+	for _, x := range xs {
+		raw, err := marshalX509(x)
+		if err != nil {
+			return nil, err
+		}
+		if C.go_openssl_X509_up_ref(x) != 1 {
+			return nil, newOpenSSLError("X509_up_ref")
+		}
+		certs = append(certs, newCertificate(x, raw))
+	}
+	return certs, nil
+}
+
+// parseX509 decodes the DER encoded certificate der.
+// The caller must free the returned certificate.
+func parseX509(der []byte) (C.GO_X509_PTR, error) {
+	if len(der) == 0 {
+		return nil, errors.New("openssl: empty certificate")
+	}
+	x := C.go_openssl_d2i_X509_buf(base(der), C.long(len(der)))
+	if x == nil {
+		return nil, newOpenSSLError("d2i_X509")
+	}
+	return x, nil
+}
+
+// marshalX509 returns the DER encoding of x.
+func marshalX509(x C.GO_X509_PTR) ([]byte, error) {
+	n := C.go_openssl_i2d_X509_buf(x, nil)
+	if n <= 0 {
+		return nil, newOpenSSLError("i2d_X509")
+	}
+	der := make([]byte, n)
+	if C.go_openssl_i2d_X509_buf(x, base(der)) != n {
+		return nil, newOpenSSLError("i2d_X509")
+	}
+	return der, nil
+}
+
+// x509Stack is a STACK_OF(X509) owning its certificates.
+type x509Stack struct {
+	st C.GO_OPENSSL_STACK_PTR
+}
+
+// newX509Stack returns a stack holding references to certs.
+// The stack is nil if certs is empty.
+func newX509Stack(certs []*Certificate) (x509Stack, error) {
+	var s x509Stack
+	if len(certs) == 0 {
+		return s, nil
+	}
+	if s.st = C.go_openssl_OPENSSL_sk_new_null(); s.st == nil {
+		return s, newOpenSSLError("OPENSSL_sk_new_null")
+	}
+	for _, c := range certs {
+		if c.withX509(func(x C.GO_X509_PTR) C.int {
+			if C.go_openssl_X509_up_ref(x) != 1 {
+				return 0
+			}
+			if C.go_openssl_OPENSSL_sk_push(s.st, unsafe.Pointer(x)) <= 0 {
+				C.go_openssl_X509_free(x)
+				return 0
+			}
+			return 1
+		}) != 1 {
+			s.free()
+			return x509Stack{}, newOpenSSLError("OPENSSL_sk_push")
+		}
+	}
+	return s, nil
+}
+
+// newX509StackFromDER returns a stack of the DER encoded certificates ders.
+// The stack is nil if ders is empty.
+func newX509StackFromDER(ders [][]byte) (x509Stack, error) {
+	var s x509Stack
+	if len(ders) == 0 {
+		return s, nil
+	}
+	if s.st = C.go_openssl_OPENSSL_sk_new_null(); s.st == nil {
+		return s, newOpenSSLError("OPENSSL_sk_new_null")
+	}
+	for _, der := range ders {
+		x, err := parseX509(der)
+		if err != nil {
+			s.free()
+			return x509Stack{}, err
+		}
+		if C.go_openssl_OPENSSL_sk_push(s.st, unsafe.Pointer(x)) <= 0 {
+			C.go_openssl_X509_free(x)
+			s.free()
+			return x509Stack{}, newOpenSSLError("OPENSSL_sk_push")
+		}
+	}
+	return s, nil
+}
+
+// certs returns the certificates of s, which are owned by s.
+func (s x509Stack) certs() []C.GO_X509_PTR {
+	if s.st == nil {
+		return nil
+	}
+	n := int(C.go_openssl_OPENSSL_sk_num(s.st))
+	certs := make([]C.GO_X509_PTR, 0, n)
+	for i := 0; i < n; i++ {
+		certs = append(certs, C.GO_X509_PTR(C.go_openssl_OPENSSL_sk_value(s.st, C.int(i))))
+	}
+	return certs
+}
+
+// free frees s and its certificates.
+func (s x509Stack) free() {
+	if s.st == nil {
+		return
+	}
+	for _, x := range s.certs() {
+		C.go_openssl_X509_free(x)
+	}
+	C.go_openssl_OPENSSL_sk_free(s.st)
+}
@@ -0,0 +1,190 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+// testCertificateChain returns a self-signed ECDSA CA certificate
+// and an RSA leaf certificate issued by it.
+func testCertificateChain(t *testing.T) (ca, leaf *x509.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA", Organization: []string{"Acme Ünïcode"}},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: new(big.Int).Lsh(big.NewInt(0x1234567), 100),
+		Subject:      pkix.Name{CommonName: "leaf.example.com"},
+		NotBefore:    notBefore,
+		// GeneralizedTime is used from 2050 on.
+		NotAfter: time.Date(2051, 6, 7, 8, 9, 10, 0, time.UTC),
+		KeyUsage: x509.KeyUsageDigitalSignature,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca, err = x509.ParseCertificate(caDER); err != nil {
+		t.Fatal(err)
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf, err = x509.ParseCertificate(leafDER); err != nil {
+		t.Fatal(err)
+	}
+	return ca, leaf
+}
+
+func TestCertificate(t *testing.T) {
+	ca, leaf := testCertificateChain(t)
+	for _, tt := range []struct {
+		name    string
+		cert    *x509.Certificate
+		sigAlg  string
+		keyType string
+	}{
+		{"CA", ca, "ecdsa-with-SHA256", "*PublicKeyECDSA"},
+		{"Leaf", leaf, "ecdsa-with-SHA256", "*PublicKeyRSA"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := openssl.ParseCertificate(tt.cert.Raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.Raw(), tt.cert.Raw) {
+				t.Error("Raw doesn't match the certificate")
+			}
+			if got := c.Version(); got != tt.cert.Version {
+				t.Errorf("Version() = %d, want %d", got, tt.cert.Version)
+			}
+			serial, err := c.SerialNumber()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bbig.Dec(serial); got.Cmp(tt.cert.SerialNumber) != 0 {
+				t.Errorf("SerialNumber() = %v, want %v", got, tt.cert.SerialNumber)
+			}
+			if got, err := c.Subject(); err != nil || got != tt.cert.Subject.String() {
+				t.Errorf("Subject() = %q, %v, want %q", got, err, tt.cert.Subject.String())
+			}
+			if got, err := c.Issuer(); err != nil || got != tt.cert.Issuer.String() {
+				t.Errorf("Issuer() = %q, %v, want %q", got, err, tt.cert.Issuer.String())
+			}
+			if got, err := c.NotBefore(); err != nil || !got.Equal(tt.cert.NotBefore) {
+				t.Errorf("NotBefore() = %v, %v, want %v", got, err, tt.cert.NotBefore)
+			}
+			if got, err := c.NotAfter(); err != nil || !got.Equal(tt.cert.NotAfter) {
+				t.Errorf("NotAfter() = %v, %v, want %v", got, err, tt.cert.NotAfter)
+			}
+			if got := c.SignatureAlgorithm(); got != tt.sigAlg {
+				t.Errorf("SignatureAlgorithm() = %q, want %q", got, tt.sigAlg)
+			}
+			pub, err := c.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := typeName(pub); got != tt.keyType {
+				t.Errorf("PublicKey() type = %s, want %s", got, tt.keyType)
+			}
+			if der, err := openssl.MarshalPKIXPublicKey(pub); err != nil || !bytes.Equal(der, tt.cert.RawSubjectPublicKeyInfo) {
+				t.Errorf("PublicKey() marshals to %x, %v, want %x", der, err, tt.cert.RawSubjectPublicKeyInfo)
+			}
+		})
+	}
+}
+
+func TestCertificateCheckSignatureFrom(t *testing.T) {
+	ca, leaf := testCertificateChain(t)
+	caCert, err := openssl.ParseCertificate(ca.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafCert, err := openssl.ParseCertificate(leaf.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leafCert.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("leaf not issued by CA: %v", err)
+	}
+	if err := caCert.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("CA not self-signed: %v", err)
+	}
+	if err := caCert.CheckSignatureFrom(leafCert); err == nil {
+		t.Error("CA issued by leaf")
+	}
+	// Corrupt the signature.
+	der := append([]byte(nil), leaf.Raw...)
+	der[len(der)-1] ^= 0xff
+	bad, err := openssl.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bad.CheckSignatureFrom(caCert); err == nil {
+		t.Error("corrupted signature verified")
+	}
+}
+
+func TestCertificateVerifyWithPublicKey(t *testing.T) {
+	ca, leaf := testCertificateChain(t)
+	caCert, err := openssl.ParseCertificate(ca.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := caCert.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := crypto.SHA256.New()
+	h.Write(leaf.RawTBSCertificate)
+	if !openssl.VerifyECDSA(pub.(*openssl.PublicKeyECDSA), h.Sum(nil), leaf.Signature) {
+		t.Error("leaf signature doesn't verify with the CA public key")
+	}
+}
+
+func TestParseCertificateInvalid(t *testing.T) {
+	ca, _ := testCertificateChain(t)
+	if _, err := openssl.ParseCertificate(nil); err == nil {
+		t.Error("empty certificate parsed")
+	}
+	if _, err := openssl.ParseCertificate(ca.Raw[:len(ca.Raw)-1]); err == nil {
+		t.Error("truncated certificate parsed")
+	}
+	if _, err := openssl.ParseCertificate(append(ca.Raw[:len(ca.Raw):len(ca.Raw)], 0)); err == nil {
+		t.Error("certificate with trailing data parsed")
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl
+
+// #include "goopenssl.h"
+import "C"
+import (
+	"crypto"
+	"errors"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// NameAttribute is an attribute of an X.509 distinguished name.
+type NameAttribute struct {
+	// Type is the OpenSSL short or long name of the attribute type, such
+	// as "CN", "O" or "emailAddress", or its OID in dotted form.
+	Type string
+	// Value is the UTF-8 value of the attribute.
+	Value string
+}
+
+// CertificateTemplate describes the certificate created by CreateCertificate.
+type CertificateTemplate struct {
+	// SerialNumber must be unique among the certificates of the issuer.
+	SerialNumber BigInt
+
+	// Subject is the subject name, ordered from the most significant
+	// attribute, for example []NameAttribute{{"O", "Example"}, {"CN", "Example CA"}}.
+	Subject []NameAttribute
+
+	// NotBefore and NotAfter bound the validity period of the certificate.
+	NotBefore, NotAfter time.Time
+
+	// IsCA marks the certificate as a certificate authority
+	// in its critical basic constraints.
+	IsCA bool
+
+	// KeyUsage lists the OpenSSL names of the key usages, such as
+	// "digitalSignature", "keyEncipherment", "keyCertSign" or "cRLSign".
+	// If not empty, the key usage extension is marked critical.
+	KeyUsage []string
+
+	// ExtKeyUsage lists the OpenSSL names or dotted OIDs of the extended
+	// key usages, such as "serverAuth", "clientAuth" or "codeSigning".
+	ExtKeyUsage []string
+
+	// DNSNames, EmailAddresses and IPAddresses
+	// are the subject alternative names.
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+
+	// Hash is the hash function of the signature. If zero, SHA-256 is
+	// used, except for keys that hash internally, such as Ed25519,
+	// which require zero.
+	Hash crypto.Hash
+}
+
+// CreateCertificate returns the DER encoding of a new X.509 v3
+// certificate based on template, certifying the public key pub.
+// The certificate is signed by priv and issued by parent, or is
+// self-signed if parent is nil, in which case priv must be the
+// private key of pub.
+//
+// pub can be any public key accepted by MarshalPKIXPublicKey, and
+// priv must be a *PrivateKeyRSA, a *PrivateKeyECDSA or a *PKey.
+// The certificate includes a subject key identifier and, if parent is
+// not nil, the authority key identifier of parent.
+//
+// CreateCertificate is not supported on OpenSSL 1.0.2.
+func CreateCertificate(template *CertificateTemplate, pub interface{}, parent *Certificate, priv interface{}) ([]byte, error) {
+	if vMajor == 1 && vMinor == 0 {
+		return nil, errUnsuportedVersion()
+	}
+	pubWithKey := publicKeyWithKey(pub)
+	if pubWithKey == nil {
+		return nil, errors.New("openssl: unsupported public key type")
+	}
+	privWithKey := privateKeyWithKey(priv)
+	if privWithKey == nil {
+		return nil, errors.New("openssl: unsupported private key type")
+	}
+	if len(template.SerialNumber) == 0 {
+		return nil, errors.New("openssl: missing certificate serial number")
+	}
+	x := C.go_openssl_X509_new()
+	if x == nil {
+		return nil, newOpenSSLError("X509_new")
+	}
+	defer C.go_openssl_X509_free(x)
+	if C.go_openssl_X509_set_version(x, 2) != 1 {
+		return nil, newOpenSSLError("X509_set_version")
+	}
+	if err := setX509Serial(x, template.SerialNumber); err != nil {
+		return nil, err
+	}
+	subject, err := newX509Name(template.Subject)
+	if err != nil {
+		return nil, err
+	}
+	defer C.go_openssl_X509_NAME_free(subject)
+	if C.go_openssl_X509_set_subject_name(x, subject) != 1 {
+		return nil, newOpenSSLError("X509_set_subject_name")
+	}
+	issuer := x
+	if parent != nil {
+		issuer = parent._x509
+		defer runtime.KeepAlive(parent)
+	}
+	if C.go_openssl_X509_set_issuer_name(x, C.go_openssl_X509_get_subject_name(issuer)) != 1 {
+		return nil, newOpenSSLError("X509_set_issuer_name")
+	}
+	if C.go_openssl_ASN1_TIME_set(C.go_openssl_X509_getm_notBefore(x), C.time_t(template.NotBefore.Unix())) == nil {
+		return nil, newOpenSSLError("ASN1_TIME_set")
+	}
+	if C.go_openssl_ASN1_TIME_set(C.go_openssl_X509_getm_notAfter(x), C.time_t(template.NotAfter.Unix())) == nil {
+		return nil, newOpenSSLError("ASN1_TIME_set")
+	}
+	if pubWithKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_X509_set_pubkey(x, pkey)
+	}) != 1 {
+		return nil, newOpenSSLError("X509_set_pubkey")
+	}
+	exts, err := certificateExtensions(template, parent != nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range exts {
+		ext, err := newX509Extension(issuer, x, nil, e)
+		if err != nil {
+			return nil, err
+		}
+		ok := C.go_openssl_X509_add_ext(x, ext, -1) == 1
+		C.go_openssl_X509_EXTENSION_free(ext)
+		if !ok {
+			return nil, newOpenSSLError("X509_add_ext")
+		}
+	}
+	if parent != nil && privWithKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		return C.go_openssl_X509_check_private_key(parent._x509, pkey)
+	}) != 1 {
+		C.go_openssl_ERR_clear_error()
+		return nil, errors.New("openssl: private key doesn't match the parent certificate")
+	}
+	var signErr error
+	if privWithKey(func(pkey C.GO_EVP_PKEY_PTR) C.int {
+		md, err := signatureMD(pkey, template.Hash)
+		if err != nil {
+			signErr = err
+			return 1
+		}
+		if C.go_openssl_X509_sign(x, pkey, md) <= 0 {
+			return 0
+		}
+		return 1
+	}) != 1 {
+		return nil, newOpenSSLError("X509_sign")
+	}
+	if signErr != nil {
+		return nil, signErr
+	}
+	return marshalX509(x)
+}
+
+func setX509Serial(x C.GO_X509_PTR, serial BigInt) error {
+	bn := bigToBN(serial)
+	if bn == nil {
+		return newOpenSSLError("BN_lebin2bn")
+	}
+	defer C.go_openssl_BN_free(bn)
+	ai := C.go_openssl_BN_to_ASN1_INTEGER(bn, nil)
+	if ai == nil {
+		return newOpenSSLError("BN_to_ASN1_INTEGER")
+	}
+	defer C.go_openssl_ASN1_INTEGER_free(ai)
+	if C.go_openssl_X509_set_serialNumber(x, ai) != 1 {
+		return newOpenSSLError("X509_set_serialNumber")
+	}
+	return nil
+}
+
+// newX509Name returns the X509_NAME of attrs. The caller must free it.
+func newX509Name(attrs []NameAttribute) (C.GO_X509_NAME_PTR, error) {
+	name := C.go_openssl_X509_NAME_new()
+	if name == nil {
+		return nil, newOpenSSLError("X509_NAME_new")
+	}
+	for _, a := range attrs {
+		ctype := C.CString(a.Type)
+		ok := C.go_openssl_X509_NAME_add_entry_by_txt(name, ctype, C.GO_MBSTRING_UTF8, base([]byte(a.Value)), C.int(len(a.Value)), -1, 0) == 1
+		C.free(unsafe.Pointer(ctype))
+		if !ok {
+			C.go_openssl_X509_NAME_free(name)
+			return nil, newOpenSSLError("X509_NAME_add_entry_by_txt(" + a.Type + ")")
+		}
+	}
+	return name, nil
+}
+
+// x509ExtensionConf is an X.509 extension described by its value
+// in the OpenSSL x509v3_config format, such as "critical,CA:TRUE".
+type x509ExtensionConf struct {
+	nid   C.int
+	value string
+}
+
+// newX509Extension returns the extension e of the certificate subject
+// issued by issuer, or of the request req. The caller must free it.
+func newX509Extension(issuer, subject C.GO_X509_PTR, req C.GO_X509_REQ_PTR, e x509ExtensionConf) (C.GO_X509_EXTENSION_PTR, error) {
+	cvalue := C.CString(e.value)
+	defer C.free(unsafe.Pointer(cvalue))
+	ext := C.go_openssl_X509V3_EXT_nconf_nid_ctx(issuer, subject, req, e.nid, cvalue)
+	if ext == nil {
+		return nil, newOpenSSLError("X509V3_EXT_nconf_nid(" + e.value + ")")
+	}
+	return ext, nil
+}
+
+func certificateExtensions(template *CertificateTemplate, issued bool) ([]x509ExtensionConf, error) {
+	ca := "critical,CA:FALSE"
+	if template.IsCA {
+		ca = "critical,CA:TRUE"
+	}
+	exts := []x509ExtensionConf{{C.GO_NID_basic_constraints, ca}}
+	if len(template.KeyUsage) > 0 {
+		list, err := x509ConfList("", template.KeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, x509ExtensionConf{C.GO_NID_key_usage, "critical," + list})
+	}
+	if len(template.ExtKeyUsage) > 0 {
+		list, err := x509ConfList("", template.ExtKeyUsage)
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, x509ExtensionConf{C.GO_NID_ext_key_usage, list})
+	}
+	san, err := subjectAltNameConf(template.DNSNames, template.EmailAddresses, template.IPAddresses)
+	if err != nil {
+		return nil, err
+	}
+	if san != "" {
+		exts = append(exts, x509ExtensionConf{C.GO_NID_subject_alt_name, san})
+	}
+	exts = append(exts, x509ExtensionConf{C.GO_NID_subject_key_identifier, "hash"})
+	if issued {
+		exts = append(exts, x509ExtensionConf{C.GO_NID_authority_key_identifier, "keyid"})
+	}
+	return exts, nil
+}
+
+// subjectAltNameConf returns the subject alternative name extension
+// value of the given names, or "" if there are none.
+func subjectAltNameConf(dnsNames, emails []string, ips []net.IP) (string, error) {
+	var parts []string
+	for _, l := range []struct {
+		prefix string
+		values []string
+	}{{"DNS:", dnsNames}, {"email:", emails}} {
+		if len(l.values) == 0 {
+			continue
+		}
+		list, err := x509ConfList(l.prefix, l.values)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, list)
+	}
+	for _, ip := range ips {
+		if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+			return "", errors.New("openssl: invalid IP address in certificate")
+		}
+		parts = append(parts, "IP:"+ip.String())
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// x509ConfList joins values with commas, each prefixed with prefix.
+// The x509v3_config lists can't escape commas, so values containing
+// them are rejected.
+func x509ConfList(prefix string, values []string) (string, error) {
+	var b strings.Builder
+	for i, v := range values {
+		if v == "" || strings.ContainsAny(v, ",\x00") || strings.TrimSpace(v) != v {
+			return "", errors.New("openssl: invalid certificate extension value " + strconv.Quote(v))
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(prefix)
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
+
+// signatureMD returns the digest for signing with pkey using h,
+// as described in CertificateTemplate.Hash.
+func signatureMD(pkey C.GO_EVP_PKEY_PTR, h crypto.Hash) (C.GO_EVP_MD_PTR, error) {
+	switch C.go_openssl_EVP_PKEY_get_base_id(pkey) {
+	case C.GO_EVP_PKEY_ED25519, C.GO_EVP_PKEY_ED448:
+		if h != 0 {
+			return nil, errors.New("openssl: signature algorithm doesn't support a separate hash")
+		}
+		return nil, nil
+	}
+	if h == 0 {
+		h = crypto.SHA256
+	}
+	md := cryptoHashToMD(h)
+	if md == nil {
+		return nil, errors.New("openssl: unsupported signature hash")
+	}
+	return md, nil
+}
@@ -0,0 +1,244 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+//go:build linux || darwin || windows
+// +build linux darwin windows
+
+package openssl_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/microsoft/go-crypto-openssl/openssl"
+	"github.com/microsoft/go-crypto-openssl/openssl/bbig"
+)
+
+func newTestRSAKey(t *testing.T) *openssl.PrivateKeyRSA {
+	t.Helper()
+	N, E, D, P, Q, Dp, Dq, Qinv, err := openssl.GenerateKeyRSA(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyRSA(N, E, D, P, Q, Dp, Dq, Qinv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func newTestECDSAKey(t *testing.T) (*openssl.PrivateKeyECDSA, *openssl.PublicKeyECDSA) {
+	t.Helper()
+	X, Y, D, err := openssl.GenerateKeyECDSA("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivateKeyECDSA("P-256", X, Y, D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := openssl.NewPublicKeyECDSA("P-256", X, Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, pub
+}
+
+func TestCreateCertificateFromRequest(t *testing.T) {
+	notBefore := time.Now().Add(-time.Hour).Truncate(time.Second)
+	notAfter := notBefore.AddDate(1, 0, 0)
+
+	// Self-signed CA.
+	caPriv, caPub := newTestECDSAKey(t)
+	caDER, err := openssl.CreateCertificate(&openssl.CertificateTemplate{
+		SerialNumber: bbig.Enc(big.NewInt(1)),
+		Subject:      []openssl.NameAttribute{{Type: "O", Value: "Acme"}, {Type: "CN", Value: "Acme Root"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		IsCA:         true,
+		KeyUsage:     []string{"keyCertSign", "cRLSign"},
+	}, caPub, nil, caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.IsCA || ca.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign || len(ca.SubjectKeyId) == 0 {
+		t.Errorf("unexpected CA certificate: IsCA %v, KeyUsage %v, SubjectKeyId %x", ca.IsCA, ca.KeyUsage, ca.SubjectKeyId)
+	}
+	if err := ca.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("CA not self-signed: %v", err)
+	}
+
+	// Certificate signing request.
+	leafPriv := newTestRSAKey(t)
+	csrDER, err := openssl.CreateCertificateRequest(&openssl.CertificateRequestTemplate{
+		Subject:     []openssl.NameAttribute{{Type: "CN", Value: "www.example.com"}},
+		DNSNames:    []string{"www.example.com", "example.com"},
+		IPAddresses: []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+	}, leafPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goCSR, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := goCSR.CheckSignature(); err != nil {
+		t.Errorf("crypto/x509 rejects the request signature: %v", err)
+	}
+	if len(goCSR.DNSNames) != 2 || len(goCSR.IPAddresses) != 2 {
+		t.Errorf("got request names %v %v", goCSR.DNSNames, goCSR.IPAddresses)
+	}
+	csr, err := openssl.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+	if got, err := csr.Subject(); err != nil || got != "CN=www.example.com" {
+		t.Errorf("Subject() = %q, %v", got, err)
+	}
+	csrPub, err := csr.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Leaf issued by the CA for the requested key.
+	caCert, err := openssl.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &openssl.CertificateTemplate{
+		SerialNumber: bbig.Enc(big.NewInt(2)),
+		Subject:      []openssl.NameAttribute{{Type: "CN", Value: "www.example.com"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     []string{"digitalSignature", "keyEncipherment"},
+		ExtKeyUsage:  []string{"serverAuth"},
+		DNSNames:     goCSR.DNSNames,
+		IPAddresses:  goCSR.IPAddresses,
+	}
+	leafDER, err := openssl.CreateCertificate(leafTmpl, csrPub, caCert, caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Issuer.String() != ca.Subject.String() || leaf.IsCA {
+		t.Errorf("unexpected leaf certificate: Issuer %v, IsCA %v", leaf.Issuer, leaf.IsCA)
+	}
+	if string(leaf.AuthorityKeyId) != string(ca.SubjectKeyId) {
+		t.Errorf("AuthorityKeyId = %x, want %x", leaf.AuthorityKeyId, ca.SubjectKeyId)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "example.com"}); err != nil {
+		t.Errorf("crypto/x509 rejects the leaf: %v", err)
+	}
+	leafCert, err := openssl.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leafCert.Verify(openssl.VerifyOptions{
+		Roots:     []*openssl.Certificate{caCert},
+		Purpose:   "sslserver",
+		IPAddress: "2001:db8::1",
+	}); err != nil {
+		t.Errorf("OpenSSL rejects the leaf: %v", err)
+	}
+
+	// Signing with a key that doesn't match the parent fails.
+	otherPriv, _ := newTestECDSAKey(t)
+	if _, err := openssl.CreateCertificate(leafTmpl, csrPub, caCert, otherPriv); err == nil {
+		t.Error("certificate signed with the wrong issuer key")
+	}
+}
+
+func TestCreateCertificateEd25519(t *testing.T) {
+	if openssl.VersionNumber()>>28 != 3 {
+		t.Skip("PKey is only supported on OpenSSL 3")
+	}
+	if !openssl.SupportsEd25519() {
+		t.Skip("Ed25519 is not supported")
+	}
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := openssl.NewPrivatePKey("ED25519", edPriv.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &openssl.CertificateTemplate{
+		SerialNumber: bbig.Enc(big.NewInt(42)),
+		Subject:      []openssl.NameAttribute{{Type: "CN", Value: "Ed25519 Σ"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := openssl.CreateCertificate(tmpl, priv, nil, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.SignatureAlgorithm != x509.PureEd25519 || cert.Subject.CommonName != "Ed25519 Σ" {
+		t.Errorf("got signature algorithm %v and subject %v", cert.SignatureAlgorithm, cert.Subject)
+	}
+	if !edPub.Equal(cert.PublicKey) {
+		t.Error("certified public key doesn't match")
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Error(err)
+	}
+	tmpl.Hash = crypto.SHA256
+	if _, err := openssl.CreateCertificate(tmpl, priv, nil, priv); err == nil {
+		t.Error("Ed25519 certificate signed with a separate hash")
+	}
+}
+
+func TestCreateCertificateInvalid(t *testing.T) {
+	priv, pub := newTestECDSAKey(t)
+	tmpl := openssl.CertificateTemplate{
+		SerialNumber: bbig.Enc(big.NewInt(1)),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	tests := []struct {
+		name   string
+		modify func(*openssl.CertificateTemplate)
+	}{
+		{"NoSerial", func(c *openssl.CertificateTemplate) { c.SerialNumber = nil }},
+		{"CommaInDNSName", func(c *openssl.CertificateTemplate) { c.DNSNames = []string{"a.example.com,DNS:b.example.com"} }},
+		{"EmptyDNSName", func(c *openssl.CertificateTemplate) { c.DNSNames = []string{""} }},
+		{"UnknownAttribute", func(c *openssl.CertificateTemplate) {
+			c.Subject = []openssl.NameAttribute{{Type: "nonexistent", Value: "x"}}
+		}},
+		{"UnknownKeyUsage", func(c *openssl.CertificateTemplate) { c.KeyUsage = []string{"nonexistent"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tmpl
+			tt.modify(&c)
+			if _, err := openssl.CreateCertificate(&c, pub, nil, priv); err == nil {
+				t.Error("CreateCertificate succeeded")
+			}
+		})
+	}
+	if _, err := openssl.CreateCertificate(&tmpl, pub, nil, pub); err == nil {
+		t.Error("CreateCertificate succeeded with a public key as signer")
+	}
+}